@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"one-api/common/config"
+	"one-api/model"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Admin 是否执行 admin 子命令
+var Admin bool
+
+var (
+	adminServer string
+	adminToken  string
+	adminRun    adminRunner
+)
+
+// adminBackend 管理命令的执行方式，指定 --server 时调用运行中服务的接口，否则直接读写数据库
+type adminBackend interface {
+	ListChannels(params *model.SearchChannelsParams) (*model.DataResult[model.Channel], error)
+	AddChannel(channel *model.Channel) error
+	TestChannel(id int, testModel string) (float64, error)
+	SetChannelStatus(id int, status int) error
+	ListUsers(params *model.GenericParams) (*model.DataResult[model.User], error)
+	FindUser(username string) (*model.User, error)
+	AddUserQuota(id int, quota int, remark string) error
+	ResetPassword(username, password string) error
+}
+
+type adminRunner func(backend adminBackend) error
+
+var errNotSupportedByAPI = errors.New("this command requires direct database access, run it without --server")
+
+// adminCommands 子命令，注册参数后返回执行函数
+var adminCommands = map[string]func(fs *flag.FlagSet) adminRunner{
+	"channel list": func(fs *flag.FlagSet) adminRunner {
+		params := &model.SearchChannelsParams{}
+		fs.IntVar(&params.Type, "type", 0, "filter by channel type")
+		fs.StringVar(&params.Group, "group", "", "filter by group")
+		fs.StringVar(&params.Name, "name", "", "filter by name")
+		fs.IntVar(&params.Page, "page", 1, "page number")
+		fs.IntVar(&params.Size, "size", 50, "page size")
+		return func(backend adminBackend) error {
+			result, err := backend.ListChannels(params)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tTYPE\tSTATUS\tGROUP\tRESPONSE(ms)\tMODELS")
+			if result.Data != nil {
+				for _, channel := range *result.Data {
+					fmt.Fprintf(w, "%d\t%s\t%d\t%s\t%s\t%d\t%s\n", channel.Id, channel.Name, channel.Type, channelStatusName(channel.Status), channel.Group, channel.ResponseTime, channel.Models)
+				}
+			}
+			w.Flush()
+			fmt.Printf("total: %d\n", result.TotalCount)
+			return nil
+		}
+	},
+	"channel add": func(fs *flag.FlagSet) adminRunner {
+		channel := &model.Channel{}
+		baseURL := fs.String("base-url", "", "base url")
+		fs.IntVar(&channel.Type, "type", 1, "channel type")
+		fs.StringVar(&channel.Name, "name", "", "channel name")
+		fs.StringVar(&channel.Key, "key", "", "channel key")
+		fs.StringVar(&channel.Models, "models", "", "comma separated models")
+		fs.StringVar(&channel.Group, "group", "default", "comma separated groups")
+		fs.StringVar(&channel.TestModel, "test-model", "", "model used to test the channel")
+		return func(backend adminBackend) error {
+			if channel.Name == "" || channel.Key == "" || channel.Models == "" {
+				return errors.New("--name, --key and --models are required")
+			}
+			if *baseURL != "" {
+				channel.BaseURL = baseURL
+			}
+			if err := backend.AddChannel(channel); err != nil {
+				return err
+			}
+			fmt.Println("channel added")
+			return nil
+		}
+	},
+	"channel test": func(fs *flag.FlagSet) adminRunner {
+		id := fs.Int("id", 0, "channel id")
+		testModel := fs.String("model", "", "model to test, defaults to the channel's test model")
+		return func(backend adminBackend) error {
+			if *id == 0 {
+				return errors.New("--id is required")
+			}
+			seconds, err := backend.TestChannel(*id, *testModel)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("channel %d ok, %.2fs\n", *id, seconds)
+			return nil
+		}
+	},
+	"channel enable": func(fs *flag.FlagSet) adminRunner {
+		return channelStatusCommand(fs, config.ChannelStatusEnabled)
+	},
+	"channel disable": func(fs *flag.FlagSet) adminRunner {
+		return channelStatusCommand(fs, config.ChannelStatusManuallyDisabled)
+	},
+	"channel drain": func(fs *flag.FlagSet) adminRunner {
+		return channelStatusCommand(fs, config.ChannelStatusDraining)
+	},
+	"user list": func(fs *flag.FlagSet) adminRunner {
+		params := &model.GenericParams{}
+		fs.StringVar(&params.Keyword, "keyword", "", "search username, display name or email")
+		fs.IntVar(&params.Page, "page", 1, "page number")
+		fs.IntVar(&params.Size, "size", 50, "page size")
+		return func(backend adminBackend) error {
+			result, err := backend.ListUsers(params)
+			if err != nil {
+				return err
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tUSERNAME\tROLE\tSTATUS\tGROUP\tQUOTA\tUSED")
+			if result.Data != nil {
+				for _, user := range *result.Data {
+					fmt.Fprintf(w, "%d\t%s\t%d\t%d\t%s\t%d\t%d\n", user.Id, user.Username, user.Role, user.Status, user.Group, user.Quota, user.UsedQuota)
+				}
+			}
+			w.Flush()
+			fmt.Printf("total: %d\n", result.TotalCount)
+			return nil
+		}
+	},
+	"user quota add": func(fs *flag.FlagSet) adminRunner {
+		id := fs.Int("id", 0, "user id")
+		username := fs.String("username", "", "username, used when --id is not set")
+		quota := fs.Int("quota", 0, "quota to add, negative to deduct")
+		remark := fs.String("remark", "", "remark in the quota log")
+		return func(backend adminBackend) error {
+			if *quota == 0 {
+				return errors.New("--quota is required")
+			}
+			userId, err := resolveUserId(backend, *id, *username)
+			if err != nil {
+				return err
+			}
+			if err := backend.AddUserQuota(userId, *quota, *remark); err != nil {
+				return err
+			}
+			fmt.Printf("user %d quota changed by %d\n", userId, *quota)
+			return nil
+		}
+	},
+	"user password": func(fs *flag.FlagSet) adminRunner {
+		username := fs.String("username", "root", "username")
+		password := fs.String("password", "", "new password")
+		return func(backend adminBackend) error {
+			if *password == "" {
+				return errors.New("--password is required")
+			}
+			if err := backend.ResetPassword(*username, *password); err != nil {
+				return err
+			}
+			fmt.Printf("password of %s reset\n", *username)
+			return nil
+		}
+	},
+}
+
+// parseAdmin 解析 one-api admin <资源> <操作> [参数]，例如 one-api admin user quota add --id 1 --quota 500000
+func parseAdmin(args []string) {
+	for words := min(3, len(args)); words >= 1; words-- {
+		build, ok := adminCommands[strings.Join(args[:words], " ")]
+		if !ok {
+			continue
+		}
+
+		fs := flag.NewFlagSet("admin "+strings.Join(args[:words], " "), flag.ExitOnError)
+		fs.StringVar(&adminServer, "server", os.Getenv("ONE_API_SERVER"), "server address, e.g. http://localhost:3000; access the database directly when empty")
+		fs.StringVar(&adminToken, "token", os.Getenv("ONE_API_ACCESS_TOKEN"), "admin access token, required with --server")
+		fs.StringVar(Config, "config", "config.yaml", "specify the config.yaml path")
+		adminRun = build(fs)
+		fs.Parse(args[words:])
+
+		Admin = true
+		return
+	}
+
+	adminHelp()
+	os.Exit(1)
+}
+
+func adminHelp() {
+	commands := make([]string, 0, len(adminCommands))
+	for command := range adminCommands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	fmt.Println("Usage: one-api admin <command> [--server <address> --token <access token>] [flags]")
+	fmt.Println("Commands:")
+	for _, command := range commands {
+		fmt.Println("  " + command)
+	}
+	fmt.Println("Run one-api admin <command> --help for flags of a command.")
+}
+
+// RunAdmin 执行管理命令后退出
+func RunAdmin() {
+	var backend adminBackend
+	if adminServer != "" {
+		if adminToken == "" {
+			fmt.Fprintln(os.Stderr, "--token is required with --server")
+			os.Exit(1)
+		}
+		backend = newAPIBackend(adminServer, adminToken)
+	} else {
+		backend = newDBBackend()
+	}
+
+	if err := adminRun(backend); err != nil {
+		fmt.Fprintln(os.Stderr, "error: "+err.Error())
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func channelStatusCommand(fs *flag.FlagSet, status int) adminRunner {
+	id := fs.Int("id", 0, "channel id")
+	return func(backend adminBackend) error {
+		if *id == 0 {
+			return errors.New("--id is required")
+		}
+		if err := backend.SetChannelStatus(*id, status); err != nil {
+			return err
+		}
+		fmt.Printf("channel %d %s\n", *id, channelStatusName(status))
+		return nil
+	}
+}
+
+func channelStatusName(status int) string {
+	switch status {
+	case config.ChannelStatusEnabled:
+		return "enabled"
+	case config.ChannelStatusManuallyDisabled:
+		return "disabled"
+	case config.ChannelStatusAutoDisabled:
+		return "auto-disabled"
+	case config.ChannelStatusDraining:
+		return "draining"
+	}
+	return "unknown"
+}
+
+func resolveUserId(backend adminBackend, id int, username string) (int, error) {
+	if id != 0 {
+		return id, nil
+	}
+	if username == "" {
+		return 0, errors.New("--id or --username is required")
+	}
+	user, err := backend.FindUser(username)
+	if err != nil {
+		return 0, err
+	}
+	return user.Id, nil
+}
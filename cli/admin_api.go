@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"one-api/model"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiBackend 使用管理员 access token 调用运行中服务的管理接口
+type apiBackend struct {
+	server string
+	token  string
+	client *http.Client
+}
+
+type apiResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+	Time    float64         `json:"time"`
+}
+
+func newAPIBackend(server, token string) *apiBackend {
+	return &apiBackend{
+		server: strings.TrimSuffix(server, "/"),
+		token:  token,
+		client: &http.Client{Timeout: 5 * time.Minute},
+	}
+}
+
+func (b *apiBackend) do(method, path string, query url.Values, body any, data any) (*apiResponse, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	target := b.server + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+b.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	response := &apiResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil, fmt.Errorf("unexpected response from server, status %d", resp.StatusCode)
+	}
+	if !response.Success {
+		if response.Message == "" {
+			response.Message = fmt.Sprintf("request failed with status %d", resp.StatusCode)
+		}
+		return response, errors.New(response.Message)
+	}
+	if data != nil && len(response.Data) > 0 {
+		if err := json.Unmarshal(response.Data, data); err != nil {
+			return response, err
+		}
+	}
+	return response, nil
+}
+
+func (b *apiBackend) ListChannels(params *model.SearchChannelsParams) (*model.DataResult[model.Channel], error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(params.Page))
+	query.Set("size", strconv.Itoa(params.Size))
+	if params.Type != 0 {
+		query.Set("type", strconv.Itoa(params.Type))
+	}
+	if params.Group != "" {
+		query.Set("group", params.Group)
+	}
+	if params.Name != "" {
+		query.Set("name", params.Name)
+	}
+
+	result := &model.DataResult[model.Channel]{}
+	_, err := b.do(http.MethodGet, "/api/channel/", query, nil, result)
+	return result, err
+}
+
+func (b *apiBackend) AddChannel(channel *model.Channel) error {
+	_, err := b.do(http.MethodPost, "/api/channel/", nil, channel, nil)
+	return err
+}
+
+func (b *apiBackend) TestChannel(id int, testModel string) (float64, error) {
+	query := url.Values{}
+	if testModel != "" {
+		query.Set("model", testModel)
+	}
+	response, err := b.do(http.MethodGet, fmt.Sprintf("/api/channel/test/%d", id), query, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+	return response.Time, nil
+}
+
+func (b *apiBackend) SetChannelStatus(id int, status int) error {
+	_, err := b.do(http.MethodPut, "/api/channel/", nil, map[string]int{"id": id, "status": status}, nil)
+	return err
+}
+
+func (b *apiBackend) ListUsers(params *model.GenericParams) (*model.DataResult[model.User], error) {
+	query := url.Values{}
+	query.Set("page", strconv.Itoa(params.Page))
+	query.Set("size", strconv.Itoa(params.Size))
+	if params.Keyword != "" {
+		query.Set("keyword", params.Keyword)
+	}
+
+	result := &model.DataResult[model.User]{}
+	_, err := b.do(http.MethodGet, "/api/user/", query, nil, result)
+	return result, err
+}
+
+func (b *apiBackend) FindUser(username string) (*model.User, error) {
+	result, err := b.ListUsers(&model.GenericParams{
+		PaginationParams: model.PaginationParams{Page: 1, Size: 100},
+		Keyword:          username,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Data != nil {
+		for _, user := range *result.Data {
+			if user.Username == username {
+				return user, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("user %s not found", username)
+}
+
+func (b *apiBackend) AddUserQuota(id int, quota int, remark string) error {
+	body := map[string]any{"quota": quota, "remark": remark}
+	_, err := b.do(http.MethodPost, fmt.Sprintf("/api/user/quota/%d", id), nil, body, nil)
+	return err
+}
+
+func (b *apiBackend) ResetPassword(username, password string) error {
+	return errNotSupportedByAPI
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"one-api/common"
+	"one-api/common/utils"
+	"one-api/controller"
+	"one-api/model"
+	"time"
+)
+
+// dbBackend 直接读写数据库，服务不可用时用于恢复，启用 Redis 时会通知运行中的实例刷新缓存
+type dbBackend struct{}
+
+func newDBBackend() *dbBackend {
+	model.SetupDB()
+	model.InitOptionMap()
+	return &dbBackend{}
+}
+
+func (b *dbBackend) ListChannels(params *model.SearchChannelsParams) (*model.DataResult[model.Channel], error) {
+	return model.GetChannelsList(params)
+}
+
+func (b *dbBackend) AddChannel(channel *model.Channel) error {
+	if err := channel.ValidateProxy(); err != nil {
+		return err
+	}
+	channel.CreatedTime = utils.GetTimestamp()
+	return model.BatchInsertChannels([]model.Channel{*channel})
+}
+
+func (b *dbBackend) TestChannel(id int, testModel string) (float64, error) {
+	channel, err := model.GetChannelById(id)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if err := controller.TestChannelWithModel(channel, testModel); err != nil {
+		return 0, err
+	}
+	milliseconds := time.Since(start).Milliseconds()
+	channel.UpdateResponseTime(milliseconds)
+
+	return float64(milliseconds) / 1000.0, nil
+}
+
+func (b *dbBackend) SetChannelStatus(id int, status int) error {
+	if _, err := model.GetChannelById(id); err != nil {
+		return err
+	}
+	model.UpdateChannelStatusById(id, status)
+	return nil
+}
+
+func (b *dbBackend) ListUsers(params *model.GenericParams) (*model.DataResult[model.User], error) {
+	return model.GetUsersList(params, 0)
+}
+
+func (b *dbBackend) FindUser(username string) (*model.User, error) {
+	user := &model.User{}
+	if err := model.DB.Where("username = ?", username).First(user).Error; err != nil {
+		return nil, fmt.Errorf("user %s not found", username)
+	}
+	return user, nil
+}
+
+func (b *dbBackend) AddUserQuota(id int, quota int, remark string) error {
+	if _, err := model.GetUserById(id, false); err != nil {
+		return err
+	}
+	if err := model.ChangeUserQuota(id, quota, false); err != nil {
+		return err
+	}
+
+	content := fmt.Sprintf("管理员通过命令行增减用户额度 %s", common.LogQuota(quota))
+	if remark != "" {
+		content = fmt.Sprintf("%s, 备注: %s", content, remark)
+	}
+	model.RecordQuotaLog(id, model.LogTypeManage, quota, "", content)
+	return nil
+}
+
+func (b *dbBackend) ResetPassword(username, password string) error {
+	user, err := b.FindUser(username)
+	if err != nil {
+		return err
+	}
+	hashedPassword, err := common.Password2Hash(password)
+	if err != nil {
+		return err
+	}
+	return model.DB.Model(user).Update("password", hashedPassword).Error
+}
@@ -17,10 +17,18 @@ var (
 	logDir       = flag.String("log-dir", "", "specify the log directory")
 	Config       = flag.String("config", "config.yaml", "specify the config.yaml path")
 	export       = flag.Bool("export", false, "Exports prices to a JSON file.")
+	RotateKeys   = flag.Bool("rotate-channel-keys", false, "Re-encrypt all channel keys with the current master key and exit.")
 )
 
 func InitCli() {
-	flag.Parse()
+	switch {
+	case len(os.Args) > 1 && os.Args[1] == "migrate":
+		parseMigrate(os.Args[2:])
+	case len(os.Args) > 1 && os.Args[1] == "admin":
+		parseAdmin(os.Args[2:])
+	default:
+		flag.Parse()
+	}
 
 	if *printVersion {
 		fmt.Println(config.Version)
@@ -61,5 +69,7 @@ func help() {
 	fmt.Println("Copyright (C) 2024 MartialBE. All rights reserved.")
 	fmt.Println("Original copyright holder: JustSong")
 	fmt.Println("GitHub: https://github.com/MartialBE/one-hub")
-	fmt.Println("Usage: one-api [--port <port>] [--log-dir <log directory>] [--config <config.yaml path>] [--version] [--help]")
+	fmt.Println("Usage: one-api [--port <port>] [--log-dir <log directory>] [--config <config.yaml path>] [--rotate-channel-keys] [--version] [--help]")
+	fmt.Println("       one-api migrate --from <sqlite|mysql|postgres> --to <sqlite|mysql|postgres> [--from-dsn <dsn>] [--to-dsn <dsn>] [--batch-size <rows>]")
+	fmt.Println("       one-api admin <command> [--server <address> --token <access token>], run one-api admin for commands")
 }
@@ -0,0 +1,88 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"one-api/common/logger"
+	"one-api/model"
+	"os"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// Migrate 是否执行 migrate 子命令
+var Migrate bool
+
+var migrateFlags = flag.NewFlagSet("migrate", flag.ExitOnError)
+
+var (
+	migrateFrom      = migrateFlags.String("from", "", "source database type: sqlite, mysql or postgres")
+	migrateTo        = migrateFlags.String("to", "", "target database type: sqlite, mysql or postgres")
+	migrateFromDSN   = migrateFlags.String("from-dsn", "", "source dsn, defaults to sqlite_path or sql_dsn in config")
+	migrateToDSN     = migrateFlags.String("to-dsn", "", "target dsn, defaults to sqlite_path or sql_dsn in config")
+	migrateBatchSize = migrateFlags.Int("batch-size", 500, "rows per batch")
+)
+
+func init() {
+	migrateFlags.StringVar(Config, "config", "config.yaml", "specify the config.yaml path")
+}
+
+// parseMigrate 解析 one-api migrate --from sqlite --to postgres 的参数
+func parseMigrate(args []string) {
+	migrateFlags.Parse(args)
+	if *migrateFrom == "" || *migrateTo == "" {
+		fmt.Println("Usage: one-api migrate --from <sqlite|mysql|postgres> --to <sqlite|mysql|postgres> [--from-dsn <dsn>] [--to-dsn <dsn>] [--batch-size <rows>] [--config <config.yaml path>]")
+		os.Exit(1)
+	}
+	if *migrateFrom == *migrateTo && *migrateFromDSN == *migrateToDSN {
+		fmt.Println("source and target database are the same")
+		os.Exit(1)
+	}
+	Migrate = true
+}
+
+// MigrateDatabase 将源库的所有表复制到目标库后退出，目标库中的表必须为空
+// 渠道 key 读取时解密、写入时使用当前主密钥加密
+func MigrateDatabase() {
+	src, err := openMigrateDatabase(*migrateFrom, *migrateFromDSN)
+	if err != nil {
+		logger.FatalLog("failed to open source database: " + err.Error())
+	}
+	dst, err := openMigrateDatabase(*migrateTo, *migrateToDSN)
+	if err != nil {
+		logger.FatalLog("failed to open target database: " + err.Error())
+	}
+
+	logger.SysLog(fmt.Sprintf("migrating database from %s to %s", *migrateFrom, *migrateTo))
+	results, err := model.CopyDatabase(src, dst, *migrateBatchSize, func(table string, copied int64) {
+		logger.SysLog(fmt.Sprintf("%s: %d rows copied", table, copied))
+	})
+	for _, result := range results {
+		if result.Skipped {
+			logger.SysLog(fmt.Sprintf("%s: skipped, not found in source database", result.Table))
+			continue
+		}
+		logger.SysLog(fmt.Sprintf("%s: source %d, copied %d, target %d", result.Table, result.Source, result.Copied, result.Target))
+	}
+	if err != nil {
+		logger.FatalLog("database migration failed: " + err.Error())
+	}
+
+	logger.SysLog("database migration completed and verified")
+	os.Exit(0)
+}
+
+func openMigrateDatabase(driver, dsn string) (*gorm.DB, error) {
+	if dsn == "" {
+		if driver == "sqlite" {
+			dsn = viper.GetString("sqlite_path")
+		} else {
+			dsn = viper.GetString("sql_dsn")
+		}
+	}
+	if dsn == "" {
+		return nil, fmt.Errorf("dsn of %s is not set", driver)
+	}
+	return model.OpenDatabase(driver, dsn)
+}
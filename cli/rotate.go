@@ -0,0 +1,20 @@
+package cli
+
+import (
+	"fmt"
+	"one-api/common/logger"
+	"one-api/model"
+	"os"
+)
+
+// RotateChannelKeys 使用当前主密钥重新加密所有渠道 key 后退出
+func RotateChannelKeys() {
+	rotated, err := model.RotateChannelKeys()
+	if err != nil {
+		logger.SysError(fmt.Sprintf("Failed to rotate channel keys after %d channels: %s", rotated, err.Error()))
+		os.Exit(1)
+	}
+
+	logger.SysLog(fmt.Sprintf("Rotated %d channel keys", rotated))
+	os.Exit(0)
+}
@@ -0,0 +1,293 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrUnknownFormat 不支持的格式或头部信息不完整
+var ErrUnknownFormat = errors.New("unknown audio format")
+
+// Duration 从容器头部读取音频时长（秒），不解码音频数据
+// 支持 WAV、MP3、FLAC、OGG（Vorbis、Opus）和 MP4/M4A
+func Duration(r io.ReadSeeker, size int64) (float64, error) {
+	head := make([]byte, 12)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return 0, ErrUnknownFormat
+	}
+
+	switch {
+	case bytes.Equal(head[0:4], []byte("RIFF")) && bytes.Equal(head[8:12], []byte("WAVE")):
+		return wavDuration(r, size)
+	case bytes.Equal(head[0:4], []byte("fLaC")):
+		return flacDuration(r)
+	case bytes.Equal(head[0:4], []byte("OggS")):
+		return oggDuration(r, size)
+	case bytes.Equal(head[4:8], []byte("ftyp")):
+		return mp4Duration(r, size)
+	case bytes.Equal(head[0:3], []byte("ID3")) || (head[0] == 0xFF && head[1]&0xE0 == 0xE0):
+		return mp3Duration(r, size)
+	}
+	return 0, ErrUnknownFormat
+}
+
+func readAt(r io.ReadSeeker, offset int64, buf []byte) error {
+	if _, err := r.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(r, buf)
+	return err
+}
+
+func wavDuration(r io.ReadSeeker, size int64) (float64, error) {
+	var byteRate uint32
+	offset := int64(12)
+	header := make([]byte, 8)
+	for offset+8 <= size {
+		if err := readAt(r, offset, header); err != nil {
+			return 0, ErrUnknownFormat
+		}
+		chunkSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+		switch string(header[0:4]) {
+		case "fmt ":
+			format := make([]byte, 12)
+			if err := readAt(r, offset+8, format); err != nil {
+				return 0, ErrUnknownFormat
+			}
+			byteRate = binary.LittleEndian.Uint32(format[8:12])
+		case "data":
+			if byteRate == 0 {
+				return 0, ErrUnknownFormat
+			}
+			// 流式写入的 WAV 数据块大小可能未填写
+			if chunkSize == 0 || chunkSize == 0xFFFFFFFF || offset+8+chunkSize > size {
+				chunkSize = size - offset - 8
+			}
+			return float64(chunkSize) / float64(byteRate), nil
+		}
+		offset += 8 + chunkSize + chunkSize%2
+	}
+	return 0, ErrUnknownFormat
+}
+
+func flacDuration(r io.ReadSeeker) (float64, error) {
+	// fLaC 之后第一个元数据块必须是 STREAMINFO
+	info := make([]byte, 4+34)
+	if err := readAt(r, 4, info); err != nil || info[0]&0x7F != 0 {
+		return 0, ErrUnknownFormat
+	}
+	b := info[4:]
+	sampleRate := uint64(b[10])<<12 | uint64(b[11])<<4 | uint64(b[12])>>4
+	totalSamples := uint64(b[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(b[14:18]))
+	if sampleRate == 0 || totalSamples == 0 {
+		return 0, ErrUnknownFormat
+	}
+	return float64(totalSamples) / float64(sampleRate), nil
+}
+
+func oggDuration(r io.ReadSeeker, size int64) (float64, error) {
+	// 第一个页只包含标识头
+	page := make([]byte, 27+19)
+	if err := readAt(r, 0, page[:27]); err != nil {
+		return 0, ErrUnknownFormat
+	}
+	segments := int(page[26])
+	if err := readAt(r, 27+int64(segments), page[27:27+19]); err != nil {
+		return 0, ErrUnknownFormat
+	}
+	packet := page[27 : 27+19]
+
+	var sampleRate, preSkip uint64
+	switch {
+	case bytes.HasPrefix(packet, []byte("\x01vorbis")):
+		sampleRate = uint64(binary.LittleEndian.Uint32(packet[12:16]))
+	case bytes.HasPrefix(packet, []byte("OpusHead")):
+		// Opus 的粒度位置固定以 48kHz 计
+		sampleRate = 48000
+		preSkip = uint64(binary.LittleEndian.Uint16(packet[10:12]))
+	default:
+		return 0, ErrUnknownFormat
+	}
+	if sampleRate == 0 {
+		return 0, ErrUnknownFormat
+	}
+
+	// 最后一个页的粒度位置即总采样数
+	tailSize := min(size, 64*1024)
+	tail := make([]byte, tailSize)
+	if err := readAt(r, size-tailSize, tail); err != nil {
+		return 0, ErrUnknownFormat
+	}
+	index := bytes.LastIndex(tail, []byte("OggS"))
+	if index < 0 || index+14 > len(tail) {
+		return 0, ErrUnknownFormat
+	}
+	granule := binary.LittleEndian.Uint64(tail[index+6 : index+14])
+	if granule <= preSkip {
+		return 0, ErrUnknownFormat
+	}
+	return float64(granule-preSkip) / float64(sampleRate), nil
+}
+
+func mp4Duration(r io.ReadSeeker, size int64) (float64, error) {
+	moovOffset, moovSize, err := findBox(r, 0, size, "moov")
+	if err != nil {
+		return 0, err
+	}
+	mvhdOffset, _, err := findBox(r, moovOffset, moovOffset+moovSize, "mvhd")
+	if err != nil {
+		return 0, err
+	}
+
+	mvhd := make([]byte, 32)
+	if err := readAt(r, mvhdOffset, mvhd); err != nil {
+		return 0, ErrUnknownFormat
+	}
+	var timescale uint32
+	var duration uint64
+	if mvhd[0] == 1 {
+		timescale = binary.BigEndian.Uint32(mvhd[20:24])
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	} else {
+		timescale = binary.BigEndian.Uint32(mvhd[12:16])
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	}
+	if timescale == 0 {
+		return 0, ErrUnknownFormat
+	}
+	return float64(duration) / float64(timescale), nil
+}
+
+// findBox 在 [start, end) 内查找指定类型的盒子，返回盒子内容的偏移和长度
+func findBox(r io.ReadSeeker, start, end int64, boxType string) (int64, int64, error) {
+	header := make([]byte, 16)
+	for offset := start; offset+8 <= end; {
+		if err := readAt(r, offset, header[:8]); err != nil {
+			return 0, 0, ErrUnknownFormat
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = end - offset
+		case 1:
+			if err := readAt(r, offset+8, header[8:16]); err != nil {
+				return 0, 0, ErrUnknownFormat
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize {
+			return 0, 0, ErrUnknownFormat
+		}
+		if string(header[4:8]) == boxType {
+			return offset + headerSize, boxSize - headerSize, nil
+		}
+		offset += boxSize
+	}
+	return 0, 0, ErrUnknownFormat
+}
+
+var (
+	mp3Bitrates = map[[2]int][]int{
+		{1, 1}: {0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{1, 2}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{1, 3}: {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+		{2, 1}: {0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{2, 2}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{2, 3}: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	}
+	mp3SampleRates = []int{44100, 48000, 32000}
+)
+
+func mp3Duration(r io.ReadSeeker, size int64) (float64, error) {
+	// 跳过 ID3v2 标签
+	audioStart := int64(0)
+	id3 := make([]byte, 10)
+	if err := readAt(r, 0, id3); err != nil {
+		return 0, ErrUnknownFormat
+	}
+	if bytes.Equal(id3[0:3], []byte("ID3")) {
+		audioStart = 10 + int64(id3[6]&0x7F)<<21 | int64(id3[7]&0x7F)<<14 | int64(id3[8]&0x7F)<<7 | int64(id3[9]&0x7F)
+		if id3[5]&0x10 != 0 {
+			audioStart += 10
+		}
+	}
+
+	buf := make([]byte, min(size-audioStart, 64*1024))
+	if len(buf) < 4 {
+		return 0, ErrUnknownFormat
+	}
+	if err := readAt(r, audioStart, buf); err != nil {
+		return 0, ErrUnknownFormat
+	}
+
+	for i := 0; i+4 <= len(buf); i++ {
+		if buf[i] != 0xFF || buf[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		versionBits := int(buf[i+1]>>3) & 3
+		layerBits := int(buf[i+1]>>1) & 3
+		bitrateIndex := int(buf[i+2] >> 4)
+		sampleRateIndex := int(buf[i+2]>>2) & 3
+		if versionBits == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 15 || sampleRateIndex == 3 {
+			continue
+		}
+
+		version := 1
+		sampleRate := mp3SampleRates[sampleRateIndex]
+		switch versionBits {
+		case 2:
+			version = 2
+			sampleRate /= 2
+		case 0:
+			version = 2
+			sampleRate /= 4
+		}
+		layer := 4 - layerBits
+		bitrate := mp3Bitrates[[2]int{version, layer}][bitrateIndex] * 1000
+
+		samplesPerFrame := 1152
+		if layer == 1 {
+			samplesPerFrame = 384
+		} else if layer == 3 && version == 2 {
+			samplesPerFrame = 576
+		}
+
+		// VBR 文件在第一帧中记录总帧数
+		if frames := mp3FrameCount(buf[i:], version, buf[i+3]>>6 == 3); frames > 0 {
+			return float64(frames) * float64(samplesPerFrame) / float64(sampleRate), nil
+		}
+		return float64(size-audioStart-int64(i)) * 8 / float64(bitrate), nil
+	}
+	return 0, ErrUnknownFormat
+}
+
+// mp3FrameCount 读取 Xing/Info 或 VBRI 头中的总帧数，没有时返回 0
+func mp3FrameCount(frame []byte, version int, mono bool) uint32 {
+	sideInfo := 32
+	switch {
+	case version == 1 && mono:
+		sideInfo = 17
+	case version == 2 && !mono:
+		sideInfo = 17
+	case version == 2 && mono:
+		sideInfo = 9
+	}
+
+	xing := 4 + sideInfo
+	if len(frame) >= xing+12 {
+		tag := string(frame[xing : xing+4])
+		if (tag == "Xing" || tag == "Info") && binary.BigEndian.Uint32(frame[xing+4:xing+8])&1 != 0 {
+			return binary.BigEndian.Uint32(frame[xing+8 : xing+12])
+		}
+	}
+
+	vbri := 4 + 32
+	if len(frame) >= vbri+18 && string(frame[vbri:vbri+4]) == "VBRI" {
+		return binary.BigEndian.Uint32(frame[vbri+14 : vbri+18])
+	}
+	return 0
+}
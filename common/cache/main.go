@@ -4,13 +4,14 @@ import (
 	"context"
 	"errors"
 	"one-api/common/config"
+	"one-api/common/logger"
 	"one-api/common/redis"
 	"time"
 
 	"github.com/coocood/freecache"
 	cacheM "github.com/eko/gocache/lib/v4/cache"
 	"github.com/eko/gocache/lib/v4/marshaler"
-	"github.com/eko/gocache/lib/v4/store"
+	lib_store "github.com/eko/gocache/lib/v4/store"
 	freecache_store "github.com/eko/gocache/store/freecache/v4"
 	redis_store "github.com/eko/gocache/store/redis/v4"
 	"golang.org/x/sync/singleflight"
@@ -18,30 +19,52 @@ import (
 
 var (
 	kvCache       *marshaler.Marshaler
+	localCache    *marshaler.Marshaler // Redis 不可用时使用的本地缓存
 	ctx           = context.Background()
 	sfGroup       singleflight.Group
 	CacheTimeout  = 1 * time.Second
 	CacheNotFound = errors.New("cache not found")
 )
 
+// Redis 不可用时本地缓存的最长有效期，避免各实例之间长时间不一致
+const localCacheMaxTTL = 30 * time.Second
+
+func newLocalCache() *marshaler.Marshaler {
+	freecacheStore := freecache_store.NewFreecache(freecache.NewCache(1024 * 1024))
+	return marshaler.New(cacheM.New[any](freecacheStore))
+}
+
 func InitCacheManager() {
-	var client *cacheM.Cache[any]
-	if config.RedisEnabled {
-		redisStore := redis_store.NewRedis(redis.RDB)
-		client = cacheM.New[any](redisStore)
-	} else {
-		freecacheStore := freecache_store.NewFreecache(freecache.NewCache(1024 * 1024))
-		client = cacheM.New[any](freecacheStore)
+	if !config.RedisEnabled {
+		kvCache = newLocalCache()
+		return
 	}
 
-	kvCache = marshaler.New(client)
+	redisStore := redis_store.NewRedis(redis.RDB)
+	kvCache = marshaler.New(cacheM.New[any](redisStore))
+	localCache = newLocalCache()
+	// 恢复后丢弃本地缓存，重新从 Redis 读取
+	redis.OnRecover(func() {
+		if err := localCache.Clear(ctx); err != nil {
+			logger.SysError("failed to clear local cache: " + err.Error())
+		}
+	})
+}
+
+// fallback Redis 不可用时使用本地缓存
+func fallback() bool {
+	return localCache != nil && !redis.Available()
 }
 
 func GetCache[T any](key string) (T, error) {
 	var val T
-	_, err := kvCache.Get(ctx, key, &val)
+	store := kvCache
+	if fallback() {
+		store = localCache
+	}
+	_, err := store.Get(ctx, key, &val)
 	if err != nil {
-		if errors.Is(err, store.NotFound{}) {
+		if errors.Is(err, lib_store.NotFound{}) {
 			return *new(T), CacheNotFound
 		}
 		return *new(T), err
@@ -50,10 +73,21 @@ func GetCache[T any](key string) (T, error) {
 }
 
 func SetCache(key string, value any, expiration time.Duration) error {
-	return kvCache.Set(ctx, key, value, store.WithExpiration(expiration))
+	if fallback() {
+		redis.MarkStale(key)
+		if expiration <= 0 || expiration > localCacheMaxTTL {
+			expiration = localCacheMaxTTL
+		}
+		return localCache.Set(ctx, key, value, lib_store.WithExpiration(expiration))
+	}
+	return kvCache.Set(ctx, key, value, lib_store.WithExpiration(expiration))
 }
 
 func DeleteCache(key string) error {
+	if fallback() {
+		redis.MarkStale(key)
+		return localCache.Delete(ctx, key)
+	}
 	return kvCache.Delete(ctx, key)
 }
 
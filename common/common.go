@@ -6,13 +6,44 @@ import (
 	"one-api/common/config"
 )
 
-func LogQuota(quota int) string {
+const (
+	QuotaUnitQuota  = "quota"  // 原始额度
+	QuotaUnitUSD    = "usd"    // 美元
+	QuotaUnitTokens = "tokens" // token 数，1 点额度对应倍率为 1 时的 1 个 token
+)
+
+// QuotaUnit 额度显示单位，未设置 QuotaDisplayUnit 时按 DisplayInCurrencyEnabled 决定
+func QuotaUnit() string {
+	switch config.QuotaDisplayUnit {
+	case QuotaUnitQuota, QuotaUnitUSD, QuotaUnitTokens:
+		return config.QuotaDisplayUnit
+	}
 	if config.DisplayInCurrencyEnabled {
+		return QuotaUnitUSD
+	}
+	return QuotaUnitQuota
+}
+
+// FormatQuota 按额度显示设置格式化额度，供接口和日志统一使用
+func FormatQuota(quota int) string {
+	switch QuotaUnit() {
+	case QuotaUnitUSD:
+		decimals := max(config.QuotaDisplayDecimals, 0)
+		amount := math.Abs(float64(quota) / config.QuotaPerUnit)
 		if quota < 0 {
-			return fmt.Sprintf("-＄%.6f 额度", math.Abs(float64(quota)/config.QuotaPerUnit))
+			return fmt.Sprintf("-＄%.*f", decimals, amount)
 		}
-		return fmt.Sprintf("＄%.6f 额度", float64(quota)/config.QuotaPerUnit)
-	} else {
+		return fmt.Sprintf("＄%.*f", decimals, amount)
+	case QuotaUnitTokens:
+		return fmt.Sprintf("%d tokens", quota)
+	default:
+		return fmt.Sprintf("%d", quota)
+	}
+}
+
+func LogQuota(quota int) string {
+	if QuotaUnit() == QuotaUnitQuota {
 		return fmt.Sprintf("%d 点额度", quota)
 	}
+	return FormatQuota(quota) + " 额度"
 }
@@ -37,9 +37,37 @@ func defaultConfig() {
 	viper.SetDefault("sqlite_busy_timeout", 3000)
 	viper.SetDefault("sync_frequency", 600)
 	viper.SetDefault("batch_update_interval", 5)
+	viper.SetDefault("batch_update_wal", true)
 	viper.SetDefault("global.api_rate_limit", 300)
 	viper.SetDefault("global.web_rate_limit", 180)
+	viper.SetDefault("channel.test_concurrency", 5)
+	viper.SetDefault("channel.test_all_models", false)
+	viper.SetDefault("channel.test_report_keep", 30)
 	viper.SetDefault("connect_timeout", 5)
+	viper.SetDefault("shutdown_timeout", 30)
+	viper.SetDefault("stream_keepalive_interval", 0)
+	viper.SetDefault("stream_aggregation_interval", 0)
+	viper.SetDefault("stream_failover_times", 0)
+	viper.SetDefault("image_fetch.max_size", 20)
+	viper.SetDefault("image_fetch.timeout", 15)
+	viper.SetDefault("image_fetch.cache_ttl", 300)
+	viper.SetDefault("audio.max_size", 0)
+	viper.SetDefault("audio.max_duration", 0)
+	viper.SetDefault("audio.tokens_per_second", 0)
+	viper.SetDefault("retry_after_max", 300)
+	viper.SetDefault("client_timeout_min", 0)
+	viper.SetDefault("client_timeout_max", 0)
+	viper.SetDefault("group_queue_timeout", 0)
+	viper.SetDefault("request_journal_timeout", 3600)
+	viper.SetDefault("billing_idempotency_ttl", 24)
+	viper.SetDefault("refund_on_stream_error", true)
+	viper.SetDefault("upstream.http2", true)
+	viper.SetDefault("upstream.max_idle_conns", 1000)
+	viper.SetDefault("upstream.max_idle_conns_per_host", 100)
+	viper.SetDefault("upstream.max_conns_per_host", 0)
+	viper.SetDefault("upstream.idle_conn_timeout", 90)
+	viper.SetDefault("upstream.tls_handshake_timeout", 10)
+	viper.SetDefault("upstream.local_addr", "")
 	viper.SetDefault("auto_price_updates", false)
 	viper.SetDefault("auto_price_updates_mode", "system")
 	viper.SetDefault("auto_price_updates_interval", 1440)
@@ -48,10 +76,108 @@ func defaultConfig() {
 	viper.SetDefault("favicon", "")
 	viper.SetDefault("user_invoice_month", false)
 	viper.SetDefault("mcp.enable", false)
+	viper.SetDefault("graphql.enable", false)
+	viper.SetDefault("graphql.max_root_fields", 10)
+	viper.SetDefault("idempotency.ttl", 24)
+	viper.SetDefault("prompt_compression.threshold", 0.9)
+	viper.SetDefault("prompt_compression.keep_recent", 6)
+	viper.SetDefault("prompt_compression.summarizer.timeout", 15)
+	viper.SetDefault("prompt_compression.summarizer.max_tokens", 1024)
+	viper.SetDefault("upstream_cache.models_ttl", 3600)
+	viper.SetDefault("upstream_cache.balance_ttl", 600)
+	viper.SetDefault("upstream_cache.jitter", 0.2)
+	viper.SetDefault("token_features.allow_disable_cache", true)
+	viper.SetDefault("token_features.moderation_levels", []string{"standard", "strict"})
+	viper.SetDefault("token_features.temperature_min", 0)
+	viper.SetDefault("token_features.temperature_max", 2)
+	viper.SetDefault("token_features.allow_hide_headers", true)
+	viper.SetDefault("pii_filter.enable", false)
+	viper.SetDefault("pii_filter.restore", true)
+	viper.SetDefault("ip_guard.enable", false)
+	viper.SetDefault("ip_guard.window", 300)
+	viper.SetDefault("ip_guard.max_failures", 20)
+	viper.SetDefault("ip_guard.ban_duration", 3600)
+	viper.SetDefault("mtls.enable", false)
+	viper.SetDefault("mtls.port", "3443")
+	viper.SetDefault("mtls.exclusive", false)
+	viper.SetDefault("grpc.enable", false)
+	viper.SetDefault("grpc.listen", "127.0.0.1")
+	viper.SetDefault("grpc.port", "50051")
+	viper.SetDefault("grpc.watch_interval", 2)
+	viper.SetDefault("remote_config.prefix", "one-api/options/")
+	viper.SetDefault("usage_events.enable", false)
+	viper.SetDefault("usage_events.driver", "kafka")
+	viper.SetDefault("usage_events.topic", "one-api.usage")
+	viper.SetDefault("usage_events.buffer_size", 10000)
+	viper.SetDefault("usage_events.batch_size", 100)
+	viper.SetDefault("usage_events.flush_interval", 1000)
+	viper.SetDefault("usage_events.timeout", 10)
+	viper.SetDefault("usage_events.rabbitmq.vhost", "/")
+	viper.SetDefault("usage_events.rabbitmq.exchange", "amq.topic")
+	viper.SetDefault("channel_key_encryption.kms_region", "us-east-1")
+	viper.SetDefault("bootstrap.file", "")
+	viper.SetDefault("secret_ref.cache_ttl", 300)
+	viper.SetDefault("secret_ref.env_prefix", "ONEAPI_SECRET_")
+	viper.SetDefault("secret_ref.aws.region", "us-east-1")
+	viper.SetDefault("hmac_auth.enable", false)
+	viper.SetDefault("hmac_auth.window", 300)
+	viper.SetDefault("hmac_auth.max_body_size", 32768)
+	viper.SetDefault("jailbreak_filter.enable", false)
+	viper.SetDefault("jailbreak_filter.threshold", 50)
+	viper.SetDefault("jailbreak_filter.action", "block")
+	viper.SetDefault("balancer.mode", "smooth")
+	viper.SetDefault("auto_priority.enable", false)
+	viper.SetDefault("auto_priority.interval", 60)
+	viper.SetDefault("auto_priority.window", 600)
+	viper.SetDefault("auto_priority.min_samples", 20)
+	viper.SetDefault("auto_priority.min_success_rate", 0.8)
+	viper.SetDefault("auto_priority.max_p95_latency", 0)
+	viper.SetDefault("auto_priority.min_weight_ratio", 0.1)
+	viper.SetDefault("unknown_model.policy", "default")
+	viper.SetDefault("unknown_model.channel_id", 0)
+	viper.SetDefault("unknown_model.ratio", 30)
+	viper.SetDefault("price_sync.enable", false)
+	viper.SetDefault("price_sync.url", "")
+	viper.SetDefault("price_sync.interval", 1440)
+	viper.SetDefault("price_sync.auto_apply", false)
+	viper.SetDefault("github_org_check.interval", 1440)
+	viper.SetDefault("request_capture.enable", false)
+	viper.SetDefault("request_capture.max_size", 64)
+	viper.SetDefault("request_capture.retention_days", 7)
+	viper.SetDefault("login_history.retention_days", 90)
+	viper.SetDefault("trash.retention_days", 30)
+	viper.SetDefault("i18n.default_language", "")
+	viper.SetDefault("anomaly_detection.enable", false)
+	viper.SetDefault("anomaly_detection.spike_ratio", 10)
+	viper.SetDefault("anomaly_detection.min_quota", 500000)
+	viper.SetDefault("anomaly_detection.min_requests", 10)
+	viper.SetDefault("anomaly_detection.min_samples", 24)
+	viper.SetDefault("anomaly_detection.baseline_hours", 48)
+	viper.SetDefault("trial.enable", false)
+	viper.SetDefault("trial.user_id", 0)
+	viper.SetDefault("trial.quota", 50000)
+	viper.SetDefault("trial.expire_hours", 24)
+	viper.SetDefault("trial.rpm", 3)
+	viper.SetDefault("trial.per_ip_daily", 1)
+	viper.SetDefault("transfer.enable", false)
+	viper.SetDefault("transfer.fee_rate", 0)
+	viper.SetDefault("transfer.min_quota", 0)
+	viper.SetDefault("transfer.max_quota", 0)
+	viper.SetDefault("transfer.daily_limit", 0)
+	viper.SetDefault("low_balance.check_interval", 30)
+	viper.SetDefault("diagnostics.pprof", false)
 	viper.SetDefault("uptime_kuma.enable", false)
 	viper.SetDefault("uptime_kuma.domain", "")
 	viper.SetDefault("uptime_kuma.status_page_name", "")
-	// Leader election via Redis
+	viper.SetDefault("redis.mode", "standalone")
+	viper.SetDefault("redis.addrs", []string{})
+	viper.SetDefault("redis.master_name", "")
+	viper.SetDefault("redis.health_check_interval", 5)
+	viper.SetDefault("statistics.rollup_interval", 10)
+	viper.SetDefault("statistics.hourly_retention_days", 90)
+	// Leader election via Redis or database advisory locks
 	viper.SetDefault("leader_election.enable", true)
+	viper.SetDefault("leader_election.backend", "redis")
 	viper.SetDefault("leader_election.lease_seconds", 15)
+	viper.SetDefault("leader_election.key", "one-api/leader")
 }
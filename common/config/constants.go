@@ -26,6 +26,12 @@ var AnalyticsCode = ""
 var QuotaPerUnit = 500 * 1000.0 // $0.002 / 1K tokens
 var DisplayInCurrencyEnabled = true
 
+// 额度显示单位：quota、usd 或 tokens，为空时按 DisplayInCurrencyEnabled 决定
+var QuotaDisplayUnit = ""
+
+// 以美元显示额度时保留的小数位数
+var QuotaDisplayDecimals = 6
+
 // 是否开启用户月账单功能
 var UserInvoiceMonth = false
 
@@ -43,6 +49,8 @@ var EmailVerificationEnabled = false
 var GitHubOAuthEnabled = false
 var WeChatAuthEnabled = false
 var LarkAuthEnabled = false
+var WeComAuthEnabled = false
+var DingTalkAuthEnabled = false
 var TurnstileCheckEnabled = false
 var RegisterEnabled = true
 var OIDCAuthEnabled = false
@@ -142,6 +150,26 @@ var EmailDomainWhitelist = []string{
   "foxmail.com",
 }
 
+// 注册限制
+var InviteOnlyRegister = false
+var RegisterIPLimitNum = 0           // 单个 IP 在限制时间内最多注册的用户数，0 为不限制
+var RegisterIPLimitDuration = 86400 // 单位秒
+var DisposableEmailBlockEnabled = false
+var DisposableEmailDomains = []string{
+  "mailinator.com",
+  "guerrillamail.com",
+  "10minutemail.com",
+  "tempmail.com",
+  "temp-mail.org",
+  "yopmail.com",
+  "sharklasers.com",
+  "trashmail.com",
+  "getnada.com",
+  "dispostable.com",
+  "maildrop.cc",
+  "throwawaymail.com",
+}
+
 var MemoryCacheEnabled = false
 
 var LogConsumeEnabled = true
@@ -152,6 +180,17 @@ var SMTPAccount = ""
 var SMTPFrom = ""
 var SMTPToken = ""
 
+// 发送邮件的服务：smtp、sendgrid 或 ses，发件人使用 SMTPFrom
+var EmailProvider = "smtp"
+var SendGridToken = ""
+var SESRegion = ""
+var SESAccessKeyId = ""
+var SESSecret = ""
+
+// 自定义邮件模板（JSON），邮件语言无法从请求确定时使用 EmailDefaultLanguage
+var EmailTemplates = ""
+var EmailDefaultLanguage = "zh"
+
 var ChatImageRequestProxy = ""
 
 var GitHubProxy = ""
@@ -159,9 +198,31 @@ var GitHubClientId = ""
 var GitHubClientSecret = ""
 var GitHubOldIdCloseEnabled = false
 
+// 只允许指定组织或团队的成员通过 GitHub 注册，逗号分隔，格式为 org 或 org/team-slug，为空时不限制
+var GitHubAllowedOrganizations = ""
+
+// 查询组织成员的令牌，需要 read:org 权限，未设置时使用用户登录的令牌（需要申请 read:org 权限）且不定期复查
+var GitHubOrgCheckToken = ""
+
 var LarkClientId = ""
 var LarkClientSecret = ""
 
+// 企业微信自建应用
+var WeComCorpId = ""
+var WeComAgentId = ""
+var WeComSecret = ""
+
+// 钉钉企业内部应用
+var DingTalkClientId = ""
+var DingTalkClientSecret = ""
+
+// 企业账号（企业微信、飞书、钉钉）首次登录时自动创建用户所在的分组（JSON），按提供方配置部门 ID 到分组的映射，* 为默认分组
+// 例如 {"wecom": {"2": "vip", "*": "default"}, "dingtalk": {"*": "team"}}
+var EnterpriseOAuthGroupMapping = ""
+
+// SCIM 用户同步接口的 Bearer 令牌，为空时关闭 SCIM 接口
+var SCIMToken = ""
+
 var WeChatServerAddress = ""
 var WeChatServerToken = ""
 var WeChatAccountQRCodeImageURL = ""
@@ -178,6 +239,10 @@ var OIDCUsernameClaims = ""
 var QuotaForNewUser = 0
 var QuotaForInviter = 0
 var QuotaForInvitee = 0
+var QuotaForInviterPurchase = 0 // 被邀请用户首次充值后邀请人获得的额度
+var QuotaForInviteePurchase = 0 // 被邀请用户首次充值后额外获得的额度
+var ReferralDailyLimit = 0      // 每个邀请人每天最多获得奖励的邀请数，0 为不限制
+var ReferralIPLimit = 0         // 同一 IP 24 小时内最多获得奖励的邀请数，0 为不限制
 var ChannelDisableThreshold = 5.0
 var AutomaticDisableChannelEnabled = false
 var AutomaticEnableChannelEnabled = false
@@ -188,7 +253,19 @@ var DisableTokenEncoders = false
 var RetryTimes = 0
 var RetryTimeOut = 10
 
+// 上游无需转换时直接透传流式响应
+var StreamPassthroughEnabled = true
+
+// 中继响应头中是否返回渠道 ID
+var RelayChannelHeaderEnabled = false
+
 var DefaultChannelWeight = uint(1)
+
+// 添加渠道时遇到类型和 key 都相同的已有渠道的处理方式：warn、skip、merge、allow
+var ChannelDuplicateKeyPolicy = "warn"
+
+// 百度文心模型对应的接口名称，覆盖内置的对应关系，未配置的模型按名称生成
+var BaiduModelEndpoints = map[string]string{}
 var RetryCooldownSeconds = 0
 
 // Global non-retry settings
@@ -221,6 +298,16 @@ var GeminiAPIEnabled = true
 // Claude
 var ClaudeAPIEnabled = true
 
+// 推理 token 的默认计费倍率，模型价格设置了 reasoning_tokens 倍率时以模型为准
+var ReasoningTokenRatio = 1.0
+
+// 返回给客户端之前移除推理内容，推理 token 仍然计费
+var RedactReasoningContent = false
+
+// 维护模式，开启后中继请求返回 503，管理接口不受影响
+var MaintenanceEnabled = false
+var MaintenanceMessage = "系统维护中，请稍后再试"
+
 const (
   RoleGuestUser  = 0
   RoleCommonUser = 1
@@ -253,6 +340,7 @@ const (
   ChannelStatusEnabled          = 1 // don't use 0, 0 is the default value!
   ChannelStatusManuallyDisabled = 2 // also don't use 0
   ChannelStatusAutoDisabled     = 3
+  ChannelStatusDraining         = 4 // 不再分配新请求，进行中的请求正常完成
 )
 
 const (
@@ -332,6 +420,7 @@ const (
   RelayModeChatRealtime
   RelayModeKling
   RelayModeResponses
+  RelayModeFineTuning
 )
 
 type ContextKey string
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// 跨域配置，修改后需调用 CORSChanged 使中间件重新生成
+var (
+	CORSAllowOrigins     = []string{"*"}
+	CORSAllowHeaders     = []string{"*"}
+	CORSExposeHeaders    = []string{"X-Oneapi-Request-Id", "X-RateLimit-Limit-Requests", "X-RateLimit-Remaining-Requests", "X-RateLimit-Reset-Requests"}
+	CORSAllowCredentials = true
+	CORSMaxAge           = 43200 // 预检请求缓存时间，单位秒
+)
+
+var corsVersion atomic.Int64
+
+// CORSChanged 标记跨域配置已修改
+func CORSChanged() {
+	corsVersion.Add(1)
+}
+
+// CORSVersion 跨域配置版本号
+func CORSVersion() int64 {
+	return corsVersion.Load()
+}
+
+// SplitList 按逗号拆分配置项，去除空白和空项
+func SplitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
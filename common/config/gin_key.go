@@ -1,5 +1,8 @@
 package config
 
 const (
-	GinRequestBodyKey = "cached_request_body"
+	GinRequestBodyKey     = "cached_request_body"
+	GinUpstreamContextKey = "upstream_context"
+	GinExtraFieldsKey     = "request_extra_fields" // 请求结构体未定义的字段
+	GinStreamErrorKey     = "stream_error"         // 流式响应中途上游返回的错误
 )
@@ -0,0 +1,12 @@
+package config
+
+// 对象存储（S3/MinIO）相关设置，过期天数为 0 表示不自动删除
+var (
+	StorageImagePersistEnabled      = false // 生成的图片保存到对象存储并返回稳定地址
+	StorageImageExpirationDays      = 0
+	StorageTranscriptEnabled        = false // 语音转文字结果保存到对象存储
+	StorageTranscriptExpirationDays = 0
+	StorageLogArchiveEnabled        = false // 定期将历史消费日志归档到对象存储并从数据库删除
+	StorageLogArchiveDays           = 30    // 归档多少天之前的日志
+	StorageLogArchiveExpirationDays = 0
+)
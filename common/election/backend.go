@@ -0,0 +1,86 @@
+package election
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"one-api/common"
+	"one-api/common/config"
+	rds "one-api/common/redis"
+
+	"github.com/spf13/viper"
+)
+
+// Backend 选举后端，负责租约的获取、续约和释放
+type Backend interface {
+	Name() string
+	// Acquire 尝试获取租约，返回是否成功
+	Acquire(ctx context.Context, nodeID string, ttl time.Duration) (bool, error)
+	// Renew 续约，返回 false 表示租约已不属于当前节点
+	Renew(ctx context.Context, nodeID string, ttl time.Duration) (bool, error)
+	// Release 释放当前节点持有的租约
+	Release(ctx context.Context, nodeID string) (bool, error)
+	// Leader 查询当前主节点和租约剩余时间，没有主节点时返回空字符串
+	Leader(ctx context.Context) (string, time.Duration, error)
+}
+
+// availabilityChecker 可以暂时不可用的选举后端
+type availabilityChecker interface {
+	Available() bool
+}
+
+// backendAvailable 后端不可用时返回 false
+func backendAvailable(backend Backend) bool {
+	checker, ok := backend.(availabilityChecker)
+	return !ok || checker.Available()
+}
+
+// 数据库选举后端使用的连接池，由 SetDatabase 设置
+var electionDB *sql.DB
+
+// SetDatabase 设置数据库选举后端使用的连接池，需要在 StartLeaderElection 之前调用
+func SetDatabase(db *sql.DB) {
+	electionDB = db
+}
+
+// newBackend 按配置创建选举后端，返回 nil 表示不启用自动选举
+func newBackend(name string) (Backend, error) {
+	switch name {
+	case "", "redis":
+		if !config.RedisEnabled {
+			// Redis disabled: stick to configured node_type behavior
+			return nil, nil
+		}
+		client := rds.GetRedisClient()
+		if client == nil {
+			return nil, fmt.Errorf("redis client not initialized")
+		}
+		return newRedisBackend(client), nil
+	case "database":
+		if electionDB == nil {
+			return nil, fmt.Errorf("database not initialized")
+		}
+		switch {
+		case common.UsingPostgreSQL:
+			return newPostgresBackend(electionDB), nil
+		case common.UsingSQLite:
+			return nil, fmt.Errorf("database backend requires MySQL or PostgreSQL")
+		default:
+			return newMySQLBackend(electionDB), nil
+		}
+	case "etcd", "consul":
+		endpoint := viper.GetString("leader_election.endpoint")
+		if endpoint == "" {
+			return nil, fmt.Errorf("%s backend requires leader_election.endpoint", name)
+		}
+		key := viper.GetString("leader_election.key")
+		if name == "etcd" {
+			return newEtcdBackend(endpoint, key), nil
+		}
+		return newConsulBackend(endpoint, key), nil
+	default:
+		return nil, fmt.Errorf("unknown backend: %s", name)
+	}
+}
@@ -0,0 +1,224 @@
+package election
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Consul 会话 TTL 的最小值
+const consulMinSessionTTL = 10 * time.Second
+
+// consulBackend 使用 Consul 会话和 KV acquire 选举
+// 会话失效时 Consul 删除主节点键（Behavior=delete），续约即会话 renew
+type consulBackend struct {
+	endpoint   string
+	key        string
+	token      string
+	datacenter string
+	client     *http.Client
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+type consulKeyValue struct {
+	Value   *string `json:"Value"`
+	Session string  `json:"Session"`
+}
+
+func newConsulBackend(endpoint, key string) *consulBackend {
+	return &consulBackend{
+		endpoint:   strings.TrimRight(endpoint, "/"),
+		key:        key,
+		token:      viper.GetString("leader_election.token"),
+		datacenter: viper.GetString("leader_election.datacenter"),
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (b *consulBackend) Name() string {
+	return "consul"
+}
+
+func (b *consulBackend) Acquire(ctx context.Context, nodeID string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ttl < consulMinSessionTTL {
+		ttl = consulMinSessionTTL
+	}
+	var session struct {
+		ID string `json:"ID"`
+	}
+	err := b.do(ctx, http.MethodPut, "/v1/session/create", nil, map[string]any{
+		"Name":      "one-api-leader",
+		"TTL":       ttl.String(),
+		"Behavior":  "delete",
+		"LockDelay": "0s",
+	}, &session)
+	if err != nil {
+		return false, err
+	}
+	if session.ID == "" {
+		return false, fmt.Errorf("consul session create returned empty id")
+	}
+
+	var acquired bool
+	err = b.do(ctx, http.MethodPut, "/v1/kv/"+b.key, url.Values{"acquire": {session.ID}}, []byte(nodeID), &acquired)
+	if err != nil || !acquired {
+		b.destroy(ctx, session.ID)
+		return false, err
+	}
+
+	b.sessionID = session.ID
+	return true, nil
+}
+
+func (b *consulBackend) Renew(ctx context.Context, nodeID string, _ time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.sessionID == "" {
+		return false, nil
+	}
+
+	// 会话已失效时返回 404
+	err := b.do(ctx, http.MethodPut, "/v1/session/renew/"+b.sessionID, nil, nil, nil)
+	if err == errConsulNotFound {
+		b.sessionID = ""
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	leader, session, err := b.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if leader != nodeID || session != b.sessionID {
+		b.destroy(ctx, b.sessionID)
+		b.sessionID = ""
+		return false, nil
+	}
+	return true, nil
+}
+
+// Release 销毁会话，主节点键随之删除
+func (b *consulBackend) Release(ctx context.Context, _ string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.sessionID == "" {
+		return false, nil
+	}
+	sessionID := b.sessionID
+	b.sessionID = ""
+
+	if err := b.do(ctx, http.MethodPut, "/v1/session/destroy/"+sessionID, nil, nil, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Leader Consul 无法查询会话的剩余时间，返回 0
+func (b *consulBackend) Leader(ctx context.Context) (string, time.Duration, error) {
+	leader, session, err := b.get(ctx)
+	if err != nil || session == "" {
+		return "", 0, err
+	}
+	return leader, 0, nil
+}
+
+// get 读取主节点键的值和持有的会话
+func (b *consulBackend) get(ctx context.Context) (string, string, error) {
+	var pairs []consulKeyValue
+	err := b.do(ctx, http.MethodGet, "/v1/kv/"+b.key, nil, nil, &pairs)
+	if err == errConsulNotFound || (err == nil && len(pairs) == 0) {
+		return "", "", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if pairs[0].Value == nil {
+		return "", pairs[0].Session, nil
+	}
+	value, err := base64.StdEncoding.DecodeString(*pairs[0].Value)
+	if err != nil {
+		return "", "", err
+	}
+	return string(value), pairs[0].Session, nil
+}
+
+// destroy 尽力销毁会话，失败时等待会话过期
+func (b *consulBackend) destroy(ctx context.Context, sessionID string) {
+	_ = b.do(ctx, http.MethodPut, "/v1/session/destroy/"+sessionID, nil, nil, nil)
+}
+
+var errConsulNotFound = errors.New("consul key or session not found")
+
+// do 发送请求，body 为 []byte 时原样发送，其它类型编码为 JSON
+func (b *consulBackend) do(ctx context.Context, method, path string, params url.Values, body, result any) error {
+	if params == nil {
+		params = url.Values{}
+	}
+	if b.datacenter != "" {
+		params.Set("dc", b.datacenter)
+	}
+
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case []byte:
+		reader = bytes.NewReader(body)
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	target := b.endpoint + path
+	if len(params) > 0 {
+		target += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if b.token != "" {
+		req.Header.Set("X-Consul-Token", b.token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errConsulNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("consul %s returned %d: %s", path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,142 @@
+package election
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// PostgreSQL 会话级锁的键
+const advisoryLockKey int64 = 0x6f6e6568
+
+// advisoryLockBackend 使用数据库会话级锁选举
+// 锁由一个独占连接持有，连接断开时数据库自动释放锁，因此租约时长不生效
+type advisoryLockBackend struct {
+	name string
+	db   *sql.DB
+	// 锁参数，PostgreSQL 为数值键，MySQL 为锁名
+	lockArg any
+
+	// 连接建立后执行，参数为节点 ID，用于在数据库中标识持有者
+	setupQuery  string
+	lockQuery   string
+	checkQuery  string
+	unlockQuery string
+	leaderQuery string
+
+	mu   sync.Mutex
+	conn *sql.Conn
+}
+
+func newPostgresBackend(db *sql.DB) *advisoryLockBackend {
+	return &advisoryLockBackend{
+		name:        "postgres",
+		db:          db,
+		lockArg:     advisoryLockKey,
+		setupQuery:  "SELECT set_config('application_name', $1, false)",
+		lockQuery:   "SELECT pg_try_advisory_lock($1)::int",
+		checkQuery:  "SELECT COUNT(*) FROM pg_locks WHERE locktype = 'advisory' AND pid = pg_backend_pid() AND objid::bigint = $1 AND granted",
+		unlockQuery: "SELECT pg_advisory_unlock($1)::int",
+		leaderQuery: "SELECT a.application_name FROM pg_locks l JOIN pg_stat_activity a ON a.pid = l.pid WHERE l.locktype = 'advisory' AND l.objid::bigint = $1 AND l.granted LIMIT 1",
+	}
+}
+
+func newMySQLBackend(db *sql.DB) *advisoryLockBackend {
+	return &advisoryLockBackend{
+		name:        "mysql",
+		db:          db,
+		lockArg:     leaderKey,
+		lockQuery:   "SELECT GET_LOCK(?, 0)",
+		checkQuery:  "SELECT IS_USED_LOCK(?) = CONNECTION_ID()",
+		unlockQuery: "SELECT RELEASE_LOCK(?)",
+		// MySQL 无法给连接命名，只能返回持有锁的连接 ID
+		leaderQuery: "SELECT CONCAT('connection-', IS_USED_LOCK(?))",
+	}
+}
+
+func (b *advisoryLockBackend) Name() string {
+	return b.name
+}
+
+func (b *advisoryLockBackend) Acquire(ctx context.Context, nodeID string, _ time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		conn, err := b.db.Conn(ctx)
+		if err != nil {
+			return false, err
+		}
+		b.conn = conn
+
+		if b.setupQuery != "" {
+			if _, err := b.conn.ExecContext(ctx, b.setupQuery, nodeID); err != nil {
+				b.closeConn()
+				return false, err
+			}
+		}
+	}
+
+	ok, err := b.queryFlag(ctx, b.lockQuery)
+	if err != nil || !ok {
+		// 未获取到锁时不占用连接
+		b.closeConn()
+	}
+
+	return ok, err
+}
+
+func (b *advisoryLockBackend) Renew(ctx context.Context, _ string, _ time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		return false, nil
+	}
+
+	ok, err := b.queryFlag(ctx, b.checkQuery)
+	if err != nil || !ok {
+		b.closeConn()
+	}
+
+	return ok, err
+}
+
+func (b *advisoryLockBackend) Release(ctx context.Context, _ string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn == nil {
+		return false, nil
+	}
+	defer b.closeConn()
+
+	return b.queryFlag(ctx, b.unlockQuery)
+}
+
+func (b *advisoryLockBackend) Leader(ctx context.Context) (string, time.Duration, error) {
+	var leader sql.NullString
+	if err := b.db.QueryRowContext(ctx, b.leaderQuery, b.lockArg).Scan(&leader); err != nil && err != sql.ErrNoRows {
+		return "", 0, err
+	}
+
+	return leader.String, 0, nil
+}
+
+// queryFlag 在持有的连接上执行返回 0/1 的查询
+func (b *advisoryLockBackend) queryFlag(ctx context.Context, query string) (bool, error) {
+	var res sql.NullInt64
+	if err := b.conn.QueryRowContext(ctx, query, b.lockArg).Scan(&res); err != nil {
+		return false, err
+	}
+
+	return res.Valid && res.Int64 > 0, nil
+}
+
+func (b *advisoryLockBackend) closeConn() {
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn = nil
+	}
+}
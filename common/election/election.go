@@ -4,53 +4,124 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	"one-api/common/config"
 	"one-api/common/logger"
-	rds "one-api/common/redis"
 
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
 	"github.com/spf13/viper"
 )
 
-const leaderKey = "onehub:leader"
+var (
+	electionBackend Backend
+	electionNodeID  string
+	electionStop    chan struct{}
+	electionDone    chan struct{}
 
-// Lua script: renew TTL only if we still own the lock (value matches)
-const renewLua = `
-local v = redis.call('GET', KEYS[1])
-if v == ARGV[1] then
-  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
-else
-  return 0
-end
-`
+	callbacksMu sync.Mutex
+	callbacks   []func(isLeader bool)
+)
+
+// LeaderInfo 当前节点看到的选举状态
+type LeaderInfo struct {
+	Enabled  bool   `json:"enabled"`
+	Backend  string `json:"backend"`
+	NodeID   string `json:"node_id"`
+	IsLeader bool   `json:"is_leader"`
+	Leader   string `json:"leader"`
+	// 租约剩余时间（毫秒），-1 表示当前没有主节点，0 表示租约不会过期（数据库锁）或无法查询（Consul）
+	LeaseTTL int64 `json:"lease_ttl"`
+}
+
+// OnLeadershipChange 注册主节点身份变化的回调，注册时会以当前状态立即调用一次
+// 回调在选举协程中同步执行，耗时操作需要自行开启协程
+func OnLeadershipChange(fn func(isLeader bool)) {
+	callbacksMu.Lock()
+	callbacks = append(callbacks, fn)
+	isLeader := config.IsMasterNode
+	callbacksMu.Unlock()
+
+	fn(isLeader)
+}
+
+// setLeader 更新主节点身份，状态变化时通知回调
+func setLeader(isLeader bool) {
+	callbacksMu.Lock()
+	changed := config.IsMasterNode != isLeader
+	config.IsMasterNode = isLeader
+	fns := append([]func(bool){}, callbacks...)
+	callbacksMu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	for _, fn := range fns {
+		fn(isLeader)
+	}
+}
+
+// IsEnabled 是否启用了自动选举
+func IsEnabled() bool {
+	return electionNodeID != ""
+}
+
+// GetLeaderInfo 查询当前主节点和租约剩余时间
+func GetLeaderInfo(ctx context.Context) (*LeaderInfo, error) {
+	info := &LeaderInfo{
+		Enabled:  IsEnabled(),
+		NodeID:   electionNodeID,
+		IsLeader: config.IsMasterNode,
+		LeaseTTL: -1,
+	}
+	if !info.Enabled {
+		return info, nil
+	}
+	info.Backend = electionBackend.Name()
+
+	leader, ttl, err := electionBackend.Leader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if leader == "" {
+		return info, nil
+	}
+
+	info.Leader = leader
+	info.LeaseTTL = 0
+	if ttl > 0 {
+		info.LeaseTTL = ttl.Milliseconds()
+	}
+
+	return info, nil
+}
 
 // StartLeaderElection starts a background goroutine that:
-// - competes for a Redis SETNX lease to become leader (master)
+// - competes for a lease on the configured backend to become leader (master)
 // - renews the lease while holding it
 // - demotes to follower (slave) when lease cannot be renewed
 //
-// Behavior:
-// - If Redis is not enabled, this function returns immediately and
-//   the legacy node_type config continues to control IsMasterNode.
-// - If Redis is enabled, automatic leader election runs and overrides
-//   config.IsMasterNode according to the lease ownership.
+// Backends (leader_election.backend):
+//   - redis: SETNX lease, requires Redis. If Redis is not enabled, this function
+//     returns immediately and the legacy node_type config controls IsMasterNode.
+//     While Redis is unavailable, node_type controls IsMasterNode until it recovers.
+//   - database: session-level advisory lock on MySQL/PostgreSQL, requires SetDatabase.
+//   - etcd: key bound to a lease and written by a txn, via the v3 HTTP gateway.
+//   - consul: KV acquire with a session that deletes the key when it expires.
 func StartLeaderElection() {
-	if !config.RedisEnabled {
-		// Redis disabled: stick to configured node_type behavior
-		return
-	}
 	if viper.IsSet("leader_election.enable") && !viper.GetBool("leader_election.enable") {
 		logger.SysLog("Leader election disabled by config: leader_election.enable=false")
 		return
 	}
 
-	client := rds.GetRedisClient()
-	if client == nil {
-		// Defensive: Redis said enabled but client not ready
-		logger.SysError("Leader election skipped: Redis client not initialized")
+	backend, err := newBackend(viper.GetString("leader_election.backend"))
+	if err != nil {
+		logger.SysError("Leader election skipped: " + err.Error())
+		return
+	}
+	if backend == nil {
 		return
 	}
 
@@ -66,14 +137,19 @@ func StartLeaderElection() {
 	}
 
 	nodeID := makeNodeID()
-	renewScript := redis.NewScript(renewLua)
+
+	electionBackend = backend
+	electionNodeID = nodeID
+	electionStop = make(chan struct{})
+	electionDone = make(chan struct{})
 
 	go func() {
+		defer close(electionDone)
 		ctx := context.Background()
 		isLeader := false
 		lastStateLogged := time.Time{}
 
-		logger.SysLog(fmt.Sprintf("Leader election started, node=%s, lease=%ds, renew=%s", nodeID, leaseSeconds, renewInterval))
+		logger.SysLog(fmt.Sprintf("Leader election started, backend=%s, node=%s, lease=%ds, renew=%s", backend.Name(), nodeID, leaseSeconds, renewInterval))
 
 		logState := func(msg string) {
 			// Avoid log spam: at most once every 30s unless state flips
@@ -84,66 +160,109 @@ func StartLeaderElection() {
 		}
 
 		for {
+			if !backendAvailable(backend) {
+				// 后端恢复前使用配置的 node_type，恢复后重新竞选
+				configured := viper.GetString("node_type") == "master"
+				if isLeader || config.IsMasterNode != configured {
+					logger.SysLog(fmt.Sprintf("Election backend unavailable, falling back to node_type, master=%t, node=%s", configured, nodeID))
+				}
+				isLeader = false
+				setLeader(configured)
+				logState(fmt.Sprintf("Election backend unavailable, waiting for recovery, node=%s", nodeID))
+				if !sleepOrStop(renewInterval) {
+					return
+				}
+				continue
+			}
+
 			if !isLeader {
 				// Try to acquire leadership
-				ok, err := client.SetNX(ctx, leaderKey, nodeID, leaseTTL).Result()
+				ok, err := backend.Acquire(ctx, nodeID, leaseTTL)
 				if err != nil {
-					logger.SysError(fmt.Sprintf("Leader election SetNX error (node=%s): %v", nodeID, err))
+					logger.SysError(fmt.Sprintf("Leader acquire error (node=%s): %v", nodeID, err))
 				}
 				if ok {
 					// We are the leader now
 					if !config.IsMasterNode {
 						logger.SysLog(fmt.Sprintf("Leadership acquired, node=%s", nodeID))
 					}
-					config.IsMasterNode = true
 					isLeader = true
+					setLeader(true)
 				} else {
 					// Not leader
 					if config.IsMasterNode {
 						logger.SysLog(fmt.Sprintf("Leadership lost (another node holds the lease), node=%s", nodeID))
 					}
-					config.IsMasterNode = false
+					setLeader(false)
 					logState(fmt.Sprintf("Follower state, waiting to acquire leadership, node=%s", nodeID))
 				}
-				time.Sleep(renewInterval)
+				if !sleepOrStop(renewInterval) {
+					return
+				}
 				continue
 			}
 
-			// Renew lease if we still own it
-			// ARGV[1]=nodeID, ARGV[2]=ttlMillis
-			ttlMillis := int(leaseTTL / time.Millisecond)
-			res, err := renewScript.Run(ctx, client, []string{leaderKey}, nodeID, ttlMillis).Result()
+			// Renew lease if we still own it; on error be conservative and demote
+			ok, err := backend.Renew(ctx, nodeID, leaseTTL)
 			if err != nil {
 				logger.SysError(fmt.Sprintf("Leader renew error (node=%s): %v", nodeID, err))
 			}
 
-			switch v := res.(type) {
-			case int64:
-				if v == 1 {
-					// Successfully renewed; stay leader
-					logState(fmt.Sprintf("Leader state, lease renewed, node=%s", nodeID))
-				} else {
-					// Renew failed; demote
-					isLeader = false
-					if config.IsMasterNode {
-						logger.SysLog(fmt.Sprintf("Leadership renewal failed, demoting to follower, node=%s", nodeID))
-					}
-					config.IsMasterNode = false
-				}
-			default:
-				// Unexpected response; be conservative: demote
+			if ok {
+				// Successfully renewed; stay leader
+				logState(fmt.Sprintf("Leader state, lease renewed, node=%s", nodeID))
+			} else {
 				isLeader = false
 				if config.IsMasterNode {
-					logger.SysLog(fmt.Sprintf("Leadership renewal returned unexpected result, demoting to follower, node=%s", nodeID))
+					logger.SysLog(fmt.Sprintf("Leadership renewal failed, demoting to follower, node=%s", nodeID))
 				}
-				config.IsMasterNode = false
+				setLeader(false)
 			}
 
-			time.Sleep(renewInterval)
+			if !sleepOrStop(renewInterval) {
+				return
+			}
 		}
 	}()
 }
 
+// sleepOrStop waits for d, returning false if the election loop was asked to stop.
+func sleepOrStop(d time.Duration) bool {
+	select {
+	case <-electionStop:
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+// StopLeaderElection stops the election loop and releases the lease if this
+// node holds it, so another node can take over without waiting for the TTL.
+// Leadership callbacks are notified before the lease is released.
+func StopLeaderElection() {
+	if electionStop == nil {
+		return
+	}
+
+	close(electionStop)
+	<-electionDone
+	electionStop = nil
+	setLeader(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	released, err := electionBackend.Release(ctx, electionNodeID)
+	if err != nil {
+		logger.SysError(fmt.Sprintf("Leader release error (node=%s): %v", electionNodeID, err))
+		return
+	}
+
+	if released {
+		logger.SysLog(fmt.Sprintf("Leadership released, node=%s", electionNodeID))
+	}
+}
+
 func makeNodeID() string {
 	host, _ := os.Hostname()
 	if host == "" {
@@ -0,0 +1,251 @@
+package election
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// etcdBackend 通过 etcd v3 的 HTTP 网关选举
+// 主节点键绑定在租约上，用事务保证键不存在时才写入，续约即租约 keepalive，租约过期后键自动删除
+type etcdBackend struct {
+	endpoint string
+	key      string // base64 编码的键
+	username string
+	password string
+	client   *http.Client
+
+	mu      sync.Mutex
+	leaseID string
+}
+
+type etcdRangeResult struct {
+	Kvs []struct {
+		Value string `json:"value"`
+		Lease string `json:"lease"`
+	} `json:"kvs"`
+}
+
+func newEtcdBackend(endpoint, key string) *etcdBackend {
+	return &etcdBackend{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		key:      base64.StdEncoding.EncodeToString([]byte(key)),
+		username: viper.GetString("leader_election.username"),
+		password: viper.GetString("leader_election.password"),
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (b *etcdBackend) Name() string {
+	return "etcd"
+}
+
+func (b *etcdBackend) Acquire(ctx context.Context, nodeID string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	seconds := int64(ttl / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	var grant struct {
+		ID    string `json:"ID"`
+		Error string `json:"error"`
+	}
+	if err := b.post(ctx, "/v3/lease/grant", map[string]any{"TTL": strconv.FormatInt(seconds, 10)}, &grant); err != nil {
+		return false, err
+	}
+	if grant.ID == "" {
+		return false, fmt.Errorf("etcd lease grant failed: %s", grant.Error)
+	}
+
+	// 键不存在（create_revision 为 0）时才写入
+	var txn struct {
+		Succeeded bool `json:"succeeded"`
+	}
+	err := b.post(ctx, "/v3/kv/txn", map[string]any{
+		"compare": []map[string]any{{"key": b.key, "result": "EQUAL", "target": "CREATE", "create_revision": "0"}},
+		"success": []map[string]any{{"request_put": map[string]any{
+			"key":   b.key,
+			"value": base64.StdEncoding.EncodeToString([]byte(nodeID)),
+			"lease": grant.ID,
+		}}},
+	}, &txn)
+	if err != nil || !txn.Succeeded {
+		b.revoke(ctx, grant.ID)
+		return false, err
+	}
+
+	b.leaseID = grant.ID
+	return true, nil
+}
+
+func (b *etcdBackend) Renew(ctx context.Context, nodeID string, _ time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.leaseID == "" {
+		return false, nil
+	}
+
+	// 租约已过期时返回的 TTL 为空或 0
+	var keepalive struct {
+		Result struct {
+			TTL string `json:"TTL"`
+		} `json:"result"`
+	}
+	if err := b.post(ctx, "/v3/lease/keepalive", map[string]any{"ID": b.leaseID}, &keepalive); err != nil {
+		return false, err
+	}
+	if ttl, _ := strconv.ParseInt(keepalive.Result.TTL, 10, 64); ttl <= 0 {
+		b.leaseID = ""
+		return false, nil
+	}
+
+	leader, lease, err := b.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	if leader != nodeID || lease != b.leaseID {
+		b.revoke(ctx, b.leaseID)
+		b.leaseID = ""
+		return false, nil
+	}
+	return true, nil
+}
+
+// Release 撤销租约，绑定在租约上的主节点键随之删除
+func (b *etcdBackend) Release(ctx context.Context, _ string) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.leaseID == "" {
+		return false, nil
+	}
+	leaseID := b.leaseID
+	b.leaseID = ""
+
+	if err := b.post(ctx, "/v3/lease/revoke", map[string]any{"ID": leaseID}, nil); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (b *etcdBackend) Leader(ctx context.Context) (string, time.Duration, error) {
+	leader, lease, err := b.get(ctx)
+	if err != nil || leader == "" {
+		return "", 0, err
+	}
+	if lease == "" || lease == "0" {
+		return leader, 0, nil
+	}
+
+	var ttl struct {
+		TTL string `json:"TTL"`
+	}
+	if err := b.post(ctx, "/v3/lease/timetolive", map[string]any{"ID": lease}, &ttl); err != nil {
+		return "", 0, err
+	}
+	seconds, _ := strconv.ParseInt(ttl.TTL, 10, 64)
+	if seconds < 0 {
+		seconds = 0
+	}
+	return leader, time.Duration(seconds) * time.Second, nil
+}
+
+// get 读取主节点键的值和绑定的租约
+func (b *etcdBackend) get(ctx context.Context) (string, string, error) {
+	var result etcdRangeResult
+	if err := b.post(ctx, "/v3/kv/range", map[string]any{"key": b.key}, &result); err != nil {
+		return "", "", err
+	}
+	if len(result.Kvs) == 0 {
+		return "", "", nil
+	}
+
+	value, err := base64.StdEncoding.DecodeString(result.Kvs[0].Value)
+	if err != nil {
+		return "", "", err
+	}
+	return string(value), result.Kvs[0].Lease, nil
+}
+
+// revoke 尽力撤销租约，失败时等待租约过期
+func (b *etcdBackend) revoke(ctx context.Context, leaseID string) {
+	_ = b.post(ctx, "/v3/lease/revoke", map[string]any{"ID": leaseID}, nil)
+}
+
+func (b *etcdBackend) post(ctx context.Context, path string, body, result any) error {
+	token := ""
+	if b.username != "" {
+		var err error
+		if token, err = b.authenticate(ctx); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("etcd %s returned %d: %s", path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+// authenticate etcd 的 token 有效期较短，每次请求前重新获取
+func (b *etcdBackend) authenticate(ctx context.Context) (string, error) {
+	data, _ := json.Marshal(map[string]string{"name": b.username, "password": b.password})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint+"/v3/auth/authenticate", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("etcd authenticate returned %d", resp.StatusCode)
+	}
+	var result struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.Token, nil
+}
@@ -0,0 +1,92 @@
+package election
+
+import (
+	"context"
+	rds "one-api/common/redis"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const leaderKey = "onehub:leader"
+
+// Lua script: renew TTL only if we still own the lock (value matches)
+const renewLua = `
+local v = redis.call('GET', KEYS[1])
+if v == ARGV[1] then
+  return redis.call('PEXPIRE', KEYS[1], ARGV[2])
+else
+  return 0
+end
+`
+
+// Lua script: delete the lock only if we still own it
+const releaseLua = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+  return redis.call('DEL', KEYS[1])
+else
+  return 0
+end
+`
+
+// redisBackend 使用 Redis SETNX 租约选举
+type redisBackend struct {
+	client        redis.UniversalClient
+	renewScript   *redis.Script
+	releaseScript *redis.Script
+}
+
+func newRedisBackend(client redis.UniversalClient) *redisBackend {
+	return &redisBackend{
+		client:        client,
+		renewScript:   redis.NewScript(renewLua),
+		releaseScript: redis.NewScript(releaseLua),
+	}
+}
+
+func (b *redisBackend) Name() string {
+	return "redis"
+}
+
+func (b *redisBackend) Acquire(ctx context.Context, nodeID string, ttl time.Duration) (bool, error) {
+	return b.client.SetNX(ctx, leaderKey, nodeID, ttl).Result()
+}
+
+func (b *redisBackend) Renew(ctx context.Context, nodeID string, ttl time.Duration) (bool, error) {
+	// ARGV[1]=nodeID, ARGV[2]=ttlMillis
+	res, err := b.renewScript.Run(ctx, b.client, []string{leaderKey}, nodeID, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (b *redisBackend) Release(ctx context.Context, nodeID string) (bool, error) {
+	res, err := b.releaseScript.Run(ctx, b.client, []string{leaderKey}, nodeID).Int64()
+	if err != nil {
+		return false, err
+	}
+	return res == 1, nil
+}
+
+func (b *redisBackend) Leader(ctx context.Context) (string, time.Duration, error) {
+	leader, err := b.client.Get(ctx, leaderKey).Result()
+	if err == redis.Nil {
+		return "", 0, nil
+	}
+	if err != nil {
+		return "", 0, err
+	}
+
+	ttl, err := b.client.PTTL(ctx, leaderKey).Result()
+	if err != nil {
+		return "", 0, err
+	}
+
+	return leader, ttl, nil
+}
+
+// Available Redis 暂时不可用时选举暂停，按配置的 node_type 决定是否为主节点
+func (b *redisBackend) Available() bool {
+	return rds.Available()
+}
@@ -0,0 +1,179 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/spf13/viper"
+)
+
+// 密文格式：enc:v1:<密钥 ID>:<base64(nonce + 密文)>
+const prefix = "enc:v1:"
+
+var ErrNoKey = errors.New("no master key to decrypt channel key")
+
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+var (
+	current *masterKey
+	keys    = make(map[string]*masterKey)
+)
+
+// Init 加载主密钥，old_secrets 只用于解密，便于轮换主密钥，重复调用时替换之前加载的密钥
+func Init() error {
+	current = nil
+	keys = make(map[string]*masterKey)
+
+	secret, err := loadSecret()
+	if err != nil {
+		return err
+	}
+	if len(secret) == 0 {
+		return nil
+	}
+
+	current, err = newMasterKey(secret)
+	if err != nil {
+		return err
+	}
+	keys[current.id] = current
+
+	for _, old := range viper.GetStringSlice("channel_key_encryption.old_secrets") {
+		key, err := newMasterKey([]byte(old))
+		if err != nil {
+			return err
+		}
+		keys[key.id] = key
+	}
+	return nil
+}
+
+// loadSecret 按 kms_data_key、secret_file、secret 的顺序读取主密钥
+func loadSecret() ([]byte, error) {
+	if dataKey := viper.GetString("channel_key_encryption.kms_data_key"); dataKey != "" {
+		return decryptDataKey(dataKey)
+	}
+
+	if file := viper.GetString("channel_key_encryption.secret_file"); file != "" {
+		secret, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(strings.TrimSpace(string(secret))), nil
+	}
+
+	return []byte(viper.GetString("channel_key_encryption.secret")), nil
+}
+
+// decryptDataKey 使用 AWS KMS 解密数据密钥，凭证从环境变量或实例角色中获取
+func decryptDataKey(dataKey string) ([]byte, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kms_data_key: %w", err)
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(viper.GetString("channel_key_encryption.kms_region")),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kms session: %w", err)
+	}
+
+	output, err := kms.New(sess).Decrypt(&kms.DecryptInput{CiphertextBlob: ciphertext})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt kms_data_key: %w", err)
+	}
+	return output.Plaintext, nil
+}
+
+func newMasterKey(secret []byte) (*masterKey, error) {
+	sum := sha256.Sum256(secret)
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	id := sha256.Sum256(sum[:])
+	return &masterKey{id: hex.EncodeToString(id[:4]), aead: aead}, nil
+}
+
+// Enabled 是否配置了主密钥
+func Enabled() bool {
+	return current != nil
+}
+
+// IsEncrypted 值是否为密文
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// Encrypt 使用当前主密钥加密，未配置主密钥时原样返回
+func Encrypt(plaintext string) (string, error) {
+	if current == nil || plaintext == "" || IsEncrypted(plaintext) {
+		return plaintext, nil
+	}
+
+	nonce := make([]byte, current.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := current.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return prefix + current.id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt 解密密文，明文原样返回
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	id, data, ok := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	if !ok {
+		return "", errors.New("invalid encrypted channel key")
+	}
+	key, ok := keys[id]
+	if !ok {
+		return "", ErrNoKey
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return "", err
+	}
+	nonceSize := key.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("invalid encrypted channel key")
+	}
+
+	plaintext, err := key.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// NeedRotate 密文是否需要用当前主密钥重新加密
+func NeedRotate(value string) bool {
+	if current == nil {
+		return false
+	}
+	return !strings.HasPrefix(value, prefix+current.id+":")
+}
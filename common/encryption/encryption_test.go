@@ -0,0 +1,157 @@
+package encryption_test
+
+import (
+	"strings"
+	"testing"
+
+	"one-api/common/encryption"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func initKeys(t *testing.T, secret string, oldSecrets ...string) {
+	viper.Set("channel_key_encryption.secret", secret)
+	viper.Set("channel_key_encryption.old_secrets", oldSecrets)
+	t.Cleanup(func() {
+		viper.Set("channel_key_encryption.secret", "")
+		viper.Set("channel_key_encryption.old_secrets", nil)
+		encryption.Init()
+	})
+	assert.Nil(t, encryption.Init())
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	initKeys(t, "current-secret")
+	assert.True(t, encryption.Enabled())
+
+	tests := []struct {
+		name      string
+		plaintext string
+		encrypted bool
+	}{
+		{"api key", "sk-abcdefghijklmnopqrstuvwxyz", true},
+		{"multi line", "sk-first\nsk-second", true},
+		{"unicode", "密钥|region", true},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ciphertext, err := encryption.Encrypt(tt.plaintext)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.encrypted, encryption.IsEncrypted(ciphertext))
+			if tt.encrypted {
+				assert.NotContains(t, ciphertext, tt.plaintext)
+			}
+
+			// 已加密的值不重复加密
+			again, err := encryption.Encrypt(ciphertext)
+			assert.Nil(t, err)
+			assert.Equal(t, ciphertext, again)
+
+			plaintext, err := encryption.Decrypt(ciphertext)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.plaintext, plaintext)
+		})
+	}
+}
+
+func TestEncryptNonceUnique(t *testing.T) {
+	initKeys(t, "current-secret")
+
+	first, err := encryption.Encrypt("sk-same")
+	assert.Nil(t, err)
+	second, err := encryption.Encrypt("sk-same")
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+}
+
+func TestEncryptDisabled(t *testing.T) {
+	initKeys(t, "")
+	assert.False(t, encryption.Enabled())
+
+	value, err := encryption.Encrypt("sk-plain")
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-plain", value)
+
+	value, err = encryption.Decrypt("sk-plain")
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-plain", value)
+	assert.False(t, encryption.NeedRotate("sk-plain"))
+}
+
+func TestKeyRotation(t *testing.T) {
+	initKeys(t, "old-secret")
+	oldCiphertext, err := encryption.Encrypt("sk-rotate")
+	assert.Nil(t, err)
+	assert.False(t, encryption.NeedRotate(oldCiphertext))
+
+	// 新主密钥加密，旧主密钥只用于解密
+	initKeys(t, "new-secret", "old-secret")
+	assert.True(t, encryption.NeedRotate(oldCiphertext))
+	assert.True(t, encryption.NeedRotate("sk-plain"))
+
+	plaintext, err := encryption.Decrypt(oldCiphertext)
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-rotate", plaintext)
+
+	newCiphertext, err := encryption.Encrypt(plaintext)
+	assert.Nil(t, err)
+	assert.False(t, encryption.NeedRotate(newCiphertext))
+
+	// 轮换完成后去掉旧主密钥，旧密文无法解密
+	initKeys(t, "new-secret")
+	_, err = encryption.Decrypt(oldCiphertext)
+	assert.ErrorIs(t, err, encryption.ErrNoKey)
+
+	plaintext, err = encryption.Decrypt(newCiphertext)
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-rotate", plaintext)
+}
+
+func TestDecryptInvalid(t *testing.T) {
+	initKeys(t, "current-secret")
+	valid, err := encryption.Encrypt("sk-valid")
+	assert.Nil(t, err)
+	id := strings.Split(valid, ":")[2]
+	data := strings.Split(valid, ":")[3]
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"missing data", "enc:v1:" + id},
+		{"unknown key", "enc:v1:00000000:" + data},
+		{"bad base64", "enc:v1:" + id + ":!!!"},
+		{"too short", "enc:v1:" + id + ":AAAA"},
+		{"tampered", "enc:v1:" + id + ":" + data[:len(data)-4] + "AAAA"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := encryption.Decrypt(tt.value)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestPassphraseCipher(t *testing.T) {
+	salt, err := encryption.NewSalt()
+	assert.Nil(t, err)
+
+	cipher, err := encryption.NewPassphraseCipher("passphrase", salt)
+	assert.Nil(t, err)
+	ciphertext, err := cipher.Encrypt("sk-export")
+	assert.Nil(t, err)
+
+	plaintext, err := cipher.Decrypt(ciphertext)
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-export", plaintext)
+
+	wrong, err := encryption.NewPassphraseCipher("wrong", salt)
+	assert.Nil(t, err)
+	_, err = wrong.Decrypt(ciphertext)
+	assert.ErrorIs(t, err, encryption.ErrPassphrase)
+
+	_, err = encryption.NewPassphraseCipher("", salt)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,82 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+var ErrPassphrase = errors.New("wrong passphrase or corrupted data")
+
+// PassphraseCipher 使用口令派生的密钥加解密，与主密钥无关，用于导出到其它部署的数据
+type PassphraseCipher struct {
+	aead cipher.AEAD
+}
+
+// NewSalt 生成口令派生密钥使用的随机盐
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+func NewPassphraseCipher(passphrase string, salt []byte) (*PassphraseCipher, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is empty")
+	}
+
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &PassphraseCipher{aead: aead}, nil
+}
+
+// Encrypt 返回 base64(nonce + 密文)，空字符串原样返回
+func (p *PassphraseCipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := p.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (p *PassphraseCipher) Decrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", ErrPassphrase
+	}
+	nonceSize := p.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", ErrPassphrase
+	}
+
+	plaintext, err := p.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", ErrPassphrase
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,231 @@
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"one-api/common/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// Event 一次中继请求完成后的用量事件
+type Event struct {
+	RequestId        string `json:"request_id"`
+	Timestamp        int64  `json:"timestamp"`
+	Status           string `json:"status"`
+	StatusCode       int    `json:"status_code"`
+	ErrorCode        string `json:"error_code,omitempty"`
+	ErrorMessage     string `json:"error_message,omitempty"`
+	UserId           int    `json:"user_id"`
+	TokenId          int    `json:"token_id"`
+	TokenName        string `json:"token_name"`
+	ChannelId        int    `json:"channel_id"`
+	Group            string `json:"group"`
+	Model            string `json:"model"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	TotalTokens      int    `json:"total_tokens"`
+	Quota            int    `json:"quota"`
+	Latency          int    `json:"latency"`        // 请求耗时，单位为毫秒
+	FirstResponse    int64  `json:"first_response"` // 首字耗时，单位为毫秒
+	IsStream         bool   `json:"is_stream"`
+	SourceIp         string `json:"source_ip"`
+}
+
+// message 发送到消息队列的单条消息，key 用于 Kafka 分区
+type message struct {
+	key   string
+	value []byte
+}
+
+type publisher interface {
+	name() string
+	publish(ctx context.Context, messages []message) error
+	close() error
+}
+
+var (
+	enabled atomic.Bool
+	events  chan *Event
+	dropped atomic.Int64
+
+	target        publisher
+	batchSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+
+	stop    chan struct{}
+	stopped sync.WaitGroup
+)
+
+// Init 根据配置文件 usage_events 启用用量事件推送
+func Init() {
+	if !viper.GetBool("usage_events.enable") {
+		return
+	}
+
+	topic := viper.GetString("usage_events.topic")
+	if topic == "" {
+		logger.SysError("usage_events.topic is empty, usage events disabled")
+		return
+	}
+
+	var err error
+	switch driver := viper.GetString("usage_events.driver"); driver {
+	case "kafka":
+		target, err = newKafkaPublisher(topic)
+	case "nats":
+		target, err = newNatsPublisher(topic)
+	case "rabbitmq":
+		target, err = newRabbitMQPublisher(topic)
+	default:
+		err = fmt.Errorf("unknown driver %s", driver)
+	}
+	if err != nil {
+		logger.SysError("failed to initialize usage events: " + err.Error())
+		return
+	}
+
+	batchSize = viper.GetInt("usage_events.batch_size")
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	flushInterval = time.Duration(viper.GetInt("usage_events.flush_interval")) * time.Millisecond
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+	timeout = time.Duration(viper.GetInt("usage_events.timeout")) * time.Second
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	bufferSize := viper.GetInt("usage_events.buffer_size")
+	if bufferSize <= 0 {
+		bufferSize = 10000
+	}
+
+	events = make(chan *Event, bufferSize)
+	stop = make(chan struct{})
+	stopped.Add(1)
+	go run()
+
+	enabled.Store(true)
+	logger.SysLog(fmt.Sprintf("usage events enabled: %s topic %s", target.name(), topic))
+}
+
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Publish 异步推送事件，队列已满时丢弃，不阻塞请求
+func Publish(event *Event) {
+	if !Enabled() {
+		return
+	}
+	if event.Timestamp == 0 {
+		event.Timestamp = time.Now().Unix()
+	}
+	event.TotalTokens = event.PromptTokens + event.CompletionTokens
+
+	select {
+	case events <- event:
+	default:
+		if count := dropped.Add(1); count%1000 == 1 {
+			logger.SysError(fmt.Sprintf("usage events queue is full, %d events dropped", count))
+		}
+	}
+}
+
+// Close 停止接收新事件，发送队列中剩余的事件，ctx 超时后直接退出
+func Close(ctx context.Context) {
+	if !enabled.CompareAndSwap(true, false) {
+		return
+	}
+	close(stop)
+
+	done := make(chan struct{})
+	go func() {
+		stopped.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.SysError("timed out flushing usage events")
+	}
+
+	if err := target.close(); err != nil {
+		logger.SysError("failed to close usage events publisher: " + err.Error())
+	}
+}
+
+func run() {
+	defer stopped.Done()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]message, 0, batchSize)
+	for {
+		select {
+		case event := <-events:
+			batch = appendEvent(batch, event)
+			if len(batch) >= batchSize {
+				batch = flush(batch)
+			}
+		case <-ticker.C:
+			batch = flush(batch)
+		case <-stop:
+			for {
+				select {
+				case event := <-events:
+					batch = appendEvent(batch, event)
+					if len(batch) >= batchSize {
+						batch = flush(batch)
+					}
+				default:
+					flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func appendEvent(batch []message, event *Event) []message {
+	value, err := json.Marshal(event)
+	if err != nil {
+		logger.SysError("failed to marshal usage event: " + err.Error())
+		return batch
+	}
+	return append(batch, message{key: fmt.Sprintf("%d", event.UserId), value: value})
+}
+
+// flush 发送失败时重试一次，仍然失败则丢弃这批事件
+func flush(batch []message) []message {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err = target.publish(ctx, batch)
+		cancel()
+		if err == nil {
+			return batch[:0]
+		}
+	}
+
+	logger.SysError(fmt.Sprintf("failed to publish %d usage events to %s: %s", len(batch), target.name(), err.Error()))
+	return batch[:0]
+}
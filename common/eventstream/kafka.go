@@ -0,0 +1,100 @@
+package eventstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// kafkaPublisher 通过 Kafka REST Proxy (v2) 写入 topic
+type kafkaPublisher struct {
+	endpoint string
+	username string
+	password string
+	client   *http.Client
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+func newKafkaPublisher(topic string) (*kafkaPublisher, error) {
+	restURL := strings.TrimSuffix(viper.GetString("usage_events.kafka.rest_url"), "/")
+	if restURL == "" {
+		return nil, errors.New("usage_events.kafka.rest_url is empty")
+	}
+
+	return &kafkaPublisher{
+		endpoint: restURL + "/topics/" + url.PathEscape(topic),
+		username: viper.GetString("usage_events.kafka.username"),
+		password: viper.GetString("usage_events.kafka.password"),
+		client:   &http.Client{},
+	}, nil
+}
+
+func (p *kafkaPublisher) name() string {
+	return "kafka"
+}
+
+func (p *kafkaPublisher) publish(ctx context.Context, messages []message) error {
+	records := make([]kafkaRecord, 0, len(messages))
+	for _, msg := range messages {
+		records = append(records, kafkaRecord{Key: msg.key, Value: msg.value})
+	}
+	body, err := json.Marshal(map[string]any{"records": records})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+	if p.username != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("kafka rest proxy returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	// 单条记录写入失败时，REST Proxy 仍返回 200，错误在 offsets 中
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil
+	}
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka rest proxy error %d: %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+	return nil
+}
+
+func (p *kafkaPublisher) close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
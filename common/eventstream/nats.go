@@ -0,0 +1,210 @@
+package eventstream
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// natsPublisher 使用 NATS 文本协议发布到 subject，连接断开后在下次发送时重连
+type natsPublisher struct {
+	address  string
+	useTLS   bool
+	subject  string
+	token    string
+	user     string
+	password string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func newNatsPublisher(subject string) (*natsPublisher, error) {
+	rawURL := viper.GetString("usage_events.nats.url")
+	if rawURL == "" {
+		return nil, errors.New("usage_events.nats.url is empty")
+	}
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "nats://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "nats" && u.Scheme != "tls" {
+		return nil, fmt.Errorf("unsupported nats url scheme %s", u.Scheme)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	p := &natsPublisher{
+		address:  address,
+		useTLS:   u.Scheme == "tls",
+		subject:  subject,
+		token:    viper.GetString("usage_events.nats.token"),
+		user:     viper.GetString("usage_events.nats.user"),
+		password: viper.GetString("usage_events.nats.password"),
+	}
+	if u.User != nil && p.user == "" {
+		p.user = u.User.Username()
+		p.password, _ = u.User.Password()
+	}
+	return p, nil
+}
+
+func (p *natsPublisher) name() string {
+	return "nats"
+}
+
+func (p *natsPublisher) publish(ctx context.Context, messages []message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := p.send(ctx, messages); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+// send 写入所有 PUB 后发送 PING，收到 PONG 即表示服务端已处理完之前的命令
+func (p *natsPublisher) send(ctx context.Context, messages []message) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		p.conn.SetDeadline(deadline)
+	} else {
+		p.conn.SetDeadline(time.Time{})
+	}
+
+	writer := bufio.NewWriter(p.conn)
+	for _, msg := range messages {
+		fmt.Fprintf(writer, "PUB %s %d\r\n", p.subject, len(msg.value))
+		writer.Write(msg.value)
+		writer.WriteString("\r\n")
+	}
+	writer.WriteString("PING\r\n")
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return p.waitPong()
+}
+
+func (p *natsPublisher) connect(ctx context.Context) error {
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected nats greeting: %s", strings.TrimSpace(line))
+	}
+
+	var info struct {
+		TLSRequired bool `json:"tls_required"`
+	}
+	json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+	if p.useTLS || info.TLSRequired {
+		host, _, _ := net.SplitHostPort(p.address)
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: host})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tlsConn
+		reader = bufio.NewReader(conn)
+	}
+
+	options := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "one-api",
+		"lang":     "go",
+		"version":  "1.0.0",
+		"protocol": 1,
+	}
+	if p.token != "" {
+		options["auth_token"] = p.token
+	}
+	if p.user != "" {
+		options["user"] = p.user
+		options["pass"] = p.password
+	}
+	connect, _ := json.Marshal(options)
+	if _, err := fmt.Fprintf(conn, "CONNECT %s\r\nPING\r\n", connect); err != nil {
+		conn.Close()
+		return err
+	}
+
+	p.conn = conn
+	p.reader = reader
+	if err := p.waitPong(); err != nil {
+		p.reset()
+		return err
+	}
+	return nil
+}
+
+func (p *natsPublisher) waitPong() error {
+	for {
+		line, err := p.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+func (p *natsPublisher) reset() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn = nil
+	p.reader = nil
+}
+
+func (p *natsPublisher) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.reset()
+	return nil
+}
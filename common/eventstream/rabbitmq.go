@@ -0,0 +1,108 @@
+package eventstream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// rabbitMQPublisher 通过管理插件的 HTTP API 发布到 exchange，routing key 为 topic
+type rabbitMQPublisher struct {
+	endpoint   string
+	routingKey string
+	username   string
+	password   string
+	client     *http.Client
+}
+
+func newRabbitMQPublisher(topic string) (*rabbitMQPublisher, error) {
+	apiURL := strings.TrimSuffix(viper.GetString("usage_events.rabbitmq.url"), "/")
+	if apiURL == "" {
+		return nil, errors.New("usage_events.rabbitmq.url is empty")
+	}
+	vhost := viper.GetString("usage_events.rabbitmq.vhost")
+	if vhost == "" {
+		vhost = "/"
+	}
+	exchange := viper.GetString("usage_events.rabbitmq.exchange")
+	if exchange == "" {
+		// 默认 exchange 在管理 API 中的名称
+		exchange = "amq.default"
+	}
+
+	return &rabbitMQPublisher{
+		endpoint:   fmt.Sprintf("%s/api/exchanges/%s/%s/publish", apiURL, url.PathEscape(vhost), url.PathEscape(exchange)),
+		routingKey: topic,
+		username:   viper.GetString("usage_events.rabbitmq.username"),
+		password:   viper.GetString("usage_events.rabbitmq.password"),
+		client:     &http.Client{},
+	}, nil
+}
+
+func (p *rabbitMQPublisher) name() string {
+	return "rabbitmq"
+}
+
+// publish 管理 API 每次只能发布一条消息
+func (p *rabbitMQPublisher) publish(ctx context.Context, messages []message) error {
+	for _, msg := range messages {
+		if err := p.publishOne(ctx, msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *rabbitMQPublisher) publishOne(ctx context.Context, msg message) error {
+	body, err := json.Marshal(map[string]any{
+		"properties": map[string]any{
+			"content_type":  "application/json",
+			"delivery_mode": 2,
+		},
+		"routing_key":      p.routingKey,
+		"payload":          string(msg.value),
+		"payload_encoding": "string",
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth(p.username, p.password)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("rabbitmq returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result struct {
+		Routed bool `json:"routed"`
+	}
+	if err := json.Unmarshal(respBody, &result); err == nil && !result.Routed {
+		return fmt.Errorf("rabbitmq message not routed, check exchange and routing key %s", p.routingKey)
+	}
+	return nil
+}
+
+func (p *rabbitMQPublisher) close() error {
+	p.client.CloseIdleConnections()
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"one-api/common/config"
+	"one-api/common/i18n"
 	"one-api/common/logger"
 	"one-api/types"
 	"strings"
@@ -88,7 +89,7 @@ func StringErrorWrapperLocal(err string, code string, statusCode int) *types.Ope
 func AbortWithMessage(c *gin.Context, statusCode int, message string) {
 	c.JSON(statusCode, gin.H{
 		"error": gin.H{
-			"message": message,
+			"message": i18n.T(c, message),
 			"type":    "one_hub_error",
 		},
 	})
@@ -105,7 +106,7 @@ func AbortWithErr(c *gin.Context, statusCode int, err error) {
 func APIRespondWithError(c *gin.Context, status int, err error) {
 	c.JSON(status, gin.H{
 		"success": false,
-		"message": err.Error(),
+		"message": i18n.T(c, err.Error()),
 	})
 }
 
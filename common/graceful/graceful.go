@@ -0,0 +1,47 @@
+package graceful
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+var draining atomic.Bool
+
+// 停机前必须完成的后台任务，例如扣费和写入消费日志
+var tasks sync.WaitGroup
+
+// IsDraining 是否正在停机，停机期间不再接受新的中继请求
+func IsDraining() bool {
+	return draining.Load()
+}
+
+// StartDraining 标记开始停机
+func StartDraining() {
+	draining.Store(true)
+}
+
+// Go 在后台运行任务，停机时会等待任务完成
+func Go(f func()) {
+	tasks.Add(1)
+	go func() {
+		defer tasks.Done()
+		f()
+	}()
+}
+
+// Wait 等待所有后台任务完成，超时返回 false
+func Wait(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		tasks.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,354 @@
+package graphql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Resolver 查询根字段的解析函数，返回值按 JSON 序列化后再按选择的字段裁剪
+type Resolver func(args map[string]any) (any, error)
+
+// Schema 只支持查询，根字段由 Resolver 提供，嵌套对象的字段取自 Resolver 返回值的 JSON 字段
+type Schema struct {
+	Query map[string]Resolver
+	// MaxRootFields 单个请求最多执行的根字段数（含别名），0 为不限制
+	MaxRootFields int
+}
+
+type Request struct {
+	Query         string         `json:"query" binding:"required"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type Error struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+type Response struct {
+	Data   *OrderedMap `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// OrderedMap 按查询中字段的顺序输出的 JSON 对象
+type OrderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func newOrderedMap() *OrderedMap {
+	return &OrderedMap{values: make(map[string]any)}
+}
+
+func (m *OrderedMap) Set(key string, value any) {
+	if _, ok := m.values[key]; !ok {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+func (m *OrderedMap) Get(key string) any {
+	return m.values[key]
+}
+
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type executor struct {
+	schema    *Schema
+	document  *Document
+	variables map[string]any
+	errors    []*Error
+}
+
+// Execute 解析并执行查询，根字段出错时该字段为 null，其它字段照常返回
+func (s *Schema) Execute(req *Request) *Response {
+	document, err := Parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	operation, err := selectOperation(document, req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+	if operation.Type != "query" {
+		return &Response{Errors: []*Error{{Message: "only query operations are supported"}}}
+	}
+
+	variables, err := coerceVariables(operation, req.Variables)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	e := &executor{schema: s, document: document, variables: variables}
+	fields, err := e.collectFields(operation.SelectionSet, make(map[string]bool))
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	if s.MaxRootFields > 0 {
+		count := 0
+		for _, key := range fields.keys {
+			if fields.values[key].([]*Field)[0].Name != "__typename" {
+				count++
+			}
+		}
+		if count > s.MaxRootFields {
+			return &Response{Errors: []*Error{{Message: fmt.Sprintf("too many root fields: %d, at most %d are allowed", count, s.MaxRootFields)}}}
+		}
+	}
+
+	data := newOrderedMap()
+	for _, key := range fields.keys {
+		group := fields.values[key].([]*Field)
+		data.Set(key, e.resolveRoot(key, group))
+	}
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func selectOperation(document *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(document.Operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document contains multiple operations")
+		}
+		return document.Operations[0], nil
+	}
+	for _, operation := range document.Operations {
+		if operation.Name == name {
+			return operation, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %s", name)
+}
+
+func coerceVariables(operation *Operation, values map[string]any) (map[string]any, error) {
+	variables := make(map[string]any)
+	for _, definition := range operation.Variables {
+		value, ok := values[definition.Name]
+		if !ok && definition.HasDefault {
+			value, ok = definition.Default, true
+		}
+		if definition.NonNull && (!ok || value == nil) {
+			return nil, fmt.Errorf("variable $%s is required", definition.Name)
+		}
+		if ok {
+			variables[definition.Name] = value
+		}
+	}
+	return variables, nil
+}
+
+func (e *executor) addError(path []any, format string, args ...any) {
+	e.errors = append(e.errors, &Error{Message: fmt.Sprintf(format, args...), Path: path})
+}
+
+// resolveValue 将参数中的变量替换为变量的值，枚举值转为字符串
+func (e *executor) resolveValue(value any) any {
+	switch v := value.(type) {
+	case Variable:
+		return e.variables[string(v)]
+	case EnumValue:
+		return string(v)
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = e.resolveValue(item)
+		}
+		return list
+	case map[string]any:
+		object := make(map[string]any, len(v))
+		for key, item := range v {
+			object[key] = e.resolveValue(item)
+		}
+		return object
+	}
+	return value
+}
+
+func (e *executor) resolveArguments(arguments map[string]any) map[string]any {
+	args := make(map[string]any, len(arguments))
+	for name, value := range arguments {
+		if variable, ok := value.(Variable); ok {
+			if _, set := e.variables[string(variable)]; !set {
+				continue
+			}
+		}
+		args[name] = e.resolveValue(value)
+	}
+	return args
+}
+
+// shouldInclude 处理 @skip 和 @include
+func (e *executor) shouldInclude(directives []*Directive) bool {
+	for _, directive := range directives {
+		condition, _ := e.resolveValue(directive.Arguments["if"]).(bool)
+		switch directive.Name {
+		case "skip":
+			if condition {
+				return false
+			}
+		case "include":
+			if !condition {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// collectFields 展开片段并按结果中的名称合并字段，值为 []*Field
+func (e *executor) collectFields(selections []Selection, visited map[string]bool) (*OrderedMap, error) {
+	fields := newOrderedMap()
+	var collect func(selections []Selection) error
+	collect = func(selections []Selection) error {
+		for _, selection := range selections {
+			switch s := selection.(type) {
+			case *Field:
+				if !e.shouldInclude(s.Directives) {
+					continue
+				}
+				group, _ := fields.Get(s.ResponseKey()).([]*Field)
+				fields.Set(s.ResponseKey(), append(group, s))
+			case *FragmentSpread:
+				if !e.shouldInclude(s.Directives) || visited[s.Name] {
+					continue
+				}
+				fragment, ok := e.document.Fragments[s.Name]
+				if !ok {
+					return fmt.Errorf("unknown fragment %s", s.Name)
+				}
+				visited[s.Name] = true
+				if err := collect(fragment.SelectionSet); err != nil {
+					return err
+				}
+			case *InlineFragment:
+				if !e.shouldInclude(s.Directives) {
+					continue
+				}
+				if err := collect(s.SelectionSet); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	return fields, collect(selections)
+}
+
+func (e *executor) resolveRoot(key string, group []*Field) any {
+	field := group[0]
+	path := []any{key}
+	if field.Name == "__typename" {
+		return "Query"
+	}
+
+	resolver, ok := e.schema.Query[field.Name]
+	if !ok {
+		e.addError(path, "unknown field %s on Query", field.Name)
+		return nil
+	}
+
+	result, err := resolver(e.resolveArguments(field.Arguments))
+	if err != nil {
+		e.addError(path, "%s", err.Error())
+		return nil
+	}
+
+	value, err := toGeneric(result)
+	if err != nil {
+		e.addError(path, "%s", err.Error())
+		return nil
+	}
+	return e.complete(value, group, path)
+}
+
+// toGeneric 将解析结果转换为 JSON 对应的 map、slice 和基本类型
+func toGeneric(result any) (any, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// complete 按选择的子字段裁剪值，对象必须选择子字段，基本类型不能选择子字段
+func (e *executor) complete(value any, group []*Field, path []any) any {
+	var selections []Selection
+	for _, field := range group {
+		selections = append(selections, field.SelectionSet...)
+	}
+	name := group[0].Name
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = e.complete(item, group, append(append([]any{}, path...), i))
+		}
+		return list
+	case map[string]any:
+		if len(selections) == 0 {
+			e.addError(path, "field %s of object type must have a selection of subfields", name)
+			return nil
+		}
+		fields, err := e.collectFields(selections, make(map[string]bool))
+		if err != nil {
+			e.addError(path, "%s", err.Error())
+			return nil
+		}
+		object := newOrderedMap()
+		for _, key := range fields.keys {
+			subgroup := fields.values[key].([]*Field)
+			subpath := append(append([]any{}, path...), key)
+			if subgroup[0].Name == "__typename" {
+				object.Set(key, typeName(name))
+				continue
+			}
+			object.Set(key, e.complete(v[subgroup[0].Name], subgroup, subpath))
+		}
+		return object
+	default:
+		if len(selections) > 0 {
+			e.addError(path, "field %s must not have a selection since it is a scalar", name)
+			return nil
+		}
+		return value
+	}
+}
+
+// typeName 没有类型定义，使用字段名的首字母大写形式作为 __typename
+func typeName(field string) string {
+	if field == "" {
+		return ""
+	}
+	return strings.ToUpper(field[:1]) + field[1:]
+}
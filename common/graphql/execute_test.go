@@ -0,0 +1,213 @@
+package graphql_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"one-api/common/graphql"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testUser struct {
+	Id       int      `json:"id"`
+	Username string   `json:"username"`
+	Group    string   `json:"group"`
+	Tags     []string `json:"tags"`
+	Token    struct {
+		Name string `json:"name"`
+	} `json:"token"`
+}
+
+func newTestSchema(calls *int) *graphql.Schema {
+	users := []*testUser{{Id: 1, Username: "root", Group: "default", Tags: []string{"admin"}}, {Id: 2, Username: "alice", Group: "vip"}}
+	users[0].Token.Name = "main"
+
+	return &graphql.Schema{
+		Query: map[string]graphql.Resolver{
+			"users": func(args map[string]any) (any, error) {
+				*calls++
+				if group, ok := args["group"].(string); ok {
+					var filtered []*testUser
+					for _, user := range users {
+						if user.Group == group {
+							filtered = append(filtered, user)
+						}
+					}
+					return filtered, nil
+				}
+				return users, nil
+			},
+			"user": func(args map[string]any) (any, error) {
+				*calls++
+				id, _ := args["id"].(int64)
+				if id == 0 {
+					if f, ok := args["id"].(float64); ok {
+						id = int64(f)
+					}
+				}
+				for _, user := range users {
+					if int64(user.Id) == id {
+						return user, nil
+					}
+				}
+				return nil, errors.New("user not found")
+			},
+			"count": func(args map[string]any) (any, error) {
+				*calls++
+				return len(users), nil
+			},
+		},
+	}
+}
+
+func executeJSON(t *testing.T, schema *graphql.Schema, req *graphql.Request) string {
+	data, err := json.Marshal(schema.Execute(req))
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]any
+		want      string
+	}{
+		{
+			name:  "fields keep query order",
+			query: `{ count users { username id } }`,
+			want:  `{"data":{"count":2,"users":[{"username":"root","id":1},{"username":"alice","id":2}]}}`,
+		},
+		{
+			name:  "aliases",
+			query: `{ first: user(id: 1) { name: username } second: user(id: 2) { username } }`,
+			want:  `{"data":{"first":{"name":"root"},"second":{"username":"alice"}}}`,
+		},
+		{
+			name:  "fragments",
+			query: `query { user(id: 1) { ...Base ... on User { token { name } } } } fragment Base on User { id tags }`,
+			want:  `{"data":{"user":{"id":1,"tags":["admin"],"token":{"name":"main"}}}}`,
+		},
+		{
+			name:  "recursive fragment is expanded once",
+			query: `{ user(id: 1) { ...A } } fragment A on User { id ...A }`,
+			want:  `{"data":{"user":{"id":1}}}`,
+		},
+		{
+			name:      "variables",
+			query:     `query ($group: String, $id: Int!) { users(group: $group) { username } user(id: $id) { id } }`,
+			variables: map[string]any{"group": "vip", "id": float64(1)},
+			want:      `{"data":{"users":[{"username":"alice"}],"user":{"id":1}}}`,
+		},
+		{
+			name:  "variable defaults",
+			query: `query ($id: Int = 2) { user(id: $id) { username } }`,
+			want:  `{"data":{"user":{"username":"alice"}}}`,
+		},
+		{
+			name:  "unset variables are omitted",
+			query: `query ($group: String) { users(group: $group) { id } }`,
+			want:  `{"data":{"users":[{"id":1},{"id":2}]}}`,
+		},
+		{
+			name:      "skip and include",
+			query:     `query ($yes: Boolean!) { count @skip(if: $yes) user(id: 1) @include(if: $yes) { id group @skip(if: true) } }`,
+			variables: map[string]any{"yes": true},
+			want:      `{"data":{"user":{"id":1}}}`,
+		},
+		{
+			name:  "typename",
+			query: `{ __typename user(id: 1) { __typename id } }`,
+			want:  `{"data":{"__typename":"Query","user":{"__typename":"User","id":1}}}`,
+		},
+		{
+			name:      "operation name",
+			query:     `query A { count } query B { user(id: 1) { id } }`,
+			operation: "B",
+			want:      `{"data":{"user":{"id":1}}}`,
+		},
+		{
+			name:  "resolver error only nulls the field",
+			query: `{ count user(id: 9) { id } }`,
+			want:  `{"data":{"count":2,"user":null},"errors":[{"message":"user not found","path":["user"]}]}`,
+		},
+		{
+			name:  "unknown root field",
+			query: `{ channels { id } }`,
+			want:  `{"data":{"channels":null},"errors":[{"message":"unknown field channels on Query","path":["channels"]}]}`,
+		},
+		{
+			name:  "object without selection",
+			query: `{ user(id: 1) }`,
+			want:  `{"data":{"user":null},"errors":[{"message":"field user of object type must have a selection of subfields","path":["user"]}]}`,
+		},
+		{
+			name:  "scalar with selection",
+			query: `{ user(id: 1) { id { value } } }`,
+			want:  `{"data":{"user":{"id":null}},"errors":[{"message":"field id must not have a selection since it is a scalar","path":["user","id"]}]}`,
+		},
+		{
+			name:  "syntax error",
+			query: `{ users {`,
+			want:  `{"errors":[{"message":"syntax error at 1:10: unexpected end of query"}]}`,
+		},
+		{
+			name:  "mutation",
+			query: `mutation { count }`,
+			want:  `{"errors":[{"message":"only query operations are supported"}]}`,
+		},
+		{
+			name:  "missing operation name",
+			query: `query A { count } query B { count }`,
+			want:  `{"errors":[{"message":"operationName is required when the document contains multiple operations"}]}`,
+		},
+		{
+			name:      "unknown operation",
+			query:     `query A { count }`,
+			operation: "B",
+			want:      `{"errors":[{"message":"unknown operation B"}]}`,
+		},
+		{
+			name:  "required variable",
+			query: `query ($id: Int!) { user(id: $id) { id } }`,
+			want:  `{"errors":[{"message":"variable $id is required"}]}`,
+		},
+		{
+			name:  "unknown fragment",
+			query: `{ ...Missing }`,
+			want:  `{"errors":[{"message":"unknown fragment Missing"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			schema := newTestSchema(&calls)
+			got := executeJSON(t, schema, &graphql.Request{Query: tt.query, OperationName: tt.operation, Variables: tt.variables})
+			assert.JSONEq(t, tt.want, got)
+		})
+	}
+}
+
+func TestExecuteMaxRootFields(t *testing.T) {
+	calls := 0
+	schema := newTestSchema(&calls)
+	schema.MaxRootFields = 2
+
+	// 同一字段的不同别名分别计数，__typename 不计数
+	got := executeJSON(t, schema, &graphql.Request{Query: `{ __typename a: count b: count }`})
+	assert.JSONEq(t, `{"data":{"__typename":"Query","a":2,"b":2}}`, got)
+	assert.Equal(t, 2, calls)
+
+	calls = 0
+	got = executeJSON(t, schema, &graphql.Request{Query: `{ a: count b: count ...F } fragment F on Query { c: count }`})
+	assert.JSONEq(t, `{"errors":[{"message":"too many root fields: 3, at most 2 are allowed"}]}`, got)
+	assert.Equal(t, 0, calls)
+
+	// 相同的响应名称合并为一次调用
+	got = executeJSON(t, schema, &graphql.Request{Query: `{ count count count }`})
+	assert.JSONEq(t, `{"data":{"count":2}}`, got)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,95 @@
+package graphql_test
+
+import (
+	"testing"
+
+	"one-api/common/graphql"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := graphql.Parse(`
+		# 注释和逗号视为空白
+		query Logs($page: Int = 1, $types: [Int!]!, $name: String) @cached {
+			recent: logs(page: $page, type: $types, order: DESC, filter: {name: $name, ids: [1, 2.5, "x", true, null]}) {
+				total_count
+				...LogFields
+				... on Log @include(if: true) { id }
+			}
+		}
+		fragment LogFields on Log { data { model_name } }
+	`)
+	assert.Nil(t, err)
+	assert.Len(t, doc.Operations, 1)
+	assert.Len(t, doc.Fragments, 1)
+
+	operation := doc.Operations[0]
+	assert.Equal(t, "query", operation.Type)
+	assert.Equal(t, "Logs", operation.Name)
+	assert.Equal(t, []*graphql.VariableDefinition{
+		{Name: "page", Default: int64(1), HasDefault: true},
+		{Name: "types", NonNull: true},
+		{Name: "name"},
+	}, operation.Variables)
+
+	field := operation.SelectionSet[0].(*graphql.Field)
+	assert.Equal(t, "recent", field.Alias)
+	assert.Equal(t, "logs", field.Name)
+	assert.Equal(t, "recent", field.ResponseKey())
+	assert.Equal(t, map[string]any{
+		"page":  graphql.Variable("page"),
+		"type":  graphql.Variable("types"),
+		"order": graphql.EnumValue("DESC"),
+		"filter": map[string]any{
+			"name": graphql.Variable("name"),
+			"ids":  []any{int64(1), 2.5, "x", true, nil},
+		},
+	}, field.Arguments)
+
+	assert.Len(t, field.SelectionSet, 3)
+	assert.Equal(t, &graphql.FragmentSpread{Name: "LogFields"}, field.SelectionSet[1])
+	inline := field.SelectionSet[2].(*graphql.InlineFragment)
+	assert.Equal(t, "include", inline.Directives[0].Name)
+	assert.Equal(t, true, inline.Directives[0].Arguments["if"])
+}
+
+func TestParseShorthandAndStrings(t *testing.T) {
+	doc, err := graphql.Parse(`{ a: user(name: "tab\tquote\"中", note: """
+		block "string"
+	""") { id } }`)
+	assert.Nil(t, err)
+	assert.Equal(t, "query", doc.Operations[0].Type)
+
+	field := doc.Operations[0].SelectionSet[0].(*graphql.Field)
+	assert.Equal(t, "tab\tquote\"中", field.Arguments["name"])
+	assert.Equal(t, `block "string"`, field.Arguments["note"])
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"empty", "", "no operation found"},
+		{"only fragment", "fragment F on T { id }", "no operation found"},
+		{"unclosed selection", "{ users { id }", "unexpected end of query"},
+		{"empty selection", "{ users { } }", "selection set cannot be empty"},
+		{"unexpected character", "{ users % }", `unexpected character '%'`},
+		{"unterminated string", `{ user(name: "abc) { id } }`, "syntax error"},
+		{"variable in default", "query ($a: Int = $b) { users }", "variables are not allowed here"},
+		{"fragment named on", "fragment on on T { id } { users }", "fragment cannot be named on"},
+		{"duplicate fragment", "fragment F on T { id } fragment F on T { id } { users }", "duplicate fragment F"},
+		{"missing colon", "query ($a Int) { users }", `unexpected "Int"`},
+		{"position", "{\n  users(page: ) }", "syntax error at 2:15"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := graphql.Parse(tt.query)
+			if assert.NotNil(t, err) {
+				assert.Contains(t, err.Error(), tt.err)
+			}
+		})
+	}
+}
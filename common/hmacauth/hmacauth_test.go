@@ -0,0 +1,149 @@
+package hmacauth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"one-api/common/hmacauth"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "sk-hmac-test-secret"
+
+type signedRequest struct {
+	method    string
+	target    string
+	timestamp string
+	nonce     string
+	body      []byte
+	secret    string
+}
+
+func newSignedRequest(nonce string) *signedRequest {
+	return &signedRequest{
+		method:    http.MethodPost,
+		target:    "/v1/chat/completions?stream=false",
+		timestamp: strconv.FormatInt(time.Now().Unix(), 10),
+		nonce:     nonce,
+		body:      []byte(`{"model":"gpt-4o"}`),
+		secret:    testSecret,
+	}
+}
+
+// build 按字段签名，返回的请求可以再修改以模拟篡改
+func (s *signedRequest) build() *http.Request {
+	r := httptest.NewRequest(s.method, s.target, nil)
+	r.Header.Set(hmacauth.HeaderKeyId, "1")
+	r.Header.Set(hmacauth.HeaderTimestamp, s.timestamp)
+	r.Header.Set(hmacauth.HeaderNonce, s.nonce)
+	r.Header.Set(hmacauth.HeaderSignature, hmacauth.Sign(s.secret, hmacauth.StringToSign(s.method, r.URL.RequestURI(), s.timestamp, s.nonce, s.body)))
+	return r
+}
+
+func offset(d time.Duration) string {
+	return strconv.FormatInt(time.Now().Add(d).Unix(), 10)
+}
+
+func TestVerify(t *testing.T) {
+	viper.Set("hmac_auth.window", 60)
+	t.Cleanup(func() { viper.Set("hmac_auth.window", 0) })
+
+	tests := []struct {
+		name   string
+		modify func(s *signedRequest)
+		tamper func(r *http.Request, body []byte) []byte
+		err    error
+	}{
+		{name: "valid"},
+		{name: "clock behind within window", modify: func(s *signedRequest) { s.timestamp = offset(-50 * time.Second) }},
+		{name: "clock ahead within window", modify: func(s *signedRequest) { s.timestamp = offset(50 * time.Second) }},
+		{name: "expired", modify: func(s *signedRequest) { s.timestamp = offset(-2 * time.Minute) }, err: hmacauth.ErrExpired},
+		{name: "clock ahead beyond window", modify: func(s *signedRequest) { s.timestamp = offset(2 * time.Minute) }, err: hmacauth.ErrExpired},
+		{name: "invalid timestamp", modify: func(s *signedRequest) { s.timestamp = "yesterday" }, err: hmacauth.ErrExpired},
+		{name: "millisecond timestamp", modify: func(s *signedRequest) { s.timestamp = strconv.FormatInt(time.Now().UnixMilli(), 10) }, err: hmacauth.ErrExpired},
+		{name: "short nonce", modify: func(s *signedRequest) { s.nonce = "short" }, err: hmacauth.ErrInvalidNonce},
+		{name: "long nonce", modify: func(s *signedRequest) { s.nonce = strings.Repeat("n", 65) }, err: hmacauth.ErrInvalidNonce},
+		{name: "wrong secret", modify: func(s *signedRequest) { s.secret = testSecret + "x" }, err: hmacauth.ErrSignature},
+		{name: "empty body signed", modify: func(s *signedRequest) { s.body = nil }, err: hmacauth.ErrSignature},
+		{
+			name: "body tampered",
+			tamper: func(r *http.Request, body []byte) []byte {
+				return []byte(`{"model":"gpt-4o","max_tokens":100000}`)
+			},
+			err: hmacauth.ErrSignature,
+		},
+		{
+			name: "body truncated",
+			tamper: func(r *http.Request, body []byte) []byte {
+				return body[:len(body)-1]
+			},
+			err: hmacauth.ErrSignature,
+		},
+		{
+			name: "query tampered",
+			tamper: func(r *http.Request, body []byte) []byte {
+				r.URL.RawQuery = "stream=true"
+				return body
+			},
+			err: hmacauth.ErrSignature,
+		},
+		{
+			name: "method tampered",
+			tamper: func(r *http.Request, body []byte) []byte {
+				r.Method = http.MethodPut
+				return body
+			},
+			err: hmacauth.ErrSignature,
+		},
+		{
+			name: "missing signature",
+			tamper: func(r *http.Request, body []byte) []byte {
+				r.Header.Del(hmacauth.HeaderSignature)
+				return body
+			},
+			err: hmacauth.ErrSignature,
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSignedRequest("verify-nonce-" + strconv.Itoa(i))
+			if tt.modify != nil {
+				tt.modify(s)
+			}
+			r := s.build()
+			body := []byte(`{"model":"gpt-4o"}`)
+			if tt.tamper != nil {
+				body = tt.tamper(r, body)
+			}
+			assert.Equal(t, tt.err, hmacauth.Verify(r, body, "1", testSecret))
+		})
+	}
+}
+
+func TestVerifyReplay(t *testing.T) {
+	s := newSignedRequest("replay-nonce-1")
+	assert.Nil(t, hmacauth.Verify(s.build(), s.body, "1", testSecret))
+	assert.Equal(t, hmacauth.ErrReplayed, hmacauth.Verify(s.build(), s.body, "1", testSecret))
+
+	// nonce 按令牌区分
+	assert.Nil(t, hmacauth.Verify(s.build(), s.body, "2", testSecret))
+
+	// 签名错误的请求不占用 nonce
+	wrong := newSignedRequest("replay-nonce-2")
+	wrong.secret = "sk-wrong"
+	assert.Equal(t, hmacauth.ErrSignature, hmacauth.Verify(wrong.build(), wrong.body, "1", testSecret))
+	right := newSignedRequest("replay-nonce-2")
+	assert.Nil(t, hmacauth.Verify(right.build(), right.body, "1", testSecret))
+}
+
+func TestSigned(t *testing.T) {
+	s := newSignedRequest("signed-nonce-1")
+	assert.True(t, hmacauth.Signed(s.build()))
+	assert.False(t, hmacauth.Signed(httptest.NewRequest(http.MethodGet, "/v1/models", nil)))
+}
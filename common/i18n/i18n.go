@@ -0,0 +1,137 @@
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+const (
+	LangZh = "zh"
+	LangEn = "en"
+
+	// 令牌设置的语言，由令牌鉴权写入
+	ContextKeyTokenLanguage = "token_language"
+)
+
+// SupportedLanguages 目录中提供的语言
+var SupportedLanguages = []string{LangZh, LangEn}
+
+// messagePattern 带 %s、%d、%v 占位符的消息，按顺序替换参数
+type messagePattern struct {
+	regex        *regexp.Regexp
+	translations map[string]string
+}
+
+var (
+	placeholderRegex = regexp.MustCompile(`%[sdv]`)
+	patterns         []*messagePattern
+)
+
+func init() {
+	for message, translations := range catalog {
+		if !placeholderRegex.MatchString(message) {
+			continue
+		}
+		parts := placeholderRegex.Split(message, -1)
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		patterns = append(patterns, &messagePattern{
+			regex:        regexp.MustCompile("^" + strings.Join(parts, "(.+?)") + "$"),
+			translations: translations,
+		})
+	}
+}
+
+// NormalizeLanguage 将 en-US、zh-CN 等语言标签转换为支持的语言，不支持时返回空
+func NormalizeLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	base, _, _ := strings.Cut(tag, "-")
+	base, _, _ = strings.Cut(base, "_")
+	for _, lang := range SupportedLanguages {
+		if base == lang {
+			return lang
+		}
+	}
+	return ""
+}
+
+// ParseAcceptLanguage 按 Accept-Language 的顺序返回第一个支持的语言，忽略 q 值
+func ParseAcceptLanguage(header string) string {
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		if lang := NormalizeLanguage(tag); lang != "" {
+			return lang
+		}
+	}
+	return ""
+}
+
+// Language 请求使用的语言，依次为令牌设置、Accept-Language 和 i18n.default_language，均未设置时返回空，消息保持原样
+func Language(c *gin.Context) string {
+	if lang := NormalizeLanguage(c.GetString(ContextKeyTokenLanguage)); lang != "" {
+		return lang
+	}
+	if lang := ParseAcceptLanguage(c.GetHeader("Accept-Language")); lang != "" {
+		return lang
+	}
+	return NormalizeLanguage(viper.GetString("i18n.default_language"))
+}
+
+// Translate 将消息翻译为指定语言，目录中没有的消息原样返回
+// 形如 "前缀：详情" 或 "前缀: 详情" 的消息只翻译前缀
+func Translate(lang, message string) string {
+	if lang == "" || message == "" {
+		return message
+	}
+	if translated, ok := translate(lang, message); ok {
+		return translated
+	}
+
+	for _, sep := range []string{"：", ": "} {
+		prefix, detail, found := strings.Cut(message, sep)
+		if !found {
+			continue
+		}
+		if translated, ok := translate(lang, prefix); ok {
+			if lang == LangZh {
+				return translated + "：" + detail
+			}
+			return translated + ": " + detail
+		}
+	}
+	return message
+}
+
+func translate(lang, message string) (string, bool) {
+	if translations, ok := catalog[message]; ok {
+		translated, ok := translations[lang]
+		return translated, ok
+	}
+
+	for _, pattern := range patterns {
+		matches := pattern.regex.FindStringSubmatch(message)
+		if matches == nil {
+			continue
+		}
+		format, ok := pattern.translations[lang]
+		if !ok {
+			return "", false
+		}
+		args := make([]any, 0, len(matches)-1)
+		for _, match := range matches[1:] {
+			args = append(args, match)
+		}
+		return fmt.Sprintf(placeholderRegex.ReplaceAllString(format, "%s"), args...), true
+	}
+	return "", false
+}
+
+// T 按请求的语言翻译消息
+func T(c *gin.Context, message string) string {
+	return Translate(Language(c), message)
+}
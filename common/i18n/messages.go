@@ -0,0 +1,81 @@
+package i18n
+
+// catalog 消息目录，键为代码中的原始消息，值为各语言的翻译
+// 带占位符的消息按参数顺序翻译，翻译中的占位符数量和顺序必须与原始消息一致
+var catalog = map[string]map[string]string{
+	// 令牌与鉴权
+	"无效的令牌":                        {LangEn: "Invalid token"},
+	"令牌不存在":                        {LangEn: "Token does not exist"},
+	"令牌状态不可用":                      {LangEn: "Token is not available"},
+	"令牌已过期":                        {LangEn: "Token has expired"},
+	"令牌额度已用尽":                      {LangEn: "Token quota has been used up"},
+	"令牌额度不足":                       {LangEn: "Insufficient token quota"},
+	"获取令牌额度失败":                     {LangEn: "Failed to get token quota"},
+	"该令牌必须使用签名认证":                  {LangEn: "This token requires signed requests"},
+	"必须使用客户端证书访问":                  {LangEn: "A client certificate is required"},
+	"客户端证书未绑定令牌":                   {LangEn: "The client certificate is not bound to a token"},
+	"令牌与客户端证书不匹配":                  {LangEn: "The token does not match the client certificate"},
+	"试用令牌请求过于频繁，请稍后再试":             {LangEn: "Too many requests for the trial token, please try again later"},
+	"当前 IP 已被禁止访问":                 {LangEn: "Access from the current IP is forbidden"},
+	"无权进行此操作，未登录且未提供 access token": {LangEn: "Unauthorized: not logged in and no access token provided"},
+	"无权进行此操作，权限不足":                 {LangEn: "Unauthorized: insufficient permissions"},
+	"用户已被封禁":                       {LangEn: "The user has been banned"},
+
+	// 用户与额度
+	"用户额度不足": {LangEn: "Insufficient user quota"},
+	"用户名或密码错误，或用户已被封禁": {LangEn: "Incorrect username or password, or the user has been banned"},
+	"用户名或密码为空":         {LangEn: "Username or password is empty"},
+	"用户名已存在！":          {LangEn: "Username already exists!"},
+	"没有找到用户！":          {LangEn: "User not found!"},
+	"邮箱地址已被占用":         {LangEn: "The email address is already taken"},
+	"该邮箱地址未注册":         {LangEn: "The email address is not registered"},
+	"无效的参数":            {LangEn: "Invalid parameters"},
+	"管理员关闭了密码登录":       {LangEn: "Password login has been disabled by the administrator"},
+	"管理员关闭了新用户注册":      {LangEn: "New user registration has been disabled by the administrator"},
+	"验证码错误或已过期":        {LangEn: "The verification code is incorrect or has expired"},
+	"重置链接非法或已过期":       {LangEn: "The reset link is invalid or has expired"},
+	"quota 不能为负数！":     {LangEn: "quota cannot be negative!"},
+	"id 为空！":           {LangEn: "id is empty!"},
+
+	// 分组、渠道与模型
+	"分组不存在":                  {LangEn: "Group does not exist"},
+	"分组为空":                   {LangEn: "Group is empty"},
+	"分组 %s 不存在":              {LangEn: "Group %s does not exist"},
+	"当前分组没有可用的渠道":            {LangEn: "No channels are available in the current group"},
+	"当前分组 %s 下对于模型 %s 无可用渠道": {LangEn: "No channels are available in group %s for model %s"},
+	"无可用渠道":                  {LangEn: "No channels available"},
+	"渠道不存在":                  {LangEn: "Channel does not exist"},
+	"该渠道已被禁用":                {LangEn: "This channel has been disabled"},
+	"无效的渠道 Id":               {LangEn: "Invalid channel ID"},
+	"必须指定渠道":                 {LangEn: "A channel must be specified"},
+	"普通用户不支持指定渠道":            {LangEn: "Regular users cannot specify a channel"},
+	"获取渠道信息失败，请联系管理员，渠道ID：%d":  {LangEn: "Failed to get channel information, please contact the administrator, channel ID: %d"},
+	"模型 %s 未配置价格，暂不可用":         {LangEn: "Model %s has no price configured and is currently unavailable"},
+	"模型 %s 指定的渠道均不可用":          {LangEn: "None of the channels specified for model %s are available"},
+	"候选模型过多":                   {LangEn: "Too many candidate models"},
+	"model 和 models 不能同时为空":    {LangEn: "model and models cannot both be empty"},
+	"messages 和 prompt 不能同时为空": {LangEn: "messages and prompt cannot both be empty"},
+
+	// 请求与上游
+	"无效的请求":              {LangEn: "Invalid request"},
+	"无效的请求, 无法解析模型":      {LangEn: "Invalid request, unable to parse the model"},
+	"无效的加速模式":            {LangEn: "Invalid speed mode"},
+	"请求上游地址失败":           {LangEn: "Failed to request the upstream"},
+	"当前分组上游负载已饱和，请稍后再试":  {LangEn: "The upstream for the current group is saturated, please try again later"},
+	"上游负载已饱和，请稍后再试":      {LangEn: "The upstream is saturated, please try again later"},
+	"重试超时，上游负载已饱和，请稍后再试": {LangEn: "Retry timed out, the upstream is saturated, please try again later"},
+	"当前分组负载已饱和，请稍后再试，或升级账户以提升服务质量。": {LangEn: "The current group is saturated. Please try again later or upgrade your account for better service."},
+	"当前分组的并发请求数达到上限，请稍后再试。":         {LangEn: "The current group has reached its concurrent request limit, please try again later."},
+
+	// 英文原始消息
+	"channel not implemented":                         {LangZh: "渠道未实现该接口"},
+	"upstream request timed out":                      {LangZh: "上游请求超时"},
+	"server is shutting down, please retry":           {LangZh: "服务正在关闭，请重试"},
+	"API requests are not allowed":                    {LangZh: "不允许 API 请求"},
+	"Not Found":                                       {LangZh: "未找到"},
+	"invalid type":                                    {LangZh: "无效的类型"},
+	"invalid status":                                  {LangZh: "无效的状态"},
+	"request body exceeds the limit of %d KB":         {LangZh: "请求体超过 %d KB 的限制"},
+	"user id is empty":                                {LangZh: "用户 id 为空"},
+	"Provider API error: bad response status code %s": {LangZh: "上游接口错误：响应状态码 %s"},
+}
@@ -0,0 +1,90 @@
+package image
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const imageCacheKey = "image-fetch:%s"
+
+const (
+	defaultImageFetchTimeout = 15 * time.Second
+	defaultImageFetchMaxSize = 20 * 1024 * 1024
+)
+
+func imageFetchTimeout() time.Duration {
+	if timeout := viper.GetInt("image_fetch.timeout"); timeout > 0 {
+		return time.Duration(timeout) * time.Second
+	}
+	return defaultImageFetchTimeout
+}
+
+func imageFetchMaxSize() int64 {
+	if maxSize := viper.GetInt64("image_fetch.max_size"); maxSize > 0 {
+		return maxSize * 1024 * 1024
+	}
+	return defaultImageFetchMaxSize
+}
+
+// checkImageHost 配置了 image_fetch.allowed_hosts 时只下载列表中的域名，*.example.com 匹配所有子域名
+func checkImageHost(rawURL string) error {
+	allowedHosts := viper.GetStringSlice("image_fetch.allowed_hosts")
+	if len(allowedHosts) == 0 {
+		return nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, allowed := range allowedHosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if host == allowed {
+			return nil
+		}
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok && strings.HasSuffix(host, suffix) {
+			return nil
+		}
+	}
+	return fmt.Errorf("image host %s is not allowed", host)
+}
+
+func imageCacheKeyOf(rawURL string) string {
+	sum := sha256.Sum256([]byte(rawURL))
+	return fmt.Sprintf(imageCacheKey, hex.EncodeToString(sum[:]))
+}
+
+// getCachedImage 从 Redis 读取最近下载过的图片，未开启 Redis 时不缓存
+func getCachedImage(rawURL string) (mimeType string, data string, ok bool) {
+	if !config.RedisEnabled || viper.GetInt("image_fetch.cache_ttl") <= 0 {
+		return "", "", false
+	}
+
+	value, err := redis.RedisGet(imageCacheKeyOf(rawURL))
+	if err != nil {
+		return "", "", false
+	}
+	mimeType, data, ok = strings.Cut(value, "\n")
+	return
+}
+
+func setCachedImage(rawURL, mimeType, data string) {
+	ttl := viper.GetInt("image_fetch.cache_ttl")
+	if !config.RedisEnabled || ttl <= 0 {
+		return
+	}
+
+	if err := redis.RedisSet(imageCacheKeyOf(rawURL), mimeType+"\n"+data, time.Duration(ttl)*time.Second); err != nil {
+		logger.SysError("failed to cache image: " + err.Error())
+	}
+}
@@ -3,6 +3,7 @@ package image
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"one-api/common/config"
 	"one-api/common/utils"
@@ -17,8 +18,6 @@ var ImageHttpClients = &http.Client{
 	Timeout: 15 * time.Second,
 }
 
-var maxFileSize int64 = 20 * 1024 * 1024 // 20MB
-
 type CFRequest struct {
 	Action string `json:"action"`
 	APIKey string `json:"api_key"`
@@ -53,11 +52,18 @@ func RequestFile(url, action string) (*http.Response, error) {
 		return nil, err
 	}
 
-	response, err := ImageHttpClients.Do(res)
+	client := *ImageHttpClients
+	client.Timeout = imageFetchTimeout()
+	response, err := client.Do(res)
 	if err != nil {
 		return nil, err
 	}
 
+	maxFileSize := imageFetchMaxSize()
+	if response.ContentLength > maxFileSize {
+		response.Body.Close()
+		return nil, fmt.Errorf("file size exceeds %d bytes", maxFileSize)
+	}
 	response.Body = http.MaxBytesReader(nil, response.Body, maxFileSize)
 
 	if response.StatusCode != http.StatusOK && config.CFWorkerImageUrl != "" {
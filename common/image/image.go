@@ -26,6 +26,23 @@ func GetImageFromUrl(url string) (mimeType string, data string, err error) {
 		return "", "", errors.New("invalid image url")
 	}
 
+	if err = checkImageHost(url); err != nil {
+		return
+	}
+
+	// 同一会话的多轮请求会重复携带相同的图片
+	if mimeType, data, ok := getCachedImage(url); ok {
+		return mimeType, data, nil
+	}
+
+	mimeType, data, err = fetchImage(url)
+	if err == nil {
+		setCachedImage(url, mimeType, data)
+	}
+	return
+}
+
+func fetchImage(url string) (mimeType string, data string, err error) {
 	resp, err := RequestFile(url, "base64")
 	if err != nil {
 		return
@@ -40,7 +57,7 @@ func GetImageFromUrl(url string) (mimeType string, data string, err error) {
 		}
 		mimeType = resp.Header.Get("Content-Type")
 		if mimeType == "application/octet-stream" {
-			firstBytes := buffer.Bytes()[:512]
+			firstBytes := buffer.Bytes()[:min(buffer.Len(), 512)]
 			actualMime := http.DetectContentType(firstBytes)
 			mimeType = actualMime
 		}
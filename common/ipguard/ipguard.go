@@ -0,0 +1,240 @@
+package ipguard
+
+import (
+	"context"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"one-api/model"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	failureKeyPrefix = "ip_guard:fail:"
+	banKeyPrefix     = "ip_guard:ban:"
+)
+
+var (
+	enabled     bool
+	window      time.Duration
+	maxFailures int
+	banDuration time.Duration
+)
+
+// Ban 自动封禁记录
+type Ban struct {
+	IP        string `json:"ip"`
+	Reason    string `json:"reason"`
+	ExpiredAt int64  `json:"expired_at"`
+}
+
+type failure struct {
+	count   int
+	resetAt time.Time
+}
+
+// 未启用 Redis 时只在本实例内生效
+var memoryStore = struct {
+	sync.Mutex
+	failures map[string]*failure
+	bans     map[string]*Ban
+}{
+	failures: make(map[string]*failure),
+	bans:     make(map[string]*Ban),
+}
+
+var increaseFailureScript = redis.NewScript(`
+	local count = redis.call("INCR", KEYS[1])
+	if count == 1 then
+		redis.call("EXPIRE", KEYS[1], ARGV[1])
+	end
+	return count
+`)
+
+// Init 根据配置文件 ip_guard 启用失败计数和自动封禁
+func Init() {
+	enabled = viper.GetBool("ip_guard.enable")
+	if !enabled {
+		return
+	}
+
+	window = time.Duration(viper.GetInt("ip_guard.window")) * time.Second
+	maxFailures = viper.GetInt("ip_guard.max_failures")
+	banDuration = time.Duration(viper.GetInt("ip_guard.ban_duration")) * time.Second
+	if window <= 0 || maxFailures <= 0 || banDuration <= 0 {
+		logger.SysError("invalid ip_guard config, automatic ban disabled")
+		enabled = false
+		return
+	}
+
+	if !config.RedisEnabled {
+		go clearExpired()
+	}
+	logger.SysLog(fmt.Sprintf("ip guard enabled: %d failures in %s bans for %s", maxFailures, window, banDuration))
+}
+
+// RecordFailure 记录一次失败请求，窗口内失败次数达到阈值时临时封禁
+func RecordFailure(ip, reason string) {
+	if !enabled || ip == "" || model.IPRulesInstance.IsAllowed(ip) {
+		return
+	}
+
+	count, err := increaseFailure(ip)
+	if err != nil {
+		logger.SysError("failed to record ip failure: " + err.Error())
+		return
+	}
+
+	if count < maxFailures {
+		return
+	}
+
+	if err := ban(ip, reason); err != nil {
+		logger.SysError("failed to ban ip: " + err.Error())
+		return
+	}
+	logger.SysLog(fmt.Sprintf("ip %s banned for %s: %d failures, last reason: %s", ip, banDuration, count, reason))
+}
+
+func increaseFailure(ip string) (int, error) {
+	if config.RedisEnabled {
+		count, err := increaseFailureScript.Run(context.Background(), redis.GetRedisClient(), []string{failureKeyPrefix + ip}, int(window.Seconds())).Int()
+		return count, err
+	}
+
+	memoryStore.Lock()
+	defer memoryStore.Unlock()
+
+	now := time.Now()
+	record, ok := memoryStore.failures[ip]
+	if !ok || now.After(record.resetAt) {
+		record = &failure{resetAt: now.Add(window)}
+		memoryStore.failures[ip] = record
+	}
+	record.count++
+	return record.count, nil
+}
+
+func ban(ip, reason string) error {
+	expiredAt := time.Now().Add(banDuration).Unix()
+
+	if config.RedisEnabled {
+		value := strconv.FormatInt(expiredAt, 10) + "|" + reason
+		if err := redis.RedisSet(banKeyPrefix+ip, value, banDuration); err != nil {
+			return err
+		}
+		return redis.RedisDel(failureKeyPrefix + ip)
+	}
+
+	memoryStore.Lock()
+	defer memoryStore.Unlock()
+
+	memoryStore.bans[ip] = &Ban{IP: ip, Reason: reason, ExpiredAt: expiredAt}
+	delete(memoryStore.failures, ip)
+	return nil
+}
+
+// IsBanned IP 是否处于自动封禁中
+func IsBanned(ip string) bool {
+	if !enabled || ip == "" {
+		return false
+	}
+
+	if config.RedisEnabled {
+		exists, err := redis.RedisExists(banKeyPrefix + ip)
+		if err != nil {
+			logger.SysError("failed to check ip ban: " + err.Error())
+			return false
+		}
+		return exists
+	}
+
+	memoryStore.Lock()
+	defer memoryStore.Unlock()
+
+	record, ok := memoryStore.bans[ip]
+	return ok && record.ExpiredAt > time.Now().Unix()
+}
+
+// Unban 解除自动封禁并清空失败计数
+func Unban(ip string) error {
+	if config.RedisEnabled {
+		if err := redis.RedisDel(banKeyPrefix + ip); err != nil {
+			return err
+		}
+		return redis.RedisDel(failureKeyPrefix + ip)
+	}
+
+	memoryStore.Lock()
+	defer memoryStore.Unlock()
+
+	delete(memoryStore.bans, ip)
+	delete(memoryStore.failures, ip)
+	return nil
+}
+
+// ListBans 返回当前所有自动封禁
+func ListBans() ([]*Ban, error) {
+	bans := make([]*Ban, 0)
+	if !enabled {
+		return bans, nil
+	}
+
+	if !config.RedisEnabled {
+		memoryStore.Lock()
+		defer memoryStore.Unlock()
+
+		now := time.Now().Unix()
+		for _, record := range memoryStore.bans {
+			if record.ExpiredAt > now {
+				bans = append(bans, record)
+			}
+		}
+		return bans, nil
+	}
+
+	ctx := context.Background()
+	client := redis.GetRedisClient()
+	iter := client.Scan(ctx, 0, banKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		value, err := client.Get(ctx, key).Result()
+		if err != nil {
+			continue
+		}
+
+		record := &Ban{IP: strings.TrimPrefix(key, banKeyPrefix)}
+		expiredAt, reason, _ := strings.Cut(value, "|")
+		record.ExpiredAt, _ = strconv.ParseInt(expiredAt, 10, 64)
+		record.Reason = reason
+		bans = append(bans, record)
+	}
+
+	return bans, iter.Err()
+}
+
+func clearExpired() {
+	for {
+		time.Sleep(time.Minute)
+
+		now := time.Now()
+		memoryStore.Lock()
+		for ip, record := range memoryStore.failures {
+			if now.After(record.resetAt) {
+				delete(memoryStore.failures, ip)
+			}
+		}
+		for ip, record := range memoryStore.bans {
+			if record.ExpiredAt <= now.Unix() {
+				delete(memoryStore.bans, ip)
+			}
+		}
+		memoryStore.Unlock()
+	}
+}
@@ -0,0 +1,88 @@
+package ipguard
+
+import (
+	"context"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const signupKeyPrefix = "ip_guard:signup:"
+
+// 未启用 Redis 时只在本实例内计数
+var signupStore = struct {
+	sync.Mutex
+	records map[string]*failure
+}{
+	records: make(map[string]*failure),
+}
+
+func signupWindow() time.Duration {
+	duration := config.RegisterIPLimitDuration
+	if duration <= 0 {
+		duration = 86400
+	}
+	return time.Duration(duration) * time.Second
+}
+
+// AllowSignup IP 在限制时间内的注册数是否未达到上限
+func AllowSignup(ip string) bool {
+	if config.RegisterIPLimitNum <= 0 || ip == "" {
+		return true
+	}
+
+	if config.RedisEnabled {
+		value, err := redis.RedisGet(signupKeyPrefix + ip)
+		if err != nil {
+			// key 不存在时同样返回错误
+			return true
+		}
+		count, _ := strconv.Atoi(value)
+		return count < config.RegisterIPLimitNum
+	}
+
+	signupStore.Lock()
+	defer signupStore.Unlock()
+
+	record, ok := signupStore.records[ip]
+	if !ok || time.Now().After(record.resetAt) {
+		return true
+	}
+	return record.count < config.RegisterIPLimitNum
+}
+
+// RecordSignup 记录一次成功注册
+func RecordSignup(ip string) {
+	if config.RegisterIPLimitNum <= 0 || ip == "" {
+		return
+	}
+
+	window := signupWindow()
+	if config.RedisEnabled {
+		err := increaseFailureScript.Run(context.Background(), redis.GetRedisClient(), []string{signupKeyPrefix + ip}, int(window.Seconds())).Err()
+		if err != nil {
+			logger.SysError("failed to record signup: " + err.Error())
+		}
+		return
+	}
+
+	signupStore.Lock()
+	defer signupStore.Unlock()
+
+	now := time.Now()
+	for key, record := range signupStore.records {
+		if now.After(record.resetAt) {
+			delete(signupStore.records, key)
+		}
+	}
+
+	record, ok := signupStore.records[ip]
+	if !ok {
+		record = &failure{resetAt: now.Add(window)}
+		signupStore.records[ip] = record
+	}
+	record.count++
+}
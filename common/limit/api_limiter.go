@@ -2,6 +2,8 @@ package limit
 
 import (
 	"one-api/common/config"
+	"one-api/common/redis"
+	"sync"
 	"time"
 )
 
@@ -21,33 +23,67 @@ const (
 func NewAPILimiter(rpm int) RateLimiter {
 	// 如果Redis未启用，使用内存限流器
 	if !config.RedisEnabled {
-		if rpm < RPMThreshold {
-			// 对于低RPM，使用固定窗口方式
-			return NewMemoryLimiter(rpm, rpm, window, false)
-		} else {
-			// 对于高RPM，使用令牌桶方式
-			ratePerSecond := float64(rpm) / 60
-			return NewMemoryLimiter(int(ratePerSecond), rpm, window, true)
-		}
+		return newMemoryAPILimiter(rpm)
 	}
 
-	// Redis启用时，使用Redis限流器
+	// Redis启用时，使用Redis限流器，Redis暂时不可用时使用内存限流器
 	if rpm < RPMThreshold {
 		// 如果是rpm设定值较小，说明并发较小，使用固定窗口
-		return NewCountLimiter(rpm, rpm, window)
+		return &FallbackLimiter{primary: NewCountLimiter(rpm, rpm, window), rpm: rpm}
 	}
 	// 将RPM转换为每秒速率
 	ratePerSecond := float64(rpm) / 60
 	burst := int(ratePerSecond * TokenBurstMultiplier)
-	return NewTokenLimiter(
+	return &FallbackLimiter{primary: NewTokenLimiter(
 		int(ratePerSecond),
 		rpm,
 		burst,
-	)
+	), rpm: rpm}
 	// 如果是rpm设定值较大，说明并发较大，限流敏感，使用滑动窗口灵活限流
 	//return NewSlidingWindowLimiter(rpm, rpm, window)
 }
 
+func newMemoryAPILimiter(rpm int) *MemoryLimiter {
+	if rpm < RPMThreshold {
+		// 对于低RPM，使用固定窗口方式
+		return NewMemoryLimiter(rpm, rpm, window, false)
+	}
+	// 对于高RPM，使用令牌桶方式
+	ratePerSecond := float64(rpm) / 60
+	return NewMemoryLimiter(int(ratePerSecond), rpm, window, true)
+}
+
+// FallbackLimiter Redis 可用时使用 Redis 限流器，不可用时改用本实例的内存限流器
+type FallbackLimiter struct {
+	primary RateLimiter
+	rpm     int
+
+	localOnce sync.Once
+	local     *MemoryLimiter // 第一次降级时创建
+}
+
+func (l *FallbackLimiter) current() RateLimiter {
+	if redis.Available() {
+		return l.primary
+	}
+	l.localOnce.Do(func() {
+		l.local = newMemoryAPILimiter(l.rpm)
+	})
+	return l.local
+}
+
+func (l *FallbackLimiter) Allow(keyPrefix string) bool {
+	return l.current().Allow(keyPrefix)
+}
+
+func (l *FallbackLimiter) AllowN(keyPrefix string, n int) bool {
+	return l.current().AllowN(keyPrefix, n)
+}
+
+func (l *FallbackLimiter) GetCurrentRate(keyPrefix string) (int, error) {
+	return l.current().GetCurrentRate(keyPrefix)
+}
+
 // GetMaxRate 获取限流器的最大速率（rpm）
 func GetMaxRate(limiter RateLimiter) int {
 	switch l := limiter.(type) {
@@ -59,6 +95,8 @@ func GetMaxRate(limiter RateLimiter) int {
 		return l.rpm
 	case *MemoryLimiter:
 		return l.rpm
+	case *FallbackLimiter:
+		return l.rpm
 	default:
 		return 0
 	}
@@ -0,0 +1,80 @@
+package limit
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"one-api/common/utils"
+	"sync"
+	"time"
+)
+
+const (
+	concurrencyFormat = "{%s}:concurrency"
+	// 请求超过该时间未释放时视为已结束，避免实例崩溃后永久占用
+	concurrencyTTL = 30 * time.Minute
+)
+
+var (
+	//go:embed concurrencyscript.lua
+	concurrencyLuaScript string
+	concurrencyScript    = redis.NewScript(concurrencyLuaScript)
+
+	concurrencyLock     sync.Mutex
+	concurrencyCounters = make(map[string]int)
+)
+
+// AcquireConcurrency 占用一个并发名额，超过 max 时返回 false，成功时需要调用 release 释放
+// 启用 Redis 时所有实例共享名额，Redis 暂时不可用时按本实例计数，Redis 出错时放行
+func AcquireConcurrency(keyPrefix string, max int) (release func(), ok bool) {
+	if config.RedisEnabled && redis.Available() {
+		return acquireRedisConcurrency(keyPrefix, max)
+	}
+
+	concurrencyLock.Lock()
+	defer concurrencyLock.Unlock()
+	if concurrencyCounters[keyPrefix] >= max {
+		return nil, false
+	}
+	concurrencyCounters[keyPrefix]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			concurrencyLock.Lock()
+			defer concurrencyLock.Unlock()
+			if concurrencyCounters[keyPrefix]--; concurrencyCounters[keyPrefix] <= 0 {
+				delete(concurrencyCounters, keyPrefix)
+			}
+		})
+	}, true
+}
+
+func acquireRedisConcurrency(keyPrefix string, max int) (release func(), ok bool) {
+	key := fmt.Sprintf(concurrencyFormat, keyPrefix)
+	member := utils.GetUUID()
+	result, err := redis.ScriptRunCtx(context.Background(),
+		concurrencyScript,
+		[]string{key},
+		time.Now().UnixMilli(),        // ARGV[1]: now
+		concurrencyTTL.Milliseconds(), // ARGV[2]: ttl
+		max,                           // ARGV[3]: max concurrency
+		member,                        // ARGV[4]: member
+	)
+	if err != nil {
+		logger.SysError("Redis acquire concurrency error: " + err.Error())
+		return func() {}, true
+	}
+	if result.(int64) != 1 {
+		return nil, false
+	}
+
+	return func() {
+		if err := redis.GetRedisClient().ZRem(context.Background(), key, member).Err(); err != nil {
+			logger.SysError("Redis release concurrency error: " + err.Error())
+		}
+	}, true
+}
@@ -0,0 +1,16 @@
+-- KEYS[1] as semaphore_key
+-- ARGV[1] as now (in milliseconds)
+-- ARGV[2] as ttl (in milliseconds)
+-- ARGV[3] as max concurrency
+-- ARGV[4] as member
+
+-- 清理超时未释放的请求（实例崩溃等情况）
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', tonumber(ARGV[1]) - tonumber(ARGV[2]))
+
+if redis.call('ZCARD', KEYS[1]) < tonumber(ARGV[3]) then
+    redis.call('ZADD', KEYS[1], ARGV[1], ARGV[4])
+    redis.call('PEXPIRE', KEYS[1], ARGV[2])
+    return 1
+end
+
+return 0
@@ -0,0 +1,159 @@
+package limit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// 其它实例释放名额时本实例收不到通知，排在队首的请求定期重试
+const fairQueueRetryInterval = 100 * time.Millisecond
+
+// fairQueue 并发已满时按用户公平排队（start-time fair queuing）
+// 每个请求的开始标签为 max(虚拟时间, 该用户上一个请求的结束标签)，结束标签为开始标签加 1，
+// 开始标签最小的请求优先放行，持续大量请求的用户标签不断增大，不会挤占其它用户
+type fairQueue struct {
+	sync.Mutex
+	virtualTime float64
+	finish      map[int]float64
+	waiters     []*fairWaiter
+	notify      chan struct{}
+}
+
+type fairWaiter struct {
+	userId int
+	start  float64
+}
+
+var fairQueues sync.Map
+
+func getFairQueue(keyPrefix string) *fairQueue {
+	queue, _ := fairQueues.LoadOrStore(keyPrefix, &fairQueue{
+		finish: make(map[int]float64),
+		notify: make(chan struct{}),
+	})
+	return queue.(*fairQueue)
+}
+
+// AcquireConcurrencyFair 占用一个并发名额，已满时最多排队 timeout，排队的请求按用户公平放行
+func AcquireConcurrencyFair(ctx context.Context, keyPrefix string, userId, max int, timeout time.Duration) (release func(), ok bool) {
+	queue := getFairQueue(keyPrefix)
+
+	queue.Lock()
+	waiter := queue.tag(userId)
+	if len(queue.waiters) == 0 {
+		if release, ok := AcquireConcurrency(keyPrefix, max); ok {
+			queue.dispatch(waiter)
+			queue.Unlock()
+			return queue.wrap(release), true
+		}
+	}
+	if timeout <= 0 {
+		queue.rollback(waiter)
+		queue.Unlock()
+		return nil, false
+	}
+	queue.waiters = append(queue.waiters, waiter)
+	queue.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(fairQueueRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		queue.Lock()
+		if queue.head() == waiter {
+			if release, ok := AcquireConcurrency(keyPrefix, max); ok {
+				queue.remove(waiter)
+				queue.dispatch(waiter)
+				queue.broadcast()
+				queue.Unlock()
+				return queue.wrap(release), true
+			}
+		}
+		notify := queue.notify
+		queue.Unlock()
+
+		select {
+		case <-notify:
+		case <-ticker.C:
+		case <-timer.C:
+			queue.abandon(waiter)
+			return nil, false
+		case <-ctx.Done():
+			queue.abandon(waiter)
+			return nil, false
+		}
+	}
+}
+
+// tag 计算请求的开始标签，并预先推进用户的结束标签
+func (q *fairQueue) tag(userId int) *fairWaiter {
+	start := max(q.virtualTime, q.finish[userId])
+	q.finish[userId] = start + 1
+	return &fairWaiter{userId: userId, start: start}
+}
+
+// rollback 请求没有排队也没有放行时撤销标签
+func (q *fairQueue) rollback(waiter *fairWaiter) {
+	if q.finish[waiter.userId] == waiter.start+1 {
+		q.finish[waiter.userId] = waiter.start
+	}
+}
+
+// dispatch 放行请求，推进虚拟时间并清理已经空闲的用户
+func (q *fairQueue) dispatch(waiter *fairWaiter) {
+	q.virtualTime = max(q.virtualTime, waiter.start)
+	for userId, finish := range q.finish {
+		if finish <= q.virtualTime {
+			delete(q.finish, userId)
+		}
+	}
+}
+
+func (q *fairQueue) head() *fairWaiter {
+	var head *fairWaiter
+	for _, waiter := range q.waiters {
+		if head == nil || waiter.start < head.start {
+			head = waiter
+		}
+	}
+	return head
+}
+
+func (q *fairQueue) remove(waiter *fairWaiter) {
+	for i, item := range q.waiters {
+		if item == waiter {
+			q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (q *fairQueue) abandon(waiter *fairWaiter) {
+	q.Lock()
+	defer q.Unlock()
+	q.remove(waiter)
+	q.rollback(waiter)
+	q.broadcast()
+}
+
+// broadcast 唤醒所有排队的请求重新检查
+func (q *fairQueue) broadcast() {
+	close(q.notify)
+	q.notify = make(chan struct{})
+}
+
+// wrap 释放名额后唤醒排队的请求
+func (q *fairQueue) wrap(release func()) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			release()
+			q.Lock()
+			q.broadcast()
+			q.Unlock()
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package mtls
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+// Enabled 是否启用客户端证书监听
+func Enabled() bool {
+	return viper.GetBool("mtls.enable")
+}
+
+// Exclusive 启用后普通监听端口不再接受中继请求，只能通过客户端证书访问
+func Exclusive() bool {
+	return Enabled() && viper.GetBool("mtls.exclusive")
+}
+
+// NewServer 创建要求客户端证书的 HTTPS 服务，证书由 mtls.client_ca_file 签发才可连接
+func NewServer(handler http.Handler) (*http.Server, error) {
+	certFile := viper.GetString("mtls.cert_file")
+	keyFile := viper.GetString("mtls.key_file")
+	caFile := viper.GetString("mtls.client_ca_file")
+	if certFile == "" || keyFile == "" || caFile == "" {
+		return nil, errors.New("mtls.cert_file, mtls.key_file and mtls.client_ca_file are required")
+	}
+
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, errors.New("no valid certificate found in mtls.client_ca_file")
+	}
+
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Server{
+		Addr:    ":" + viper.GetString("mtls.port"),
+		Handler: handler,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{certificate},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    clientCAs,
+			MinVersion:   tls.VersionTLS12,
+		},
+	}, nil
+}
+
+// PeerCertificate 获取请求中已校验的客户端证书
+func PeerCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return r.TLS.PeerCertificates[0]
+}
+
+// Fingerprint 证书的 SHA-256 指纹
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
@@ -3,6 +3,7 @@ package channel
 import (
 	"context"
 	"errors"
+	"fmt"
 	"one-api/common/config"
 	"one-api/common/stmp"
 
@@ -31,8 +32,13 @@ func (e *Email) Send(_ context.Context, title, message string) error {
 		to = config.RootUserEmail
 	}
 
-	if config.SMTPServer == "" || config.SMTPAccount == "" || config.SMTPToken == "" || to == "" {
-		return errors.New("smtp config is not set, skip send email notifier")
+	if to == "" {
+		return errors.New("email receiver is not set, skip send email notifier")
+	}
+
+	sender, err := stmp.GetSystemSender()
+	if err != nil {
+		return fmt.Errorf("email config is not set, skip send email notifier: %w", err)
 	}
 
 	p := parser.NewWithExtensions(parser.CommonExtensions | parser.DefinitionLists | parser.OrderedListStart)
@@ -44,7 +50,5 @@ func (e *Email) Send(_ context.Context, title, message string) error {
 
 	body := markdown.Render(doc, renderer)
 
-	emailClient := stmp.NewStmp(config.SMTPServer, config.SMTPPort, config.SMTPAccount, config.SMTPToken, config.SMTPFrom)
-
-	return emailClient.Send(to, title, string(body))
+	return sender.Send(to, title, string(body))
 }
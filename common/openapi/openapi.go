@@ -0,0 +1,347 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"one-api/common/config"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Servers    []Server                         `json:"servers,omitempty"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+type Tag struct {
+	Name string `json:"name"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	In          string `json:"in,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type Operation struct {
+	OperationId string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Route 路由的补充说明，Query 和 Body 为请求参数的结构体，Response 为响应结构体
+// Raw 表示管理接口的响应不使用 {success, message, data} 结构
+type Route struct {
+	Summary     string
+	Query       any
+	Body        any
+	ContentType string
+	Response    any
+	Raw         bool
+}
+
+// SecurityRule 路径前缀对应的认证方式，按注册顺序匹配第一个
+type SecurityRule struct {
+	Prefix   string
+	Tag      string
+	Security []map[string][]string
+	Relay    bool
+}
+
+var (
+	routes        = make(map[string]Route)
+	publicRoutes  = make(map[string]bool)
+	securityRules []SecurityRule
+
+	document    *Document
+	cache       []byte
+	cacheServer string
+	lock        sync.Mutex
+)
+
+var pathParamRegex = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+var supportedMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// Describe 为路由补充说明和请求响应结构，path 使用 gin 的路由格式
+func Describe(method, path string, route Route) {
+	routes[method+" "+path] = route
+}
+
+// MarkPublic 标记无需认证的路由，route 格式为 "GET /api/status"
+func MarkPublic(route string) {
+	publicRoutes[route] = true
+}
+
+// AddSecurityRule 注册路径前缀对应的认证方式和分组
+func AddSecurityRule(rule SecurityRule) {
+	securityRules = append(securityRules, rule)
+}
+
+// Build 根据已注册的路由生成文档，需要在所有路由注册完成后调用
+func Build(routesInfo gin.RoutesInfo) {
+	registry := newSchemaRegistry()
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info: Info{
+			Title:       config.SystemName + " API",
+			Description: "管理接口（/api）使用系统访问令牌或登录会话认证，返回 {success, message, data} 结构；中继接口使用令牌 key 认证，请求和响应与上游接口兼容",
+			Version:     config.Version,
+		},
+		Paths: make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas: registry.schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"accessToken": {Type: "http", Scheme: "bearer", Description: "个人设置中生成的系统访问令牌"},
+				"session":     {Type: "apiKey", In: "cookie", Name: "session", Description: "网页登录后的会话"},
+				"apiKey":      {Type: "http", Scheme: "bearer", Description: "令牌 key，sk- 开头"},
+				"claudeKey":   {Type: "apiKey", In: "header", Name: "x-api-key"},
+				"geminiKey":   {Type: "apiKey", In: "header", Name: "x-goog-api-key"},
+				"mjKey":       {Type: "apiKey", In: "header", Name: "mj-api-secret"},
+			},
+		},
+	}
+	registry.schemas["APIResponse"] = &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"success": {Type: "boolean"},
+			"message": {Type: "string"},
+			"data":    {},
+		},
+		Required: []string{"success", "message"},
+	}
+	errorSchema := registry.schemaOf(errorResponse{})
+
+	operationIds := make(map[string]int)
+	for _, info := range routesInfo {
+		operationIds[handlerName(info.Handler)]++
+	}
+
+	tags := make(map[string]bool)
+	for _, info := range routesInfo {
+		if !supportedMethods[info.Method] {
+			continue
+		}
+		rule, ok := matchSecurityRule(info.Path)
+		if !ok {
+			continue
+		}
+
+		path := pathParamRegex.ReplaceAllString(info.Path, "{$1}")
+		route := routes[info.Method+" "+info.Path]
+
+		operation := &Operation{
+			OperationId: handlerName(info.Handler),
+			Summary:     route.Summary,
+			Security:    rule.Security,
+			Responses:   make(map[string]*Response),
+		}
+		if publicRoutes[info.Method+" "+info.Path] {
+			operation.Security = nil
+		}
+		if operationIds[operation.OperationId] > 1 {
+			operation.OperationId = operationIdFromPath(info.Method, info.Path)
+		}
+
+		tag := rule.Tag
+		if tag == "" {
+			tag = tagFromPath(info.Path)
+		}
+		if tag != "" {
+			operation.Tags = []string{tag}
+			tags[tag] = true
+		}
+
+		for _, match := range pathParamRegex.FindAllStringSubmatch(info.Path, -1) {
+			operation.Parameters = append(operation.Parameters, &Parameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+		operation.Parameters = append(operation.Parameters, registry.queryParameters(route.Query)...)
+
+		if route.Body != nil {
+			contentType := route.ContentType
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			operation.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]*MediaType{contentType: {Schema: registry.schemaOf(route.Body)}},
+			}
+		}
+
+		responseSchema := registry.schemaOf(route.Response)
+		if rule.Relay || route.Raw {
+			response := &Response{Description: "OK"}
+			if responseSchema != nil {
+				response.Content = map[string]*MediaType{"application/json": {Schema: responseSchema}}
+			}
+			operation.Responses["200"] = response
+			if rule.Relay {
+				operation.Responses["default"] = &Response{
+					Description: "错误",
+					Content:     map[string]*MediaType{"application/json": {Schema: errorSchema}},
+				}
+			}
+		} else {
+			envelope := &Schema{Ref: "#/components/schemas/APIResponse"}
+			if responseSchema != nil {
+				envelope = &Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"success": {Type: "boolean"},
+						"message": {Type: "string"},
+						"data":    responseSchema,
+					},
+					Required: []string{"success", "message"},
+				}
+			}
+			operation.Responses["200"] = &Response{
+				Description: "success 为 false 时 message 为错误信息",
+				Content:     map[string]*MediaType{"application/json": {Schema: envelope}},
+			}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		doc.Paths[path][strings.ToLower(info.Method)] = operation
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool {
+		return doc.Tags[i].Name < doc.Tags[j].Name
+	})
+
+	lock.Lock()
+	defer lock.Unlock()
+	document = doc
+	cache = nil
+}
+
+// JSON 返回序列化后的文档，服务地址变化时重新生成
+func JSON() ([]byte, error) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	if document == nil {
+		return nil, nil
+	}
+	if cache != nil && cacheServer == config.ServerAddress {
+		return cache, nil
+	}
+
+	document.Servers = nil
+	if config.ServerAddress != "" {
+		document.Servers = []Server{{URL: strings.TrimSuffix(config.ServerAddress, "/")}}
+	}
+	data, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	cache = data
+	cacheServer = config.ServerAddress
+	return cache, nil
+}
+
+// errorResponse 中继接口的错误结构，与 types.OpenAIErrorResponse 一致
+type errorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Param   string `json:"param,omitempty"`
+		Code    any    `json:"code,omitempty"`
+	} `json:"error"`
+}
+
+func matchSecurityRule(path string) (SecurityRule, bool) {
+	for _, rule := range securityRules {
+		if strings.HasPrefix(path, rule.Prefix) {
+			return rule, true
+		}
+	}
+	return SecurityRule{}, false
+}
+
+// handlerName 取处理函数名，例如 one-api/controller.GetChannelsList 返回 GetChannelsList
+func handlerName(handler string) string {
+	handler = handler[strings.LastIndex(handler, "/")+1:]
+	handler = strings.TrimSuffix(handler, ".func1")
+	return handler[strings.LastIndex(handler, ".")+1:]
+}
+
+func operationIdFromPath(method, path string) string {
+	var builder strings.Builder
+	builder.WriteString(strings.ToLower(method))
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == ':' || r == '*' || r == '-' || r == '_' || r == '.'
+	}) {
+		builder.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return builder.String()
+}
+
+// tagFromPath 管理接口按 /api 之后的第一段分组
+func tagFromPath(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) > 1 && parts[0] == "api" {
+		return parts[1]
+	}
+	return parts[0]
+}
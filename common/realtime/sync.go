@@ -2,6 +2,8 @@ package realtime
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,11 +13,15 @@ import (
 	"one-api/model"
 )
 
+// 上报已应用配置版本的间隔
+const configReportInterval = 15 * time.Second
+
 // StartRealtimeSync starts Redis pub/sub listeners to refresh in-memory state immediately.
-// - optionsTopic: triggers model.ReloadOptions()
-// - channelsTopic: triggers model.ChannelGroup.Load()
+// - optionsTopic: "set:{key}" reloads a single option, anything else triggers model.ReloadOptions()
+// - channelsTopic: "change:{id}" / "status:{id}:{enabled}" update a single channel, anything else triggers model.ChannelGroup.Load()
 //
-// It also performs an initial warm-up load to avoid cold state on startup.
+// It also performs an initial warm-up load to avoid cold state on startup, and a full
+// reload after Redis recovers from an outage.
 func StartRealtimeSync() {
 	if !config.RedisEnabled {
 		return
@@ -30,10 +36,16 @@ func StartRealtimeSync() {
 	go func() {
 		// Small stagger to avoid thundering herd during simultaneous boots
 		time.Sleep(500 * time.Millisecond)
-		safeReloadOptions()
-		safeReloadChannels()
+		reloadAll()
 	}()
 
+	// Redis 不可用期间的消息已丢失，恢复后全量加载
+	rds.OnRecover(func() {
+		go reloadAll()
+	})
+
+	go reportConfigVersion()
+
 	ctx := context.Background()
 	pubsub := client.Subscribe(ctx, rds.RedisTopicOptionsSync, rds.RedisTopicChannelsSync)
 	go func() {
@@ -60,25 +72,123 @@ func StartRealtimeSync() {
 				}
 				payload = payload[sep+1:]
 			}
+			// 配置版本，旧版本实例发布的消息没有版本
+			var version int64
+			if sep := strings.Index(payload, "|"); sep > 0 {
+				if v, err := strconv.ParseInt(payload[:sep], 10, 64); err == nil {
+					version = v
+					payload = payload[sep+1:]
+				}
+			}
 
 			switch msg.Channel {
 			case rds.RedisTopicOptionsSync:
-				// Optional payload schema: "key=value" or "reload"
-				if strings.TrimSpace(payload) == "" || strings.HasPrefix(payload, "reload") {
-					safeReloadOptions()
-				} else {
-					// For now, do a full reload to keep behavior consistent
-					safeReloadOptions()
-				}
+				handleOptionsMessage(strings.TrimSpace(payload))
 			case rds.RedisTopicChannelsSync:
-				// Optional payload schema: "reload" / "change:{id}:{enabled}"
-				// For simplicity and consistency, just reload the group.
-				safeReloadChannels()
+				handleChannelsMessage(strings.TrimSpace(payload))
 			default:
 				// ignore unknown channels
 			}
+			if version > 0 {
+				rds.AdvanceConfigVersion(version)
+				reportCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+				_ = rds.ReportConfigVersion(reportCtx)
+				cancel()
+			}
+		}
+	}()
+}
+
+// reloadAll 全量加载设置和渠道，加载前读取最新的配置版本，加载后已应用的版本至少为该版本
+func reloadAll() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	version, err := rds.CurrentConfigVersion(ctx)
+	cancel()
+
+	safeReloadOptions()
+	safeReloadChannels()
+	if err == nil {
+		rds.MarkConfigReloaded(version)
+	}
+}
+
+// reportConfigVersion 定期上报已应用的配置版本，连续两次落后且没有进展时说明有消息丢失，全量加载
+func reportConfigVersion() {
+	ticker := time.NewTicker(configReportInterval)
+	defer ticker.Stop()
+
+	var lastApplied int64 = -1
+	for range ticker.C {
+		if !rds.Available() {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		current, err := rds.CurrentConfigVersion(ctx)
+		cancel()
+		applied := rds.AppliedConfigVersion()
+		if err == nil && applied < current && applied == lastApplied {
+			logger.SysLog(fmt.Sprintf("config version %d is behind %d, reloading", applied, current))
+			reloadAll()
+			applied = rds.AppliedConfigVersion()
+		}
+		lastApplied = applied
+
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		if err := rds.ReportConfigVersion(ctx); err != nil {
+			logger.SysError("failed to report config version: " + err.Error())
+		}
+		cancel()
+	}
+}
+
+// handleOptionsMessage payload schema: "set:{key}" or "reload"
+func handleOptionsMessage(payload string) {
+	key, ok := strings.CutPrefix(payload, "set:")
+	if !ok || key == "" {
+		safeReloadOptions()
+		return
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			logger.SysError("panic reloading option " + key)
+		}
+	}()
+	if err := model.ReloadOption(key); err != nil {
+		logger.SysError("failed to reload option " + key + ": " + err.Error() + ", fallback to full reload")
+		safeReloadOptions()
+	}
+}
+
+// handleChannelsMessage payload schema: "change:{id}", "status:{id}:{enabled}" or "reload"
+func handleChannelsMessage(payload string) {
+	parts := strings.Split(payload, ":")
+	switch {
+	case len(parts) == 2 && parts[0] == "change":
+		if id, err := strconv.Atoi(parts[1]); err == nil {
+			safeRefreshChannel(id)
+			return
+		}
+	case len(parts) == 3 && parts[0] == "status":
+		id, err := strconv.Atoi(parts[1])
+		enabled, err2 := strconv.ParseBool(parts[2])
+		// 未缓存的渠道需要全量加载才能进入路由表
+		if err == nil && err2 == nil && model.ChannelGroup.Has(id) {
+			model.ChannelGroup.ChangeStatus(id, enabled)
+			return
+		}
+	}
+
+	safeReloadChannels()
+}
+
+func safeRefreshChannel(id int) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.SysError("panic refreshing channel")
 		}
 	}()
+	model.ChannelGroup.Refresh(id)
 }
 
 func safeReloadOptions() {
@@ -97,6 +207,7 @@ func safeReloadChannels() {
 		}
 	}()
 	model.ChannelGroup.Load()
+	model.GlobalUserGroupRatio.Load()
 	// Keep Pricing and ModelOwnedBy in sync like periodic SyncChannelCache
 	if model.PricingInstance != nil {
 		_ = model.PricingInstance.Init()
@@ -104,4 +215,9 @@ func safeReloadChannels() {
 	if model.ModelOwnedBysInstance != nil {
 		_ = model.ModelOwnedBysInstance.Load()
 	}
+	_ = model.ModelInfosInstance.Load()
+	_ = model.PromptTemplatesInstance.Load()
+	_ = model.IPRulesInstance.Load()
+	_ = model.ModelRoutesInstance.Load()
+	_ = model.ClientCertsInstance.Load()
 }
@@ -0,0 +1,149 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"one-api/common/config"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 配置版本：每次通过同步主题发布渠道或设置的修改时递增，各实例记录已应用的版本，
+// 版本落后说明有修改还没有应用（消息丢失或正在加载），用于观察多实例的发布状态
+const (
+	configVersionKey   = "onehub:config:version"
+	configInstancesKey = "onehub:config:instances"
+
+	// 超过 configInstanceTimeout 未上报视为下线，超过 configInstanceExpire 时删除
+	configInstanceTimeout = time.Minute
+	configInstanceExpire  = 10 * time.Minute
+
+	// 等待中的版本过多时不再记录，等待下次全量加载
+	maxPendingConfigVersions = 1000
+)
+
+var (
+	configVersionMu sync.Mutex
+	appliedVersion  int64
+	// 已应用但前面还有版本没有收到的版本，版本按发布顺序连续应用
+	pendingVersions = make(map[int64]struct{})
+)
+
+// ConfigInstance 实例已应用的配置版本
+type ConfigInstance struct {
+	InstanceId string `json:"instance_id"`
+	Version    int64  `json:"version"`
+	StartTime  int64  `json:"start_time"`
+	UpdatedAt  int64  `json:"updated_at"`
+	Alive      bool   `json:"alive"`
+	Stale      bool   `json:"stale"`
+}
+
+type ConfigVersionStatus struct {
+	Version   int64             `json:"version"`
+	Instances []*ConfigInstance `json:"instances"`
+}
+
+func isSyncTopic(channel string) bool {
+	return channel == RedisTopicOptionsSync || channel == RedisTopicChannelsSync
+}
+
+// AppliedConfigVersion 当前实例已应用的配置版本，未启用 Redis 时为 0
+func AppliedConfigVersion() int64 {
+	configVersionMu.Lock()
+	defer configVersionMu.Unlock()
+	return appliedVersion
+}
+
+// AdvanceConfigVersion 应用了版本 version 的修改，只有之前的版本都已应用时已应用版本才会前进
+func AdvanceConfigVersion(version int64) {
+	configVersionMu.Lock()
+	defer configVersionMu.Unlock()
+	if version <= appliedVersion {
+		return
+	}
+	if len(pendingVersions) < maxPendingConfigVersions {
+		pendingVersions[version] = struct{}{}
+	}
+	advancePendingVersions()
+}
+
+// advancePendingVersions 连续的版本都已应用时前进，调用时需要持有 configVersionMu
+func advancePendingVersions() {
+	for {
+		if _, ok := pendingVersions[appliedVersion+1]; !ok {
+			return
+		}
+		delete(pendingVersions, appliedVersion+1)
+		appliedVersion++
+	}
+}
+
+// MarkConfigReloaded 全量加载后调用，version 为加载前读取的最新版本
+func MarkConfigReloaded(version int64) {
+	configVersionMu.Lock()
+	defer configVersionMu.Unlock()
+	if version <= appliedVersion {
+		return
+	}
+	appliedVersion = version
+	for pending := range pendingVersions {
+		if pending <= version {
+			delete(pendingVersions, pending)
+		}
+	}
+	advancePendingVersions()
+}
+
+// CurrentConfigVersion 最新的配置版本
+func CurrentConfigVersion(ctx context.Context) (int64, error) {
+	version, err := RDB.Get(ctx, configVersionKey).Int64()
+	if err == Nil {
+		return 0, nil
+	}
+	return version, err
+}
+
+// ReportConfigVersion 上报当前实例已应用的版本
+func ReportConfigVersion(ctx context.Context) error {
+	data, err := json.Marshal(&ConfigInstance{
+		InstanceId: config.InstanceID,
+		Version:    AppliedConfigVersion(),
+		StartTime:  config.StartTime,
+		UpdatedAt:  time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+	return RDB.HSet(ctx, configInstancesKey, config.InstanceID, data).Err()
+}
+
+// GetConfigVersionStatus 最新版本和各实例已应用的版本，删除长时间未上报的实例
+func GetConfigVersionStatus(ctx context.Context) (*ConfigVersionStatus, error) {
+	version, err := CurrentConfigVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	values, err := RDB.HGetAll(ctx, configInstancesKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	status := &ConfigVersionStatus{Version: version, Instances: make([]*ConfigInstance, 0, len(values))}
+	for instanceId, value := range values {
+		var instance ConfigInstance
+		if err := json.Unmarshal([]byte(value), &instance); err != nil || now-instance.UpdatedAt > int64(configInstanceExpire.Seconds()) {
+			RDB.HDel(ctx, configInstancesKey, instanceId)
+			continue
+		}
+		instance.Alive = now-instance.UpdatedAt <= int64(configInstanceTimeout.Seconds())
+		instance.Stale = instance.Version < version
+		status.Instances = append(status.Instances, &instance)
+	}
+	sort.Slice(status.Instances, func(i, j int) bool {
+		return status.Instances[i].InstanceId < status.Instances[j].InstanceId
+	})
+	return status, nil
+}
@@ -0,0 +1,103 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"one-api/common/logger"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Redis 不可用期间写入本地的 key 最多记录的数量，超过后不再记录
+const maxStaleKeys = 100000
+
+var (
+	available atomic.Bool
+
+	recoverMu        sync.Mutex
+	recoverCallbacks []func()
+
+	staleMu   sync.Mutex
+	staleKeys = make(map[string]struct{})
+)
+
+// Available Redis 当前是否可用，不可用时限流、缓存和选举使用本地状态
+func Available() bool {
+	return RDB != nil && available.Load()
+}
+
+// OnRecover 注册 Redis 恢复后的回调，用于重新同步本地状态
+// 回调在健康检查协程中同步执行，耗时操作需要自行开启协程
+func OnRecover(fn func()) {
+	recoverMu.Lock()
+	defer recoverMu.Unlock()
+	recoverCallbacks = append(recoverCallbacks, fn)
+}
+
+// MarkStale 记录 Redis 不可用期间在本地修改过的 key，恢复后从 Redis 中删除，下次读取时重新加载
+func MarkStale(key string) {
+	staleMu.Lock()
+	defer staleMu.Unlock()
+	if len(staleKeys) >= maxStaleKeys {
+		return
+	}
+	staleKeys[key] = struct{}{}
+}
+
+// purgeStaleKeys 删除不可用期间记录的 key，单个删除以兼容集群模式
+func purgeStaleKeys(ctx context.Context) {
+	staleMu.Lock()
+	keys := staleKeys
+	staleKeys = make(map[string]struct{})
+	staleMu.Unlock()
+
+	if len(keys) >= maxStaleKeys {
+		logger.SysError(fmt.Sprintf("too many keys changed while Redis was unavailable, only the first %d are purged", maxStaleKeys))
+	}
+	for key := range keys {
+		if err := RDB.Del(ctx, key).Err(); err != nil {
+			logger.SysError("failed to purge stale Redis key " + key + ": " + err.Error())
+		}
+	}
+}
+
+// startHealthCheck 定时检测 Redis 连接，状态变化时切换本地降级并在恢复后同步状态
+func startHealthCheck() {
+	interval := time.Duration(viper.GetInt("redis.health_check_interval")) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			err := RDB.Ping(ctx).Err()
+			if err != nil {
+				if available.Swap(false) {
+					logger.SysError("Redis is unavailable, falling back to local state: " + err.Error())
+				}
+				cancel()
+				continue
+			}
+
+			if !available.Load() {
+				logger.SysLog("Redis is available again, resyncing state")
+				// 先删除过期的 key 再切换回 Redis，避免读到不可用期间未同步的旧值
+				purgeStaleKeys(context.Background())
+				available.Store(true)
+				recoverMu.Lock()
+				fns := append([]func(){}, recoverCallbacks...)
+				recoverMu.Unlock()
+				for _, fn := range fns {
+					fn()
+				}
+			}
+			cancel()
+		}
+	}()
+}
@@ -2,15 +2,18 @@ package redis
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"one-api/common/config"
 	"one-api/common/logger"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 	"github.com/spf13/viper"
 )
 
-var RDB *redis.Client
+var RDB redis.UniversalClient
 
 const Nil = redis.Nil
 
@@ -18,28 +21,104 @@ const Nil = redis.Nil
 const RedisTopicOptionsSync = "onehub:sync:options"
 const RedisTopicChannelsSync = "onehub:sync:channels"
 
+// Redis 部署方式
+const (
+	ModeStandalone = "standalone"
+	ModeSentinel   = "sentinel"
+	ModeCluster    = "cluster"
+)
+
+// GetMode 返回配置的 Redis 部署方式，未知的值按单机处理
+func GetMode() string {
+	switch mode := strings.ToLower(strings.TrimSpace(viper.GetString("redis.mode"))); mode {
+	case ModeSentinel, ModeCluster:
+		return mode
+	default:
+		return ModeStandalone
+	}
+}
+
+// getAddrs 哨兵或集群节点地址，支持列表或逗号分隔
+func getAddrs() []string {
+	var addrs []string
+	for _, item := range viper.GetStringSlice("redis.addrs") {
+		for _, addr := range strings.Split(item, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				addrs = append(addrs, addr)
+			}
+		}
+	}
+	return addrs
+}
+
+// newClient 按部署方式创建客户端，未配置时返回 nil
+func newClient() (redis.UniversalClient, error) {
+	mode := GetMode()
+	if mode == ModeStandalone {
+		redisConn := viper.GetString("redis_conn_string")
+		if redisConn == "" {
+			return nil, nil
+		}
+		opt, err := redis.ParseURL(redisConn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis connection string: %w", err)
+		}
+		if opt.DB == 0 {
+			opt.DB = viper.GetInt("redis_db")
+		}
+		return redis.NewClient(opt), nil
+	}
+
+	addrs := getAddrs()
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("redis.addrs is required in %s mode", mode)
+	}
+
+	if mode == ModeSentinel {
+		masterName := viper.GetString("redis.master_name")
+		if masterName == "" {
+			return nil, errors.New("redis.master_name is required in sentinel mode")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       masterName,
+			SentinelAddrs:    addrs,
+			SentinelUsername: viper.GetString("redis.sentinel_username"),
+			SentinelPassword: viper.GetString("redis.sentinel_password"),
+			Username:         viper.GetString("redis.username"),
+			Password:         viper.GetString("redis.password"),
+			DB:               viper.GetInt("redis_db"),
+		}), nil
+	}
+
+	// 集群只有 0 号数据库，多键操作的 key 需要使用 {hash tag} 保证在同一个槽
+	if viper.GetInt("redis_db") != 0 {
+		logger.SysLog("redis_db is ignored in cluster mode")
+	}
+	return redis.NewClusterClient(&redis.ClusterOptions{
+		Addrs:    addrs,
+		Username: viper.GetString("redis.username"),
+		Password: viper.GetString("redis.password"),
+	}), nil
+}
+
 // InitRedisClient This function is called after init()
 func InitRedisClient() (err error) {
-	redisConn := viper.GetString("redis_conn_string")
-
-	if redisConn == "" {
+	client, err := newClient()
+	if err != nil {
+		logger.FatalLog(err.Error())
+		return
+	}
+	if client == nil {
 		logger.SysLog("REDIS_CONN_STRING not set, Redis is not enabled")
 		return nil
 	}
 	if viper.GetInt("sync_frequency") == 0 {
 		logger.SysLog("SYNC_FREQUENCY not set, Redis is disabled")
+		client.Close()
 		return nil
 	}
-	logger.SysLog("Redis is enabled")
-	opt, err := redis.ParseURL(redisConn)
-	if err != nil {
-		logger.FatalLog("failed to parse Redis connection string: " + err.Error())
-		return
-	}
-	if opt.DB == 0 {
-		opt.DB = viper.GetInt("redis_db")
-	}
-	RDB = redis.NewClient(opt)
+	logger.SysLog("Redis is enabled, mode: " + GetMode())
+	RDB = client
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -51,16 +130,25 @@ func InitRedisClient() (err error) {
 		config.RedisEnabled = true
 		// for compatibility with old versions
 		config.MemoryCacheEnabled = true
+		available.Store(true)
+		startHealthCheck()
 	}
 
 	return err
 }
 
+// ParseRedisOption 单机模式的连接参数，哨兵和集群模式返回 nil
 func ParseRedisOption() *redis.Options {
+	if GetMode() != ModeStandalone {
+		return nil
+	}
 	opt, err := redis.ParseURL(viper.GetString("redis_conn_string"))
 	if err != nil {
 		logger.FatalLog("failed to parse Redis connection string: " + err.Error())
 	}
+	if opt.DB == 0 {
+		opt.DB = viper.GetInt("redis_db")
+	}
 	return opt
 }
 
@@ -88,7 +176,7 @@ func NewScript(script string) *redis.Script {
 	return redis.NewScript(script)
 }
 
-func GetRedisClient() *redis.Client {
+func GetRedisClient() redis.UniversalClient {
 	return RDB
 }
 
@@ -112,9 +200,27 @@ func RedisSIsMember(key string, member interface{}) (bool, error) {
 	return RDB.SIsMember(ctx, key, member).Result()
 }
 
+// publishWithVersionScript 递增配置版本并发布，两者同时成功，避免版本出现空缺
+var publishWithVersionScript = redis.NewScript(`
+local version = redis.call('INCR', KEYS[1])
+redis.call('PUBLISH', ARGV[1], ARGV[2] .. '|' .. version .. '|' .. ARGV[3])
+return version
+`)
+
 // Event publishing helper
+// 同步主题的消息带有递增的配置版本：{instance}|{version}|{message}
 func RedisPublish(channel string, message string) error {
 	ctx := context.Background()
-	full := config.InstanceID + "|" + message
-	return RDB.Publish(ctx, channel, full).Err()
+	if !isSyncTopic(channel) {
+		full := config.InstanceID + "|" + message
+		return RDB.Publish(ctx, channel, full).Err()
+	}
+
+	version, err := publishWithVersionScript.Run(ctx, RDB, []string{configVersionKey}, channel, config.InstanceID, message).Int64()
+	if err != nil {
+		return err
+	}
+	// 发布前已在本地应用
+	AdvanceConfigVersion(version)
+	return ReportConfigVersion(ctx)
 }
@@ -0,0 +1,116 @@
+package remoteconfig
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// consulBackend 通过 Consul KV 的阻塞查询监听前缀下的键
+type consulBackend struct {
+	endpoint   string
+	prefix     string
+	token      string
+	datacenter string
+	client     *http.Client
+}
+
+func newConsulBackend(endpoint, prefix string) *consulBackend {
+	return &consulBackend{
+		endpoint:   endpoint,
+		prefix:     prefix,
+		token:      viper.GetString("remote_config.token"),
+		datacenter: viper.GetString("remote_config.datacenter"),
+		client:     &http.Client{},
+	}
+}
+
+func (c *consulBackend) name() string {
+	return "consul"
+}
+
+func (c *consulBackend) load(ctx context.Context) (map[string]string, int64, error) {
+	return c.query(ctx, 0)
+}
+
+// wait 阻塞查询最长等待五分钟，索引不变表示超时，继续等待
+func (c *consulBackend) wait(ctx context.Context, revision int64) error {
+	for {
+		_, index, err := c.query(ctx, revision)
+		if err != nil {
+			return err
+		}
+		if index != revision {
+			return nil
+		}
+	}
+}
+
+func (c *consulBackend) query(ctx context.Context, index int64) (map[string]string, int64, error) {
+	params := url.Values{}
+	params.Set("recurse", "true")
+	if c.datacenter != "" {
+		params.Set("dc", c.datacenter)
+	}
+	if index > 0 {
+		params.Set("index", strconv.FormatInt(index, 10))
+		params.Set("wait", "5m")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"/v1/kv/"+c.prefix+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	newIndex, _ := strconv.ParseInt(resp.Header.Get("X-Consul-Index"), 10, 64)
+	// 索引变小说明 Consul 数据被重置，需要重新读取
+	if newIndex < index {
+		newIndex = 0
+	}
+
+	values := make(map[string]string)
+	if resp.StatusCode == http.StatusNotFound {
+		return values, newIndex, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, 0, fmt.Errorf("consul returned %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var pairs []struct {
+		Key   string  `json:"Key"`
+		Value *string `json:"Value"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pairs); err != nil {
+		return nil, 0, err
+	}
+	for _, pair := range pairs {
+		name, ok := optionKey(pair.Key)
+		if !ok || pair.Value == nil {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(*pair.Value)
+		if err != nil {
+			continue
+		}
+		values[name] = string(value)
+	}
+	return values, newIndex, nil
+}
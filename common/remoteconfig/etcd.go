@@ -0,0 +1,205 @@
+package remoteconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// etcdBackend 通过 etcd v3 的 HTTP 网关读取和监听前缀下的键
+type etcdBackend struct {
+	endpoint string
+	key      string
+	rangeEnd string
+	username string
+	password string
+	client   *http.Client
+}
+
+type etcdKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type etcdHeader struct {
+	Revision string `json:"revision"`
+}
+
+func newEtcdBackend(endpoint, prefix string) *etcdBackend {
+	return &etcdBackend{
+		endpoint: endpoint,
+		key:      base64.StdEncoding.EncodeToString([]byte(prefix)),
+		rangeEnd: base64.StdEncoding.EncodeToString(prefixEnd([]byte(prefix))),
+		username: viper.GetString("remote_config.username"),
+		password: viper.GetString("remote_config.password"),
+		client:   &http.Client{},
+	}
+}
+
+// prefixEnd 前缀查询的结束键，最后一个字节加一
+func prefixEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return []byte{0}
+}
+
+func (e *etcdBackend) name() string {
+	return "etcd"
+}
+
+func (e *etcdBackend) load(ctx context.Context) (map[string]string, int64, error) {
+	resp, err := e.post(ctx, "/v3/kv/range", map[string]any{"key": e.key, "range_end": e.rangeEnd})
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Header etcdHeader     `json:"header"`
+		Kvs    []etcdKeyValue `json:"kvs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, err
+	}
+
+	values := make(map[string]string, len(result.Kvs))
+	for _, kv := range result.Kvs {
+		key, err := base64.StdEncoding.DecodeString(kv.Key)
+		if err != nil {
+			continue
+		}
+		name, ok := optionKey(string(key))
+		if !ok {
+			continue
+		}
+		value, err := base64.StdEncoding.DecodeString(kv.Value)
+		if err != nil {
+			continue
+		}
+		values[name] = string(value)
+	}
+
+	revision, _ := strconv.ParseInt(result.Header.Revision, 10, 64)
+	return values, revision, nil
+}
+
+// wait 从 revision 之后开始监听，收到第一批事件后返回，由调用方重新读取全部配置
+func (e *etcdBackend) wait(ctx context.Context, revision int64) error {
+	resp, err := e.post(ctx, "/v3/watch", map[string]any{
+		"create_request": map[string]any{
+			"key":            e.key,
+			"range_end":      e.rangeEnd,
+			"start_revision": strconv.FormatInt(revision+1, 10),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var message struct {
+			Result struct {
+				Canceled     bool   `json:"canceled"`
+				CancelReason string `json:"cancel_reason"`
+				// 请求的 revision 已被压缩时返回，直接重新读取
+				CompactRevision string `json:"compact_revision"`
+				Events          []any  `json:"events"`
+			} `json:"result"`
+			Error *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := decoder.Decode(&message); err != nil {
+			return err
+		}
+		if message.Error != nil {
+			return fmt.Errorf("etcd watch error: %s", message.Error.Message)
+		}
+		if message.Result.CompactRevision != "" && message.Result.CompactRevision != "0" {
+			return nil
+		}
+		if message.Result.Canceled {
+			return fmt.Errorf("etcd watch canceled: %s", message.Result.CancelReason)
+		}
+		if len(message.Result.Events) > 0 {
+			return nil
+		}
+	}
+}
+
+func (e *etcdBackend) post(ctx context.Context, path string, body any) (*http.Response, error) {
+	token := ""
+	if e.username != "" {
+		var err error
+		if token, err = e.authenticate(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("etcd %s returned %d: %s", path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return resp, nil
+}
+
+// authenticate etcd 的 token 有效期较短，每次请求前重新获取
+func (e *etcdBackend) authenticate(ctx context.Context) (string, error) {
+	data, _ := json.Marshal(map[string]string{"name": e.username, "password": e.password})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint+"/v3/auth/authenticate", bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Token string `json:"token"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("etcd authenticate returned %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.Token, nil
+}
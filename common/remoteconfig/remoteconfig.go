@@ -0,0 +1,174 @@
+package remoteconfig
+
+import (
+	"context"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// backend 外部配置中心，load 读取前缀下的全部配置，wait 阻塞到配置发生变化或 ctx 结束
+type backend interface {
+	name() string
+	load(ctx context.Context) (values map[string]string, revision int64, err error)
+	wait(ctx context.Context, revision int64) error
+}
+
+var (
+	source   backend
+	prefix   string
+	restore  func(key string) error
+	revision int64
+
+	// managed 由配置中心接管的配置项，original 为接管前的值，配置中心删除后恢复
+	managed  = make(map[string]string)
+	original = make(map[string]string)
+	lock     sync.RWMutex
+
+	cancel context.CancelFunc
+)
+
+// Init 根据配置文件 remote_config 连接 etcd 或 Consul，首次加载完成后在后台监听变化
+// 配置中心中的值优先于数据库，release 用于配置项被删除后从数据库重新读取
+func Init(release func(key string) error) {
+	driver := viper.GetString("remote_config.driver")
+	if driver == "" {
+		return
+	}
+
+	endpoint := strings.TrimSuffix(viper.GetString("remote_config.endpoint"), "/")
+	prefix = viper.GetString("remote_config.prefix")
+	if endpoint == "" || prefix == "" {
+		logger.SysError("remote_config.endpoint and remote_config.prefix are required, remote config disabled")
+		return
+	}
+
+	switch driver {
+	case "etcd":
+		source = newEtcdBackend(endpoint, prefix)
+	case "consul":
+		source = newConsulBackend(endpoint, prefix)
+	default:
+		logger.SysError("unknown remote config driver: " + driver)
+		return
+	}
+	restore = release
+
+	ctx, cancelLoad := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelLoad()
+	if err := reload(ctx); err != nil {
+		// 启动时读取失败不影响服务，继续使用数据库中的配置，后台重试
+		logger.SysError(fmt.Sprintf("failed to load options from %s: %s", source.name(), err.Error()))
+	}
+
+	var watchCtx context.Context
+	watchCtx, cancel = context.WithCancel(context.Background())
+	go watch(watchCtx)
+}
+
+func Enabled() bool {
+	return source != nil
+}
+
+// Managed 配置项是否由配置中心管理，管理中的配置项不能在后台修改，也不会被数据库同步覆盖
+func Managed(key string) bool {
+	lock.RLock()
+	defer lock.RUnlock()
+	_, ok := managed[key]
+	return ok
+}
+
+// Revision 当前生效的配置版本，etcd 为 revision，Consul 为 ModifyIndex
+func Revision() int64 {
+	lock.RLock()
+	defer lock.RUnlock()
+	return revision
+}
+
+func Stop() {
+	if cancel != nil {
+		cancel()
+	}
+}
+
+func watch(ctx context.Context) {
+	for {
+		err := source.wait(ctx, Revision())
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = reload(ctx)
+		}
+		if err != nil {
+			logger.SysError(fmt.Sprintf("remote config %s error: %s", source.name(), err.Error()))
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+		}
+	}
+}
+
+// reload 读取全部配置并与当前接管的配置比较，只应用有变化的项
+func reload(ctx context.Context) error {
+	values, rev, err := source.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	lock.Lock()
+	var changed, released []string
+	for key, value := range values {
+		if current, ok := managed[key]; ok && current == value {
+			continue
+		}
+		if _, ok := managed[key]; !ok {
+			original[key] = config.GlobalOption.Get(key)
+		}
+		if err := config.GlobalOption.Set(key, value); err != nil {
+			logger.SysError(fmt.Sprintf("failed to apply remote option %s: %s", key, err.Error()))
+			continue
+		}
+		managed[key] = value
+		changed = append(changed, key)
+	}
+	for key := range managed {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		config.GlobalOption.Set(key, original[key])
+		delete(managed, key)
+		delete(original, key)
+		released = append(released, key)
+	}
+	revision = rev
+	lock.Unlock()
+
+	// 不再由配置中心管理的项以数据库为准
+	for _, key := range released {
+		if restore != nil {
+			restore(key)
+		}
+	}
+
+	if len(changed) > 0 || len(released) > 0 {
+		logger.SysLog(fmt.Sprintf("remote config %s revision %d: updated %v, released %v", source.name(), rev, changed, released))
+	}
+	return nil
+}
+
+// optionKey 去掉前缀后的配置名，子目录中的键忽略
+func optionKey(key string) (string, bool) {
+	key, ok := strings.CutPrefix(key, prefix)
+	if !ok || key == "" || strings.Contains(key, "/") {
+		return "", false
+	}
+	return key, true
+}
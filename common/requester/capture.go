@@ -0,0 +1,217 @@
+package requester
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// 记录请求和响应体的最大字节数，超出部分丢弃
+const captureBodyLimit = 1 << 20
+
+// 记录时隐藏的请求头和查询参数
+var (
+	sensitiveHeaders = map[string]bool{
+		"authorization":       true,
+		"proxy-authorization": true,
+		"api-key":             true,
+		"x-api-key":           true,
+		"x-goog-api-key":      true,
+		"mj-api-secret":       true,
+		"cookie":              true,
+	}
+	sensitiveQueryParams = []string{"key", "api_key", "access_token"}
+)
+
+// ErrDryRun 试运行时 CaptureTransport 只记录请求，不发送到上游
+var ErrDryRun = errors.New("dry run: request not sent to upstream")
+
+// Exchange 一次上游请求的原始内容
+type Exchange struct {
+	Method            string              `json:"method"`
+	URL               string              `json:"url"`
+	RequestHeaders    map[string][]string `json:"request_headers"`
+	RequestBody       string              `json:"request_body"`
+	StatusCode        int                 `json:"status_code"`
+	ResponseHeaders   map[string][]string `json:"response_headers,omitempty"`
+	ResponseBody      string              `json:"response_body"`
+	ResponseTruncated bool                `json:"response_truncated,omitempty"`
+	Error             string              `json:"error,omitempty"`
+
+	body *captureBuffer
+}
+
+// CaptureTransport 记录经过的上游请求和响应，用于调试，密钥相关的请求头和参数会被隐藏
+type CaptureTransport struct {
+	Base http.RoundTripper
+	// DryRun 为 true 时只记录请求，返回 ErrDryRun
+	DryRun bool
+
+	lock      sync.Mutex
+	exchanges []*Exchange
+}
+
+// CaptureClient 返回使用 client 连接配置并记录请求的新客户端
+func CaptureClient(client *http.Client) (*http.Client, *CaptureTransport) {
+	if client == nil {
+		client = HTTPClient
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	transport := &CaptureTransport{Base: base}
+	captured := *client
+	captured.Transport = transport
+	return &captured, transport
+}
+
+func (t *CaptureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	exchange := &Exchange{
+		Method:         req.Method,
+		URL:            redactURL(req.URL),
+		RequestHeaders: redactHeaders(req.Header),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		exchange.RequestBody = truncate(body)
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	t.lock.Lock()
+	t.exchanges = append(t.exchanges, exchange)
+	t.lock.Unlock()
+
+	if t.DryRun {
+		return nil, ErrDryRun
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		exchange.Error = err.Error()
+		return nil, err
+	}
+
+	exchange.StatusCode = resp.StatusCode
+	exchange.ResponseHeaders = resp.Header.Clone()
+	// 流式响应边读边记录，调用方读取完成后再取结果
+	exchange.body = &captureBuffer{}
+	resp.Body = &captureBody{ReadCloser: resp.Body, buffer: exchange.body}
+	return resp, nil
+}
+
+// Exchanges 返回已记录的请求，响应体为调用时已读取的部分
+func (t *CaptureTransport) Exchanges() []*Exchange {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, exchange := range t.exchanges {
+		if exchange.body != nil {
+			exchange.ResponseBody, exchange.ResponseTruncated = exchange.body.content()
+		}
+	}
+	return t.exchanges
+}
+
+// Redact 把请求和响应中出现的 secrets 替换为 ***，用于隐藏放在路径或请求体中的渠道密钥
+// 需要在 Exchanges 之后调用
+func (e *Exchange) Redact(secrets []string) {
+	replace := func(value string) string {
+		for _, secret := range secrets {
+			value = strings.ReplaceAll(value, secret, "***")
+		}
+		return value
+	}
+	replaceHeaders := func(header map[string][]string) {
+		for key, values := range header {
+			for i := range values {
+				values[i] = replace(values[i])
+			}
+			header[key] = values
+		}
+	}
+
+	e.URL = replace(e.URL)
+	e.RequestBody = replace(e.RequestBody)
+	e.ResponseBody = replace(e.ResponseBody)
+	e.Error = replace(e.Error)
+	replaceHeaders(e.RequestHeaders)
+	replaceHeaders(e.ResponseHeaders)
+}
+
+type captureBuffer struct {
+	lock      sync.Mutex
+	buffer    bytes.Buffer
+	truncated bool
+}
+
+func (b *captureBuffer) write(p []byte) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	remain := captureBodyLimit - b.buffer.Len()
+	if len(p) > remain {
+		p = p[:remain]
+		b.truncated = true
+	}
+	b.buffer.Write(p)
+}
+
+func (b *captureBuffer) content() (string, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buffer.String(), b.truncated
+}
+
+type captureBody struct {
+	io.ReadCloser
+	buffer *captureBuffer
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.buffer.write(p[:n])
+	}
+	return n, err
+}
+
+func truncate(body []byte) string {
+	if len(body) > captureBodyLimit {
+		body = body[:captureBodyLimit]
+	}
+	return string(body)
+}
+
+func redactHeaders(header http.Header) map[string][]string {
+	redacted := header.Clone()
+	for key := range redacted {
+		if sensitiveHeaders[strings.ToLower(key)] {
+			redacted[key] = []string{"***"}
+		}
+	}
+	return redacted
+}
+
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.User = nil
+	query := redacted.Query()
+	for _, param := range sensitiveQueryParams {
+		if query.Has(param) {
+			query.Set(param, "***")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
@@ -1,21 +1,69 @@
 package requester
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"net"
 	"net/http"
+	"one-api/common/logger"
 	"one-api/common/utils"
+	"one-api/metrics"
+	"strings"
+	"sync"
 	"time"
+
+	"github.com/spf13/viper"
 )
 
 var HTTPClient *http.Client
 
+// 按配置缓存的渠道 HTTP 客户端，配置相同的渠道共用连接池
+var channelHTTPClients sync.Map
+
+// HTTPClientConfig 渠道级别的上游 HTTP 客户端配置，未设置的项使用全局配置
+type HTTPClientConfig struct {
+	// 连接超时（秒）
+	ConnectTimeout int `json:"connect_timeout,omitempty"`
+	// 等待响应头超时（秒）
+	ResponseHeaderTimeout int `json:"response_header_timeout,omitempty"`
+	// 整个请求的超时（秒），包含读取响应体
+	Timeout int `json:"timeout,omitempty"`
+	// 跳过 TLS 证书校验
+	InsecureSkipVerify bool `json:"insecure_skip_verify,omitempty"`
+	// 自定义 CA 证书（PEM 格式）
+	CACert string `json:"ca_cert,omitempty"`
+	// 空闲连接池大小
+	MaxIdleConns        int `json:"max_idle_conns,omitempty"`
+	MaxIdleConnsPerHost int `json:"max_idle_conns_per_host,omitempty"`
+	// 空闲连接保持时间（秒）
+	IdleConnTimeout int `json:"idle_conn_timeout,omitempty"`
+	// 出口 IP 或网卡名称，多个用逗号分隔，按连接轮流使用，为空时使用 upstream.local_addr
+	LocalAddr string `json:"local_addr,omitempty"`
+}
+
 func InitHttpClient() {
-	trans := &http.Transport{
-		DialContext: utils.Socks5ProxyFunc,
-		Proxy:       utils.ProxyFunc,
+	tlsConfig, err := initTLSConfig()
+	if err != nil {
+		logger.FatalLog("failed to initialize upstream tls config: " + err.Error())
+	}
+	globalTLSConfig = tlsConfig
+	if globalTLSConfig.InsecureSkipVerify {
+		logger.SysLog("upstream tls certificate verification is disabled")
+	}
+
+	var trans *http.Transport
+	localAddrs := parseLocalAddrs(viper.GetString("upstream.local_addr"))
+	if localAddrs.Len() > 0 {
+		connectTimeout := time.Duration(utils.GetOrDefault("connect_timeout", 5)) * time.Second
+		trans = newTransport(utils.NewProxyDialContext(connectTimeout, localAddrs))
+	} else {
+		trans = newTransport(utils.Socks5ProxyFunc)
 	}
 
 	HTTPClient = &http.Client{
-		Transport: trans,
+		Transport: metrics.NewConnTracingTransport(trans),
 	}
 
 	relayTimeout := utils.GetOrDefault("relay_timeout", 0)
@@ -23,3 +71,128 @@ func InitHttpClient() {
 		HTTPClient.Timeout = time.Duration(relayTimeout) * time.Second
 	}
 }
+
+// newTransport 按全局连接池配置创建 Transport
+// 自定义 DialContext 后 Go 默认不再尝试 HTTP/2，需要显式开启
+func newTransport(dialContext func(ctx context.Context, network, addr string) (net.Conn, error)) *http.Transport {
+	return &http.Transport{
+		DialContext:         dialContext,
+		Proxy:               utils.ProxyFunc,
+		ForceAttemptHTTP2:   viper.GetBool("upstream.http2"),
+		MaxIdleConns:        viper.GetInt("upstream.max_idle_conns"),
+		MaxIdleConnsPerHost: viper.GetInt("upstream.max_idle_conns_per_host"),
+		MaxConnsPerHost:     viper.GetInt("upstream.max_conns_per_host"),
+		IdleConnTimeout:     time.Duration(viper.GetInt("upstream.idle_conn_timeout")) * time.Second,
+		TLSHandshakeTimeout: time.Duration(viper.GetInt("upstream.tls_handshake_timeout")) * time.Second,
+		TLSClientConfig:     globalTLSConfig.Clone(),
+	}
+}
+
+// parseLocalAddrs 解析出口地址，不属于本机的地址记录日志后忽略
+func parseLocalAddrs(value string) *utils.LocalAddrs {
+	if strings.TrimSpace(value) == "" {
+		return nil
+	}
+
+	localAddrs, skipped, err := utils.ParseLocalAddrs(value)
+	if err != nil {
+		logger.SysError("failed to parse upstream local address: " + err.Error())
+		return nil
+	}
+	if len(skipped) > 0 {
+		logger.SysLog("upstream local address not found on this host, skipped: " + strings.Join(skipped, ","))
+	}
+	return localAddrs
+}
+
+func (c *HTTPClientConfig) IsEmpty() bool {
+	return c == nil || *c == HTTPClientConfig{}
+}
+
+// Validate 检查配置是否合法
+func (c *HTTPClientConfig) Validate() error {
+	if c.IsEmpty() {
+		return nil
+	}
+
+	if c.ConnectTimeout < 0 || c.ResponseHeaderTimeout < 0 || c.Timeout < 0 || c.IdleConnTimeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+
+	if c.MaxIdleConns < 0 || c.MaxIdleConnsPerHost < 0 {
+		return errors.New("pool size must not be negative")
+	}
+
+	_, err := c.tlsConfig()
+	return err
+}
+
+func (c *HTTPClientConfig) tlsConfig() (*tls.Config, error) {
+	return newTLSConfig(c.CACert, c.InsecureSkipVerify)
+}
+
+// GetHTTPClient 获取渠道配置对应的 HTTP 客户端，配置为空时返回全局客户端
+func GetHTTPClient(c *HTTPClientConfig) *http.Client {
+	if c.IsEmpty() {
+		return HTTPClient
+	}
+
+	key, err := json.Marshal(c)
+	if err != nil {
+		return HTTPClient
+	}
+
+	if client, ok := channelHTTPClients.Load(string(key)); ok {
+		return client.(*http.Client)
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		logger.SysError("failed to create channel http client: " + err.Error())
+		return HTTPClient
+	}
+
+	actual, _ := channelHTTPClients.LoadOrStore(string(key), client)
+	return actual.(*http.Client)
+}
+
+func (c *HTTPClientConfig) newClient() (*http.Client, error) {
+	tlsConfig, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	connectTimeout := time.Duration(utils.GetOrDefault("connect_timeout", 5)) * time.Second
+	if c.ConnectTimeout > 0 {
+		connectTimeout = time.Duration(c.ConnectTimeout) * time.Second
+	}
+
+	localAddr := c.LocalAddr
+	if localAddr == "" {
+		localAddr = viper.GetString("upstream.local_addr")
+	}
+
+	trans := newTransport(utils.NewProxyDialContext(connectTimeout, parseLocalAddrs(localAddr)))
+	trans.TLSClientConfig = tlsConfig
+	trans.ResponseHeaderTimeout = time.Duration(c.ResponseHeaderTimeout) * time.Second
+	if c.MaxIdleConns > 0 {
+		trans.MaxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost > 0 {
+		trans.MaxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeout > 0 {
+		trans.IdleConnTimeout = time.Duration(c.IdleConnTimeout) * time.Second
+	}
+
+	client := &http.Client{
+		Transport: metrics.NewConnTracingTransport(trans),
+		Timeout:   HTTPClient.Timeout,
+	}
+
+	if c.Timeout > 0 {
+		client.Timeout = time.Duration(c.Timeout) * time.Second
+	}
+
+	return client, nil
+}
@@ -19,6 +19,14 @@ import (
 
 type HttpErrorHandler func(*http.Response) *types.OpenAIError
 
+// InvalidResponseCode 上游返回的响应无法解析、被截断或缺少必要字段
+const InvalidResponseCode = "invalid_upstream_response"
+
+// InvalidResponseError 上游的响应无效时返回 502，不把损坏的内容转发给客户端，并允许重试
+func InvalidResponseError(err error) *types.OpenAIErrorWithStatusCode {
+	return common.StringErrorWrapper(fmt.Sprintf("上游返回了无效的响应：%s", err.Error()), InvalidResponseCode, http.StatusBadGateway)
+}
+
 type HTTPRequester struct {
 	// requestBuilder    utils.RequestBuilder
 	CreateFormBuilder func(io.Writer) FormBuilder
@@ -26,6 +34,12 @@ type HTTPRequester struct {
 	proxyAddr         string
 	Context           context.Context
 	IsOpenAI          bool
+	// 渠道自定义的 HTTP 客户端，为空时使用全局客户端
+	HTTPClient *http.Client
+	// 渠道自定义的请求头，覆盖供应商设置的同名请求头
+	ExtraHeaders map[string]string
+	// 注入到 JSON 请求体顶层的字段
+	ExtraBody map[string]any
 }
 
 // NewHTTPRequester 创建一个新的 HTTPRequester 实例。
@@ -51,6 +65,14 @@ type requestOptions struct {
 
 type requestOption func(*requestOptions)
 
+func (r *HTTPRequester) getHTTPClient() *http.Client {
+	if r.HTTPClient != nil {
+		return r.HTTPClient
+	}
+
+	return HTTPClient
+}
+
 func (r *HTTPRequester) setProxy() context.Context {
 	return utils.SetProxy(r.proxyAddr, r.Context)
 }
@@ -64,6 +86,16 @@ func (r *HTTPRequester) NewRequest(method, url string, setters ...requestOption)
 	for _, setter := range setters {
 		setter(args)
 	}
+	for k, v := range r.ExtraHeaders {
+		args.header.Set(k, v)
+	}
+	if len(r.ExtraBody) > 0 && args.body != nil {
+		body, err := injectBody(args.body, r.ExtraBody)
+		if err != nil {
+			return nil, err
+		}
+		args.body = body
+	}
 	req, err := utils.RequestBuilder(r.setProxy(), method, url, args.body, args.header)
 	if err != nil {
 		return nil, err
@@ -72,9 +104,38 @@ func (r *HTTPRequester) NewRequest(method, url string, setters ...requestOption)
 	return req, nil
 }
 
+// injectBody 将字段合并到 JSON 请求体的顶层，表单、流等非 JSON 请求体保持不变
+func injectBody(body any, extra map[string]any) (any, error) {
+	var raw []byte
+	switch v := body.(type) {
+	case io.Reader:
+		return body, nil
+	case []byte:
+		if !json.Valid(v) {
+			return body, nil
+		}
+		raw = v
+	default:
+		var err error
+		if raw, err = json.Marshal(body); err != nil {
+			return nil, err
+		}
+	}
+
+	var requestMap map[string]any
+	if err := json.Unmarshal(raw, &requestMap); err != nil {
+		// 顶层不是对象时无法注入
+		return body, nil
+	}
+	for key, value := range extra {
+		requestMap[key] = value
+	}
+	return requestMap, nil
+}
+
 // 发送请求
 func (r *HTTPRequester) SendRequest(req *http.Request, response any, outputResp bool) (*http.Response, *types.OpenAIErrorWithStatusCode) {
-	resp, err := HTTPClient.Do(req)
+	resp, err := r.getHTTPClient().Do(req)
 	if err != nil {
 		return nil, common.ErrorWrapper(err, "http_request_failed", http.StatusInternalServerError)
 	}
@@ -105,7 +166,7 @@ func (r *HTTPRequester) SendRequest(req *http.Request, response any, outputResp
 	}
 
 	if err != nil {
-		return nil, common.ErrorWrapper(err, "decode_response_failed", http.StatusInternalServerError)
+		return nil, InvalidResponseError(err)
 	}
 
 	return resp, nil
@@ -114,7 +175,7 @@ func (r *HTTPRequester) SendRequest(req *http.Request, response any, outputResp
 // 发送请求 RAW
 func (r *HTTPRequester) SendRequestRaw(req *http.Request) (*http.Response, *types.OpenAIErrorWithStatusCode) {
 	// 发送请求
-	resp, err := HTTPClient.Do(req)
+	resp, err := r.getHTTPClient().Do(req)
 	if err != nil {
 		return nil, common.ErrorWrapper(err, "http_request_failed", http.StatusInternalServerError)
 	}
@@ -198,6 +259,7 @@ func HandleErrorResp(resp *http.Response, toOpenAIError HttpErrorHandler, isPref
 			Param:   strconv.Itoa(resp.StatusCode),
 		},
 	}
+	openAIErrorWithStatusCode.RetryAfter = ParseRetryAfter(resp.Header)
 
 	defer resp.Body.Close()
 
@@ -0,0 +1,88 @@
+package requester
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net/http"
+	"one-api/types"
+)
+
+// 透传时读取上游数据的缓冲区大小
+const passthroughBufferSize = 32 * 1024
+
+// PassthroughInspector 检查即将透传的每一行数据，只应读取 line，不能持有或修改
+// forward 为 false 时该行不写入客户端；返回 io.EOF 表示流正常结束，其它错误表示流异常
+type PassthroughInspector func(line []byte) (forward bool, err error)
+
+// PassthroughStreamInterface 无需转换的流式响应，可以将上游的 SSE 字节直接写入客户端
+type PassthroughStreamInterface interface {
+	StreamReaderInterface[string]
+	// Passthrough 将上游数据逐行写入 w，每个事件结束（空行）时调用 flush
+	// 遇到 [DONE] 时停止，由调用方写入结束标记
+	Passthrough(w io.Writer, flush func()) error
+}
+
+type passthroughStream struct {
+	*streamReader[string]
+	inspector PassthroughInspector
+}
+
+// RequestPassthroughStream 获取可透传的流式响应
+// handlerPrefix 用于 Recv 的兼容模式（例如需要转换为其它格式时），inspector 用于透传模式
+func RequestPassthroughStream(requester *HTTPRequester, resp *http.Response, handlerPrefix HandlerPrefix[string], inspector PassthroughInspector) (*passthroughStream, *types.OpenAIErrorWithStatusCode) {
+	stream, errWithCode := RequestStream(requester, resp, handlerPrefix)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	stream.reader = bufio.NewReaderSize(resp.Body, passthroughBufferSize)
+
+	return &passthroughStream{
+		streamReader: stream,
+		inspector:    inspector,
+	}, nil
+}
+
+func (stream *passthroughStream) Passthrough(w io.Writer, flush func()) error {
+	var longLine []byte
+	for {
+		line, readErr := stream.reader.ReadSlice('\n')
+		if readErr == bufio.ErrBufferFull {
+			// 超长的行需要拼接后再处理
+			longLine = append(longLine, line...)
+			continue
+		}
+
+		if longLine != nil {
+			line = append(longLine, line...)
+			longLine = nil
+		}
+
+		if len(line) > 0 {
+			trimmed := bytes.TrimSpace(line)
+			if len(trimmed) == 0 {
+				// 空行代表一个事件结束
+				if _, err := w.Write(line); err != nil {
+					return err
+				}
+				flush()
+			} else {
+				forward, err := stream.inspector(trimmed)
+				if err != nil {
+					return err
+				}
+
+				if forward {
+					if _, err := w.Write(line); err != nil {
+						return err
+					}
+				}
+			}
+		}
+
+		if readErr != nil {
+			return readErr
+		}
+	}
+}
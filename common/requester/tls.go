@@ -0,0 +1,94 @@
+package requester
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+// 上游请求使用的全局 TLS 策略，默认校验证书
+var globalTLSConfig = &tls.Config{}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// initTLSConfig 读取全局 TLS 配置
+// upstream.tls.insecure_skip_verify 跳过证书校验，upstream.tls.ca_file 追加信任的 CA 证书，
+// upstream.tls.min_version 限制最低 TLS 版本
+func initTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: viper.GetBool("upstream.tls.insecure_skip_verify"),
+	}
+
+	if minVersion := viper.GetString("upstream.tls.min_version"); minVersion != "" {
+		version, ok := tlsVersions[minVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported tls min_version: %s", minVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if caFile := viper.GetString("upstream.tls.ca_file"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tls ca_file: %w", err)
+		}
+
+		pool, err := appendCACert(nil, pem)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+// newTLSConfig 在全局 TLS 策略的基础上追加渠道的 CA 证书和校验设置
+func newTLSConfig(caCert string, insecureSkipVerify bool) (*tls.Config, error) {
+	tlsConfig := globalTLSConfig.Clone()
+	if insecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+
+	if caCert != "" {
+		pool, err := appendCACert(tlsConfig.RootCAs, []byte(caCert))
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
+func appendCACert(pool *x509.CertPool, pem []byte) (*x509.CertPool, error) {
+	if pool == nil {
+		var err error
+		pool, err = x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+	} else {
+		pool = pool.Clone()
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("invalid ca certificate: no PEM certificate found")
+	}
+
+	return pool, nil
+}
+
+// GetTLSConfig 获取全局 TLS 策略，供 WebSocket 等其它上游连接使用
+func GetTLSConfig() *tls.Config {
+	return globalTLSConfig.Clone()
+}
@@ -0,0 +1,89 @@
+package requester
+
+import (
+	"net/http"
+	"one-api/common"
+	"one-api/common/utils"
+	"one-api/types"
+	"time"
+)
+
+// WSSigner 为连接地址签名，返回实际连接的地址和握手请求头，签名通常包含时间戳，每次连接都会重新计算
+type WSSigner func(rawURL string) (string, http.Header, error)
+
+// WSUsageExtractor 从上游的原始消息中提取用量，在消息处理函数之前调用
+type WSUsageExtractor func(message []byte, usage *types.Usage)
+
+// WSBridge 只提供 WebSocket 接口的上游：签名后建立连接，发送一次请求，将返回的消息逐条转换为流
+// 新的 WebSocket 上游只需要提供签名、用量提取和消息处理函数
+// 转换后的数据被读取之后才会读取下一条消息，客户端读取慢时由 TCP 流控让上游放慢发送
+type WSBridge struct {
+	Requester      *WSRequester
+	Signer         WSSigner
+	UsageExtractor WSUsageExtractor
+	// 两条消息之间的最长等待时间，为 0 时使用 relay_timeout，都为 0 时不限制
+	ReadTimeout time.Duration
+}
+
+// WSBridgeRequest 一次 WebSocket 请求，Handler 的用法与 HTTP 流式请求相同
+type WSBridgeRequest[T streamable] struct {
+	URL     string
+	Header  http.Header
+	Payload any
+	Handler HandlerPrefix[T]
+	// UsageExtractor 写入的用量
+	Usage *types.Usage
+}
+
+func NewWSBridge(proxyAddr string, signer WSSigner, usageExtractor WSUsageExtractor) *WSBridge {
+	return &WSBridge{
+		Requester:      NewWSRequester(proxyAddr),
+		Signer:         signer,
+		UsageExtractor: usageExtractor,
+	}
+}
+
+// SendWSBridgeRequest 建立连接并发送请求，返回的流读取完毕或调用 Close 后关闭连接
+func SendWSBridgeRequest[T streamable](bridge *WSBridge, request *WSBridgeRequest[T]) (StreamReaderInterface[T], *types.OpenAIErrorWithStatusCode) {
+	url := request.URL
+	header := request.Header
+	if bridge.Signer != nil {
+		signedURL, signedHeader, err := bridge.Signer(url)
+		if err != nil {
+			return nil, common.ErrorWrapper(err, "ws_sign_failed", http.StatusInternalServerError)
+		}
+		url = signedURL
+		if header == nil {
+			header = signedHeader
+		} else {
+			for key, values := range signedHeader {
+				header[key] = values
+			}
+		}
+	}
+
+	conn, err := bridge.Requester.NewRequest(url, header)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "ws_request_failed", http.StatusInternalServerError)
+	}
+
+	if err := conn.WriteJSON(request.Payload); err != nil {
+		conn.Close()
+		return nil, common.ErrorWrapper(err, "ws_request_failed", http.StatusInternalServerError)
+	}
+
+	readTimeout := bridge.ReadTimeout
+	if readTimeout == 0 {
+		readTimeout = time.Duration(utils.GetOrDefault("relay_timeout", 0)) * time.Second
+	}
+
+	stream := newWSReader(conn, request.Handler)
+	stream.readTimeout = readTimeout
+	if bridge.UsageExtractor != nil && request.Usage != nil {
+		stream.onMessage = func(message []byte) {
+			bridge.UsageExtractor(message, request.Usage)
+		}
+	}
+
+	return stream, nil
+}
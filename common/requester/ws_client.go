@@ -16,6 +16,7 @@ import (
 func GetWSClient(proxyAddr string) *websocket.Dialer {
 	dialer := &websocket.Dialer{
 		HandshakeTimeout: time.Duration(utils.GetOrDefault("connect_timeout", 5)) * time.Second,
+		TLSClientConfig:  GetTLSConfig(),
 	}
 
 	if proxyAddr != "" {
@@ -2,6 +2,9 @@ package requester
 
 import (
 	"bytes"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -9,24 +12,51 @@ import (
 type wsReader[T streamable] struct {
 	reader        *websocket.Conn
 	handlerPrefix HandlerPrefix[T]
+	// 每条原始消息在 handlerPrefix 之前调用
+	onMessage   func(message []byte)
+	readTimeout time.Duration
 
 	DataChan chan T
 	ErrChan  chan error
+
+	started   atomic.Bool
+	exited    chan struct{}
+	closeOnce sync.Once
+}
+
+// newWSReader DataChan 不设缓冲，数据被读取后才读取下一条消息，也保证结束信号在所有数据之后
+func newWSReader[T streamable](conn *websocket.Conn, handlerPrefix HandlerPrefix[T]) *wsReader[T] {
+	return &wsReader[T]{
+		reader:        conn,
+		handlerPrefix: handlerPrefix,
+		DataChan:      make(chan T),
+		ErrChan:       make(chan error, 1),
+		exited:        make(chan struct{}),
+	}
 }
 
 func (stream *wsReader[T]) Recv() (<-chan T, <-chan error) {
+	stream.started.Store(true)
 	go stream.processLines()
 	return stream.DataChan, stream.ErrChan
 }
 
 func (stream *wsReader[T]) processLines() {
+	defer close(stream.exited)
+
 	for {
+		if stream.readTimeout > 0 {
+			stream.reader.SetReadDeadline(time.Now().Add(stream.readTimeout))
+		}
 		_, msg, err := stream.reader.ReadMessage()
 		if err != nil {
 			stream.ErrChan <- err
 			return
 		}
 
+		if stream.onMessage != nil {
+			stream.onMessage(msg)
+		}
 		stream.handlerPrefix(&msg, stream.DataChan, stream.ErrChan)
 
 		if msg == nil {
@@ -39,6 +69,22 @@ func (stream *wsReader[T]) processLines() {
 	}
 }
 
+// Close 关闭连接，调用方不再读取时丢弃剩余的数据，避免读取协程阻塞
 func (stream *wsReader[T]) Close() {
-	stream.reader.Close()
+	stream.closeOnce.Do(func() {
+		stream.reader.Close()
+		if !stream.started.Load() {
+			return
+		}
+		go func() {
+			for {
+				select {
+				case <-stream.DataChan:
+				case <-stream.ErrChan:
+				case <-stream.exited:
+					return
+				}
+			}
+		}()
+	})
 }
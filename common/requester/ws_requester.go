@@ -3,8 +3,6 @@ package requester
 import (
 	"errors"
 	"net/http"
-	"one-api/common"
-	"one-api/types"
 
 	"github.com/gorilla/websocket"
 )
@@ -32,23 +30,6 @@ func (w *WSRequester) NewRequest(url string, header http.Header) (*websocket.Con
 	return conn, nil
 }
 
-func SendWSJsonRequest[T streamable](conn *websocket.Conn, data any, handlerPrefix HandlerPrefix[T]) (*wsReader[T], *types.OpenAIErrorWithStatusCode) {
-	err := conn.WriteJSON(data)
-	if err != nil {
-		return nil, common.ErrorWrapper(err, "ws_request_failed", http.StatusInternalServerError)
-	}
-
-	stream := &wsReader[T]{
-		reader:        conn,
-		handlerPrefix: handlerPrefix,
-
-		DataChan: make(chan T),
-		ErrChan:  make(chan error),
-	}
-
-	return stream, nil
-}
-
 // 设置请求头
 func (w *WSRequester) WithHeader(headers map[string]string) http.Header {
 	header := make(http.Header)
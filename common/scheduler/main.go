@@ -78,3 +78,19 @@ func (tm *TaskManager) GetJob(name string) *JobInfo {
 
 	return tm.jobs[name]
 }
+
+// RemoveJob 移除任务，任务不存在时忽略
+func (tm *TaskManager) RemoveJob(name string) {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	job, exists := tm.jobs[name]
+	if !exists {
+		return
+	}
+
+	if err := tm.scheduler.RemoveJob(job.Job.ID()); err != nil {
+		logger.SysError(fmt.Sprintf("移除任务 %s 失败: %v", name, err))
+	}
+	delete(tm.jobs, name)
+}
@@ -0,0 +1,222 @@
+package secrets
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"one-api/common/logger"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/secretsmanager"
+	"github.com/spf13/viper"
+)
+
+// 渠道 key 可以引用外部密钥，# 之后为 JSON 字段名，只能读取配置允许的环境变量和路径
+//
+//	env://ONEAPI_SECRET_OPENAI
+//	vault://secret/data/openai#api_key
+//	aws-sm://prod/openai#api_key
+const (
+	SchemeEnv       = "env://"
+	SchemeVault     = "vault://"
+	SchemeAWSSecret = "aws-sm://"
+)
+
+type cachedSecret struct {
+	value     string
+	expiredAt time.Time
+}
+
+var cache = struct {
+	sync.RWMutex
+	secrets map[string]*cachedSecret
+}{
+	secrets: make(map[string]*cachedSecret),
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// IsReference key 是否为外部密钥引用
+func IsReference(key string) bool {
+	return strings.HasPrefix(key, SchemeEnv) || strings.HasPrefix(key, SchemeVault) || strings.HasPrefix(key, SchemeAWSSecret)
+}
+
+// Resolve 解析外部密钥引用并缓存，刷新失败时继续使用过期的缓存
+func Resolve(ref string) (string, error) {
+	cache.RLock()
+	cached, ok := cache.secrets[ref]
+	cache.RUnlock()
+	if ok && time.Now().Before(cached.expiredAt) {
+		return cached.value, nil
+	}
+
+	value, err := fetch(ref)
+	if err != nil {
+		if ok {
+			logger.SysError(fmt.Sprintf("failed to refresh secret %s, using cached value: %s", ref, err.Error()))
+			return cached.value, nil
+		}
+		return "", err
+	}
+
+	ttl := time.Duration(viper.GetInt("secret_ref.cache_ttl")) * time.Second
+	cache.Lock()
+	cache.secrets[ref] = &cachedSecret{value: value, expiredAt: time.Now().Add(ttl)}
+	cache.Unlock()
+
+	return value, nil
+}
+
+// Purge 清空缓存，下次使用时重新读取
+func Purge() {
+	cache.Lock()
+	defer cache.Unlock()
+
+	cache.secrets = make(map[string]*cachedSecret)
+}
+
+func fetch(ref string) (string, error) {
+	switch {
+	case strings.HasPrefix(ref, SchemeEnv):
+		name := strings.TrimPrefix(ref, SchemeEnv)
+		// 只能读取指定前缀的环境变量，避免通过引用读取数据库连接串等服务器配置
+		prefix := viper.GetString("secret_ref.env_prefix")
+		if prefix == "" || !strings.HasPrefix(name, prefix) {
+			return "", fmt.Errorf("environment variable %s is not allowed, name must start with %s", name, prefix)
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return value, nil
+	case strings.HasPrefix(ref, SchemeVault):
+		path, field, _ := strings.Cut(strings.TrimPrefix(ref, SchemeVault), "#")
+		if !allowedPath(strings.TrimPrefix(path, "/"), viper.GetStringSlice("secret_ref.vault.allowed_paths")) {
+			return "", fmt.Errorf("vault path %s is not in secret_ref.vault.allowed_paths", path)
+		}
+		return fetchVault(path, field)
+	case strings.HasPrefix(ref, SchemeAWSSecret):
+		name, field, _ := strings.Cut(strings.TrimPrefix(ref, SchemeAWSSecret), "#")
+		if !allowedPath(name, viper.GetStringSlice("secret_ref.aws.allowed_names")) {
+			return "", fmt.Errorf("secret %s is not in secret_ref.aws.allowed_names", name)
+		}
+		return fetchAWSSecret(name, field)
+	}
+	return "", errors.New("unsupported secret reference")
+}
+
+// allowedPath 路径是否以允许列表中的某个前缀开头，列表为空时不允许
+func allowedPath(path string, allowed []string) bool {
+	if strings.Contains(path, "..") {
+		return false
+	}
+	for _, prefix := range allowed {
+		if prefix != "" && strings.HasPrefix(path, strings.TrimPrefix(prefix, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchVault 读取 Vault KV 密钥，同时支持 KV v1 和 v2 的返回格式
+func fetchVault(path, field string) (string, error) {
+	address := strings.TrimSuffix(viper.GetString("secret_ref.vault.address"), "/")
+	if address == "" {
+		return "", errors.New("secret_ref.vault.address is not set")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, address+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", viper.GetString("secret_ref.vault.token"))
+	if namespace := viper.GetString("secret_ref.vault.namespace"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned status code %d", resp.StatusCode)
+	}
+
+	var response struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", err
+	}
+
+	data := response.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		data = nested
+	}
+	return pickField(data, field)
+}
+
+// fetchAWSSecret 读取 AWS Secrets Manager 密钥，凭证从环境变量或实例角色中获取
+func fetchAWSSecret(name, field string) (string, error) {
+	sess, err := session.NewSession(&aws.Config{
+		Region: aws.String(viper.GetString("secret_ref.aws.region")),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	output, err := secretsmanager.New(sess).GetSecretValue(&secretsmanager.GetSecretValueInput{
+		SecretId: aws.String(name),
+	})
+	if err != nil {
+		return "", err
+	}
+	if output.SecretString == nil {
+		return "", fmt.Errorf("secret %s has no string value", name)
+	}
+
+	if field == "" {
+		return *output.SecretString, nil
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal([]byte(*output.SecretString), &data); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object", name)
+	}
+	return pickField(data, field)
+}
+
+// pickField 获取指定字段，未指定字段时密钥中必须只有一个字段
+func pickField(data map[string]any, field string) (string, error) {
+	if field == "" {
+		if len(data) != 1 {
+			return "", errors.New("secret has multiple fields, specify one with #field")
+		}
+		for name := range data {
+			field = name
+		}
+	}
+
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("field %s not found in secret", field)
+	}
+	if text, ok := value.(string); ok {
+		return text, nil
+	}
+
+	// 非字符串字段按 JSON 返回，例如 Vertex AI 的服务账号凭证
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
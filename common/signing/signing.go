@@ -0,0 +1,34 @@
+// Package signing 上游请求签名，腾讯混元 HMAC、讯飞鉴权 URL 和智谱 JWT
+// 拼接待签名字符串与签名分开实现，便于单独校验
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+)
+
+func hmacBase64(h func() hash.Hash, key, data string) string {
+	mac := hmac.New(h, []byte(key))
+	mac.Write([]byte(data))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// HmacSha1Base64 HMAC-SHA1 签名，结果为 base64
+func HmacSha1Base64(key, data string) string {
+	return hmacBase64(sha1.New, key, data)
+}
+
+// HmacSha256Base64 HMAC-SHA256 签名，结果为 base64
+func HmacSha256Base64(key, data string) string {
+	return hmacBase64(sha256.New, key, data)
+}
+
+// keyFingerprint 密钥摘要，用作缓存 key，密钥变更后缓存自然失效
+func keyFingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
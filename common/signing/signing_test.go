@@ -0,0 +1,73 @@
+package signing_test
+
+import (
+	"testing"
+	"time"
+
+	"one-api/common/signing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTencentCanonicalString(t *testing.T) {
+	canonical := signing.TencentCanonicalString(signing.TencentSignEndpoint, map[string]string{
+		"timestamp": "1700000000",
+		"app_id":    "123",
+		"messages":  `[{"role":"user","content":"hi"}]`,
+	})
+	assert.Equal(t, signing.TencentSignEndpoint+`?app_id=123&messages=[{"role":"user","content":"hi"}]&timestamp=1700000000`, canonical)
+
+	cred, err := signing.ParseTencentKey("123|sid|skey")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(123), cred.AppId)
+	assert.Equal(t, signing.HmacSha1Base64("skey", canonical), cred.Sign(map[string]string{
+		"app_id":    "123",
+		"messages":  `[{"role":"user","content":"hi"}]`,
+		"timestamp": "1700000000",
+	}))
+
+	_, err = signing.ParseTencentKey("abc|sid|skey")
+	assert.NotNil(t, err)
+	_, err = signing.ParseTencentKey("123|sid")
+	assert.NotNil(t, err)
+}
+
+func TestXunfeiCanonicalString(t *testing.T) {
+	date := time.Unix(0, 0).UTC().Format(time.RFC1123)
+	assert.Equal(t, "host: spark-api.xf-yun.com\ndate: Thu, 01 Jan 1970 00:00:00 UTC\nGET /v1.1/chat HTTP/1.1",
+		signing.XunfeiCanonicalString("spark-api.xf-yun.com", date, "/v1.1/chat"))
+
+	cred, err := signing.ParseXunfeiKey("app|secret|key")
+	assert.Nil(t, err)
+	authURL, err := cred.AuthURL("wss://spark-api.xf-yun.com/v1.1/chat", time.Unix(0, 0))
+	assert.Nil(t, err)
+	assert.Contains(t, authURL, "wss://spark-api.xf-yun.com/v1.1/chat?authorization=")
+	assert.Contains(t, authURL, "&host=spark-api.xf-yun.com")
+
+	_, err = signing.ParseXunfeiKey("app||key")
+	assert.NotNil(t, err)
+}
+
+func TestZhipuToken(t *testing.T) {
+	cred, err := signing.ParseZhipuKey("id.secret")
+	assert.Nil(t, err)
+	now := time.Unix(1700000000, 0)
+	token1, err := cred.Token(now, signing.ZhipuTokenTTL)
+	assert.Nil(t, err)
+	token2, _ := cred.Token(now, signing.ZhipuTokenTTL)
+	assert.Equal(t, token1, token2)
+
+	_, err = signing.ParseZhipuKey("id")
+	assert.NotNil(t, err)
+}
+
+func TestTencentTC3CanonicalRequest(t *testing.T) {
+	canonical := signing.TencentTC3CanonicalRequest("hunyuan.tencentcloudapi.com", "application/json", "ChatCompletions", []byte("{}"))
+	assert.Equal(t, "POST\n/\n\ncontent-type:application/json\nhost:hunyuan.tencentcloudapi.com\nx-tc-action:chatcompletions\n\ncontent-type;host;x-tc-action\n44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a", canonical)
+
+	cred, err := signing.ParseTencentCloudKey("123|sid|skey")
+	assert.Nil(t, err)
+	assert.Equal(t, "sid", cred.SecretId)
+	authorization := cred.Authorization("hunyuan", "hunyuan.tencentcloudapi.com", "application/json", "ChatCompletions", []byte("{}"), 0)
+	assert.Contains(t, authorization, "TC3-HMAC-SHA256 Credential=sid/1970-01-01/hunyuan/tc3_request, SignedHeaders=content-type;host;x-tc-action, Signature=")
+}
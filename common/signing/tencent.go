@@ -0,0 +1,126 @@
+package signing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const TencentSignEndpoint = "hunyuan.cloud.tencent.com/hyllm/v1/chat/completions"
+
+// TencentCredential 腾讯混元密钥，格式为 AppId|SecretId|SecretKey
+type TencentCredential struct {
+	AppId     int64
+	SecretId  string
+	SecretKey string
+}
+
+func ParseTencentKey(key string) (*TencentCredential, error) {
+	parts := strings.Split(key, "|")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid tencent key, the format should be AppId|SecretId|SecretKey")
+	}
+	appId, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("invalid tencent app id")
+	}
+	if parts[1] == "" || parts[2] == "" {
+		return nil, errors.New("tencent secret id and secret key cannot be empty")
+	}
+	return &TencentCredential{
+		AppId:     appId,
+		SecretId:  parts[1],
+		SecretKey: parts[2],
+	}, nil
+}
+
+// TencentCanonicalString 参数拼接为 key=value 后按字典序排列，接在请求地址后
+func TencentCanonicalString(endpoint string, params map[string]string) string {
+	pairs := make([]string, 0, len(params))
+	for k, v := range params {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return endpoint + "?" + strings.Join(pairs, "&")
+}
+
+// Sign 按参数生成请求签名
+func (cred *TencentCredential) Sign(params map[string]string) string {
+	return HmacSha1Base64(cred.SecretKey, TencentCanonicalString(TencentSignEndpoint, params))
+}
+
+const TencentTC3Algorithm = "TC3-HMAC-SHA256"
+
+// TencentCloudCredential 腾讯云 API 3.0 密钥，格式为 SecretId|SecretKey，兼容旧格式 AppId|SecretId|SecretKey
+type TencentCloudCredential struct {
+	SecretId  string
+	SecretKey string
+}
+
+func ParseTencentCloudKey(key string) (*TencentCloudCredential, error) {
+	parts := strings.Split(key, "|")
+	if len(parts) == 3 {
+		parts = parts[1:]
+	}
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.New("invalid tencent cloud key, the format should be SecretId|SecretKey")
+	}
+	return &TencentCloudCredential{
+		SecretId:  parts[0],
+		SecretKey: parts[1],
+	}, nil
+}
+
+// TencentTC3CanonicalRequest 签名方法 v3 的规范请求串，只签名 content-type、host 和 x-tc-action
+// https://cloud.tencent.com/document/api/1729/101843
+func TencentTC3CanonicalRequest(host, contentType, action string, payload []byte) string {
+	payloadHash := sha256.Sum256(payload)
+	canonicalHeaders := fmt.Sprintf("content-type:%s\nhost:%s\nx-tc-action:%s\n", contentType, host, strings.ToLower(action))
+	return strings.Join([]string{
+		http.MethodPost,
+		"/",
+		"",
+		canonicalHeaders,
+		"content-type;host;x-tc-action",
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+}
+
+// TencentTC3StringToSign 待签名字符串，credentialScope 为 日期/服务/tc3_request
+func TencentTC3StringToSign(timestamp int64, credentialScope, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	return strings.Join([]string{
+		TencentTC3Algorithm,
+		strconv.FormatInt(timestamp, 10),
+		credentialScope,
+		hex.EncodeToString(hash[:]),
+	}, "\n")
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// Authorization 生成签名方法 v3 的 Authorization 请求头
+func (cred *TencentCloudCredential) Authorization(service, host, contentType, action string, payload []byte, timestamp int64) string {
+	date := time.Unix(timestamp, 0).UTC().Format("2006-01-02")
+	credentialScope := date + "/" + service + "/tc3_request"
+	stringToSign := TencentTC3StringToSign(timestamp, credentialScope, TencentTC3CanonicalRequest(host, contentType, action, payload))
+
+	secretDate := hmacSha256([]byte("TC3"+cred.SecretKey), date)
+	secretService := hmacSha256(secretDate, service)
+	secretSigning := hmacSha256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSha256(secretSigning, stringToSign))
+
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=content-type;host;x-tc-action, Signature=%s",
+		TencentTC3Algorithm, cred.SecretId, credentialScope, signature)
+}
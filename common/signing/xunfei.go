@@ -0,0 +1,60 @@
+package signing
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// XunfeiCredential 讯飞星火密钥，格式为 APPID|APISecret|APIKey
+type XunfeiCredential struct {
+	AppId     string
+	APISecret string
+	APIKey    string
+}
+
+func ParseXunfeiKey(key string) (*XunfeiCredential, error) {
+	parts := strings.Split(key, "|")
+	if len(parts) != 3 {
+		return nil, errors.New("invalid xunfei key, the format should be APPID|APISecret|APIKey")
+	}
+	if parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, errors.New("xunfei APPID, APISecret and APIKey cannot be empty")
+	}
+	return &XunfeiCredential{
+		AppId:     parts[0],
+		APISecret: parts[1],
+		APIKey:    parts[2],
+	}, nil
+}
+
+// XunfeiCanonicalString 待签名字符串，依次为 host、date 和 request-line
+func XunfeiCanonicalString(host, date, path string) string {
+	return strings.Join([]string{"host: " + host, "date: " + date, "GET " + path + " HTTP/1.1"}, "\n")
+}
+
+// AuthURL 在 websocket 地址后附加 host、date 和 authorization 参数
+// https://www.xfyun.cn/doc/spark/general_url_authentication.html
+func (cred *XunfeiCredential) AuthURL(rawURL string, now time.Time) (string, error) {
+	ul, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if ul.Host == "" {
+		return "", errors.New("invalid xunfei url")
+	}
+
+	date := now.UTC().Format(time.RFC1123)
+	signature := HmacSha256Base64(cred.APISecret, XunfeiCanonicalString(ul.Host, date, ul.Path))
+	authorization := fmt.Sprintf(`hmac username="%s", algorithm="%s", headers="%s", signature="%s"`,
+		cred.APIKey, "hmac-sha256", "host date request-line", signature)
+
+	v := url.Values{}
+	v.Add("host", ul.Host)
+	v.Add("date", date)
+	v.Add("authorization", base64.StdEncoding.EncodeToString([]byte(authorization)))
+	return rawURL + "?" + v.Encode(), nil
+}
@@ -0,0 +1,69 @@
+package signing
+
+import (
+	"errors"
+	"one-api/common/cache"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	ZhipuTokenTTL = 24 * time.Hour
+	// 缓存比 token 提前过期，避免使用即将过期的 token
+	zhipuTokenCacheTTL = ZhipuTokenTTL - 10*time.Minute
+	zhipuCacheKey      = "signing:zhipu:"
+)
+
+// ZhipuCredential 智谱密钥，格式为 id.secret
+type ZhipuCredential struct {
+	Id     string
+	Secret string
+}
+
+func ParseZhipuKey(key string) (*ZhipuCredential, error) {
+	parts := strings.Split(key, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.New("invalid zhipu key, the format should be id.secret")
+	}
+	return &ZhipuCredential{
+		Id:     parts[0],
+		Secret: parts[1],
+	}, nil
+}
+
+// Token 生成 JWT，exp 和 timestamp 为毫秒
+func (cred *ZhipuCredential) Token(now time.Time, ttl time.Duration) (string, error) {
+	payload := jwt.MapClaims{
+		"api_key":   cred.Id,
+		"exp":       now.Add(ttl).UnixMilli(),
+		"timestamp": now.UnixMilli(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
+	token.Header["alg"] = "HS256"
+	token.Header["sign_type"] = "SIGN"
+
+	return token.SignedString([]byte(cred.Secret))
+}
+
+// ZhipuToken 获取密钥对应的 JWT，按密钥摘要缓存
+func ZhipuToken(key string) (string, error) {
+	cacheKey := zhipuCacheKey + keyFingerprint(key)
+	if token, err := cache.GetCache[string](cacheKey); err == nil && token != "" {
+		return token, nil
+	}
+
+	cred, err := ParseZhipuKey(key)
+	if err != nil {
+		return "", err
+	}
+	token, err := cred.Token(time.Now(), ZhipuTokenTTL)
+	if err != nil {
+		return "", err
+	}
+
+	cache.SetCache(cacheKey, token, zhipuTokenCacheTTL)
+	return token, nil
+}
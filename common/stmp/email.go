@@ -93,88 +93,32 @@ func GetSystemStmp() (*StmpConfig, error) {
 	return NewStmp(config.SMTPServer, config.SMTPPort, config.SMTPAccount, config.SMTPToken, config.SMTPFrom), nil
 }
 
-func SendPasswordResetEmail(userName, email, link string) error {
-	stmp, err := GetSystemStmp()
-
-	if err != nil {
-		return err
-	}
-
-	contentTemp := `<p style="font-size: 30px">Hi <strong>%s,</strong></p>
-	<p>
-		您正在进行密码重置。点击下方按钮以重置密码。
-	</p>
-	
-	<p style="text-align: center; font-size: 13px;">
-		<a target="__blank" href="%s" class="button" style="color: #ffffff;">重置密码</a>
-	</p>
-	
-	<p style="color: #858585; padding-top: 15px;">
-		如果链接无法点击，请尝试点击下面的链接或将其复制到浏览器中打开<br> %s
-	</p>
-	<p style="color: #858585;">重置链接 %d 分钟内有效，如果不是本人操作，请忽略。</p>`
-
-	subject := fmt.Sprintf("%s密码重置", config.SystemName)
-	content := fmt.Sprintf(contentTemp, userName, link, link, common.VerificationValidMinutes)
-
-	return stmp.Render(email, subject, content)
+func SendPasswordResetEmail(userName, email, link, language string) error {
+	return SendTemplateEmail(email, EmailEventPasswordReset, language, map[string]any{
+		"Username":     userName,
+		"Link":         link,
+		"ValidMinutes": common.VerificationValidMinutes,
+	})
 }
 
-func SendVerificationCodeEmail(email, code string) error {
-	stmp, err := GetSystemStmp()
-
-	if err != nil {
-		return err
-	}
-
-	contentTemp := `
-	<p>
-		您正在进行邮箱验证。您的验证码为: 
-	</p>
-	
-	<p style="text-align: center; font-size: 30px; color: #58a6ff;">
-		<strong>%s</strong>
-	</p>
-	
-	<p style="color: #858585; padding-top: 15px;">
-		验证码 %d 分钟内有效，如果不是本人操作，请忽略。
-	</p>`
-
-	subject := fmt.Sprintf("%s邮箱验证邮件", config.SystemName)
-	content := fmt.Sprintf(contentTemp, code, common.VerificationValidMinutes)
-
-	return stmp.Render(email, subject, content)
+func SendVerificationCodeEmail(email, code, language string) error {
+	return SendTemplateEmail(email, EmailEventVerification, language, map[string]any{
+		"Code":         code,
+		"ValidMinutes": common.VerificationValidMinutes,
+	})
 }
 
 func SendQuotaWarningCodeEmail(userName, email string, quota int, noMoreQuota bool) error {
-	stmp, err := GetSystemStmp()
-
-	if err != nil {
-		return err
-	}
-
-	contentTemp := `<p style="font-size: 30px">Hi <strong>%s,</strong></p>
-		<p>
-			%s，当前剩余额度为 %d，为了不影响您的使用，请及时充值。
-		</p>
-		
-		<p style="text-align: center; font-size: 13px;">
-			<a target="__blank" href="%s" class="button" style="color: #ffffff;">点击充值</a>
-		</p>
-		
-		<p style="color: #858585; padding-top: 15px;">
-			如果链接无法点击，请尝试点击下面的链接或将其复制到浏览器中打开<br> %s
-		</p>`
-
-	subject := "您的额度即将用尽"
+	event := EmailEventQuotaWarning
 	if noMoreQuota {
-		subject = "您的额度已用尽"
+		event = EmailEventQuotaExhausted
 	}
-	topUpLink := fmt.Sprintf("%s/topup", config.ServerAddress)
-
-	content := fmt.Sprintf(contentTemp, userName, subject, quota, topUpLink, topUpLink)
 
-	return stmp.Render(email, subject, content)
+	return SendTemplateEmail(email, event, config.EmailDefaultLanguage, map[string]any{
+		"Username":  userName,
+		"Quota":     quota,
+		"TopUpLink": fmt.Sprintf("%s/topup", config.ServerAddress),
+	})
 }
 
 func DialAndSend(c *mail.Client, messages ...*mail.Msg) error {
@@ -0,0 +1,286 @@
+package stmp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"one-api/common/config"
+	"strings"
+	"text/template"
+)
+
+const (
+	EmailEventVerification   = "verification"
+	EmailEventPasswordReset  = "password_reset"
+	EmailEventQuotaWarning   = "quota_warning"
+	EmailEventQuotaExhausted = "quota_exhausted"
+)
+
+// EmailTemplate 一个事件在一种语言下的模板，主题和内容使用 Go 模板语法，内容会放入默认的邮件布局
+type EmailTemplate struct {
+	Subject string `json:"subject"`
+	Content string `json:"content"`
+}
+
+// EmailTemplates 事件 -> 语言 -> 模板
+type EmailTemplates map[string]map[string]*EmailTemplate
+
+// EmailTemplateVariables 每个事件可以使用的变量，所有事件都可以使用 SystemName 和 ServerAddress
+var EmailTemplateVariables = map[string][]string{
+	EmailEventVerification:   {"Code", "ValidMinutes"},
+	EmailEventPasswordReset:  {"Username", "Link", "ValidMinutes"},
+	EmailEventQuotaWarning:   {"Username", "Quota", "TopUpLink"},
+	EmailEventQuotaExhausted: {"Username", "Quota", "TopUpLink"},
+}
+
+const quotaEmailContentZh = `<p style="font-size: 30px">Hi <strong>{{.Username}},</strong></p>
+	<p>
+		{{.Reason}}，当前剩余额度为 {{.Quota}}，为了不影响您的使用，请及时充值。
+	</p>
+
+	<p style="text-align: center; font-size: 13px;">
+		<a target="__blank" href="{{.TopUpLink}}" class="button" style="color: #ffffff;">点击充值</a>
+	</p>
+
+	<p style="color: #858585; padding-top: 15px;">
+		如果链接无法点击，请尝试点击下面的链接或将其复制到浏览器中打开<br> {{.TopUpLink}}
+	</p>`
+
+const quotaEmailContentEn = `<p style="font-size: 30px">Hi <strong>{{.Username}},</strong></p>
+	<p>
+		{{.Reason}}. Your remaining quota is {{.Quota}}. Please top up to avoid service interruption.
+	</p>
+
+	<p style="text-align: center; font-size: 13px;">
+		<a target="__blank" href="{{.TopUpLink}}" class="button" style="color: #ffffff;">Top up</a>
+	</p>
+
+	<p style="color: #858585; padding-top: 15px;">
+		If the button does not work, copy the link below into your browser<br> {{.TopUpLink}}
+	</p>`
+
+// DefaultEmailTemplates 内置模板，自定义模板没有对应的事件和语言时使用
+var DefaultEmailTemplates = EmailTemplates{
+	EmailEventVerification: {
+		"zh": {
+			Subject: "{{.SystemName}}邮箱验证邮件",
+			Content: `
+	<p>
+		您正在进行邮箱验证。您的验证码为:
+	</p>
+
+	<p style="text-align: center; font-size: 30px; color: #58a6ff;">
+		<strong>{{.Code}}</strong>
+	</p>
+
+	<p style="color: #858585; padding-top: 15px;">
+		验证码 {{.ValidMinutes}} 分钟内有效，如果不是本人操作，请忽略。
+	</p>`,
+		},
+		"en": {
+			Subject: "{{.SystemName}} email verification",
+			Content: `
+	<p>
+		You are verifying your email address. Your verification code is:
+	</p>
+
+	<p style="text-align: center; font-size: 30px; color: #58a6ff;">
+		<strong>{{.Code}}</strong>
+	</p>
+
+	<p style="color: #858585; padding-top: 15px;">
+		The code is valid for {{.ValidMinutes}} minutes. If you did not request it, please ignore this email.
+	</p>`,
+		},
+	},
+	EmailEventPasswordReset: {
+		"zh": {
+			Subject: "{{.SystemName}}密码重置",
+			Content: `<p style="font-size: 30px">Hi <strong>{{.Username}},</strong></p>
+	<p>
+		您正在进行密码重置。点击下方按钮以重置密码。
+	</p>
+
+	<p style="text-align: center; font-size: 13px;">
+		<a target="__blank" href="{{.Link}}" class="button" style="color: #ffffff;">重置密码</a>
+	</p>
+
+	<p style="color: #858585; padding-top: 15px;">
+		如果链接无法点击，请尝试点击下面的链接或将其复制到浏览器中打开<br> {{.Link}}
+	</p>
+	<p style="color: #858585;">重置链接 {{.ValidMinutes}} 分钟内有效，如果不是本人操作，请忽略。</p>`,
+		},
+		"en": {
+			Subject: "{{.SystemName}} password reset",
+			Content: `<p style="font-size: 30px">Hi <strong>{{.Username}},</strong></p>
+	<p>
+		You requested a password reset. Click the button below to reset your password.
+	</p>
+
+	<p style="text-align: center; font-size: 13px;">
+		<a target="__blank" href="{{.Link}}" class="button" style="color: #ffffff;">Reset password</a>
+	</p>
+
+	<p style="color: #858585; padding-top: 15px;">
+		If the button does not work, copy the link below into your browser<br> {{.Link}}
+	</p>
+	<p style="color: #858585;">The link is valid for {{.ValidMinutes}} minutes. If you did not request it, please ignore this email.</p>`,
+		},
+	},
+	EmailEventQuotaWarning: {
+		"zh": {Subject: "您的额度即将用尽", Content: strings.Replace(quotaEmailContentZh, "{{.Reason}}", "您的额度即将用尽", 1)},
+		"en": {Subject: "Your quota is running low", Content: strings.Replace(quotaEmailContentEn, "{{.Reason}}", "Your quota is running low", 1)},
+	},
+	EmailEventQuotaExhausted: {
+		"zh": {Subject: "您的额度已用尽", Content: strings.Replace(quotaEmailContentZh, "{{.Reason}}", "您的额度已用尽", 1)},
+		"en": {Subject: "Your quota has been used up", Content: strings.Replace(quotaEmailContentEn, "{{.Reason}}", "Your quota has been used up", 1)},
+	},
+}
+
+// ParseEmailTemplates 解析并检查自定义模板
+func ParseEmailTemplates(value string) (EmailTemplates, error) {
+	templates := EmailTemplates{}
+	if strings.TrimSpace(value) == "" {
+		return templates, nil
+	}
+	if err := json.Unmarshal([]byte(value), &templates); err != nil {
+		return nil, fmt.Errorf("邮件模板不是有效的 JSON：%w", err)
+	}
+
+	for event, languages := range templates {
+		if _, ok := EmailTemplateVariables[event]; !ok {
+			return nil, fmt.Errorf("未知的邮件事件：%s", event)
+		}
+		for language, tmpl := range languages {
+			if tmpl == nil || tmpl.Subject == "" || tmpl.Content == "" {
+				return nil, fmt.Errorf("%s/%s：主题和内容不能为空", event, language)
+			}
+			if _, err := template.New("subject").Parse(tmpl.Subject); err != nil {
+				return nil, fmt.Errorf("%s/%s 主题：%w", event, language, err)
+			}
+			if _, err := htmltemplate.New("content").Parse(tmpl.Content); err != nil {
+				return nil, fmt.Errorf("%s/%s 内容：%w", event, language, err)
+			}
+		}
+	}
+	return templates, nil
+}
+
+// languageCandidates 按 Accept-Language 和默认语言依次尝试，例如 en-US 依次尝试 en-us、en
+func languageCandidates(language string) []string {
+	var candidates []string
+	for _, part := range strings.Split(language, ",") {
+		tag := strings.ToLower(strings.TrimSpace(strings.SplitN(part, ";", 2)[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		candidates = append(candidates, tag)
+		if base, _, found := strings.Cut(tag, "-"); found {
+			candidates = append(candidates, base)
+		}
+	}
+	return append(candidates, strings.ToLower(config.EmailDefaultLanguage), "zh")
+}
+
+// findEmailTemplate 优先使用自定义模板，同一语言下自定义模板优先于内置模板
+func findEmailTemplate(event, language string) (*EmailTemplate, error) {
+	custom, err := ParseEmailTemplates(config.EmailTemplates)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := languageCandidates(language)
+	for _, templates := range []EmailTemplates{custom, DefaultEmailTemplates} {
+		for _, candidate := range candidates {
+			if tmpl, ok := templates[event][candidate]; ok {
+				return tmpl, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("没有邮件事件 %s 的模板", event)
+}
+
+// RenderEmail 渲染事件的主题和完整的邮件内容
+func RenderEmail(event, language string, data map[string]any) (subject, body string, err error) {
+	tmpl, err := findEmailTemplate(event, language)
+	if err != nil {
+		return "", "", err
+	}
+	return renderEmailTemplate(tmpl, data)
+}
+
+func renderEmailTemplate(tmpl *EmailTemplate, data map[string]any) (string, string, error) {
+	variables := map[string]any{
+		"SystemName":    getSystemName(),
+		"ServerAddress": config.ServerAddress,
+	}
+	for key, value := range data {
+		variables[key] = value
+	}
+
+	subjectTemplate, err := template.New("subject").Option("missingkey=zero").Parse(tmpl.Subject)
+	if err != nil {
+		return "", "", err
+	}
+	var subject bytes.Buffer
+	if err = subjectTemplate.Execute(&subject, variables); err != nil {
+		return "", "", err
+	}
+
+	contentTemplate, err := htmltemplate.New("content").Option("missingkey=zero").Parse(tmpl.Content)
+	if err != nil {
+		return "", "", err
+	}
+	var content bytes.Buffer
+	if err = contentTemplate.Execute(&content, variables); err != nil {
+		return "", "", err
+	}
+
+	return strings.TrimSpace(subject.String()), getDefaultTemplate(content.String()), nil
+}
+
+// emailPreviewData 预览使用的示例数据
+var emailPreviewData = map[string]any{
+	"Code":         "123456",
+	"ValidMinutes": 10,
+	"Username":     "demo",
+	"Link":         "https://example.com/user/reset?email=demo@example.com&token=demo",
+	"Quota":        500000,
+	"TopUpLink":    "https://example.com/topup",
+}
+
+// PreviewEmail 用示例数据渲染模板，tmpl 为空时使用当前生效的模板
+func PreviewEmail(event, language string, tmpl *EmailTemplate, data map[string]any) (string, string, error) {
+	if _, ok := EmailTemplateVariables[event]; !ok {
+		return "", "", fmt.Errorf("未知的邮件事件：%s", event)
+	}
+	if tmpl == nil {
+		var err error
+		if tmpl, err = findEmailTemplate(event, language); err != nil {
+			return "", "", err
+		}
+	}
+
+	variables := make(map[string]any, len(emailPreviewData)+len(data))
+	for key, value := range emailPreviewData {
+		variables[key] = value
+	}
+	for key, value := range data {
+		variables[key] = value
+	}
+	return renderEmailTemplate(tmpl, variables)
+}
+
+// SendTemplateEmail 用系统的发件服务发送事件邮件
+func SendTemplateEmail(to, event, language string, data map[string]any) error {
+	sender, err := GetSystemSender()
+	if err != nil {
+		return err
+	}
+	subject, body, err := RenderEmail(event, language, data)
+	if err != nil {
+		return err
+	}
+	return sender.Send(to, subject, body)
+}
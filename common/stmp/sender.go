@@ -0,0 +1,132 @@
+package stmp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"one-api/common/config"
+	"one-api/providers/bedrock/sigv4"
+	"time"
+)
+
+const (
+	EmailProviderSMTP     = "smtp"
+	EmailProviderSendGrid = "sendgrid"
+	EmailProviderSES      = "ses"
+)
+
+// Sender 发送 HTML 邮件
+type Sender interface {
+	Send(to, subject, body string) error
+}
+
+var emailHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// GetSystemSender 按 EmailProvider 返回系统的发件服务
+func GetSystemSender() (Sender, error) {
+	switch config.EmailProvider {
+	case EmailProviderSendGrid:
+		if config.SendGridToken == "" || config.SMTPFrom == "" {
+			return nil, fmt.Errorf("SendGrid 信息未配置")
+		}
+		return &SendGridSender{APIKey: config.SendGridToken, From: config.SMTPFrom}, nil
+	case EmailProviderSES:
+		if config.SESRegion == "" || config.SESAccessKeyId == "" || config.SESSecret == "" || config.SMTPFrom == "" {
+			return nil, fmt.Errorf("SES 信息未配置")
+		}
+		return &SESSender{
+			Region:          config.SESRegion,
+			AccessKeyId:     config.SESAccessKeyId,
+			SecretAccessKey: config.SESSecret,
+			From:            config.SMTPFrom,
+		}, nil
+	case "", EmailProviderSMTP:
+		return GetSystemStmp()
+	}
+	return nil, fmt.Errorf("不支持的邮件服务：%s", config.EmailProvider)
+}
+
+// SendGridSender 通过 SendGrid v3 API 发送邮件
+type SendGridSender struct {
+	APIKey string
+	From   string
+}
+
+func (s *SendGridSender) Send(to, subject, body string) error {
+	payload := map[string]any{
+		"personalizations": []map[string]any{{"to": []map[string]string{{"email": to}}}},
+		"from":             map[string]string{"email": s.From},
+		"subject":          subject,
+		"content":          []map[string]string{{"type": "text/html", "value": body}},
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, "https://api.sendgrid.com/v3/mail/send", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+	return doEmailRequest(req, "SendGrid")
+}
+
+// SESSender 通过 Amazon SES v2 API 发送邮件
+type SESSender struct {
+	Region          string
+	AccessKeyId     string
+	SecretAccessKey string
+	From            string
+}
+
+func (s *SESSender) Send(to, subject, body string) error {
+	payload := map[string]any{
+		"FromEmailAddress": s.From,
+		"Destination":      map[string]any{"ToAddresses": []string{to}},
+		"Content": map[string]any{
+			"Simple": map[string]any{
+				"Subject": map[string]string{"Data": subject, "Charset": "UTF-8"},
+				"Body":    map[string]any{"Html": map[string]string{"Data": body, "Charset": "UTF-8"}},
+			},
+		},
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	url := fmt.Sprintf("https://email.%s.amazonaws.com/v2/email/outbound-emails", s.Region)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	sig, err := sigv4.New(sigv4.WithCredential(s.AccessKeyId, s.SecretAccessKey, ""), sigv4.WithRegionService(s.Region, "ses"))
+	if err != nil {
+		return err
+	}
+	if err = sig.Sign(req, fmt.Sprintf("%x", sha256.Sum256(data)), sigv4.NewTime(time.Now())); err != nil {
+		return err
+	}
+	return doEmailRequest(req, "SES")
+}
+
+func doEmailRequest(req *http.Request, provider string) error {
+	resp, err := emailHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s returned %d: %s", provider, resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -3,45 +3,61 @@ package drives
 import (
 	"bytes"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 )
 
+// lifecycleRulePrefix 由本系统管理的生命周期规则 ID 前缀，其它规则保持不变
+const lifecycleRulePrefix = "one-api-"
+
 type S3Upload struct {
 	EndPoint        string
 	CustomDomain    string
 	AccessKeyId     string
 	AccessKeySecret string
 	BucketName      string
+	Region          string
 	expirationDays  int
+
+	svc  *s3.S3
+	lock sync.Mutex
 }
 
-func NewS3Upload(endpoint, accessKeyId, accessKeySecret, bucketName, cdnurl string, expirationDays int) *S3Upload {
+func NewS3Upload(endpoint, accessKeyId, accessKeySecret, bucketName, cdnurl, region string, expirationDays int) *S3Upload {
 	_cdnurl := cdnurl
 	if _cdnurl == "" {
 		_cdnurl = endpoint
 	}
+	if region == "" {
+		region = "auto"
+	}
 	return &S3Upload{
 		EndPoint:        endpoint,
 		BucketName:      bucketName,
-		CustomDomain:    _cdnurl,
+		CustomDomain:    strings.TrimSuffix(_cdnurl, "/"),
 		AccessKeyId:     accessKeyId,
 		AccessKeySecret: accessKeySecret,
+		Region:          region,
 		expirationDays:  expirationDays,
 	}
 }
 
-func (a *S3Upload) Name() string {
-	return "S3"
-}
+// client 复用 S3 客户端，MinIO 等兼容服务需要使用 path style
+func (a *S3Upload) client() (*s3.S3, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
 
-func (a *S3Upload) Upload(data []byte, s3Key string) (string, error) {
+	if a.svc != nil {
+		return a.svc, nil
+	}
 
-	// 创建 S3 会话
 	sess, err := session.NewSession(&aws.Config{
 		Credentials: credentials.NewStaticCredentials(
 			a.AccessKeyId,
@@ -49,14 +65,112 @@ func (a *S3Upload) Upload(data []byte, s3Key string) (string, error) {
 			"",
 		),
 		Endpoint:         aws.String(a.EndPoint),
-		Region:           aws.String("auto"),
+		Region:           aws.String(a.Region),
 		S3ForcePathStyle: aws.Bool(true),
 	})
 	if err != nil {
-		return "", fmt.Errorf("failed to create session: %v", err)
+		return nil, fmt.Errorf("failed to create session: %v", err)
 	}
 
-	svc := s3.New(sess)
+	a.svc = s3.New(sess)
+	return a.svc, nil
+}
+
+// URL 对象的公共访问地址
+func (a *S3Upload) URL(key string) string {
+	return fmt.Sprintf("%s/%s", a.CustomDomain, key)
+}
+
+// IsOwnURL 判断地址是否已经指向本存储
+func (a *S3Upload) IsOwnURL(url string) bool {
+	return strings.HasPrefix(url, a.CustomDomain+"/")
+}
+
+// PutObject 按指定 key 上传，返回公共访问地址
+func (a *S3Upload) PutObject(key string, data []byte, contentType string) (string, error) {
+	svc, err := a.client()
+	if err != nil {
+		return "", err
+	}
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(a.BucketName),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+
+	if _, err := svc.PutObject(input); err != nil {
+		return "", fmt.Errorf("failed to upload file to S3: %v", err)
+	}
+	return a.URL(key), nil
+}
+
+// SetLifecycleRules 设置按前缀过期的生命周期规则，days 为 0 时移除该前缀的规则
+// 只修改 ID 以 one-api- 开头的规则，存储桶中已有的其它规则保持不变
+func (a *S3Upload) SetLifecycleRules(prefixDays map[string]int) error {
+	svc, err := a.client()
+	if err != nil {
+		return err
+	}
+
+	var rules []*s3.LifecycleRule
+	current, err := svc.GetBucketLifecycleConfiguration(&s3.GetBucketLifecycleConfigurationInput{
+		Bucket: aws.String(a.BucketName),
+	})
+	if err != nil {
+		if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != "NoSuchLifecycleConfiguration" {
+			return fmt.Errorf("failed to get bucket lifecycle: %v", err)
+		}
+	} else {
+		for _, rule := range current.Rules {
+			if !strings.HasPrefix(aws.StringValue(rule.ID), lifecycleRulePrefix) {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	for prefix, days := range prefixDays {
+		if days <= 0 {
+			continue
+		}
+		rules = append(rules, &s3.LifecycleRule{
+			ID:         aws.String(lifecycleRulePrefix + strings.Trim(prefix, "/")),
+			Status:     aws.String(s3.ExpirationStatusEnabled),
+			Filter:     &s3.LifecycleRuleFilter{Prefix: aws.String(prefix)},
+			Expiration: &s3.LifecycleExpiration{Days: aws.Int64(int64(days))},
+		})
+	}
+
+	if len(rules) == 0 {
+		if current == nil || len(current.Rules) == 0 {
+			return nil
+		}
+		_, err = svc.DeleteBucketLifecycle(&s3.DeleteBucketLifecycleInput{Bucket: aws.String(a.BucketName)})
+		return err
+	}
+
+	_, err = svc.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(a.BucketName),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: rules},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to put bucket lifecycle: %v", err)
+	}
+	return nil
+}
+
+func (a *S3Upload) Name() string {
+	return "S3"
+}
+
+func (a *S3Upload) Upload(data []byte, s3Key string) (string, error) {
+	svc, err := a.client()
+	if err != nil {
+		return "", err
+	}
 
 	// 获取当前日期作为文件名前缀
 	now := time.Now()
@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/storage/drives"
+	"time"
+)
+
+// 对象存储中各类文件的前缀，生命周期规则按前缀设置
+const (
+	ImagePrefix      = "images/"
+	TranscriptPrefix = "transcripts/"
+	LogArchivePrefix = "log-archive/"
+)
+
+var ErrObjectStorageDisabled = errors.New("object storage is not configured")
+
+// objectStore 配置了 S3 时用于按 key 保存文件，图床类存储不支持
+var objectStore *drives.S3Upload
+
+func ObjectStorageEnabled() bool {
+	return objectStore != nil
+}
+
+// ObjectKey 生成 前缀/日期/文件名 格式的 key
+func ObjectKey(prefix, fileName string) string {
+	return fmt.Sprintf("%s%s/%s", prefix, time.Now().Format("2006-01-02"), fileName)
+}
+
+// PutObject 保存文件到对象存储，返回公共访问地址
+func PutObject(key string, data []byte, contentType string) (string, error) {
+	if objectStore == nil {
+		return "", ErrObjectStorageDisabled
+	}
+	return objectStore.PutObject(key, data, contentType)
+}
+
+// IsStoredURL 地址是否已经指向对象存储
+func IsStoredURL(url string) bool {
+	return objectStore != nil && objectStore.IsOwnURL(url)
+}
+
+// ApplyLifecycle 根据设置中的过期天数更新存储桶的生命周期规则
+func ApplyLifecycle() error {
+	if objectStore == nil {
+		return nil
+	}
+	return objectStore.SetLifecycleRules(map[string]int{
+		ImagePrefix:      config.StorageImageExpirationDays,
+		TranscriptPrefix: config.StorageTranscriptExpirationDays,
+		LogArchivePrefix: config.StorageLogArchiveExpirationDays,
+	})
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"one-api/common/logger"
 	"one-api/common/storage/drives"
 
 	"github.com/spf13/viper"
@@ -83,7 +84,15 @@ func InitS3Storage() {
 	}
 
 	expirationDays := viper.GetInt("storage.s3.expirationDays")
+	region := viper.GetString("storage.s3.region")
 
-	s3Upload := drives.NewS3Upload(endpoint, accessKeyId, accessKeySecret, bucketName, cdnurl, expirationDays)
+	s3Upload := drives.NewS3Upload(endpoint, accessKeyId, accessKeySecret, bucketName, cdnurl, region, expirationDays)
 	AddStorageDrive(s3Upload)
+
+	objectStore = s3Upload
+	go func() {
+		if err := ApplyLifecycle(); err != nil {
+			logger.SysError("failed to apply storage lifecycle: " + err.Error())
+		}
+	}()
 }
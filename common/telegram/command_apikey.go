@@ -2,10 +2,7 @@ package telegram
 
 import (
 	"fmt"
-	"net/url"
-	"one-api/common/config"
 	"one-api/model"
-	"strings"
 
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext"
@@ -55,39 +52,14 @@ func getApikeyList(userId, page int) (message string, pageParams *paginationPara
 		return "找不到令牌", nil
 	}
 
-	chatUrlTmp := ""
-	if config.ServerAddress != "" {
-		chatUrlTmp = getChatUrl()
-	}
-
-	message = "点击令牌可复制：\n"
+	// 令牌只保存了哈希，完整的 key 只在创建时显示
+	message = "令牌只在创建时显示完整的 key：\n"
 
 	for _, token := range *list.Data {
-		key := "sk-" + token.Key
+		key := "sk-" + token.KeyPrefix + "..."
 		message += fmt.Sprintf("*%s* : `%s`\n", escapeText(token.Name, "MarkdownV2"), key)
-		if chatUrlTmp != "" {
-			message += strings.ReplaceAll(chatUrlTmp, `setToken`, key)
-		}
 		message += "\n"
 	}
 
 	return message, getPageParams("apikey", page, genericParams.Size, int(list.TotalCount))
 }
-
-func getChatUrl() string {
-	serverAddress := strings.TrimSuffix(config.ServerAddress, "/")
-	chatNextUrl := fmt.Sprintf(`{"key":"setToken","url":"%s"}`, serverAddress)
-	chatNextUrl = "https://chat.oneapi.pro/#/?settings=" + url.QueryEscape(chatNextUrl)
-	if config.ChatLink != "" {
-		chatLink := strings.TrimSuffix(config.ChatLink, "/")
-		chatNextUrl = strings.ReplaceAll(chatNextUrl, `https://chat.oneapi.pro`, chatLink)
-	}
-
-	jumpUrl := fmt.Sprintf(`%s/jump?url=`, serverAddress)
-
-	amaUrl := jumpUrl + url.QueryEscape(fmt.Sprintf(`ama://set-api-key?server=%s&key=setToken`, serverAddress))
-
-	openCatUrl := jumpUrl + url.QueryEscape(fmt.Sprintf(`opencat://team/join?domain=%s&token=setToken`, serverAddress))
-
-	return fmt.Sprintf("[Next Chat](%s)  [AMA](%s)  [OpenCat](%s)\n", chatNextUrl, amaUrl, openCatUrl)
-}
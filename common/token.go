@@ -8,6 +8,7 @@ import (
 	"one-api/common/config"
 	"one-api/common/logger"
 	"strings"
+	"sync"
 
 	"one-api/common/image"
 	"one-api/types"
@@ -16,11 +17,36 @@ import (
 	"github.com/spf13/viper"
 )
 
-var tokenEncoderMap = map[string]*tiktoken.Tiktoken{}
+// 按编码族缓存编码器（cl100k_base、o200k_base 等），同族模型共用同一个实例
+var tokenEncoderFamilies sync.Map
+
+// 模型名到编码器的映射缓存
+var tokenEncoderMap sync.Map
+
 var gpt35TokenEncoder *tiktoken.Tiktoken
 var gpt4TokenEncoder *tiktoken.Tiktoken
 var gpt4oTokenEncoder *tiktoken.Tiktoken
 
+// 拼接消息文本用的 Builder 池，避免高并发下频繁分配
+var textBuilderPool = sync.Pool{
+	New: func() any {
+		return new(strings.Builder)
+	},
+}
+
+func getTextBuilder() *strings.Builder {
+	return textBuilderPool.Get().(*strings.Builder)
+}
+
+func putTextBuilder(builder *strings.Builder) {
+	// 过大的 Builder 不回收，避免长期占用内存
+	if builder.Cap() > 64*1024 {
+		return
+	}
+	builder.Reset()
+	textBuilderPool.Put(builder)
+}
+
 func InitTokenEncoders() {
 	if viper.GetBool("disable_token_encoders") {
 		config.DisableTokenEncoders = true
@@ -29,17 +55,15 @@ func InitTokenEncoders() {
 	}
 	logger.SysLog("initializing token encoders")
 	var err error
-	gpt35TokenEncoder, err = tiktoken.EncodingForModel("gpt-3.5-turbo")
+	gpt35TokenEncoder, err = getFamilyEncoder(tiktoken.MODEL_CL100K_BASE)
 	if err != nil {
 		logger.FatalLog(fmt.Sprintf("failed to get gpt-3.5-turbo token encoder: %s", err.Error()))
 	}
 
-	gpt4TokenEncoder, err = tiktoken.EncodingForModel("gpt-4")
-	if err != nil {
-		logger.FatalLog(fmt.Sprintf("failed to get gpt-4 token encoder: %s", err.Error()))
-	}
+	// gpt-3.5 与 gpt-4 同属 cl100k_base
+	gpt4TokenEncoder = gpt35TokenEncoder
 
-	gpt4oTokenEncoder, err = tiktoken.EncodingForModel("gpt-4o")
+	gpt4oTokenEncoder, err = getFamilyEncoder(tiktoken.MODEL_O200K_BASE)
 	if err != nil {
 		logger.FatalLog(fmt.Sprintf("failed to get gpt-4o token encoder: %s", err.Error()))
 	}
@@ -47,40 +71,72 @@ func InitTokenEncoders() {
 	logger.SysLog("token encoders initialized")
 }
 
+// getFamilyEncoder 获取编码族对应的编码器，每个编码族只初始化一次
+func getFamilyEncoder(encodingName string) (*tiktoken.Tiktoken, error) {
+	if encoder, ok := tokenEncoderFamilies.Load(encodingName); ok {
+		return encoder.(*tiktoken.Tiktoken), nil
+	}
+
+	encoder, err := tiktoken.GetEncoding(encodingName)
+	if err != nil {
+		return nil, err
+	}
+
+	actual, _ := tokenEncoderFamilies.LoadOrStore(encodingName, encoder)
+	return actual.(*tiktoken.Tiktoken), nil
+}
+
+// getModelEncodingName 根据模型名解析所属的编码族
+func getModelEncodingName(model string) (string, bool) {
+	if encodingName, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		return encodingName, true
+	}
+
+	for prefix, encodingName := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(model, prefix) {
+			return encodingName, true
+		}
+	}
+
+	return "", false
+}
+
 func GetTokenEncoder(model string) *tiktoken.Tiktoken {
 	if config.DisableTokenEncoders {
 		return nil
 	}
 
-	tokenEncoder, ok := tokenEncoderMap[model]
-	if ok {
-		return tokenEncoder
+	if tokenEncoder, ok := tokenEncoderMap.Load(model); ok {
+		return tokenEncoder.(*tiktoken.Tiktoken)
 	}
 
+	var tokenEncoder *tiktoken.Tiktoken
 	if strings.HasPrefix(model, "gpt-3.5") {
 		tokenEncoder = gpt35TokenEncoder
 	} else if strings.HasPrefix(model, "gpt-4o") {
 		tokenEncoder = gpt4oTokenEncoder
 	} else if strings.HasPrefix(model, "gpt-4") {
 		tokenEncoder = gpt4TokenEncoder
-	} else {
+	} else if encodingName, ok := getModelEncodingName(model); ok {
 		var err error
-		tokenEncoder, err = tiktoken.EncodingForModel(model)
+		tokenEncoder, err = getFamilyEncoder(encodingName)
 		if err != nil {
 			logger.SysError(fmt.Sprintf("failed to get token encoder for model %s: %s, using encoder for gpt-3.5-turbo", model, err.Error()))
 			tokenEncoder = gpt35TokenEncoder
 		}
+	} else {
+		tokenEncoder = gpt35TokenEncoder
 	}
 
-	tokenEncoderMap[model] = tokenEncoder
+	tokenEncoderMap.Store(model, tokenEncoder)
 	return tokenEncoder
 }
 
 func GetTokenNum(tokenEncoder *tiktoken.Tiktoken, text string) int {
-	if config.DisableTokenEncoders || config.ApproximateTokenEnabled {
+	if config.DisableTokenEncoders || config.ApproximateTokenEnabled || tokenEncoder == nil {
 		return int(float64(len(text)) * 0.38)
 	}
-	return len(tokenEncoder.Encode(text, nil, nil))
+	return len(tokenEncoder.EncodeOrdinary(text))
 }
 
 func CountTokenMessages(messages []types.ChatCompletionMessage, model string, preCostType int) int {
@@ -105,7 +161,8 @@ func CountTokenMessages(messages []types.ChatCompletionMessage, model string, pr
 		tokensPerName = 1
 	}
 	tokenNum := 0
-	var textMsg strings.Builder
+	textMsg := getTextBuilder()
+	defer putTextBuilder(textMsg)
 
 	for _, message := range messages {
 		tokenNum += tokensPerMessage
@@ -195,7 +252,8 @@ func CountTokenInputMessages(input any, model string, preCostType int) int {
 		return 0
 	}
 
-	var textMsg strings.Builder
+	textMsg := getTextBuilder()
+	defer putTextBuilder(textMsg)
 	var messages []types.ChatCompletionMessage
 	err = json.Unmarshal(jsonStr, &messages)
 	if err != nil {
@@ -260,7 +318,8 @@ func CountTokenRerankMessages(messages types.RerankRequest, model string, preCos
 
 	tokenEncoder := GetTokenEncoder(model)
 	tokenNum := 0
-	var textMsg strings.Builder
+	textMsg := getTextBuilder()
+	defer putTextBuilder(textMsg)
 
 	textMsg.WriteString(messages.Query + "\n")
 
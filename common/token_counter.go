@@ -0,0 +1,59 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// 缓冲区超过该长度时，对已完整的部分进行编码
+const streamTokenFlushSize = 512
+
+// StreamTokenCounter 流式输出时增量统计 tokens
+// 在空白处切分已接收的文本并提前编码，结束时只需编码剩余的尾部，
+// 避免在流结束后对整段输出重新编码
+type StreamTokenCounter struct {
+	encoder *tiktoken.Tiktoken
+	pending strings.Builder
+	tokens  int
+}
+
+func NewStreamTokenCounter(model string) *StreamTokenCounter {
+	return &StreamTokenCounter{
+		encoder: GetTokenEncoder(model),
+	}
+}
+
+func (c *StreamTokenCounter) Write(text string) {
+	c.pending.WriteString(text)
+	if c.pending.Len() < streamTokenFlushSize {
+		return
+	}
+
+	buffered := c.pending.String()
+	// 在最后一个空白字符前切分，空白归入下一段，与 tiktoken 的预分词规则保持一致
+	cut := strings.LastIndexAny(buffered, " \n\t")
+	if cut <= 0 {
+		// 没有可切分的位置时，等到缓冲区足够大再整体编码
+		if len(buffered) < streamTokenFlushSize*8 {
+			return
+		}
+		cut = len(buffered)
+	}
+
+	c.tokens += GetTokenNum(c.encoder, buffered[:cut])
+	c.pending.Reset()
+	c.pending.WriteString(buffered[cut:])
+}
+
+func (c *StreamTokenCounter) HasText() bool {
+	return c.tokens > 0 || c.pending.Len() > 0
+}
+
+func (c *StreamTokenCounter) Tokens() int {
+	if c.pending.Len() == 0 {
+		return c.tokens
+	}
+
+	return c.tokens + GetTokenNum(c.encoder, c.pending.String())
+}
@@ -91,7 +91,8 @@ func ValidateToken(token string) (tokenID, userID int, err error) {
 		return 0, 0, fmt.Errorf("签名解码失败")
 	}
 
-	if !bytes.Equal(decodedSignature, expectedSignature) {
+	// 常量时间比较，避免通过响应时间推测签名
+	if !hmac.Equal(decodedSignature, expectedSignature) {
 		return 0, 0, fmt.Errorf("签名验证失败")
 	}
 
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"one-api/common/logger"
+	"one-api/model"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BackupRequest struct {
+	Passphrase string `json:"passphrase"`
+}
+
+// CreateBackup 下载 gzip 压缩的备份文件，未提供口令时不导出密钥
+func CreateBackup(c *gin.Context) {
+	var req BackupRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "无效的参数",
+			})
+			return
+		}
+	}
+
+	backup, err := model.CreateBackup(req.Passphrase)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("one-api-backup-%s.json.gz", time.Now().Format("20060102150405"))
+	c.Header("Content-Type", "application/gzip")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+
+	gz := gzip.NewWriter(c.Writer)
+	if err := json.NewEncoder(gz).Encode(backup); err != nil {
+		logger.LogError(c.Request.Context(), "failed to write backup: "+err.Error())
+	}
+	gz.Close()
+}
+
+// RestoreBackup 从上传的备份文件恢复，表单字段 file 为备份文件，passphrase 为导出时使用的口令
+func RestoreBackup(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "请上传备份文件",
+		})
+		return
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	defer f.Close()
+
+	backup, err := readBackup(f)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无效的备份文件：" + err.Error(),
+		})
+		return
+	}
+
+	if err := model.RestoreBackup(backup, c.PostForm("passphrase")); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	reloadCaches()
+	logger.LogInfo(c.Request.Context(), fmt.Sprintf("restored backup created at %d: %d channels, %d tokens, %d users",
+		backup.CreatedAt, len(backup.Channels), len(backup.Tokens), len(backup.Users)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// readBackup 读取备份文件，兼容未压缩的 JSON
+func readBackup(r io.Reader) (*model.Backup, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if data, err = io.ReadAll(gz); err != nil {
+			return nil, err
+		}
+	}
+
+	var backup model.Backup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, err
+	}
+	return &backup, nil
+}
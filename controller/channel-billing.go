@@ -2,9 +2,11 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"one-api/common/config"
+	"one-api/common/logger"
 	"one-api/model"
 	"one-api/providers"
 	providersBase "one-api/providers/base"
@@ -88,7 +90,8 @@ func UpdateChannelBalance(c *gin.Context) {
 		})
 		return
 	}
-	balance, err := updateChannelBalance(channel)
+	// refresh=true 时不使用缓存
+	balance, err := cachedChannelBalance(channel, c.Query("refresh") == "true")
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -116,7 +119,7 @@ func updateAllChannelsBalance() error {
 		if channel.Type != config.ChannelTypeOpenAI && channel.Type != config.ChannelTypeCustom {
 			continue
 		}
-		balance, err := updateChannelBalance(channel)
+		balance, err := cachedChannelBalance(channel, false)
 		if err != nil {
 			continue
 		} else {
@@ -130,6 +133,24 @@ func updateAllChannelsBalance() error {
 	return nil
 }
 
+// CheckLowBalanceChannels 查询设置了余额阈值的渠道的上游余额，余额跨过阈值时由 UpdateBalance 切换流量并通知管理员
+func CheckLowBalanceChannels() {
+	channels, err := model.GetAllChannels()
+	if err != nil {
+		logger.SysError("failed to get channels: " + err.Error())
+		return
+	}
+	for _, channel := range channels {
+		if channel.Status != config.ChannelStatusEnabled || channel.LowBalanceThreshold <= 0 {
+			continue
+		}
+		if _, err := cachedChannelBalance(channel, false); err != nil {
+			logger.SysError(fmt.Sprintf("failed to update channel #%d balance: %s", channel.Id, err.Error()))
+		}
+		time.Sleep(config.RequestInterval)
+	}
+}
+
 func UpdateAllChannelsBalance(c *gin.Context) {
 	// TODO: make it async
 	err := updateAllChannelsBalance()
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"net/http"
+	"one-api/common"
+	"one-api/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChannelCaptureRequest 采样接下来的 Count 次上游请求，为 0 时关闭采样
+type ChannelCaptureRequest struct {
+	Count int `json:"count"`
+}
+
+// ChannelCaptureResult 剩余采样次数和已记录的采样
+type ChannelCaptureResult struct {
+	Remaining int                                     `json:"remaining"`
+	Captures  *model.DataResult[model.ChannelCapture] `json:"captures,omitempty"`
+}
+
+func StartChannelCapture(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if _, err := model.GetChannelById(id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var req ChannelCaptureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := model.StartChannelCapture(id, req.Count); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    ChannelCaptureResult{Remaining: model.GetChannelCaptureRemaining(id)},
+	})
+}
+
+func GetChannelCaptures(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var params model.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	captures, err := model.GetChannelCaptures(id, &params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data": ChannelCaptureResult{
+			Remaining: model.GetChannelCaptureRemaining(id),
+			Captures:  captures,
+		},
+	})
+}
+
+// DeleteChannelCaptures 关闭采样并删除渠道的所有采样
+func DeleteChannelCaptures(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := model.StartChannelCapture(id, 0); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	count, err := model.DeleteChannelCaptures(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    count,
+	})
+}
@@ -0,0 +1,281 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"one-api/model"
+	"one-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 兼容性测试使用的不存在的模型，用于检查错误格式
+const conformanceInvalidModel = "one-hub-conformance-invalid-model"
+
+// ConformanceCheck 一项兼容性检查的结果
+type ConformanceCheck struct {
+	Name    string  `json:"name"`
+	Passed  bool    `json:"passed"`
+	Message string  `json:"message,omitempty"`
+	Time    float64 `json:"time"`
+}
+
+// ConformanceReport 渠道兼容性测试报告，所有检查通过时 Passed 为 true
+type ConformanceReport struct {
+	ChannelId int                 `json:"channel_id"`
+	Model     string              `json:"model"`
+	Passed    bool                `json:"passed"`
+	Checks    []*ConformanceCheck `json:"checks"`
+}
+
+type conformanceCase struct {
+	name    string
+	model   string
+	request func(modelName string) *types.ChatCompletionRequest
+	check   func(result *PlaygroundResult) error
+}
+
+var conformanceCases = []conformanceCase{
+	{
+		name: "chat",
+		request: func(modelName string) *types.ChatCompletionRequest {
+			return &types.ChatCompletionRequest{
+				Model:    modelName,
+				Messages: []types.ChatCompletionMessage{{Role: types.ChatMessageRoleUser, Content: "You just need to output 'hi' next."}},
+			}
+		},
+		check: checkConformanceChat,
+	},
+	{
+		name: "stream",
+		request: func(modelName string) *types.ChatCompletionRequest {
+			return &types.ChatCompletionRequest{
+				Model:         modelName,
+				Messages:      []types.ChatCompletionMessage{{Role: types.ChatMessageRoleUser, Content: "You just need to output 'hi' next."}},
+				Stream:        true,
+				StreamOptions: &types.StreamOptions{IncludeUsage: true},
+			}
+		},
+		check: checkConformanceStream,
+	},
+	{
+		name: "tool_calls",
+		request: func(modelName string) *types.ChatCompletionRequest {
+			return &types.ChatCompletionRequest{
+				Model:    modelName,
+				Messages: []types.ChatCompletionMessage{{Role: types.ChatMessageRoleUser, Content: "What is the weather like in Paris?"}},
+				Tools: []*types.ChatCompletionTool{{
+					Type: types.ToolChoiceTypeFunction,
+					Function: types.ChatCompletionFunction{
+						Name:        "get_weather",
+						Description: "Get the current weather in a given city",
+						Parameters: map[string]any{
+							"type":       "object",
+							"properties": map[string]any{"city": map[string]any{"type": "string"}},
+							"required":   []string{"city"},
+						},
+					},
+				}},
+				ToolChoice: types.ToolChoiceTypeRequired,
+			}
+		},
+		check: checkConformanceToolCalls,
+	},
+	{
+		name: "json_mode",
+		request: func(modelName string) *types.ChatCompletionRequest {
+			return &types.ChatCompletionRequest{
+				Model: modelName,
+				Messages: []types.ChatCompletionMessage{
+					{Role: types.ChatMessageRoleSystem, Content: "You reply in JSON."},
+					{Role: types.ChatMessageRoleUser, Content: "Return a JSON object with a key named answer whose value is hi."},
+				},
+				ResponseFormat: &types.ChatCompletionResponseFormat{Type: "json_object"},
+			}
+		},
+		check: checkConformanceJSON,
+	},
+	{
+		name:  "error",
+		model: conformanceInvalidModel,
+		request: func(modelName string) *types.ChatCompletionRequest {
+			return &types.ChatCompletionRequest{
+				Model:    modelName,
+				Messages: []types.ChatCompletionMessage{{Role: types.ChatMessageRoleUser, Content: "hi"}},
+			}
+		},
+		check: checkConformanceError,
+	},
+}
+
+// channelConformance 依次检查渠道的普通响应、流式响应、工具调用、JSON 模式和错误格式是否与 OpenAI 一致
+// 和调试一样不计费，也不会因为失败而禁用渠道
+func channelConformance(c *gin.Context, channel *model.Channel, modelName string) (*ConformanceReport, error) {
+	if modelName == "" {
+		modelName = channel.TestModel
+		if modelName == "" {
+			return nil, errors.New("请填写测速模型后再试")
+		}
+	}
+
+	report := &ConformanceReport{ChannelId: channel.Id, Model: modelName, Passed: true}
+	for _, item := range conformanceCases {
+		caseModel := modelName
+		if item.model != "" {
+			caseModel = item.model
+		}
+
+		check := &ConformanceCheck{Name: item.name}
+		result, err := playground(c, channel, caseModel, item.request(caseModel))
+		if err == nil {
+			check.Time = result.Time
+			err = item.check(result)
+		}
+		if err != nil {
+			check.Message = err.Error()
+			report.Passed = false
+		} else {
+			check.Passed = true
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	return report, nil
+}
+
+// conformanceResponse 按客户端看到的 JSON 解析非流式响应，并检查共同的字段
+func conformanceResponse(result *PlaygroundResult) (*types.ChatCompletionResponse, error) {
+	if result.Error != nil {
+		return nil, fmt.Errorf("request failed: %s", result.Error.Message)
+	}
+	body, err := json.Marshal(result.Response)
+	if err != nil {
+		return nil, err
+	}
+	response := &types.ChatCompletionResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, fmt.Errorf("invalid response: %s", err.Error())
+	}
+
+	switch {
+	case response.ID == "":
+		return nil, errors.New("id is empty")
+	case response.Object != "chat.completion":
+		return nil, fmt.Errorf("object is %q, expected chat.completion", response.Object)
+	case len(response.Choices) == 0:
+		return nil, errors.New("choices is empty")
+	case response.Choices[0].Message.Role != types.ChatMessageRoleAssistant:
+		return nil, fmt.Errorf("message role is %q, expected assistant", response.Choices[0].Message.Role)
+	case response.Choices[0].FinishReason == "":
+		return nil, errors.New("finish_reason is empty")
+	case response.Usage == nil || response.Usage.PromptTokens == 0 || response.Usage.TotalTokens == 0:
+		return nil, errors.New("usage is missing")
+	}
+	return response, nil
+}
+
+func checkConformanceChat(result *PlaygroundResult) error {
+	response, err := conformanceResponse(result)
+	if err != nil {
+		return err
+	}
+	if response.Choices[0].Message.StringContent() == "" {
+		return errors.New("content is empty")
+	}
+	return nil
+}
+
+func checkConformanceStream(result *PlaygroundResult) error {
+	if result.Error != nil {
+		return fmt.Errorf("request failed: %s", result.Error.Message)
+	}
+	if len(result.Chunks) == 0 {
+		return errors.New("no chunks received")
+	}
+
+	content := ""
+	finished := false
+	for i, data := range result.Chunks {
+		var chunk types.ChatCompletionStreamResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("chunk %d is not valid JSON: %s", i, err.Error())
+		}
+		if chunk.ID == "" {
+			return fmt.Errorf("chunk %d id is empty", i)
+		}
+		if chunk.Object != "chat.completion.chunk" {
+			return fmt.Errorf("chunk %d object is %q, expected chat.completion.chunk", i, chunk.Object)
+		}
+		for _, choice := range chunk.Choices {
+			content += choice.Delta.Content
+			if reason, ok := choice.FinishReason.(string); ok && reason != "" {
+				finished = true
+			}
+		}
+	}
+
+	if content == "" {
+		return errors.New("content is empty")
+	}
+	if !finished {
+		return errors.New("no chunk with finish_reason")
+	}
+	return nil
+}
+
+func checkConformanceToolCalls(result *PlaygroundResult) error {
+	response, err := conformanceResponse(result)
+	if err != nil {
+		return err
+	}
+
+	choice := response.Choices[0]
+	if len(choice.Message.ToolCalls) == 0 {
+		return errors.New("tool_calls is empty")
+	}
+	if choice.FinishReason != types.FinishReasonToolCalls {
+		return fmt.Errorf("finish_reason is %q, expected tool_calls", choice.FinishReason)
+	}
+	for i, call := range choice.Message.ToolCalls {
+		if call.Id == "" || call.Type != types.ToolChoiceTypeFunction {
+			return fmt.Errorf("tool call %d is missing id or type", i)
+		}
+		if call.Function == nil || call.Function.Name != "get_weather" {
+			return fmt.Errorf("tool call %d has unexpected function", i)
+		}
+		if !json.Valid([]byte(call.Function.Arguments)) {
+			return fmt.Errorf("tool call %d arguments are not valid JSON", i)
+		}
+	}
+	return nil
+}
+
+func checkConformanceJSON(result *PlaygroundResult) error {
+	response, err := conformanceResponse(result)
+	if err != nil {
+		return err
+	}
+
+	var content map[string]any
+	if err := json.Unmarshal([]byte(response.Choices[0].Message.StringContent()), &content); err != nil {
+		return errors.New("content is not a JSON object")
+	}
+	return nil
+}
+
+func checkConformanceError(result *PlaygroundResult) error {
+	if result.Error == nil {
+		return errors.New("expected an error for an unknown model")
+	}
+	if result.StatusCode < 400 || result.StatusCode > 499 {
+		return fmt.Errorf("status code is %d, expected 4xx", result.StatusCode)
+	}
+	if result.Error.Message == "" {
+		return errors.New("error message is empty")
+	}
+	if result.Error.Type == "" {
+		return errors.New("error type is empty")
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"one-api/common"
+	"one-api/model"
+	"one-api/providers"
+	providers_base "one-api/providers/base"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChannelCredentialResult 渠道凭证的校验结果，Signed 为 false 时该类型不需要签名，只检查密钥是否为空
+type ChannelCredentialResult struct {
+	Signed  bool   `json:"signed"`
+	Valid   bool   `json:"valid"`
+	Message string `json:"message"`
+}
+
+// ValidateChannelCredentials 校验渠道密钥的格式并在本地生成签名，不发送补全请求
+func ValidateChannelCredentials(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := checkChannelTenant(c, id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	channel, err := model.GetChannelById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    validateChannelCredentials(channel),
+	})
+}
+
+func validateChannelCredentials(channel *model.Channel) *ChannelCredentialResult {
+	result := &ChannelCredentialResult{}
+	// 引用外部密钥时先解析，解析失败直接返回原因
+	key, err := channel.ResolveKey()
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	channel.Key = key
+	channel.SetProxy()
+
+	testCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	testCtx.Request, _ = http.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	provider := providers.GetProvider(channel, testCtx)
+	if provider == nil {
+		result.Message = "channel not implemented"
+		return result
+	}
+
+	validator, ok := provider.(providers_base.CredentialInterface)
+	if !ok {
+		result.Valid = strings.TrimSpace(channel.Key) != ""
+		if !result.Valid {
+			result.Message = "key 不能为空"
+		}
+		return result
+	}
+
+	result.Signed = true
+	if err := validator.ValidateCredentials(); err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	result.Valid = true
+	return result
+}
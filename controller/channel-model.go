@@ -1,11 +1,19 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/logger"
 	"one-api/model"
 	"one-api/providers"
 	providersBase "one-api/providers/base"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -42,43 +50,134 @@ func GetModelList(c *gin.Context) {
 		}
 	}
 
-	provider := providers.GetProvider(channel, c)
-	if provider == nil {
+	// refresh=true 时不使用缓存
+	modelList, err := cachedUpstreamModels(channel, c, c.Query("refresh") == "true")
+	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
-			"message": "provider not found",
+			"message": err.Error(),
 		})
 		return
 	}
 
+	// 去除重复的模型名称
+	uniqueModels := removeDuplicates(modelList)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    uniqueModels,
+	})
+}
+
+// fetchUpstreamModels 获取上游的模型列表，c 为空时使用测试上下文
+func fetchUpstreamModels(channel *model.Channel, c *gin.Context) ([]string, error) {
+	if c == nil {
+		c, _ = gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest(http.MethodGet, "/v1/models", nil)
+	}
+
+	provider := providers.GetProvider(channel, c)
+	if provider == nil {
+		return nil, errors.New("provider not found")
+	}
+
 	modelProvider, ok := provider.(providersBase.ModelListInterface)
 	if !ok {
-		c.JSON(http.StatusOK, gin.H{
-			"success": false,
-			"message": "channel not implemented",
-		})
-		return
+		return nil, errors.New("channel not implemented")
 	}
 
-	modelList, err := modelProvider.GetModelList()
+	return modelProvider.GetModelList()
+}
+
+// syncChannelModels 按渠道的同步设置更新模型列表，dryRun 时只返回结果
+func syncChannelModels(channel *model.Channel, dryRun bool) (*model.ModelSyncResult, error) {
+	// 多个 key 的渠道使用第一个 key 获取模型列表
+	upstream := *channel
+	upstream.Key = strings.Split(channel.Key, "\n")[0]
+
+	modelList, err := cachedUpstreamModels(&upstream, nil, false)
 	if err != nil {
+		return nil, err
+	}
+
+	result, err := channel.GetModelSync().Apply(channel.Models, modelList)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun || !result.Changed {
+		return result, nil
+	}
+
+	if err := channel.UpdateModels(result.Models); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SyncChannelModels 从上游同步渠道的模型列表，dry_run=true 时只返回变化
+func SyncChannelModels(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	channel, err := model.GetChannelById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if !inTenantScope(c, channel.TenantId) {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
-			"message": err.Error(),
+			"message": "无权操作其他租户的渠道",
 		})
 		return
 	}
 
-	// 去除重复的模型名称
-	uniqueModels := removeDuplicates(modelList)
-
+	result, err := syncChannelModels(channel, c.Query("dry_run") == "true")
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
-		"data":    uniqueModels,
+		"data":    result,
 	})
 }
 
+// AutomaticallySyncChannelModels 定时同步开启了自动同步的渠道，只在主节点执行
+func AutomaticallySyncChannelModels(frequency int) {
+	if frequency <= 0 {
+		return
+	}
+
+	for {
+		time.Sleep(time.Duration(frequency) * time.Minute)
+		if !config.IsMasterNode {
+			continue
+		}
+
+		channels, err := model.GetModelSyncChannels()
+		if err != nil {
+			logger.SysError("failed to get channels for model sync: " + err.Error())
+			continue
+		}
+		for _, channel := range channels {
+			result, err := syncChannelModels(channel, false)
+			if err != nil {
+				logger.SysError(fmt.Sprintf("failed to sync models of channel #%d: %s", channel.Id, err.Error()))
+				continue
+			}
+			if result.Changed {
+				logger.SysLog(fmt.Sprintf("channel #%d models synced, added: %s, removed: %s", channel.Id, strings.Join(result.Added, ","), strings.Join(result.Removed, ",")))
+			}
+			time.Sleep(config.RequestInterval)
+		}
+	}
+}
+
 // 辅助函数：去除切片中的重复元素
 func removeDuplicates(slice []string) []string {
 	keys := make(map[string]bool)
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"one-api/common/requester"
+	"one-api/model"
+	"one-api/providers"
+	providers_base "one-api/providers/base"
+	"one-api/types"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlaygroundRequest 调试请求，Request 为 OpenAI 格式的聊天请求，Model 为空时使用其中的 model
+type PlaygroundRequest struct {
+	ChannelId int                         `json:"channel_id"`
+	Model     string                      `json:"model"`
+	Request   types.ChatCompletionRequest `json:"request"`
+}
+
+// PlaygroundResult 调试结果，Exchanges 为发送到上游的原始请求和响应
+type PlaygroundResult struct {
+	Model         string                `json:"model"`
+	UpstreamModel string                `json:"upstream_model"`
+	Time          float64               `json:"time"`
+	Response      any                   `json:"response,omitempty"`
+	Chunks        []string              `json:"chunks,omitempty"`
+	Error         *types.OpenAIError    `json:"error,omitempty"`
+	StatusCode    int                   `json:"status_code,omitempty"`
+	Exchanges     []*requester.Exchange `json:"exchanges"`
+	Usage         *types.Usage          `json:"usage,omitempty"`
+}
+
+// ChannelPlayground 通过指定渠道发送一次聊天请求，返回转换后的结果和上游的原始请求响应
+// 不计费、不记录日志，也不会因为失败而禁用渠道
+func ChannelPlayground(c *gin.Context) {
+	var req PlaygroundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无效的参数",
+		})
+		return
+	}
+	if req.Model == "" {
+		req.Model = req.Request.Model
+	}
+	if req.ChannelId == 0 || req.Model == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "请选择渠道和模型",
+		})
+		return
+	}
+
+	channel, err := model.GetChannelById(req.ChannelId)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if !inTenantScope(c, channel.TenantId) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无权访问其他租户的渠道",
+		})
+		return
+	}
+
+	result, err := playground(c, channel, req.Model, &req.Request)
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    result,
+	})
+}
+
+func playground(c *gin.Context, channel *model.Channel, modelName string, request *types.ChatCompletionRequest) (*PlaygroundResult, error) {
+	channel.SetProxy()
+
+	w := httptest.NewRecorder()
+	testCtx, _ := gin.CreateTestContext(w)
+	httpReq, err := http.NewRequestWithContext(c.Request.Context(), http.MethodPost, "/v1/chat/completions", nil)
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	testCtx.Request = httpReq
+
+	provider := providers.GetProvider(channel, testCtx)
+	if provider == nil {
+		return nil, errors.New("channel not implemented")
+	}
+	chatProvider, ok := provider.(providers_base.ChatInterface)
+	if !ok {
+		return nil, errors.New("channel not implemented")
+	}
+
+	r := provider.GetRequester()
+	if r == nil {
+		return nil, errors.New("channel not implemented")
+	}
+	var capture *requester.CaptureTransport
+	r.HTTPClient, capture = requester.CaptureClient(r.HTTPClient)
+	r.Context = c.Request.Context()
+
+	upstreamModel, err := provider.ModelMappingHandler(modelName)
+	if err != nil {
+		return nil, err
+	}
+	upstreamModel = strings.TrimPrefix(upstreamModel, "+")
+	request.Model = upstreamModel
+
+	usage := &types.Usage{}
+	provider.SetUsage(usage)
+
+	result := &PlaygroundResult{
+		Model:         modelName,
+		UpstreamModel: upstreamModel,
+		Usage:         usage,
+	}
+
+	start := time.Now()
+	var apiErr *types.OpenAIErrorWithStatusCode
+	if request.Stream {
+		var stream requester.StreamReaderInterface[string]
+		stream, apiErr = chatProvider.CreateChatCompletionStream(request)
+		if apiErr == nil {
+			result.Chunks, err = drainStream(stream)
+			if err != nil {
+				result.Error = &types.OpenAIError{Message: err.Error(), Type: "stream_error"}
+			}
+		}
+	} else {
+		result.Response, apiErr = chatProvider.CreateChatCompletion(request)
+	}
+	result.Time = float64(time.Since(start).Milliseconds()) / 1000.0
+
+	if apiErr != nil {
+		result.Error = &apiErr.OpenAIError
+		result.StatusCode = apiErr.StatusCode
+		result.Response = nil
+	}
+	result.Exchanges = capture.Exchanges()
+
+	return result, nil
+}
+
+// drainStream 读取转换后的流式数据，直到结束或出错
+func drainStream(stream requester.StreamReaderInterface[string]) ([]string, error) {
+	defer stream.Close()
+
+	var chunks []string
+	dataChan, errChan := stream.Recv()
+	for {
+		select {
+		case data, ok := <-dataChan:
+			if !ok {
+				return chunks, nil
+			}
+			chunks = append(chunks, data)
+		case err := <-errChan:
+			// 结束信号可能先于缓冲中的数据到达
+			for {
+				select {
+				case data, ok := <-dataChan:
+					if ok {
+						chunks = append(chunks, data)
+						continue
+					}
+				default:
+				}
+				break
+			}
+			if errors.Is(err, io.EOF) {
+				return chunks, nil
+			}
+			return chunks, err
+		}
+	}
+}
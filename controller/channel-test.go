@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"one-api/common"
 	"one-api/common/config"
 	"one-api/common/logger"
 	"one-api/common/notify"
@@ -21,6 +22,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 )
 
 var (
@@ -153,6 +155,12 @@ func testChannel(channel *model.Channel, testModel string) (openaiErr *types.Ope
 	return nil, nil
 }
 
+// TestChannelWithModel 测试渠道，失败时不会自动禁用，供命令行使用
+func TestChannelWithModel(channel *model.Channel, testModel string) error {
+	_, err := testChannel(channel, testModel)
+	return err
+}
+
 func getModelType(modelName string) string {
 	if noSupportRegex.MatchString(modelName) {
 		return "noSupport"
@@ -190,7 +198,31 @@ func TestChannel(c *gin.Context) {
 		})
 		return
 	}
+	if !inTenantScope(c, channel.TenantId) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无权访问其他租户的渠道",
+		})
+		return
+	}
 	testModel := c.Query("model")
+	if c.Query("mode") == "conformance" {
+		report, err := channelConformance(c, channel, testModel)
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "",
+			"data":    report,
+		})
+		return
+	}
+
 	tik := time.Now()
 	openaiErr, err := testChannel(channel, testModel)
 	tok := time.Now()
@@ -224,88 +256,217 @@ func TestChannel(c *gin.Context) {
 var testAllChannelsLock sync.Mutex
 var testAllChannelsRunning bool = false
 
-func testAllChannels(isNotify bool) error {
+// channelTestOutcome 单个渠道的测试结果和通知内容
+type channelTestOutcome struct {
+	results []*model.ChannelTestResult
+	message string
+}
+
+// testAllChannels 按 channel.test_concurrency 并发测试所有渠道，同一渠道的模型依次测试
+// allModels 为 true 时测试渠道的所有模型，否则只测试测速模型，渠道的启用或禁用只取决于测速模型的结果
+func testAllChannels(trigger string, allModels bool, isNotify bool) (*model.ChannelTestReport, error) {
 	testAllChannelsLock.Lock()
 	if testAllChannelsRunning {
 		testAllChannelsLock.Unlock()
-		return errors.New("测试已在运行中")
+		return nil, errors.New("测试已在运行中")
 	}
 	testAllChannelsRunning = true
 	testAllChannelsLock.Unlock()
+
+	finish := func() {
+		testAllChannelsLock.Lock()
+		testAllChannelsRunning = false
+		testAllChannelsLock.Unlock()
+	}
+
 	channels, err := model.GetAllChannels()
 	if err != nil {
-		return err
+		finish()
+		return nil, err
 	}
-	var disableThreshold = int64(config.ChannelDisableThreshold * 1000)
-	if disableThreshold == 0 {
-		disableThreshold = 10000000 // a impossible value
+
+	report := &model.ChannelTestReport{
+		Trigger:   trigger,
+		Status:    model.ChannelTestStatusRunning,
+		AllModels: allModels,
+		Channels:  len(channels),
+		StartedAt: utils.GetTimestamp(),
+	}
+	if err := report.Insert(); err != nil {
+		finish()
+		return nil, err
 	}
+
+	concurrency := viper.GetInt("channel.test_concurrency")
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
 	go func() {
+		defer finish()
+
+		outcomes := make([]*channelTestOutcome, len(channels))
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i, channel := range channels {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(i int, channel *model.Channel) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				outcomes[i] = testChannelModels(channel, allModels)
+			}(i, channel)
+		}
+		wg.Wait()
+
+		results := make([]*model.ChannelTestResult, 0, len(channels))
 		var sendMessage string
-		for _, channel := range channels {
-			time.Sleep(config.RequestInterval)
-
-			isChannelEnabled := channel.Status == config.ChannelStatusEnabled
-			sendMessage += fmt.Sprintf("**通道 %s - #%d - %s** : \n\n", utils.EscapeMarkdownText(channel.Name), channel.Id, channel.StatusToStr())
-			tik := time.Now()
-			openaiErr, err := testChannel(channel, "")
-			tok := time.Now()
-			milliseconds := tok.Sub(tik).Milliseconds()
-			// 通道为禁用状态，并且还是请求错误 或者 响应时间超过阈值 直接跳过，也不需要更新响应时间。
-			if !isChannelEnabled {
-				if err != nil {
-					sendMessage += fmt.Sprintf("- 测试报错: %s \n\n- 无需改变状态，跳过\n\n", utils.EscapeMarkdownText(err.Error()))
-					continue
-				}
-				if milliseconds > disableThreshold {
-					sendMessage += fmt.Sprintf("- 响应时间 %.2fs 超过阈值 %.2fs \n\n- 无需改变状态，跳过\n\n", float64(milliseconds)/1000.0, float64(disableThreshold)/1000.0)
-					continue
-				}
-				// 如果已被禁用，但是请求成功，需要判断是否需要恢复
-				// 手动禁用的通道，不会自动恢复
-				if shouldEnableChannel(err, openaiErr) {
-					if channel.Status == config.ChannelStatusAutoDisabled {
-						EnableChannel(channel.Id, channel.Name, false)
-						sendMessage += "- 已被启用 \n\n"
-					} else {
-						sendMessage += "- 手动禁用的通道，不会自动恢复 \n\n"
-					}
-				}
-			} else {
-				// 如果通道启用状态，但是返回了错误 或者 响应时间超过阈值，需要判断是否需要禁用
-				if milliseconds > disableThreshold {
-					errMsg := fmt.Sprintf("响应时间 %.2fs 超过阈值 %.2fs ", float64(milliseconds)/1000.0, float64(disableThreshold)/1000.0)
-					sendMessage += fmt.Sprintf("- %s \n\n- 禁用\n\n", errMsg)
-					DisableChannel(channel.Id, channel.Name, errMsg, false)
-					continue
-				}
-
-				if ShouldDisableChannel(channel.Type, openaiErr) {
-					sendMessage += fmt.Sprintf("- 已被禁用，原因：%s\n\n", utils.EscapeMarkdownText(err.Error()))
-					DisableChannel(channel.Id, channel.Name, err.Error(), false)
-					continue
-				}
-
-				if err != nil {
-					sendMessage += fmt.Sprintf("- 测试报错: %s \n\n", utils.EscapeMarkdownText(err.Error()))
-					continue
-				}
-			}
-			channel.UpdateResponseTime(milliseconds)
-			sendMessage += fmt.Sprintf("- 测试完成，耗时 %.2fs\n\n", float64(milliseconds)/1000.0)
+		for _, outcome := range outcomes {
+			results = append(results, outcome.results...)
+			sendMessage += outcome.message
 		}
-		testAllChannelsLock.Lock()
-		testAllChannelsRunning = false
-		testAllChannelsLock.Unlock()
+
+		report.FinishedAt = utils.GetTimestamp()
+		if err := report.Finish(results, viper.GetInt("channel.test_report_keep")); err != nil {
+			logger.SysError("failed to save channel test report: " + err.Error())
+		}
+
 		if isNotify {
+			sendMessage = fmt.Sprintf("报告 #%d：共 %d 项，通过 %d，失败 %d，跳过 %d\n\n", report.Id, report.Total, report.Passed, report.Failed, report.Skipped) + sendMessage
 			notify.Send("通道测试完成", sendMessage)
 		}
 	}()
-	return nil
+
+	return report, nil
 }
 
+// channelTestModels 需要测试的模型，第一个为测速模型，未设置测速模型时使用渠道的第一个模型
+func channelTestModels(channel *model.Channel, allModels bool) []string {
+	models := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(name string) {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			return
+		}
+		seen[name] = true
+		models = append(models, name)
+	}
+
+	add(channel.TestModel)
+	if allModels {
+		for _, name := range strings.Split(channel.Models, ",") {
+			add(name)
+		}
+	}
+	return models
+}
+
+func testChannelModels(channel *model.Channel, allModels bool) *channelTestOutcome {
+	outcome := &channelTestOutcome{}
+	outcome.message = fmt.Sprintf("**通道 %s - #%d - %s** : \n\n", utils.EscapeMarkdownText(channel.Name), channel.Id, channel.StatusToStr())
+
+	models := channelTestModels(channel, allModels)
+	if len(models) == 0 {
+		// 没有可测试的模型，沿用单个渠道测试的报错
+		models = []string{""}
+	}
+
+	for i, testModel := range models {
+		result := &model.ChannelTestResult{
+			ChannelId:   channel.Id,
+			ChannelName: channel.Name,
+			Model:       testModel,
+			CreatedAt:   utils.GetTimestamp(),
+		}
+		outcome.results = append(outcome.results, result)
+
+		if getModelType(testModel) == "noSupport" {
+			result.Skipped = true
+			result.Error = "不支持测试的模型类型"
+			continue
+		}
+
+		time.Sleep(config.RequestInterval)
+		tik := time.Now()
+		openaiErr, err := testChannel(channel, testModel)
+		milliseconds := time.Since(tik).Milliseconds()
+		result.Latency = milliseconds
+		result.Success = err == nil
+		if err != nil {
+			result.Error = err.Error()
+		}
+
+		if i == 0 {
+			var message string
+			result.Action, message = applyChannelTestResult(channel, milliseconds, openaiErr, err)
+			outcome.message += message
+		} else if err != nil {
+			outcome.message += fmt.Sprintf("- 模型 %s 测试报错: %s \n\n", utils.EscapeMarkdownText(testModel), utils.EscapeMarkdownText(err.Error()))
+		}
+	}
+
+	return outcome
+}
+
+// applyChannelTestResult 根据测速模型的结果启用或禁用渠道，返回对渠道的处理和通知内容
+func applyChannelTestResult(channel *model.Channel, milliseconds int64, openaiErr *types.OpenAIErrorWithStatusCode, err error) (string, string) {
+	var disableThreshold = int64(config.ChannelDisableThreshold * 1000)
+	if disableThreshold == 0 {
+		disableThreshold = 10000000 // a impossible value
+	}
+
+	// 通道为禁用状态，并且还是请求错误 或者 响应时间超过阈值 直接跳过，也不需要更新响应时间。
+	if channel.Status != config.ChannelStatusEnabled {
+		if err != nil {
+			return "", fmt.Sprintf("- 测试报错: %s \n\n- 无需改变状态，跳过\n\n", utils.EscapeMarkdownText(err.Error()))
+		}
+		if milliseconds > disableThreshold {
+			return "", fmt.Sprintf("- 响应时间 %.2fs 超过阈值 %.2fs \n\n- 无需改变状态，跳过\n\n", float64(milliseconds)/1000.0, float64(disableThreshold)/1000.0)
+		}
+
+		channel.UpdateResponseTime(milliseconds)
+		message := fmt.Sprintf("- 测试完成，耗时 %.2fs\n\n", float64(milliseconds)/1000.0)
+		// 如果已被禁用，但是请求成功，需要判断是否需要恢复
+		// 手动禁用的通道，不会自动恢复
+		if shouldEnableChannel(err, openaiErr) {
+			if channel.Status == config.ChannelStatusAutoDisabled {
+				EnableChannel(channel.Id, channel.Name, false)
+				return "enabled", "- 已被启用 \n\n" + message
+			}
+			return "", "- 手动禁用的通道，不会自动恢复 \n\n" + message
+		}
+		return "", message
+	}
+
+	// 如果通道启用状态，但是返回了错误 或者 响应时间超过阈值，需要判断是否需要禁用
+	if milliseconds > disableThreshold {
+		errMsg := fmt.Sprintf("响应时间 %.2fs 超过阈值 %.2fs ", float64(milliseconds)/1000.0, float64(disableThreshold)/1000.0)
+		DisableChannel(channel.Id, channel.Name, errMsg, false)
+		return "disabled", fmt.Sprintf("- %s \n\n- 禁用\n\n", errMsg)
+	}
+	if ShouldDisableChannel(channel.Type, openaiErr) {
+		DisableChannel(channel.Id, channel.Name, err.Error(), false)
+		return "disabled", fmt.Sprintf("- 已被禁用，原因：%s\n\n", utils.EscapeMarkdownText(err.Error()))
+	}
+	if err != nil {
+		return "", fmt.Sprintf("- 测试报错: %s \n\n", utils.EscapeMarkdownText(err.Error()))
+	}
+
+	channel.UpdateResponseTime(milliseconds)
+	return "", fmt.Sprintf("- 测试完成，耗时 %.2fs\n\n", float64(milliseconds)/1000.0)
+}
+
+// TestAllChannels 后台测试所有渠道，all_models=true 时测试渠道的所有模型，返回报告 id
 func TestAllChannels(c *gin.Context) {
-	err := testAllChannels(true)
+	allModels := viper.GetBool("channel.test_all_models")
+	if value := c.Query("all_models"); value != "" {
+		allModels = value == "true"
+	}
+
+	report, err := testAllChannels(model.ChannelTestTriggerManual, allModels, true)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -316,6 +477,46 @@ func TestAllChannels(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
+		"data":    report,
+	})
+}
+
+func GetChannelTestReports(c *gin.Context) {
+	var params model.PaginationParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	reports, err := model.GetChannelTestReports(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    reports,
+	})
+}
+
+// GetChannelTestReport 获取报告详情，failed=true 时只返回失败的结果
+func GetChannelTestReport(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	report, err := model.GetChannelTestReport(id, c.Query("failed") == "true")
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    report,
 	})
 }
 
@@ -327,7 +528,7 @@ func AutomaticallyTestChannels(frequency int) {
 	for {
 		time.Sleep(time.Duration(frequency) * time.Minute)
 		logger.SysLog("testing all channels")
-		_ = testAllChannels(false)
+		_, _ = testAllChannels(model.ChannelTestTriggerScheduled, viper.GetBool("channel.test_all_models"), false)
 		logger.SysLog("channel test finished")
 	}
 }
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"net/http"
 	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/secrets"
 	"one-api/common/utils"
 	"one-api/model"
+	"one-api/providers/openai"
 	"strconv"
 	"strings"
 
@@ -19,6 +22,10 @@ func GetChannelsList(c *gin.Context) {
 		return
 	}
 
+	if tenantId := c.GetInt("tenant_id"); tenantId != 0 {
+		params.TenantId = tenantId
+	}
+
 	channels, err := model.GetChannelsList(&params)
 	if err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
@@ -48,6 +55,13 @@ func GetChannel(c *gin.Context) {
 		})
 		return
 	}
+	if !inTenantScope(c, channel.TenantId) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无权访问其他租户的渠道",
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -65,7 +79,66 @@ func AddChannel(c *gin.Context) {
 		})
 		return
 	}
+	if err = validateChannel(&channel); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = checkSecretReference(c, channel.Key); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if tenantId := c.GetInt("tenant_id"); tenantId != 0 {
+		channel.TenantId = tenantId
+	}
 	channel.CreatedTime = utils.GetTimestamp()
+	// duplicate_policy 为空时使用系统设置的重复 key 处理方式
+	result, err := model.ImportChannels(splitChannelKeys(channel), c.Query("duplicate_policy"), c.GetInt("tenant_id"))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    result,
+	})
+}
+
+// validateChannel 检查渠道的代理、Azure 凭据、HTTP、自定义请求头和请求体、模型同步、流式和时间段设置
+func validateChannel(channel *model.Channel) error {
+	if err := channel.ValidateProxy(); err != nil {
+		return err
+	}
+	if channel.Type == config.ChannelTypeAzure || channel.Type == config.ChannelTypeAzureV1 {
+		for _, key := range strings.Split(channel.Key, "\n") {
+			if _, err := openai.ParseAzureADCredentials(key); err != nil {
+				return err
+			}
+		}
+	}
+	if err := channel.ValidateInjection(); err != nil {
+		return err
+	}
+	if err := channel.GetHTTPConfig().Validate(); err != nil {
+		return err
+	}
+	if err := channel.GetModelSync().Validate(); err != nil {
+		return err
+	}
+	if err := channel.ValidateStreamMode(); err != nil {
+		return err
+	}
+	return channel.ValidateSchedule()
+}
+
+// splitChannelKeys key 按行拆分为多个渠道，base_url 也有多行时按顺序对应
+func splitChannelKeys(channel model.Channel) []model.Channel {
 	keys := strings.Split(channel.Key, "\n")
 
 	baseUrls := []string{}
@@ -91,22 +164,15 @@ func AddChannel(c *gin.Context) {
 
 		channels = append(channels, localChannel)
 	}
-	err = model.BatchInsertChannels(channels)
-	if err != nil {
-		c.JSON(http.StatusOK, gin.H{
-			"success": false,
-			"message": err.Error(),
-		})
-		return
-	}
-	c.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "",
-	})
+	return channels
 }
 
 func DeleteChannel(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
+	if err := checkChannelTenant(c, id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
 	channel := model.Channel{Id: id}
 	err := channel.Delete()
 	if err != nil {
@@ -164,11 +230,38 @@ func UpdateChannel(c *gin.Context) {
 		})
 		return
 	}
+	if err = validateChannel(&channel); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err = checkChannelTenant(c, channel.Id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	existing, err := model.GetChannelById(channel.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err = checkSecretReference(c, channel.Key, existing.Key); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if tenantId := c.GetInt("tenant_id"); tenantId != 0 {
+		channel.TenantId = tenantId
+	}
 	if channel.Models == "" {
 		err = channel.Update(false)
 	} else {
 		err = channel.Update(true)
 	}
+	if errors.Is(err, model.ErrVersionConflict) {
+		common.APIRespondWithError(c, http.StatusConflict, err)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -234,6 +327,26 @@ func BatchDelModelChannels(c *gin.Context) {
 	})
 }
 
+// BatchPatchChannels 批量修改渠道的优先级、权重、分组、模型和模型映射，全部成功或全部失败
+func BatchPatchChannels(c *gin.Context) {
+	var params model.BatchPatchChannelsParams
+	if err := c.ShouldBindJSON(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	count, err := model.BatchPatchChannels(&params, c.GetInt("tenant_id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":    count,
+		"success": true,
+		"message": "更新成功",
+	})
+}
+
 func BatchDeleteChannel(c *gin.Context) {
 	var params model.BatchChannelsParams
 	err := c.ShouldBindJSON(&params)
@@ -258,3 +371,44 @@ func BatchDeleteChannel(c *gin.Context) {
 		"data":    count,
 	})
 }
+
+// checkSecretReference 外部密钥引用由服务器读取环境变量和密钥库，只有超级管理员可以保存引用，
+// 或修改、复制使用引用的渠道，避免把服务器的密钥发送到其他人设置的 base_url
+func checkSecretReference(c *gin.Context, keys ...string) error {
+	if c.GetInt("role") >= config.RoleRootUser && c.GetInt("tenant_id") == 0 {
+		return nil
+	}
+	for _, key := range keys {
+		for _, line := range strings.Split(key, "\n") {
+			if secrets.IsReference(strings.TrimSpace(line)) {
+				return errors.New("只有超级管理员可以使用外部密钥引用")
+			}
+		}
+	}
+	return nil
+}
+
+// checkChannelTenant 租户管理员只能操作本租户的渠道
+func checkChannelTenant(c *gin.Context, channelId int) error {
+	if c.GetInt("tenant_id") == 0 {
+		return nil
+	}
+
+	channel, err := model.GetChannelById(channelId)
+	if err != nil {
+		return err
+	}
+	if !inTenantScope(c, channel.TenantId) {
+		return errors.New("无权操作其他租户的渠道")
+	}
+	return nil
+}
+
+// GetChannelAdjustments 本实例按成功率和延迟对渠道的自动调整
+func GetChannelAdjustments(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    model.GetChannelAdjustments(),
+	})
+}
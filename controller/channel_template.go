@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"one-api/common"
+	"one-api/common/utils"
+	"one-api/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/datatypes"
+)
+
+type ChannelTemplateRequest struct {
+	model.ChannelTemplate
+	FromChannelId int `json:"from_channel_id"` // 不为 0 时使用该渠道的设置作为模板
+}
+
+type ApplyChannelTemplateRequest struct {
+	Key     string `json:"key" binding:"required"` // 按行拆分为多个渠道
+	Name    string `json:"name"`                   // 为空时使用模板中的渠道名称
+	BaseURL string `json:"base_url"`               // 为空时使用模板中的地址，多行时与 key 按顺序对应
+	// 重复 key 的处理方式，为空时使用系统设置
+	DuplicatePolicy string `json:"duplicate_policy"`
+}
+
+type CloneChannelRequest struct {
+	Name    string  `json:"name"`     // 为空时在原名称后加 _copy
+	Key     string  `json:"key"`      // 为空时沿用原渠道的密钥，多行时拆分为多个渠道
+	BaseURL *string `json:"base_url"` // 为空时沿用原渠道的地址
+	// 重复 key 的处理方式，为空时使用系统设置，沿用原密钥时不检查
+	DuplicatePolicy string `json:"duplicate_policy"`
+}
+
+func GetChannelTemplatesList(c *gin.Context) {
+	var params model.GenericParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	templates, err := model.GetChannelTemplatesList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    templates,
+	})
+}
+
+func GetChannelTemplate(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	template, err := model.GetChannelTemplateById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    template,
+	})
+}
+
+// bindChannelTemplate 解析模板请求，from_channel_id 不为 0 时复制该渠道的设置
+func bindChannelTemplate(c *gin.Context) (*model.ChannelTemplate, error) {
+	var req ChannelTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return nil, err
+	}
+
+	template := req.ChannelTemplate
+	if req.FromChannelId != 0 {
+		channel, err := model.GetChannelById(req.FromChannelId)
+		if err != nil {
+			return nil, err
+		}
+		template.Channel = datatypes.NewJSONType(*channel)
+	}
+
+	channel := template.Channel.Data()
+	if err := validateChannel(&channel); err != nil {
+		return nil, err
+	}
+	if err := checkSecretReference(c, channel.Key); err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func AddChannelTemplate(c *gin.Context) {
+	template, err := bindChannelTemplate(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := template.Insert(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    template,
+	})
+}
+
+func UpdateChannelTemplate(c *gin.Context) {
+	template, err := bindChannelTemplate(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if _, err := model.GetChannelTemplateById(template.Id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := template.Update(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    template,
+	})
+}
+
+func DeleteChannelTemplate(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := model.DeleteChannelTemplate(id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// ApplyChannelTemplate 按模板添加渠道，只需要提供密钥，模板中的价格只补充系统中没有的模型
+func ApplyChannelTemplate(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	template, err := model.GetChannelTemplateById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var req ApplyChannelTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	channel := template.NewChannel()
+	channel.Key = req.Key
+	if req.Name != "" {
+		channel.Name = req.Name
+	}
+	if channel.Name == "" {
+		channel.Name = template.Name
+	}
+	if req.BaseURL != "" {
+		channel.BaseURL = &req.BaseURL
+	}
+	if tenantId := c.GetInt("tenant_id"); tenantId != 0 {
+		channel.TenantId = tenantId
+	}
+
+	if err := checkSecretReference(c, channel.Key); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	channels := splitChannelKeys(channel)
+	if len(channels) == 0 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("key 不能为空"))
+		return
+	}
+	result, err := model.ImportChannels(channels, req.DuplicatePolicy, c.GetInt("tenant_id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	// 价格对所有租户生效，租户管理员添加渠道时不修改价格
+	if c.GetInt("tenant_id") == 0 {
+		if err := template.ApplyPrices(); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("渠道已添加，但补充模型价格失败："+err.Error()))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    result,
+	})
+}
+
+// CloneChannel 复制渠道的所有设置，可以替换名称、密钥和地址
+func CloneChannel(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := checkChannelTenant(c, id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	source, err := model.GetChannelById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	// 请求体为空时完全复制
+	var req CloneChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	channel := source.Clone()
+	channel.TenantId = source.TenantId
+	channel.Key = source.Key
+	if req.Key != "" {
+		channel.Key = req.Key
+	}
+	channel.Name = source.Name + "_copy"
+	if req.Name != "" {
+		channel.Name = req.Name
+	}
+	if req.BaseURL != nil {
+		channel.BaseURL = req.BaseURL
+	}
+	channel.CreatedTime = utils.GetTimestamp()
+	if err := checkSecretReference(c, channel.Key); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	// 沿用原密钥时是有意复制，不检查重复
+	policy := req.DuplicatePolicy
+	if req.Key == "" {
+		policy = model.DuplicateKeyPolicyAllow
+	}
+	result, err := model.ImportChannels(splitChannelKeys(channel), policy, c.GetInt("tenant_id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    result,
+	})
+}
@@ -60,6 +60,10 @@ func ShouldDisableChannel(channelType int, err *types.OpenAIErrorWithStatusCode)
 
 // disable & notify
 func DisableChannel(channelId int, channelName string, reason string, sendNotify bool) {
+	// 排空中的渠道保持原状态，避免之后被自动恢复
+	if channel, err := model.GetChannelById(channelId); err == nil && channel.Status == config.ChannelStatusDraining {
+		return
+	}
 	model.UpdateChannelStatusById(channelId, config.ChannelStatusAutoDisabled)
 	if !sendNotify {
 		return
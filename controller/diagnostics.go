@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/middleware"
+	"runtime"
+	runtimePprof "runtime/pprof"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// RuntimeStats 本实例的运行时状态
+type RuntimeStats struct {
+	GoVersion    string  `json:"go_version"`
+	NumCPU       int     `json:"num_cpu"`
+	GOMAXPROCS   int     `json:"gomaxprocs"`
+	Goroutines   int     `json:"goroutines"`
+	InFlight     int     `json:"in_flight"` // 正在处理的中继请求数
+	Uptime       int64   `json:"uptime"`    // 秒
+	HeapAlloc    uint64  `json:"heap_alloc"`
+	HeapInuse    uint64  `json:"heap_inuse"`
+	HeapObjects  uint64  `json:"heap_objects"`
+	StackInuse   uint64  `json:"stack_inuse"`
+	Sys          uint64  `json:"sys"`
+	TotalAlloc   uint64  `json:"total_alloc"`
+	NumGC        uint32  `json:"num_gc"`
+	LastGC       int64   `json:"last_gc"`
+	LastGCPause  uint64  `json:"last_gc_pause"` // 纳秒
+	GCCPUPercent float64 `json:"gc_cpu_percent"`
+}
+
+func GetRuntimeStats(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := &RuntimeStats{
+		GoVersion:    runtime.Version(),
+		NumCPU:       runtime.NumCPU(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		Goroutines:   runtime.NumGoroutine(),
+		InFlight:     middleware.InFlightCount(),
+		Uptime:       time.Now().Unix() - config.StartTime,
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		HeapObjects:  mem.HeapObjects,
+		StackInuse:   mem.StackInuse,
+		Sys:          mem.Sys,
+		TotalAlloc:   mem.TotalAlloc,
+		NumGC:        mem.NumGC,
+		LastGCPause:  mem.PauseNs[(mem.NumGC+255)%256],
+		GCCPUPercent: mem.GCCPUFraction * 100,
+	}
+	if mem.LastGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC)).Unix()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    stats,
+	})
+}
+
+// GetInFlightRequests 本实例正在处理的中继请求，处理时间最长的在前，min_age 可只返回处理超过指定秒数的请求
+func GetInFlightRequests(c *gin.Context) {
+	minAge, _ := strconv.ParseFloat(c.Query("min_age"), 64)
+
+	requests := make([]*middleware.InFlightRequest, 0)
+	for _, request := range middleware.GetInFlightRequests() {
+		if request.Age >= minAge {
+			requests = append(requests, request)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    requests,
+	})
+}
+
+func pprofEnabled(c *gin.Context) bool {
+	if !viper.GetBool("diagnostics.pprof") {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("未开启 diagnostics.pprof"))
+		return false
+	}
+	return true
+}
+
+// GetGoroutineDump 以文本返回所有 goroutine 的调用栈，debug=1 时合并相同的调用栈
+func GetGoroutineDump(c *gin.Context) {
+	if !pprofEnabled(c) {
+		return
+	}
+	debug := 2
+	if c.Query("debug") == "1" {
+		debug = 1
+	}
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	_ = runtimePprof.Lookup("goroutine").WriteTo(c.Writer, debug)
+}
+
+// GetPprofProfiles 可用的 pprof 分析项
+func GetPprofProfiles(c *gin.Context) {
+	if !pprofEnabled(c) {
+		return
+	}
+	profiles := []string{"profile", "trace"}
+	for _, profile := range runtimePprof.Profiles() {
+		profiles = append(profiles, profile.Name())
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    profiles,
+	})
+}
+
+// GetPprofProfile 与 net/http/pprof 相同，profile 和 trace 按 seconds 参数采样，其它分析项支持 debug 和 gc 参数
+func GetPprofProfile(c *gin.Context) {
+	if !pprofEnabled(c) {
+		return
+	}
+	switch name := c.Param("name"); name {
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	default:
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
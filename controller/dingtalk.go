@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/model"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var dingTalkProvider = &enterpriseOAuthProvider{
+	Name:        EnterpriseOAuthDingTalk,
+	DisplayName: "钉钉",
+	IdField:     "dingtalk_id",
+	SetId: func(user *model.User, id string) {
+		user.DingTalkId = id
+	},
+}
+
+type DingTalkAccessTokenResponse struct {
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	AccessToken string `json:"accessToken"`
+}
+
+type DingTalkUser struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Nick      string `json:"nick"`
+	UnionId   string `json:"unionId"`
+	AvatarUrl string `json:"avatarUrl"`
+	Email     string `json:"email"`
+}
+
+type DingTalkTopResponse[T any] struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+	Result  T      `json:"result"`
+}
+
+type DingTalkMember struct {
+	UserId     string `json:"userid"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	OrgEmail   string `json:"org_email"`
+	DeptIdList []int  `json:"dept_id_list"`
+}
+
+func postDingTalk(rawURL string, header map[string]string, body, v any) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, rawURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	return doDingTalk(req, header, v)
+}
+
+func doDingTalk(req *http.Request, header map[string]string, v any) error {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for key, value := range header {
+		req.Header.Set(key, value)
+	}
+	client := http.Client{
+		Timeout: 5 * time.Second,
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		logger.SysError("无法连接至 钉钉 服务器, err:" + err.Error())
+		return errors.New("无法连接至钉钉服务器，请稍后重试！")
+	}
+	defer res.Body.Close()
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// getDingTalkMember 用应用凭证按 unionId 查询企业成员，不是本企业成员时返回错误
+func getDingTalkMember(unionId string) (*DingTalkMember, error) {
+	var tokenRes DingTalkAccessTokenResponse
+	err := postDingTalk("https://api.dingtalk.com/v1.0/oauth2/accessToken", nil, map[string]string{
+		"appKey":    config.DingTalkClientId,
+		"appSecret": config.DingTalkClientSecret,
+	}, &tokenRes)
+	if err != nil {
+		return nil, err
+	}
+	if tokenRes.AccessToken == "" {
+		return nil, errors.New(tokenRes.Message)
+	}
+	query := url.Values{"access_token": {tokenRes.AccessToken}}.Encode()
+
+	var userIdRes DingTalkTopResponse[struct {
+		UserId string `json:"userid"`
+	}]
+	err = postDingTalk("https://oapi.dingtalk.com/topapi/user/getbyunionid?"+query, nil, map[string]string{"unionid": unionId}, &userIdRes)
+	if err != nil {
+		return nil, err
+	}
+	if userIdRes.ErrCode != 0 || userIdRes.Result.UserId == "" {
+		return nil, errors.New("仅企业成员可以登录")
+	}
+
+	var memberRes DingTalkTopResponse[DingTalkMember]
+	err = postDingTalk("https://oapi.dingtalk.com/topapi/v2/user/get?"+query, nil, map[string]string{"userid": userIdRes.Result.UserId}, &memberRes)
+	if err != nil {
+		return nil, err
+	}
+	if memberRes.ErrCode != 0 {
+		return nil, fmt.Errorf("获取钉钉成员信息失败：%s", memberRes.ErrMsg)
+	}
+	return &memberRes.Result, nil
+}
+
+func getDingTalkUserInfoByCode(code string) (*enterpriseOAuthUser, error) {
+	var tokenRes DingTalkAccessTokenResponse
+	err := postDingTalk("https://api.dingtalk.com/v1.0/oauth2/userAccessToken", nil, map[string]string{
+		"clientId":     config.DingTalkClientId,
+		"clientSecret": config.DingTalkClientSecret,
+		"code":         code,
+		"grantType":    "authorization_code",
+	}, &tokenRes)
+	if err != nil {
+		return nil, err
+	}
+	if tokenRes.AccessToken == "" {
+		return nil, errors.New(tokenRes.Message)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://api.dingtalk.com/v1.0/contact/users/me", nil)
+	if err != nil {
+		return nil, err
+	}
+	var dingTalkUser DingTalkUser
+	if err = doDingTalk(req, map[string]string{"x-acs-dingtalk-access-token": tokenRes.AccessToken}, &dingTalkUser); err != nil {
+		return nil, err
+	}
+	if dingTalkUser.UnionId == "" {
+		return nil, errors.New(dingTalkUser.Message)
+	}
+
+	member, err := getDingTalkMember(dingTalkUser.UnionId)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &enterpriseOAuthUser{
+		Id:        dingTalkUser.UnionId,
+		Name:      member.Name,
+		Email:     member.OrgEmail,
+		AvatarUrl: dingTalkUser.AvatarUrl,
+	}
+	if user.Name == "" {
+		user.Name = dingTalkUser.Nick
+	}
+	if user.Email == "" {
+		user.Email = member.Email
+	}
+	if user.Email == "" {
+		user.Email = dingTalkUser.Email
+	}
+	for _, department := range member.DeptIdList {
+		user.Departments = append(user.Departments, strconv.Itoa(department))
+	}
+	return user, nil
+}
+
+// DingTalkOAuth 钉钉登录回调，已登录时绑定钉钉账户
+func DingTalkOAuth(c *gin.Context) {
+	if !config.DingTalkAuthEnabled {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "管理员未开启通过钉钉登录以及注册",
+			"success": false,
+		})
+		return
+	}
+	enterpriseOAuthCallback(c, dingTalkProvider, getDingTalkUserInfoByCode)
+}
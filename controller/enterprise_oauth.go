@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/ipguard"
+	"one-api/model"
+	"strconv"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	EnterpriseOAuthWeCom    = "wecom"
+	EnterpriseOAuthLark     = "lark"
+	EnterpriseOAuthDingTalk = "dingtalk"
+)
+
+// enterpriseOAuthProvider 企业账号登录提供方，IdField 为用户表中保存账号 ID 的列
+type enterpriseOAuthProvider struct {
+	Name        string
+	DisplayName string
+	IdField     string
+	SetId       func(user *model.User, id string)
+}
+
+// enterpriseOAuthUser 企业账号信息，Departments 为所在部门 ID
+type enterpriseOAuthUser struct {
+	Id          string
+	Name        string
+	Email       string
+	AvatarUrl   string
+	Departments []string
+}
+
+// parseEnterpriseOAuthGroupMapping 解析企业账号的分组映射，并检查分组是否存在
+func parseEnterpriseOAuthGroupMapping(value string) (map[string]map[string]string, error) {
+	mapping := make(map[string]map[string]string)
+	if value == "" {
+		return mapping, nil
+	}
+	if err := json.Unmarshal([]byte(value), &mapping); err != nil {
+		return nil, err
+	}
+	for provider, departments := range mapping {
+		if provider != EnterpriseOAuthWeCom && provider != EnterpriseOAuthLark && provider != EnterpriseOAuthDingTalk {
+			return nil, fmt.Errorf("未知的提供方 %s", provider)
+		}
+		for _, group := range departments {
+			if model.GlobalUserGroupRatio.GetBySymbol(group) == nil {
+				return nil, fmt.Errorf("分组 %s 不存在", group)
+			}
+		}
+	}
+	return mapping, nil
+}
+
+// enterpriseOAuthGroup 按部门顺序取第一个配置了映射的部门的分组，都没有时使用 * 的分组，未配置时返回空，使用默认分组
+func enterpriseOAuthGroup(provider string, departments []string) string {
+	mapping, err := parseEnterpriseOAuthGroupMapping(config.EnterpriseOAuthGroupMapping)
+	if err != nil {
+		return ""
+	}
+	groups := mapping[provider]
+	for _, department := range departments {
+		if group, ok := groups[department]; ok {
+			return group
+		}
+	}
+	return groups["*"]
+}
+
+// enterpriseOAuthLogin 企业账号登录，首次登录时自动创建用户，不受注册开关限制，只有企业成员可以通过认证
+func enterpriseOAuthLogin(c *gin.Context, provider *enterpriseOAuthProvider, oauthUser *enterpriseOAuthUser) {
+	user, err := model.FindUserByField(provider.IdField, oauthUser.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if user == nil {
+		if !ipguard.AllowSignup(c.ClientIP()) {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("当前 IP 注册次数过多，请稍后再试"))
+			return
+		}
+
+		user = &model.User{
+			Username:    provider.Name + "_" + strconv.Itoa(model.GetMaxUserId()+1),
+			DisplayName: oauthUser.Name,
+			AvatarUrl:   oauthUser.AvatarUrl,
+			Role:        config.RoleCommonUser,
+			Status:      config.UserStatusEnabled,
+		}
+		if user.DisplayName == "" {
+			user.DisplayName = provider.DisplayName + " User"
+		}
+		if oauthUser.Email != "" && !model.IsEmailAlreadyTaken(oauthUser.Email) {
+			user.Email = oauthUser.Email
+		}
+		if group := enterpriseOAuthGroup(provider.Name, oauthUser.Departments); group != "" {
+			user.Group = group
+		}
+		provider.SetId(user, oauthUser.Id)
+
+		if err := user.Insert(); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+		ipguard.RecordSignup(c.ClientIP())
+	}
+
+	if user.Status != config.UserStatusEnabled {
+		recordLogin(c, user.Id, user.Username, false, "用户已被封禁")
+		c.JSON(http.StatusOK, gin.H{
+			"message": "用户已被封禁",
+			"success": false,
+		})
+		return
+	}
+	setupLogin(user, c)
+}
+
+// enterpriseOAuthBind 已登录用户绑定企业账号
+func enterpriseOAuthBind(c *gin.Context, provider *enterpriseOAuthProvider, oauthUser *enterpriseOAuthUser) {
+	if model.IsFieldAlreadyTaken(provider.IdField, oauthUser.Id) {
+		common.APIRespondWithError(c, http.StatusOK, fmt.Errorf("该%s账户已被绑定", provider.DisplayName))
+		return
+	}
+
+	session := sessions.Default(c)
+	user := model.User{Id: session.Get("id").(int)}
+	if err := user.FillUserById(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	provider.SetId(&user, oauthUser.Id)
+	if err := user.Update(false); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "bind",
+	})
+}
+
+// enterpriseOAuthCallback 检查 state 后获取企业账号，已登录时绑定，否则登录
+func enterpriseOAuthCallback(c *gin.Context, provider *enterpriseOAuthProvider, getUser func(code string) (*enterpriseOAuthUser, error)) {
+	session := sessions.Default(c)
+	state := c.Query("state")
+	if state == "" || session.Get("oauth_state") == nil || state != session.Get("oauth_state").(string) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "state is empty or not same",
+		})
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		// 钉钉回调的参数为 authCode
+		code = c.Query("authCode")
+	}
+	if code == "" {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("无效的参数"))
+		return
+	}
+
+	oauthUser, err := getUser(code)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if oauthUser.Id == "" {
+		common.APIRespondWithError(c, http.StatusOK, fmt.Errorf("无法获取%s账户信息", provider.DisplayName))
+		return
+	}
+
+	if session.Get("username") != nil {
+		enterpriseOAuthBind(c, provider, oauthUser)
+		return
+	}
+	enterpriseOAuthLogin(c, provider, oauthUser)
+}
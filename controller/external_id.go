@@ -0,0 +1,285 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/utils"
+	"one-api/model"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 按外部 ID 创建或更新资源，供 Terraform、Ansible 等声明式工具使用：
+// 外部 ID 不存在时创建，存在时只修改请求中提供的字段，重复调用结果相同
+
+const (
+	externalActionCreated   = "created"
+	externalActionUpdated   = "updated"
+	externalActionUnchanged = "unchanged"
+)
+
+// upsertChannelByExternalId 按外部 ID 创建或更新渠道，spec 中没有的字段保持不变，没有变化时不更新
+func upsertChannelByExternalId(c *gin.Context, externalId string, spec []byte, dryRun bool) (*model.Channel, string, error) {
+	channel, err := model.GetChannelByExternalId(externalId)
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		return nil, "", err
+	}
+	if created {
+		channel = &model.Channel{}
+	} else if !inTenantScope(c, channel.TenantId) {
+		return nil, "", errors.New("无权操作其他租户的渠道")
+	}
+
+	before, _ := json.Marshal(channel)
+	id, createdTime := channel.Id, channel.CreatedTime
+	if err = json.Unmarshal(spec, channel); err != nil {
+		return nil, "", err
+	}
+	channel.Id = id
+	channel.CreatedTime = createdTime
+	channel.ExternalId = externalId
+	if tenantId := c.GetInt("tenant_id"); tenantId != 0 {
+		channel.TenantId = tenantId
+	}
+	if err = validateChannel(channel); err != nil {
+		return nil, "", err
+	}
+	// 未修改 key 时仍是原渠道的 key，原渠道使用引用时同样拒绝
+	if err = checkSecretReference(c, channel.Key); err != nil {
+		return nil, "", err
+	}
+
+	if created {
+		if !dryRun {
+			channel.CreatedTime = utils.GetTimestamp()
+			channel.Version = 0
+			err = channel.Insert()
+		}
+		return channel, externalActionCreated, err
+	}
+	if after, _ := json.Marshal(channel); bytes.Equal(before, after) {
+		return channel, externalActionUnchanged, nil
+	}
+	if !dryRun {
+		err = channel.Update(true)
+	}
+	return channel, externalActionUpdated, err
+}
+
+// PutChannelByExternalId 按外部 ID 创建或更新渠道，创建时 key 不按行拆分
+func PutChannelByExternalId(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	channel, _, err := upsertChannelByExternalId(c, c.Param("external_id"), body, false)
+	if errors.Is(err, model.ErrVersionConflict) {
+		common.APIRespondWithError(c, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    channel,
+	})
+}
+
+// upsertTokenByExternalId 按外部 ID 创建或更新用户的令牌，spec 中没有的字段保持不变，没有变化时不更新
+func upsertTokenByExternalId(userId int, externalId string, spec []byte, dryRun bool) (*model.Token, string, error) {
+	token, err := model.GetTokenByExternalId(userId, externalId)
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		return nil, "", err
+	}
+	if created {
+		token = &model.Token{}
+	}
+
+	before, _ := json.Marshal(token)
+	id, key, keyPrefix := token.Id, token.Key, token.KeyPrefix
+	if err = json.Unmarshal(spec, token); err != nil {
+		return nil, "", err
+	}
+	token.Id = id
+	token.Key = key
+	token.KeyPrefix = keyPrefix
+	token.PlainKey = ""
+	token.UserId = userId
+	token.ExternalId = externalId
+	if err = validateTokenRequest(token, userId); err != nil {
+		return nil, "", err
+	}
+
+	if created {
+		cleanToken := model.Token{
+			UserId:         userId,
+			Name:           token.Name,
+			Status:         token.Status,
+			CreatedTime:    utils.GetTimestamp(),
+			AccessedTime:   utils.GetTimestamp(),
+			ExpiredTime:    token.ExpiredTime,
+			RemainQuota:    token.RemainQuota,
+			UnlimitedQuota: token.UnlimitedQuota,
+			Group:          token.Group,
+			BackupGroup:    token.BackupGroup,
+			Setting:        token.Setting,
+			ExternalId:     externalId,
+		}
+		if !dryRun {
+			err = cleanToken.Insert()
+		}
+		return &cleanToken, externalActionCreated, err
+	}
+	if after, _ := json.Marshal(token); bytes.Equal(before, after) {
+		return token, externalActionUnchanged, nil
+	}
+	if !dryRun {
+		// Update 只修改允许用户修改的字段
+		err = token.Update()
+	}
+	return token, externalActionUpdated, err
+}
+
+// PutTokenByExternalId 按外部 ID 创建或更新当前用户的令牌
+func PutTokenByExternalId(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	token, _, err := upsertTokenByExternalId(c.GetInt("id"), c.Param("external_id"), body, false)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    token,
+	})
+}
+
+// ExternalUserRequest 按外部 ID 创建或更新用户，未提供的字段保持不变，额度通过 /api/user/quota/:id 修改
+type ExternalUserRequest struct {
+	Username    *string `json:"username"`
+	Password    *string `json:"password"`
+	DisplayName *string `json:"display_name"`
+	Email       *string `json:"email"`
+	Role        *int    `json:"role"`
+	Status      *int    `json:"status"`
+	Group       *string `json:"group"`
+}
+
+func (req *ExternalUserRequest) apply(user *model.User) {
+	if req.Username != nil {
+		user.Username = *req.Username
+	}
+	if req.Password != nil {
+		user.Password = *req.Password
+	}
+	if req.DisplayName != nil {
+		user.DisplayName = *req.DisplayName
+	}
+	if req.Email != nil {
+		user.Email = *req.Email
+	}
+	if req.Role != nil {
+		user.Role = *req.Role
+	}
+	if req.Status != nil {
+		user.Status = *req.Status
+	}
+	if req.Group != nil {
+		user.Group = *req.Group
+	}
+}
+
+// PutUserByExternalId 按外部 ID 创建或更新用户，创建时需要用户名和密码，外部 ID 与 SCIM 共用
+func PutUserByExternalId(c *gin.Context) {
+	externalId := c.Param("external_id")
+	var req ExternalUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	user, err := model.GetUserByExternalId(externalId)
+	created := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !created {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	myRole := c.GetInt("role")
+	if created {
+		if req.Username == nil || req.Password == nil {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("创建用户需要用户名和密码"))
+			return
+		}
+		user = &model.User{ExternalId: externalId, TenantId: c.GetInt("tenant_id")}
+	} else {
+		if myRole <= user.Role && myRole != config.RoleRootUser {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("无权更新同权限等级或更高权限等级的用户信息"))
+			return
+		}
+		if !inTenantScope(c, user.TenantId) {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("无权更新其他租户的用户"))
+			return
+		}
+	}
+
+	req.apply(user)
+	if created && user.Role >= myRole {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("无法创建权限大于等于自己的用户"))
+		return
+	}
+	if !created && myRole <= user.Role && myRole != config.RoleRootUser {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("无权将其他用户权限等级提升到大于等于自己的权限等级"))
+		return
+	}
+
+	updatePassword := user.Password != ""
+	if !updatePassword {
+		user.Password = "$I_LOVE_U" // make Validator happy :)
+	}
+	if err := common.Validate.Struct(user); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("输入不合法 "+err.Error()))
+		return
+	}
+	if !updatePassword {
+		user.Password = ""
+	}
+
+	if created {
+		if user.DisplayName == "" {
+			user.DisplayName = user.Username
+		}
+		err = user.Insert()
+	} else {
+		err = user.Update(updatePassword)
+	}
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	hideUserSecrets(user)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    user,
+	})
+}
@@ -10,7 +10,9 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/ipguard"
 	"one-api/common/logger"
 	"one-api/common/utils"
 	"one-api/model"
@@ -34,6 +36,8 @@ type GitHubUser struct {
 	Name      string `json:"name"`
 	Email     string `json:"email"`
 	AvatarUrl string `json:"avatar_url"`
+	// 用户授权的令牌，用于检查组织成员身份
+	AccessToken string `json:"-"`
 }
 
 type GithubEmail struct {
@@ -146,6 +150,7 @@ func getGitHubUserInfoByCode(code string) (*GitHubUser, error) {
 	if githubUser.Login == "" {
 		return nil, errors.New("返回值非法，用户字段为空，请稍后重试！")
 	}
+	githubUser.AccessToken = oAuthResponse.AccessToken
 
 	if hasUserEmailScope {
 		req, err = http.NewRequest("GET", "https://api.github.com/user/emails", nil)
@@ -264,6 +269,14 @@ func GitHubOAuth(c *gin.Context) {
 			})
 			return
 		}
+		if err := checkSignup(c, true); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+		if err := checkGitHubOrgSignup(githubUser); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
 
 		user = &model.User{
 			GitHubId:    githubUser.Login,
@@ -295,13 +308,15 @@ func GitHubOAuth(c *gin.Context) {
 			user.DisplayName = user.Username
 		}
 
-		if err := user.Insert(inviterId); err != nil {
+		if err := user.Insert(); err != nil {
 			c.JSON(http.StatusOK, gin.H{
 				"success": false,
 				"message": err.Error(),
 			})
 			return
 		}
+		model.ApplyReferral(user, c.ClientIP())
+		ipguard.RecordSignup(c.ClientIP())
 
 	} else {
 		// 如果用户存在，则更新用户
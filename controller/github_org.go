@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/model"
+	"strings"
+	"time"
+)
+
+// gitHubOrgRequirement 允许注册的组织，Team 不为空时只允许该团队的成员
+type gitHubOrgRequirement struct {
+	Org  string
+	Team string
+}
+
+func parseGitHubAllowedOrganizations(value string) []gitHubOrgRequirement {
+	var requirements []gitHubOrgRequirement
+	for _, item := range config.SplitList(value) {
+		org, team, _ := strings.Cut(item, "/")
+		if org = strings.TrimSpace(org); org == "" {
+			continue
+		}
+		requirements = append(requirements, gitHubOrgRequirement{Org: org, Team: strings.TrimSpace(team)})
+	}
+	return requirements
+}
+
+// checkGitHubOrgSignup 开启组织限制时，只允许组织或团队的成员注册
+func checkGitHubOrgSignup(githubUser *GitHubUser) error {
+	requirements := parseGitHubAllowedOrganizations(config.GitHubAllowedOrganizations)
+	if len(requirements) == 0 {
+		return nil
+	}
+
+	token := config.GitHubOrgCheckToken
+	if token == "" {
+		token = githubUser.AccessToken
+	}
+	member, err := isGitHubOrgMember(requirements, token, githubUser.Login)
+	if err != nil {
+		logger.SysError("check GitHub organization membership error: " + err.Error())
+		return errors.New("无法验证 GitHub 组织成员身份，请稍后重试！")
+	}
+	if !member {
+		return errors.New("管理员只允许指定 GitHub 组织的成员注册")
+	}
+	return nil
+}
+
+// isGitHubOrgMember 用户是否属于任一允许的组织或团队
+func isGitHubOrgMember(requirements []gitHubOrgRequirement, token, login string) (bool, error) {
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+	}
+	if err := configureClientWithGitHubProxy(client); err != nil {
+		return false, err
+	}
+
+	for _, requirement := range requirements {
+		member, err := checkGitHubMembership(client, requirement, token, login)
+		if err != nil {
+			return false, err
+		}
+		if member {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func checkGitHubMembership(client *http.Client, requirement gitHubOrgRequirement, token, login string) (bool, error) {
+	apiURL := fmt.Sprintf("https://api.github.com/orgs/%s/members/%s", url.PathEscape(requirement.Org), url.PathEscape(login))
+	if requirement.Team != "" {
+		apiURL = fmt.Sprintf("https://api.github.com/orgs/%s/teams/%s/memberships/%s", url.PathEscape(requirement.Org), url.PathEscape(requirement.Team), url.PathEscape(login))
+	}
+
+	req, err := http.NewRequest(http.MethodGet, apiURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusNoContent:
+		return true, nil
+	case http.StatusOK:
+		// 团队成员关系为 pending 时表示尚未接受邀请
+		var membership struct {
+			State string `json:"state"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&membership); err != nil {
+			return false, err
+		}
+		return membership.State == "active", nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("GitHub returned %d for %s", res.StatusCode, apiURL)
+}
+
+// RevalidateGitHubOrgMembers 复查绑定了 GitHub 的普通用户，禁用已不在允许的组织或团队中的用户，返回被禁用的用户名
+// 需要设置 GitHubOrgCheckToken，查询失败的用户跳过，不会被禁用
+func RevalidateGitHubOrgMembers() ([]string, error) {
+	requirements := parseGitHubAllowedOrganizations(config.GitHubAllowedOrganizations)
+	if len(requirements) == 0 || config.GitHubOrgCheckToken == "" {
+		return nil, nil
+	}
+
+	users, err := model.GetEnabledGitHubUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	var disabled []string
+	for _, user := range users {
+		member, err := isGitHubOrgMember(requirements, config.GitHubOrgCheckToken, user.GitHubId)
+		if err != nil {
+			logger.SysError(fmt.Sprintf("check GitHub organization membership of user #%d error: %s", user.Id, err.Error()))
+			continue
+		}
+		if member {
+			continue
+		}
+
+		if err := model.UpdateUser(user.Id, map[string]interface{}{"status": config.UserStatusDisabled}); err != nil {
+			logger.SysError(fmt.Sprintf("disable user #%d error: %s", user.Id, err.Error()))
+			continue
+		}
+		model.RecordLog(user.Id, model.LogTypeSystem, fmt.Sprintf("GitHub 账户 %s 已不是允许的组织成员，账户已被禁用", user.GitHubId))
+		disabled = append(disabled, user.Username)
+	}
+	return disabled, nil
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"one-api/common"
+	"one-api/common/graphql"
+	"one-api/model"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/spf13/viper"
+)
+
+// GraphQLIdArgs 按 ID 查询单条记录的参数
+type GraphQLIdArgs struct {
+	Id int `form:"id"`
+}
+
+// GraphQLTokensArgs 令牌列表只能按用户查询
+type GraphQLTokensArgs struct {
+	model.GenericParams
+	UserId int `form:"user_id"`
+}
+
+// GraphQLStatisticsArgs 与 /api/analytics/period 的渠道统计相同
+type GraphQLStatisticsArgs struct {
+	StartTimestamp int64  `form:"start_timestamp"`
+	EndTimestamp   int64  `form:"end_timestamp"`
+	GroupType      string `form:"group_type"` // channel、model 或 model_type
+	UserId         int    `form:"user_id"`
+}
+
+// GraphQLUsageArgs 与 /api/log/stat 相同
+type GraphQLUsageArgs struct {
+	StartTimestamp int64  `form:"start_timestamp"`
+	EndTimestamp   int64  `form:"end_timestamp"`
+	ModelName      string `form:"model_name"`
+	Username       string `form:"username"`
+	TokenName      string `form:"token_name"`
+	Channel        int    `form:"channel"`
+}
+
+type GraphQLStatistic struct {
+	Date             string `json:"date"`
+	Channel          string `json:"channel"`
+	RequestCount     int64  `json:"request_count"`
+	Quota            int64  `json:"quota"`
+	PromptTokens     int64  `json:"prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens"`
+	RequestTime      int64  `json:"request_time"`
+}
+
+// bindGraphQLArgs 按 form 标签将查询参数绑定到结构体，与查询字符串使用相同的参数名
+func bindGraphQLArgs(args map[string]any, ptr any) error {
+	form := make(map[string][]string, len(args))
+	for name, value := range args {
+		switch v := value.(type) {
+		case nil:
+			continue
+		case float64:
+			if v == math.Trunc(v) {
+				form[name] = []string{strconv.FormatInt(int64(v), 10)}
+			} else {
+				form[name] = []string{strconv.FormatFloat(v, 'f', -1, 64)}
+			}
+		case []any, map[string]any:
+			return fmt.Errorf("argument %s must be a scalar", name)
+		default:
+			form[name] = []string{fmt.Sprint(v)}
+		}
+	}
+	return binding.MapFormWithTag(ptr, form, "form")
+}
+
+// graphQLResolver 绑定参数后调用 fn
+func graphQLResolver[T any](fn func(args *T) (any, error)) graphql.Resolver {
+	return func(args map[string]any) (any, error) {
+		var params T
+		if err := bindGraphQLArgs(args, &params); err != nil {
+			return nil, err
+		}
+		return fn(&params)
+	}
+}
+
+// hideUserSecrets 不返回密码和系统访问令牌
+func hideUserSecrets(user *model.User) {
+	user.Password = ""
+	user.AccessToken = ""
+}
+
+var adminGraphQLSchema = &graphql.Schema{
+	Query: map[string]graphql.Resolver{
+		"users": graphQLResolver(func(params *model.GenericParams) (any, error) {
+			users, err := model.GetUsersList(params, 0)
+			if err != nil {
+				return nil, err
+			}
+			for _, user := range *users.Data {
+				hideUserSecrets(user)
+			}
+			return users, nil
+		}),
+		"user": graphQLResolver(func(args *GraphQLIdArgs) (any, error) {
+			user, err := model.GetUserById(args.Id, false)
+			if err != nil {
+				return nil, err
+			}
+			hideUserSecrets(user)
+			return user, nil
+		}),
+		"tokens": graphQLResolver(func(args *GraphQLTokensArgs) (any, error) {
+			if args.UserId == 0 {
+				return nil, errors.New("user_id is required")
+			}
+			return model.GetUserTokensList(args.UserId, &args.GenericParams)
+		}),
+		"token": graphQLResolver(func(args *GraphQLIdArgs) (any, error) {
+			return model.GetTokenById(args.Id)
+		}),
+		"channels": graphQLResolver(func(params *model.SearchChannelsParams) (any, error) {
+			return model.GetChannelsList(params)
+		}),
+		"channel": graphQLResolver(func(args *GraphQLIdArgs) (any, error) {
+			channel, err := model.GetChannelById(args.Id)
+			if err != nil {
+				return nil, err
+			}
+			channel.Key = ""
+			return channel, nil
+		}),
+		"logs": graphQLResolver(func(params *model.LogsListParams) (any, error) {
+			return model.GetLogsList(params)
+		}),
+		"statistics": graphQLResolver(func(args *GraphQLStatisticsArgs) (any, error) {
+			startDate := time.Unix(args.StartTimestamp, 0).Format("2006-01-02")
+			endDate := time.Unix(args.EndTimestamp, 0).Format("2006-01-02")
+			rows, err := model.GetChannelExpensesStatisticsByPeriod(startDate, endDate, args.GroupType, args.UserId)
+			if err != nil {
+				return nil, err
+			}
+			statistics := make([]*GraphQLStatistic, 0, len(rows))
+			for _, row := range rows {
+				statistics = append(statistics, &GraphQLStatistic{
+					Date:             row.Date,
+					Channel:          row.Channel,
+					RequestCount:     row.RequestCount,
+					Quota:            row.Quota,
+					PromptTokens:     row.PromptTokens,
+					CompletionTokens: row.CompletionTokens,
+					RequestTime:      row.RequestTime,
+				})
+			}
+			return statistics, nil
+		}),
+		"usage": graphQLResolver(func(args *GraphQLUsageArgs) (any, error) {
+			return gin.H{
+				"quota": model.SumUsedQuota(args.StartTimestamp, args.EndTimestamp, args.ModelName, args.Username, args.TokenName, args.Channel),
+			}, nil
+		}),
+	},
+}
+
+// GraphQL 管理数据的只读 GraphQL 查询，根字段的参数与对应列表接口的查询参数相同，对象的字段与 JSON 字段相同
+func GraphQL(c *gin.Context) {
+	var req graphql.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	schema := *adminGraphQLSchema
+	schema.MaxRootFields = viper.GetInt("graphql.max_root_fields")
+	c.JSON(http.StatusOK, schema.Execute(&req))
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"one-api/common/config"
+	"one-api/common/graceful"
+	"one-api/common/redis"
+	"one-api/model"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	healthStatusOK       = "ok"
+	healthStatusFail     = "fail"
+	healthStatusDisabled = "disabled"
+)
+
+// 单项依赖检查的超时时间
+const healthCheckTimeout = 2 * time.Second
+
+type dependencyStatus struct {
+	Status  string `json:"status"`
+	Latency int64  `json:"latency_ms"`
+	Error   string `json:"error,omitempty"`
+	Enabled *int   `json:"enabled,omitempty"`
+}
+
+// Healthz 存活检查，进程能处理请求即返回 200
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": healthStatusOK})
+}
+
+// Readyz 就绪检查，数据库、Redis（启用时）可用且至少有一个启用的渠道才返回 200，停机期间返回 503
+func Readyz(c *gin.Context) {
+	checks := map[string]func(ctx context.Context) *dependencyStatus{
+		"database": checkDatabase,
+		"redis":    checkRedis,
+		"channels": checkChannels,
+	}
+
+	results := make(map[string]*dependencyStatus, len(checks))
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check func(ctx context.Context) *dependencyStatus) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(c.Request.Context(), healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			result := check(ctx)
+			result.Latency = time.Since(start).Milliseconds()
+
+			lock.Lock()
+			results[name] = result
+			lock.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	status := healthStatusOK
+	for _, result := range results {
+		if result.Status == healthStatusFail {
+			status = healthStatusFail
+		}
+	}
+	draining := graceful.IsDraining()
+	if draining {
+		status = healthStatusFail
+	}
+
+	code := http.StatusOK
+	if status != healthStatusOK {
+		code = http.StatusServiceUnavailable
+	}
+	c.JSON(code, gin.H{
+		"status":   status,
+		"draining": draining,
+		"checks":   results,
+	})
+}
+
+func checkDatabase(ctx context.Context) *dependencyStatus {
+	if err := model.PingDB(ctx); err != nil {
+		return &dependencyStatus{Status: healthStatusFail, Error: err.Error()}
+	}
+	return &dependencyStatus{Status: healthStatusOK}
+}
+
+func checkRedis(ctx context.Context) *dependencyStatus {
+	if !config.RedisEnabled {
+		return &dependencyStatus{Status: healthStatusDisabled}
+	}
+	if err := redis.GetRedisClient().Ping(ctx).Err(); err != nil {
+		return &dependencyStatus{Status: healthStatusFail, Error: err.Error()}
+	}
+	return &dependencyStatus{Status: healthStatusOK}
+}
+
+func checkChannels(_ context.Context) *dependencyStatus {
+	enabled := model.ChannelGroup.EnabledCount()
+	if enabled == 0 {
+		return &dependencyStatus{Status: healthStatusFail, Error: "no enabled channel", Enabled: &enabled}
+	}
+	return &dependencyStatus{Status: healthStatusOK, Enabled: &enabled}
+}
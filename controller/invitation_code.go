@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"net/http"
+	"one-api/common"
+	"one-api/common/utils"
+	"one-api/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetInvitationCodesList(c *gin.Context) {
+	var params model.GenericParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	codes, err := model.GetInvitationCodesList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    codes,
+	})
+}
+
+func GetInvitationCode(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	code, err := model.GetInvitationCodeById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    code,
+	})
+}
+
+func AddInvitationCode(c *gin.Context) {
+	code := model.InvitationCode{}
+	if err := c.ShouldBindJSON(&code); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := code.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if code.Count <= 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "邀请码个数必须大于0",
+		})
+		return
+	}
+	if code.Count > 100 {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "一次邀请码批量生成的个数不能大于 100",
+		})
+		return
+	}
+
+	var keys []string
+	for i := 0; i < code.Count; i++ {
+		key := utils.GetUUID()
+		cleanCode := model.InvitationCode{
+			UserId:      c.GetInt("id"),
+			Code:        key,
+			Name:        code.Name,
+			Quota:       code.Quota,
+			Group:       code.Group,
+			MaxUses:     code.MaxUses,
+			ExpiredTime: code.ExpiredTime,
+			CreatedTime: utils.GetTimestamp(),
+		}
+		if err := cleanCode.Insert(); err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": err.Error(),
+				"data":    keys,
+			})
+			return
+		}
+		keys = append(keys, key)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    keys,
+	})
+}
+
+func DeleteInvitationCode(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+	if err := model.DeleteInvitationCodeById(id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func UpdateInvitationCode(c *gin.Context) {
+	statusOnly := c.Query("status_only")
+	code := model.InvitationCode{}
+	if err := c.ShouldBindJSON(&code); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	cleanCode, err := model.GetInvitationCodeById(code.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if statusOnly != "" {
+		cleanCode.Status = code.Status
+	} else {
+		// If you add more fields, please also update InvitationCode.Update()
+		cleanCode.Name = code.Name
+		cleanCode.Quota = code.Quota
+		cleanCode.Group = code.Group
+		cleanCode.MaxUses = code.MaxUses
+		cleanCode.ExpiredTime = code.ExpiredTime
+		if err := cleanCode.Validate(); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+	}
+	if err := cleanCode.Update(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    cleanCode,
+	})
+}
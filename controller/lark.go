@@ -6,7 +6,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/ipguard"
 	"one-api/common/logger"
 	"one-api/model"
 	"strconv"
@@ -192,6 +194,10 @@ func LarkOAuth(c *gin.Context) {
 		}
 	} else {
 		if config.RegisterEnabled {
+			if err := checkSignup(c, true); err != nil {
+				common.APIRespondWithError(c, http.StatusOK, err)
+				return
+			}
 			user.Username = "lark_" + strconv.Itoa(model.GetMaxUserId()+1)
 			if larkUser.Data.Name != "" {
 				user.DisplayName = larkUser.Data.Name
@@ -200,14 +206,18 @@ func LarkOAuth(c *gin.Context) {
 			}
 			user.Role = config.RoleCommonUser
 			user.Status = config.UserStatusEnabled
+			if group := enterpriseOAuthGroup(EnterpriseOAuthLark, nil); group != "" {
+				user.Group = group
+			}
 
-			if err := user.Insert(0); err != nil {
+			if err := user.Insert(); err != nil {
 				c.JSON(http.StatusOK, gin.H{
 					"success": false,
 					"message": err.Error(),
 				})
 				return
 			}
+			ipguard.RecordSignup(c.ClientIP())
 		} else {
 			c.JSON(http.StatusOK, gin.H{
 				"success": false,
@@ -28,6 +28,26 @@ func GetLogsList(c *gin.Context) {
 	})
 }
 
+// SearchLogs 游标分页查询日志
+func SearchLogs(c *gin.Context) {
+	var params model.LogsCursorParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	logs, err := model.SearchLogs(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    logs,
+	})
+}
+
 func GetUserLogsList(c *gin.Context) {
 	userId := c.GetInt("id")
 
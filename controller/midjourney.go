@@ -152,7 +152,11 @@ func MjTaskHandler(midjourneyChannel *model.Channel, taskIds []string, taskM map
 	// 使用带有超时的 context 创建新的请求
 	req = req.WithContext(ctx)
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("mj-api-secret", midjourneyChannel.Key)
+	key, err := midjourneyChannel.ResolveKey()
+	if err != nil {
+		return fmt.Errorf("resolve channel key error: %v", err)
+	}
+	req.Header.Set("mj-api-secret", key)
 	resp, err := requester.HTTPClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("get task do req error: %v", err)
@@ -2,14 +2,16 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/election"
+	"one-api/common/redis"
 	"one-api/common/stmp"
 	"one-api/common/telegram"
 	"one-api/model"
-	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -23,45 +25,92 @@ func GetStatus(c *gin.Context) {
 		"success": true,
 		"message": "",
 		"data": gin.H{
-			"version":             config.Version,
-			"start_time":          config.StartTime,
-			"email_verification":  config.EmailVerificationEnabled,
-			"github_oauth":        config.GitHubOAuthEnabled,
-			"github_client_id":    config.GitHubClientId,
-			"oidc_auth":           config.OIDCAuthEnabled,
-			"lark_login":          config.LarkAuthEnabled,
-			"lark_client_id":      config.LarkClientId,
-			"system_name":         config.SystemName,
-			"logo":                config.Logo,
-			"language":            config.Language,
-			"footer_html":         config.Footer,
-			"analytics_code":      config.AnalyticsCode,
-			"wechat_qrcode":       config.WeChatAccountQRCodeImageURL,
-			"wechat_login":        config.WeChatAuthEnabled,
-			"server_address":      config.ServerAddress,
-			"turnstile_check":     config.TurnstileCheckEnabled,
-			"turnstile_site_key":  config.TurnstileSiteKey,
-			"top_up_link":         config.TopUpLink,
-			"chat_link":           config.ChatLink,
-			"quota_per_unit":      config.QuotaPerUnit,
-			"display_in_currency": config.DisplayInCurrencyEnabled,
-			"telegram_bot":        telegramBot,
-			"mj_notify_enabled":   config.MjNotifyEnabled,
-			"chat_links":          config.ChatLinks,
-			"PaymentUSDRate":      config.PaymentUSDRate,
-			"PaymentMinAmount":    config.PaymentMinAmount,
-			"RechargeDiscount":    config.RechargeDiscount,
-			"EnableSafe":          config.EnableSafe,
-			"SafeToolName":        config.SafeToolName,
-			"SafeKeyWords":        config.SafeKeyWords,
-			"UserInvoiceMonth":    config.UserInvoiceMonth,
-			"UptimeDomain":        config.UPTIMEKUMA_DOMAIN,
-			"UptimePageName":      config.UPTIMEKUMA_STATUS_PAGE_NAME,
-			"UptimeEnabled":       config.UPTIMEKUMA_ENABLE,
+			"version":                config.Version,
+			"start_time":             config.StartTime,
+			"email_verification":     config.EmailVerificationEnabled,
+			"github_oauth":           config.GitHubOAuthEnabled,
+			"github_client_id":       config.GitHubClientId,
+			"oidc_auth":              config.OIDCAuthEnabled,
+			"lark_login":             config.LarkAuthEnabled,
+			"lark_client_id":         config.LarkClientId,
+			"wecom_login":            config.WeComAuthEnabled,
+			"wecom_corp_id":          config.WeComCorpId,
+			"wecom_agent_id":         config.WeComAgentId,
+			"dingtalk_login":         config.DingTalkAuthEnabled,
+			"dingtalk_client_id":     config.DingTalkClientId,
+			"system_name":            config.SystemName,
+			"logo":                   config.Logo,
+			"language":               config.Language,
+			"footer_html":            config.Footer,
+			"analytics_code":         config.AnalyticsCode,
+			"wechat_qrcode":          config.WeChatAccountQRCodeImageURL,
+			"wechat_login":           config.WeChatAuthEnabled,
+			"server_address":         config.ServerAddress,
+			"turnstile_check":        config.TurnstileCheckEnabled,
+			"turnstile_site_key":     config.TurnstileSiteKey,
+			"invite_only_register":   config.InviteOnlyRegister,
+			"trial_enabled":          model.TrialUserId() != 0,
+			"transfer_enabled":       model.QuotaTransferEnabled(),
+			"top_up_link":            config.TopUpLink,
+			"chat_link":              config.ChatLink,
+			"quota_per_unit":         config.QuotaPerUnit,
+			"display_in_currency":    config.DisplayInCurrencyEnabled,
+			"quota_display_unit":     common.QuotaUnit(),
+			"quota_display_decimals": config.QuotaDisplayDecimals,
+			"telegram_bot":           telegramBot,
+			"mj_notify_enabled":      config.MjNotifyEnabled,
+			"chat_links":             config.ChatLinks,
+			"PaymentUSDRate":         config.PaymentUSDRate,
+			"PaymentMinAmount":       config.PaymentMinAmount,
+			"RechargeDiscount":       config.RechargeDiscount,
+			"EnableSafe":             config.EnableSafe,
+			"SafeToolName":           config.SafeToolName,
+			"SafeKeyWords":           config.SafeKeyWords,
+			"UserInvoiceMonth":       config.UserInvoiceMonth,
+			"UptimeDomain":           config.UPTIMEKUMA_DOMAIN,
+			"UptimePageName":         config.UPTIMEKUMA_STATUS_PAGE_NAME,
+			"UptimeEnabled":          config.UPTIMEKUMA_ENABLE,
+			"config_version":         redis.AppliedConfigVersion(),
 		},
 	})
 }
 
+// GetLeaderStatus 查看当前主节点和租约状态
+func GetLeaderStatus(c *gin.Context) {
+	info, err := election.GetLeaderInfo(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    info,
+	})
+}
+
+// GetConfigVersionStatus 最新的配置版本和各实例已应用的版本，版本落后的实例还有修改没有应用
+func GetConfigVersionStatus(c *gin.Context) {
+	if !config.RedisEnabled {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("配置版本需要启用 Redis"))
+		return
+	}
+	status, err := redis.GetConfigVersionStatus(c.Request.Context())
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    status,
+	})
+}
+
 func GetNotice(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -95,21 +144,12 @@ func SendEmailVerification(c *gin.Context) {
 		})
 		return
 	}
-	if config.EmailDomainRestrictionEnabled {
-		allowed := false
-		for _, domain := range config.EmailDomainWhitelist {
-			if strings.HasSuffix(email, "@"+domain) {
-				allowed = true
-				break
-			}
-		}
-		if !allowed {
-			c.JSON(http.StatusOK, gin.H{
-				"success": false,
-				"message": "管理员启用了邮箱域名白名单，您的邮箱地址的域名不在白名单中",
-			})
-			return
-		}
+	if err := checkEmailDomain(email); err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
 	}
 	if model.IsEmailAlreadyTaken(email) {
 		c.JSON(http.StatusOK, gin.H{
@@ -120,7 +160,7 @@ func SendEmailVerification(c *gin.Context) {
 	}
 	code := common.GenerateVerificationCode(6)
 	common.RegisterVerificationCodeWithKey(email, code, common.EmailVerificationPurpose)
-	err := stmp.SendVerificationCodeEmail(email, code)
+	err := stmp.SendVerificationCodeEmail(email, code, c.GetHeader("Accept-Language"))
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -164,7 +204,7 @@ func SendPasswordResetEmail(c *gin.Context) {
 	code := common.GenerateVerificationCode(0)
 	common.RegisterVerificationCodeWithKey(email, code, common.PasswordResetPurpose)
 	link := fmt.Sprintf("%s/user/reset?email=%s&token=%s", config.ServerAddress, email, code)
-	err := stmp.SendPasswordResetEmail(userName, email, link)
+	err := stmp.SendPasswordResetEmail(userName, email, link, c.GetHeader("Accept-Language"))
 
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
@@ -1,9 +1,15 @@
 package controller
 
 import (
+	"fmt"
 	"net/http"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/notify"
+	"one-api/common/utils"
 	"one-api/model"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -134,3 +140,60 @@ func DeleteModelInfo(c *gin.Context) {
 		"message": "",
 	})
 }
+
+// GetDeprecatedModelUsages 列出仍有渠道提供的弃用模型
+func GetDeprecatedModelUsages(c *gin.Context) {
+	usages, err := model.GetDeprecatedModelUsages()
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    usages,
+	})
+}
+
+// AutomaticallyNotifyDeprecatedModels 定时通知管理员仍在提供弃用模型的渠道，只在主节点执行
+func AutomaticallyNotifyDeprecatedModels(frequency int) {
+	if frequency <= 0 {
+		return
+	}
+
+	for {
+		time.Sleep(time.Duration(frequency) * time.Minute)
+		if !config.IsMasterNode {
+			continue
+		}
+
+		usages, err := model.GetDeprecatedModelUsages()
+		if err != nil {
+			logger.SysError("failed to check deprecated models: " + err.Error())
+			continue
+		}
+		if len(usages) == 0 {
+			continue
+		}
+
+		var message string
+		for _, usage := range usages {
+			message += fmt.Sprintf("**%s**", utils.EscapeMarkdownText(usage.Model))
+			if usage.SunsetAt > 0 {
+				message += fmt.Sprintf(" 下线时间 %s", time.Unix(usage.SunsetAt, 0).Format("2006-01-02 15:04"))
+			}
+			if usage.Successor != "" {
+				message += fmt.Sprintf("，后继模型 %s", utils.EscapeMarkdownText(usage.Successor))
+			}
+			message += "\n\n"
+			for _, channel := range usage.Channels {
+				message += fmt.Sprintf("- 通道 %s - #%d\n", utils.EscapeMarkdownText(channel.Name), channel.Id)
+			}
+			message += "\n"
+		}
+		notify.Send("仍在提供弃用模型的通道", message)
+	}
+}
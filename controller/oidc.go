@@ -7,7 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 	"net/http"
+	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/ipguard"
 	"one-api/common/logger"
 	"one-api/common/oidc"
 	"one-api/common/utils"
@@ -195,6 +197,10 @@ func OIDCAuth(c *gin.Context) {
 		})
 		return
 	}
+	if err := checkSignup(c, true); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
 
 	// 检测邀请码
 	var inviterId int
@@ -220,13 +226,15 @@ func OIDCAuth(c *gin.Context) {
 	user.Role = config.RoleCommonUser
 	user.Status = config.UserStatusEnabled
 
-	if err := user.Insert(0); err != nil {
+	if err := user.Insert(); err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
 			"message": err.Error(),
 		})
 		return
 	}
+	model.ApplyReferral(&user, c.ClientIP())
+	ipguard.RecordSignup(c.ClientIP())
 
 	setupLogin(&user, c)
 }
@@ -0,0 +1,19 @@
+package controller
+
+import (
+	"net/http"
+	"one-api/common"
+	"one-api/common/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOpenAPISpec 返回管理接口和中继接口的 OpenAPI 文档
+func GetOpenAPISpec(c *gin.Context) {
+	data, err := openapi.JSON()
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
@@ -2,11 +2,21 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/redis"
+	"one-api/common/remoteconfig"
+	"one-api/common/secrets"
+	"one-api/common/stmp"
+	"one-api/common/storage"
 	"one-api/common/utils"
+	"one-api/middleware"
 	"one-api/model"
 	"one-api/safty"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -14,13 +24,16 @@ import (
 
 func GetOptions(c *gin.Context) {
 	var options []*model.Option
+	versions := model.GetOptionVersions()
 	for k, v := range config.GlobalOption.GetAll() {
-		if strings.HasSuffix(k, "Token") || strings.HasSuffix(k, "Secret") {
+		if model.IsSecretOption(k) {
 			continue
 		}
 		options = append(options, &model.Option{
-			Key:   k,
-			Value: utils.Interface2String(v),
+			Key:     k,
+			Value:   utils.Interface2String(v),
+			Version: versions[k],
+			Managed: remoteconfig.Managed(k),
 		})
 	}
 	c.JSON(http.StatusOK, gin.H{
@@ -50,6 +63,13 @@ func UpdateOption(c *gin.Context) {
 		})
 		return
 	}
+	if remoteconfig.Managed(option.Key) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("该设置由配置中心管理（版本 %d），请在配置中心中修改", remoteconfig.Revision()),
+		})
+		return
+	}
 	switch option.Key {
 	case "GitHubOAuthEnabled":
 		if option.Value == "true" && config.GitHubClientId == "" {
@@ -67,6 +87,57 @@ func UpdateOption(c *gin.Context) {
 			})
 			return
 		}
+	case "LarkAuthEnabled":
+		if option.Value == "true" && (config.LarkClientId == "" || config.LarkClientSecret == "") {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "无法启用飞书登录，请先填入飞书 Client Id 以及 Client Secret！",
+			})
+			return
+		}
+	case "WeComAuthEnabled":
+		if option.Value == "true" && (config.WeComCorpId == "" || config.WeComAgentId == "" || config.WeComSecret == "") {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "无法启用企业微信登录，请先填入企业 ID、应用 AgentId 以及 Secret！",
+			})
+			return
+		}
+	case "DingTalkAuthEnabled":
+		if option.Value == "true" && (config.DingTalkClientId == "" || config.DingTalkClientSecret == "") {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "无法启用钉钉登录，请先填入钉钉 Client Id 以及 Client Secret！",
+			})
+			return
+		}
+	case "ChannelDuplicateKeyPolicy":
+		if !model.IsValidDuplicateKeyPolicy(option.Value) {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "重复 key 的处理方式只能是 warn、skip、merge 或 allow",
+			})
+			return
+		}
+	case "BaiduModelEndpoints":
+		endpoints := make(map[string]string)
+		if strings.TrimSpace(option.Value) != "" {
+			if err := json.Unmarshal([]byte(option.Value), &endpoints); err != nil {
+				c.JSON(http.StatusOK, gin.H{
+					"success": false,
+					"message": "百度模型接口设置必须是模型名称到接口名称的 JSON 对象",
+				})
+				return
+			}
+		}
+	case "EnterpriseOAuthGroupMapping":
+		if _, err := parseEnterpriseOAuthGroupMapping(option.Value); err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "企业账号分组映射设置无效：" + err.Error(),
+			})
+			return
+		}
 	case "EmailDomainRestrictionEnabled":
 		if option.Value == "true" && len(config.EmailDomainWhitelist) == 0 {
 			c.JSON(http.StatusOK, gin.H{
@@ -83,6 +154,46 @@ func UpdateOption(c *gin.Context) {
 			})
 			return
 		}
+	case "CORSAllowOrigins":
+		if err := middleware.ValidateCORSOrigins(config.SplitList(option.Value)); err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "跨域来源设置无效：" + err.Error(),
+			})
+			return
+		}
+	case "StorageImagePersistEnabled", "StorageTranscriptEnabled", "StorageLogArchiveEnabled":
+		if option.Value == "true" && !storage.ObjectStorageEnabled() {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "无法启用，请先在配置文件中设置 storage.s3 对象存储！",
+			})
+			return
+		}
+	case "StorageImageExpirationDays", "StorageTranscriptExpirationDays", "StorageLogArchiveExpirationDays", "StorageLogArchiveDays":
+		if days, err := strconv.Atoi(option.Value); err != nil || days < 0 {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "天数必须为非负整数",
+			})
+			return
+		}
+	case "EmailProvider":
+		if option.Value != stmp.EmailProviderSMTP && option.Value != stmp.EmailProviderSendGrid && option.Value != stmp.EmailProviderSES {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "不支持的邮件服务，可选 smtp、sendgrid、ses",
+			})
+			return
+		}
+	case "EmailTemplates":
+		if _, err := stmp.ParseEmailTemplates(option.Value); err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "邮件模板设置无效：" + err.Error(),
+			})
+			return
+		}
 	case "TurnstileCheckEnabled":
 		if option.Value == "true" && config.TurnstileSiteKey == "" {
 			c.JSON(http.StatusOK, gin.H{
@@ -92,7 +203,11 @@ func UpdateOption(c *gin.Context) {
 			return
 		}
 	}
-	err = model.UpdateOption(option.Key, option.Value)
+	version, err := model.UpdateOptionWithVersion(option.Key, option.Value, option.Version)
+	if errors.Is(err, model.ErrVersionConflict) {
+		common.APIRespondWithError(c, http.StatusConflict, err)
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -100,9 +215,98 @@ func UpdateOption(c *gin.Context) {
 		})
 		return
 	}
+	if strings.HasPrefix(option.Key, "Storage") && strings.HasSuffix(option.Key, "ExpirationDays") {
+		if err := storage.ApplyLifecycle(); err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "设置已保存，但更新存储桶生命周期规则失败：" + err.Error(),
+			})
+			return
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
+		"data":    version,
 	})
 	return
 }
+
+// GetEmailTemplates 返回内置模板、自定义模板和各事件可用的变量
+func GetEmailTemplates(c *gin.Context) {
+	custom, err := stmp.ParseEmailTemplates(config.EmailTemplates)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data": gin.H{
+			"defaults":  stmp.DefaultEmailTemplates,
+			"custom":    custom,
+			"variables": stmp.EmailTemplateVariables,
+		},
+	})
+}
+
+type EmailTemplatePreviewRequest struct {
+	Event    string              `json:"event" binding:"required"`
+	Language string              `json:"language"`
+	Template *stmp.EmailTemplate `json:"template"`
+	Data     map[string]any      `json:"data"`
+}
+
+// PreviewEmailTemplate 用示例数据渲染模板，未提供 template 时使用当前生效的模板
+func PreviewEmailTemplate(c *gin.Context) {
+	var req EmailTemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	subject, body, err := stmp.PreviewEmail(req.Event, req.Language, req.Template, req.Data)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data": gin.H{
+			"subject": subject,
+			"body":    body,
+		},
+	})
+}
+
+// ForceReload 从数据库重新加载配置和渠道，并通知其它实例
+func ForceReload(c *gin.Context) {
+	reloadCaches()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func reloadCaches() {
+	model.ReloadOptions()
+	model.ChannelGroup.Load()
+	model.PricingInstance.Init()
+	model.ModelOwnedBysInstance.Load()
+	model.ModelInfosInstance.Load()
+	model.PromptTemplatesInstance.Load()
+	model.IPRulesInstance.Load()
+	model.ModelRoutesInstance.Load()
+	model.ClientCertsInstance.Load()
+	// 重新读取外部密钥，仅对本实例生效
+	secrets.Purge()
+
+	if config.RedisEnabled {
+		_ = redis.RedisPublish(redis.RedisTopicOptionsSync, "reload")
+		_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+	}
+}
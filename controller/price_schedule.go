@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"net/http"
+	"one-api/common"
+	"one-api/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 预览默认使用最近 7 天的用量，最多 90 天
+const (
+	priceSchedulePreviewDays    = 7
+	priceSchedulePreviewMaxDays = 90
+)
+
+func GetPriceSchedules(c *gin.Context) {
+	var params model.PriceSchedulesListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	schedules, err := model.GetPriceSchedulesList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    schedules,
+	})
+}
+
+func AddPriceSchedule(c *gin.Context) {
+	var schedule model.PriceSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := schedule.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := schedule.Insert(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    schedule,
+	})
+}
+
+// PreviewPriceSchedule 按最近的用量估算调整的影响，days 为统计的天数
+func PreviewPriceSchedule(c *gin.Context) {
+	var schedule model.PriceSchedule
+	if err := c.ShouldBindJSON(&schedule); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	days, _ := strconv.Atoi(c.Query("days"))
+	if days <= 0 {
+		days = priceSchedulePreviewDays
+	}
+	days = min(days, priceSchedulePreviewMaxDays)
+
+	preview, err := model.PreviewPriceSchedule(&schedule, days)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    preview,
+	})
+}
+
+func CancelPriceSchedule(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := model.CancelPriceSchedule(id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"one-api/common"
 	"one-api/model"
+	"slices"
 
 	"github.com/spf13/viper"
 
@@ -205,3 +206,98 @@ func GetUpdatePriceService(c *gin.Context) {
 		"message": "",
 	})
 }
+
+func GetPriceDocument(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    model.PricingInstance.ExportPriceDocument(),
+	})
+}
+
+// UpdatePriceDocument 用整份价格文档替换全部价格，dry_run=true 时只返回变化
+func UpdatePriceDocument(c *gin.Context) {
+	document := make(model.PriceDocument)
+	if err := c.ShouldBindJSON(&document); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	diff, err := model.PricingInstance.ApplyPriceDocument(document, c.Query("dry_run") == "true")
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    diff,
+	})
+}
+
+type PricePresetImportRequest struct {
+	Preset string         `json:"preset"` // price_presets 中配置的预设名称，为空时使用 update_price_service
+	Prices []*model.Price `json:"prices"` // 直接提交的价格，设置后忽略 preset
+	Mode   string         `json:"mode"`   // add、update 或 overwrite，默认为 add
+	DryRun bool           `json:"dry_run"`
+}
+
+// ImportPricePreset 导入社区价格预设，返回价格的变化
+func ImportPricePreset(c *gin.Context) {
+	var request PricePresetImportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	prices := request.Prices
+	if len(prices) == 0 {
+		api := viper.GetString("update_price_service")
+		if request.Preset != "" {
+			api = viper.GetStringMapString("price_presets")[request.Preset]
+			if api == "" {
+				common.APIRespondWithError(c, http.StatusOK, errors.New("price preset not found"))
+				return
+			}
+		}
+		if api == "" {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("update_price_service is not configured"))
+			return
+		}
+
+		var err error
+		if prices, err = model.FetchPrices(api); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+	}
+
+	document := model.PricingInstance.MergePricePreset(prices, request.Mode)
+	diff, err := model.PricingInstance.ApplyPriceDocument(document, request.DryRun)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    diff,
+	})
+}
+
+// GetPricePresets 可导入的价格预设名称
+func GetPricePresets(c *gin.Context) {
+	presets := make([]string, 0)
+	for name := range viper.GetStringMapString("price_presets") {
+		presets = append(presets, name)
+	}
+	slices.Sort(presets)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    presets,
+	})
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"one-api/common"
+	"one-api/model"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RefundLogsRequest 按消费日志退款，Quota 为 0 时全额退款，部分退款时只能指定一条日志
+type RefundLogsRequest struct {
+	LogIds []int  `json:"log_ids"`
+	Quota  int    `json:"quota"`
+	Reason string `json:"reason"`
+}
+
+// RefundLogFailure 退款失败的日志
+type RefundLogFailure struct {
+	LogId   int    `json:"log_id"`
+	Message string `json:"message"`
+}
+
+// RefundLogsResult 退款结果，每条日志单独退款，部分失败不影响其它日志
+type RefundLogsResult struct {
+	Refunds  []*model.QuotaRefund `json:"refunds"`
+	Failures []*RefundLogFailure  `json:"failures"`
+}
+
+func RefundLogs(c *gin.Context) {
+	var req RefundLogsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if len(req.LogIds) == 0 || req.Reason == "" {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("请选择日志并填写退款原因"))
+		return
+	}
+	if req.Quota != 0 && len(req.LogIds) != 1 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("部分退款只能指定一条日志"))
+		return
+	}
+
+	result := &RefundLogsResult{Refunds: []*model.QuotaRefund{}, Failures: []*RefundLogFailure{}}
+	for _, logId := range req.LogIds {
+		refund, err := refundLog(logId, req.Quota, req.Reason, c.GetInt("id"))
+		if err != nil {
+			result.Failures = append(result.Failures, &RefundLogFailure{LogId: logId, Message: err.Error()})
+			continue
+		}
+		result.Refunds = append(result.Refunds, refund)
+	}
+
+	message := ""
+	if len(result.Failures) > 0 {
+		message = "部分日志退款失败"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": len(result.Failures) == 0,
+		"message": message,
+		"data":    result,
+	})
+}
+
+func refundLog(logId int, quota int, reason string, operatorId int) (*model.QuotaRefund, error) {
+	log, err := model.GetLogById(logId)
+	if err != nil {
+		return nil, err
+	}
+	refund, err := model.NewLogRefund(log, quota, reason, operatorId)
+	if err != nil {
+		return nil, err
+	}
+	if err := refund.Refund(); err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+func GetQuotaRefundsList(c *gin.Context) {
+	var params model.QuotaRefundsListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	refunds, err := model.GetQuotaRefundsList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    refunds,
+	})
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"one-api/common"
+	"one-api/model"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TransferQuotaRequest 转账请求，接收用户可以用用户名或 ID 指定
+type TransferQuotaRequest struct {
+	Username string `json:"username"`
+	UserId   int    `json:"user_id"`
+	Quota    int    `json:"quota"`
+	Remark   string `json:"remark"`
+}
+
+// TransferQuota 当前用户向其他用户转账，手续费从转出方额外扣除
+func TransferQuota(c *gin.Context) {
+	var req TransferQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	toUserId := req.UserId
+	if username := strings.TrimSpace(req.Username); username != "" {
+		user, err := model.FindUserByField("username", username)
+		if err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+		if user == nil {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("接收用户不存在"))
+			return
+		}
+		toUserId = user.Id
+	}
+	if toUserId == 0 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("请指定接收用户"))
+		return
+	}
+
+	transfer, err := model.TransferQuota(c.GetInt("id"), toUserId, req.Quota, strings.TrimSpace(req.Remark))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    transfer,
+	})
+}
+
+// GetUserQuotaTransfers 当前用户转出和收到的转账记录
+func GetUserQuotaTransfers(c *gin.Context) {
+	var params model.QuotaTransfersListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	params.UserId = c.GetInt("id")
+
+	transfers, err := model.GetQuotaTransfersList(&params, 0)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    transfers,
+	})
+}
+
+func GetQuotaTransfersList(c *gin.Context) {
+	var params model.QuotaTransfersListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	transfers, err := model.GetQuotaTransfersList(&params, c.GetInt("tenant_id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    transfers,
+	})
+}
@@ -84,6 +84,23 @@ func AddRedemption(c *gin.Context) {
 		})
 		return
 	}
+
+	// 每次生成的兑换码作为一个批次，记录活动、兑换时间和每用户上限
+	batch := redemption.Batch
+	if batch == nil {
+		batch = &model.RedemptionBatch{}
+	}
+	batch.Name = redemption.Name
+	batch.CreatedBy = c.GetInt("id")
+	if err = batch.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err = batch.Insert(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
 	var keys []string
 	for i := 0; i < redemption.Count; i++ {
 		key := utils.GetUUID()
@@ -93,6 +110,7 @@ func AddRedemption(c *gin.Context) {
 			Key:         key,
 			CreatedTime: utils.GetTimestamp(),
 			Quota:       redemption.Quota,
+			BatchId:     batch.Id,
 		}
 		err = cleanRedemption.Insert()
 		if err != nil {
@@ -168,3 +186,68 @@ func UpdateRedemption(c *gin.Context) {
 		"data":    cleanRedemption,
 	})
 }
+
+func GetRedemptionBatchesList(c *gin.Context) {
+	var params model.RedemptionBatchesListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	batches, err := model.GetRedemptionBatchesList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    batches,
+	})
+}
+
+// UpdateRedemptionBatch 修改批次的活动、兑换时间和每用户上限
+func UpdateRedemptionBatch(c *gin.Context) {
+	batch := model.RedemptionBatch{}
+	if err := c.ShouldBindJSON(&batch); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	cleanBatch, err := model.GetRedemptionBatchById(batch.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	cleanBatch.Campaign = batch.Campaign
+	cleanBatch.StartTime = batch.StartTime
+	cleanBatch.EndTime = batch.EndTime
+	cleanBatch.PerUserLimit = batch.PerUserLimit
+	if err = cleanBatch.Validate(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err = cleanBatch.Update(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    cleanBatch,
+	})
+}
+
+// GetRedemptionCampaignStatistics 按活动统计兑换率
+func GetRedemptionCampaignStatistics(c *gin.Context) {
+	statistics, err := model.GetRedemptionCampaignStatistics(c.Query("campaign"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    statistics,
+	})
+}
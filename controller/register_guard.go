@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"errors"
+	"one-api/common/config"
+	"one-api/common/ipguard"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// checkSignup 检查注册限制，仅邀请注册时第三方登录不能创建新用户
+func checkSignup(c *gin.Context, thirdParty bool) error {
+	if thirdParty && config.InviteOnlyRegister {
+		return errors.New("管理员开启了邀请注册，请先使用邀请码注册账户后再绑定")
+	}
+	if !ipguard.AllowSignup(c.ClientIP()) {
+		return errors.New("当前 IP 注册次数过多，请稍后再试")
+	}
+	return nil
+}
+
+// checkEmailDomain 检查邮箱域名白名单和一次性邮箱
+func checkEmailDomain(email string) error {
+	index := strings.LastIndex(email, "@")
+	if index < 0 {
+		return errors.New("无效的邮箱地址")
+	}
+	domain := strings.ToLower(email[index+1:])
+
+	if config.EmailDomainRestrictionEnabled && !matchDomain(domain, config.EmailDomainWhitelist, false) {
+		return errors.New("管理员启用了邮箱域名白名单，您的邮箱地址的域名不在白名单中")
+	}
+	if config.DisposableEmailBlockEnabled && matchDomain(domain, config.DisposableEmailDomains, true) {
+		return errors.New("不支持使用临时邮箱注册")
+	}
+	return nil
+}
+
+// matchDomain 域名是否在列表中，subdomain 为 true 时同时匹配子域名
+func matchDomain(domain string, domains []string, subdomain bool) bool {
+	for _, item := range domains {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		if domain == item || (subdomain && strings.HasSuffix(domain, "."+item)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,918 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/utils"
+	"one-api/model"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	scimSchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	scimSchemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	scimSchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	scimSchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	scimDefaultCount = 100
+	scimMaxCount     = 200
+)
+
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type SCIMMultiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type SCIMMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+// SCIMUser 用户资源，userName 对应用户名，active 对应启用状态，groups 为用户所在的分组（只读）
+type SCIMUser struct {
+	Schemas     []string         `json:"schemas"`
+	Id          string           `json:"id,omitempty"`
+	ExternalId  string           `json:"externalId,omitempty"`
+	UserName    string           `json:"userName"`
+	Name        *SCIMName        `json:"name,omitempty"`
+	DisplayName string           `json:"displayName,omitempty"`
+	Emails      []SCIMMultiValue `json:"emails,omitempty"`
+	Active      *bool            `json:"active,omitempty"`
+	Password    string           `json:"password,omitempty"`
+	Groups      []SCIMMultiValue `json:"groups,omitempty"`
+	Meta        *SCIMMeta        `json:"meta,omitempty"`
+}
+
+// SCIMGroup 分组资源，对应用户分组，displayName 为分组标识，每个用户只能属于一个分组
+type SCIMGroup struct {
+	Schemas     []string         `json:"schemas"`
+	Id          string           `json:"id,omitempty"`
+	DisplayName string           `json:"displayName"`
+	Members     []SCIMMultiValue `json:"members"`
+	Meta        *SCIMMeta        `json:"meta,omitempty"`
+}
+
+type SCIMListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value"`
+}
+
+// scimError 带 SCIM 错误类型的错误
+type scimError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func (e *scimError) Error() string {
+	return e.detail
+}
+
+func newSCIMError(status int, scimType, format string, args ...any) *scimError {
+	return &scimError{status: status, scimType: scimType, detail: fmt.Sprintf(format, args...)}
+}
+
+var (
+	scimFilterRegex       = regexp.MustCompile(`(?i)^\s*([\w.]+)\s+eq\s+"((?:[^"\\]|\\.)*)"\s*$`)
+	scimMemberFilterRegex = regexp.MustCompile(`(?i)^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+)
+
+func scimRespond(c *gin.Context, status int, data any) {
+	c.Header("Content-Type", "application/scim+json")
+	c.JSON(status, data)
+}
+
+func scimRespondError(c *gin.Context, err error) {
+	var e *scimError
+	if !errors.As(err, &e) {
+		e = &scimError{status: http.StatusInternalServerError, detail: err.Error()}
+	}
+	body := gin.H{
+		"schemas": []string{scimSchemaError},
+		"status":  strconv.Itoa(e.status),
+		"detail":  e.detail,
+	}
+	if e.scimType != "" {
+		body["scimType"] = e.scimType
+	}
+	scimRespond(c, e.status, body)
+}
+
+func scimLocation(resource, id string) string {
+	return fmt.Sprintf("%s/scim/v2/%s/%s", strings.TrimSuffix(config.ServerAddress, "/"), resource, id)
+}
+
+// scimPagination 解析 startIndex（从 1 开始）和 count
+func scimPagination(c *gin.Context) (startIndex, count int) {
+	startIndex, _ = strconv.Atoi(c.Query("startIndex"))
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count, err := strconv.Atoi(c.Query("count"))
+	if err != nil || count < 0 {
+		count = scimDefaultCount
+	}
+	if count > scimMaxCount {
+		count = scimMaxCount
+	}
+	return startIndex, count
+}
+
+// parseSCIMFilter 只支持 attr eq "value" 形式的过滤条件
+func parseSCIMFilter(filter string) (attr, value string, err error) {
+	if filter == "" {
+		return "", "", nil
+	}
+	matches := scimFilterRegex.FindStringSubmatch(filter)
+	if matches == nil {
+		return "", "", newSCIMError(http.StatusBadRequest, "invalidFilter", "unsupported filter: %s", filter)
+	}
+	value, err = strconv.Unquote(`"` + matches[2] + `"`)
+	if err != nil {
+		return "", "", newSCIMError(http.StatusBadRequest, "invalidFilter", "invalid filter value: %s", matches[2])
+	}
+	return strings.ToLower(matches[1]), value, nil
+}
+
+func parseSCIMId(c *gin.Context) (int, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, newSCIMError(http.StatusNotFound, "", "resource %s not found", c.Param("id"))
+	}
+	return id, nil
+}
+
+// parseSCIMBool 部分身份提供方在 PATCH 中使用字符串 "True"/"False"
+func parseSCIMBool(raw json.RawMessage) (bool, error) {
+	var value bool
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return value, nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return false, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid boolean value")
+	}
+	value, err := strconv.ParseBool(text)
+	if err != nil {
+		return false, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid boolean value: %s", text)
+	}
+	return value, nil
+}
+
+func parseSCIMString(raw json.RawMessage) (string, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", newSCIMError(http.StatusBadRequest, "invalidValue", "invalid string value")
+	}
+	return value, nil
+}
+
+func userToSCIM(user *model.User) *SCIMUser {
+	active := user.Status == config.UserStatusEnabled
+	scimUser := &SCIMUser{
+		Schemas:     []string{scimSchemaUser},
+		Id:          strconv.Itoa(user.Id),
+		ExternalId:  user.ExternalId,
+		UserName:    user.Username,
+		Name:        &SCIMName{Formatted: user.DisplayName},
+		DisplayName: user.DisplayName,
+		Active:      &active,
+		Meta: &SCIMMeta{
+			ResourceType: "User",
+			Created:      time.Unix(user.CreatedTime, 0).UTC().Format(time.RFC3339),
+			Location:     scimLocation("Users", strconv.Itoa(user.Id)),
+		},
+	}
+	if user.Email != "" {
+		scimUser.Emails = []SCIMMultiValue{{Value: user.Email, Type: "work", Primary: true}}
+	}
+	if group := model.GlobalUserGroupRatio.GetBySymbol(user.Group); group != nil {
+		scimUser.Groups = []SCIMMultiValue{{Value: strconv.Itoa(group.Id), Display: group.Symbol}}
+	}
+	return scimUser
+}
+
+// primaryEmail 优先使用 primary 邮箱，否则使用第一个
+func (u *SCIMUser) primaryEmail() string {
+	for _, email := range u.Emails {
+		if email.Primary {
+			return email.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+func (u *SCIMUser) displayName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name != nil {
+		if u.Name.Formatted != "" {
+			return u.Name.Formatted
+		}
+		if name := strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName); name != "" {
+			return name
+		}
+	}
+	return u.UserName
+}
+
+// applySCIMUser 将 SCIM 用户的属性写入用户，用户名和邮箱不能与其他用户重复
+func applySCIMUser(user *model.User, scimUser *SCIMUser) (map[string]any, error) {
+	if scimUser.UserName == "" {
+		return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	if scimUser.UserName != user.Username && model.IsUsernameAlreadyTaken(scimUser.UserName) {
+		return nil, newSCIMError(http.StatusConflict, "uniqueness", "userName %s already exists", scimUser.UserName)
+	}
+	email := scimUser.primaryEmail()
+	if email != "" && email != user.Email && model.IsEmailAlreadyTaken(email) {
+		return nil, newSCIMError(http.StatusConflict, "uniqueness", "email %s already exists", email)
+	}
+
+	user.Username = scimUser.UserName
+	user.DisplayName = scimUser.displayName()
+	user.Email = email
+	user.ExternalId = scimUser.ExternalId
+	if scimUser.Active != nil {
+		user.Status = config.UserStatusDisabled
+		if *scimUser.Active {
+			user.Status = config.UserStatusEnabled
+		}
+	}
+
+	return map[string]any{
+		"username":     user.Username,
+		"display_name": user.DisplayName,
+		"email":        user.Email,
+		"external_id":  user.ExternalId,
+		"status":       user.Status,
+	}, nil
+}
+
+// getSCIMUser 获取可以通过 SCIM 管理的用户，管理员和超级管理员不能通过 SCIM 修改或删除，
+// 避免身份提供方的令牌泄露后被用来重置管理员的密码或用户名
+func getSCIMUser(id int, forUpdate bool) (*model.User, error) {
+	user, err := model.GetUserById(id, false)
+	if err != nil {
+		return nil, newSCIMError(http.StatusNotFound, "", "user %d not found", id)
+	}
+	if forUpdate && user.Role >= config.RoleAdminUser {
+		return nil, newSCIMError(http.StatusForbidden, "mutability", "admin users cannot be managed by SCIM")
+	}
+	return user, nil
+}
+
+// SCIMServiceProviderConfig 声明支持的 SCIM 功能
+func SCIMServiceProviderConfig(c *gin.Context) {
+	scimRespond(c, http.StatusOK, gin.H{
+		"schemas":        []string{"urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"},
+		"patch":          gin.H{"supported": true},
+		"bulk":           gin.H{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         gin.H{"supported": true, "maxResults": scimMaxCount},
+		"changePassword": gin.H{"supported": true},
+		"sort":           gin.H{"supported": false},
+		"etag":           gin.H{"supported": false},
+		"authenticationSchemes": []gin.H{{
+			"type":        "oauthbearertoken",
+			"name":        "OAuth Bearer Token",
+			"description": "Authentication with the SCIMToken option",
+		}},
+	})
+}
+
+// SCIMResourceTypes 支持的资源类型
+func SCIMResourceTypes(c *gin.Context) {
+	resources := []any{
+		gin.H{"schemas": []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"}, "id": "User", "name": "User", "endpoint": "/Users", "schema": scimSchemaUser},
+		gin.H{"schemas": []string{"urn:ietf:params:scim:schemas:core:2.0:ResourceType"}, "id": "Group", "name": "Group", "endpoint": "/Groups", "schema": scimSchemaGroup},
+	}
+	scimRespond(c, http.StatusOK, SCIMListResponse{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: int64(len(resources)),
+		StartIndex:   1,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+// SCIMListUsers 支持按 userName、externalId、emails.value、id 过滤
+func SCIMListUsers(c *gin.Context) {
+	attr, value, err := parseSCIMFilter(c.Query("filter"))
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	fields := map[string]string{
+		"":             "",
+		"username":     "username",
+		"externalid":   "external_id",
+		"emails.value": "email",
+		"emails":       "email",
+		"id":           "id",
+	}
+	field, ok := fields[attr]
+	if !ok {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "invalidFilter", "unsupported filter attribute: %s", attr))
+		return
+	}
+
+	startIndex, count := scimPagination(c)
+	users, total, err := model.SearchUsersByField(field, value, startIndex-1, count)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+
+	resources := make([]any, 0, len(users))
+	for _, user := range users {
+		resources = append(resources, userToSCIM(user))
+	}
+	scimRespond(c, http.StatusOK, SCIMListResponse{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func SCIMGetUser(c *gin.Context) {
+	id, err := parseSCIMId(c)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	user, err := getSCIMUser(id, false)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, userToSCIM(user))
+}
+
+// SCIMCreateUser 创建普通用户，未提供密码时随机生成，用户通过单点登录使用
+func SCIMCreateUser(c *gin.Context) {
+	var scimUser SCIMUser
+	if err := json.NewDecoder(c.Request.Body).Decode(&scimUser); err != nil {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "invalidSyntax", "%s", err.Error()))
+		return
+	}
+
+	user := &model.User{
+		Role:     config.RoleCommonUser,
+		Status:   config.UserStatusEnabled,
+		Password: scimUser.Password,
+	}
+	if user.Password == "" {
+		user.Password = utils.GetRandomString(32)
+	}
+	if _, err := applySCIMUser(user, &scimUser); err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	if err := user.Insert(); err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	scimRespond(c, http.StatusCreated, userToSCIM(user))
+}
+
+// SCIMReplaceUser 用请求中的属性替换用户属性
+func SCIMReplaceUser(c *gin.Context) {
+	id, err := parseSCIMId(c)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	user, err := getSCIMUser(id, true)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+
+	var scimUser SCIMUser
+	if err := json.NewDecoder(c.Request.Body).Decode(&scimUser); err != nil {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "invalidSyntax", "%s", err.Error()))
+		return
+	}
+	if err := updateSCIMUser(user, &scimUser); err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, userToSCIM(user))
+}
+
+func updateSCIMUser(user *model.User, scimUser *SCIMUser) error {
+	fields, err := applySCIMUser(user, scimUser)
+	if err != nil {
+		return err
+	}
+	if scimUser.Password != "" {
+		password, err := common.Password2Hash(scimUser.Password)
+		if err != nil {
+			return err
+		}
+		fields["password"] = password
+	}
+	return model.UpdateUser(user.Id, fields)
+}
+
+// SCIMPatchUser 支持 active、userName、displayName、name、emails、externalId、password 的 add、replace、remove
+func SCIMPatchUser(c *gin.Context) {
+	id, err := parseSCIMId(c)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	user, err := getSCIMUser(id, true)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+
+	var req SCIMPatchRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "invalidSyntax", "%s", err.Error()))
+		return
+	}
+
+	scimUser := userToSCIM(user)
+	for _, operation := range req.Operations {
+		if err := patchSCIMUser(scimUser, operation); err != nil {
+			scimRespondError(c, err)
+			return
+		}
+	}
+	if err := updateSCIMUser(user, scimUser); err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, userToSCIM(user))
+}
+
+func patchSCIMUser(scimUser *SCIMUser, operation SCIMPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return newSCIMError(http.StatusBadRequest, "invalidSyntax", "unsupported op: %s", operation.Op)
+	}
+
+	// 没有 path 时 value 为属性对象
+	if operation.Path == "" {
+		if op == "remove" {
+			return newSCIMError(http.StatusBadRequest, "noTarget", "path is required for remove")
+		}
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &values); err != nil {
+			return newSCIMError(http.StatusBadRequest, "invalidValue", "value must be an object when path is empty")
+		}
+		for path, value := range values {
+			if err := patchSCIMUserAttribute(scimUser, op, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return patchSCIMUserAttribute(scimUser, op, operation.Path, operation.Value)
+}
+
+func patchSCIMUserAttribute(scimUser *SCIMUser, op, path string, value json.RawMessage) error {
+	path = strings.ToLower(strings.TrimPrefix(path, scimSchemaUser+":"))
+	if strings.HasPrefix(path, "emails") {
+		// emails、emails.value、emails[type eq "work"].value 都按主邮箱处理
+		path = "emails"
+	}
+	if scimUser.Name == nil {
+		scimUser.Name = &SCIMName{}
+	}
+
+	if op == "remove" {
+		switch path {
+		case "externalid":
+			scimUser.ExternalId = ""
+		case "displayname":
+			scimUser.DisplayName = ""
+		case "name", "name.formatted", "name.givenname", "name.familyname":
+			scimUser.Name = &SCIMName{}
+		case "emails":
+			scimUser.Emails = nil
+		default:
+			return newSCIMError(http.StatusBadRequest, "mutability", "attribute %s cannot be removed", path)
+		}
+		return nil
+	}
+
+	var err error
+	switch path {
+	case "active":
+		var active bool
+		active, err = parseSCIMBool(value)
+		scimUser.Active = &active
+	case "username":
+		scimUser.UserName, err = parseSCIMString(value)
+	case "displayname":
+		scimUser.DisplayName, err = parseSCIMString(value)
+	case "externalid":
+		scimUser.ExternalId, err = parseSCIMString(value)
+	case "password":
+		scimUser.Password, err = parseSCIMString(value)
+	case "name.formatted":
+		scimUser.DisplayName = ""
+		scimUser.Name.Formatted, err = parseSCIMString(value)
+	case "name.givenname":
+		scimUser.DisplayName, scimUser.Name.Formatted = "", ""
+		scimUser.Name.GivenName, err = parseSCIMString(value)
+	case "name.familyname":
+		scimUser.DisplayName, scimUser.Name.Formatted = "", ""
+		scimUser.Name.FamilyName, err = parseSCIMString(value)
+	case "name":
+		var name SCIMName
+		if err = json.Unmarshal(value, &name); err == nil {
+			scimUser.DisplayName = ""
+			scimUser.Name = &name
+		}
+	case "emails":
+		var emails []SCIMMultiValue
+		if json.Unmarshal(value, &emails) == nil {
+			scimUser.Emails = emails
+			break
+		}
+		var email string
+		if email, err = parseSCIMString(value); err == nil {
+			scimUser.Emails = []SCIMMultiValue{{Value: email, Primary: true}}
+		}
+	default:
+		// 忽略不支持的扩展属性
+	}
+	if err != nil {
+		return newSCIMError(http.StatusBadRequest, "invalidValue", "invalid value for %s: %s", path, err.Error())
+	}
+	return nil
+}
+
+// SCIMDeleteUser 删除用户
+func SCIMDeleteUser(c *gin.Context) {
+	id, err := parseSCIMId(c)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	user, err := getSCIMUser(id, true)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	if err := user.Delete(); err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func groupToSCIM(group *model.UserGroup, withMembers bool) (*SCIMGroup, error) {
+	scimGroup := &SCIMGroup{
+		Schemas:     []string{scimSchemaGroup},
+		Id:          strconv.Itoa(group.Id),
+		DisplayName: group.Symbol,
+		Members:     []SCIMMultiValue{},
+		Meta: &SCIMMeta{
+			ResourceType: "Group",
+			Location:     scimLocation("Groups", strconv.Itoa(group.Id)),
+		},
+	}
+	if !withMembers {
+		return scimGroup, nil
+	}
+
+	users, err := model.GetUsersByGroup(group.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		scimGroup.Members = append(scimGroup.Members, SCIMMultiValue{Value: strconv.Itoa(user.Id), Display: user.Username})
+	}
+	return scimGroup, nil
+}
+
+// scimWithMembers 请求 excludedAttributes=members 时不返回成员
+func scimWithMembers(c *gin.Context) bool {
+	return !strings.Contains(strings.ToLower(c.Query("excludedAttributes")), "members")
+}
+
+func getSCIMGroup(c *gin.Context) (*model.UserGroup, error) {
+	id, err := parseSCIMId(c)
+	if err != nil {
+		return nil, err
+	}
+	group, err := model.GetUserGroupsById(id)
+	if err != nil {
+		return nil, newSCIMError(http.StatusNotFound, "", "group %d not found", id)
+	}
+	return group, nil
+}
+
+// SCIMListGroups 支持按 displayName 过滤
+func SCIMListGroups(c *gin.Context) {
+	attr, value, err := parseSCIMFilter(c.Query("filter"))
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	if attr != "" && attr != "displayname" {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "invalidFilter", "unsupported filter attribute: %s", attr))
+		return
+	}
+
+	groups, err := model.GetUserGroupsAll(false)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	startIndex, count := scimPagination(c)
+	resources := make([]any, 0)
+	var total int64
+	for _, group := range groups {
+		if attr != "" && group.Symbol != value {
+			continue
+		}
+		total++
+		if total < int64(startIndex) || len(resources) >= count {
+			continue
+		}
+		scimGroup, err := groupToSCIM(group, scimWithMembers(c))
+		if err != nil {
+			scimRespondError(c, err)
+			return
+		}
+		resources = append(resources, scimGroup)
+	}
+
+	scimRespond(c, http.StatusOK, SCIMListResponse{
+		Schemas:      []string{scimSchemaListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func SCIMGetGroup(c *gin.Context) {
+	group, err := getSCIMGroup(c)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	scimGroup, err := groupToSCIM(group, scimWithMembers(c))
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, scimGroup)
+}
+
+// SCIMCreateGroup 创建倍率为 1 的用户分组，已存在同名分组时返回冲突
+func SCIMCreateGroup(c *gin.Context) {
+	var scimGroup SCIMGroup
+	if err := json.NewDecoder(c.Request.Body).Decode(&scimGroup); err != nil {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "invalidSyntax", "%s", err.Error()))
+		return
+	}
+	if scimGroup.DisplayName == "" {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "invalidValue", "displayName is required"))
+		return
+	}
+	if model.GlobalUserGroupRatio.GetBySymbol(scimGroup.DisplayName) != nil {
+		scimRespondError(c, newSCIMError(http.StatusConflict, "uniqueness", "group %s already exists", scimGroup.DisplayName))
+		return
+	}
+
+	group := &model.UserGroup{
+		Symbol:  scimGroup.DisplayName,
+		Name:    scimGroup.DisplayName,
+		Ratio:   1,
+		APIRate: 600,
+	}
+	if err := group.Create(); err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	if err := setSCIMGroupMembers(group, scimGroup.Members); err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	result, err := groupToSCIM(group, true)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	scimRespond(c, http.StatusCreated, result)
+}
+
+// SCIMReplaceGroup 替换分组成员，分组名称不能修改
+func SCIMReplaceGroup(c *gin.Context) {
+	group, err := getSCIMGroup(c)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+
+	var scimGroup SCIMGroup
+	if err := json.NewDecoder(c.Request.Body).Decode(&scimGroup); err != nil {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "invalidSyntax", "%s", err.Error()))
+		return
+	}
+	if scimGroup.DisplayName != "" && scimGroup.DisplayName != group.Symbol {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "mutability", "displayName cannot be changed"))
+		return
+	}
+	if err := setSCIMGroupMembers(group, scimGroup.Members); err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	result, err := groupToSCIM(group, true)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+	scimRespond(c, http.StatusOK, result)
+}
+
+// SCIMPatchGroup 支持 members 的 add、remove、replace
+func SCIMPatchGroup(c *gin.Context) {
+	group, err := getSCIMGroup(c)
+	if err != nil {
+		scimRespondError(c, err)
+		return
+	}
+
+	var req SCIMPatchRequest
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		scimRespondError(c, newSCIMError(http.StatusBadRequest, "invalidSyntax", "%s", err.Error()))
+		return
+	}
+
+	for _, operation := range req.Operations {
+		if err := patchSCIMGroup(group, operation); err != nil {
+			scimRespondError(c, err)
+			return
+		}
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func patchSCIMGroup(group *model.UserGroup, operation SCIMPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+
+	// 没有 path 时 value 为属性对象
+	if operation.Path == "" {
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(operation.Value, &values); err != nil {
+			return newSCIMError(http.StatusBadRequest, "invalidValue", "value must be an object when path is empty")
+		}
+		for path, value := range values {
+			if err := patchSCIMGroupAttribute(group, op, path, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// members[value eq "1"]
+	if matches := scimMemberFilterRegex.FindStringSubmatch(operation.Path); matches != nil {
+		if op != "remove" {
+			return newSCIMError(http.StatusBadRequest, "invalidPath", "unsupported path: %s", operation.Path)
+		}
+		return removeSCIMGroupMembers(group, []SCIMMultiValue{{Value: matches[1]}})
+	}
+	return patchSCIMGroupAttribute(group, op, operation.Path, operation.Value)
+}
+
+func patchSCIMGroupAttribute(group *model.UserGroup, op, path string, value json.RawMessage) error {
+	switch strings.ToLower(path) {
+	case "displayname":
+		displayName, err := parseSCIMString(value)
+		if err != nil {
+			return err
+		}
+		if displayName != group.Symbol {
+			return newSCIMError(http.StatusBadRequest, "mutability", "displayName cannot be changed")
+		}
+		return nil
+	case "members":
+	default:
+		return newSCIMError(http.StatusBadRequest, "invalidPath", "unsupported path: %s", path)
+	}
+
+	var members []SCIMMultiValue
+	if len(value) > 0 {
+		if err := json.Unmarshal(value, &members); err != nil {
+			return newSCIMError(http.StatusBadRequest, "invalidValue", "members must be an array")
+		}
+	}
+
+	switch op {
+	case "add":
+		return addSCIMGroupMembers(group, members)
+	case "remove":
+		if len(members) == 0 {
+			return setSCIMGroupMembers(group, nil)
+		}
+		return removeSCIMGroupMembers(group, members)
+	case "replace":
+		return setSCIMGroupMembers(group, members)
+	}
+	return newSCIMError(http.StatusBadRequest, "invalidSyntax", "unsupported op: %s", op)
+}
+
+// addSCIMGroupMembers 将用户移入分组，用户只能属于一个分组
+func addSCIMGroupMembers(group *model.UserGroup, members []SCIMMultiValue) error {
+	for _, member := range members {
+		id, err := strconv.Atoi(member.Value)
+		if err != nil {
+			return newSCIMError(http.StatusBadRequest, "invalidValue", "invalid member %s", member.Value)
+		}
+		if _, err := getSCIMUser(id, true); err != nil {
+			return err
+		}
+		if err := model.UpdateUser(id, map[string]any{"group": group.Symbol}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeSCIMGroupMembers 将用户移回 default 分组
+func removeSCIMGroupMembers(group *model.UserGroup, members []SCIMMultiValue) error {
+	for _, member := range members {
+		id, err := strconv.Atoi(member.Value)
+		if err != nil {
+			continue
+		}
+		user, err := getSCIMUser(id, true)
+		if err != nil || user.Group != group.Symbol {
+			continue
+		}
+		if err := model.UpdateUser(id, map[string]any{"group": "default"}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setSCIMGroupMembers 替换分组成员，不在列表中的成员移回 default 分组
+func setSCIMGroupMembers(group *model.UserGroup, members []SCIMMultiValue) error {
+	keep := make(map[string]bool, len(members))
+	for _, member := range members {
+		keep[member.Value] = true
+	}
+
+	users, err := model.GetUsersByGroup(group.Symbol)
+	if err != nil {
+		return err
+	}
+	var removed []SCIMMultiValue
+	for _, user := range users {
+		if !keep[strconv.Itoa(user.Id)] {
+			removed = append(removed, SCIMMultiValue{Value: strconv.Itoa(user.Id)})
+		}
+	}
+	if err := removeSCIMGroupMembers(group, removed); err != nil {
+		return err
+	}
+	return addSCIMGroupMembers(group, members)
+}
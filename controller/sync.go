@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"one-api/common"
+	"one-api/model"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 声明式同步：外部控制器（如 Kubernetes operator）将自定义资源中声明的渠道和令牌整体提交，
+// 网关按外部 ID 创建或更新，prune 时删除同一前缀下不再声明的资源，并返回每个资源的状态供回写到资源的 status
+
+const externalActionDeleted = "deleted"
+
+type SyncResource struct {
+	ExternalId string          `json:"external_id" binding:"required"`
+	UserId     int             `json:"user_id"` // 令牌所属的用户
+	Spec       json.RawMessage `json:"spec"`    // 字段与渠道、令牌接口相同，没有的字段保持不变
+}
+
+type SyncRequest struct {
+	Prefix   string          `json:"prefix" binding:"required"` // 外部 ID 的前缀，同一前缀下的资源由调用方全权管理
+	Channels []*SyncResource `json:"channels"`
+	Tokens   []*SyncResource `json:"tokens"`
+	Prune    bool            `json:"prune"`   // 删除前缀下请求中没有的渠道和令牌
+	DryRun   bool            `json:"dry_run"` // 只返回将要执行的操作
+}
+
+type SyncChannelStatus struct {
+	Id                 int     `json:"id"`
+	Status             int     `json:"status"`
+	StatusText         string  `json:"status_text"`
+	ResponseTime       int     `json:"response_time"`
+	TestTime           int64   `json:"test_time"`
+	Balance            float64 `json:"balance"`
+	BalanceUpdatedTime int64   `json:"balance_updated_time"`
+	UsedQuota          int64   `json:"used_quota"`
+	Version            int     `json:"version"`
+}
+
+type SyncTokenStatus struct {
+	Id           int    `json:"id"`
+	UserId       int    `json:"user_id"`
+	Key          string `json:"key,omitempty"` // 只在创建时返回
+	Status       int    `json:"status"`
+	RemainQuota  int    `json:"remain_quota"`
+	UsedQuota    int    `json:"used_quota"`
+	AccessedTime int64  `json:"accessed_time"`
+	ExpiredTime  int64  `json:"expired_time"`
+}
+
+type SyncResult struct {
+	Kind       string `json:"kind"` // channel 或 token
+	ExternalId string `json:"external_id"`
+	Action     string `json:"action,omitempty"` // created、updated、unchanged、deleted，失败时为空
+	Error      string `json:"error,omitempty"`
+	Status     any    `json:"status,omitempty"`
+}
+
+func channelSyncStatus(channel *model.Channel) *SyncChannelStatus {
+	return &SyncChannelStatus{
+		Id:                 channel.Id,
+		Status:             channel.Status,
+		StatusText:         channel.StatusToStr(),
+		ResponseTime:       channel.ResponseTime,
+		TestTime:           channel.TestTime,
+		Balance:            channel.Balance,
+		BalanceUpdatedTime: channel.BalanceUpdatedTime,
+		UsedQuota:          channel.UsedQuota,
+		Version:            channel.Version,
+	}
+}
+
+func tokenSyncStatus(token *model.Token) *SyncTokenStatus {
+	return &SyncTokenStatus{
+		Id:           token.Id,
+		UserId:       token.UserId,
+		Key:          token.PlainKey,
+		Status:       token.Status,
+		RemainQuota:  token.RemainQuota,
+		UsedQuota:    token.UsedQuota,
+		AccessedTime: token.AccessedTime,
+		ExpiredTime:  token.ExpiredTime,
+	}
+}
+
+// syncExternalIdSet 检查外部 ID 的前缀和是否重复
+func syncExternalIdSet(prefix string, resources []*SyncResource) (map[string]bool, error) {
+	ids := make(map[string]bool, len(resources))
+	for _, resource := range resources {
+		if resource == nil || !strings.HasPrefix(resource.ExternalId, prefix) {
+			return nil, errors.New("外部 ID 必须以 prefix 开头")
+		}
+		if ids[resource.ExternalId] {
+			return nil, errors.New("外部 ID 重复：" + resource.ExternalId)
+		}
+		ids[resource.ExternalId] = true
+	}
+	return ids, nil
+}
+
+// SyncResources 按声明同步渠道和令牌，单个资源失败不影响其它资源，失败的资源在结果中返回错误
+func SyncResources(c *gin.Context) {
+	var req SyncRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	channelIds, err := syncExternalIdSet(req.Prefix, req.Channels)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if _, err = syncExternalIdSet(req.Prefix, req.Tokens); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	results := make([]*SyncResult, 0, len(req.Channels)+len(req.Tokens))
+	for _, resource := range req.Channels {
+		result := &SyncResult{Kind: "channel", ExternalId: resource.ExternalId}
+		channel, action, err := upsertChannelByExternalId(c, resource.ExternalId, resource.Spec, req.DryRun)
+		if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Action = action
+			result.Status = channelSyncStatus(channel)
+		}
+		results = append(results, result)
+	}
+
+	// 令牌的外部 ID 在同一用户内唯一，按用户和外部 ID 判断是否仍在声明中
+	tokenIds := make(map[string]bool, len(req.Tokens))
+	for _, resource := range req.Tokens {
+		result := &SyncResult{Kind: "token", ExternalId: resource.ExternalId}
+		results = append(results, result)
+		if _, err := model.GetUserById(resource.UserId, false); err != nil {
+			result.Error = "用户不存在"
+			continue
+		}
+		tokenIds[syncTokenKey(resource.UserId, resource.ExternalId)] = true
+		token, action, err := upsertTokenByExternalId(resource.UserId, resource.ExternalId, resource.Spec, req.DryRun)
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+		result.Action = action
+		result.Status = tokenSyncStatus(token)
+	}
+
+	if req.Prune {
+		results = append(results, pruneSyncResources(req.Prefix, channelIds, tokenIds, req.DryRun)...)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    results,
+	})
+}
+
+func syncTokenKey(userId int, externalId string) string {
+	return fmt.Sprintf("%d:%s", userId, externalId)
+}
+
+// pruneSyncResources 删除前缀下不再声明的渠道和令牌，删除的资源进入回收站
+func pruneSyncResources(prefix string, channelIds, tokenIds map[string]bool, dryRun bool) []*SyncResult {
+	var results []*SyncResult
+	channels, err := model.GetChannelsByExternalIdPrefix(prefix)
+	if err != nil {
+		return append(results, &SyncResult{Kind: "channel", ExternalId: prefix, Error: err.Error()})
+	}
+	for _, channel := range channels {
+		if channelIds[channel.ExternalId] {
+			continue
+		}
+		result := &SyncResult{Kind: "channel", ExternalId: channel.ExternalId, Action: externalActionDeleted}
+		if !dryRun {
+			if err := channel.Delete(); err != nil {
+				result.Action = ""
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+
+	tokens, err := model.GetTokensByExternalIdPrefix(prefix)
+	if err != nil {
+		return append(results, &SyncResult{Kind: "token", ExternalId: prefix, Error: err.Error()})
+	}
+	for _, token := range tokens {
+		if tokenIds[syncTokenKey(token.UserId, token.ExternalId)] {
+			continue
+		}
+		result := &SyncResult{Kind: "token", ExternalId: token.ExternalId, Action: externalActionDeleted}
+		if !dryRun {
+			if err := token.Delete(); err != nil {
+				result.Action = ""
+				result.Error = err.Error()
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// GetSyncStatus 前缀下所有渠道和令牌的状态，包括渠道的状态、最后测试时间和响应时间
+func GetSyncStatus(c *gin.Context) {
+	prefix := c.Query("prefix")
+	if prefix == "" {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("prefix 不能为空"))
+		return
+	}
+
+	channels, err := model.GetChannelsByExternalIdPrefix(prefix)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	tokens, err := model.GetTokensByExternalIdPrefix(prefix)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	results := make([]*SyncResult, 0, len(channels)+len(tokens))
+	for _, channel := range channels {
+		results = append(results, &SyncResult{Kind: "channel", ExternalId: channel.ExternalId, Status: channelSyncStatus(channel)})
+	}
+	for _, token := range tokens {
+		results = append(results, &SyncResult{Kind: "token", ExternalId: token.ExternalId, Status: tokenSyncStatus(token)})
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    results,
+	})
+}
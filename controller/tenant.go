@@ -0,0 +1,145 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"one-api/common"
+	"one-api/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetTenants(c *gin.Context) {
+	var params model.SearchTenantParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	tenants, err := model.GetTenantsList(&params)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    tenants,
+	})
+}
+
+func GetTenantById(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	tenant, err := model.GetTenantById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    tenant,
+	})
+}
+
+func AddTenant(c *gin.Context) {
+	tenant := model.Tenant{}
+	if err := c.ShouldBindJSON(&tenant); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if tenant.Name == "" {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("租户名称不能为空"))
+		return
+	}
+
+	tenant.Id = 0
+	if err := tenant.Create(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    tenant,
+	})
+}
+
+func UpdateTenant(c *gin.Context) {
+	tenant := model.Tenant{}
+	if err := c.ShouldBindJSON(&tenant); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if tenant.Id == 0 || tenant.Name == "" {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("无效的参数"))
+		return
+	}
+
+	if err := tenant.Update(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func DeleteTenant(c *gin.Context) {
+	id, _ := strconv.Atoi(c.Param("id"))
+
+	tenant, err := model.GetTenantById(id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := tenant.Delete(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+type SetUserTenantRequest struct {
+	UserId   int `json:"user_id"`
+	TenantId int `json:"tenant_id"`
+}
+
+// SetUserTenant 将用户分配到租户，tenant_id 为 0 时移出租户
+func SetUserTenant(c *gin.Context) {
+	var req SetUserTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := model.SetUserTenant(req.UserId, req.TenantId); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// inTenantScope 租户管理员只能管理本租户的数据，tenant_id 为 0 的管理员不受限制
+func inTenantScope(c *gin.Context, tenantId int) bool {
+	myTenantId := c.GetInt("tenant_id")
+	return myTenantId == 0 || myTenantId == tenantId
+}
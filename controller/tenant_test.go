@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type tenantTestResponse struct {
+	Success bool            `json:"success"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+func setupTenantDB(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	oldLogger := logger.Logger
+	logger.Logger = zap.NewNop()
+	t.Cleanup(func() { logger.Logger = oldLogger })
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	// 内存数据库每个连接是独立的库
+	sqlDB, err := db.DB()
+	assert.Nil(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	assert.Nil(t, db.AutoMigrate(&model.User{}, &model.Channel{}))
+
+	oldDB := model.DB
+	model.DB = db
+	t.Cleanup(func() { model.DB = oldDB })
+}
+
+func createTenantUser(t *testing.T, username string, role, tenantId int) *model.User {
+	user := &model.User{
+		Username:    username,
+		DisplayName: username,
+		Role:        role,
+		Status:      config.UserStatusEnabled,
+		TenantId:    tenantId,
+		AccessToken: "access-" + username,
+		AffCode:     "aff-" + username,
+	}
+	assert.Nil(t, model.DB.Create(user).Error)
+	return user
+}
+
+func createTenantChannel(t *testing.T, name, key string, tenantId int) *model.Channel {
+	channel := &model.Channel{Name: name, Type: config.ChannelTypeOpenAI, Key: key, Models: "gpt-4o", Group: "default", TenantId: tenantId}
+	assert.Nil(t, model.DB.Create(channel).Error)
+	return channel
+}
+
+// callAsTenantAdmin 以租户管理员身份调用接口，tenantId 为 0 时为不属于任何租户的管理员
+func callAsTenantAdmin(t *testing.T, handler gin.HandlerFunc, tenantId int, method, target string, body any, params ...gin.Param) *tenantTestResponse {
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		assert.Nil(t, err)
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, reader)
+	c.Request.Header.Set("Content-Type", "application/json")
+	c.Params = params
+	c.Set("id", 1)
+	c.Set("role", config.RoleAdminUser)
+	c.Set("tenant_id", tenantId)
+	handler(c)
+
+	response := &tenantTestResponse{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), response))
+	return response
+}
+
+func idParam(id int) gin.Param {
+	return gin.Param{Key: "id", Value: strconv.Itoa(id)}
+}
+
+func TestInTenantScope(t *testing.T) {
+	tests := []struct {
+		name     string
+		myTenant int
+		tenant   int
+		want     bool
+	}{
+		{"no tenant sees shared", 0, 0, true},
+		{"no tenant sees any tenant", 0, 2, true},
+		{"same tenant", 1, 1, true},
+		{"other tenant", 1, 2, false},
+		{"shared data", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Set("tenant_id", tt.myTenant)
+			assert.Equal(t, tt.want, inTenantScope(c, tt.tenant))
+		})
+	}
+}
+
+func TestTenantAdminUsers(t *testing.T) {
+	setupTenantDB(t)
+	own := createTenantUser(t, "own", config.RoleCommonUser, 1)
+	other := createTenantUser(t, "other", config.RoleCommonUser, 2)
+	shared := createTenantUser(t, "shared", config.RoleCommonUser, 0)
+
+	for _, user := range []*model.User{other, shared} {
+		response := callAsTenantAdmin(t, GetUser, 1, http.MethodGet, "/api/user/", nil, idParam(user.Id))
+		assert.False(t, response.Success, user.Username)
+
+		response = callAsTenantAdmin(t, UpdateUser, 1, http.MethodPut, "/api/user/", map[string]any{"id": user.Id, "username": user.Username, "display_name": "changed"})
+		assert.False(t, response.Success, user.Username)
+
+		response = callAsTenantAdmin(t, ManageUser, 1, http.MethodPost, "/api/user/manage", map[string]any{"username": user.Username, "action": "disable"})
+		assert.False(t, response.Success, user.Username)
+
+		response = callAsTenantAdmin(t, DeleteUser, 1, http.MethodDelete, "/api/user/", nil, idParam(user.Id))
+		assert.False(t, response.Success, user.Username)
+
+		stored, err := model.GetUserById(user.Id, false)
+		assert.Nil(t, err)
+		assert.Equal(t, user.Username, stored.DisplayName)
+		assert.Equal(t, config.UserStatusEnabled, stored.Status)
+	}
+
+	response := callAsTenantAdmin(t, GetUser, 1, http.MethodGet, "/api/user/", nil, idParam(own.Id))
+	assert.True(t, response.Success)
+
+	// 不属于任何租户的管理员可以管理所有租户的用户
+	response = callAsTenantAdmin(t, GetUser, 0, http.MethodGet, "/api/user/", nil, idParam(other.Id))
+	assert.True(t, response.Success)
+}
+
+func TestTenantAdminChannels(t *testing.T) {
+	setupTenantDB(t)
+	own := createTenantChannel(t, "own", "sk-own", 1)
+	other := createTenantChannel(t, "other", "sk-other", 2)
+	shared := createTenantChannel(t, "shared", "sk-shared", 0)
+
+	for _, channel := range []*model.Channel{other, shared} {
+		response := callAsTenantAdmin(t, GetChannel, 1, http.MethodGet, "/api/channel/", nil, idParam(channel.Id))
+		assert.False(t, response.Success, channel.Name)
+		assert.NotContains(t, string(response.Data), channel.Key)
+
+		response = callAsTenantAdmin(t, UpdateChannel, 1, http.MethodPut, "/api/channel/", map[string]any{"id": channel.Id, "type": config.ChannelTypeOpenAI, "name": "changed", "models": "gpt-4o-mini"})
+		assert.False(t, response.Success, channel.Name)
+
+		response = callAsTenantAdmin(t, DeleteChannel, 1, http.MethodDelete, "/api/channel/", nil, idParam(channel.Id))
+		assert.False(t, response.Success, channel.Name)
+
+		stored, err := model.GetChannelById(channel.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, channel.Name, stored.Name)
+		assert.Equal(t, "gpt-4o", stored.Models)
+	}
+
+	response := callAsTenantAdmin(t, GetChannel, 1, http.MethodGet, "/api/channel/", nil, idParam(own.Id))
+	assert.True(t, response.Success)
+}
+
+func TestTenantAdminChannelMerge(t *testing.T) {
+	setupTenantDB(t)
+	own := createTenantChannel(t, "own", "sk-own", 1)
+	other := createTenantChannel(t, "other", "sk-other", 2)
+	shared := createTenantChannel(t, "shared", "sk-shared", 0)
+
+	// 与其它租户或共享渠道的 key 相同时按新渠道添加，不合并，也不返回已有渠道的 ID
+	for _, channel := range []*model.Channel{other, shared} {
+		response := callAsTenantAdmin(t, AddChannel, 1, http.MethodPost, "/api/channel/?duplicate_policy=merge", map[string]any{"type": config.ChannelTypeOpenAI, "name": "copy", "key": channel.Key, "models": "gpt-4o-mini", "group": "vip"})
+		assert.True(t, response.Success, response.Message)
+
+		result := &model.ChannelImportResult{}
+		assert.Nil(t, json.Unmarshal(response.Data, result))
+		assert.Equal(t, 1, result.Added)
+		assert.Equal(t, 0, result.Merged)
+		assert.Empty(t, result.Duplicates)
+
+		stored, err := model.GetChannelById(channel.Id)
+		assert.Nil(t, err)
+		assert.Equal(t, "gpt-4o", stored.Models)
+		assert.Equal(t, "default", stored.Group)
+	}
+
+	// 本租户的渠道可以合并
+	response := callAsTenantAdmin(t, AddChannel, 1, http.MethodPost, "/api/channel/?duplicate_policy=merge", map[string]any{"type": config.ChannelTypeOpenAI, "name": "copy", "key": own.Key, "models": "gpt-4o-mini", "group": "vip"})
+	assert.True(t, response.Success, response.Message)
+
+	result := &model.ChannelImportResult{}
+	assert.Nil(t, json.Unmarshal(response.Data, result))
+	assert.Equal(t, 1, result.Merged)
+	if assert.Len(t, result.Duplicates, 1) {
+		assert.Equal(t, own.Id, result.Duplicates[0].ExistingId)
+	}
+
+	stored, err := model.GetChannelById(own.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "gpt-4o,gpt-4o-mini", stored.Models)
+	assert.Equal(t, "default,vip", stored.Group)
+}
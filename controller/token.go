@@ -2,12 +2,16 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/i18n"
 	"one-api/common/utils"
 	"one-api/model"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -57,6 +61,52 @@ func GetToken(c *gin.Context) {
 	})
 }
 
+type TokenStatisticsParams struct {
+	StartTimestamp int64 `form:"start_timestamp"`
+	EndTimestamp   int64 `form:"end_timestamp"`
+}
+
+// GetTokenStatistics 令牌按天和按模型的用量，默认最近 30 天
+func GetTokenStatistics(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	token, err := model.GetTokenByIds(id, c.GetInt("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var params TokenStatisticsParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if params.EndTimestamp == 0 {
+		params.EndTimestamp = time.Now().Unix()
+	}
+	if params.StartTimestamp == 0 {
+		params.StartTimestamp = params.EndTimestamp - 30*86400
+	}
+	if params.StartTimestamp > params.EndTimestamp || params.EndTimestamp-params.StartTimestamp > 366*86400 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("时间范围不能超过一年"))
+		return
+	}
+
+	statistics, err := model.GetTokenStatistics(token, params.StartTimestamp, params.EndTimestamp)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    statistics,
+	})
+}
+
 func GetPlaygroundToken(c *gin.Context) {
 	tokenName := "sys_playground"
 	userId := c.GetInt("id")
@@ -83,10 +133,20 @@ func GetPlaygroundToken(c *gin.Context) {
 		token = &cleanToken
 	}
 
+	// 演练场令牌由服务端使用，key 可以重新签名得到
+	key, ok := token.DerivedKey()
+	if !ok {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "演练场令牌的 key 无法重新生成，请删除名称为：sys_playground 的令牌后重试",
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
-		"data":    token.Key,
+		"data":    key,
 	})
 }
 
@@ -101,38 +161,7 @@ func AddToken(c *gin.Context) {
 		})
 		return
 	}
-	if len(token.Name) > 30 {
-		c.JSON(http.StatusOK, gin.H{
-			"success": false,
-			"message": "令牌名称过长",
-		})
-		return
-	}
-
-	if token.Group != "" {
-		err = validateTokenGroup(token.Group, userId)
-		if err != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"success": false,
-				"message": err.Error(),
-			})
-			return
-		}
-	}
-	if token.BackupGroup != "" {
-		err = validateTokenGroup(token.BackupGroup, userId)
-		if err != nil {
-			c.JSON(http.StatusOK, gin.H{
-				"success": false,
-				"message": err.Error(),
-			})
-			return
-		}
-	}
-
-	setting := token.Setting.Data()
-	err = validateTokenSetting(&setting)
-	if err != nil {
+	if err = validateTokenRequest(&token, userId); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
 	}
@@ -161,9 +190,29 @@ func AddToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
+		"data":    cleanToken,
 	})
 }
 
+// validateTokenRequest 检查新建令牌的名称、分组和设置
+func validateTokenRequest(token *model.Token, userId int) error {
+	if len(token.Name) > 30 {
+		return errors.New("令牌名称过长")
+	}
+	if token.Group != "" {
+		if err := validateTokenGroup(token.Group, userId); err != nil {
+			return err
+		}
+	}
+	if token.BackupGroup != "" {
+		if err := validateTokenGroup(token.BackupGroup, userId); err != nil {
+			return err
+		}
+	}
+	setting := token.Setting.Data()
+	return validateTokenSetting(&setting)
+}
+
 func DeleteToken(c *gin.Context) {
 	id, _ := strconv.Atoi(c.Param("id"))
 	userId := c.GetInt("id")
@@ -310,5 +359,103 @@ func validateTokenSetting(setting *model.TokenSetting) error {
 		}
 	}
 
+	if setting.Language != "" && i18n.NormalizeLanguage(setting.Language) == "" {
+		return fmt.Errorf("unsupported language %s, supported: %s", setting.Language, strings.Join(i18n.SupportedLanguages, ", "))
+	}
+
+	if err := setting.Features.Validate(); err != nil {
+		return fmt.Errorf("invalid features: %w", err)
+	}
+
 	return nil
 }
+
+type TokenChannelsRequest struct {
+	ChannelIds []int `json:"channel_ids"`
+}
+
+// UpdateTokenChannels 管理员为令牌绑定专属渠道
+func UpdateTokenChannels(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var req TokenChannelsRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	// 租户管理员只能绑定本租户用户的令牌和本租户的渠道
+	if c.GetInt("tenant_id") != 0 {
+		token, err := model.GetTokenById(id)
+		if err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+		tenantId, err := model.CacheGetUserTenantId(token.UserId)
+		if err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+		if !inTenantScope(c, tenantId) {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("无权操作其他租户的令牌"))
+			return
+		}
+		for _, channelId := range req.ChannelIds {
+			if err = checkChannelTenant(c, channelId); err != nil {
+				common.APIRespondWithError(c, http.StatusOK, err)
+				return
+			}
+		}
+	}
+
+	token, err := model.UpdateTokenChannels(id, req.ChannelIds)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    token,
+	})
+}
+
+type TokenServiceAccountRequest struct {
+	ServiceAccount bool `json:"service_account"`
+}
+
+// UpdateTokenServiceAccount 将令牌设为内部服务账号，用于健康检查、评测等不计费的流量
+func UpdateTokenServiceAccount(c *gin.Context) {
+	// 服务账号不扣额度，租户管理员不能设置
+	if c.GetInt("tenant_id") != 0 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("租户管理员无权设置服务账号"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var req TokenServiceAccountRequest
+	if err = c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	token, err := model.UpdateTokenServiceAccount(id, req.ServiceAccount)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    token,
+	})
+}
@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrashList 回收站中已删除的渠道、令牌或用户，租户管理员只能查看本租户的记录
+func GetTrashList(c *gin.Context) {
+	var params model.TrashListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	items, err := model.GetTrashList(&params, c.GetInt("tenant_id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    items,
+	})
+}
+
+// getTrashItem 按路由参数获取回收站中的记录，并检查操作权限
+func getTrashItem(c *gin.Context) (*model.TrashItem, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, errors.New("无效的参数")
+	}
+	item, err := model.GetTrashItem(c.Param("type"), id, c.GetInt("tenant_id"))
+	if err != nil {
+		return nil, err
+	}
+
+	myRole := c.GetInt("role")
+	if item.Type == model.TrashTypeUser && myRole <= item.Role && myRole != config.RoleRootUser {
+		return nil, errors.New("无权操作同权限等级或更高权限等级的用户")
+	}
+	return item, nil
+}
+
+// RestoreTrashItem 从回收站恢复渠道、令牌或用户
+func RestoreTrashItem(c *gin.Context) {
+	item, err := getTrashItem(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := model.RestoreTrashItem(item); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// PurgeTrashItem 彻底删除回收站中的记录，无法恢复
+func PurgeTrashItem(c *gin.Context) {
+	item, err := getTrashItem(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if err := model.PurgeTrashItem(item); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
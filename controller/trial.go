@@ -0,0 +1,47 @@
+package controller
+
+import (
+	"net/http"
+	"one-api/common"
+	"one-api/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+type TrialTokenRequest struct {
+	Fingerprint string `json:"fingerprint" binding:"required"`
+}
+
+type TrialTokenResult struct {
+	Key         string   `json:"key"`
+	ExpiredTime int64    `json:"expired_time"`
+	RemainQuota int      `json:"remain_quota"`
+	Models      []string `json:"models"`
+}
+
+// IssueTrialToken 访客领取匿名试用令牌
+func IssueTrialToken(c *gin.Context) {
+	var req TrialTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	token, err := model.IssueTrialToken(c.ClientIP(), req.Fingerprint)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data": TrialTokenResult{
+			Key:         token.PlainKey,
+			ExpiredTime: token.ExpiredTime,
+			RemainQuota: token.RemainQuota,
+			Models:      viper.GetStringSlice("trial.models"),
+		},
+	})
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/rand"
+	"one-api/common/cache"
+	"one-api/model"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// 缓存上游的模型列表和余额查询结果，避免大量渠道频繁请求这些接口触发上游限流，
+// 有效期按比例随机缩短，使各渠道的刷新时间错开；查询失败不缓存
+const (
+	upstreamModelsCacheKey  = "upstream_models"
+	upstreamBalanceCacheKey = "upstream_balance"
+	upstreamCacheTimeout    = 60 * time.Second
+)
+
+type upstreamCacheTTL struct {
+	ModelsTTL  *int `mapstructure:"models_ttl"`
+	BalanceTTL *int `mapstructure:"balance_ttl"`
+}
+
+// upstreamCacheExpiration 渠道的缓存有效期，upstream_cache.channels 中按渠道 ID 覆盖，为 0 时不缓存
+func upstreamCacheExpiration(channelId int, balance bool) time.Duration {
+	seconds := viper.GetInt("upstream_cache.models_ttl")
+	if balance {
+		seconds = viper.GetInt("upstream_cache.balance_ttl")
+	}
+
+	var channels map[string]upstreamCacheTTL
+	if err := viper.UnmarshalKey("upstream_cache.channels", &channels); err == nil {
+		if ttl, ok := channels[strconv.Itoa(channelId)]; ok {
+			if balance && ttl.BalanceTTL != nil {
+				seconds = *ttl.BalanceTTL
+			} else if !balance && ttl.ModelsTTL != nil {
+				seconds = *ttl.ModelsTTL
+			}
+		}
+	}
+	if seconds <= 0 {
+		return 0
+	}
+
+	expiration := time.Duration(seconds) * time.Second
+	if jitter := viper.GetFloat64("upstream_cache.jitter"); jitter > 0 {
+		expiration -= time.Duration(float64(expiration) * min(jitter, 1) * rand.Float64())
+	}
+	return max(expiration, time.Second)
+}
+
+// upstreamCacheKey 按渠道、key 和地址区分，编辑渠道时用新的 key 查询不会读到旧的结果
+func upstreamCacheKey(prefix string, channel *model.Channel) string {
+	digest := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%s", channel.Type, channel.Key, channel.GetBaseURL())))
+	return fmt.Sprintf("%s:%d:%s", prefix, channel.Id, hex.EncodeToString(digest[:8]))
+}
+
+// cachedUpstreamModels 读取缓存的上游模型列表，refresh 时重新查询
+func cachedUpstreamModels(channel *model.Channel, c *gin.Context, refresh bool) ([]string, error) {
+	expiration := upstreamCacheExpiration(channel.Id, false)
+	if expiration == 0 {
+		return fetchUpstreamModels(channel, c)
+	}
+
+	key := upstreamCacheKey(upstreamModelsCacheKey, channel)
+	if refresh {
+		cache.DeleteCache(key)
+	}
+	return cache.GetOrSetCache(key, expiration, func() ([]string, error) {
+		return fetchUpstreamModels(channel, c)
+	}, upstreamCacheTimeout)
+}
+
+// cachedChannelBalance 读取缓存的余额，refresh 时重新查询
+func cachedChannelBalance(channel *model.Channel, refresh bool) (float64, error) {
+	expiration := upstreamCacheExpiration(channel.Id, true)
+	if expiration == 0 {
+		return updateChannelBalance(channel)
+	}
+
+	key := upstreamCacheKey(upstreamBalanceCacheKey, channel)
+	if refresh {
+		cache.DeleteCache(key)
+	}
+	return cache.GetOrSetCache(key, expiration, func() (float64, error) {
+		return updateChannelBalance(channel)
+	}, upstreamCacheTimeout)
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/ipguard"
 	"one-api/common/limit"
 	"one-api/common/utils"
 	"one-api/model"
@@ -55,6 +56,8 @@ func Login(c *gin.Context) {
 	}
 	err = user.ValidateAndFill()
 	if err != nil {
+		// 用户存在时 ValidateAndFill 已填充用户 ID
+		recordLogin(c, user.Id, username, false, err.Error())
 		c.JSON(http.StatusOK, gin.H{
 			"message": err.Error(),
 			"success": false,
@@ -66,12 +69,22 @@ func Login(c *gin.Context) {
 
 // setup session & cookies and then return user info
 func setupLogin(user *model.User, c *gin.Context) {
+	userSession, err := model.CreateUserSession(user.Id, c.ClientIP(), c.Request.UserAgent(), loginMethod(c))
+	if err != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "无法保存会话信息，请重试",
+			"success": false,
+		})
+		return
+	}
+
 	session := sessions.Default(c)
+	session.Set("sid", userSession.SessionId)
 	session.Set("id", user.Id)
 	session.Set("username", user.Username)
 	session.Set("role", user.Role)
 	session.Set("status", user.Status)
-	err := session.Save()
+	err = session.Save()
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"message": "无法保存会话信息，请重试",
@@ -83,6 +96,7 @@ func setupLogin(user *model.User, c *gin.Context) {
 	user.LastLoginIp = c.ClientIP()
 
 	user.Update(false)
+	recordLogin(c, user.Id, user.Username, true, "")
 
 	cleanUser := model.User{
 		Id:          user.Id,
@@ -101,6 +115,9 @@ func setupLogin(user *model.User, c *gin.Context) {
 
 func Logout(c *gin.Context) {
 	session := sessions.Default(c)
+	if sessionId, ok := session.Get("sid").(string); ok {
+		model.RevokeUserSessionBySessionId(sessionId)
+	}
 	session.Clear()
 	err := session.Save()
 	if err != nil {
@@ -147,6 +164,10 @@ func Register(c *gin.Context) {
 		})
 		return
 	}
+	if err := checkSignup(c, false); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
 	if config.EmailVerificationEnabled {
 		if user.Email == "" || user.VerificationCode == "" {
 			c.JSON(http.StatusOK, gin.H{
@@ -155,6 +176,10 @@ func Register(c *gin.Context) {
 			})
 			return
 		}
+		if err := checkEmailDomain(user.Email); err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
 		if !common.VerifyCodeWithKey(user.Email, user.VerificationCode, common.EmailVerificationPurpose) {
 			c.JSON(http.StatusOK, gin.H{
 				"success": false,
@@ -174,13 +199,34 @@ func Register(c *gin.Context) {
 	if config.EmailVerificationEnabled {
 		cleanUser.Email = user.Email
 	}
-	if err := cleanUser.Insert(inviterId); err != nil {
+
+	var invitation *model.InvitationCode
+	if config.InviteOnlyRegister {
+		invitation, err = model.UseInvitationCode(user.InvitationCode)
+		if err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+		if invitation.Group != "" {
+			cleanUser.Group = invitation.Group
+		}
+	}
+
+	if err := cleanUser.Insert(); err != nil {
+		if invitation != nil {
+			model.ReleaseInvitationCode(invitation)
+		}
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
 			"message": err.Error(),
 		})
 		return
 	}
+	if invitation != nil {
+		model.ApplyInvitationQuota(cleanUser.Id, invitation)
+	}
+	model.ApplyReferral(&cleanUser, c.ClientIP())
+	ipguard.RecordSignup(c.ClientIP())
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -194,7 +240,7 @@ func GetUsersList(c *gin.Context) {
 		return
 	}
 
-	users, err := model.GetUsersList(&params)
+	users, err := model.GetUsersList(&params, c.GetInt("tenant_id"))
 	if err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
@@ -231,6 +277,13 @@ func GetUser(c *gin.Context) {
 		})
 		return
 	}
+	if !inTenantScope(c, user.TenantId) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无权获取其他租户用户的信息",
+		})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "",
@@ -240,6 +293,41 @@ func GetUser(c *gin.Context) {
 
 const API_LIMIT_KEY = "api-limiter:%d"
 
+// UserRateLimit 用户所在分组的速率和并发限制
+type UserRateLimit struct {
+	Group          string  `json:"group"`
+	RPM            int     `json:"rpm"`
+	MaxRPM         int     `json:"max_rpm"`
+	UsageRpmRate   float64 `json:"usage_rpm_rate"`
+	MaxConcurrency int     `json:"max_concurrency"`
+}
+
+func getUserRateLimit(userId int, group string) (*UserRateLimit, error) {
+	limiter := model.GlobalUserGroupRatio.GetAPILimiter(group)
+	if limiter == nil {
+		return nil, errors.New("API requests are not allowed")
+	}
+	key := fmt.Sprintf(API_LIMIT_KEY, userId)
+	// 获取当前已使用的速率
+	rpm, err := limiter.GetCurrentRate(key)
+	if err != nil {
+		return nil, err
+	}
+	maxRPM := limit.GetMaxRate(limiter)
+	var usageRpmRate float64 = 0
+	if maxRPM > 0 {
+		usageRpmRate = math.Floor(float64(rpm)/float64(maxRPM)*100*100) / 100
+	}
+
+	return &UserRateLimit{
+		Group:          group,
+		RPM:            rpm,
+		MaxRPM:         maxRPM,
+		UsageRpmRate:   usageRpmRate,
+		MaxConcurrency: model.GlobalUserGroupRatio.GetMaxConcurrency(group),
+	}, nil
+}
+
 func GetRateRealtime(c *gin.Context) {
 	id := c.GetInt("id")
 	user, err := model.GetUserById(id, false)
@@ -250,10 +338,7 @@ func GetRateRealtime(c *gin.Context) {
 		})
 		return
 	}
-	limiter := model.GlobalUserGroupRatio.GetAPILimiter(user.Group)
-	key := fmt.Sprintf(API_LIMIT_KEY, id)
-	// 获取当前已使用的速率
-	rpm, err := limiter.GetCurrentRate(key)
+	rateLimit, err := getUserRateLimit(id, user.Group)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -261,16 +346,11 @@ func GetRateRealtime(c *gin.Context) {
 		})
 		return
 	}
-	maxRPM := limit.GetMaxRate(limiter)
-	var usageRpmRate float64 = 0
-	if maxRPM > 0 {
-		usageRpmRate = math.Floor(float64(rpm)/float64(maxRPM)*100*100) / 100
-	}
 
 	data := map[string]interface{}{
-		"rpm":          rpm,
-		"maxRPM":       maxRPM,
-		"usageRpmRate": usageRpmRate,
+		"rpm":          rateLimit.RPM,
+		"maxRPM":       rateLimit.MaxRPM,
+		"usageRpmRate": rateLimit.UsageRpmRate,
 		"tpm":          0,
 		"maxTPM":       0,
 		"usageTpmRate": 0,
@@ -306,6 +386,67 @@ func GetUserDashboard(c *gin.Context) {
 	})
 }
 
+// UserDashboardOverview 用户自助查询的用量、令牌、限流和最近的错误
+type UserDashboardOverview struct {
+	Quota         int                      `json:"quota"`
+	UsedQuota     int                      `json:"used_quota"`
+	RequestCount  int                      `json:"request_count"`
+	QuotaText     string                   `json:"quota_text"`
+	UsedQuotaText string                   `json:"used_quota_text"`
+	Today         *model.UsageStatistic    `json:"today"`
+	Last7Days     *model.UsageStatistic    `json:"last_7_days"`
+	Last30Days    *model.UsageStatistic    `json:"last_30_days"`
+	Tokens        []*model.Token           `json:"tokens"`
+	RateLimit     *UserRateLimit           `json:"rate_limit"`
+	RecentErrors  []*model.UserRecentError `json:"recent_errors"`
+}
+
+func GetUserDashboardOverview(c *gin.Context) {
+	id := c.GetInt("id")
+	user, err := model.GetUserById(id, false)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	overview := &UserDashboardOverview{
+		Quota:         user.Quota,
+		UsedQuota:     user.UsedQuota,
+		RequestCount:  user.RequestCount,
+		QuotaText:     user.QuotaText,
+		UsedQuotaText: user.UsedQuotaText,
+		RecentErrors:  model.GetUserRecentErrors(id),
+	}
+
+	now := time.Now()
+	today := now.Format("2006-01-02")
+	for _, period := range []struct {
+		days   int
+		result **model.UsageStatistic
+	}{{0, &overview.Today}, {6, &overview.Last7Days}, {29, &overview.Last30Days}} {
+		*period.result, err = model.GetUserStatisticsSummary(id, now.AddDate(0, 0, -period.days).Format("2006-01-02"), today)
+		if err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+	}
+
+	if overview.Tokens, err = model.GetUserActiveTokens(id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	if overview.RateLimit, err = getUserRateLimit(id, user.Group); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    overview,
+	})
+}
+
 func GenerateAccessToken(c *gin.Context) {
 	id := c.GetInt("id")
 	user, err := model.GetUserById(id, true)
@@ -421,6 +562,13 @@ func UpdateUser(c *gin.Context) {
 		})
 		return
 	}
+	if !inTenantScope(c, originUser.TenantId) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无权更新其他租户的用户",
+		})
+		return
+	}
 	if myRole <= updatedUser.Role && myRole != config.RoleRootUser {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
@@ -428,6 +576,8 @@ func UpdateUser(c *gin.Context) {
 		})
 		return
 	}
+	// 所属租户只能通过租户接口修改
+	updatedUser.TenantId = 0
 	if updatedUser.Password == "$I_LOVE_U" {
 		updatedUser.Password = "" // rollback to what it should be
 	}
@@ -519,6 +669,13 @@ func DeleteUser(c *gin.Context) {
 		})
 		return
 	}
+	if !inTenantScope(c, originUser.TenantId) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无权删除其他租户的用户",
+		})
+		return
+	}
 	err = model.DeleteUserById(id)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
@@ -562,8 +719,9 @@ func CreateUser(c *gin.Context) {
 		Username:    user.Username,
 		Password:    user.Password,
 		DisplayName: user.DisplayName,
+		TenantId:    c.GetInt("tenant_id"),
 	}
-	if err := cleanUser.Insert(0); err != nil {
+	if err := cleanUser.Insert(); err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"success": false,
 			"message": err.Error(),
@@ -614,6 +772,13 @@ func ManageUser(c *gin.Context) {
 		})
 		return
 	}
+	if !inTenantScope(c, user.TenantId) {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无权更新其他租户的用户",
+		})
+		return
+	}
 	switch req.Action {
 	case "disable":
 		user.Status = config.UserStatusDisabled
@@ -787,6 +952,18 @@ func ChangeUserQuota(c *gin.Context) {
 		return
 	}
 
+	if tenantId := c.GetInt("tenant_id"); tenantId != 0 {
+		user, err := model.GetUserById(userId, false)
+		if err != nil {
+			common.APIRespondWithError(c, http.StatusOK, err)
+			return
+		}
+		if user.TenantId != tenantId {
+			common.APIRespondWithError(c, http.StatusOK, errors.New("无权修改其他租户用户的额度"))
+			return
+		}
+	}
+
 	err = model.ChangeUserQuota(userId, req.Quota, false)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
@@ -53,6 +53,21 @@ func AddUserGroup(c *gin.Context) {
 		return
 	}
 
+	if err := userGroup.ValidateModels(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := userGroup.ValidateSafePolicy(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := userGroup.ValidateParent(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
 	if err := userGroup.Create(); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
@@ -72,6 +87,21 @@ func UpdateUserGroup(c *gin.Context) {
 		return
 	}
 
+	if err := userGroup.ValidateModels(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := userGroup.ValidateSafePolicy(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	if err := userGroup.ValidateParent(); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
 	if err := userGroup.Update(); err != nil {
 		common.APIRespondWithError(c, http.StatusOK, err)
 		return
@@ -0,0 +1,200 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/model"
+	"strconv"
+	"strings"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+// loginMethod 按路由判断登录方式，如 password、github、webauthn
+func loginMethod(c *gin.Context) string {
+	path := c.FullPath()
+	switch {
+	case path == "/api/user/login":
+		return "password"
+	case strings.HasPrefix(path, "/api/webauthn/"):
+		return "webauthn"
+	case strings.HasPrefix(path, "/api/oauth/"):
+		return strings.TrimPrefix(path, "/api/oauth/")
+	}
+	return path
+}
+
+// recordLogin 记录登录结果，userId 为 0 表示用户不存在
+func recordLogin(c *gin.Context, userId int, username string, success bool, message string) {
+	model.RecordLoginHistory(&model.LoginHistory{
+		UserId:    userId,
+		Username:  username,
+		Ip:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Method:    loginMethod(c),
+		Success:   success,
+		Message:   message,
+	})
+}
+
+func currentSessionId(c *gin.Context) string {
+	sessionId, _ := sessions.Default(c).Get("sid").(string)
+	return sessionId
+}
+
+// GetUserSessions 当前用户的登录会话，current 为 true 的是当前会话
+func GetUserSessions(c *gin.Context) {
+	userSessions, err := model.GetUserSessions(c.GetInt("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	sessionId := currentSessionId(c)
+	for _, userSession := range userSessions {
+		userSession.Current = sessionId != "" && userSession.SessionId == sessionId
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    userSessions,
+	})
+}
+
+// RevokeUserSession 撤销当前用户的指定会话
+func RevokeUserSession(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("无效的参数"))
+		return
+	}
+	if err := model.RevokeUserSession(c.GetInt("id"), id); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+// RevokeOtherUserSessions 撤销当前用户除当前会话外的所有会话
+func RevokeOtherUserSessions(c *gin.Context) {
+	sessionId := currentSessionId(c)
+	if sessionId == "" {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("当前会话不支持该操作，请重新登录"))
+		return
+	}
+	count, err := model.RevokeUserSessions(c.GetInt("id"), sessionId)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    count,
+	})
+}
+
+// GetSelfLoginHistory 当前用户的登录记录
+func GetSelfLoginHistory(c *gin.Context) {
+	var params model.LoginHistoryListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	params.UserId = c.GetInt("id")
+	params.Username = ""
+
+	histories, err := model.GetLoginHistoryList(&params, 0)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    histories,
+	})
+}
+
+// GetLoginHistoryList 管理员查看登录记录，租户管理员只能查看本租户用户的记录
+func GetLoginHistoryList(c *gin.Context) {
+	var params model.LoginHistoryListParams
+	if err := c.ShouldBindQuery(&params); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	histories, err := model.GetLoginHistoryList(&params, c.GetInt("tenant_id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    histories,
+	})
+}
+
+// getManagedUser 管理员可以管理的用户，不能管理同级或更高等级的用户以及其他租户的用户
+func getManagedUser(c *gin.Context) (*model.User, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return nil, errors.New("无效的参数")
+	}
+	user, err := model.GetUserById(id, false)
+	if err != nil {
+		return nil, err
+	}
+	myRole := c.GetInt("role")
+	if myRole <= user.Role && myRole != config.RoleRootUser {
+		return nil, errors.New("无权管理同级或更高等级用户的会话")
+	}
+	if !inTenantScope(c, user.TenantId) {
+		return nil, errors.New("无权管理其他租户用户的会话")
+	}
+	return user, nil
+}
+
+// GetUserSessionsByAdmin 管理员查看用户的登录会话
+func GetUserSessionsByAdmin(c *gin.Context) {
+	user, err := getManagedUser(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	userSessions, err := model.GetUserSessions(user.Id)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    userSessions,
+	})
+}
+
+// RevokeUserSessionsByAdmin 管理员撤销用户的所有会话，用户需要重新登录
+func RevokeUserSessionsByAdmin(c *gin.Context) {
+	user, err := getManagedUser(c)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	count, err := model.RevokeUserSessions(user.Id, "")
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    count,
+	})
+}
@@ -292,6 +292,7 @@ func WebauthnFinishLogin(c *gin.Context) {
 
 		_, err = webauthnInstance.FinishLogin(user, sessionData, c.Request)
 		if err != nil {
+			recordLogin(c, user.Id, user.Username, false, "登录验证失败: "+err.Error())
 			c.JSON(http.StatusBadRequest, gin.H{
 				"message": "登录验证失败: " + err.Error(),
 				"success": false,
@@ -350,6 +351,7 @@ func WebauthnFinishLogin(c *gin.Context) {
 
 	// 检查用户状态
 	if user.Status != 1 {
+		recordLogin(c, user.Id, user.Username, false, "用户已被封禁")
 		c.JSON(http.StatusForbidden, gin.H{
 			"message": "登陆失败",
 			"success": false,
@@ -5,7 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/ipguard"
 	"one-api/model"
 	"strconv"
 	"time"
@@ -81,18 +83,23 @@ func WeChatAuth(c *gin.Context) {
 		}
 	} else {
 		if config.RegisterEnabled {
+			if err := checkSignup(c, true); err != nil {
+				common.APIRespondWithError(c, http.StatusOK, err)
+				return
+			}
 			user.Username = "wechat_" + strconv.Itoa(model.GetMaxUserId()+1)
 			user.DisplayName = "WeChat User"
 			user.Role = config.RoleCommonUser
 			user.Status = config.UserStatusEnabled
 
-			if err := user.Insert(0); err != nil {
+			if err := user.Insert(); err != nil {
 				c.JSON(http.StatusOK, gin.H{
 					"success": false,
 					"message": err.Error(),
 				})
 				return
 			}
+			ipguard.RecordSignup(c.ClientIP())
 		} else {
 			c.JSON(http.StatusOK, gin.H{
 				"success": false,
@@ -0,0 +1,153 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/model"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var weComProvider = &enterpriseOAuthProvider{
+	Name:        EnterpriseOAuthWeCom,
+	DisplayName: "企业微信",
+	IdField:     "wecom_id",
+	SetId: func(user *model.User, id string) {
+		user.WeComId = id
+	},
+}
+
+type WeComResponse struct {
+	ErrCode int    `json:"errcode"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+type WeComAccessTokenResponse struct {
+	WeComResponse
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+type WeComUserIdResponse struct {
+	WeComResponse
+	UserId string `json:"userid"`
+	OpenId string `json:"openid"`
+}
+
+type WeComUser struct {
+	WeComResponse
+	UserId     string `json:"userid"`
+	Name       string `json:"name"`
+	Email      string `json:"email"`
+	BizMail    string `json:"biz_mail"`
+	Avatar     string `json:"avatar"`
+	Department []int  `json:"department"`
+}
+
+var weComAccessToken struct {
+	sync.Mutex
+	key       string
+	token     string
+	expiresAt time.Time
+}
+
+// getWeComAccessToken 获取应用的 access_token，有效期内复用
+func getWeComAccessToken() (string, error) {
+	weComAccessToken.Lock()
+	defer weComAccessToken.Unlock()
+
+	key := config.WeComCorpId + ":" + config.WeComSecret
+	if weComAccessToken.key == key && time.Now().Before(weComAccessToken.expiresAt) {
+		return weComAccessToken.token, nil
+	}
+
+	var res WeComAccessTokenResponse
+	query := url.Values{"corpid": {config.WeComCorpId}, "corpsecret": {config.WeComSecret}}
+	if err := getWeCom("https://qyapi.weixin.qq.com/cgi-bin/gettoken?"+query.Encode(), &res); err != nil {
+		return "", err
+	}
+	if res.ErrCode != 0 {
+		return "", errors.New(res.ErrMsg)
+	}
+
+	weComAccessToken.key = key
+	weComAccessToken.token = res.AccessToken
+	// 提前一分钟过期
+	weComAccessToken.expiresAt = time.Now().Add(time.Duration(res.ExpiresIn-60) * time.Second)
+	return res.AccessToken, nil
+}
+
+func getWeCom(rawURL string, v any) error {
+	client := http.Client{
+		Timeout: 5 * time.Second,
+	}
+	res, err := client.Get(rawURL)
+	if err != nil {
+		logger.SysError("无法连接至 企业微信 服务器, err:" + err.Error())
+		return errors.New("无法连接至企业微信服务器，请稍后重试！")
+	}
+	defer res.Body.Close()
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+func getWeComUserInfoByCode(code string) (*enterpriseOAuthUser, error) {
+	accessToken, err := getWeComAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var userIdRes WeComUserIdResponse
+	query := url.Values{"access_token": {accessToken}, "code": {code}}
+	if err = getWeCom("https://qyapi.weixin.qq.com/cgi-bin/auth/getuserinfo?"+query.Encode(), &userIdRes); err != nil {
+		return nil, err
+	}
+	if userIdRes.ErrCode != 0 {
+		return nil, errors.New(userIdRes.ErrMsg)
+	}
+	if userIdRes.UserId == "" {
+		return nil, errors.New("仅企业成员可以登录")
+	}
+
+	var weComUser WeComUser
+	query = url.Values{"access_token": {accessToken}, "userid": {userIdRes.UserId}}
+	if err = getWeCom("https://qyapi.weixin.qq.com/cgi-bin/user/get?"+query.Encode(), &weComUser); err != nil {
+		return nil, err
+	}
+	if weComUser.ErrCode != 0 {
+		return nil, fmt.Errorf("获取企业微信成员信息失败：%s", weComUser.ErrMsg)
+	}
+
+	user := &enterpriseOAuthUser{
+		Id:        userIdRes.UserId,
+		Name:      weComUser.Name,
+		Email:     weComUser.Email,
+		AvatarUrl: weComUser.Avatar,
+	}
+	if user.Email == "" {
+		user.Email = weComUser.BizMail
+	}
+	for _, department := range weComUser.Department {
+		user.Departments = append(user.Departments, strconv.Itoa(department))
+	}
+	return user, nil
+}
+
+// WeComOAuth 企业微信扫码登录回调，已登录时绑定企业微信账户
+func WeComOAuth(c *gin.Context) {
+	if !config.WeComAuthEnabled {
+		c.JSON(http.StatusOK, gin.H{
+			"message": "管理员未开启通过企业微信登录以及注册",
+			"success": false,
+		})
+		return
+	}
+	enterpriseOAuthCallback(c, weComProvider, getWeComUserInfoByCode)
+}
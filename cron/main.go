@@ -1,22 +1,64 @@
 package cron
 
 import (
+	"fmt"
 	"github.com/spf13/viper"
 	"one-api/common/config"
+	"one-api/common/election"
 	"one-api/common/logger"
+	"one-api/common/notify"
 	"one-api/common/scheduler"
+	"one-api/common/storage"
+	"one-api/common/utils"
+	"one-api/controller"
 	"one-api/model"
+	"strings"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
 )
 
+// 只在主节点运行的任务
+var cronJobNames = []string{
+	"update_daily_statistics",
+	"generate_statistics_month",
+	"update_statistics",
+	"update_pricing_by_service",
+	"recover_request_journals",
+	"archive_logs",
+	"detect_usage_anomalies",
+	"run_quota_grants",
+	"sync_price_proposals",
+	"apply_price_schedules",
+	"delete_expired_request_captures",
+	"revalidate_github_org_members",
+	"delete_expired_user_sessions",
+	"delete_expired_idempotency_keys",
+	"delete_expired_billing_records",
+	"purge_expired_trash",
+	"check_low_balance_channels",
+}
+
+// InitCron 成为主节点时注册定时任务，失去主节点身份时移除
 func InitCron() {
-	if !config.IsMasterNode {
-		logger.SysLog("Cron is disabled on slave node")
-		return
+	election.OnLeadershipChange(func(isLeader bool) {
+		if isLeader {
+			logger.SysLog("Cron is enabled on master node")
+			startCron()
+		} else {
+			logger.SysLog("Cron is disabled on slave node")
+			stopCron()
+		}
+	})
+}
+
+func stopCron() {
+	for _, name := range cronJobNames {
+		scheduler.Manager.RemoveJob(name)
 	}
+}
 
+func startCron() {
 	// 添加每日统计任务
 	err := scheduler.Manager.AddJob(
 		"update_daily_statistics",
@@ -26,8 +68,17 @@ func InitCron() {
 				gocron.NewAtTime(0, 0, 30),
 			)),
 		gocron.NewTask(func() {
+			// 先汇总昨天最后一个小时
+			if err := model.RollupStatistics(); err != nil {
+				logger.SysError("Rollup statistics error: " + err.Error())
+			}
 			model.UpdateStatistics(model.StatisticsUpdateTypeYesterday)
 			logger.SysLog("更新昨日统计数据")
+			if count, err := model.PruneStatisticsHourly(); err != nil {
+				logger.SysError("Prune hourly statistics error: " + err.Error())
+			} else if count > 0 {
+				logger.SysLog(fmt.Sprintf("deleted %d expired hourly statistics", count))
+			}
 		}),
 	)
 	if err != nil {
@@ -49,16 +100,122 @@ func InitCron() {
 		)
 	}
 
-	// 每十分钟更新一次统计数据
+	// 定时汇总小时统计和当天的每日统计，只扫描最近两个小时的日志
 	err = scheduler.Manager.AddJob(
 		"update_statistics",
-		gocron.DurationJob(10*time.Minute),
+		gocron.DurationJob(time.Duration(utils.GetOrDefault("statistics.rollup_interval", 10))*time.Minute),
 		gocron.NewTask(func() {
-			model.UpdateStatistics(model.StatisticsUpdateTypeToDay)
-			logger.SysLog("10分钟统计数据")
+			if err := model.RollupStatistics(); err != nil {
+				logger.SysError("Rollup statistics error: " + err.Error())
+				return
+			}
+			logger.SysLog("更新小时统计数据")
 		}),
 	)
 
+	// 每五分钟退还异常中断请求的预扣费用
+	err = scheduler.Manager.AddJob(
+		"recover_request_journals",
+		gocron.DurationJob(5*time.Minute),
+		gocron.NewTask(model.RecoverRequestJournals),
+	)
+
+	// 每天凌晨三点将历史日志归档到对象存储
+	err = scheduler.Manager.AddJob(
+		"archive_logs",
+		gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(3, 0, 0))),
+		gocron.NewTask(archiveLogs),
+	)
+
+	// 每小时第五分钟检测上一个小时的用量异常
+	if viper.GetBool("anomaly_detection.enable") {
+		err = scheduler.Manager.AddJob(
+			"detect_usage_anomalies",
+			gocron.CronJob("5 * * * *", false),
+			gocron.NewTask(detectUsageAnomalies),
+		)
+	}
+
+	// 每小时检查一次定时发放额度，每个用户每个周期只发放一次
+	err = scheduler.Manager.AddJob(
+		"run_quota_grants",
+		gocron.CronJob("1 * * * *", false),
+		gocron.NewTask(runQuotaGrants),
+	)
+
+	// 定期为渠道已提供但没有价格的模型提出价格
+	if viper.GetBool("price_sync.enable") && viper.GetInt("price_sync.interval") > 0 {
+		err = scheduler.Manager.AddJob(
+			"sync_price_proposals",
+			gocron.DurationJob(time.Duration(viper.GetInt("price_sync.interval"))*time.Minute),
+			gocron.NewTask(syncPriceProposals),
+		)
+	}
+
+	// 每分钟写入到期的价格和分组倍率调整
+	err = scheduler.Manager.AddJob(
+		"apply_price_schedules",
+		gocron.CronJob("* * * * *", false),
+		gocron.NewTask(applyPriceSchedules),
+	)
+
+	// 每天凌晨四点删除过期的请求体
+	if model.RequestCaptureEnabled() {
+		err = scheduler.Manager.AddJob(
+			"delete_expired_request_captures",
+			gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(4, 0, 0))),
+			gocron.NewTask(deleteExpiredRequestCaptures),
+		)
+	}
+
+	// 定期复查 GitHub 组织成员身份
+	if interval := viper.GetInt("github_org_check.interval"); interval > 0 {
+		err = scheduler.Manager.AddJob(
+			"revalidate_github_org_members",
+			gocron.DurationJob(time.Duration(interval)*time.Minute),
+			gocron.NewTask(revalidateGitHubOrgMembers),
+		)
+	}
+
+	// 每天凌晨四点删除过期的登录会话和登录记录
+	err = scheduler.Manager.AddJob(
+		"delete_expired_user_sessions",
+		gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(4, 10, 0))),
+		gocron.NewTask(deleteExpiredUserSessions),
+	)
+
+	// 每小时删除过期的幂等键
+	err = scheduler.Manager.AddJob(
+		"delete_expired_idempotency_keys",
+		gocron.CronJob("30 * * * *", false),
+		gocron.NewTask(deleteExpiredIdempotencyKeys),
+	)
+
+	// 定期查询设置了余额阈值的渠道的上游余额
+	if interval := viper.GetInt("low_balance.check_interval"); interval > 0 {
+		err = scheduler.Manager.AddJob(
+			"check_low_balance_channels",
+			gocron.DurationJob(time.Duration(interval)*time.Minute),
+			gocron.NewTask(controller.CheckLowBalanceChannels),
+		)
+	}
+
+	// 每小时删除过期的结算幂等记录和批量更新回执
+	err = scheduler.Manager.AddJob(
+		"delete_expired_billing_records",
+		gocron.CronJob("40 * * * *", false),
+		gocron.NewTask(deleteExpiredBillingRecords),
+	)
+
+	// 每天凌晨四点彻底删除超过保留期的渠道、令牌和用户
+	if viper.GetInt("trash.retention_days") > 0 {
+		err = scheduler.Manager.AddJob(
+			"purge_expired_trash",
+			gocron.DailyJob(1, gocron.NewAtTimes(gocron.NewAtTime(4, 20, 0))),
+			gocron.NewTask(purgeExpiredTrash),
+		)
+	}
+
 	// 开启自动更新 并且设置了有效自动更新时间 同时自动更新模式不是system 则会从服务器拉取最新价格表
 	autoPriceUpdatesInterval := viper.GetInt("auto_price_updates_interval")
 	autoPriceUpdates := viper.GetBool("auto_price_updates")
@@ -93,3 +250,145 @@ func InitCron() {
 		return
 	}
 }
+
+func archiveLogs() {
+	if !config.StorageLogArchiveEnabled || config.StorageLogArchiveDays <= 0 || !storage.ObjectStorageEnabled() {
+		return
+	}
+
+	before := time.Now().AddDate(0, 0, -config.StorageLogArchiveDays).Unix()
+	count, err := model.ArchiveLogs(before, func(name string, data []byte) error {
+		_, err := storage.PutObject(storage.LogArchivePrefix+name, data, "application/gzip")
+		return err
+	})
+	if err != nil {
+		logger.SysError(fmt.Sprintf("Archive logs error after %d logs: %s", count, err.Error()))
+		return
+	}
+	logger.SysLog(fmt.Sprintf("Archived %d logs", count))
+}
+
+func detectUsageAnomalies() {
+	if !config.LogConsumeEnabled {
+		logger.SysLog("consume log is disabled, skip usage anomaly detection")
+		return
+	}
+
+	anomalies, err := model.DetectUsageAnomalies(time.Now().Add(-time.Hour))
+	if err != nil {
+		logger.SysError("Detect usage anomalies error: " + err.Error())
+	}
+	if len(anomalies) == 0 {
+		return
+	}
+
+	var message string
+	for _, anomaly := range anomalies {
+		message += fmt.Sprintf("- 用户 #%d 令牌 %s：%s\n", anomaly.UserId, utils.EscapeMarkdownText(anomaly.TokenName), utils.EscapeMarkdownText(anomaly.Detail))
+	}
+	message += "\n如果不是用户本人的使用，令牌可能已经泄露，请及时处理。"
+	notify.Send(fmt.Sprintf("检测到 %d 个用量异常", len(anomalies)), message)
+}
+
+func runQuotaGrants() {
+	granted, err := model.RunQuotaGrants(time.Now())
+	if err != nil {
+		logger.SysError("Run quota grants error: " + err.Error())
+	}
+	if granted > 0 {
+		logger.SysLog(fmt.Sprintf("Granted quota to %d users", granted))
+	}
+}
+
+func syncPriceProposals() {
+	count, err := model.SyncPriceProposalsByService()
+	if err != nil {
+		logger.SysError("Sync price proposals error: " + err.Error())
+	}
+	if count == 0 {
+		return
+	}
+
+	if viper.GetBool("price_sync.auto_apply") {
+		logger.SysLog(fmt.Sprintf("Applied prices of %d new models", count))
+		return
+	}
+	notify.Send("新模型价格待审核", fmt.Sprintf("价格同步为 %d 个按默认价格计费的模型提出了价格，请在后台审核。", count))
+}
+
+func applyPriceSchedules() {
+	applied, err := model.ApplyDuePriceSchedules(utils.GetTimestamp())
+	if err != nil {
+		logger.SysError("Apply price schedules error: " + err.Error())
+		notify.Send("定时价格调整失败", utils.EscapeMarkdownText(err.Error()))
+	}
+	if applied > 0 {
+		logger.SysLog(fmt.Sprintf("Applied %d price schedules", applied))
+	}
+}
+
+func deleteExpiredRequestCaptures() {
+	count, err := model.DeleteExpiredRequestCaptures()
+	if err != nil {
+		logger.SysError("Delete expired request captures error: " + err.Error())
+		return
+	}
+	logger.SysLog(fmt.Sprintf("Deleted %d expired request captures", count))
+}
+
+func deleteExpiredUserSessions() {
+	count, err := model.DeleteExpiredUserSessions()
+	if err != nil {
+		logger.SysError("Delete expired user sessions error: " + err.Error())
+		return
+	}
+	logger.SysLog(fmt.Sprintf("Deleted %d expired user sessions and login histories", count))
+}
+
+func deleteExpiredIdempotencyKeys() {
+	count, err := model.DeleteExpiredIdempotencyKeys()
+	if err != nil {
+		logger.SysError("Delete expired idempotency keys error: " + err.Error())
+		return
+	}
+	if count > 0 {
+		logger.SysLog(fmt.Sprintf("Deleted %d expired idempotency keys", count))
+	}
+}
+
+func purgeExpiredTrash() {
+	count, err := model.PurgeExpiredTrash()
+	if err != nil {
+		logger.SysError("Purge expired trash error: " + err.Error())
+		return
+	}
+	logger.SysLog(fmt.Sprintf("Purged %d expired channels, tokens and users from trash", count))
+}
+
+func revalidateGitHubOrgMembers() {
+	disabled, err := controller.RevalidateGitHubOrgMembers()
+	if err != nil {
+		logger.SysError("Revalidate GitHub organization members error: " + err.Error())
+		return
+	}
+	if len(disabled) == 0 {
+		return
+	}
+	notify.Send("GitHub 组织成员复查", fmt.Sprintf("%d 个用户已不是允许的 GitHub 组织成员，账户已被禁用：%s", len(disabled), utils.EscapeMarkdownText(strings.Join(disabled, ", "))))
+}
+
+func deleteExpiredBillingRecords() {
+	count, err := model.DeleteExpiredBillingRecords()
+	if err != nil {
+		logger.SysError("Delete expired billing records error: " + err.Error())
+		return
+	}
+	receipts, err := model.DeleteExpiredBatchUpdateReceipts()
+	if err != nil {
+		logger.SysError("Delete expired batch update receipts error: " + err.Error())
+		return
+	}
+	if count > 0 || receipts > 0 {
+		logger.SysLog(fmt.Sprintf("Deleted %d expired billing records and %d batch update receipts", count, receipts))
+	}
+}
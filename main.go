@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"fmt"
 	"net/http"
 	"one-api/cli"
@@ -9,11 +11,17 @@ import (
 	"one-api/common/cache"
 	"one-api/common/config"
 	"one-api/common/election"
+	"one-api/common/encryption"
+	"one-api/common/eventstream"
+	"one-api/common/graceful"
+	"one-api/common/ipguard"
 	"one-api/common/logger"
+	"one-api/common/mtls"
 	"one-api/common/notify"
 	"one-api/common/oidc"
 	"one-api/common/realtime"
 	"one-api/common/redis"
+	"one-api/common/remoteconfig"
 	"one-api/common/requester"
 	"one-api/common/search"
 	"one-api/common/storage"
@@ -23,10 +31,17 @@ import (
 	"one-api/cron"
 	"one-api/middleware"
 	"one-api/model"
+	"one-api/relay/hooks"
+	"one-api/relay/hooks/jailbreak"
+	"one-api/relay/hooks/pii"
 	"one-api/relay/task"
 	"one-api/router"
+	"one-api/rpc"
 	"one-api/safty"
+	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/sessions"
@@ -34,6 +49,7 @@ import (
 	redissession "github.com/gin-contrib/sessions/redis"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc"
 )
 
 //go:embed web/build
@@ -59,13 +75,32 @@ func main() {
 	}
 	// Initialize Redis
 	redis.InitRedisClient()
-	election.StartLeaderElection()
+	// 加载渠道 key 加密主密钥，必须在读取渠道之前
+	if err := encryption.Init(); err != nil {
+		logger.FatalLog("failed to initialize channel key encryption: " + err.Error())
+	}
+	if cli.Migrate {
+		cli.MigrateDatabase()
+	}
+	if cli.Admin {
+		cli.RunAdmin()
+	}
 	// Initialize SQL Database
 	model.SetupDB()
 	defer model.CloseDB()
+	if *cli.RotateKeys {
+		cli.RotateChannelKeys()
+	}
+	// Leader election (redis or database backend)
+	if sqlDB, err := model.DB.DB(); err == nil {
+		election.SetDatabase(sqlDB)
+	}
+	election.StartLeaderElection()
 	cache.InitCacheManager()
 	// Initialize options
 	model.InitOptionMap()
+	// 从 etcd 或 Consul 加载配置并监听变化，优先于数据库
+	remoteconfig.Init(model.ReloadOption)
 	// Start Redis realtime sync (options/channels)
 	realtime.StartRealtimeSync()
 	// Initialize oidc
@@ -91,6 +126,12 @@ func main() {
 	search.InitSearcher()
 	// 初始化安全检查器
 	safty.InitSaftyTools()
+	ipguard.Init()
+	// 初始化中继钩子
+	jailbreak.Init()
+	pii.Init()
+	hooks.InitHTTPHooks()
+	eventstream.Init()
 	// 初始化账单数据
 	if config.UserInvoiceMonth {
 		logger.SysLog("Enable User Invoice Monthly Data")
@@ -124,6 +165,9 @@ func initMemoryCache() {
 func initSync() {
 	// go controller.AutomaticallyUpdateChannels(viper.GetInt("channel.update_frequency"))
 	go controller.AutomaticallyTestChannels(viper.GetInt("channel.test_frequency"))
+	go controller.AutomaticallySyncChannelModels(viper.GetInt("channel.model_sync_frequency"))
+	go controller.AutomaticallyNotifyDeprecatedModels(viper.GetInt("channel.deprecation_notify_frequency"))
+	go model.AutomaticallyAdjustChannelPriorities(viper.GetInt("auto_priority.interval"))
 }
 
 func initHttpServer() {
@@ -134,6 +178,7 @@ func initHttpServer() {
 	server := gin.New()
 	server.Use(gin.Recovery())
 	server.Use(middleware.RequestId())
+	server.Use(middleware.IPGuard())
 	middleware.SetUpLogger(server)
 
 	trustedHeader := viper.GetString("trusted_header")
@@ -143,8 +188,11 @@ func initHttpServer() {
 
 	var store sessions.Store
 	if config.RedisEnabled {
+		// 会话存储只支持单机 Redis，哨兵和集群模式使用 cookie
 		opt := redis.ParseRedisOption()
-		if opt != nil {
+		if opt == nil {
+			logger.SysLog("redis session store only supports standalone mode, using cookie store")
+		} else {
 			rs, err := redissession.NewStoreWithDB(64, "tcp", opt.Addr, opt.Username, opt.Password, strconv.Itoa(opt.DB), []byte(config.SessionSecret))
 			if err != nil {
 				logger.SysError("failed to initialize redis session store: " + err.Error() + ", fallback to cookie store")
@@ -168,10 +216,85 @@ func initHttpServer() {
 	router.SetRouter(server, buildFS, indexPage)
 	port := viper.GetString("port")
 
-	err := server.Run(":" + port)
-	if err != nil {
-		logger.FatalLog("failed to start HTTP server: " + err.Error())
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: server,
+	}
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.FatalLog("failed to start HTTP server: " + err.Error())
+		}
+	}()
+
+	servers := []*http.Server{srv}
+	if mtls.Enabled() {
+		mtlsSrv, err := mtls.NewServer(server)
+		if err != nil {
+			logger.FatalLog("failed to initialize mTLS server: " + err.Error())
+		}
+		go func() {
+			if err := mtlsSrv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.FatalLog("failed to start mTLS server: " + err.Error())
+			}
+		}()
+		logger.SysLog("mTLS server listening on " + mtlsSrv.Addr)
+		servers = append(servers, mtlsSrv)
+	}
+
+	var grpcSrv *grpc.Server
+	if rpc.Enabled() {
+		var err error
+		if grpcSrv, err = rpc.Start(); err != nil {
+			logger.FatalLog("failed to start gRPC server: " + err.Error())
+		}
 	}
+
+	waitForShutdown(grpcSrv, servers...)
+}
+
+// waitForShutdown 收到退出信号后停止接受新请求，等待进行中的请求和扣费任务完成，
+// 写入批量更新并释放主节点身份后退出
+func waitForShutdown(grpcSrv *grpc.Server, servers ...*http.Server) {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	timeout := time.Duration(viper.GetInt("shutdown_timeout")) * time.Second
+	logger.SysLog(fmt.Sprintf("shutting down, waiting up to %s for in-flight requests", timeout))
+	graceful.StartDraining()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			logger.SysError("failed to drain in-flight requests: " + err.Error())
+		}
+	}
+	if grpcSrv != nil {
+		// Watch 类接口不会主动结束，超时后强制关闭
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcSrv.Stop()
+		}
+	}
+
+	if !graceful.Wait(ctx) {
+		logger.SysError("timed out waiting for background billing tasks")
+	}
+	eventstream.Close(ctx)
+
+	model.FlushBatchUpdates()
+	remoteconfig.Stop()
+	election.StopLeaderElection()
+	logger.SysLog("server exited")
 }
 
 func SyncChannelCache(frequency int) {
@@ -185,6 +308,12 @@ func SyncChannelCache(frequency int) {
 		logger.SysLog("syncing channels from database")
 		model.ChannelGroup.Load()
 		model.PricingInstance.Init()
+		model.GlobalUserGroupRatio.Load()
 		model.ModelOwnedBysInstance.Load()
+		model.ModelInfosInstance.Load()
+		model.PromptTemplatesInstance.Load()
+		model.IPRulesInstance.Load()
+		model.ModelRoutesInstance.Load()
+		model.ClientCertsInstance.Load()
 	}
 }
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var upstreamConnCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "upstream_connections_total",
+		Help: "Total number of connections obtained for upstream requests.",
+	},
+	[]string{"host", "reused", "was_idle"},
+)
+
+var upstreamProtocolCounter = promauto.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "upstream_responses_total",
+		Help: "Total number of upstream responses by protocol.",
+	},
+	[]string{"host", "protocol"},
+)
+
+// connTracingTransport 记录上游连接的复用情况和协议版本
+type connTracingTransport struct {
+	next http.RoundTripper
+}
+
+func NewConnTracingTransport(next http.RoundTripper) http.RoundTripper {
+	return &connTracingTransport{next: next}
+}
+
+func (t *connTracingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			SafelyRecordMetric(func() {
+				upstreamConnCounter.WithLabelValues(
+					host,
+					strconv.FormatBool(info.Reused),
+					strconv.FormatBool(info.WasIdle),
+				).Inc()
+			})
+		},
+	}
+
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+	resp, err := t.next.RoundTrip(req)
+	if err == nil {
+		SafelyRecordMetric(func() {
+			upstreamProtocolCounter.WithLabelValues(host, resp.Proto).Inc()
+		})
+	}
+
+	return resp, err
+}
+
+// CloseIdleConnections 透传给底层 Transport，使 http.Client.CloseIdleConnections 生效
+func (t *connTracingTransport) CloseIdleConnections() {
+	if closer, ok := t.next.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
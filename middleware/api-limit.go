@@ -3,17 +3,21 @@ package middleware
 import (
 	"fmt"
 	"net/http"
+	"one-api/common/limit"
 	"one-api/model"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 )
 
 const (
-	LIMIT_KEY               = "api-limiter:%d"
-	INTERNAL                = 1 * time.Minute
-	RATE_LIMIT_EXCEEDED_MSG = "您的速率达到上限，请稍后再试。"
-	SERVER_ERROR_MSG        = "Server error"
+	LIMIT_KEY                = "api-limiter:%d"
+	CONCURRENCY_KEY          = "group-concurrency:%s"
+	INTERNAL                 = 1 * time.Minute
+	RATE_LIMIT_EXCEEDED_MSG  = "您的速率达到上限，请稍后再试。"
+	SERVER_ERROR_MSG         = "Server error"
+	CONCURRENCY_EXCEEDED_MSG = "当前分组的并发请求数达到上限，请稍后再试。"
 )
 
 func DynamicRedisRateLimiter() gin.HandlerFunc {
@@ -34,6 +38,18 @@ func DynamicRedisRateLimiter() gin.HandlerFunc {
 			return
 		}
 
+		// 分组并发限制，请求结束后释放
+		if maxConcurrency := model.GlobalUserGroupRatio.GetMaxConcurrency(userGroup); maxConcurrency > 0 {
+			// 并发已满时排队等待，按用户公平放行，避免单个用户的大量请求占满分组的并发
+			queueTimeout := time.Duration(viper.GetInt("group_queue_timeout")) * time.Second
+			release, ok := limit.AcquireConcurrencyFair(c.Request.Context(), fmt.Sprintf(CONCURRENCY_KEY, userGroup), userID, maxConcurrency, queueTimeout)
+			if !ok {
+				abortWithMessage(c, http.StatusTooManyRequests, CONCURRENCY_EXCEEDED_MSG)
+				return
+			}
+			defer release()
+		}
+
 		c.Next()
 	}
 }
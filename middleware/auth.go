@@ -1,9 +1,14 @@
 package middleware
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"one-api/common/config"
+	"one-api/common/hmacauth"
+	"one-api/common/i18n"
+	"one-api/common/ipguard"
+	"one-api/common/mtls"
 	"one-api/common/utils"
 	"one-api/model"
 	"strings"
@@ -12,12 +17,27 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func authHelper(c *gin.Context, minRole int) {
+// authHelper allowTenantAdmin 为 false 时拒绝租户管理员，租户管理员只能访问已做租户隔离的接口
+func authHelper(c *gin.Context, minRole int, allowTenantAdmin bool) {
 	session := sessions.Default(c)
 	username := session.Get("username")
 	role := session.Get("role")
 	id := session.Get("id")
 	status := session.Get("status")
+	if sessionId, ok := session.Get("sid").(string); ok && username != nil {
+		// 会话已被撤销时清除 cookie，旧版本登录的会话没有 sid，不做检查
+		if !model.IsUserSessionActive(sessionId) {
+			session.Clear()
+			session.Save()
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "会话已失效，请重新登录",
+			})
+			c.Abort()
+			return
+		}
+		model.TouchUserSession(sessionId)
+	}
 	if username == nil {
 		// Check access token
 		accessToken := c.Request.Header.Get("Authorization")
@@ -65,9 +85,31 @@ func authHelper(c *gin.Context, minRole int) {
 		c.Abort()
 		return
 	}
+	tenantId := 0
+	if role.(int) >= config.RoleAdminUser && role.(int) < config.RoleRootUser {
+		var err error
+		tenantId, err = model.CacheGetUserTenantId(id.(int))
+		if err != nil {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "无法获取用户所属租户",
+			})
+			c.Abort()
+			return
+		}
+	}
+	if tenantId != 0 && !allowTenantAdmin && minRole >= config.RoleAdminUser {
+		c.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "无权进行此操作，租户管理员无法访问",
+		})
+		c.Abort()
+		return
+	}
 	c.Set("username", username)
 	c.Set("role", role)
 	c.Set("id", id)
+	c.Set("tenant_id", tenantId)
 	c.Next()
 }
 
@@ -97,19 +139,26 @@ func TrySetUserBySession() func(c *gin.Context) {
 
 func UserAuth() func(c *gin.Context) {
 	return func(c *gin.Context) {
-		authHelper(c, config.RoleCommonUser)
+		authHelper(c, config.RoleCommonUser, true)
 	}
 }
 
 func AdminAuth() func(c *gin.Context) {
 	return func(c *gin.Context) {
-		authHelper(c, config.RoleAdminUser)
+		authHelper(c, config.RoleAdminUser, false)
+	}
+}
+
+// TenantAdminAuth 允许租户管理员访问，接口需要通过 tenant_id 限定可管理的范围
+func TenantAdminAuth() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		authHelper(c, config.RoleAdminUser, true)
 	}
 }
 
 func RootAuth() func(c *gin.Context) {
 	return func(c *gin.Context) {
-		authHelper(c, config.RoleRootUser)
+		authHelper(c, config.RoleRootUser, false)
 	}
 }
 
@@ -117,7 +166,28 @@ func tokenAuth(c *gin.Context, key string) {
 	key = strings.TrimPrefix(key, "Bearer ")
 	key = strings.TrimPrefix(key, "sk-")
 
+	var err error
+	signed := hmacauth.Enabled() && hmacauth.Signed(c.Request)
+	if signed {
+		if key, err = signedRequestKey(c); err != nil {
+			if errors.Is(err, errSignedBodyTooLarge) {
+				abortWithMessage(c, http.StatusRequestEntityTooLarge, err.Error())
+				return
+			}
+			ipguard.RecordFailure(c.ClientIP(), "invalid signature")
+			abortWithMessage(c, http.StatusUnauthorized, err.Error())
+			return
+		}
+	}
+
+	key, err = clientCertKey(c, key)
+	if err != nil {
+		abortWithMessage(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
 	if len(key) < 48 {
+		ipguard.RecordFailure(c.ClientIP(), "invalid token")
 		abortWithMessage(c, http.StatusUnauthorized, "无效的令牌")
 		return
 	}
@@ -126,6 +196,10 @@ func tokenAuth(c *gin.Context, key string) {
 	key = parts[0]
 	token, err := model.ValidateUserToken(key)
 	if err != nil {
+		// 只记录不存在或无效的 key，过期、额度用尽和禁用的令牌不计入，避免共用出口 IP 的其他用户被封禁
+		if errors.Is(err, model.ErrTokenInvalid) {
+			ipguard.RecordFailure(c.ClientIP(), "invalid token")
+		}
 		abortWithMessage(c, http.StatusUnauthorized, err.Error())
 		return
 	}
@@ -135,13 +209,43 @@ func tokenAuth(c *gin.Context, key string) {
 	c.Set("token_name", token.Name)
 	c.Set("token_group", token.Group)
 	c.Set("token_backup_group", token.BackupGroup)
-	c.Set("token_setting", utils.GetPointer(token.Setting.Data()))
+	setting := token.Setting.Data()
+	c.Set("token_setting", &setting)
+	if setting.Language != "" {
+		c.Set(i18n.ContextKeyTokenLanguage, setting.Language)
+	}
+	if len(token.ChannelIds) > 0 {
+		c.Set("token_channel_ids", []int(token.ChannelIds))
+	}
+	if token.ServiceAccount {
+		c.Set("token_service_account", true)
+	}
+	if setting.Sandbox && !checkSandboxRoute(c) {
+		return
+	}
+	if !signed && setting.RequireSignature {
+		abortWithMessage(c, http.StatusUnauthorized, "该令牌必须使用签名认证")
+		return
+	}
 	if err := checkLimitIP(c); err != nil {
 		abortWithMessage(c, http.StatusForbidden, err.Error())
 		return
 	}
+	if trialUserId := model.TrialUserId(); trialUserId != 0 && token.UserId == trialUserId {
+		if !allowTrialRequest(c) {
+			abortWithMessage(c, http.StatusTooManyRequests, "试用令牌请求过于频繁，请稍后再试")
+			return
+		}
+	}
+	tenantId, err := model.CacheGetUserTenantId(token.UserId)
+	if err != nil {
+		abortWithMessage(c, http.StatusInternalServerError, "无法获取用户所属租户")
+		return
+	}
+	c.Set("tenant_id", tenantId)
 	if len(parts) > 1 {
-		if model.IsAdmin(token.UserId) {
+		// 租户管理员不能指定渠道，避免绕过租户隔离
+		if tenantId == 0 && model.IsAdmin(token.UserId) {
 			if strings.HasPrefix(parts[1], "!") {
 				channelId := utils.String2Int(parts[1][1:])
 				c.Set("skip_channel_ids", []int{channelId})
@@ -164,6 +268,33 @@ func tokenAuth(c *gin.Context, key string) {
 	c.Next()
 }
 
+// clientCertKey 通过客户端证书访问时使用证书绑定的令牌，同时携带令牌时必须与绑定的令牌一致
+func clientCertKey(c *gin.Context, key string) (string, error) {
+	cert := mtls.PeerCertificate(c.Request)
+	if cert == nil {
+		if mtls.Exclusive() {
+			return "", errors.New("必须使用客户端证书访问")
+		}
+		return key, nil
+	}
+
+	tokenKey, ok := model.ClientCertsInstance.GetTokenKey(cert.Subject.CommonName, mtls.Fingerprint(cert))
+	if !ok {
+		ipguard.RecordFailure(c.ClientIP(), "unbound client certificate")
+		return "", errors.New("客户端证书未绑定令牌")
+	}
+
+	if key == "" {
+		return tokenKey, nil
+	}
+	// 保留 # 之后的渠道参数
+	parts := strings.SplitN(key, "#", 2)
+	if parts[0] != tokenKey {
+		return "", errors.New("令牌与客户端证书不匹配")
+	}
+	return key, nil
+}
+
 // 检测是否IP白名单
 func checkLimitIP(c *gin.Context) (error error) {
 	// 从context中获取token设置
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"one-api/common/config"
+	"one-api/common/ipguard"
+	"one-api/common/logger"
+	"one-api/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestTokenAuthRecordsOnlyInvalidKeys(t *testing.T) {
+	setupSignedTokenDB(t)
+	assert.Nil(t, model.DB.AutoMigrate(&model.User{}))
+	assert.Nil(t, model.DB.Create(&model.User{Id: 1, Username: "user", Status: config.UserStatusEnabled, AccessToken: "access-user", AffCode: "aff-user"}).Error)
+
+	oldLogger := logger.Logger
+	logger.Logger = zap.NewNop()
+	t.Cleanup(func() { logger.Logger = oldLogger })
+
+	// 失败一次即封禁
+	viper.Set("ip_guard.enable", true)
+	viper.Set("ip_guard.window", 60)
+	viper.Set("ip_guard.max_failures", 1)
+	viper.Set("ip_guard.ban_duration", 60)
+	t.Cleanup(func() {
+		viper.Set("ip_guard.enable", false)
+		ipguard.Init()
+	})
+	ipguard.Init()
+
+	newToken := func(name string, status int, expiredTime int64) string {
+		token := &model.Token{UserId: 1, Name: name, Status: status, ExpiredTime: expiredTime, RemainQuota: 100}
+		assert.Nil(t, token.Insert())
+		return token.PlainKey
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		ip     string
+		banned bool
+	}{
+		{"exhausted", newToken("exhausted", config.TokenStatusExhausted, -1), "192.0.2.1", false},
+		{"expired", newToken("expired", config.TokenStatusEnabled, 1), "192.0.2.2", false},
+		{"disabled", newToken("disabled", config.TokenStatusDisabled, -1), "192.0.2.3", false},
+		{"unknown", "sk-unknownabcdefghijklmnopqrstuvwxyzabcdefghijklmnopqrstu", "192.0.2.4", true},
+		{"too short", "sk-short", "192.0.2.5", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+			c.Request.RemoteAddr = tt.ip + ":1234"
+
+			tokenAuth(c, "Bearer "+tt.key)
+			assert.True(t, c.IsAborted())
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, tt.banned, ipguard.IsBanned(tt.ip))
+		})
+	}
+}
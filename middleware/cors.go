@@ -1,15 +1,82 @@
 package middleware
 
 import (
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
+var corsHandler = struct {
+	sync.RWMutex
+	version int64
+	handler gin.HandlerFunc
+}{version: -1}
+
+// CORS 按系统设置处理跨域请求，设置修改后自动重新生成
 func CORS() gin.HandlerFunc {
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	config.AllowCredentials = true
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"*"}
-	return cors.New(config)
+	return func(c *gin.Context) {
+		getCORSHandler()(c)
+	}
+}
+
+func getCORSHandler() gin.HandlerFunc {
+	version := config.CORSVersion()
+
+	corsHandler.RLock()
+	if corsHandler.version == version {
+		handler := corsHandler.handler
+		corsHandler.RUnlock()
+		return handler
+	}
+	corsHandler.RUnlock()
+
+	corsHandler.Lock()
+	defer corsHandler.Unlock()
+	if corsHandler.version != version {
+		corsHandler.handler = newCORSHandler()
+		corsHandler.version = version
+	}
+	return corsHandler.handler
+}
+
+func newCORSHandler() gin.HandlerFunc {
+	origins := config.CORSAllowOrigins
+	if err := ValidateCORSOrigins(origins); err != nil {
+		logger.SysError("invalid cors config, fallback to allow all origins: " + err.Error())
+		origins = []string{"*"}
+	}
+	return cors.New(buildCORSConfig(origins))
+}
+
+// ValidateCORSOrigins 检查允许的来源，每个来源必须带协议且最多包含一个 *
+func ValidateCORSOrigins(origins []string) error {
+	for _, origin := range origins {
+		if strings.Count(origin, "*") > 1 {
+			return fmt.Errorf("invalid origin %s: only one * is allowed", origin)
+		}
+	}
+	return buildCORSConfig(origins).Validate()
+}
+
+func buildCORSConfig(origins []string) cors.Config {
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	corsConfig.AllowHeaders = config.CORSAllowHeaders
+	corsConfig.ExposeHeaders = config.CORSExposeHeaders
+	corsConfig.AllowCredentials = config.CORSAllowCredentials
+	corsConfig.MaxAge = time.Duration(config.CORSMaxAge) * time.Second
+
+	if len(origins) == 0 || (len(origins) == 1 && origins[0] == "*") {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = origins
+		corsConfig.AllowWildcard = true
+	}
+	return corsConfig
 }
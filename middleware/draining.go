@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/graceful"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Draining 停机期间拒绝新的请求，已在处理中的请求不受影响
+func Draining() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if graceful.IsDraining() {
+			c.Header("Connection", "close")
+			common.AbortWithMessage(c, http.StatusServiceUnavailable, "server is shutting down, please retry")
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// Maintenance 维护模式下拒绝中继请求，管理接口不受影响
+func Maintenance() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if config.MaintenanceEnabled {
+			common.AbortWithMessage(c, http.StatusServiceUnavailable, config.MaintenanceMessage)
+			return
+		}
+
+		c.Next()
+	}
+}
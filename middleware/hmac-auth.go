@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"one-api/common/hmacauth"
+	"one-api/common/utils"
+	"one-api/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+var errSignedBodyTooLarge = errors.New("请求体过大")
+
+// signedRequestKey 校验签名请求，返回签名所用令牌的 key
+// 请求体读取后会重新放回，后续中间件和中继仍可读取
+func signedRequestKey(c *gin.Context) (string, error) {
+	keyId := c.GetHeader(hmacauth.HeaderKeyId)
+	token, err := model.GetTokenById(utils.String2Int(keyId))
+	if err != nil {
+		return "", errors.New("无效的令牌")
+	}
+
+	// 签名校验前就要读取请求体，先按 hmac_auth.max_body_size 和令牌的请求体限制取较小值限制读取的大小
+	limit := model.RequestLimitSetting{MaxBodySize: viper.GetInt("hmac_auth.max_body_size")}
+	limit = limit.Merge(token.Setting.Data().Limits.RequestLimit)
+	if limit.MaxBodySize > 0 {
+		maxBytes := int64(limit.MaxBodySize) * 1024
+		if c.Request.ContentLength > maxBytes {
+			return "", errSignedBodyTooLarge
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return "", errSignedBodyTooLarge
+		}
+		return "", err
+	}
+	c.Request.Body.Close()
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+	// 只保存了哈希，签名密钥由令牌 ID 和用户 ID 重新签名得到，旧格式的随机 key 不支持签名请求
+	key, ok := token.DerivedKey()
+	if !ok {
+		return "", errors.New("该令牌不支持签名请求")
+	}
+	if err := hmacauth.Verify(c.Request, body, keyId, key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"one-api/common"
+	"one-api/common/database"
+	"one-api/common/hmacauth"
+	"one-api/model"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/datatypes"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupSignedTokenDB(t *testing.T) {
+	viper.Set("user_token_secret", "hmac-auth-test-secret")
+	t.Cleanup(func() { viper.Set("user_token_secret", "") })
+	assert.Nil(t, common.InitUserToken())
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(&model.Token{}))
+
+	oldDB := model.DB
+	model.DB = db
+	t.Cleanup(func() { model.DB = oldDB })
+}
+
+// signedContext 构造签名请求，contentLength 为 -1 时模拟未声明长度的分块请求
+func signedContext(keyId int, secret, nonce string, body []byte, contentLength int64) *gin.Context {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	r := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader(body))
+	r.ContentLength = contentLength
+	r.Header.Set(hmacauth.HeaderKeyId, strconv.Itoa(keyId))
+	r.Header.Set(hmacauth.HeaderTimestamp, timestamp)
+	r.Header.Set(hmacauth.HeaderNonce, nonce)
+	r.Header.Set(hmacauth.HeaderSignature, hmacauth.Sign(secret, hmacauth.StringToSign(r.Method, r.URL.RequestURI(), timestamp, nonce, body)))
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = r
+	return c
+}
+
+func TestSignedRequestKey(t *testing.T) {
+	setupSignedTokenDB(t)
+	viper.Set("hmac_auth.max_body_size", 1)
+	t.Cleanup(func() { viper.Set("hmac_auth.max_body_size", 0) })
+
+	token := &model.Token{UserId: 1, Name: "signed"}
+	assert.Nil(t, token.Insert())
+	other := &model.Token{UserId: 2, Name: "other"}
+	assert.Nil(t, other.Insert())
+
+	body := []byte(`{"model":"gpt-4o"}`)
+	large := []byte(`{"model":"gpt-4o","prompt":"` + strings.Repeat("a", 2048) + `"}`)
+
+	tests := []struct {
+		name          string
+		keyId         int
+		secret        string
+		body          []byte
+		contentLength int64
+		err           error
+	}{
+		{name: "valid", keyId: token.Id, secret: token.PlainKey, body: body, contentLength: int64(len(body))},
+		{name: "valid without content length", keyId: token.Id, secret: token.PlainKey, body: body, contentLength: -1},
+		{name: "key of other token", keyId: token.Id, secret: other.PlainKey, body: body, contentLength: int64(len(body)), err: hmacauth.ErrSignature},
+		{name: "wrong derived key", keyId: token.Id, secret: token.PlainKey + "x", body: body, contentLength: int64(len(body)), err: hmacauth.ErrSignature},
+		{name: "body over cap", keyId: token.Id, secret: token.PlainKey, body: large, contentLength: int64(len(large)), err: errSignedBodyTooLarge},
+		// 未声明长度时读取到上限后停止，不会按截断的请求体校验签名
+		{name: "body over cap without content length", keyId: token.Id, secret: token.PlainKey, body: large, contentLength: -1, err: errSignedBodyTooLarge},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := signedContext(tt.keyId, tt.secret, "signed-request-"+strconv.Itoa(i), tt.body, tt.contentLength)
+			key, err := signedRequestKey(c)
+			assert.Equal(t, tt.err, err)
+			if tt.err != nil {
+				return
+			}
+
+			assert.Equal(t, token.PlainKey, key)
+			// 请求体放回后仍可读取
+			restored, readErr := io.ReadAll(c.Request.Body)
+			assert.Nil(t, readErr)
+			assert.Equal(t, tt.body, restored)
+		})
+	}
+}
+
+func TestSignedRequestKeyUnknownToken(t *testing.T) {
+	setupSignedTokenDB(t)
+
+	body := []byte(`{"model":"gpt-4o"}`)
+	_, err := signedRequestKey(signedContext(999, "sk-unknown", "unknown-token-1", body, int64(len(body))))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "无效的令牌", err.Error())
+	}
+}
+
+func TestSignedRequestKeyTokenLimit(t *testing.T) {
+	setupSignedTokenDB(t)
+
+	// 令牌的请求体限制比全局设置更小时按令牌的限制
+	setting := model.TokenSetting{}
+	setting.Limits.RequestLimit.MaxBodySize = 1
+	token := &model.Token{UserId: 1, Name: "limited", Setting: database.JSONType[model.TokenSetting]{JSONType: datatypes.NewJSONType(setting)}}
+	assert.Nil(t, token.Insert())
+
+	body := []byte(`{"model":"gpt-4o","prompt":"` + strings.Repeat("a", 2048) + `"}`)
+	_, err := signedRequestKey(signedContext(token.Id, token.PlainKey, "token-limit-1", body, -1))
+	assert.Equal(t, errSignedBodyTooLarge, err)
+}
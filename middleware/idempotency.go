@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"one-api/common"
+	"one-api/common/logger"
+	"one-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentResponseSize = 1 << 20
+)
+
+// idempotencyWriter 记录响应内容，请求成功后保存到幂等键
+type idempotencyWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency 请求带有 Idempotency-Key 时，同一用户使用相同的键重复请求会直接返回第一次成功的响应
+// 相同的键用于不同的请求时返回 422，第一次请求还在处理时返回 409，失败的请求不保存，可以用同一个键重试
+func Idempotency() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			common.APIRespondWithError(c, http.StatusBadRequest, errors.New("Idempotency-Key 过长"))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			common.APIRespondWithError(c, http.StatusBadRequest, err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		fingerprint := hex.EncodeToString(hash.Sum(nil))
+
+		record, completed, err := model.BeginIdempotentRequest(c.GetInt("id"), key, fingerprint)
+		switch {
+		case errors.Is(err, model.ErrIdempotencyKeyMismatch):
+			common.APIRespondWithError(c, http.StatusUnprocessableEntity, err)
+			c.Abort()
+			return
+		case errors.Is(err, model.ErrIdempotencyKeyProcessing):
+			common.APIRespondWithError(c, http.StatusConflict, err)
+			c.Abort()
+			return
+		case err != nil:
+			common.APIRespondWithError(c, http.StatusInternalServerError, err)
+			c.Abort()
+			return
+		case completed:
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Data(record.StatusCode, "application/json; charset=utf-8", []byte(record.Response))
+			c.Abort()
+			return
+		}
+
+		writer := &idempotencyWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		defer func() {
+			// 处理时 panic 也要释放幂等键
+			if r := recover(); r != nil {
+				_ = model.ReleaseIdempotentRequest(record)
+				panic(r)
+			}
+		}()
+		c.Next()
+
+		if idempotentSucceeded(writer.Status(), writer.body.Bytes()) {
+			err = model.CompleteIdempotentRequest(record, writer.Status(), writer.body.String())
+		} else {
+			err = model.ReleaseIdempotentRequest(record)
+		}
+		if err != nil {
+			logger.LogError(c.Request.Context(), "failed to save idempotency key: "+err.Error())
+		}
+	}
+}
+
+// idempotentSucceeded 管理接口出错时也返回 200，需要检查 success 字段
+func idempotentSucceeded(status int, body []byte) bool {
+	if status < 200 || status >= 300 || len(body) > maxIdempotentResponseSize {
+		return false
+	}
+	var response struct {
+		Success bool `json:"success"`
+	}
+	return json.Unmarshal(body, &response) == nil && response.Success
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"one-api/common/logger"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InFlightRequest 正在处理的中继请求
+type InFlightRequest struct {
+	RequestId string  `json:"request_id"`
+	Method    string  `json:"method"`
+	Path      string  `json:"path"`
+	UserId    int     `json:"user_id"`
+	TokenName string  `json:"token_name"`
+	ChannelId int     `json:"channel_id"`
+	Model     string  `json:"model"`
+	Stream    bool    `json:"stream"`
+	StartedAt int64   `json:"started_at"`
+	Age       float64 `json:"age"` // 已处理的时间，单位为秒
+}
+
+// inFlightEntry 后续中间件可能替换 c.Request，方法和路径在开始时记录
+type inFlightEntry struct {
+	c       *gin.Context
+	method  string
+	path    string
+	started time.Time
+}
+
+var inFlight = struct {
+	sync.Mutex
+	seq      atomic.Uint64
+	requests map[uint64]*inFlightEntry
+}{requests: make(map[uint64]*inFlightEntry)}
+
+// TrackInFlight 记录正在处理的请求，用于排查卡住的流式响应和泄漏的请求
+func TrackInFlight() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := inFlight.seq.Add(1)
+		inFlight.Lock()
+		inFlight.requests[id] = &inFlightEntry{c: c, method: c.Request.Method, path: c.Request.URL.Path, started: time.Now()}
+		inFlight.Unlock()
+
+		// 请求结束后 gin 会复用 Context，必须在返回前移除
+		defer func() {
+			inFlight.Lock()
+			delete(inFlight.requests, id)
+			inFlight.Unlock()
+		}()
+
+		c.Next()
+	}
+}
+
+// InFlightCount 正在处理的请求数
+func InFlightCount() int {
+	inFlight.Lock()
+	defer inFlight.Unlock()
+	return len(inFlight.requests)
+}
+
+// GetInFlightRequests 正在处理的请求，处理时间最长的在前
+func GetInFlightRequests() []*InFlightRequest {
+	now := time.Now()
+
+	inFlight.Lock()
+	requests := make([]*InFlightRequest, 0, len(inFlight.requests))
+	for _, entry := range inFlight.requests {
+		c := entry.c
+		model := c.GetString("original_model")
+		if model == "" {
+			model = c.GetString("new_model")
+		}
+		requests = append(requests, &InFlightRequest{
+			RequestId: c.GetString(logger.RequestIdKey),
+			Method:    entry.method,
+			Path:      entry.path,
+			UserId:    c.GetInt("id"),
+			TokenName: c.GetString("token_name"),
+			ChannelId: c.GetInt("channel_id"),
+			Model:     model,
+			Stream:    c.GetBool("is_stream"),
+			StartedAt: entry.started.Unix(),
+			Age:       now.Sub(entry.started).Seconds(),
+		})
+	}
+	inFlight.Unlock()
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Age > requests[j].Age
+	})
+	return requests
+}
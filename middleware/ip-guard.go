@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"one-api/common/ipguard"
+	"one-api/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPGuard 拒绝黑名单和自动封禁中的 IP，白名单中的 IP 直接放行
+func IPGuard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ip := c.ClientIP()
+		if model.IPRulesInstance.IsAllowed(ip) {
+			c.Next()
+			return
+		}
+
+		if model.IPRulesInstance.IsBlocked(ip) || ipguard.IsBanned(ip) {
+			abortWithMessage(c, http.StatusForbidden, "当前 IP 已被禁止访问")
+			return
+		}
+
+		c.Next()
+	}
+}
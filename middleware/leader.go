@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"one-api/common/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LeaderOnly 只允许在主节点上执行
+func LeaderOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.IsMasterNode {
+			c.JSON(http.StatusOK, gin.H{
+				"success": false,
+				"message": "该操作只能在主节点上执行",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 )
 
 var timeFormat = "2006-01-02T15:04:05.000Z"
@@ -88,17 +89,21 @@ func memoryRateLimiter(c *gin.Context, maxRequestNum int, duration int64, mark s
 }
 
 func rateLimitFactory(maxRequestNum int, duration int64, mark string) func(c *gin.Context) {
+	// It's safe to call multi times.
+	inMemoryRateLimiter.Init(config.RateLimitKeyExpirationDuration)
 	if config.RedisEnabled {
+		// Redis 暂时不可用时使用本实例的限流
 		return func(c *gin.Context) {
-			redisRateLimiter(c, maxRequestNum, duration, mark)
-		}
-	} else {
-		// It's safe to call multi times.
-		inMemoryRateLimiter.Init(config.RateLimitKeyExpirationDuration)
-		return func(c *gin.Context) {
-			memoryRateLimiter(c, maxRequestNum, duration, mark)
+			if redis.Available() {
+				redisRateLimiter(c, maxRequestNum, duration, mark)
+			} else {
+				memoryRateLimiter(c, maxRequestNum, duration, mark)
+			}
 		}
 	}
+	return func(c *gin.Context) {
+		memoryRateLimiter(c, maxRequestNum, duration, mark)
+	}
 }
 
 func GlobalWebRateLimit() func(c *gin.Context) {
@@ -120,3 +125,13 @@ func DownloadRateLimit() func(c *gin.Context) {
 func UploadRateLimit() func(c *gin.Context) {
 	return rateLimitFactory(UploadRateLimitNum, UploadRateLimitDuration, "UP")
 }
+
+// allowTrialRequest 试用令牌绑定了 IP，按 IP 限制每分钟请求数
+func allowTrialRequest(c *gin.Context) bool {
+	rpm := viper.GetInt("trial.rpm")
+	if rpm <= 0 {
+		return true
+	}
+	rateLimitFactory(rpm, 60, "TR")(c)
+	return !c.IsAborted()
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sandboxRoutes 沙盒令牌可以访问的路由，其余路由会请求上游或计费，沙盒令牌一律拒绝
+var sandboxRoutes = map[string]bool{
+	"POST /v1/chat/completions":      true,
+	"POST /v1/completions":           true,
+	"POST /v1/embeddings":            true,
+	"GET /v1/models":                 true,
+	"GET /v1/models/:model":          true,
+	"GET /v1/capabilities":           true,
+	"POST /v1/cost/estimate":         true,
+	"POST /v1/echo":                  true,
+	"POST /v1/echo/chat/completions": true,
+}
+
+// checkSandboxRoute 沙盒令牌不检查剩余额度，只能访问由 sandboxRelay 模拟响应或不计费的路由
+func checkSandboxRoute(c *gin.Context) bool {
+	if sandboxRoutes[c.Request.Method+" "+c.FullPath()] {
+		return true
+	}
+	abortWithMessage(c, http.StatusForbidden, "沙盒令牌只支持聊天、补全和向量接口")
+	return false
+}
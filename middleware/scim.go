@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"one-api/common/config"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SCIMAuth 使用 SCIMToken 认证身份提供方，未设置令牌时 SCIM 接口不可用
+func SCIMAuth() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if config.SCIMToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(config.SCIMToken)) != 1 {
+			c.Header("Content-Type", "application/scim+json")
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"schemas": []string{"urn:ietf:params:scim:api:messages:2.0:Error"},
+				"status":  "401",
+				"detail":  "invalid SCIM token",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
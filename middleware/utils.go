@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"one-api/common/i18n"
 	"one-api/common/logger"
 	"one-api/common/utils"
 
@@ -11,7 +12,7 @@ import (
 func abortWithMessage(c *gin.Context, statusCode int, message string) {
 	c.JSON(statusCode, gin.H{
 		"error": gin.H{
-			"message": utils.MessageWithRequestId(message, c.GetString(logger.RequestIdKey)),
+			"message": utils.MessageWithRequestId(i18n.T(c, message), c.GetString(logger.RequestIdKey)),
 			"type":    "one_hub_error",
 		},
 	})
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"one-api/model"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// vectorStoreRequest Assistants 接口中引用向量库的字段
+type vectorStoreRequest struct {
+	ToolResources struct {
+		FileSearch struct {
+			VectorStoreIds []string `json:"vector_store_ids"`
+		} `json:"file_search"`
+	} `json:"tool_resources"`
+	Tools []struct {
+		VectorStoreIds []string `json:"vector_store_ids"`
+	} `json:"tools"`
+}
+
+// VectorStoreChannel 请求用到已记录的向量库时转发到向量库所在的渠道，已指定渠道时不处理
+func VectorStoreChannel() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetInt("specific_channel_id") > 0 {
+			c.Next()
+			return
+		}
+
+		var vectorStoreIds []string
+		if id := vectorStoreIdFromPath(c.Request.URL.Path); id != "" {
+			vectorStoreIds = append(vectorStoreIds, id)
+		}
+		if c.Request.Method == http.MethodPost && c.ContentType() == "application/json" {
+			vectorStoreIds = append(vectorStoreIds, vectorStoreIdsFromBody(c)...)
+		}
+
+		channelId, err := model.GetVectorStoreChannelId(c.GetInt("id"), vectorStoreIds)
+		if err != nil {
+			abortWithMessage(c, http.StatusBadRequest, err.Error())
+			return
+		}
+		if channelId > 0 {
+			c.Set("specific_channel_id", channelId)
+		}
+		c.Next()
+	}
+}
+
+func vectorStoreIdFromPath(path string) string {
+	_, rest, found := strings.Cut(path, "/vector_stores/")
+	if !found {
+		return ""
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	return id
+}
+
+// vectorStoreIdsFromBody 读取请求体后放回，后续仍按原样转发
+func vectorStoreIdsFromBody(c *gin.Context) []string {
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body.Close()
+	c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	if err != nil {
+		return nil
+	}
+
+	var request vectorStoreRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		return nil
+	}
+	vectorStoreIds := request.ToolResources.FileSearch.VectorStoreIds
+	for _, tool := range request.Tools {
+		vectorStoreIds = append(vectorStoreIds, tool.VectorStoreIds...)
+	}
+	return vectorStoreIds
+}
@@ -0,0 +1,266 @@
+package model
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/encryption"
+	"one-api/common/secrets"
+	"one-api/common/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const BackupVersion = 1
+
+// 备份中密钥类字段的处理方式
+const (
+	BackupSecretsExcluded  = "excluded"
+	BackupSecretsEncrypted = "encrypted"
+)
+
+var ErrBackupTargetNotEmpty = errors.New("当前部署已有渠道、令牌或用户，只能恢复到新部署")
+
+// Backup 渠道、令牌、用户、配置和价格的备份
+// 密钥类字段（渠道 key、令牌 key、用户密码和 access token、以 Token/Secret 结尾的配置）不导出，或使用口令加密后导出
+// 令牌 key 需要新部署使用相同的 user_token_secret 和 hashids_salt 才能继续使用
+type Backup struct {
+	Version   int        `json:"version"`
+	CreatedAt int64      `json:"created_at"`
+	Secrets   string     `json:"secrets"`
+	Salt      string     `json:"salt,omitempty"`
+	Channels  []*Channel `json:"channels"`
+	Tokens    []*Token   `json:"tokens"`
+	// 令牌 key 的哈希，按顺序对应 Tokens，令牌的 JSON 中不包含 key
+	TokenKeys  []string     `json:"token_keys,omitempty"`
+	Users      []*User      `json:"users"`
+	Options    []*Option    `json:"options"`
+	Prices     []*Price     `json:"prices"`
+	UserGroups []*UserGroup `json:"user_groups"`
+}
+
+// IsSecretOption 配置项是否为密钥，不在后台展示，也不明文导出
+func IsSecretOption(key string) bool {
+	return strings.HasSuffix(key, "Token") || strings.HasSuffix(key, "Secret")
+}
+
+// CreateBackup 在同一个只读事务中读取所有数据，passphrase 为空时不导出密钥
+func CreateBackup(passphrase string) (*Backup, error) {
+	backup := &Backup{
+		Version:   BackupVersion,
+		CreatedAt: utils.GetTimestamp(),
+		Secrets:   BackupSecretsExcluded,
+	}
+
+	// SQLite 的事务本身是串行化的，不支持指定隔离级别
+	var opts []*sql.TxOptions
+	if !common.UsingSQLite {
+		opts = append(opts, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	}
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Order("id").Find(&backup.Channels).Error; err != nil {
+			return err
+		}
+		if err := tx.Order("id").Find(&backup.Tokens).Error; err != nil {
+			return err
+		}
+		for _, token := range backup.Tokens {
+			backup.TokenKeys = append(backup.TokenKeys, token.Key)
+		}
+		if err := tx.Order("id").Find(&backup.Users).Error; err != nil {
+			return err
+		}
+		if err := tx.Find(&backup.Options).Error; err != nil {
+			return err
+		}
+		if err := tx.Find(&backup.Prices).Error; err != nil {
+			return err
+		}
+		return tx.Order("id").Find(&backup.UserGroups).Error
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if passphrase == "" {
+		for _, field := range backup.secretFields() {
+			*field = ""
+		}
+		return backup, nil
+	}
+
+	salt, err := encryption.NewSalt()
+	if err != nil {
+		return nil, err
+	}
+	cipher, err := encryption.NewPassphraseCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range backup.secretFields() {
+		if *field, err = cipher.Encrypt(*field); err != nil {
+			return nil, err
+		}
+	}
+	backup.Secrets = BackupSecretsEncrypted
+	backup.Salt = base64.StdEncoding.EncodeToString(salt)
+
+	return backup, nil
+}
+
+// secretFields 需要保护的字段，引用外部密钥的渠道 key 不是密钥本身，原样导出
+func (b *Backup) secretFields() []*string {
+	fields := make([]*string, 0)
+	for _, channel := range b.Channels {
+		if !secrets.IsReference(channel.Key) {
+			fields = append(fields, &channel.Key)
+		}
+	}
+	for i := range b.TokenKeys {
+		fields = append(fields, &b.TokenKeys[i])
+	}
+	for _, user := range b.Users {
+		fields = append(fields, &user.Password, &user.AccessToken)
+	}
+	for _, option := range b.Options {
+		if IsSecretOption(option.Key) {
+			fields = append(fields, &option.Value)
+		}
+	}
+	return fields
+}
+
+// decryptSecrets 解密或补全密钥，未导出密钥时：
+// 渠道被手动禁用，令牌由 restoreTokenKeys 重新生成 key，用户重新生成 access token，超级管理员沿用当前部署的密码，其它用户需要重置密码
+func (b *Backup) decryptSecrets(passphrase, rootPassword string) error {
+	switch b.Secrets {
+	case BackupSecretsEncrypted:
+		salt, err := base64.StdEncoding.DecodeString(b.Salt)
+		if err != nil {
+			return fmt.Errorf("invalid backup salt: %w", err)
+		}
+		cipher, err := encryption.NewPassphraseCipher(passphrase, salt)
+		if err != nil {
+			return err
+		}
+		for _, field := range b.secretFields() {
+			if *field, err = cipher.Decrypt(*field); err != nil {
+				return err
+			}
+		}
+		return nil
+	case BackupSecretsExcluded:
+		for _, channel := range b.Channels {
+			if channel.Key == "" {
+				channel.Status = config.ChannelStatusManuallyDisabled
+			}
+		}
+		for _, user := range b.Users {
+			user.AccessToken = utils.GetUUID()
+			if user.Role == config.RoleRootUser {
+				user.Password = rootPassword
+			}
+		}
+		options := make([]*Option, 0, len(b.Options))
+		for _, option := range b.Options {
+			if !IsSecretOption(option.Key) {
+				options = append(options, option)
+			}
+		}
+		b.Options = options
+		return nil
+	default:
+		return fmt.Errorf("unknown backup secrets mode: %s", b.Secrets)
+	}
+}
+
+// RestoreBackup 恢复到新部署，新部署只允许有初始化时创建的超级管理员，恢复后替换所有用户、配置和价格
+func RestoreBackup(backup *Backup, passphrase string) error {
+	if backup.Version <= 0 || backup.Version > BackupVersion {
+		return fmt.Errorf("unsupported backup version: %d", backup.Version)
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		var channels, tokens, users int64
+		if err := tx.Unscoped().Model(&Channel{}).Count(&channels).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&Token{}).Count(&tokens).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&User{}).Count(&users).Error; err != nil {
+			return err
+		}
+		if channels > 0 || tokens > 0 || users > 1 {
+			return ErrBackupTargetNotEmpty
+		}
+
+		var rootPassword string
+		if err := tx.Model(&User{}).Where("role = ?", config.RoleRootUser).Select("password").Find(&rootPassword).Error; err != nil {
+			return err
+		}
+		if err := backup.decryptSecrets(passphrase, rootPassword); err != nil {
+			return err
+		}
+		if err := backup.restoreTokenKeys(); err != nil {
+			return err
+		}
+
+		global := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Unscoped()
+		for _, table := range []any{&User{}, &Option{}, &Price{}, &UserGroup{}} {
+			if err := global.Delete(table).Error; err != nil {
+				return err
+			}
+		}
+
+		// 按列写入，保留零值和备份中的令牌 key，渠道 key 使用当前部署的主密钥加密
+		tables := []struct{ model, rows any }{
+			{&User{}, backup.Users},
+			{&Channel{}, backup.Channels},
+			{&Token{}, backup.Tokens},
+			{&Option{}, backup.Options},
+			{&Price{}, backup.Prices},
+			{&UserGroup{}, backup.UserGroups},
+		}
+		for _, table := range tables {
+			if err := insertModelRows(tx, table.model, table.rows, 100); err != nil {
+				return err
+			}
+		}
+
+		if common.UsingPostgreSQL {
+			for _, table := range []string{"users", "channels", "tokens", "user_groups"} {
+				if err := resetSequence(tx, table); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// restoreTokenKeys 写回令牌 key 的哈希，未导出密钥或旧版本备份中没有哈希的令牌重新生成 key
+func (b *Backup) restoreTokenKeys() error {
+	for i, token := range b.Tokens {
+		if i < len(b.TokenKeys) && b.TokenKeys[i] != "" {
+			token.Key = b.TokenKeys[i]
+			continue
+		}
+		key, err := common.GenerateToken(token.Id, token.UserId)
+		if err != nil {
+			return err
+		}
+		token.Key = HashTokenKey(key)
+		token.KeyPrefix = TokenKeyPrefix(key)
+	}
+	return nil
+}
+
+// resetSequence 显式写入 id 后 PostgreSQL 的自增序列不会变化，需要设置为当前最大 id
+func resetSequence(tx *gorm.DB, table string) error {
+	return tx.Exec(fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%s', 'id'), COALESCE((SELECT MAX(id) FROM %s), 0) + 1, false)", table, table)).Error
+}
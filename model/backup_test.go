@@ -0,0 +1,44 @@
+package model
+
+import (
+	"testing"
+
+	"one-api/common/encryption"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupBackupDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(&Channel{}, &Token{}, &User{}, &Option{}, &Price{}, &UserGroup{}))
+	return db
+}
+
+func TestRestoreBackupKeepsChannelKeyEncrypted(t *testing.T) {
+	setupChannelKeyEncryption(t)
+
+	oldDB := DB
+	t.Cleanup(func() { DB = oldDB })
+
+	DB = setupBackupDB(t)
+	channel := &Channel{Name: "openai", Key: "sk-backup-channel-key"}
+	assert.Nil(t, DB.Create(channel).Error)
+
+	backup, err := CreateBackup("passphrase")
+	assert.Nil(t, err)
+
+	DB = setupBackupDB(t)
+	assert.Nil(t, RestoreBackup(backup, "passphrase"))
+
+	var stored string
+	assert.Nil(t, DB.Raw("SELECT `key` FROM channels WHERE id = ?", channel.Id).Scan(&stored).Error)
+	assert.True(t, encryption.IsEncrypted(stored))
+	assert.NotContains(t, stored, "sk-backup-channel-key")
+
+	restored, err := GetChannelById(channel.Id)
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-backup-channel-key", restored.Key)
+}
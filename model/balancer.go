@@ -11,6 +11,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/spf13/viper"
 )
 
 type ChannelChoice struct {
@@ -22,7 +24,8 @@ type ChannelChoice struct {
 type ChannelsChooser struct {
 	sync.RWMutex
 	Channels  map[int]*ChannelChoice
-	Rule      map[string]map[string][][]int // group -> model -> priority -> channelIds
+	Rule      map[string]map[string][][]int        // group -> model -> priority -> channelIds
+	Pools     map[string]map[string][]*channelPool // 与 Rule 结构相同，用于按权重抽样
 	Match     []string
 	Cooldowns sync.Map
 
@@ -43,6 +46,13 @@ func FilterChannelTypes(channelTypes []int) ChannelsFilterFunc {
 	}
 }
 
+// FilterPathPrefix 只保留开放了该路径前缀的渠道
+func FilterPathPrefix(prefix string) ChannelsFilterFunc {
+	return func(_ int, choice *ChannelChoice) bool {
+		return choice.Channel.PathPrefix != prefix
+	}
+}
+
 func FilterOnlyChat() ChannelsFilterFunc {
 	return func(channelId int, choice *ChannelChoice) bool {
 		return choice.Channel.OnlyChat
@@ -55,6 +65,34 @@ func FilterDisabledStream(modelName string) ChannelsFilterFunc {
 	}
 }
 
+// FilterTenant 过滤其它租户的私有渠道，共享渠道（tenant_id 为 0）对所有用户可用
+func FilterTenant(tenantId int) ChannelsFilterFunc {
+	return func(_ int, choice *ChannelChoice) bool {
+		return choice.Channel.TenantId != 0 && choice.Channel.TenantId != tenantId
+	}
+}
+
+// FilterBoundChannels 令牌绑定了渠道时过滤其它渠道
+func FilterBoundChannels(channelIds []int) ChannelsFilterFunc {
+	return func(channelId int, _ *ChannelChoice) bool {
+		return !utils.Contains(channelId, channelIds)
+	}
+}
+
+// FilterChannelTag 过滤标签不一致的渠道
+func FilterChannelTag(tag string) ChannelsFilterFunc {
+	return func(_ int, choice *ChannelChoice) bool {
+		return choice.Channel.Tag != tag
+	}
+}
+
+// FilterChannelRegion 过滤区域不一致的渠道
+func FilterChannelRegion(region string) ChannelsFilterFunc {
+	return func(_ int, choice *ChannelChoice) bool {
+		return !strings.EqualFold(choice.Channel.Region, region)
+	}
+}
+
 func init() {
 	// 每小时清理一次过期的冷却时间
 	go func() {
@@ -65,25 +103,46 @@ func init() {
 	}()
 }
 
+type cooldownKey struct {
+	channelId int
+	modelName string
+}
+
 func (cc *ChannelsChooser) SetCooldowns(channelId int, modelName string) bool {
-	if channelId == 0 || modelName == "" || config.RetryCooldownSeconds == 0 {
+	return cc.SetCooldownsWithRetryAfter(channelId, modelName, 0)
+}
+
+// SetCooldownsWithRetryAfter 冻结渠道的模型，冻结时间取 RetryCooldownSeconds 和上游 Retry-After 中较大的一个
+// 上游给出的时间会加上最多 20% 的随机抖动，避免所有请求在同一时刻重新打到同一个 key
+func (cc *ChannelsChooser) SetCooldownsWithRetryAfter(channelId int, modelName string, retryAfter time.Duration) bool {
+	if channelId == 0 || modelName == "" {
 		return false
 	}
 
-	key := fmt.Sprintf("%d:%s", channelId, modelName)
-	nowTime := time.Now().Unix()
+	cooldown := time.Duration(config.RetryCooldownSeconds) * time.Second
+	if retryAfter > 0 {
+		if maxRetryAfter := time.Duration(viper.GetInt("retry_after_max")) * time.Second; maxRetryAfter > 0 && retryAfter > maxRetryAfter {
+			retryAfter = maxRetryAfter
+		}
+		retryAfter += time.Duration(rand.Int63n(int64(retryAfter)/5 + 1))
+		cooldown = max(cooldown, retryAfter)
+	}
+	if cooldown <= 0 {
+		return false
+	}
 
-	cooldownTime, exists := cc.Cooldowns.Load(key)
-	if exists && nowTime < cooldownTime.(int64) {
+	key := cooldownKey{channelId, modelName}
+	until := time.Now().Add(cooldown).Unix()
+	if cooldownTime, exists := cc.Cooldowns.Load(key); exists && cooldownTime.(int64) >= until {
 		return true
 	}
 
-	cc.Cooldowns.LoadOrStore(key, nowTime+int64(config.RetryCooldownSeconds))
+	cc.Cooldowns.Store(key, until)
 	return true
 }
 
 func (cc *ChannelsChooser) IsInCooldown(channelId int, modelName string) bool {
-	key := fmt.Sprintf("%d:%s", channelId, modelName)
+	key := cooldownKey{channelId, modelName}
 
 	cooldownTime, exists := cc.Cooldowns.Load(key)
 	if !exists {
@@ -123,6 +182,27 @@ func (cc *ChannelsChooser) Enable(channelId int) {
 	cc.Channels[channelId].Disable = false
 }
 
+// Has 判断渠道是否在缓存中（只有启用状态的渠道会被加载）
+func (cc *ChannelsChooser) Has(channelId int) bool {
+	cc.RLock()
+	defer cc.RUnlock()
+	_, ok := cc.Channels[channelId]
+	return ok
+}
+
+// EnabledCount 缓存中未被禁用的渠道数
+func (cc *ChannelsChooser) EnabledCount() int {
+	cc.RLock()
+	defer cc.RUnlock()
+	count := 0
+	for _, choice := range cc.Channels {
+		if !choice.Disable {
+			count++
+		}
+	}
+	return count
+}
+
 func (cc *ChannelsChooser) ChangeStatus(channelId int, status bool) {
 	if status {
 		cc.Enable(channelId)
@@ -131,50 +211,81 @@ func (cc *ChannelsChooser) ChangeStatus(channelId int, status bool) {
 	}
 }
 
-func (cc *ChannelsChooser) balancer(channelIds []int, filters []ChannelsFilterFunc, modelName string) *Channel {
-	totalWeight := 0
+// 按权重抽样的次数，抽中的渠道都不可用时退化为线性扫描
+const poolSampleAttempts = 3
 
-	validChannels := make([]*ChannelChoice, 0, len(channelIds))
-	for _, channelId := range channelIds {
-		choice, ok := cc.Channels[channelId]
-		if !ok || choice.Disable {
-			continue
-		}
+const (
+	BalancerModeSmooth = "smooth" // 平滑加权轮询
+	BalancerModeRandom = "random" // 按权重随机
+)
 
-		if cc.IsInCooldown(channelId, modelName) {
-			continue
-		}
+func (cc *ChannelsChooser) balancer(pool *channelPool, filters []ChannelsFilterFunc, modelName string) *Channel {
+	if viper.GetString("balancer.mode") != BalancerModeRandom {
+		return pool.next(modelName, func(channelId int) *Channel {
+			return cc.available(channelId, filters, modelName)
+		})
+	}
 
-		isSkip := false
-		for _, filter := range filters {
-			if filter(channelId, choice) {
-				isSkip = true
-				break
+	if len(pool.ids) > 1 {
+		// 抽中不可用的渠道后重新抽样，结果仍按可用渠道的权重分布
+		for i := 0; i < poolSampleAttempts; i++ {
+			if channel := cc.available(pool.ids[pool.sample()], filters, modelName); channel != nil {
+				return channel
 			}
 		}
-		if isSkip {
+	}
+
+	return cc.scan(pool.ids, filters, modelName)
+}
+
+// available 渠道可用时返回渠道，否则返回 nil
+func (cc *ChannelsChooser) available(channelId int, filters []ChannelsFilterFunc, modelName string) *Channel {
+	choice, ok := cc.Channels[channelId]
+	if !ok || choice.Disable {
+		return nil
+	}
+
+	if cc.IsInCooldown(channelId, modelName) {
+		return nil
+	}
+
+	for _, filter := range filters {
+		if filter(channelId, choice) {
+			return nil
+		}
+	}
+
+	return choice.Channel
+}
+
+// scan 在所有可用渠道中按权重选择
+func (cc *ChannelsChooser) scan(channelIds []int, filters []ChannelsFilterFunc, modelName string) *Channel {
+	totalWeight := 0
+
+	validChannels := make([]*Channel, 0, len(channelIds))
+	for _, channelId := range channelIds {
+		channel := cc.available(channelId, filters, modelName)
+		if channel == nil {
 			continue
 		}
 
-		weight := int(*choice.Channel.Weight)
-		totalWeight += weight
-		validChannels = append(validChannels, choice)
+		totalWeight += int(*channel.Weight)
+		validChannels = append(validChannels, channel)
 	}
 
 	if len(validChannels) == 0 {
 		return nil
 	}
 
-	if len(validChannels) == 1 {
-		return validChannels[0].Channel
+	if len(validChannels) == 1 || totalWeight <= 0 {
+		return validChannels[0]
 	}
 
 	choiceWeight := rand.Intn(totalWeight)
-	for _, choice := range validChannels {
-		weight := int(*choice.Channel.Weight)
-		choiceWeight -= weight
+	for _, channel := range validChannels {
+		choiceWeight -= int(*channel.Weight)
 		if choiceWeight < 0 {
-			return choice.Channel
+			return channel
 		}
 	}
 
@@ -184,14 +295,32 @@ func (cc *ChannelsChooser) balancer(channelIds []int, filters []ChannelsFilterFu
 func (cc *ChannelsChooser) Next(group, modelName string, filters ...ChannelsFilterFunc) (*Channel, error) {
 	cc.RLock()
 	defer cc.RUnlock()
-	if _, ok := cc.Rule[group]; !ok {
+
+	// 先跳过余额不足的渠道，再使用这些渠道指定的备用分组，都不可用时仍使用余额不足的渠道
+	sufficient := append(filters[:len(filters):len(filters)], FilterLowBalance())
+	channel, err := cc.next(group, modelName, sufficient)
+	if err == nil {
+		return channel, nil
+	}
+	for _, backupGroup := range cc.lowBalanceGroups(group, modelName) {
+		if channel, err := cc.next(backupGroup, modelName, sufficient); err == nil {
+			return channel, nil
+		}
+	}
+
+	return cc.next(group, modelName, filters)
+}
+
+// groupModelPools 分组中模型按优先级排列的渠道，没有精确匹配时使用通配符模型
+func (cc *ChannelsChooser) groupModelPools(group, modelName string) ([]*channelPool, error) {
+	if _, ok := cc.Pools[group]; !ok {
 		return nil, errors.New("group not found")
 	}
 
-	channelsPriority, ok := cc.Rule[group][modelName]
+	channelsPriority, ok := cc.Pools[group][modelName]
 	if !ok {
 		matchModel := utils.GetModelsWithMatch(&cc.Match, modelName)
-		channelsPriority, ok = cc.Rule[group][matchModel]
+		channelsPriority, ok = cc.Pools[group][matchModel]
 		if !ok {
 			return nil, errors.New("model not found")
 		}
@@ -200,6 +329,25 @@ func (cc *ChannelsChooser) Next(group, modelName string, filters ...ChannelsFilt
 	if len(channelsPriority) == 0 {
 		return nil, errors.New("channel not found")
 	}
+	return channelsPriority, nil
+}
+
+// next 调用方需要持有读锁
+func (cc *ChannelsChooser) next(group, modelName string, filters []ChannelsFilterFunc) (*Channel, error) {
+	channelsPriority, err := cc.groupModelPools(group, modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	// 先跳过自动降级的渠道，都不可用时再使用
+	if AutoPriorityEnabled() {
+		healthy := append(filters[:len(filters):len(filters)], FilterDegraded(modelName))
+		for _, priority := range channelsPriority {
+			if channel := cc.balancer(priority, healthy, modelName); channel != nil {
+				return channel, nil
+			}
+		}
+	}
 
 	for _, priority := range channelsPriority {
 		channel := cc.balancer(priority, filters, modelName)
@@ -211,6 +359,19 @@ func (cc *ChannelsChooser) Next(group, modelName string, filters ...ChannelsFilt
 	return nil, errors.New("channel not found")
 }
 
+// FirstAvailable 按顺序返回第一个可用的渠道，不检查渠道是否提供该模型
+func (cc *ChannelsChooser) FirstAvailable(channelIds []int, modelName string, filters ...ChannelsFilterFunc) *Channel {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	for _, channelId := range channelIds {
+		if channel := cc.available(channelId, filters, modelName); channel != nil {
+			return channel
+		}
+	}
+	return nil
+}
+
 func (cc *ChannelsChooser) GetGroupModels(group string) ([]string, error) {
 	cc.RLock()
 	defer cc.RUnlock()
@@ -227,6 +388,23 @@ func (cc *ChannelsChooser) GetGroupModels(group string) ([]string, error) {
 	return models, nil
 }
 
+// GroupModelChannels 分组中模型当前可用的渠道，按优先级排列
+func (cc *ChannelsChooser) GroupModelChannels(group, modelName string) []*Channel {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	var channels []*Channel
+	for _, channelIds := range cc.Rule[group][modelName] {
+		for _, channelId := range channelIds {
+			if channel := cc.available(channelId, nil, modelName); channel != nil {
+				channels = append(channels, channel)
+			}
+		}
+	}
+
+	return channels
+}
+
 func (cc *ChannelsChooser) GetModelsGroups() map[string]map[string]bool {
 	cc.RLock()
 	defer cc.RUnlock()
@@ -247,11 +425,53 @@ func (cc *ChannelsChooser) GetChannel(channelId int) *Channel {
 
 var ChannelGroup = ChannelsChooser{}
 
+// Refresh 重新加载单个渠道
+// 只有分组、模型、优先级、权重、状态都未变化时才原地替换，否则需要重建路由表，退化为全量加载
+func (cc *ChannelsChooser) Refresh(channelId int) {
+	channel := &Channel{}
+	if err := DB.First(channel, "id = ?", channelId).Error; err != nil {
+		cc.Load()
+		return
+	}
+
+	cc.Lock()
+	choice, ok := cc.Channels[channelId]
+	if !ok || channel.Status != config.ChannelStatusEnabled || !sameRouting(choice.Channel, channel) {
+		cc.Unlock()
+		cc.Load()
+		return
+	}
+
+	channel.SetProxy()
+	if *channel.Weight == 0 {
+		channel.Weight = &config.DefaultChannelWeight
+	}
+	choice.Channel = channel
+	cc.Unlock()
+	logger.SysLog(fmt.Sprintf("channel #%d refreshed", channelId))
+}
+
+// sameRouting 判断两个渠道的路由相关配置是否相同
+func sameRouting(a, b *Channel) bool {
+	return a.Group == b.Group &&
+		a.Models == b.Models &&
+		a.GetPriority() == b.GetPriority() &&
+		effectiveWeight(a) == effectiveWeight(b)
+}
+
+func effectiveWeight(channel *Channel) uint {
+	if channel.Weight == nil || *channel.Weight == 0 {
+		return config.DefaultChannelWeight
+	}
+	return *channel.Weight
+}
+
 func (cc *ChannelsChooser) Load() {
 	var channels []*Channel
 	DB.Where("status = ?", config.ChannelStatusEnabled).Find(&channels)
 
 	newGroup := make(map[string]map[string][][]int)
+	newPools := make(map[string]map[string][]*channelPool)
 	newChannels := make(map[int]*ChannelChoice)
 	newMatch := make(map[string]bool)
 	newModelGroup := make(map[string]map[string]bool)
@@ -264,6 +484,10 @@ func (cc *ChannelsChooser) Load() {
 
 	// 处理每个channel
 	for _, channel := range channels {
+		if channel.KeyDecryptFailed() {
+			logger.SysError(fmt.Sprintf("channel %d is skipped: %s", channel.Id, ErrChannelKeyUndecryptable.Error()))
+			continue
+		}
 		channel.SetProxy()
 		if *channel.Weight == 0 {
 			channel.Weight = &config.DefaultChannelWeight
@@ -318,6 +542,7 @@ func (cc *ChannelsChooser) Load() {
 		// 初始化group和model的map
 		if _, ok := newGroup[key.group]; !ok {
 			newGroup[key.group] = make(map[string][][]int)
+			newPools[key.group] = make(map[string][]*channelPool)
 		}
 
 		// 获取所有优先级并排序（从大到小）
@@ -331,11 +556,14 @@ func (cc *ChannelsChooser) Load() {
 
 		// 按优先级顺序构建[][]int
 		var channelsList [][]int
+		var pools []*channelPool
 		for _, priority := range priorities {
 			channelsList = append(channelsList, priorityMap[priority])
+			pools = append(pools, newChannelPool(priorityMap[priority], newChannels))
 		}
 
 		newGroup[key.group][key.model] = channelsList
+		newPools[key.group][key.model] = pools
 	}
 
 	// 构建newMatchList
@@ -347,6 +575,7 @@ func (cc *ChannelsChooser) Load() {
 	// 更新ChannelsChooser
 	cc.Lock()
 	cc.Rule = newGroup
+	cc.Pools = newPools
 	cc.Channels = newChannels
 	cc.Match = newMatchList
 	cc.ModelGroup = newModelGroup
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// channelPool 同一优先级的渠道，使用 alias 方法按权重 O(1) 抽样，或者按平滑加权轮询选择
+type channelPool struct {
+	ids   []int
+	prob  []float64
+	alias []int
+
+	mu      sync.Mutex
+	weights []int
+	current []int // 平滑加权轮询的当前权重
+}
+
+// newChannelPool 构建 alias 表，权重总和为 0 时等概率抽样
+func newChannelPool(ids []int, channels map[int]*ChannelChoice) *channelPool {
+	n := len(ids)
+	pool := &channelPool{
+		ids:     ids,
+		prob:    make([]float64, n),
+		alias:   make([]int, n),
+		weights: make([]int, n),
+		current: make([]int, n),
+	}
+	if n == 0 {
+		return pool
+	}
+
+	weights := make([]float64, n)
+	total := 0.0
+	for i, id := range ids {
+		if choice, ok := channels[id]; ok && choice.Channel.Weight != nil && *choice.Channel.Weight > 0 {
+			weights[i] = float64(*choice.Channel.Weight)
+		}
+		total += weights[i]
+	}
+
+	// 权重总和为 0 时轮询等权
+	for i := range weights {
+		pool.weights[i] = int(weights[i])
+		if total <= 0 {
+			pool.weights[i] = 1
+		}
+	}
+
+	// 缩放到平均值为 1
+	scaled := make([]float64, n)
+	small := make([]int, 0, n)
+	large := make([]int, 0, n)
+	for i := range weights {
+		if total > 0 {
+			scaled[i] = weights[i] * float64(n) / total
+		} else {
+			scaled[i] = 1
+		}
+		if scaled[i] < 1 {
+			small = append(small, i)
+		} else {
+			large = append(large, i)
+		}
+	}
+
+	for len(small) > 0 && len(large) > 0 {
+		s := small[len(small)-1]
+		small = small[:len(small)-1]
+		l := large[len(large)-1]
+		large = large[:len(large)-1]
+
+		pool.prob[s] = scaled[s]
+		pool.alias[s] = l
+
+		scaled[l] = scaled[l] + scaled[s] - 1
+		if scaled[l] < 1 {
+			small = append(small, l)
+		} else {
+			large = append(large, l)
+		}
+	}
+
+	// 剩余项由于浮点误差可能落在任意一侧，概率均为 1
+	for _, i := range large {
+		pool.prob[i] = 1
+	}
+	for _, i := range small {
+		pool.prob[i] = 1
+	}
+
+	return pool
+}
+
+// sample 返回按权重抽中的下标
+func (p *channelPool) sample() int {
+	i := rand.Intn(len(p.ids))
+	if rand.Float64() < p.prob[i] {
+		return i
+	}
+	return p.alias[i]
+}
+
+// 权重放大后再乘以调整系数，避免取整丢失精度
+const weightRatioScale = 100
+
+// next 平滑加权轮询（nginx 算法），只在可用的渠道中选择，低流量时分布也与权重一致
+// 每次选择时可用渠道的当前权重加上各自的权重，选出当前权重最大的渠道并减去可用渠道的权重总和，
+// 权重按自动调整的系数缩放
+func (p *channelPool) next(modelName string, available func(channelId int) *Channel) *Channel {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	total := 0
+	best := -1
+	var channel *Channel
+	for i, id := range p.ids {
+		candidate := available(id)
+		if candidate == nil || p.weights[i] <= 0 {
+			continue
+		}
+		weight := p.weights[i] * weightRatioScale
+		if adjustment := GetChannelAdjustment(id, modelName); adjustment != nil {
+			weight = max(int(float64(weight)*adjustment.WeightRatio), 1)
+		}
+		p.current[i] += weight
+		total += weight
+		if best == -1 || p.current[i] > p.current[best] {
+			best = i
+			channel = candidate
+		}
+	}
+
+	if best != -1 {
+		p.current[best] -= total
+	}
+	return channel
+}
@@ -0,0 +1,305 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"one-api/common/utils"
+	"strconv"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 批量更新的 Redis 预写日志
+// 每条记录在写入内存的同时累加到本实例的 pending 哈希中，写库前将 pending 移入 flushing 哈希并分配标识，
+// 每写入一条就从 flushing 中删除。实例崩溃后，其它实例在其心跳过期后接管剩余记录并写库。
+// flushing 中的记录写库时在同一个事务中保存回执，写库成功但删除记录前崩溃时，按回执跳过，不会重复写入。
+// 所有 key 使用同一个 hash tag，集群模式下合并脚本跨实例操作的 key 位于同一个槽。
+const (
+	batchWALPendingKey   = "{batch_update}:%s:%d"
+	batchWALFlushingKey  = "{batch_update}:%s:%d:flushing"
+	batchWALNonceKey     = "{batch_update}:%s:%d:flushing:nonce"
+	batchWALAliveKey     = "{batch_update}:%s:alive"
+	batchWALInstancesKey = "{batch_update}:instances"
+)
+
+var batchWALEnabled = false
+
+// batchWALRecord 待写库的记录，nonce 为移入 flushing 时分配的标识，没有标识的记录不保存回执
+type batchWALRecord struct {
+	value int
+	nonce string
+}
+
+// BatchUpdateReceipt 预写日志记录的写库回执，和更新在同一个事务中写入，从 flushing 中删除记录后删除
+type BatchUpdateReceipt struct {
+	ReceiptKey string `json:"receipt_key" gorm:"type:varchar(128);primaryKey"`
+	CreatedAt  int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+// mergeWALScript 将 KEYS[1] 累加到 KEYS[2] 并删除 KEYS[1]，返回 KEYS[2] 的全部内容
+var mergeWALScript = redis.NewScript(`
+	local fields = redis.call("HGETALL", KEYS[1])
+	for i = 1, #fields, 2 do
+		redis.call("HINCRBY", KEYS[2], fields[i], fields[i + 1])
+	end
+	redis.call("DEL", KEYS[1])
+	return redis.call("HGETALL", KEYS[2])
+`)
+
+// rotateWALScript 将 KEYS[1] 中不在 KEYS[2] 的记录移入 KEYS[2]，并在 KEYS[3] 中记录标识 ARGV[1]，
+// 仍在 KEYS[2] 中的记录留到写库确认后再移入，保证同一个标识对应的值不变，返回 KEYS[2] 和 KEYS[3] 的全部内容
+var rotateWALScript = redis.NewScript(`
+	local fields = redis.call("HGETALL", KEYS[1])
+	for i = 1, #fields, 2 do
+		if redis.call("HEXISTS", KEYS[2], fields[i]) == 0 then
+			redis.call("HSET", KEYS[2], fields[i], fields[i + 1])
+			redis.call("HSET", KEYS[3], fields[i], ARGV[1])
+			redis.call("HDEL", KEYS[1], fields[i])
+		end
+	end
+	return {redis.call("HGETALL", KEYS[2]), redis.call("HGETALL", KEYS[3])}
+`)
+
+// popWALScript 取出并删除 KEYS[1] 和 KEYS[2]
+var popWALScript = redis.NewScript(`
+	local result = {redis.call("HGETALL", KEYS[1]), redis.call("HGETALL", KEYS[2])}
+	redis.call("DEL", KEYS[1], KEYS[2])
+	return result
+`)
+
+func initBatchWAL() {
+	if !config.RedisEnabled || !viper.GetBool("batch_update_wal") {
+		return
+	}
+
+	batchWALEnabled = true
+	refreshBatchWALAlive()
+	recoverBatchWAL()
+	logger.SysLog("batch update write-ahead log enabled")
+}
+
+func batchWALAliveTTL() time.Duration {
+	ttl := time.Duration(config.BatchUpdateInterval*3) * time.Second
+	if ttl < 30*time.Second {
+		ttl = 30 * time.Second
+	}
+	return ttl
+}
+
+// refreshBatchWALAlive 登记本实例并刷新心跳
+func refreshBatchWALAlive() {
+	ctx := context.Background()
+	client := redis.GetRedisClient()
+	if err := client.SAdd(ctx, batchWALInstancesKey, config.InstanceID).Err(); err != nil {
+		logger.SysError("failed to register batch update wal: " + err.Error())
+		return
+	}
+	if err := client.Set(ctx, fmt.Sprintf(batchWALAliveKey, config.InstanceID), time.Now().Unix(), batchWALAliveTTL()).Err(); err != nil {
+		logger.SysError("failed to refresh batch update wal: " + err.Error())
+	}
+}
+
+// recoverBatchWAL 接管心跳已过期实例的未写库记录
+func recoverBatchWAL() {
+	ctx := context.Background()
+	client := redis.GetRedisClient()
+
+	instances, err := client.SMembers(ctx, batchWALInstancesKey).Result()
+	if err != nil {
+		logger.SysError("failed to list batch update wal: " + err.Error())
+		return
+	}
+
+	for _, instance := range instances {
+		if instance == config.InstanceID {
+			continue
+		}
+
+		alive, err := client.Exists(ctx, fmt.Sprintf(batchWALAliveKey, instance)).Result()
+		if err != nil || alive > 0 {
+			continue
+		}
+
+		recovered := true
+		for i := 0; i < BatchUpdateTypeCount; i++ {
+			dst := fmt.Sprintf(batchWALPendingKey, config.InstanceID, i)
+			if err := mergeWALScript.Run(ctx, client, []string{fmt.Sprintf(batchWALPendingKey, instance, i), dst}).Err(); err != nil {
+				logger.SysError("failed to recover batch update wal: " + err.Error())
+				recovered = false
+			}
+			if err := recoverFlushingWAL(instance, i); err != nil {
+				logger.SysError("failed to recover batch update wal: " + err.Error())
+				recovered = false
+			}
+		}
+
+		if recovered {
+			client.SRem(ctx, batchWALInstancesKey, instance)
+			logger.SysLog("recovered batch update wal from instance " + instance)
+		}
+	}
+}
+
+// recoverFlushingWAL 接管已崩溃实例 flushing 中的记录，有回执的记录已经写库，其余记录移入本实例的 pending
+// 取出后、移入前本实例也崩溃时这些记录会丢失，宁可少写也不重复写入
+func recoverFlushingWAL(instance string, type_ int) error {
+	ctx := context.Background()
+	client := redis.GetRedisClient()
+	keys := []string{fmt.Sprintf(batchWALFlushingKey, instance, type_), fmt.Sprintf(batchWALNonceKey, instance, type_)}
+	result, err := popWALScript.Run(ctx, client, keys).Slice()
+	if err != nil {
+		return err
+	}
+	records := parseWALRecords(result)
+	if len(records) == 0 {
+		return nil
+	}
+
+	dst := fmt.Sprintf(batchWALPendingKey, config.InstanceID, type_)
+	for id, record := range records {
+		if record.nonce != "" {
+			key := batchReceiptKey(type_, id, record.nonce)
+			var count int64
+			if err := DB.Model(&BatchUpdateReceipt{}).Where("receipt_key = ?", key).Count(&count).Error; err != nil {
+				// 无法确认时放回，避免丢失
+				logger.SysError("failed to check batch update receipt: " + err.Error())
+			} else if count > 0 {
+				DB.Delete(&BatchUpdateReceipt{}, "receipt_key = ?", key)
+				continue
+			}
+		}
+		if err := client.HIncrBy(ctx, dst, strconv.Itoa(id), int64(record.value)).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendBatchWAL 累加到本实例的 pending，返回错误时记录未写入预写日志，由调用方保存在内存中
+func appendBatchWAL(type_ int, id int, value int) error {
+	key := fmt.Sprintf(batchWALPendingKey, config.InstanceID, type_)
+	return redis.GetRedisClient().HIncrBy(context.Background(), key, strconv.Itoa(id), int64(value)).Err()
+}
+
+// rotateBatchWAL 将 pending 移入 flushing，返回需要写库的全部记录（包含之前写库失败的记录）
+func rotateBatchWAL(type_ int) (map[int]batchWALRecord, error) {
+	keys := []string{
+		fmt.Sprintf(batchWALPendingKey, config.InstanceID, type_),
+		fmt.Sprintf(batchWALFlushingKey, config.InstanceID, type_),
+		fmt.Sprintf(batchWALNonceKey, config.InstanceID, type_),
+	}
+	result, err := rotateWALScript.Run(context.Background(), redis.GetRedisClient(), keys, utils.GetUUID()).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return parseWALRecords(result), nil
+}
+
+// parseWALRecords 解析脚本返回的 flushing 和标识
+func parseWALRecords(result []any) map[int]batchWALRecord {
+	hash := func(index int) []string {
+		if index >= len(result) {
+			return nil
+		}
+		items, _ := result[index].([]any)
+		fields := make([]string, 0, len(items))
+		for _, item := range items {
+			field, _ := item.(string)
+			fields = append(fields, field)
+		}
+		return fields
+	}
+
+	values, nonces := hash(0), hash(1)
+	nonceMap := make(map[string]string, len(nonces)/2)
+	for i := 0; i+1 < len(nonces); i += 2 {
+		nonceMap[nonces[i]] = nonces[i+1]
+	}
+
+	records := make(map[int]batchWALRecord, len(values)/2)
+	for i := 0; i+1 < len(values); i += 2 {
+		id, err := strconv.Atoi(values[i])
+		if err != nil {
+			continue
+		}
+		value, err := strconv.Atoi(values[i+1])
+		if err != nil {
+			continue
+		}
+		records[id] = batchWALRecord{value: value, nonce: nonceMap[values[i]]}
+	}
+	return records
+}
+
+func batchReceiptKey(type_ int, id int, nonce string) string {
+	return fmt.Sprintf("%d:%d:%s", type_, id, nonce)
+}
+
+// applyBatchRecordOnce 写库，有标识的记录和回执在同一个事务中写入，已有回执时跳过
+func applyBatchRecordOnce(type_ int, id int, record batchWALRecord) error {
+	if record.nonce == "" {
+		return applyBatchRecord(DB, type_, id, record.value)
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		receipt := &BatchUpdateReceipt{ReceiptKey: batchReceiptKey(type_, id, record.nonce), CreatedAt: utils.GetTimestamp()}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(receipt)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return applyBatchRecord(tx, type_, id, record.value)
+	})
+}
+
+// ackBatchWAL 从 flushing 中删除已写库的记录，删除成功后回执不再需要
+func ackBatchWAL(type_ int, id int, nonce string) {
+	ctx := context.Background()
+	client := redis.GetRedisClient()
+	field := strconv.Itoa(id)
+	if err := client.HDel(ctx, fmt.Sprintf(batchWALFlushingKey, config.InstanceID, type_), field).Err(); err != nil {
+		logger.SysError("failed to ack batch update wal: " + err.Error())
+		return
+	}
+	client.HDel(ctx, fmt.Sprintf(batchWALNonceKey, config.InstanceID, type_), field)
+	if nonce != "" {
+		DB.Delete(&BatchUpdateReceipt{}, "receipt_key = ?", batchReceiptKey(type_, id, nonce))
+	}
+}
+
+// DeleteExpiredBatchUpdateReceipts 删除确认时未能删除的回执
+func DeleteExpiredBatchUpdateReceipts() (int64, error) {
+	result := DB.Where("created_at < ?", time.Now().Add(-billingRecordTTL()).Unix()).Delete(&BatchUpdateReceipt{})
+	return result.RowsAffected, result.Error
+}
+
+// closeBatchWAL 停机时注销本实例，仍有未写库的记录时保留登记，由其它实例接管
+func closeBatchWAL() {
+	if !batchWALEnabled {
+		return
+	}
+
+	ctx := context.Background()
+	client := redis.GetRedisClient()
+	client.Del(ctx, fmt.Sprintf(batchWALAliveKey, config.InstanceID))
+
+	for i := 0; i < BatchUpdateTypeCount; i++ {
+		for _, key := range []string{fmt.Sprintf(batchWALFlushingKey, config.InstanceID, i), fmt.Sprintf(batchWALPendingKey, config.InstanceID, i)} {
+			if n, err := client.HLen(ctx, key).Result(); err != nil || n > 0 {
+				logger.SysError("batch update wal still has pending records, leaving them for recovery")
+				return
+			}
+		}
+	}
+
+	client.SRem(ctx, batchWALInstancesKey, config.InstanceID)
+}
@@ -0,0 +1,72 @@
+package model
+
+import (
+	"errors"
+	"one-api/common/utils"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errConsumeLogRecorded = errors.New("consume log already recorded")
+
+// BillingRecord 请求结算的幂等记录，同一个幂等键（请求 ID）只结算和记录一次消费日志，
+// 结算协程重复执行或重试时跳过。先占用记录再调整额度，结算中途崩溃时最多少扣费，不会重复扣费
+type BillingRecord struct {
+	Id        int    `json:"id"`
+	RequestId string `json:"request_id" gorm:"type:varchar(64);uniqueIndex"`
+	UserId    int    `json:"user_id"`
+	Quota     int    `json:"quota"`
+	Logged    bool   `json:"logged" gorm:"default:false"` // 已记录消费日志
+	CreatedAt int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+// billingRecordTTL 幂等记录的保留时间
+func billingRecordTTL() time.Duration {
+	return time.Duration(viper.GetInt("billing_idempotency_ttl")) * time.Hour
+}
+
+// ClaimBilling 占用请求的结算，返回 false 表示已经结算过，幂等键为空时不做检查
+func ClaimBilling(requestId string, userId int, quota int) (bool, error) {
+	if requestId == "" {
+		return true, nil
+	}
+
+	record := &BillingRecord{
+		RequestId: requestId,
+		UserId:    userId,
+		Quota:     quota,
+		CreatedAt: utils.GetTimestamp(),
+	}
+	result := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// createConsumeLog 写入消费日志，有幂等键时和结算记录在同一个事务中标记，已记录过时返回 errConsumeLogRecorded
+func createConsumeLog(idempotencyKey string, log *Log) error {
+	if idempotencyKey == "" {
+		return DB.Create(log).Error
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&BillingRecord{}).Where("request_id = ? AND logged = ?", idempotencyKey, false).Update("logged", true)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errConsumeLogRecorded
+		}
+		return tx.Create(log).Error
+	})
+}
+
+// DeleteExpiredBillingRecords 删除超过保留时间的幂等记录
+func DeleteExpiredBillingRecords() (int64, error) {
+	result := DB.Where("created_at < ?", time.Now().Add(-billingRecordTTL()).Unix()).Delete(&BillingRecord{})
+	return result.RowsAffected, result.Error
+}
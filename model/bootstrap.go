@@ -0,0 +1,239 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/utils"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// BootstrapConfig 首次启动时的初始化数据，来自 bootstrap.file 指定的文件或配置文件中的 bootstrap 节点
+// viper 会把 map 的键转为小写，所以配置项使用列表，模型映射和别名使用 JSON 字符串
+type BootstrapConfig struct {
+	Root     BootstrapRoot       `mapstructure:"root"`
+	Groups   []*BootstrapGroup   `mapstructure:"groups"`
+	Channels []*BootstrapChannel `mapstructure:"channels"`
+	Options  []*BootstrapOption  `mapstructure:"options"`
+}
+
+type BootstrapRoot struct {
+	Username    string `mapstructure:"username"`
+	Password    string `mapstructure:"password"`
+	Email       string `mapstructure:"email"`
+	AccessToken string `mapstructure:"access_token"`
+	Quota       int    `mapstructure:"quota"`
+}
+
+type BootstrapGroup struct {
+	Symbol          string   `mapstructure:"symbol"`
+	Name            string   `mapstructure:"name"`
+	Ratio           *float64 `mapstructure:"ratio"`
+	APIRate         int      `mapstructure:"api_rate"`
+	Public          bool     `mapstructure:"public"`
+	Models          string   `mapstructure:"models"`
+	ModelAliases    string   `mapstructure:"model_aliases"`
+	ModelClasses    string   `mapstructure:"model_classes"`
+	LanguageRouting string   `mapstructure:"language_routing"`
+	Parent          string   `mapstructure:"parent"`
+}
+
+type BootstrapChannel struct {
+	Name         string `mapstructure:"name"`
+	Type         int    `mapstructure:"type"`
+	Key          string `mapstructure:"key"`
+	BaseURL      string `mapstructure:"base_url"`
+	Models       string `mapstructure:"models"`
+	Group        string `mapstructure:"group"`
+	Tag          string `mapstructure:"tag"`
+	Other        string `mapstructure:"other"`
+	ModelMapping string `mapstructure:"model_mapping"`
+	TestModel    string `mapstructure:"test_model"`
+	Priority     int64  `mapstructure:"priority"`
+	Weight       uint   `mapstructure:"weight"`
+	Proxy        string `mapstructure:"proxy"`
+}
+
+type BootstrapOption struct {
+	Key   string `mapstructure:"key"`
+	Value string `mapstructure:"value"`
+}
+
+// loadBootstrapConfig 读取初始化数据，root 的字段可以用环境变量 BOOTSTRAP_ROOT_USERNAME 等覆盖
+func loadBootstrapConfig() (*BootstrapConfig, error) {
+	bootstrap := &BootstrapConfig{}
+	if file := viper.GetString("bootstrap.file"); file != "" {
+		v := viper.New()
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("failed to read bootstrap file: %w", err)
+		}
+		if err := v.Unmarshal(bootstrap); err != nil {
+			return nil, fmt.Errorf("invalid bootstrap file: %w", err)
+		}
+	} else if err := viper.UnmarshalKey("bootstrap", bootstrap); err != nil {
+		return nil, fmt.Errorf("invalid bootstrap config: %w", err)
+	}
+
+	overrides := map[string]*string{
+		"bootstrap.root.username":     &bootstrap.Root.Username,
+		"bootstrap.root.password":     &bootstrap.Root.Password,
+		"bootstrap.root.email":        &bootstrap.Root.Email,
+		"bootstrap.root.access_token": &bootstrap.Root.AccessToken,
+	}
+	for key, field := range overrides {
+		if value := viper.GetString(key); value != "" {
+			*field = value
+		}
+	}
+
+	return bootstrap, nil
+}
+
+// bootstrap 首次启动时创建超级管理员、分组、渠道和配置，未配置的部分使用默认值
+func bootstrap() error {
+	spec, err := loadBootstrapConfig()
+	if err != nil {
+		return err
+	}
+
+	root := User{
+		Username:    "root",
+		Role:        config.RoleRootUser,
+		Status:      config.UserStatusEnabled,
+		DisplayName: "Root User",
+		Email:       spec.Root.Email,
+		AccessToken: spec.Root.AccessToken,
+		Quota:       100000000,
+	}
+	if spec.Root.Username != "" {
+		root.Username = spec.Root.Username
+	}
+	if spec.Root.Quota > 0 {
+		root.Quota = spec.Root.Quota
+	}
+	if root.AccessToken == "" {
+		root.AccessToken = utils.GetUUID()
+	} else if len(root.AccessToken) > 32 {
+		return errors.New("bootstrap root access_token must not exceed 32 characters")
+	}
+
+	password := spec.Root.Password
+	if password == "" {
+		password = "123456"
+		logger.SysLog(fmt.Sprintf("no user exists, create a root user for you: username is %s, password is 123456", root.Username))
+	}
+	if root.Password, err = common.Password2Hash(password); err != nil {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&root).Error; err != nil {
+			return err
+		}
+
+		for _, group := range spec.Groups {
+			if err := bootstrapGroup(tx, group); err != nil {
+				return err
+			}
+		}
+
+		for _, spec := range spec.Channels {
+			channel := spec.toChannel()
+			if err := channel.ValidateProxy(); err != nil {
+				return fmt.Errorf("channel %s: %w", spec.Name, err)
+			}
+			if err := tx.Omit("UsedQuota").Create(channel).Error; err != nil {
+				return fmt.Errorf("channel %s: %w", spec.Name, err)
+			}
+		}
+
+		for _, option := range spec.Options {
+			if err := tx.Save(&Option{Key: option.Key, Value: option.Value}).Error; err != nil {
+				return err
+			}
+		}
+
+		if len(spec.Groups) > 0 || len(spec.Channels) > 0 || len(spec.Options) > 0 {
+			logger.SysLog(fmt.Sprintf("bootstrap completed: %d groups, %d channels, %d options", len(spec.Groups), len(spec.Channels), len(spec.Options)))
+		}
+		return nil
+	})
+}
+
+// bootstrapGroup 按标识创建或覆盖分组，迁移时已经创建了默认分组
+func bootstrapGroup(tx *gorm.DB, spec *BootstrapGroup) error {
+	if spec.Symbol == "" {
+		return errors.New("bootstrap group symbol is required")
+	}
+
+	enable := true
+	group := &UserGroup{
+		Symbol:          spec.Symbol,
+		Name:            spec.Name,
+		Ratio:           1,
+		APIRate:         spec.APIRate,
+		Public:          spec.Public,
+		Enable:          &enable,
+		Models:          spec.Models,
+		ModelAliases:    spec.ModelAliases,
+		ModelClasses:    spec.ModelClasses,
+		LanguageRouting: spec.LanguageRouting,
+		Parent:          spec.Parent,
+	}
+	if spec.Ratio != nil {
+		group.Ratio = *spec.Ratio
+	}
+	if group.Name == "" {
+		group.Name = spec.Symbol
+	}
+	if group.APIRate == 0 && group.Parent == "" {
+		group.APIRate = 600
+	}
+
+	var existing UserGroup
+	if err := tx.Where("symbol = ?", spec.Symbol).First(&existing).Error; err == nil {
+		group.Id = existing.Id
+		return tx.Select("*").Save(group).Error
+	}
+	return insertModelRows(tx, &UserGroup{}, []*UserGroup{group}, 1)
+}
+
+func (spec *BootstrapChannel) toChannel() *Channel {
+	channel := &Channel{
+		Type:        spec.Type,
+		Key:         spec.Key,
+		Status:      config.ChannelStatusEnabled,
+		Name:        spec.Name,
+		Models:      spec.Models,
+		Group:       spec.Group,
+		Tag:         spec.Tag,
+		Other:       spec.Other,
+		TestModel:   spec.TestModel,
+		PreCost:     config.PreCostDefault,
+		CreatedTime: utils.GetTimestamp(),
+	}
+	if channel.Group == "" {
+		channel.Group = "default"
+	}
+	if spec.BaseURL != "" {
+		channel.BaseURL = &spec.BaseURL
+	}
+	if spec.ModelMapping != "" {
+		channel.ModelMapping = &spec.ModelMapping
+	}
+	if spec.Proxy != "" {
+		channel.Proxy = &spec.Proxy
+	}
+	if spec.Priority != 0 {
+		channel.Priority = &spec.Priority
+	}
+	if spec.Weight != 0 {
+		channel.Weight = &spec.Weight
+	}
+	return channel
+}
@@ -3,6 +3,7 @@ package model
 import (
 	"context"
 	"fmt"
+	"one-api/common"
 	"one-api/common/cache"
 	"one-api/common/config"
 	"one-api/common/logger"
@@ -14,6 +15,7 @@ import (
 var (
 	TokenCacheSeconds           = 0
 	UserGroupCacheKey           = "user_group:%d"
+	UserTenantCacheKey          = "user_tenant:%d"
 	UserTokensKey               = "token:%s"
 	UsernameCacheKey            = "user_name:%d"
 	UserQuotaCacheKey           = "user_quota:%d"
@@ -24,13 +26,30 @@ var (
 	OldUserTokensCacheKey = "old_user_tokens_cache"
 )
 
+// redisUnavailable Redis 暂时不可用时记录该 key，恢复后从 Redis 中删除，调用方改用数据库
+func redisUnavailable(key string) bool {
+	if redis.Available() {
+		return false
+	}
+	redis.MarkStale(key)
+	return true
+}
+
+// tokenCacheKey 缓存键不包含 key 本身，新格式的 key 按令牌 ID，旧格式按前缀，读取后调用方需校验哈希
+func tokenCacheKey(key string) string {
+	if tokenId, _, err := common.ValidateToken(key); err == nil && tokenId > 0 {
+		return fmt.Sprintf(UserTokensKey, "id:"+strconv.Itoa(tokenId))
+	}
+	return fmt.Sprintf(UserTokensKey, "prefix:"+TokenKeyPrefix(key))
+}
+
 func CacheGetTokenByKey(key string) (*Token, error) {
 	if !config.RedisEnabled {
 		return GetTokenByKey(key)
 	}
 
 	token, err := cache.GetOrSetCache(
-		fmt.Sprintf(UserTokensKey, key),
+		tokenCacheKey(key),
 		time.Duration(TokenCacheSeconds)*time.Second,
 		func() (*Token, error) {
 			return GetTokenByKey(key)
@@ -60,8 +79,22 @@ func CacheGetUserGroup(id int) (group string, err error) {
 	return group, err
 }
 
-func CacheGetUserQuota(id int) (quota int, err error) {
+func CacheGetUserTenantId(id int) (tenantId int, err error) {
 	if !config.RedisEnabled {
+		return GetUserTenantId(id)
+	}
+
+	return cache.GetOrSetCache(
+		fmt.Sprintf(UserTenantCacheKey, id),
+		time.Duration(TokenCacheSeconds)*time.Second,
+		func() (int, error) {
+			return GetUserTenantId(id)
+		},
+		cache.CacheTimeout)
+}
+
+func CacheGetUserQuota(id int) (quota int, err error) {
+	if !config.RedisEnabled || !redis.Available() {
 		return GetUserQuota(id)
 	}
 	quotaString, err := redis.RedisGet(fmt.Sprintf(UserQuotaCacheKey, id))
@@ -81,7 +114,7 @@ func CacheGetUserQuota(id int) (quota int, err error) {
 }
 
 func CacheUpdateUserQuota(id int) error {
-	if !config.RedisEnabled {
+	if !config.RedisEnabled || redisUnavailable(fmt.Sprintf(UserQuotaCacheKey, id)) {
 		return nil
 	}
 	quota, err := GetUserQuota(id)
@@ -92,8 +125,52 @@ func CacheUpdateUserQuota(id int) error {
 	return err
 }
 
-func CacheDecreaseUserQuota(id int, quota int) error {
+var increaseIfExistsScript = redis.NewScript(`
+	if redis.call("EXISTS", KEYS[1]) == 1 then
+		return redis.call("INCRBY", KEYS[1], ARGV[1])
+	end
+	return 0
+`)
+
+// CacheIncreaseUserQuota 充值后同步增加缓存中的额度，缓存不存在时下次读取会从数据库加载
+// 批量更新模式下数据库写入有延迟，不能直接删除缓存
+func CacheIncreaseUserQuota(id int, quota int) error {
+	if !config.RedisEnabled || redisUnavailable(fmt.Sprintf(UserQuotaCacheKey, id)) {
+		return nil
+	}
+	return increaseIfExistsScript.Run(context.Background(), redis.GetRedisClient(), []string{fmt.Sprintf(UserQuotaCacheKey, id)}, quota).Err()
+}
+
+// InvalidateUserCache 删除用户的分组、租户、用户名、状态缓存，所有实例共用 Redis 缓存，删除后立即生效
+func InvalidateUserCache(id int) {
 	if !config.RedisEnabled {
+		return
+	}
+	for _, key := range []string{UserGroupCacheKey, UserTenantCacheKey, UsernameCacheKey, UserEnabledCacheKey} {
+		if err := cache.DeleteCache(fmt.Sprintf(key, id)); err != nil {
+			logger.SysError("Redis delete user cache error: " + err.Error())
+		}
+	}
+}
+
+// InvalidateTokenCache 删除令牌缓存
+func InvalidateTokenCache(token *Token) {
+	if !config.RedisEnabled || token == nil {
+		return
+	}
+	keys := []string{fmt.Sprintf(UserTokensKey, "id:"+strconv.Itoa(token.Id))}
+	if token.KeyPrefix != "" {
+		keys = append(keys, fmt.Sprintf(UserTokensKey, "prefix:"+token.KeyPrefix))
+	}
+	for _, key := range keys {
+		if err := cache.DeleteCache(key); err != nil {
+			logger.SysError("Redis delete token cache error: " + err.Error())
+		}
+	}
+}
+
+func CacheDecreaseUserQuota(id int, quota int) error {
+	if !config.RedisEnabled || redisUnavailable(fmt.Sprintf(UserQuotaCacheKey, id)) {
 		return nil
 	}
 	err := redis.RedisDecrease(fmt.Sprintf(UserQuotaCacheKey, id), int64(quota))
@@ -181,6 +258,9 @@ func CacheUpdateUserRealtimeQuota(id int, quota int) (int64, error) {
 		return 0, nil
 	}
 	key := fmt.Sprintf(UserRealtimeQuotaKey, id)
+	if redisUnavailable(key) {
+		return 0, nil
+	}
 
 	newValue, err := updateQuotaScript.Run(context.Background(), redis.GetRedisClient(), []string{key}, quota, int(UserRealtimeQuotaExpiration.Seconds())).Int64()
 	if err != nil {
@@ -200,6 +280,11 @@ func HandleOldTokenMaxId() {
 	//if exists {
 	//	return
 	//}
+	// 集合中保存 key 的前缀，先清除旧版本保存的明文 key
+	if err := redis.RedisDel(OldUserTokensCacheKey); err != nil {
+		logger.SysError("清除旧token集合失败: " + err.Error())
+	}
+
 	const batchSize = 1000
 	var offset int
 
@@ -209,7 +294,7 @@ func HandleOldTokenMaxId() {
 			Where("id <= ?", config.OldTokenMaxId).
 			Limit(batchSize).
 			Offset(offset).
-			Pluck("key", &tokenKeys)
+			Pluck("key_prefix", &tokenKeys)
 
 		if result.Error != nil {
 			logger.SysError("查询旧token失败: " + result.Error.Error())
@@ -3,9 +3,14 @@ package model
 import (
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"one-api/common/config"
+	"one-api/common/encryption"
 	"one-api/common/logger"
 	"one-api/common/redis"
+	"one-api/common/requester"
 	"one-api/common/utils"
 	"slices"
 	"strings"
@@ -17,7 +22,7 @@ import (
 type Channel struct {
 	Id                 int     `json:"id"`
 	Type               int     `json:"type" form:"type" gorm:"default:0"`
-	Key                string  `json:"key" form:"key" gorm:"type:text"`
+	Key                string  `json:"key" form:"key" gorm:"type:text;serializer:channel_key"`
 	Status             int     `json:"status" form:"status" gorm:"default:1"`
 	Name               string  `json:"name" form:"name" gorm:"index"`
 	Weight             *uint   `json:"weight" gorm:"default:1"`
@@ -41,13 +46,42 @@ type Channel struct {
 	OnlyChat           bool    `json:"only_chat" form:"only_chat" gorm:"default:false"`
 	PreCost            int     `json:"pre_cost" form:"pre_cost" gorm:"default:1"`
 	CompatibleResponse bool    `json:"compatible_response" gorm:"default:false"`
+	RawPassthrough     bool    `json:"raw_passthrough" form:"raw_passthrough" gorm:"default:false"`       // 原样转发请求和响应，只做鉴权、计费和日志，仅用于 OpenAI 兼容的上游，需要映射模型或审查内容的请求仍按常规处理
+	TenantId           int     `json:"tenant_id" form:"tenant_id" gorm:"index;default:0"`                 // 所属租户，0 为所有用户共享
+	Region             string  `json:"region" form:"region" gorm:"type:varchar(32);default:''"`           // 上游所在区域，客户端可通过请求头优先选择
+	PathPrefix         string  `json:"path_prefix" form:"path_prefix" gorm:"type:varchar(64);default:''"` // 通过 /proxy/<前缀>/ 转发任意上游路径，为空时不开放
+
+	// 余额低于阈值（美元）时优先使用其它渠道和 LowBalanceGroup 备用分组，为 0 时不检查
+	LowBalanceThreshold float64 `json:"low_balance_threshold" form:"low_balance_threshold" gorm:"default:0"`
+	LowBalanceGroup     string  `json:"low_balance_group" form:"low_balance_group" gorm:"type:varchar(32);default:''"`
+
+	// 外部系统（如 Terraform）中的 ID，按此 ID 创建或更新
+	ExternalId string `json:"external_id" form:"external_id" gorm:"type:varchar(255);index;default:''"`
 
 	DisabledStream *datatypes.JSONSlice[string] `json:"disabled_stream,omitempty" gorm:"type:json"`
+	// 上游对流式的支持，为空时按客户端请求发送，non_stream 和 stream 时在中继转换（仅聊天接口）
+	StreamMode string `json:"stream_mode" form:"stream_mode" gorm:"type:varchar(16);default:''"`
+
+	HTTPConfig *datatypes.JSONType[requester.HTTPClientConfig] `json:"http_config,omitempty" gorm:"type:json"`
+
+	// 注入到每个上游 JSON 请求体顶层的字段，覆盖请求中的同名字段
+	ExtraBody *datatypes.JSONType[map[string]any] `json:"extra_body,omitempty" gorm:"type:json"`
+
+	ModelSync *datatypes.JSONType[ModelSyncConfig] `json:"model_sync,omitempty" gorm:"type:json"`
+
+	// 启用时间段，为空时全天可用
+	Schedule *datatypes.JSONType[ChannelSchedule] `json:"schedule,omitempty" gorm:"type:json"`
 
 	Plugin    *datatypes.JSONType[PluginType] `json:"plugin" form:"plugin" gorm:"type:json"`
 	DeletedAt gorm.DeletedAt                  `json:"-" gorm:"index"`
+
+	// 每次修改加一，更新时传入读取到的版本，版本不一致说明已被他人修改，为 0 时不检查
+	Version int `json:"version" form:"version" gorm:"default:1"`
 }
 
+// ErrVersionConflict 记录已被其他管理员或自动任务修改
+var ErrVersionConflict = errors.New("数据已被他人修改，请刷新后重试")
+
 func (c *Channel) AllowStream(modelName string) bool {
 	if c.DisabledStream == nil {
 		return true
@@ -56,6 +90,20 @@ func (c *Channel) AllowStream(modelName string) bool {
 	return !slices.Contains(*c.DisabledStream, modelName)
 }
 
+const (
+	ChannelStreamModeNonStream = "non_stream" // 上游只支持非流式，流式请求由中继切分为 chunk
+	ChannelStreamModeStream    = "stream"     // 上游只支持流式，非流式请求由中继合并 chunk
+)
+
+// ValidateStreamMode 检查渠道的流式模式是否合法
+func (c *Channel) ValidateStreamMode() error {
+	switch c.StreamMode {
+	case "", ChannelStreamModeNonStream, ChannelStreamModeStream:
+		return nil
+	}
+	return fmt.Errorf("不支持的流式模式：%s", c.StreamMode)
+}
+
 type PluginType map[string]map[string]interface{}
 
 var allowedChannelOrderFields = map[string]bool{
@@ -79,8 +127,8 @@ type SearchChannelsParams struct {
 func GetChannelsList(params *SearchChannelsParams) (*DataResult[Channel], error) {
 	var channels []*Channel
 
-	db := DB.Omit("key")
-	tagDB := DB.Model(&Channel{}).Select("Max(id) as id").Where("tag != ''").Group("tag")
+	db := ReadDB().Omit("key")
+	tagDB := ReadDB().Model(&Channel{}).Select("Max(id) as id").Where("tag != ''").Group("tag")
 
 	if params.Type != 0 {
 		db = db.Where("type = ?", params.Type)
@@ -116,8 +164,17 @@ func GetChannelsList(params *SearchChannelsParams) (*DataResult[Channel], error)
 	}
 
 	if params.Key != "" {
-		db = db.Where(quotePostgresField("key")+" = ?", params.Key)
-		tagDB = tagDB.Where(quotePostgresField("key")+" = ?", params.Key)
+		if encryption.Enabled() {
+			ids, err := channelIdsByKey(params.Key)
+			if err != nil {
+				return nil, err
+			}
+			db = db.Where("id IN ?", ids)
+			tagDB = tagDB.Where("id IN ?", ids)
+		} else {
+			db = db.Where(quotePostgresField("key")+" = ?", params.Key)
+			tagDB = tagDB.Where(quotePostgresField("key")+" = ?", params.Key)
+		}
 	}
 
 	if params.TestModel != "" {
@@ -130,6 +187,16 @@ func GetChannelsList(params *SearchChannelsParams) (*DataResult[Channel], error)
 		tagDB = tagDB.Where("tag = ?", params.Tag)
 	}
 
+	if params.ExternalId != "" {
+		db = db.Where("external_id = ?", params.ExternalId)
+		tagDB = tagDB.Where("external_id = ?", params.ExternalId)
+	}
+
+	if params.TenantId != 0 {
+		db = db.Where("tenant_id = ?", params.TenantId)
+		tagDB = tagDB.Where("tenant_id = ?", params.TenantId)
+	}
+
 	switch params.FilterTag {
 	case 1:
 		db = db.Where("tag = ''")
@@ -155,6 +222,29 @@ func GetChannelById(id int) (*Channel, error) {
 	return &channel, err
 }
 
+// GetChannelByExternalId 按外部 ID 查询渠道
+func GetChannelByExternalId(externalId string) (*Channel, error) {
+	var channel Channel
+	err := DB.Where(&Channel{ExternalId: externalId}).First(&channel).Error
+	return &channel, err
+}
+
+// GetChannelsByExternalIdPrefix 外部 ID 以 prefix 开头的渠道，不包含 key
+func GetChannelsByExternalIdPrefix(prefix string) ([]*Channel, error) {
+	var channels []*Channel
+	if err := DB.Omit("key").Where("external_id LIKE ?", prefix+"%").Order("id").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	// LIKE 中的 % 和 _ 是通配符，再按前缀精确过滤
+	matched := channels[:0]
+	for _, channel := range channels {
+		if strings.HasPrefix(channel.ExternalId, prefix) {
+			matched = append(matched, channel)
+		}
+	}
+	return matched, nil
+}
+
 func GetChannelsByTag(tag string) ([]*Channel, error) {
 	var channels []*Channel
 	err := DB.Where("tag = ?", tag).Find(&channels).Error
@@ -256,6 +346,68 @@ func (c *Channel) SetProxy() {
 
 }
 
+// ValidateProxy 检查渠道代理地址是否合法
+func (c *Channel) ValidateProxy() error {
+	if c.Proxy == nil {
+		return nil
+	}
+
+	return utils.ValidateProxyURL(strings.TrimSpace(*c.Proxy))
+}
+
+// GetHTTPConfig 获取渠道的上游 HTTP 客户端配置
+func (channel *Channel) GetHTTPConfig() *requester.HTTPClientConfig {
+	if channel.HTTPConfig == nil {
+		return nil
+	}
+
+	httpConfig := channel.HTTPConfig.Data()
+	return &httpConfig
+}
+
+// GetModelHeaders 渠道自定义的上游请求头，覆盖供应商设置的同名请求头
+func (channel *Channel) GetModelHeaders() map[string]string {
+	if channel.ModelHeaders == nil || *channel.ModelHeaders == "" {
+		return nil
+	}
+
+	var headers map[string]string
+	if err := json.Unmarshal([]byte(*channel.ModelHeaders), &headers); err != nil {
+		return nil
+	}
+	return headers
+}
+
+// GetExtraBody 渠道注入到上游请求体的字段
+func (channel *Channel) GetExtraBody() map[string]any {
+	if channel.ExtraBody == nil {
+		return nil
+	}
+	return channel.ExtraBody.Data()
+}
+
+// ValidateInjection 检查自定义请求头和注入的请求体字段，model 和 stream 由中继决定，不能注入
+func (c *Channel) ValidateInjection() error {
+	if c.ModelHeaders != nil && *c.ModelHeaders != "" {
+		var headers map[string]string
+		if err := json.Unmarshal([]byte(*c.ModelHeaders), &headers); err != nil {
+			return fmt.Errorf("自定义请求头格式错误: %w", err)
+		}
+		for key := range headers {
+			if key == "" || strings.ContainsAny(key, " \t\r\n:") {
+				return fmt.Errorf("自定义请求头 %s 不合法", key)
+			}
+		}
+	}
+
+	for key := range c.GetExtraBody() {
+		if key == "" || key == "model" || key == "stream" {
+			return fmt.Errorf("不能注入请求体字段 %s", key)
+		}
+	}
+	return nil
+}
+
 func (channel *Channel) GetPriority() int64 {
 	if channel.Priority == nil {
 		return 0
@@ -301,28 +453,44 @@ func (channel *Channel) Update(overwrite bool) error {
 	err := channel.UpdateRaw(overwrite)
 
 	if err == nil {
-		ChannelGroup.Load()
+		ChannelGroup.Refresh(channel.Id)
 		if config.RedisEnabled {
-			_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+			_ = redis.RedisPublish(redis.RedisTopicChannelsSync, fmt.Sprintf("change:%d", channel.Id))
 		}
 	}
 
 	return err
 }
 
+// UpdateRaw channel.Version 不为 0 时检查版本，不一致时返回 ErrVersionConflict
 func (channel *Channel) UpdateRaw(overwrite bool) error {
-	var err error
-
+	tx := DB.Model(channel)
 	if overwrite {
-		err = DB.Model(channel).Select("*").Omit("UsedQuota").Updates(channel).Error
+		tx = tx.Select("*")
+	}
+	version := channel.Version
+	if version > 0 {
+		tx = tx.Where("version = ?", version)
+		channel.Version++
+		tx = tx.Omit("UsedQuota")
 	} else {
-		err = DB.Model(channel).Omit("UsedQuota").Updates(channel).Error
+		tx = tx.Omit("UsedQuota", "Version")
 	}
-	if err != nil {
-		return err
+
+	result := tx.Updates(channel)
+	if result.Error != nil {
+		channel.Version = version
+		return result.Error
+	}
+	if version > 0 && result.RowsAffected == 0 {
+		channel.Version = version
+		return ErrVersionConflict
+	}
+	if version == 0 {
+		DB.Model(&Channel{}).Where("id = ?", channel.Id).UpdateColumn("version", gorm.Expr("version + 1"))
 	}
 	DB.Model(channel).First(channel, "id = ?", channel.Id)
-	return err
+	return nil
 }
 
 func (channel *Channel) UpdateResponseTime(responseTime int64) {
@@ -336,13 +504,19 @@ func (channel *Channel) UpdateResponseTime(responseTime int64) {
 }
 
 func (channel *Channel) UpdateBalance(balance float64) {
+	wasLow := channel.IsLowBalance()
+	updatedTime := utils.GetTimestamp()
 	err := DB.Model(channel).Select("balance_updated_time", "balance").Updates(Channel{
-		BalanceUpdatedTime: utils.GetTimestamp(),
+		BalanceUpdatedTime: updatedTime,
 		Balance:            balance,
 	}).Error
 	if err != nil {
 		logger.SysError("failed to update balance: " + err.Error())
+		return
 	}
+	channel.Balance = balance
+	channel.BalanceUpdatedTime = updatedTime
+	channel.onBalanceChanged(wasLow)
 }
 
 func (channel *Channel) Delete() error {
@@ -364,6 +538,8 @@ func (channel *Channel) StatusToStr() string {
 		return "自动禁用"
 	case config.ChannelStatusManuallyDisabled:
 		return "手动禁用"
+	case config.ChannelStatusDraining:
+		return "排空中"
 	}
 
 	return "禁用"
@@ -371,7 +547,10 @@ func (channel *Channel) StatusToStr() string {
 
 func UpdateChannelStatusById(id int, status int) {
 	tx := DB.Begin()
-	err := tx.Model(&Channel{}).Where("id = ?", id).Update("status", status).Error
+	err := tx.Model(&Channel{}).Where("id = ?", id).Updates(map[string]any{
+		"status":  status,
+		"version": gorm.Expr("version + 1"),
+	}).Error
 	if err != nil {
 		logger.SysError("failed to update channel status: " + err.Error())
 		tx.Rollback()
@@ -380,9 +559,10 @@ func UpdateChannelStatusById(id int, status int) {
 
 	tx.Commit()
 
-	go ChannelGroup.ChangeStatus(id, status == config.ChannelStatusEnabled)
+	enabled := status == config.ChannelStatusEnabled
+	go ChannelGroup.ChangeStatus(id, enabled)
 	if config.RedisEnabled {
-		_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+		_ = redis.RedisPublish(redis.RedisTopicChannelsSync, fmt.Sprintf("status:%d:%t", id, enabled))
 	}
 }
 
@@ -391,14 +571,15 @@ func UpdateChannelUsedQuota(id int, quota int) {
 		addNewRecord(BatchUpdateTypeChannelUsedQuota, id, quota)
 		return
 	}
-	updateChannelUsedQuota(id, quota)
+	updateChannelUsedQuota(DB, id, quota)
 }
 
-func updateChannelUsedQuota(id int, quota int) {
-	err := DB.Model(&Channel{}).Where("id = ?", id).Update("used_quota", gorm.Expr("used_quota + ?", quota)).Error
+func updateChannelUsedQuota(db *gorm.DB, id int, quota int) error {
+	err := db.Model(&Channel{}).Where("id = ?", id).Update("used_quota", gorm.Expr("used_quota + ?", quota)).Error
 	if err != nil {
 		logger.SysError("failed to update channel used quota: " + err.Error())
 	}
+	return err
 }
 
 func DeleteDisabledChannel() (int64, error) {
@@ -415,6 +596,6 @@ type ChannelStatistics struct {
 }
 
 func GetStatisticsChannel() (statistics []*ChannelStatistics, err error) {
-	err = DB.Model(&Channel{}).Select("count(*) as total_channels, status").Group("status").Scan(&statistics).Error
+	err = ReadDB().Model(&Channel{}).Select("count(*) as total_channels, status").Group("status").Scan(&statistics).Error
 	return statistics, err
 }
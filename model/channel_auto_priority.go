@@ -0,0 +1,183 @@
+package model
+
+import (
+	"math"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 每个渠道和模型最多保留的样本数
+const maxChannelSamples = 1000
+
+type channelSample struct {
+	time    int64 // unix 秒
+	success bool
+	latency int64 // 毫秒
+}
+
+type channelModelKey struct {
+	channelId int
+	modelName string
+}
+
+// ChannelAdjustment 按最近的成功率和 p95 延迟对渠道的调整，只在本实例生效
+type ChannelAdjustment struct {
+	ChannelId   int     `json:"channel_id"`
+	Model       string  `json:"model"`
+	Samples     int     `json:"samples"`
+	SuccessRate float64 `json:"success_rate"`
+	P95Latency  int64   `json:"p95_latency"`  // 毫秒
+	WeightRatio float64 `json:"weight_ratio"` // 平滑加权轮询时权重乘以该系数
+	Degraded    bool    `json:"degraded"`     // 降级的渠道只在没有其它可用渠道时使用
+}
+
+type channelAutoPriority struct {
+	sync.Mutex
+	samples     map[channelModelKey][]channelSample
+	adjustments atomic.Pointer[map[channelModelKey]*ChannelAdjustment]
+}
+
+var autoPriority = &channelAutoPriority{samples: make(map[channelModelKey][]channelSample)}
+
+func AutoPriorityEnabled() bool {
+	return viper.GetBool("auto_priority.enable")
+}
+
+// RecordChannelResult 记录一次上游请求的结果，只统计上游的错误
+func RecordChannelResult(channelId int, modelName string, success bool, latency time.Duration) {
+	if !AutoPriorityEnabled() || channelId == 0 {
+		return
+	}
+
+	key := channelModelKey{channelId: channelId, modelName: modelName}
+	sample := channelSample{time: time.Now().Unix(), success: success, latency: latency.Milliseconds()}
+
+	autoPriority.Lock()
+	defer autoPriority.Unlock()
+	samples := append(autoPriority.samples[key], sample)
+	if len(samples) > maxChannelSamples {
+		samples = samples[len(samples)-maxChannelSamples:]
+	}
+	autoPriority.samples[key] = samples
+}
+
+// AdjustChannelPriorities 按统计窗口内的样本重新计算渠道的调整，样本不足的渠道恢复正常，
+// 降级的渠道流量很少，样本过期后自动恢复并重新评估
+func AdjustChannelPriorities() {
+	now := time.Now().Unix()
+	window := viper.GetInt64("auto_priority.window")
+	minSamples := viper.GetInt("auto_priority.min_samples")
+	minSuccessRate := viper.GetFloat64("auto_priority.min_success_rate")
+	maxP95 := viper.GetInt64("auto_priority.max_p95_latency")
+	minRatio := min(max(viper.GetFloat64("auto_priority.min_weight_ratio"), 0.01), 1)
+
+	adjustments := make(map[channelModelKey]*ChannelAdjustment)
+
+	autoPriority.Lock()
+	for key, samples := range autoPriority.samples {
+		start := sort.Search(len(samples), func(i int) bool {
+			return samples[i].time > now-window
+		})
+		samples = samples[start:]
+		if len(samples) == 0 {
+			delete(autoPriority.samples, key)
+			continue
+		}
+		autoPriority.samples[key] = samples
+		if len(samples) < minSamples {
+			continue
+		}
+
+		if adjustment := evaluateChannelSamples(samples, minSuccessRate, maxP95, minRatio); adjustment != nil {
+			adjustment.ChannelId = key.channelId
+			adjustment.Model = key.modelName
+			adjustments[key] = adjustment
+		}
+	}
+	autoPriority.Unlock()
+
+	autoPriority.adjustments.Store(&adjustments)
+}
+
+// AutomaticallyAdjustChannelPriorities 每个实例按自己的样本定期调整，interval 单位为秒
+func AutomaticallyAdjustChannelPriorities(interval int) {
+	if !AutoPriorityEnabled() || interval <= 0 {
+		return
+	}
+
+	for {
+		time.Sleep(time.Duration(interval) * time.Second)
+		AdjustChannelPriorities()
+	}
+}
+
+// evaluateChannelSamples 渠道正常时返回 nil
+func evaluateChannelSamples(samples []channelSample, minSuccessRate float64, maxP95 int64, minRatio float64) *ChannelAdjustment {
+	latencies := make([]int64, 0, len(samples))
+	for _, sample := range samples {
+		if sample.success {
+			latencies = append(latencies, sample.latency)
+		}
+	}
+
+	adjustment := &ChannelAdjustment{
+		Samples:     len(samples),
+		SuccessRate: float64(len(latencies)) / float64(len(samples)),
+	}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		adjustment.P95Latency = latencies[int(math.Ceil(float64(len(latencies))*0.95))-1]
+	}
+
+	ratio := adjustment.SuccessRate
+	slow := maxP95 > 0 && adjustment.P95Latency > maxP95
+	if slow {
+		ratio *= float64(maxP95) / float64(adjustment.P95Latency)
+	}
+	adjustment.WeightRatio = min(max(ratio, minRatio), 1)
+	adjustment.Degraded = adjustment.SuccessRate < minSuccessRate || slow
+
+	if !adjustment.Degraded && adjustment.WeightRatio >= 1 {
+		return nil
+	}
+	return adjustment
+}
+
+// GetChannelAdjustment 渠道正常时返回 nil
+func GetChannelAdjustment(channelId int, modelName string) *ChannelAdjustment {
+	adjustments := autoPriority.adjustments.Load()
+	if adjustments == nil || !AutoPriorityEnabled() {
+		return nil
+	}
+	return (*adjustments)[channelModelKey{channelId: channelId, modelName: modelName}]
+}
+
+// GetChannelAdjustments 当前所有的调整，按渠道排序
+func GetChannelAdjustments() []*ChannelAdjustment {
+	list := make([]*ChannelAdjustment, 0)
+	if adjustments := autoPriority.adjustments.Load(); adjustments != nil && AutoPriorityEnabled() {
+		for _, adjustment := range *adjustments {
+			list = append(list, adjustment)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].ChannelId != list[j].ChannelId {
+			return list[i].ChannelId < list[j].ChannelId
+		}
+		return list[i].Model < list[j].Model
+	})
+	return list
+}
+
+// FilterDegraded 过滤降级的渠道
+func FilterDegraded(modelName string) ChannelsFilterFunc {
+	return func(channelId int, _ *ChannelChoice) bool {
+		adjustment := GetChannelAdjustment(channelId, modelName)
+		return adjustment != nil && adjustment.Degraded
+	}
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/redis"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// BatchPatchChannelsParams 批量修改渠道，只修改提供的字段
+type BatchPatchChannelsParams struct {
+	Ids          []int              `json:"ids" binding:"required"`
+	Priority     *int64             `json:"priority"`
+	Weight       *uint              `json:"weight"`
+	Group        *string            `json:"group"`         // 替换渠道的分组，多个用逗号分隔
+	AddModels    []string           `json:"add_models"`    // 追加的模型，已存在的忽略
+	RemoveModels []string           `json:"remove_models"` // 移除的模型
+	ModelMapping map[string]*string `json:"model_mapping"` // 合并到原有映射，值为 null 时删除该映射
+}
+
+func (params *BatchPatchChannelsParams) isEmpty() bool {
+	return params.Priority == nil && params.Weight == nil && params.Group == nil &&
+		len(params.AddModels) == 0 && len(params.RemoveModels) == 0 && len(params.ModelMapping) == 0
+}
+
+// patchModels 在逗号分隔的模型列表中追加和移除模型，保持原有顺序
+func patchModels(models string, add, remove []string) string {
+	var list []string
+	for _, model := range strings.Split(models, ",") {
+		if model = strings.TrimSpace(model); model != "" && !slices.Contains(remove, model) && !slices.Contains(list, model) {
+			list = append(list, model)
+		}
+	}
+	for _, model := range add {
+		if model = strings.TrimSpace(model); model != "" && !slices.Contains(remove, model) && !slices.Contains(list, model) {
+			list = append(list, model)
+		}
+	}
+	return strings.Join(list, ",")
+}
+
+// patchModelMapping 合并模型映射，值为 nil 时删除
+func patchModelMapping(mapping string, patch map[string]*string) (string, error) {
+	merged := make(map[string]string)
+	if mapping != "" && mapping != "{}" {
+		if err := json.Unmarshal([]byte(mapping), &merged); err != nil {
+			return "", err
+		}
+	}
+	for from, to := range patch {
+		if to == nil {
+			delete(merged, from)
+		} else {
+			merged[from] = *to
+		}
+	}
+	if len(merged) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(merged)
+	return string(data), err
+}
+
+// BatchPatchChannels 在一个事务中修改多个渠道，任一渠道失败时全部回滚，tenantId 不为 0 时只能修改该租户的渠道
+func BatchPatchChannels(params *BatchPatchChannelsParams, tenantId int) (int64, error) {
+	if len(params.Ids) == 0 {
+		return 0, errors.New("ids不能为空")
+	}
+	if params.isEmpty() {
+		return 0, errors.New("没有需要修改的字段")
+	}
+	if params.Group != nil && strings.TrimSpace(*params.Group) == "" {
+		return 0, errors.New("分组不能为空")
+	}
+
+	ids := slices.Compact(slices.Sorted(slices.Values(params.Ids)))
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		var channels []*Channel
+		query := tx.Select("id", "models", "model_mapping").Where("id IN ?", ids)
+		if tenantId != 0 {
+			query = query.Where("tenant_id = ?", tenantId)
+		}
+		if err := query.Find(&channels).Error; err != nil {
+			return err
+		}
+		if len(channels) != len(ids) {
+			return errors.New("部分渠道不存在或无权修改")
+		}
+
+		for _, channel := range channels {
+			updates := map[string]any{
+				"version": gorm.Expr("version + 1"),
+			}
+			if params.Priority != nil {
+				updates["priority"] = *params.Priority
+			}
+			if params.Weight != nil {
+				updates["weight"] = *params.Weight
+			}
+			if params.Group != nil {
+				updates["group"] = strings.TrimSpace(*params.Group)
+			}
+			if len(params.AddModels) > 0 || len(params.RemoveModels) > 0 {
+				models := patchModels(channel.Models, params.AddModels, params.RemoveModels)
+				if models == "" {
+					return fmt.Errorf("渠道 #%d 的模型不能为空", channel.Id)
+				}
+				updates["models"] = models
+			}
+			if len(params.ModelMapping) > 0 {
+				mapping, err := patchModelMapping(channel.GetModelMapping(), params.ModelMapping)
+				if err != nil {
+					return fmt.Errorf("渠道 #%d 的模型映射格式错误：%s", channel.Id, err.Error())
+				}
+				updates["model_mapping"] = mapping
+			}
+
+			if err := tx.Model(&Channel{}).Where("id = ?", channel.Id).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ChannelGroup.Load()
+	if config.RedisEnabled {
+		_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+	}
+	return int64(len(ids)), nil
+}
@@ -0,0 +1,164 @@
+package model
+
+import (
+	"context"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"one-api/common/requester"
+	"strconv"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+const (
+	// 单次最多采样的请求数
+	ChannelCaptureMaxCount = 100
+	// 每个渠道保留的采样数
+	channelCaptureKeep = 200
+	// 采样开关的有效期，过期后自动关闭
+	channelCaptureTTL = 24 * time.Hour
+
+	channelCaptureCacheKey = "channel_capture:%d"
+)
+
+// ChannelCapture 一次中继请求发送到上游的原始请求和响应，密钥已隐藏
+type ChannelCapture struct {
+	Id         int                                      `json:"id"`
+	ChannelId  int                                      `json:"channel_id" gorm:"index"`
+	RequestId  string                                   `json:"request_id" gorm:"type:varchar(64);default:''"`
+	Path       string                                   `json:"path" gorm:"type:varchar(255);default:''"`
+	Model      string                                   `json:"model" gorm:"type:varchar(255);default:''"`
+	Exchanges  datatypes.JSONSlice[*requester.Exchange] `json:"exchanges" gorm:"type:json"`
+	StatusCode int                                      `json:"status_code"`
+	Error      string                                   `json:"error" gorm:"type:text"`
+	CreatedAt  int64                                    `json:"created_at" gorm:"bigint;index"`
+}
+
+type channelCaptureCounter struct {
+	remaining int
+	expiresAt time.Time
+}
+
+var (
+	channelCaptureLock     sync.Mutex
+	channelCaptureCounters = make(map[int]*channelCaptureCounter)
+)
+
+var takeChannelCaptureScript = redis.NewScript(`
+	local remaining = tonumber(redis.call("GET", KEYS[1]) or "0")
+	if remaining > 0 then
+		redis.call("DECR", KEYS[1])
+		return 1
+	end
+	return 0
+`)
+
+// StartChannelCapture 采样渠道接下来的 count 次上游请求，count 为 0 时关闭采样，启用 Redis 时多实例共享计数
+func StartChannelCapture(channelId, count int) error {
+	if count < 0 || count > ChannelCaptureMaxCount {
+		return fmt.Errorf("采样次数必须在 0 到 %d 之间", ChannelCaptureMaxCount)
+	}
+
+	if config.RedisEnabled {
+		key := fmt.Sprintf(channelCaptureCacheKey, channelId)
+		if count == 0 {
+			return redis.RedisDel(key)
+		}
+		return redis.RedisSet(key, strconv.Itoa(count), channelCaptureTTL)
+	}
+
+	channelCaptureLock.Lock()
+	defer channelCaptureLock.Unlock()
+	if count == 0 {
+		delete(channelCaptureCounters, channelId)
+		return nil
+	}
+	channelCaptureCounters[channelId] = &channelCaptureCounter{remaining: count, expiresAt: time.Now().Add(channelCaptureTTL)}
+	return nil
+}
+
+// GetChannelCaptureRemaining 渠道剩余的采样次数
+func GetChannelCaptureRemaining(channelId int) int {
+	if config.RedisEnabled {
+		value, err := redis.RedisGet(fmt.Sprintf(channelCaptureCacheKey, channelId))
+		if err != nil {
+			return 0
+		}
+		remaining, _ := strconv.Atoi(value)
+		return remaining
+	}
+
+	channelCaptureLock.Lock()
+	defer channelCaptureLock.Unlock()
+	counter, ok := channelCaptureCounters[channelId]
+	if !ok || time.Now().After(counter.expiresAt) {
+		return 0
+	}
+	return counter.remaining
+}
+
+// TakeChannelCapture 渠道开启了采样时占用一次采样次数
+func TakeChannelCapture(channelId int) bool {
+	if config.RedisEnabled {
+		taken, err := takeChannelCaptureScript.Run(context.Background(), redis.GetRedisClient(), []string{fmt.Sprintf(channelCaptureCacheKey, channelId)}).Int()
+		if err != nil {
+			logger.SysError("Redis take channel capture error: " + err.Error())
+			return false
+		}
+		return taken == 1
+	}
+
+	channelCaptureLock.Lock()
+	defer channelCaptureLock.Unlock()
+	counter, ok := channelCaptureCounters[channelId]
+	if !ok {
+		return false
+	}
+	if time.Now().After(counter.expiresAt) {
+		delete(channelCaptureCounters, channelId)
+		return false
+	}
+	counter.remaining--
+	if counter.remaining <= 0 {
+		delete(channelCaptureCounters, channelId)
+	}
+	return true
+}
+
+// Insert 保存采样，只保留渠道最近的采样
+func (capture *ChannelCapture) Insert() error {
+	if err := DB.Create(capture).Error; err != nil {
+		return err
+	}
+
+	var ids []int
+	err := DB.Model(&ChannelCapture{}).Where("channel_id = ?", capture.ChannelId).Order("id desc").Offset(channelCaptureKeep).Pluck("id", &ids).Error
+	if err == nil && len(ids) > 0 {
+		err = DB.Where("id IN ?", ids).Delete(&ChannelCapture{}).Error
+	}
+	if err != nil {
+		logger.SysError("failed to prune channel captures: " + err.Error())
+	}
+	return nil
+}
+
+var allowedChannelCapturesOrderFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+}
+
+func GetChannelCaptures(channelId int, params *PaginationParams) (*DataResult[ChannelCapture], error) {
+	var captures []*ChannelCapture
+	tx := DB.Model(&ChannelCapture{}).Where("channel_id = ?", channelId)
+	return PaginateAndOrder[ChannelCapture](tx, params, &captures, allowedChannelCapturesOrderFields)
+}
+
+// DeleteChannelCaptures 删除渠道的所有采样
+func DeleteChannelCaptures(channelId int) (int64, error) {
+	result := DB.Where("channel_id = ?", channelId).Delete(&ChannelCapture{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/redis"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// 添加渠道时遇到类型和 key 都相同的已有渠道的处理方式
+const (
+	DuplicateKeyPolicyWarn  = "warn"  // 照常添加，返回重复的渠道
+	DuplicateKeyPolicySkip  = "skip"  // 不添加重复的渠道
+	DuplicateKeyPolicyMerge = "merge" // 不添加，把模型和分组合并到已有渠道
+	DuplicateKeyPolicyAllow = "allow" // 不检查
+)
+
+func IsValidDuplicateKeyPolicy(policy string) bool {
+	switch policy {
+	case DuplicateKeyPolicyWarn, DuplicateKeyPolicySkip, DuplicateKeyPolicyMerge, DuplicateKeyPolicyAllow:
+		return true
+	}
+	return false
+}
+
+// DuplicateChannel 与已有渠道或本次添加的其它渠道 key 重复的渠道
+type DuplicateChannel struct {
+	Name       string `json:"name"`
+	ExistingId int    `json:"existing_id"` // 已有渠道的 ID，0 表示与本次添加的其它渠道重复
+	Action     string `json:"action"`      // added 已添加，skipped 已跳过，merged 已合并
+}
+
+type ChannelImportResult struct {
+	Added      int                 `json:"added"`
+	Merged     int                 `json:"merged"`
+	Skipped    int                 `json:"skipped"`
+	Duplicates []*DuplicateChannel `json:"duplicates"`
+}
+
+// channelKeyHash 按类型和 key 计算摘要，用于查找重复的渠道
+func channelKeyHash(channelType int, key string) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d:%s", channelType, strings.TrimSpace(key))))
+	return hex.EncodeToString(sum[:])
+}
+
+// channelKeyIndex 已有渠道按类型和 key 摘要的索引，key 可能已加密，需要读取后在内存中计算
+// tenantId 不为 0 时只索引该租户的渠道，租户之间不能合并渠道，也不能得知其它租户是否有相同的 key
+func channelKeyIndex(tenantId int) (map[string]*Channel, error) {
+	var channels []*Channel
+	tx := ReadDB().Select("id", "type", "key", "models", quotePostgresField("group"))
+	if tenantId != 0 {
+		tx = tx.Where("tenant_id = ?", tenantId)
+	}
+	if err := tx.Order("id").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+
+	index := make(map[string]*Channel, len(channels))
+	for _, channel := range channels {
+		hash := channelKeyHash(channel.Type, channel.Key)
+		if _, ok := index[hash]; !ok {
+			index[hash] = channel
+		}
+	}
+	return index, nil
+}
+
+// ImportChannels 按重复 key 的处理方式添加渠道，policy 为空时使用系统设置，tenantId 为操作者的租户，只与该租户的渠道比较
+func ImportChannels(channels []Channel, policy string, tenantId int) (*ChannelImportResult, error) {
+	if policy == "" {
+		policy = config.ChannelDuplicateKeyPolicy
+	}
+	if !IsValidDuplicateKeyPolicy(policy) {
+		return nil, fmt.Errorf("未知的重复 key 处理方式 %s", policy)
+	}
+
+	result := &ChannelImportResult{Duplicates: make([]*DuplicateChannel, 0)}
+	if policy == DuplicateKeyPolicyAllow {
+		if err := BatchInsertChannels(channels); err != nil {
+			return nil, err
+		}
+		result.Added = len(channels)
+		return result, nil
+	}
+
+	index, err := channelKeyIndex(tenantId)
+	if err != nil {
+		return nil, err
+	}
+
+	inserts := make([]Channel, 0, len(channels))
+	merges := make(map[int]*Channel)
+	added := make(map[string]bool)
+	for _, channel := range channels {
+		hash := channelKeyHash(channel.Type, channel.Key)
+		existing := index[hash]
+		if existing == nil && !added[hash] {
+			added[hash] = true
+			inserts = append(inserts, channel)
+			continue
+		}
+
+		duplicate := &DuplicateChannel{Name: channel.Name}
+		if existing != nil {
+			duplicate.ExistingId = existing.Id
+		}
+		switch {
+		case policy == DuplicateKeyPolicyWarn:
+			duplicate.Action = "added"
+			inserts = append(inserts, channel)
+		case policy == DuplicateKeyPolicyMerge && existing != nil:
+			duplicate.Action = "merged"
+			existing.Models = patchModels(existing.Models, strings.Split(channel.Models, ","), nil)
+			existing.Group = patchModels(existing.Group, strings.Split(channel.Group, ","), nil)
+			merges[existing.Id] = existing
+			result.Merged++
+		default:
+			duplicate.Action = "skipped"
+			result.Skipped++
+		}
+		result.Duplicates = append(result.Duplicates, duplicate)
+	}
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		if len(inserts) > 0 {
+			if err := tx.Omit("UsedQuota").Create(&inserts).Error; err != nil {
+				return err
+			}
+		}
+		for _, channel := range merges {
+			err := tx.Model(&Channel{}).Where("id = ?", channel.Id).Updates(map[string]any{
+				"models":  channel.Models,
+				"group":   channel.Group,
+				"version": gorm.Expr("version + 1"),
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Added = len(inserts)
+
+	if result.Added > 0 || result.Merged > 0 {
+		ChannelGroup.Load()
+		if config.RedisEnabled {
+			_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"one-api/common/encryption"
+	"one-api/common/logger"
+	"one-api/common/secrets"
+	"reflect"
+
+	"gorm.io/gorm/schema"
+)
+
+func init() {
+	schema.RegisterSerializer("channel_key", channelKeySerializer{})
+}
+
+// channelKeySerializer 渠道 key 写入数据库时加密，读取时解密，内存中始终为明文
+type channelKeySerializer struct{}
+
+func (channelKeySerializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	var value string
+	switch v := dbValue.(type) {
+	case []byte:
+		value = string(v)
+	case string:
+		value = v
+	}
+
+	plaintext, err := encryption.Decrypt(value)
+	if err != nil {
+		// 保留密文，再次保存时不会被重复加密，ResolveKey 拒绝使用密文，渠道不会被选中
+		logger.SysError("failed to decrypt channel key: " + err.Error())
+		plaintext = value
+	}
+	return field.Set(ctx, dst, plaintext)
+}
+
+func (channelKeySerializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	value, _ := fieldValue.(string)
+	return encryption.Encrypt(value)
+}
+
+var ErrChannelKeyUndecryptable = errors.New("channel key cannot be decrypted with the configured master keys")
+
+// KeyDecryptFailed 读取时无法解密的 key 保留为密文，主密钥配置错误或轮换时未保留旧密钥
+func (c *Channel) KeyDecryptFailed() bool {
+	return encryption.IsEncrypted(c.Key)
+}
+
+// ResolveKey 获取实际使用的 key，引用外部密钥时从密钥管理服务读取，无法解密时返回错误，不把密文发送到上游
+func (c *Channel) ResolveKey() (string, error) {
+	if c.KeyDecryptFailed() {
+		return "", ErrChannelKeyUndecryptable
+	}
+	if !secrets.IsReference(c.Key) {
+		return c.Key, nil
+	}
+	return secrets.Resolve(c.Key)
+}
+
+// channelIdsByKey 启用加密后无法在数据库中按 key 查询，解密后在内存中匹配
+func channelIdsByKey(key string) ([]int, error) {
+	var channels []*Channel
+	if err := ReadDB().Select("id", "key").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0)
+	for _, channel := range channels {
+		if channel.Key == key {
+			ids = append(ids, channel.Id)
+		}
+	}
+	return ids, nil
+}
+
+// RotateChannelKeys 使用当前主密钥重新加密所有渠道 key，包括尚未加密的明文
+func RotateChannelKeys() (int, error) {
+	if !encryption.Enabled() {
+		return 0, fmt.Errorf("channel key encryption is not enabled")
+	}
+
+	keyCol := quotePostgresField("key")
+	rows, err := DB.Raw("SELECT id, " + keyCol + " FROM channels").Rows()
+	if err != nil {
+		return 0, err
+	}
+
+	stored := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var key string
+		if err := rows.Scan(&id, &key); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if key != "" && encryption.NeedRotate(key) {
+			stored[id] = key
+		}
+	}
+	rows.Close()
+
+	rotated := 0
+	for id, key := range stored {
+		plaintext, err := encryption.Decrypt(key)
+		if err != nil {
+			return rotated, fmt.Errorf("failed to decrypt channel %d: %w", id, err)
+		}
+		ciphertext, err := encryption.Encrypt(plaintext)
+		if err != nil {
+			return rotated, err
+		}
+		if err := DB.Exec("UPDATE channels SET "+keyCol+" = ? WHERE id = ?", ciphertext, id).Error; err != nil {
+			return rotated, err
+		}
+		rotated++
+	}
+
+	return rotated, nil
+}
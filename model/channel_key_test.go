@@ -0,0 +1,43 @@
+package model
+
+import (
+	"testing"
+
+	"one-api/common/encryption"
+	"one-api/common/logger"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestChannelKeyDecryptFailed(t *testing.T) {
+	setupChannelKeyEncryption(t)
+
+	oldDB := DB
+	t.Cleanup(func() { DB = oldDB })
+	DB = setupBackupDB(t)
+
+	channel := &Channel{Name: "openai", Key: "sk-channel-key"}
+	assert.Nil(t, DB.Create(channel).Error)
+
+	loaded, err := GetChannelById(channel.Id)
+	assert.Nil(t, err)
+	assert.False(t, loaded.KeyDecryptFailed())
+	key, err := loaded.ResolveKey()
+	assert.Nil(t, err)
+	assert.Equal(t, "sk-channel-key", key)
+
+	// 更换主密钥且未保留旧密钥时，不能把密文当作 key 使用
+	oldLogger := logger.Logger
+	logger.Logger = zap.NewNop()
+	t.Cleanup(func() { logger.Logger = oldLogger })
+	viper.Set("channel_key_encryption.secret", "another-secret")
+	assert.Nil(t, encryption.Init())
+
+	loaded, err = GetChannelById(channel.Id)
+	assert.Nil(t, err)
+	assert.True(t, loaded.KeyDecryptFailed())
+	_, err = loaded.ResolveKey()
+	assert.ErrorIs(t, err, ErrChannelKeyUndecryptable)
+}
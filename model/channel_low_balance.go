@@ -0,0 +1,70 @@
+package model
+
+import (
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/notify"
+	"one-api/common/redis"
+	"one-api/common/utils"
+)
+
+// IsLowBalance 设置了余额阈值并且最近一次查询到的上游余额低于阈值
+func (channel *Channel) IsLowBalance() bool {
+	return channel.LowBalanceThreshold > 0 && channel.BalanceUpdatedTime > 0 && channel.Balance < channel.LowBalanceThreshold
+}
+
+// FilterLowBalance 过滤余额不足的渠道
+func FilterLowBalance() ChannelsFilterFunc {
+	return func(_ int, choice *ChannelChoice) bool {
+		return choice.Channel.IsLowBalance()
+	}
+}
+
+// lowBalanceGroups 分组中模型余额不足的渠道指定的备用分组，按优先级排列并去重，调用方需要持有读锁
+func (cc *ChannelsChooser) lowBalanceGroups(group, modelName string) []string {
+	channelsPriority, err := cc.groupModelPools(group, modelName)
+	if err != nil {
+		return nil
+	}
+
+	var groups []string
+	for _, pool := range channelsPriority {
+		for _, channelId := range pool.ids {
+			choice, ok := cc.Channels[channelId]
+			if !ok || choice.Disable || !choice.Channel.IsLowBalance() {
+				continue
+			}
+			backupGroup := choice.Channel.LowBalanceGroup
+			if backupGroup == "" || backupGroup == group || utils.Contains(backupGroup, groups) {
+				continue
+			}
+			groups = append(groups, backupGroup)
+		}
+	}
+	return groups
+}
+
+// onBalanceChanged 余额跨过阈值时重新加载渠道并通知管理员
+func (channel *Channel) onBalanceChanged(wasLow bool) {
+	isLow := channel.IsLowBalance()
+	if channel.LowBalanceThreshold <= 0 || wasLow == isLow {
+		return
+	}
+
+	ChannelGroup.Refresh(channel.Id)
+	if config.RedisEnabled {
+		_ = redis.RedisPublish(redis.RedisTopicChannelsSync, fmt.Sprintf("change:%d", channel.Id))
+	}
+
+	target := "同分组的其它渠道"
+	if channel.LowBalanceGroup != "" {
+		target = fmt.Sprintf("同分组的其它渠道和备用分组 %s", channel.LowBalanceGroup)
+	}
+	if isLow {
+		notify.Send("渠道余额不足", fmt.Sprintf("渠道「%s」（#%d）的余额 $%.2f 低于阈值 $%.2f，请求将优先使用%s，请及时充值。",
+			utils.EscapeMarkdownText(channel.Name), channel.Id, channel.Balance, channel.LowBalanceThreshold, target))
+	} else {
+		notify.Send("渠道余额已恢复", fmt.Sprintf("渠道「%s」（#%d）的余额 $%.2f 已恢复到阈值 $%.2f 以上，恢复正常使用。",
+			utils.EscapeMarkdownText(channel.Name), channel.Id, channel.Balance, channel.LowBalanceThreshold))
+	}
+}
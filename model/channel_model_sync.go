@@ -0,0 +1,185 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/redis"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ModelSyncModeReplace = "replace"
+	ModelSyncModeAppend  = "append"
+)
+
+// ModelSyncConfig 从上游同步模型列表的设置，规则支持 * 通配符，include 为空时包含所有模型
+type ModelSyncConfig struct {
+	Enable  bool     `json:"enable"`  // 是否参与定时同步
+	Mode    string   `json:"mode"`    // replace 使用上游列表替换，append 只追加新模型，默认为 replace
+	Include []string `json:"include"` // 包含的模型
+	Exclude []string `json:"exclude"` // 排除的模型，优先于 include
+}
+
+// ModelSyncResult 同步结果
+type ModelSyncResult struct {
+	Models  string   `json:"models"`
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+	Changed bool     `json:"changed"`
+}
+
+func (s *ModelSyncConfig) Validate() error {
+	if s.Mode != "" && s.Mode != ModelSyncModeReplace && s.Mode != ModelSyncModeAppend {
+		return fmt.Errorf("不支持的同步模式：%s", s.Mode)
+	}
+	for _, pattern := range append(s.Include, s.Exclude...) {
+		if _, err := modelPatternRegexp(pattern); err != nil {
+			return fmt.Errorf("模型规则 %s 无效：%w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Apply 按规则过滤上游模型，合并到当前模型列表
+func (s *ModelSyncConfig) Apply(current string, upstream []string) (*ModelSyncResult, error) {
+	include, err := compileModelPatterns(s.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compileModelPatterns(s.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]string, 0, len(upstream))
+	seen := make(map[string]bool)
+	for _, name := range upstream {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		if len(include) > 0 && !matchModelPatterns(include, name) {
+			continue
+		}
+		if matchModelPatterns(exclude, name) {
+			continue
+		}
+		seen[name] = true
+		filtered = append(filtered, name)
+	}
+
+	existing := make([]string, 0)
+	existingSet := make(map[string]bool)
+	for _, name := range strings.Split(current, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !existingSet[name] {
+			existingSet[name] = true
+			existing = append(existing, name)
+		}
+	}
+
+	result := &ModelSyncResult{Added: []string{}, Removed: []string{}}
+	models := filtered
+	if s.Mode == ModelSyncModeAppend {
+		models = existing
+		for _, name := range filtered {
+			if !existingSet[name] {
+				models = append(models, name)
+			}
+		}
+	} else {
+		if len(filtered) == 0 {
+			return nil, errors.New("上游没有符合规则的模型，未修改渠道模型")
+		}
+		for _, name := range existing {
+			if !seen[name] {
+				result.Removed = append(result.Removed, name)
+			}
+		}
+	}
+	for _, name := range models {
+		if !existingSet[name] {
+			result.Added = append(result.Added, name)
+		}
+	}
+
+	result.Models = strings.Join(models, ",")
+	result.Changed = len(result.Added) > 0 || len(result.Removed) > 0
+	return result, nil
+}
+
+func modelPatternRegexp(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, errors.New("规则不能为空")
+	}
+	return regexp.Compile("^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*") + "$")
+}
+
+func compileModelPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := modelPatternRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+func matchModelPatterns(patterns []*regexp.Regexp, name string) bool {
+	for _, re := range patterns {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetModelSync 获取渠道的模型同步设置，未设置时返回默认设置
+func (channel *Channel) GetModelSync() *ModelSyncConfig {
+	if channel.ModelSync == nil {
+		return &ModelSyncConfig{}
+	}
+
+	modelSync := channel.ModelSync.Data()
+	return &modelSync
+}
+
+// UpdateModels 只更新渠道的模型列表并刷新缓存
+func (channel *Channel) UpdateModels(models string) error {
+	err := DB.Model(channel).Updates(map[string]any{
+		"models":  models,
+		"version": gorm.Expr("version + 1"),
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	ChannelGroup.Refresh(channel.Id)
+	if config.RedisEnabled {
+		_ = redis.RedisPublish(redis.RedisTopicChannelsSync, fmt.Sprintf("change:%d", channel.Id))
+	}
+	return nil
+}
+
+// GetModelSyncChannels 开启了定时同步的渠道
+func GetModelSyncChannels() ([]*Channel, error) {
+	var channels []*Channel
+	if err := DB.Where("model_sync IS NOT NULL").Order("id").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+
+	enabled := make([]*Channel, 0, len(channels))
+	for _, channel := range channels {
+		if channel.GetModelSync().Enable {
+			enabled = append(enabled, channel)
+		}
+	}
+	return enabled, nil
+}
@@ -0,0 +1,145 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	ChannelScheduleModeAllow = "allow" // 只在时间段内使用
+	ChannelScheduleModeDeny  = "deny"  // 时间段内不使用，例如上游的维护窗口
+)
+
+// ChannelSchedule 渠道的启用时间段
+type ChannelSchedule struct {
+	Timezone string           `json:"timezone,omitempty"` // IANA 时区，例如 Asia/Shanghai，为空时使用服务器时区
+	Mode     string           `json:"mode,omitempty"`     // allow 或 deny，默认为 allow
+	Windows  []ScheduleWindow `json:"windows"`
+}
+
+// ScheduleWindow 一个时间段，结束时间小于开始时间时跨越午夜，属于开始的那一天
+type ScheduleWindow struct {
+	Days  []int  `json:"days,omitempty"` // 星期几，0 为周日，为空时每天
+	Start string `json:"start"`          // HH:MM
+	End   string `json:"end"`            // HH:MM
+}
+
+var scheduleLocations sync.Map
+
+func loadScheduleLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	if location, ok := scheduleLocations.Load(name); ok {
+		return location.(*time.Location), nil
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	scheduleLocations.Store(name, location)
+	return location, nil
+}
+
+func parseScheduleMinute(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("时间格式应为 HH:MM：%s", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ValidateSchedule 检查渠道的启用时间段是否合法
+func (c *Channel) ValidateSchedule() error {
+	if c.Schedule == nil {
+		return nil
+	}
+
+	schedule := c.Schedule.Data()
+	if schedule.Mode != "" && schedule.Mode != ChannelScheduleModeAllow && schedule.Mode != ChannelScheduleModeDeny {
+		return fmt.Errorf("不支持的时间段模式：%s", schedule.Mode)
+	}
+	if _, err := loadScheduleLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("无效的时区：%s", schedule.Timezone)
+	}
+	for _, window := range schedule.Windows {
+		for _, day := range window.Days {
+			if day < 0 || day > 6 {
+				return fmt.Errorf("星期几应为 0 到 6：%d", day)
+			}
+		}
+		start, err := parseScheduleMinute(window.Start)
+		if err != nil {
+			return err
+		}
+		end, err := parseScheduleMinute(window.End)
+		if err != nil {
+			return err
+		}
+		if start == end {
+			return fmt.Errorf("时间段的开始和结束时间不能相同：%s", window.Start)
+		}
+	}
+	return nil
+}
+
+// contains 时间段是否包含星期 weekday 的第 minute 分钟
+func (w *ScheduleWindow) contains(weekday, minute int) bool {
+	start, err := parseScheduleMinute(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseScheduleMinute(w.End)
+	if err != nil {
+		return false
+	}
+	onDay := func(day int) bool {
+		return len(w.Days) == 0 || slices.Contains(w.Days, day)
+	}
+
+	if start < end {
+		return onDay(weekday) && minute >= start && minute < end
+	}
+	// 跨越午夜
+	return (onDay(weekday) && minute >= start) || (onDay((weekday+6)%7) && minute < end)
+}
+
+// InSchedule 渠道在 now 时是否可用，没有设置时间段时总是可用
+func (c *Channel) InSchedule(now time.Time) bool {
+	if c.Schedule == nil {
+		return true
+	}
+	schedule := c.Schedule.Data()
+	if len(schedule.Windows) == 0 {
+		return true
+	}
+
+	location, err := loadScheduleLocation(schedule.Timezone)
+	if err != nil {
+		return true
+	}
+	now = now.In(location)
+	weekday := int(now.Weekday())
+	minute := now.Hour()*60 + now.Minute()
+
+	matched := false
+	for i := range schedule.Windows {
+		if schedule.Windows[i].contains(weekday, minute) {
+			matched = true
+			break
+		}
+	}
+	if schedule.Mode == ChannelScheduleModeDeny {
+		return !matched
+	}
+	return matched
+}
+
+// FilterSchedule 过滤不在启用时间段内的渠道
+func FilterSchedule(now time.Time) ChannelsFilterFunc {
+	return func(_ int, choice *ChannelChoice) bool {
+		return !choice.Channel.InSchedule(now)
+	}
+}
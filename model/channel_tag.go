@@ -9,6 +9,8 @@ import (
 	"one-api/common/redis"
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type SearchChannelsTagParams struct {
@@ -159,9 +161,18 @@ func UpdateChannelsTag(tag string, channel *Channel) error {
 			Plugin:             channel.Plugin,
 			PreCost:            channel.PreCost,
 			DisabledStream:     channel.DisabledStream,
+			StreamMode:         channel.StreamMode,
+			Region:             channel.Region,
 			CompatibleResponse: channel.CompatibleResponse,
+			HTTPConfig:         channel.HTTPConfig,
+			ExtraBody:          channel.ExtraBody,
+			ModelSync:          channel.ModelSync,
 		}).Error
 
+	if err == nil {
+		err = tx.Model(Channel{}).Where("tag = ?", tag).UpdateColumn("version", gorm.Expr("version + 1")).Error
+	}
+
 	if err != nil {
 		tx.Rollback()
 		return err
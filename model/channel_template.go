@@ -0,0 +1,107 @@
+package model
+
+import (
+	"errors"
+	"one-api/common/config"
+	"one-api/common/utils"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ChannelTemplate 渠道模板，保存类型、地址、模型、映射等设置和模型价格，通过模板添加渠道时只需要填写密钥
+type ChannelTemplate struct {
+	Id          int                         `json:"id"`
+	Name        string                      `json:"name" gorm:"type:varchar(64);uniqueIndex" binding:"required"`
+	Description string                      `json:"description" gorm:"type:varchar(255);default:''"`
+	Channel     datatypes.JSONType[Channel] `json:"channel" gorm:"type:json"`
+	Prices      datatypes.JSONSlice[*Price] `json:"prices" gorm:"type:json"` // 添加渠道时只补充系统中没有的模型价格
+	CreatedTime int64                       `json:"created_time" gorm:"bigint"`
+	UpdatedTime int64                       `json:"updated_time" gorm:"bigint"`
+}
+
+var allowedChannelTemplateOrderFields = map[string]bool{
+	"id":           true,
+	"name":         true,
+	"created_time": true,
+}
+
+// Clone 复制渠道的设置，去掉 ID、密钥、租户、用量和测试结果等与具体渠道相关的字段
+func (channel *Channel) Clone() Channel {
+	clone := *channel
+	clone.Id = 0
+	clone.Key = ""
+	clone.UsedQuota = 0
+	clone.Balance = 0
+	clone.BalanceUpdatedTime = 0
+	clone.ResponseTime = 0
+	clone.TestTime = 0
+	clone.CreatedTime = 0
+	clone.TenantId = 0
+	clone.Version = 0
+	clone.ExternalId = ""
+	clone.DeletedAt = gorm.DeletedAt{}
+	return clone
+}
+
+func (template *ChannelTemplate) normalize() {
+	channel := template.Channel.Data()
+	template.Channel = datatypes.NewJSONType(channel.Clone())
+}
+
+func GetChannelTemplatesList(params *GenericParams) (*DataResult[ChannelTemplate], error) {
+	var templates []*ChannelTemplate
+	db := DB.Model(&ChannelTemplate{})
+	if params.Keyword != "" {
+		db = db.Where("name LIKE ?", "%"+params.Keyword+"%")
+	}
+	return PaginateAndOrder[ChannelTemplate](db, &params.PaginationParams, &templates, allowedChannelTemplateOrderFields)
+}
+
+func GetChannelTemplateById(id int) (*ChannelTemplate, error) {
+	var template ChannelTemplate
+	if err := DB.First(&template, "id = ?", id).Error; err != nil {
+		return nil, errors.New("模板不存在")
+	}
+	return &template, nil
+}
+
+func (template *ChannelTemplate) Insert() error {
+	if RecordExists(&ChannelTemplate{}, "name", template.Name, nil) {
+		return errors.New("模板名称已存在")
+	}
+	template.normalize()
+	template.Id = 0
+	template.CreatedTime = utils.GetTimestamp()
+	template.UpdatedTime = template.CreatedTime
+	return DB.Create(template).Error
+}
+
+func (template *ChannelTemplate) Update() error {
+	if RecordExists(&ChannelTemplate{}, "name", template.Name, template.Id) {
+		return errors.New("模板名称已存在")
+	}
+	template.normalize()
+	template.UpdatedTime = utils.GetTimestamp()
+	return DB.Model(template).Select("name", "description", "channel", "prices", "updated_time").Updates(template).Error
+}
+
+func DeleteChannelTemplate(id int) error {
+	return DB.Delete(&ChannelTemplate{}, "id = ?", id).Error
+}
+
+// NewChannel 按模板生成渠道，需要再设置密钥
+func (template *ChannelTemplate) NewChannel() Channel {
+	channel := template.Channel.Data()
+	channel.Status = config.ChannelStatusEnabled
+	channel.CreatedTime = utils.GetTimestamp()
+	return channel
+}
+
+// ApplyPrices 补充系统中没有的模型价格，已有的价格不会被覆盖
+func (template *ChannelTemplate) ApplyPrices() error {
+	if len(template.Prices) == 0 {
+		return nil
+	}
+	return PricingInstance.SyncPriceWithoutOverwrite(template.Prices)
+}
@@ -0,0 +1,126 @@
+package model
+
+import (
+	"one-api/common/logger"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ChannelTestTriggerManual    = "manual"
+	ChannelTestTriggerScheduled = "scheduled"
+
+	ChannelTestStatusRunning  = "running"
+	ChannelTestStatusFinished = "finished"
+)
+
+// ChannelTestReport 一次全量渠道测试的结果汇总
+type ChannelTestReport struct {
+	Id         int    `json:"id"`
+	Trigger    string `json:"trigger" gorm:"type:varchar(16)"`
+	Status     string `json:"status" gorm:"type:varchar(16)"`
+	AllModels  bool   `json:"all_models"`
+	Channels   int    `json:"channels"`
+	Total      int    `json:"total"`
+	Passed     int    `json:"passed"`
+	Failed     int    `json:"failed"`
+	Skipped    int    `json:"skipped"`
+	StartedAt  int64  `json:"started_at" gorm:"bigint;index"`
+	FinishedAt int64  `json:"finished_at" gorm:"bigint"`
+
+	Results []*ChannelTestResult `json:"results,omitempty" gorm:"foreignKey:ReportId"`
+}
+
+// ChannelTestResult 单个渠道单个模型的测试结果
+type ChannelTestResult struct {
+	Id          int    `json:"id"`
+	ReportId    int    `json:"report_id" gorm:"index"`
+	ChannelId   int    `json:"channel_id" gorm:"index"`
+	ChannelName string `json:"channel_name" gorm:"type:varchar(255)"`
+	Model       string `json:"model" gorm:"type:varchar(255)"`
+	Success     bool   `json:"success"`
+	Skipped     bool   `json:"skipped"`
+	Latency     int64  `json:"latency"` // 毫秒
+	Error       string `json:"error" gorm:"type:text"`
+	Action      string `json:"action" gorm:"type:varchar(16);default:''"` // 测试后对渠道的处理：enabled、disabled
+	CreatedAt   int64  `json:"created_at" gorm:"bigint"`
+}
+
+func (r *ChannelTestReport) Insert() error {
+	return DB.Omit("Results").Create(r).Error
+}
+
+// Finish 写入结果并更新汇总，只保留最近 keep 份报告
+func (r *ChannelTestReport) Finish(results []*ChannelTestResult, keep int) error {
+	for _, result := range results {
+		result.ReportId = r.Id
+		if result.Skipped {
+			r.Skipped++
+		} else if result.Success {
+			r.Passed++
+		} else {
+			r.Failed++
+		}
+	}
+	r.Total = len(results)
+	r.Status = ChannelTestStatusFinished
+
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if len(results) > 0 {
+			if err := insertModelRows(tx, &ChannelTestResult{}, results, 100); err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Results").Save(r).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if keep > 0 {
+		if err := pruneChannelTestReports(keep); err != nil {
+			logger.SysError("failed to prune channel test reports: " + err.Error())
+		}
+	}
+	return nil
+}
+
+func pruneChannelTestReports(keep int) error {
+	var ids []int
+	if err := DB.Model(&ChannelTestReport{}).Order("id desc").Offset(keep).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("report_id IN ?", ids).Delete(&ChannelTestResult{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&ChannelTestReport{}).Error
+	})
+}
+
+var allowedChannelTestReportsOrderFields = map[string]bool{
+	"id":         true,
+	"started_at": true,
+}
+
+func GetChannelTestReports(params *PaginationParams) (*DataResult[ChannelTestReport], error) {
+	var reports []*ChannelTestReport
+	return PaginateAndOrder[ChannelTestReport](DB.Model(&ChannelTestReport{}), params, &reports, allowedChannelTestReportsOrderFields)
+}
+
+// GetChannelTestReport 获取报告及结果，onlyFailed 时只返回失败的结果
+func GetChannelTestReport(id int, onlyFailed bool) (*ChannelTestReport, error) {
+	report := &ChannelTestReport{}
+	err := DB.Preload("Results", func(db *gorm.DB) *gorm.DB {
+		if onlyFailed {
+			db = db.Where("success = ? AND skipped = ?", false, false)
+		}
+		return db.Order("channel_id, id")
+	}).First(report, "id = ?", id).Error
+
+	return report, err
+}
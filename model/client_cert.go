@@ -0,0 +1,184 @@
+package model
+
+import (
+	"errors"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"one-api/common/utils"
+	"strings"
+	"sync"
+)
+
+// ClientCert 客户端证书与令牌的绑定，通过 mTLS 访问时按证书主题 CN 查找令牌
+type ClientCert struct {
+	Id          int    `json:"id"`
+	Subject     string `json:"subject" gorm:"type:varchar(255);uniqueIndex"`
+	Fingerprint string `json:"fingerprint" gorm:"type:varchar(64);default:''"` // 证书 SHA-256 指纹，设置后必须一致
+	TokenId     int    `json:"token_id" gorm:"index"`
+	Remark      string `json:"remark" gorm:"type:varchar(255);default:''"`
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+
+	tokenKey string
+}
+
+type SearchClientCertParams struct {
+	Subject string `form:"subject"`
+	TokenId int    `form:"token_id"`
+	PaginationParams
+}
+
+var allowedClientCertOrderFields = map[string]bool{
+	"id":           true,
+	"subject":      true,
+	"token_id":     true,
+	"created_time": true,
+}
+
+func GetClientCertsList(params *SearchClientCertParams) (*DataResult[ClientCert], error) {
+	var certs []*ClientCert
+	db := DB
+
+	if params.Subject != "" {
+		db = db.Where("subject LIKE ?", params.Subject+"%")
+	}
+	if params.TokenId != 0 {
+		db = db.Where("token_id = ?", params.TokenId)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &certs, allowedClientCertOrderFields)
+}
+
+func GetClientCertById(id int) (*ClientCert, error) {
+	var cert ClientCert
+	err := DB.Where("id = ?", id).First(&cert).Error
+	return &cert, err
+}
+
+func (cc *ClientCert) Create() error {
+	cc.CreatedTime = utils.GetTimestamp()
+	err := DB.Create(cc).Error
+	if err == nil {
+		reloadClientCerts()
+	}
+	return err
+}
+
+func (cc *ClientCert) Update() error {
+	err := DB.Select("subject", "fingerprint", "token_id", "remark").Updates(cc).Error
+	if err == nil {
+		reloadClientCerts()
+	}
+	return err
+}
+
+func (cc *ClientCert) Delete() error {
+	err := DB.Delete(cc).Error
+	if err == nil {
+		reloadClientCerts()
+	}
+	return err
+}
+
+// Validate 校验证书主题和绑定的令牌
+func (cc *ClientCert) Validate() error {
+	cc.Subject = strings.TrimSpace(cc.Subject)
+	if cc.Subject == "" {
+		return errors.New("证书主题不能为空")
+	}
+
+	cc.Fingerprint = normalizeFingerprint(cc.Fingerprint)
+	if cc.Fingerprint != "" && len(cc.Fingerprint) != 64 {
+		return errors.New("证书指纹必须是 SHA-256 十六进制字符串")
+	}
+
+	if _, err := GetTokenById(cc.TokenId); err != nil {
+		return errors.New("绑定的令牌不存在")
+	}
+	return nil
+}
+
+// normalizeFingerprint 去除分隔符并转为小写
+func normalizeFingerprint(fingerprint string) string {
+	fingerprint = strings.ReplaceAll(fingerprint, ":", "")
+	return strings.ToLower(strings.TrimSpace(fingerprint))
+}
+
+// reloadClientCerts 重新加载本实例的绑定，并通知其它实例
+func reloadClientCerts() {
+	ClientCertsInstance.Load()
+	if config.RedisEnabled {
+		_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+	}
+}
+
+// ClientCerts 客户端证书绑定的内存缓存
+type ClientCerts struct {
+	sync.RWMutex
+	subjects map[string]*ClientCert
+}
+
+var ClientCertsInstance = &ClientCerts{}
+
+func NewClientCerts() {
+	if err := ClientCertsInstance.Load(); err != nil {
+		logger.SysError("Failed to initialize ClientCerts:" + err.Error())
+	}
+}
+
+func (p *ClientCerts) Load() error {
+	var certs []*ClientCert
+	if err := DB.Find(&certs).Error; err != nil {
+		return err
+	}
+
+	tokenIds := make([]int, 0, len(certs))
+	for _, cert := range certs {
+		tokenIds = append(tokenIds, cert.TokenId)
+	}
+
+	var tokens []*Token
+	if len(tokenIds) > 0 {
+		if err := DB.Where("id IN ?", tokenIds).Find(&tokens).Error; err != nil {
+			return err
+		}
+	}
+	tokenKeys := make(map[int]string, len(tokens))
+	for _, token := range tokens {
+		// 旧格式的随机 key 只保存了哈希，无法用于证书认证
+		if key, ok := token.DerivedKey(); ok {
+			tokenKeys[token.Id] = key
+		}
+	}
+
+	subjects := make(map[string]*ClientCert, len(certs))
+	for _, cert := range certs {
+		cert.tokenKey = tokenKeys[cert.TokenId]
+		if cert.tokenKey == "" {
+			continue
+		}
+		subjects[cert.Subject] = cert
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.subjects = subjects
+
+	return nil
+}
+
+// GetTokenKey 根据证书主题和指纹获取绑定令牌的 key
+func (p *ClientCerts) GetTokenKey(subject, fingerprint string) (string, bool) {
+	p.RLock()
+	defer p.RUnlock()
+
+	cert, ok := p.subjects[subject]
+	if !ok {
+		return "", false
+	}
+	if cert.Fingerprint != "" && cert.Fingerprint != normalizeFingerprint(fingerprint) {
+		return "", false
+	}
+	return cert.tokenKey, true
+}
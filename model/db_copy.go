@@ -0,0 +1,231 @@
+package model
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/utils"
+	"reflect"
+	"strings"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// migrationRecord gormigrate 的迁移记录，复制后目标库启动时不会重复执行迁移
+type migrationRecord struct {
+	ID string `gorm:"primaryKey;size:255"`
+}
+
+func (migrationRecord) TableName() string {
+	return "migrations"
+}
+
+// TableCopyResult 单张表的复制结果
+type TableCopyResult struct {
+	Table   string
+	Source  int64
+	Copied  int64
+	Target  int64
+	Skipped bool
+}
+
+// OpenDatabase 按类型打开数据库，sqlite 的 dsn 为文件路径，postgres 的 dsn 需要以 postgres:// 开头
+func OpenDatabase(driver, dsn string) (*gorm.DB, error) {
+	switch driver {
+	case "sqlite":
+		return gorm.Open(sqlite.Open(fmt.Sprintf("%s?_busy_timeout=%d", dsn, utils.GetOrDefault("sqlite_busy_timeout", 3000))), &gorm.Config{})
+	case "mysql":
+		if strings.HasPrefix(dsn, "postgres://") {
+			return nil, fmt.Errorf("dsn is not a MySQL dsn")
+		}
+		return openDB(dsn)
+	case "postgres":
+		if !strings.HasPrefix(dsn, "postgres://") {
+			return nil, fmt.Errorf("PostgreSQL dsn must start with postgres://")
+		}
+		return openDB(dsn)
+	}
+	return nil, fmt.Errorf("unsupported database type: %s", driver)
+}
+
+// copyModels 迁移工具复制的表，包括未启用月度账单时不建的表和迁移记录
+func copyModels() []any {
+	models := migrationModels()
+	if !config.UserInvoiceMonth {
+		models = append(models, &StatisticsMonthGeneratedHistory{}, &StatisticsMonth{})
+	}
+	return append(models, &migrationRecord{})
+}
+
+// CopyDatabase 在目标库建表后逐表复制数据（包括软删除的记录），最后核对每张表的行数
+// 源库中不存在的表会跳过，目标库中的表必须为空
+func CopyDatabase(src, dst *gorm.DB, batchSize int, progress func(table string, copied int64)) ([]*TableCopyResult, error) {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	type copyTable struct {
+		model  any
+		schema *schema.Schema
+		result *TableCopyResult
+	}
+
+	tables := make([]*copyTable, 0)
+	for _, model := range copyModels() {
+		stmt := &gorm.Statement{DB: dst}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		table := &copyTable{model: model, schema: stmt.Schema, result: &TableCopyResult{Table: stmt.Schema.Table}}
+		tables = append(tables, table)
+
+		if !src.Migrator().HasTable(model) {
+			table.result.Skipped = true
+			continue
+		}
+		if err := dst.AutoMigrate(model); err != nil {
+			return nil, fmt.Errorf("failed to create table %s: %w", table.result.Table, err)
+		}
+
+		var count int64
+		if err := dst.Unscoped().Model(model).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, fmt.Errorf("target table %s is not empty", table.result.Table)
+		}
+	}
+
+	results := make([]*TableCopyResult, 0, len(tables))
+	for _, table := range tables {
+		results = append(results, table.result)
+		if table.result.Skipped {
+			continue
+		}
+
+		if err := src.Unscoped().Model(table.model).Count(&table.result.Source).Error; err != nil {
+			return results, err
+		}
+		copied, err := copyRows(src, dst, table.model, table.schema, batchSize, func(copied int64) {
+			if progress != nil {
+				progress(table.result.Table, copied)
+			}
+		})
+		table.result.Copied = copied
+		if err != nil {
+			return results, fmt.Errorf("failed to copy table %s: %w", table.result.Table, err)
+		}
+
+		if dst.Dialector.Name() == "postgres" {
+			if field := table.schema.PrioritizedPrimaryField; field != nil && field.AutoIncrement {
+				if err := resetSequence(dst, table.result.Table); err != nil {
+					return results, err
+				}
+			}
+		}
+	}
+
+	for _, table := range tables {
+		if table.result.Skipped {
+			continue
+		}
+		if err := dst.Unscoped().Model(table.model).Count(&table.result.Target).Error; err != nil {
+			return results, err
+		}
+		if table.result.Target != table.result.Source {
+			return results, fmt.Errorf("table %s row count mismatch: source %d, target %d", table.result.Table, table.result.Source, table.result.Target)
+		}
+	}
+
+	return results, nil
+}
+
+// copyRows 按主键分批读取，没有主键的表按偏移分页
+func copyRows(src, dst *gorm.DB, model any, s *schema.Schema, batchSize int, progress func(copied int64)) (int64, error) {
+	var copied int64
+	rows := reflect.New(reflect.SliceOf(reflect.TypeOf(model)))
+	query := src.Session(&gorm.Session{SkipHooks: true}).Unscoped().Model(model)
+
+	insert := func() error {
+		if err := insertRows(dst, s, rows.Elem()); err != nil {
+			return err
+		}
+		copied += int64(rows.Elem().Len())
+		progress(copied)
+		return nil
+	}
+
+	if s.PrioritizedPrimaryField != nil {
+		err := query.FindInBatches(rows.Interface(), batchSize, func(tx *gorm.DB, batch int) error {
+			return insert()
+		}).Error
+		return copied, err
+	}
+
+	for offset := 0; ; offset += batchSize {
+		rows.Elem().SetLen(0)
+		if err := query.Offset(offset).Limit(batchSize).Find(rows.Interface()).Error; err != nil {
+			return copied, err
+		}
+		if rows.Elem().Len() == 0 {
+			return copied, nil
+		}
+		if err := insert(); err != nil {
+			return copied, err
+		}
+		if rows.Elem().Len() < batchSize {
+			return copied, nil
+		}
+	}
+}
+
+// insertRows 按列写入，保留零值
+// 直接使用模型写入时，gorm 会把带 default 标签字段的零值替换为默认值，例如倍率为 0 的分组会变成 1
+// 带序列化器的字段按序列化后的值写入，渠道 key 使用目标库的主密钥加密，不会以明文写入
+func insertRows(db *gorm.DB, s *schema.Schema, rows reflect.Value) error {
+	if rows.Len() == 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	values := make([]map[string]any, 0, rows.Len())
+	for i := 0; i < rows.Len(); i++ {
+		row := reflect.Indirect(rows.Index(i))
+		value := make(map[string]any, len(s.DBNames))
+		for _, name := range s.DBNames {
+			field := s.FieldsByDBName[name]
+			fieldValue, _ := field.ValueOf(ctx, row)
+			if valuer, ok := fieldValue.(driver.Valuer); ok && field.Serializer != nil {
+				serialized, err := valuer.Value()
+				if err != nil {
+					return fmt.Errorf("failed to serialize %s.%s: %w", s.Table, name, err)
+				}
+				fieldValue = serialized
+			}
+			value[name] = fieldValue
+		}
+		values = append(values, value)
+	}
+
+	return db.Session(&gorm.Session{SkipHooks: true}).Table(s.Table).Create(&values).Error
+}
+
+// insertModelRows 按批写入模型切片，保留零值
+func insertModelRows(db *gorm.DB, model any, rows any, batchSize int) error {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(rows)
+	for start := 0; start < value.Len(); start += batchSize {
+		end := min(start+batchSize, value.Len())
+		if err := insertRows(db, stmt.Schema, value.Slice(start, end)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"path/filepath"
+	"testing"
+
+	"one-api/common/encryption"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupChannelKeyEncryption(t *testing.T) {
+	viper.Set("channel_key_encryption.secret", "channel-key-test-secret")
+	t.Cleanup(func() {
+		viper.Set("channel_key_encryption.secret", "")
+		encryption.Init()
+	})
+	assert.Nil(t, encryption.Init())
+}
+
+func TestCopyDatabaseKeepsChannelKeyEncrypted(t *testing.T) {
+	setupChannelKeyEncryption(t)
+
+	dir := t.TempDir()
+	src, err := OpenDatabase("sqlite", filepath.Join(dir, "src.db"))
+	assert.Nil(t, err)
+	dst, err := OpenDatabase("sqlite", filepath.Join(dir, "dst.db"))
+	assert.Nil(t, err)
+
+	assert.Nil(t, src.AutoMigrate(&Channel{}))
+	channel := &Channel{Name: "openai", Key: "sk-copy-channel-key"}
+	assert.Nil(t, src.Create(channel).Error)
+
+	_, err = CopyDatabase(src, dst, 10, nil)
+	assert.Nil(t, err)
+
+	var stored string
+	assert.Nil(t, dst.Raw("SELECT `key` FROM channels WHERE id = ?", channel.Id).Scan(&stored).Error)
+	assert.True(t, encryption.IsEncrypted(stored))
+	assert.NotContains(t, stored, "sk-copy-channel-key")
+
+	var copied Channel
+	assert.Nil(t, dst.First(&copied, channel.Id).Error)
+	assert.Equal(t, "sk-copy-channel-key", copied.Key)
+}
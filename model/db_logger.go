@@ -0,0 +1,78 @@
+package model
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// configurePool 按配置设置连接池，主库和从库使用相同的设置
+func configurePool(sqlDB *sql.DB) {
+	sqlDB.SetMaxIdleConns(utils.GetOrDefault("sql_max_idle_conns", 100))
+	sqlDB.SetMaxOpenConns(utils.GetOrDefault("sql_max_open_conns", 1000))
+	sqlDB.SetConnMaxLifetime(time.Second * time.Duration(utils.GetOrDefault("sql_max_lifetime", 60)))
+	sqlDB.SetConnMaxIdleTime(time.Second * time.Duration(utils.GetOrDefault("sql_max_idle_time", 0)))
+}
+
+// sqlLogger 将慢查询和执行出错的 SQL 写入系统日志，调试模式下记录所有 SQL
+type sqlLogger struct {
+	level         gormlogger.LogLevel
+	slowThreshold time.Duration // 为 0 时不记录慢查询
+}
+
+func newSQLLogger() gormlogger.Interface {
+	return &sqlLogger{
+		level:         gormlogger.Warn,
+		slowThreshold: time.Duration(utils.GetOrDefault("sql_slow_threshold", 200)) * time.Millisecond,
+	}
+}
+
+func (l *sqlLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.level = level
+	return &clone
+}
+
+func (l *sqlLogger) Info(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Info {
+		logger.LogInfo(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *sqlLogger) Warn(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Warn {
+		logger.LogWarn(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *sqlLogger) Error(ctx context.Context, msg string, args ...any) {
+	if l.level >= gormlogger.Error {
+		logger.LogError(ctx, fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *sqlLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		logger.LogError(ctx, fmt.Sprintf("[SQL] %s [%.3fms] [rows:%d] %s", err.Error(), float64(elapsed.Microseconds())/1000, rows, sql))
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.LogWarn(ctx, fmt.Sprintf("[SQL] slow query >= %s [%.3fms] [rows:%d] %s", l.slowThreshold, float64(elapsed.Microseconds())/1000, rows, sql))
+	case l.level >= gormlogger.Info:
+		sql, rows := fc()
+		logger.LogInfo(ctx, fmt.Sprintf("[SQL] [%.3fms] [rows:%d] %s", float64(elapsed.Microseconds())/1000, rows, sql))
+	}
+}
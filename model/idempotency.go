@@ -0,0 +1,85 @@
+package model
+
+import (
+	"errors"
+	"one-api/common/utils"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrIdempotencyKeyMismatch   = errors.New("Idempotency-Key 已用于其他请求")
+	ErrIdempotencyKeyProcessing = errors.New("相同 Idempotency-Key 的请求正在处理中")
+)
+
+// IdempotencyKey 创建接口的幂等键，相同用户和键的重复请求直接返回第一次的响应
+type IdempotencyKey struct {
+	Id          int    `json:"id"`
+	UserId      int    `json:"user_id" gorm:"uniqueIndex:idx_idempotency_user_key"`
+	Key         string `json:"key" gorm:"type:varchar(255);uniqueIndex:idx_idempotency_user_key"`
+	Fingerprint string `json:"fingerprint" gorm:"type:varchar(64)"` // 请求方法、路径和请求体的摘要
+	StatusCode  int    `json:"status_code" gorm:"default:0"`        // 0 表示正在处理
+	Response    string `json:"response" gorm:"type:text"`
+	CreatedAt   int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+// idempotencyKeyTTL 幂等键的保留时间
+func idempotencyKeyTTL() time.Duration {
+	return time.Duration(utils.GetOrDefault("idempotency.ttl", 24)) * time.Hour
+}
+
+// BeginIdempotentRequest 占用幂等键，键已完成时返回已保存的记录，正在处理或请求不一致时返回错误
+func BeginIdempotentRequest(userId int, key, fingerprint string) (*IdempotencyKey, bool, error) {
+	record := &IdempotencyKey{
+		UserId:      userId,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   utils.GetTimestamp(),
+	}
+	result := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return record, false, nil
+	}
+
+	// 键已存在，过期的键视为不存在
+	var existing IdempotencyKey
+	if err := DB.Where(&IdempotencyKey{UserId: userId, Key: key}).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	if existing.CreatedAt < time.Now().Add(-idempotencyKeyTTL()).Unix() {
+		if err := DB.Delete(&existing).Error; err != nil {
+			return nil, false, err
+		}
+		return BeginIdempotentRequest(userId, key, fingerprint)
+	}
+	if existing.Fingerprint != fingerprint {
+		return nil, false, ErrIdempotencyKeyMismatch
+	}
+	if existing.StatusCode == 0 {
+		return nil, false, ErrIdempotencyKeyProcessing
+	}
+	return &existing, true, nil
+}
+
+// CompleteIdempotentRequest 保存响应，之后的重复请求直接返回
+func CompleteIdempotentRequest(record *IdempotencyKey, statusCode int, response string) error {
+	return DB.Model(record).Updates(map[string]any{
+		"status_code": statusCode,
+		"response":    response,
+	}).Error
+}
+
+// ReleaseIdempotentRequest 请求失败时释放幂等键，客户端可以使用同一个键重试
+func ReleaseIdempotentRequest(record *IdempotencyKey) error {
+	return DB.Delete(record).Error
+}
+
+// DeleteExpiredIdempotencyKeys 删除超过保留时间的幂等键
+func DeleteExpiredIdempotencyKeys() (int64, error) {
+	result := DB.Where("created_at < ?", time.Now().Add(-idempotencyKeyTTL()).Unix()).Delete(&IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
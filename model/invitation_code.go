@@ -0,0 +1,154 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common"
+	"one-api/common/logger"
+	"one-api/common/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	InvitationCodeStatusEnabled  = 1
+	InvitationCodeStatusDisabled = 2
+)
+
+// InvitationCode 邀请注册码，注册时按预设的额度和分组初始化用户
+type InvitationCode struct {
+	Id          int    `json:"id"`
+	UserId      int    `json:"user_id"`
+	Code        string `json:"code" gorm:"type:char(32);uniqueIndex"`
+	Name        string `json:"name" gorm:"index"`
+	Status      int    `json:"status" gorm:"default:1"`
+	Quota       int    `json:"quota" gorm:"default:0"`                   // 注册后额外赠送的额度
+	Group       string `json:"group" gorm:"type:varchar(32);default:''"` // 注册后的分组，为空时使用默认分组
+	MaxUses     int    `json:"max_uses" gorm:"default:0"`                // 最多可使用次数，0 为不限制
+	UsedCount   int    `json:"used_count" gorm:"default:0"`
+	ExpiredTime int64  `json:"expired_time" gorm:"bigint;default:0"` // 过期时间，0 为永久
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+	Count       int    `json:"count" gorm:"-:all"` // only for api request
+}
+
+var allowedInvitationCodeOrderFields = map[string]bool{
+	"id":           true,
+	"name":         true,
+	"status":       true,
+	"used_count":   true,
+	"expired_time": true,
+	"created_time": true,
+}
+
+func GetInvitationCodesList(params *GenericParams) (*DataResult[InvitationCode], error) {
+	var codes []*InvitationCode
+	db := DB
+	if params.Keyword != "" {
+		db = db.Where("id = ? or name LIKE ? or code = ?", utils.String2Int(params.Keyword), params.Keyword+"%", params.Keyword)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &codes, allowedInvitationCodeOrderFields)
+}
+
+func GetInvitationCodeById(id int) (*InvitationCode, error) {
+	if id == 0 {
+		return nil, errors.New("id 为空！")
+	}
+	var code InvitationCode
+	err := DB.First(&code, "id = ?", id).Error
+	return &code, err
+}
+
+func (code *InvitationCode) Insert() error {
+	return DB.Create(code).Error
+}
+
+func (code *InvitationCode) Update() error {
+	return DB.Model(code).Select("name", "status", "quota", "group", "max_uses", "expired_time").Updates(code).Error
+}
+
+func (code *InvitationCode) Delete() error {
+	return DB.Delete(code).Error
+}
+
+func DeleteInvitationCodeById(id int) (err error) {
+	if id == 0 {
+		return errors.New("id 为空！")
+	}
+	code := InvitationCode{Id: id}
+	err = DB.Where(code).First(&code).Error
+	if err != nil {
+		return err
+	}
+	return code.Delete()
+}
+
+// Validate 检查预设分组是否存在
+func (code *InvitationCode) Validate() error {
+	if len(code.Name) == 0 || len(code.Name) > 20 {
+		return errors.New("邀请码名称长度必须在1-20之间")
+	}
+	if code.Quota < 0 || code.MaxUses < 0 {
+		return errors.New("额度和使用次数不能为负数")
+	}
+	if code.Group != "" && GlobalUserGroupRatio.GetBySymbol(code.Group) == nil {
+		return errors.New("分组不存在")
+	}
+	return nil
+}
+
+// UseInvitationCode 占用一次邀请码，注册失败时需要调用 ReleaseInvitationCode 归还
+func UseInvitationCode(code string) (*InvitationCode, error) {
+	if code == "" {
+		return nil, errors.New("未提供邀请码")
+	}
+
+	invitation := &InvitationCode{}
+	if err := DB.Where("code = ?", code).First(invitation).Error; err != nil {
+		return nil, errors.New("无效的邀请码")
+	}
+
+	now := utils.GetTimestamp()
+	if invitation.Status != InvitationCodeStatusEnabled {
+		return nil, errors.New("邀请码已被禁用")
+	}
+	if invitation.ExpiredTime > 0 && invitation.ExpiredTime <= now {
+		return nil, errors.New("邀请码已过期")
+	}
+
+	// 使用条件更新避免并发注册超出次数
+	result := DB.Model(&InvitationCode{}).
+		Where("id = ? AND (max_uses = 0 OR used_count < max_uses)", invitation.Id).
+		Update("used_count", gorm.Expr("used_count + 1"))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, errors.New("邀请码使用次数已达上限")
+	}
+
+	return invitation, nil
+}
+
+// ReleaseInvitationCode 归还注册失败时占用的次数
+func ReleaseInvitationCode(invitation *InvitationCode) {
+	err := DB.Model(&InvitationCode{}).
+		Where("id = ? AND used_count > 0", invitation.Id).
+		Update("used_count", gorm.Expr("used_count - 1")).Error
+	if err != nil {
+		logger.SysError("failed to release invitation code: " + err.Error())
+	}
+}
+
+// ApplyInvitationQuota 新用户创建后赠送邀请码预设的额度，分组在创建前设置
+func ApplyInvitationQuota(userId int, invitation *InvitationCode) {
+	if invitation.Quota <= 0 {
+		return
+	}
+
+	if err := IncreaseUserQuota(userId, invitation.Quota); err != nil {
+		logger.SysError("failed to apply invitation quota: " + err.Error())
+		return
+	}
+	RecordLog(userId, LogTypeSystem, fmt.Sprintf("使用邀请码 %s 注册赠送 %s", invitation.Name, common.LogQuota(invitation.Quota)))
+}
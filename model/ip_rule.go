@@ -0,0 +1,219 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"one-api/common/utils"
+	"strings"
+	"sync"
+)
+
+const (
+	IPRuleTypeBlock = "block"
+	IPRuleTypeAllow = "allow"
+)
+
+// IPRule 管理员维护的 IP 黑白名单，IP 可以是单个地址或 CIDR
+type IPRule struct {
+	Id          int    `json:"id"`
+	IP          string `json:"ip" gorm:"type:varchar(64);uniqueIndex"`
+	Type        string `json:"type" gorm:"type:varchar(16)"`
+	Reason      string `json:"reason" gorm:"type:varchar(255);default:''"`
+	ExpiredTime int64  `json:"expired_time" gorm:"bigint;default:0"` // 过期时间，0 为永久
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+
+	network *net.IPNet
+}
+
+type SearchIPRuleParams struct {
+	IP   string `form:"ip"`
+	Type string `form:"type"`
+	PaginationParams
+}
+
+var allowedIPRuleOrderFields = map[string]bool{
+	"id":           true,
+	"ip":           true,
+	"expired_time": true,
+	"created_time": true,
+}
+
+func GetIPRulesList(params *SearchIPRuleParams) (*DataResult[IPRule], error) {
+	var rules []*IPRule
+	db := DB
+
+	if params.IP != "" {
+		db = db.Where("ip LIKE ?", params.IP+"%")
+	}
+	if params.Type != "" {
+		db = db.Where("type = ?", params.Type)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &rules, allowedIPRuleOrderFields)
+}
+
+func GetIPRuleById(id int) (*IPRule, error) {
+	var rule IPRule
+	err := DB.Where("id = ?", id).First(&rule).Error
+	return &rule, err
+}
+
+func (r *IPRule) Create() error {
+	r.CreatedTime = utils.GetTimestamp()
+	err := DB.Create(r).Error
+	if err == nil {
+		reloadIPRules()
+	}
+	return err
+}
+
+func (r *IPRule) Update() error {
+	err := DB.Select("ip", "type", "reason", "expired_time").Updates(r).Error
+	if err == nil {
+		reloadIPRules()
+	}
+	return err
+}
+
+func (r *IPRule) Delete() error {
+	err := DB.Delete(r).Error
+	if err == nil {
+		reloadIPRules()
+	}
+	return err
+}
+
+// Validate 校验规则类型和 IP 格式
+func (r *IPRule) Validate() error {
+	if r.Type != IPRuleTypeBlock && r.Type != IPRuleTypeAllow {
+		return fmt.Errorf("不支持的规则类型: %s", r.Type)
+	}
+
+	r.IP = strings.TrimSpace(r.IP)
+	if r.IP == "" {
+		return errors.New("IP 不能为空")
+	}
+
+	return r.parse()
+}
+
+func (r *IPRule) parse() error {
+	if strings.Contains(r.IP, "/") {
+		_, network, err := net.ParseCIDR(r.IP)
+		if err != nil {
+			return fmt.Errorf("无效的 CIDR: %s", r.IP)
+		}
+		r.network = network
+		return nil
+	}
+
+	ip := net.ParseIP(r.IP)
+	if ip == nil {
+		return fmt.Errorf("无效的 IP: %s", r.IP)
+	}
+	bits := 128
+	if ip.To4() != nil {
+		ip = ip.To4()
+		bits = 32
+	}
+	r.network = &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}
+	return nil
+}
+
+func (r *IPRule) expired(now int64) bool {
+	return r.ExpiredTime > 0 && r.ExpiredTime <= now
+}
+
+// reloadIPRules 重新加载本实例的规则，并通知其它实例
+func reloadIPRules() {
+	IPRulesInstance.Load()
+	if config.RedisEnabled {
+		_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+	}
+}
+
+// IPRules IP 黑白名单的内存缓存
+type IPRules struct {
+	sync.RWMutex
+	allow []*IPRule
+	block []*IPRule
+}
+
+var IPRulesInstance = &IPRules{}
+
+func NewIPRules() {
+	if err := IPRulesInstance.Load(); err != nil {
+		logger.SysError("Failed to initialize IPRules:" + err.Error())
+	}
+}
+
+func (p *IPRules) Load() error {
+	var rules []*IPRule
+	if err := DB.Find(&rules).Error; err != nil {
+		return err
+	}
+
+	now := utils.GetTimestamp()
+	var allow, block []*IPRule
+	for _, rule := range rules {
+		if rule.expired(now) {
+			continue
+		}
+		if err := rule.parse(); err != nil {
+			logger.SysError(fmt.Sprintf("failed to parse ip rule %d: %s", rule.Id, err.Error()))
+			continue
+		}
+		if rule.Type == IPRuleTypeAllow {
+			allow = append(allow, rule)
+		} else {
+			block = append(block, rule)
+		}
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.allow = allow
+	p.block = block
+
+	return nil
+}
+
+// IsAllowed IP 是否在白名单中，白名单中的 IP 不受黑名单和自动封禁限制
+func (p *IPRules) IsAllowed(ip string) bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	return matchIPRules(p.allow, ip)
+}
+
+// IsBlocked IP 是否在黑名单中
+func (p *IPRules) IsBlocked(ip string) bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	return matchIPRules(p.block, ip)
+}
+
+func matchIPRules(rules []*IPRule, ip string) bool {
+	if len(rules) == 0 {
+		return false
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	now := utils.GetTimestamp()
+	for _, rule := range rules {
+		if !rule.expired(now) && rule.network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
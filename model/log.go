@@ -2,7 +2,9 @@ package model
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"one-api/common"
 	"one-api/common/config"
 	"one-api/common/logger"
 	"one-api/common/utils"
@@ -13,31 +15,41 @@ import (
 
 type Log struct {
 	Id               int                                `json:"id"`
-	UserId           int                                `json:"user_id" gorm:"index"`
-	CreatedAt        int64                              `json:"created_at" gorm:"bigint;index:idx_created_at_type"`
+	UserId           int                                `json:"user_id" gorm:"index;index:idx_user_id_created_at,priority:1"`
+	CreatedAt        int64                              `json:"created_at" gorm:"bigint;index:idx_created_at_type;index:idx_user_id_created_at,priority:2;index:idx_channel_id_created_at,priority:2"`
 	Type             int                                `json:"type" gorm:"index:idx_created_at_type"`
 	Content          string                             `json:"content"`
 	Username         string                             `json:"username" gorm:"index:index_username_model_name,priority:2;default:''"`
+	TokenId          int                                `json:"token_id" gorm:"index;default:0"`
 	TokenName        string                             `json:"token_name" gorm:"index;default:''"`
 	ModelName        string                             `json:"model_name" gorm:"index;index:index_username_model_name,priority:1;default:''"`
 	Quota            int                                `json:"quota" gorm:"default:0"`
 	PromptTokens     int                                `json:"prompt_tokens" gorm:"default:0"`
 	CompletionTokens int                                `json:"completion_tokens" gorm:"default:0"`
-	ChannelId        int                                `json:"channel_id" gorm:"index"`
+	ChannelId        int                                `json:"channel_id" gorm:"index;index:idx_channel_id_created_at,priority:1"`
 	RequestTime      int                                `json:"request_time" gorm:"default:0"`
 	IsStream         bool                               `json:"is_stream" gorm:"default:false"`
 	SourceIp         string                             `json:"source_ip" gorm:"default:''"`
+	RequestId        string                             `json:"request_id" gorm:"type:varchar(64);index;default:''"`
 	Metadata         datatypes.JSONType[map[string]any] `json:"metadata" gorm:"type:json"`
+	QuotaText        string                             `json:"quota_text" gorm:"-:all"` // 按额度显示设置格式化的额度
 
 	Channel *Channel `json:"channel" gorm:"foreignKey:Id;references:ChannelId"`
 }
 
+func (log *Log) AfterFind(tx *gorm.DB) (err error) {
+	log.QuotaText = common.FormatQuota(log.Quota)
+	return nil
+}
+
 const (
 	LogTypeUnknown = iota
 	LogTypeTopup
 	LogTypeConsume
 	LogTypeManage
 	LogTypeSystem
+	LogTypeRefund
+	LogTypeTransfer
 )
 
 func RecordQuotaLog(userId int, logType int, quota int, ip string, content string) {
@@ -79,13 +91,16 @@ func RecordLog(userId int, logType int, content string) {
 	}
 }
 
+// RecordConsumeLog 记录消费日志，idempotencyKey 为 ClaimBilling 占用的幂等键，同一个键只记录一次，为空时不检查
 func RecordConsumeLog(
 	ctx context.Context,
+	idempotencyKey string,
 	userId int,
 	channelId int,
 	promptTokens int,
 	completionTokens int,
 	modelName string,
+	tokenId int,
 	tokenName string,
 	quota int,
 	content string,
@@ -99,6 +114,7 @@ func RecordConsumeLog(
 	}
 
 	username, _ := CacheGetUsername(userId)
+	requestId, _ := ctx.Value(logger.RequestIdKey).(string)
 
 	log := &Log{
 		UserId:           userId,
@@ -108,6 +124,7 @@ func RecordConsumeLog(
 		Content:          content,
 		PromptTokens:     promptTokens,
 		CompletionTokens: completionTokens,
+		TokenId:          tokenId,
 		TokenName:        tokenName,
 		ModelName:        modelName,
 		Quota:            quota,
@@ -115,13 +132,18 @@ func RecordConsumeLog(
 		RequestTime:      requestTime,
 		IsStream:         isStream,
 		SourceIp:         sourceIp,
+		RequestId:        requestId,
 	}
 
 	if metadata != nil {
 		log.Metadata = datatypes.NewJSONType(metadata)
 	}
 
-	err := DB.Create(log).Error
+	err := createConsumeLog(idempotencyKey, log)
+	if errors.Is(err, errConsumeLogRecorded) {
+		logger.LogWarn(ctx, "consume log of "+idempotencyKey+" already recorded, skipped")
+		return
+	}
 	if err != nil {
 		logger.LogError(ctx, "failed to record log: "+err.Error())
 	}
@@ -137,8 +159,33 @@ type LogsListParams struct {
 	TokenName      string `form:"token_name"`
 	ChannelId      int    `form:"channel_id"`
 	SourceIp       string `form:"source_ip"`
+	UserId         int    `form:"user_id"`
+	Tag            string `form:"tag"`              // 渠道标签
+	Status         string `form:"status"`           // charged 为扣费的日志，free 为未扣费的日志
+	MinRequestTime int    `form:"min_request_time"` // 毫秒
+	MaxRequestTime int    `form:"max_request_time"` // 毫秒
+	RequestId      string `form:"request_id"`
+	Keyword        string `form:"keyword"` // 搜索日志内容和 request_capture 保存的请求体
+}
+
+// LogsCursorParams 游标分页，cursor 为上一页最后一条日志的 id，第一页为 0
+type LogsCursorParams struct {
+	LogsListParams
+	Cursor int `form:"cursor"`
+}
+
+// LogsCursorResult 按 id 倒序的一页日志，没有更多日志时 NextCursor 为 0
+type LogsCursorResult struct {
+	Data       []*Log `json:"data"`
+	Size       int    `json:"size"`
+	NextCursor int    `json:"next_cursor"`
 }
 
+const (
+	LogStatusCharged = "charged"
+	LogStatusFree    = "free"
+)
+
 var allowedLogsOrderFields = map[string]bool{
 	"created_at": true,
 	"channel_id": true,
@@ -150,13 +197,48 @@ var allowedLogsOrderFields = map[string]bool{
 }
 
 func GetLogsList(params *LogsListParams) (*DataResult[Log], error) {
-	var tx *gorm.DB
 	var logs []*Log
 
-	tx = DB.Preload("Channel", func(db *gorm.DB) *gorm.DB {
+	tx := ReadDB().Preload("Channel", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, name")
+	})
+	tx = applyLogsFilters(tx, params)
+
+	return PaginateAndOrder[Log](tx, &params.PaginationParams, &logs, allowedLogsOrderFields)
+}
+
+// SearchLogs 按 id 倒序游标分页，大量日志时不需要统计总数和跳过前面的页
+func SearchLogs(params *LogsCursorParams) (*LogsCursorResult, error) {
+	size := params.Size
+	if size < 1 {
+		size = config.ItemsPerPage
+	}
+	if size > config.MaxRecentItems {
+		return nil, fmt.Errorf("size 参数不能超过 %d", config.MaxRecentItems)
+	}
+
+	tx := ReadDB().Preload("Channel", func(db *gorm.DB) *gorm.DB {
 		return db.Select("id, name")
 	})
+	tx = applyLogsFilters(tx, &params.LogsListParams)
+	if params.Cursor > 0 {
+		tx = tx.Where("id < ?", params.Cursor)
+	}
+
+	logs := make([]*Log, 0, size+1)
+	if err := tx.Order("id DESC").Limit(size + 1).Find(&logs).Error; err != nil {
+		return nil, err
+	}
 
+	result := &LogsCursorResult{Data: logs, Size: size}
+	if len(logs) > size {
+		result.Data = logs[:size]
+		result.NextCursor = logs[size-1].Id
+	}
+	return result, nil
+}
+
+func applyLogsFilters(tx *gorm.DB, params *LogsListParams) *gorm.DB {
 	if params.LogType != LogTypeUnknown {
 		tx = tx.Where("type = ?", params.LogType)
 	}
@@ -166,6 +248,9 @@ func GetLogsList(params *LogsListParams) (*DataResult[Log], error) {
 	if params.Username != "" {
 		tx = tx.Where("username = ?", params.Username)
 	}
+	if params.UserId != 0 {
+		tx = tx.Where("user_id = ?", params.UserId)
+	}
 	if params.TokenName != "" {
 		tx = tx.Where("token_name = ?", params.TokenName)
 	}
@@ -178,17 +263,39 @@ func GetLogsList(params *LogsListParams) (*DataResult[Log], error) {
 	if params.ChannelId != 0 {
 		tx = tx.Where("channel_id = ?", params.ChannelId)
 	}
+	if params.Tag != "" {
+		tx = tx.Where("channel_id IN (?)", ReadDB().Model(&Channel{}).Select("id").Where("tag = ?", params.Tag))
+	}
 	if params.SourceIp != "" {
 		tx = tx.Where("source_ip = ?", params.SourceIp)
 	}
-
-	return PaginateAndOrder[Log](tx, &params.PaginationParams, &logs, allowedLogsOrderFields)
+	switch params.Status {
+	case LogStatusCharged:
+		tx = tx.Where("quota > 0")
+	case LogStatusFree:
+		tx = tx.Where("quota = 0")
+	}
+	if params.MinRequestTime > 0 {
+		tx = tx.Where("request_time >= ?", params.MinRequestTime)
+	}
+	if params.MaxRequestTime > 0 {
+		tx = tx.Where("request_time <= ?", params.MaxRequestTime)
+	}
+	if params.RequestId != "" {
+		tx = tx.Where("request_id = ?", params.RequestId)
+	}
+	if params.Keyword != "" {
+		keyword := "%" + params.Keyword + "%"
+		captured := ReadDB().Model(&RequestCapture{}).Select("request_id").Where("body LIKE ?", keyword)
+		tx = tx.Where("content LIKE ? OR request_id IN (?)", keyword, captured)
+	}
+	return tx
 }
 
 func GetUserLogsList(userId int, params *LogsListParams) (*DataResult[Log], error) {
 	var logs []*Log
 
-	tx := DB.Where("user_id = ?", userId).Omit("id")
+	tx := ReadDB().Where("user_id = ?", userId).Omit("id")
 
 	if params.LogType != LogTypeUnknown {
 		tx = tx.Where("type = ?", params.LogType)
@@ -209,18 +316,69 @@ func GetUserLogsList(userId int, params *LogsListParams) (*DataResult[Log], erro
 	return PaginateAndOrder[Log](tx, &params.PaginationParams, &logs, allowedLogsOrderFields)
 }
 
+// GetLogsAfterId 按 id 升序获取指定 id 之后的日志，用于持续订阅新日志
+func GetLogsAfterId(afterId int, params *LogsListParams, limit int) (logs []*Log, err error) {
+	tx := ReadDB().Where("id > ?", afterId)
+	if params.LogType != LogTypeUnknown {
+		tx = tx.Where("type = ?", params.LogType)
+	}
+	if params.ModelName != "" {
+		tx = tx.Where("model_name = ?", params.ModelName)
+	}
+	if params.Username != "" {
+		tx = tx.Where("username = ?", params.Username)
+	}
+	err = tx.Order("id asc").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+func GetLogById(id int) (*Log, error) {
+	var log Log
+	err := ReadDB().First(&log, id).Error
+	return &log, err
+}
+
+func GetMaxLogId() (id int, err error) {
+	err = ReadDB().Model(&Log{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
+
 func SearchAllLogs(keyword string) (logs []*Log, err error) {
-	err = DB.Where("type = ? or content LIKE ?", keyword, keyword+"%").Order("id desc").Limit(config.MaxRecentItems).Find(&logs).Error
+	err = ReadDB().Where("type = ? or content LIKE ?", keyword, keyword+"%").Order("id desc").Limit(config.MaxRecentItems).Find(&logs).Error
 	return logs, err
 }
 
 func SearchUserLogs(userId int, keyword string) (logs []*Log, err error) {
-	err = DB.Where("user_id = ? and type = ?", userId, keyword).Order("id desc").Limit(config.MaxRecentItems).Omit("id").Find(&logs).Error
+	err = ReadDB().Where("user_id = ? and type = ?", userId, keyword).Order("id desc").Limit(config.MaxRecentItems).Omit("id").Find(&logs).Error
 	return logs, err
 }
 
+// SumUsedQuota 不按令牌筛选时，完整的小时从小时统计读取，只扫描两端不足一小时和尚未汇总的日志
+// 按用户名筛选时小时统计按用户 ID 汇总，用户改名前的日志也会计入
 func SumUsedQuota(startTimestamp int64, endTimestamp int64, modelName string, username string, tokenName string, channel int) (quota int) {
-	tx := DB.Table("logs").Select(assembleSumSelectStr("quota"))
+	if tokenName != "" {
+		return sumLogQuota(startTimestamp, endTimestamp, modelName, username, tokenName, channel)
+	}
+	from, to, err := hourlyRollupRange(startTimestamp, endTimestamp)
+	if err != nil {
+		return sumLogQuota(startTimestamp, endTimestamp, modelName, username, tokenName, channel)
+	}
+
+	userId := 0
+	if username != "" {
+		if err := ReadDB().Model(&User{}).Select("id").Where("username = ?", username).Limit(1).Scan(&userId).Error; err != nil || userId == 0 {
+			return sumLogQuota(startTimestamp, endTimestamp, modelName, username, tokenName, channel)
+		}
+	}
+
+	quota = sumHourlyQuota(from, to, modelName, userId, channel)
+	quota += sumLogQuota(startTimestamp, from-1, modelName, username, tokenName, channel)
+	quota += sumLogQuota(to, endTimestamp, modelName, username, tokenName, channel)
+	return quota
+}
+
+func sumLogQuota(startTimestamp int64, endTimestamp int64, modelName string, username string, tokenName string, channel int) (quota int) {
+	tx := ReadDB().Table("logs").Select(assembleSumSelectStr("quota"))
 	if username != "" {
 		tx = tx.Where("username = ?", username)
 	}
@@ -0,0 +1,57 @@
+package model
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// logArchiveBatchSize 每个归档文件包含的日志条数
+const logArchiveBatchSize = 10000
+
+// ArchiveLogs 将 before 之前的消费日志按 id 顺序分批压缩为 JSON Lines，交给 upload 保存后从数据库删除
+// 文件名为 日期/起始id-结束id.jsonl.gz，日期取该批第一条日志的时间；upload 失败时停止，已归档的批次不受影响
+func ArchiveLogs(before int64, upload func(name string, data []byte) error) (int64, error) {
+	var archived int64
+	for {
+		var logs []*Log
+		err := DB.Where("type = ? AND created_at < ?", LogTypeConsume, before).
+			Order("id asc").Limit(logArchiveBatchSize).Find(&logs).Error
+		if err != nil {
+			return archived, err
+		}
+		if len(logs) == 0 {
+			return archived, nil
+		}
+
+		var buffer bytes.Buffer
+		writer := gzip.NewWriter(&buffer)
+		encoder := json.NewEncoder(writer)
+		for _, log := range logs {
+			if err := encoder.Encode(log); err != nil {
+				return archived, err
+			}
+		}
+		if err := writer.Close(); err != nil {
+			return archived, err
+		}
+
+		firstId, lastId := logs[0].Id, logs[len(logs)-1].Id
+		name := fmt.Sprintf("%s/%d-%d.jsonl.gz", time.Unix(logs[0].CreatedAt, 0).Format("2006-01-02"), firstId, lastId)
+		if err := upload(name, buffer.Bytes()); err != nil {
+			return archived, err
+		}
+
+		result := DB.Where("type = ? AND created_at < ? AND id >= ? AND id <= ?", LogTypeConsume, before, firstId, lastId).Delete(&Log{})
+		if result.Error != nil {
+			return archived, result.Error
+		}
+		archived += result.RowsAffected
+
+		if len(logs) < logArchiveBatchSize {
+			return archived, nil
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"one-api/common"
@@ -9,7 +10,6 @@ import (
 	"one-api/common/utils"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/spf13/viper"
 	"gorm.io/driver/mysql"
@@ -20,15 +20,65 @@ import (
 
 var DB *gorm.DB
 
+// 只读从库，未配置时为 nil，通过 ReadDB 使用
+var replicaDB *gorm.DB
+
+// ReadDB 返回用于只读查询的连接，未配置从库时返回主库
+// 从库存在复制延迟，需要读取刚写入数据的场景（写后读、扣费相关查询）应直接使用 DB
+func ReadDB() *gorm.DB {
+	if replicaDB != nil {
+		return replicaDB
+	}
+	return DB
+}
+
+// initReplicaDB 连接只读从库，连接失败时所有查询使用主库
+func initReplicaDB() {
+	dsn := viper.GetString("sql_replica_dsn")
+	if dsn == "" {
+		return
+	}
+
+	if common.UsingSQLite || strings.HasPrefix(dsn, "postgres://") != common.UsingPostgreSQL {
+		logger.SysError("sql_replica_dsn must use the same database type as sql_dsn, replica disabled")
+		return
+	}
+
+	db, err := openDB(dsn)
+	if err != nil {
+		logger.SysError("failed to connect to replica database: " + err.Error())
+		return
+	}
+	if config.Debug {
+		db = db.Debug()
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		logger.SysError("failed to connect to replica database: " + err.Error())
+		return
+	}
+	configurePool(sqlDB)
+
+	replicaDB = db
+	logger.SysLog("replica database enabled for read-only queries")
+}
+
 func SetupDB() {
 	err := InitDB()
 	if err != nil {
 		logger.FatalLog("failed to initialize database: " + err.Error())
 	}
+	initReplicaDB()
 	ChannelGroup.Load()
 	GlobalUserGroupRatio.Load()
 	config.RootUserEmail = GetRootUserEmail()
 	NewModelOwnedBys()
+	NewModelInfos()
+	NewPromptTemplates()
+	NewIPRules()
+	NewModelRoutes()
+	NewClientCerts()
 
 	if viper.GetBool("batch_update_enabled") {
 		config.BatchUpdateEnabled = true
@@ -42,21 +92,7 @@ func createRootAccountIfNeed() error {
 	var user User
 	//if user.Status != common.UserStatusEnabled {
 	if err := DB.First(&user).Error; err != nil {
-		logger.SysLog("no user exists, create a root user for you: username is root, password is 123456")
-		hashedPassword, err := common.Password2Hash("123456")
-		if err != nil {
-			return err
-		}
-		rootUser := User{
-			Username:    "root",
-			Password:    hashedPassword,
-			Role:        config.RoleRootUser,
-			Status:      config.UserStatusEnabled,
-			DisplayName: "Root User",
-			AccessToken: utils.GetUUID(),
-			Quota:       100000000,
-		}
-		DB.Create(&rootUser)
+		return bootstrap()
 	}
 	return nil
 }
@@ -64,29 +100,15 @@ func createRootAccountIfNeed() error {
 func chooseDB() (*gorm.DB, error) {
 	if viper.IsSet("sql_dsn") {
 		dsn := viper.GetString("sql_dsn")
-		localTimezone := utils.GetLocalTimezone()
 		if strings.HasPrefix(dsn, "postgres://") {
 			// Use PostgreSQL
 			logger.SysLog("using PostgreSQL as database")
 			common.UsingPostgreSQL = true
-			dsn = dsnAddArg(dsn, "timezone", localTimezone)
-
-			return gorm.Open(postgres.New(postgres.Config{
-				DSN:                  dsn,
-				PreferSimpleProtocol: true, // disables implicit prepared statement usage
-			}), &gorm.Config{
-				PrepareStmt: true, // precompile SQL
-			})
-
+		} else {
+			// Use MySQL
+			logger.SysLog("using MySQL as database")
 		}
-		// Use MySQL
-		logger.SysLog("using MySQL as database")
-		// mysql 时区设置
-		dsn = dsnAddArg(dsn, "loc", localTimezone)
-		// dsn = dsnAddArg(dsn, "parseTime", "true")
-		return gorm.Open(mysql.Open(dsn), &gorm.Config{
-			PrepareStmt: true, // precompile SQL
-		})
+		return openDB(dsn)
 	}
 	// Use SQLite
 	logger.SysLog("SQL_DSN not set, using SQLite as database")
@@ -94,6 +116,31 @@ func chooseDB() (*gorm.DB, error) {
 	config := fmt.Sprintf("?_busy_timeout=%d", utils.GetOrDefault("sqlite_busy_timeout", 3000))
 	return gorm.Open(sqlite.Open(viper.GetString("sqlite_path")+config), &gorm.Config{
 		PrepareStmt: true, // precompile SQL
+		Logger:      newSQLLogger(),
+	})
+}
+
+// openDB 按 DSN 打开 MySQL 或 PostgreSQL 连接
+func openDB(dsn string) (*gorm.DB, error) {
+	localTimezone := utils.GetLocalTimezone()
+	if strings.HasPrefix(dsn, "postgres://") {
+		dsn = dsnAddArg(dsn, "timezone", localTimezone)
+
+		return gorm.Open(postgres.New(postgres.Config{
+			DSN:                  dsn,
+			PreferSimpleProtocol: true, // disables implicit prepared statement usage
+		}), &gorm.Config{
+			PrepareStmt: true, // precompile SQL
+			Logger:      newSQLLogger(),
+		})
+	}
+
+	// mysql 时区设置
+	dsn = dsnAddArg(dsn, "loc", localTimezone)
+	// dsn = dsnAddArg(dsn, "parseTime", "true")
+	return gorm.Open(mysql.Open(dsn), &gorm.Config{
+		PrepareStmt: true, // precompile SQL
+		Logger:      newSQLLogger(),
 	})
 }
 
@@ -108,10 +155,7 @@ func InitDB() (err error) {
 		if err != nil {
 			return err
 		}
-
-		sqlDB.SetMaxIdleConns(utils.GetOrDefault("SQL_MAX_IDLE_CONNS", 100))
-		sqlDB.SetMaxOpenConns(utils.GetOrDefault("SQL_MAX_OPEN_CONNS", 1000))
-		sqlDB.SetConnMaxLifetime(time.Second * time.Duration(utils.GetOrDefault("SQL_MAX_LIFETIME", 60)))
+		configurePool(sqlDB)
 
 		if !config.IsMasterNode {
 			return nil
@@ -120,88 +164,8 @@ func InitDB() (err error) {
 
 		migrationBefore(DB)
 
-		err = db.AutoMigrate(&Channel{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&Token{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&User{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&Option{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&Redemption{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&Log{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&TelegramMenu{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&Price{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&Midjourney{})
-		if err != nil {
-			return err
-		}
-
-		err = db.AutoMigrate(&Payment{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&Order{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&Task{})
-		if err != nil {
-			return err
-		}
-		err = db.AutoMigrate(&Statistics{})
-		if err != nil {
-			return err
-		}
-
-		err = db.AutoMigrate(&UserGroup{})
-		if err != nil {
-			return err
-		}
-
-		err = db.AutoMigrate(&ModelOwnedBy{})
-		if err != nil {
-			return err
-		}
-
-		err = db.AutoMigrate(&ModelInfo{})
-		if err != nil {
-			return err
-		}
-
-		err = DB.AutoMigrate(&WebAuthnCredential{})
-		if err != nil {
-			return err
-		}
-
-		if config.UserInvoiceMonth {
-			err = db.AutoMigrate(&StatisticsMonthGeneratedHistory{})
-			if err != nil {
-				return err
-			}
-
-			err = db.AutoMigrate(&StatisticsMonth{})
-			if err != nil {
+		for _, table := range migrationModels() {
+			if err = db.AutoMigrate(table); err != nil {
 				return err
 			}
 		}
@@ -217,6 +181,63 @@ func InitDB() (err error) {
 	return err
 }
 
+// migrationModels 需要建表的模型，迁移工具按此顺序复制数据
+func migrationModels() []any {
+	models := []any{
+		&Channel{},
+		&Token{},
+		&User{},
+		&Option{},
+		&Redemption{},
+		&RedemptionBatch{},
+		&Log{},
+		&TelegramMenu{},
+		&Price{},
+		&Midjourney{},
+		&Payment{},
+		&Order{},
+		&Task{},
+		&Statistics{},
+		&StatisticsHourly{},
+		&UserGroup{},
+		&ModelOwnedBy{},
+		&ModelInfo{},
+		&WebAuthnCredential{},
+		&RequestJournal{},
+		&Tenant{},
+		&PromptTemplate{},
+		&ModerationLog{},
+		&IPRule{},
+		&InvitationCode{},
+		&ClientCert{},
+		&ChannelTestReport{},
+		&ChannelTestResult{},
+		&UsageProfile{},
+		&UsageAnomaly{},
+		&ChannelCapture{},
+		&QuotaGrant{},
+		&QuotaGrantRecord{},
+		&Referral{},
+		&TrialToken{},
+		&VectorStoreBinding{},
+		&PriceProposal{},
+		&PriceSchedule{},
+		&BillingRecord{},
+		&BatchUpdateReceipt{},
+		&QuotaRefund{}, &QuotaTransfer{},
+		&ModelRoute{},
+		&RequestCapture{},
+		&UserSession{},
+		&LoginHistory{},
+		&ChannelTemplate{},
+		&IdempotencyKey{},
+	}
+	if config.UserInvoiceMonth {
+		models = append(models, &StatisticsMonthGeneratedHistory{}, &StatisticsMonth{})
+	}
+	return models
+}
+
 // func MigrateDB(db *gorm.DB) error {
 // 	if DB.Migrator().HasConstraint(&Price{}, "model") {
 // 		fmt.Println("----Price model has constraint----")
@@ -235,7 +256,22 @@ func InitDB() (err error) {
 // 	return nil
 // }
 
+// PingDB 检查主库连接是否可用
+func PingDB(ctx context.Context) error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
 func CloseDB() error {
+	if replicaDB != nil {
+		if sqlDB, err := replicaDB.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+
 	sqlDB, err := DB.DB()
 	if err != nil {
 		return err
@@ -246,7 +282,7 @@ func CloseDB() error {
 
 func dsnAddArg(dsn string, arg string, value string) string {
 	// 如果是MySQL 需要转义
-	if !common.UsingPostgreSQL {
+	if !strings.HasPrefix(dsn, "postgres://") {
 		value = url.QueryEscape(value)
 	}
 
@@ -2,6 +2,7 @@ package model
 
 import (
 	"encoding/json"
+	"fmt"
 	"one-api/common/config"
 	"one-api/common/logger"
 	"strconv"
@@ -443,6 +444,61 @@ func migrateTokenLimitsStructure() *gormigrate.Migration {
 		},
 	}
 }
+
+// hashTokenKeys 将明文保存的令牌 key 改为加盐哈希并记录前缀，包括已删除的令牌，迁移后明文无法恢复
+func hashTokenKeys() *gormigrate.Migration {
+	return &gormigrate.Migration{
+		ID: "202610160001",
+		Migrate: func(tx *gorm.DB) error {
+			var err error
+			switch tx.Dialector.Name() {
+			case "mysql":
+				err = tx.Exec("ALTER TABLE tokens MODIFY COLUMN `key` varchar(128)").Error
+			case "postgres":
+				err = tx.Exec("ALTER TABLE tokens ALTER COLUMN key TYPE varchar(128)").Error
+			}
+			if err != nil {
+				logger.SysLog("修改 tokens.key 字段类型失败: " + err.Error())
+				return err
+			}
+
+			type TokenRaw struct {
+				Id  int    `gorm:"column:id"`
+				Key string `gorm:"column:key"`
+			}
+
+			hashed := 0
+			var tokens []TokenRaw
+			db := tx.Session(&gorm.Session{NewDB: true})
+			err = tx.Table("tokens").Select("id", "key").FindInBatches(&tokens, 1000, func(_ *gorm.DB, _ int) error {
+				for _, token := range tokens {
+					if token.Key == "" || IsHashedTokenKey(token.Key) {
+						continue
+					}
+					if err := db.Table("tokens").Where("id = ?", token.Id).Updates(map[string]any{
+						"key":        HashTokenKey(token.Key),
+						"key_prefix": TokenKeyPrefix(token.Key),
+					}).Error; err != nil {
+						return err
+					}
+					hashed++
+				}
+				return nil
+			}).Error
+			if err != nil {
+				logger.SysLog("令牌 key 哈希失败: " + err.Error())
+				return err
+			}
+
+			logger.SysLog(fmt.Sprintf("已将 %d 个令牌的 key 改为哈希保存", hashed))
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return nil
+		},
+	}
+}
+
 func migrationAfter(db *gorm.DB) error {
 	// 从库不执行
 	if !config.IsMasterNode {
@@ -456,6 +512,7 @@ func migrationAfter(db *gorm.DB) error {
 		addOldTokenMaxId(),
 		addExtraRatios(),
 		migrateTokenLimitsStructure(),
+		hashTokenKeys(),
 	})
 	return m.Migrate()
 }
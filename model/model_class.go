@@ -0,0 +1,130 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 模型类别：客户端请求虚拟模型（如 auto:fast），服务端按分组配置的候选模型和策略解析为具体模型，
+// 运营方调整候选模型时不需要客户端修改模型名称，具体渠道仍由负载均衡在该模型的渠道中选择
+const (
+	ModelClassPrefix = "auto:"
+
+	ModelClassPolicyPriority = "priority" // 按顺序选择第一个有可用渠道的模型
+	ModelClassPolicyCost     = "cost"     // 选择价格最低的模型
+	ModelClassPolicyLatency  = "latency"  // 选择可用渠道延迟最低的模型
+)
+
+// ModelClass 分组内的模型类别
+type ModelClass struct {
+	Models []string `json:"models"`
+	Policy string   `json:"policy"` // 为空时为 priority
+}
+
+// parseModelClasses 解析分组的模型类别，格式为 {"auto:fast": {"models": ["gpt-4o-mini"], "policy": "latency"}}
+func parseModelClasses(raw string) (map[string]*ModelClass, error) {
+	classes := make(map[string]*ModelClass)
+	if err := json.Unmarshal([]byte(raw), &classes); err != nil {
+		return nil, fmt.Errorf("模型类别格式错误: %w", err)
+	}
+
+	for name, class := range classes {
+		if !strings.HasPrefix(name, ModelClassPrefix) || len(name) == len(ModelClassPrefix) || strings.Contains(name, "#") {
+			return nil, fmt.Errorf("模型类别 %s 必须以 %s 开头且不能包含 #", name, ModelClassPrefix)
+		}
+		if class == nil || len(class.Models) == 0 {
+			return nil, fmt.Errorf("模型类别 %s 没有候选模型", name)
+		}
+		for _, modelName := range class.Models {
+			if modelName == "" || strings.HasPrefix(modelName, ModelClassPrefix) {
+				return nil, fmt.Errorf("模型类别 %s 的候选模型不能为空或其它模型类别", name)
+			}
+		}
+		switch class.Policy {
+		case "":
+			class.Policy = ModelClassPolicyPriority
+		case ModelClassPolicyPriority, ModelClassPolicyCost, ModelClassPolicyLatency:
+		default:
+			return nil, fmt.Errorf("模型类别 %s 的策略 %s 不支持", name, class.Policy)
+		}
+	}
+
+	return classes, nil
+}
+
+// resolve 按策略在分组有可用渠道的候选模型中选择
+func (class *ModelClass) resolve(group string) (string, error) {
+	type candidate struct {
+		model   string
+		cost    float64
+		latency int
+	}
+
+	candidates := make([]candidate, 0, len(class.Models))
+	for _, modelName := range class.Models {
+		latency, ok := ChannelGroup.ModelLatency(group, modelName)
+		if !ok {
+			continue
+		}
+		if class.Policy == ModelClassPolicyPriority {
+			return modelName, nil
+		}
+		price := PricingInstance.GetPrice(modelName)
+		candidates = append(candidates, candidate{model: modelName, cost: price.Input + price.Output, latency: latency})
+	}
+
+	if len(candidates) == 0 {
+		return "", errors.New("模型类别的候选模型都没有可用渠道")
+	}
+
+	// 排序稳定，条件相同时保持配置的顺序
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if class.Policy == ModelClassPolicyCost {
+			return candidates[i].cost < candidates[j].cost
+		}
+		// 没有延迟数据的模型排在最后
+		if (candidates[i].latency == 0) != (candidates[j].latency == 0) {
+			return candidates[j].latency == 0
+		}
+		return candidates[i].latency < candidates[j].latency
+	})
+
+	return candidates[0].model, nil
+}
+
+// ModelLatency 分组中模型可用渠道的最低延迟（毫秒），优先使用自动优先级统计的 p95 延迟，否则使用测试的响应时间，
+// 没有延迟数据时为 0，没有可用渠道时返回 false
+func (cc *ChannelsChooser) ModelLatency(group, modelName string) (int, bool) {
+	cc.RLock()
+	defer cc.RUnlock()
+
+	priorities, ok := cc.Rule[group][modelName]
+	if !ok {
+		return 0, false
+	}
+
+	available := false
+	latency := 0
+	for _, channelIds := range priorities {
+		for _, channelId := range channelIds {
+			channel := cc.available(channelId, nil, modelName)
+			if channel == nil {
+				continue
+			}
+			available = true
+
+			channelLatency := channel.ResponseTime
+			if adjustment := GetChannelAdjustment(channelId, modelName); adjustment != nil && adjustment.P95Latency > 0 {
+				channelLatency = int(adjustment.P95Latency)
+			}
+			if channelLatency > 0 && (latency == 0 || channelLatency < latency) {
+				latency = channelLatency
+			}
+		}
+	}
+
+	return latency, available
+}
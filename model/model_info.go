@@ -3,6 +3,7 @@ package model
 import (
 	"one-api/common/logger"
 	"one-api/common/utils"
+	"sync"
 )
 
 type ModelInfo struct {
@@ -16,6 +17,11 @@ type ModelInfo struct {
 	OutputModalities string `json:"output_modalities" gorm:"type:text"`
 	Tags             string `json:"tags" gorm:"type:text"`
 	SupportUrl       string `json:"support_url" gorm:"type:text"`
+	SupportsVision   bool   `json:"supports_vision" gorm:"default:false"`
+	SupportsTools    bool   `json:"supports_tools" gorm:"default:false"`
+	DeprecatedAt     int64  `json:"deprecated_at" gorm:"bigint;default:0"`         // 弃用时间，0 表示未弃用
+	SunsetAt         int64  `json:"sunset_at" gorm:"bigint;default:0"`             // 下线时间，之后的请求改写为后继模型，没有后继模型时拒绝
+	Successor        string `json:"successor" gorm:"type:varchar(100);default:''"` // 后继模型
 	CreatedAt        int64  `json:"created_at" gorm:"autoCreateTime"`
 	UpdatedAt        int64  `json:"updated_at" gorm:"autoUpdateTime"`
 }
@@ -30,19 +36,29 @@ type ModelInfoResponse struct {
 	OutputModalities []string `json:"output_modalities"`
 	Tags             []string `json:"tags"`
 	SupportUrl       []string `json:"support_url"`
+	SupportsVision   bool     `json:"supports_vision"`
+	SupportsTools    bool     `json:"supports_tools"`
+	DeprecatedAt     int64    `json:"deprecated_at,omitempty"`
+	SunsetAt         int64    `json:"sunset_at,omitempty"`
+	Successor        string   `json:"successor,omitempty"`
 	CreatedAt        int64    `json:"created_at"`
 	UpdatedAt        int64    `json:"updated_at"`
 }
 
 func (m *ModelInfo) ToResponse() *ModelInfoResponse {
 	res := &ModelInfoResponse{
-		Model:         m.Model,
-		Name:          m.Name,
-		Description:   m.Description,
-		ContextLength: m.ContextLength,
-		MaxTokens:     m.MaxTokens,
-		CreatedAt:     m.CreatedAt,
-		UpdatedAt:     m.UpdatedAt,
+		Model:          m.Model,
+		Name:           m.Name,
+		Description:    m.Description,
+		ContextLength:  m.ContextLength,
+		MaxTokens:      m.MaxTokens,
+		SupportsVision: m.SupportsVision,
+		SupportsTools:  m.SupportsTools,
+		DeprecatedAt:   m.DeprecatedAt,
+		SunsetAt:       m.SunsetAt,
+		Successor:      m.Successor,
+		CreatedAt:      m.CreatedAt,
+		UpdatedAt:      m.UpdatedAt,
 	}
 
 	res.InputModalities, _ = utils.UnmarshalString[[]string](m.InputModalities)
@@ -71,6 +87,9 @@ func CreateModelInfo(modelInfo *ModelInfo) error {
 	if err != nil {
 		return err
 	}
+
+	ModelInfosInstance.Load()
+
 	return nil
 }
 
@@ -79,6 +98,9 @@ func UpdateModelInfo(modelInfo *ModelInfo) error {
 	if err != nil {
 		return err
 	}
+
+	ModelInfosInstance.Load()
+
 	return nil
 }
 
@@ -114,6 +136,9 @@ func DeleteModelInfo(id int) error {
 	if err != nil {
 		return err
 	}
+
+	ModelInfosInstance.Load()
+
 	return nil
 }
 
@@ -124,3 +149,43 @@ func InitModelInfo() {
 		logger.SysError("Failed to auto migrate ModelInfo: " + err.Error())
 	}
 }
+
+// ModelInfos 模型元数据的内存缓存，用于模型列表展示和请求校验
+type ModelInfos struct {
+	sync.RWMutex
+	ModelInfo map[string]*ModelInfo
+}
+
+var ModelInfosInstance = &ModelInfos{}
+
+func NewModelInfos() {
+	if err := ModelInfosInstance.Load(); err != nil {
+		logger.SysError("Failed to initialize ModelInfos:" + err.Error())
+	}
+}
+
+func (m *ModelInfos) Load() error {
+	modelInfos, err := GetAllModelInfo()
+	if err != nil {
+		return err
+	}
+
+	newModelInfo := make(map[string]*ModelInfo, len(modelInfos))
+	for _, modelInfo := range modelInfos {
+		newModelInfo[modelInfo.Model] = modelInfo
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.ModelInfo = newModelInfo
+
+	return nil
+}
+
+func (m *ModelInfos) Get(modelName string) *ModelInfo {
+	m.RLock()
+	defer m.RUnlock()
+
+	return m.ModelInfo[modelName]
+}
@@ -0,0 +1,162 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"one-api/common/utils"
+	"strings"
+	"sync"
+
+	"gorm.io/datatypes"
+)
+
+// ModelRoute 把模型固定到指定的渠道，优先于权重和优先级选择
+type ModelRoute struct {
+	Id          int                      `json:"id"`
+	Model       string                   `json:"model" gorm:"type:varchar(100);uniqueIndex:idx_model_route_group"`
+	Group       string                   `json:"group" gorm:"type:varchar(32);uniqueIndex:idx_model_route_group;default:''"` // 为空时对所有分组生效
+	ChannelIds  datatypes.JSONSlice[int] `json:"channel_ids" gorm:"type:json"`                                               // 按顺序选择第一个可用的渠道
+	Fallback    bool                     `json:"fallback" gorm:"default:false"`                                              // 渠道都不可用时按常规流程选择，否则直接失败
+	Remark      string                   `json:"remark" gorm:"type:varchar(255);default:''"`
+	CreatedTime int64                    `json:"created_time" gorm:"bigint"`
+}
+
+type SearchModelRouteParams struct {
+	Model string `form:"model"`
+	Group string `form:"group"`
+	PaginationParams
+}
+
+var allowedModelRouteOrderFields = map[string]bool{
+	"id":           true,
+	"model":        true,
+	"group":        true,
+	"created_time": true,
+}
+
+func GetModelRoutesList(params *SearchModelRouteParams) (*DataResult[ModelRoute], error) {
+	var routes []*ModelRoute
+	db := DB
+
+	if params.Model != "" {
+		db = db.Where("model LIKE ?", params.Model+"%")
+	}
+	if params.Group != "" {
+		db = db.Where(quotePostgresField("group")+" = ?", params.Group)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &routes, allowedModelRouteOrderFields)
+}
+
+func GetModelRouteById(id int) (*ModelRoute, error) {
+	var route ModelRoute
+	err := DB.Where("id = ?", id).First(&route).Error
+	return &route, err
+}
+
+func (r *ModelRoute) Create() error {
+	r.CreatedTime = utils.GetTimestamp()
+	err := DB.Create(r).Error
+	if err == nil {
+		reloadModelRoutes()
+	}
+	return err
+}
+
+func (r *ModelRoute) Update() error {
+	err := DB.Select("model", "group", "channel_ids", "fallback", "remark").Updates(r).Error
+	if err == nil {
+		reloadModelRoutes()
+	}
+	return err
+}
+
+func (r *ModelRoute) Delete() error {
+	err := DB.Delete(r).Error
+	if err == nil {
+		reloadModelRoutes()
+	}
+	return err
+}
+
+// Validate 校验模型和渠道列表
+func (r *ModelRoute) Validate() error {
+	r.Model = strings.TrimSpace(r.Model)
+	r.Group = strings.TrimSpace(r.Group)
+	if r.Model == "" {
+		return errors.New("模型不能为空")
+	}
+	if len(r.ChannelIds) == 0 {
+		return errors.New("渠道不能为空")
+	}
+
+	seen := make(map[int]bool, len(r.ChannelIds))
+	for _, channelId := range r.ChannelIds {
+		if seen[channelId] {
+			return fmt.Errorf("渠道 #%d 重复", channelId)
+		}
+		seen[channelId] = true
+		if _, err := GetChannelById(channelId); err != nil {
+			return fmt.Errorf("渠道 #%d 不存在", channelId)
+		}
+	}
+	return nil
+}
+
+// reloadModelRoutes 重新加载本实例的路由，并通知其它实例
+func reloadModelRoutes() {
+	ModelRoutesInstance.Load()
+	if config.RedisEnabled {
+		_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+	}
+}
+
+// ModelRoutes 模型路由的内存缓存，键为 分组/模型
+type ModelRoutes struct {
+	sync.RWMutex
+	routes map[string]*ModelRoute
+}
+
+var ModelRoutesInstance = &ModelRoutes{}
+
+func NewModelRoutes() {
+	if err := ModelRoutesInstance.Load(); err != nil {
+		logger.SysError("Failed to initialize ModelRoutes:" + err.Error())
+	}
+}
+
+func (p *ModelRoutes) Load() error {
+	var routes []*ModelRoute
+	if err := DB.Find(&routes).Error; err != nil {
+		return err
+	}
+
+	newRoutes := make(map[string]*ModelRoute, len(routes))
+	for _, route := range routes {
+		newRoutes[route.Group+"/"+route.Model] = route
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.routes = newRoutes
+
+	return nil
+}
+
+// Match 返回模型在分组下的路由，分组没有单独设置时使用对所有分组生效的路由
+func (p *ModelRoutes) Match(group, modelName string) *ModelRoute {
+	p.RLock()
+	defer p.RUnlock()
+
+	if len(p.routes) == 0 {
+		return nil
+	}
+	if route, ok := p.routes[group+"/"+modelName]; ok && group != "" {
+		return route
+	}
+	return p.routes["/"+modelName]
+}
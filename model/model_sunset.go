@@ -0,0 +1,103 @@
+package model
+
+import (
+	"fmt"
+	"one-api/common/utils"
+	"sort"
+	"strings"
+)
+
+// 后继模型最多改写的次数，避免配置成环
+const maxSuccessorHops = 5
+
+// IsSunset 模型是否已经下线
+func (m *ModelInfo) IsSunset(now int64) bool {
+	return m.SunsetAt > 0 && now >= m.SunsetAt
+}
+
+// ResolveSunsetModel 已下线的模型改写为后继模型，返回最终模型和请求模型的元数据
+// 下线且没有后继模型时返回错误
+func (m *ModelInfos) ResolveSunsetModel(modelName string) (string, *ModelInfo, error) {
+	requested := m.Get(modelName)
+	now := utils.GetTimestamp()
+
+	info := requested
+	for hops := 0; info != nil && info.IsSunset(now); hops++ {
+		if info.Successor == "" {
+			return "", requested, fmt.Errorf("The model `%s` has been sunset and is no longer available", info.Model)
+		}
+		if hops >= maxSuccessorHops {
+			return "", requested, fmt.Errorf("The model `%s` has too many successor rewrites", modelName)
+		}
+		modelName = info.Successor
+		info = m.Get(modelName)
+	}
+
+	return modelName, requested, nil
+}
+
+// DeprecatedModelChannel 仍在提供弃用模型的渠道
+type DeprecatedModelChannel struct {
+	Id     int    `json:"id"`
+	Name   string `json:"name"`
+	Status int    `json:"status"`
+}
+
+// DeprecatedModelUsage 弃用模型及仍在提供它的渠道
+type DeprecatedModelUsage struct {
+	Model        string                    `json:"model"`
+	DeprecatedAt int64                     `json:"deprecated_at"`
+	SunsetAt     int64                     `json:"sunset_at"`
+	Successor    string                    `json:"successor"`
+	Sunset       bool                      `json:"sunset"`
+	Channels     []*DeprecatedModelChannel `json:"channels"`
+}
+
+// GetDeprecatedModelUsages 列出已弃用或已设置下线时间、且仍有渠道提供的模型
+func GetDeprecatedModelUsages() ([]*DeprecatedModelUsage, error) {
+	ModelInfosInstance.RLock()
+	deprecated := make(map[string]*DeprecatedModelUsage)
+	now := utils.GetTimestamp()
+	for name, info := range ModelInfosInstance.ModelInfo {
+		if info.DeprecatedAt == 0 && info.SunsetAt == 0 {
+			continue
+		}
+		deprecated[name] = &DeprecatedModelUsage{
+			Model:        name,
+			DeprecatedAt: info.DeprecatedAt,
+			SunsetAt:     info.SunsetAt,
+			Successor:    info.Successor,
+			Sunset:       info.IsSunset(now),
+			Channels:     []*DeprecatedModelChannel{},
+		}
+	}
+	ModelInfosInstance.RUnlock()
+
+	if len(deprecated) == 0 {
+		return []*DeprecatedModelUsage{}, nil
+	}
+
+	var channels []*Channel
+	if err := DB.Select("id", "name", "status", "models").Order("id").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	for _, channel := range channels {
+		for _, name := range strings.Split(channel.Models, ",") {
+			if usage, ok := deprecated[strings.TrimSpace(name)]; ok {
+				usage.Channels = append(usage.Channels, &DeprecatedModelChannel{Id: channel.Id, Name: channel.Name, Status: channel.Status})
+			}
+		}
+	}
+
+	usages := make([]*DeprecatedModelUsage, 0, len(deprecated))
+	for _, usage := range deprecated {
+		if len(usage.Channels) > 0 {
+			usages = append(usages, usage)
+		}
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Model < usages[j].Model
+	})
+
+	return usages, nil
+}
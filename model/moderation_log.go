@@ -0,0 +1,98 @@
+package model
+
+import (
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"strings"
+)
+
+const (
+	SafeActionBlock = "block"
+	SafeActionMask  = "mask"
+	SafeActionLog   = "log"
+
+	SafeScopePrompt   = "prompt"
+	SafeScopeResponse = "response"
+	SafeScopeAll      = "all"
+
+	ModerationStagePrompt    = "prompt"
+	ModerationStageResponse  = "response"
+	ModerationStageJailbreak = "jailbreak"
+)
+
+// SafePolicy 分组的敏感词处理策略
+type SafePolicy struct {
+	Action   string
+	Prompt   bool
+	Response bool
+}
+
+// ModerationLog 敏感词命中记录
+type ModerationLog struct {
+	Id        int    `json:"id"`
+	CreatedAt int64  `json:"created_at" gorm:"bigint;index"`
+	UserId    int    `json:"user_id" gorm:"index"`
+	TokenId   int    `json:"token_id" gorm:"default:0"`
+	GroupName string `json:"group_name" gorm:"type:varchar(50);default:''"`
+	ModelName string `json:"model_name" gorm:"type:varchar(255);default:''"`
+	RequestId string `json:"request_id" gorm:"type:varchar(64);default:''"`
+	Stage     string `json:"stage" gorm:"type:varchar(16);index"`
+	Action    string `json:"action" gorm:"type:varchar(16)"`
+	Words     string `json:"words" gorm:"type:text"`
+	Score     int    `json:"score" gorm:"default:0"` // 越狱检测得分
+}
+
+func RecordModerationLog(log *ModerationLog, words []string) {
+	log.CreatedAt = utils.GetTimestamp()
+	log.Words = strings.Join(words, ",")
+
+	if err := DB.Create(log).Error; err != nil {
+		logger.SysError("failed to record moderation log: " + err.Error())
+	}
+}
+
+type ModerationLogsListParams struct {
+	PaginationParams
+	UserId         int    `form:"user_id"`
+	GroupName      string `form:"group_name"`
+	ModelName      string `form:"model_name"`
+	Stage          string `form:"stage"`
+	Action         string `form:"action"`
+	StartTimestamp int64  `form:"start_timestamp"`
+	EndTimestamp   int64  `form:"end_timestamp"`
+}
+
+var allowedModerationLogsOrderFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"user_id":    true,
+}
+
+func GetModerationLogsList(params *ModerationLogsListParams) (*DataResult[ModerationLog], error) {
+	var logs []*ModerationLog
+
+	tx := ReadDB().Model(&ModerationLog{})
+	if params.UserId != 0 {
+		tx = tx.Where("user_id = ?", params.UserId)
+	}
+	if params.GroupName != "" {
+		tx = tx.Where("group_name = ?", params.GroupName)
+	}
+	if params.ModelName != "" {
+		tx = tx.Where("model_name = ?", params.ModelName)
+	}
+	if params.Stage != "" {
+		tx = tx.Where("stage = ?", params.Stage)
+	}
+	if params.Action != "" {
+		tx = tx.Where("action = ?", params.Action)
+	}
+	if params.StartTimestamp != 0 {
+		tx = tx.Where("created_at >= ?", params.StartTimestamp)
+	}
+	if params.EndTimestamp != 0 {
+		tx = tx.Where("created_at <= ?", params.EndTimestamp)
+	}
+
+	return PaginateAndOrder[ModerationLog](tx, &params.PaginationParams, &logs, allowedModerationLogsOrderFields)
+}
@@ -1,18 +1,27 @@
 package model
 
 import (
+	"encoding/json"
 	"one-api/common"
 	"one-api/common/config"
 	"one-api/common/logger"
 	"one-api/common/redis"
+	"one-api/common/remoteconfig"
+	"one-api/safty"
 	"strconv"
 	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Option struct {
 	Key   string `json:"key" gorm:"primaryKey"`
 	Value string `json:"value"`
+	// 每次修改加一，更新时传入读取到的版本，版本不一致说明已被他人修改，为 0 时不检查
+	Version int `json:"version" gorm:"default:1"`
+	// Managed 由 etcd 或 Consul 管理，不能在后台修改
+	Managed bool `json:"managed,omitempty" gorm:"-"`
 }
 
 func AllOption() ([]*Option, error) {
@@ -33,6 +42,8 @@ func InitOptionMap() {
 	config.GlobalOption.RegisterBool("GitHubOAuthEnabled", &config.GitHubOAuthEnabled)
 	config.GlobalOption.RegisterBool("WeChatAuthEnabled", &config.WeChatAuthEnabled)
 	config.GlobalOption.RegisterBool("LarkAuthEnabled", &config.LarkAuthEnabled)
+	config.GlobalOption.RegisterBool("WeComAuthEnabled", &config.WeComAuthEnabled)
+	config.GlobalOption.RegisterBool("DingTalkAuthEnabled", &config.DingTalkAuthEnabled)
 	config.GlobalOption.RegisterBool("OIDCAuthEnabled", &config.OIDCAuthEnabled)
 	config.GlobalOption.RegisterBool("TurnstileCheckEnabled", &config.TurnstileCheckEnabled)
 	config.GlobalOption.RegisterBool("RegisterEnabled", &config.RegisterEnabled)
@@ -41,6 +52,8 @@ func InitOptionMap() {
 	config.GlobalOption.RegisterBool("ApproximateTokenEnabled", &config.ApproximateTokenEnabled)
 	config.GlobalOption.RegisterBool("LogConsumeEnabled", &config.LogConsumeEnabled)
 	config.GlobalOption.RegisterBool("DisplayInCurrencyEnabled", &config.DisplayInCurrencyEnabled)
+	config.GlobalOption.RegisterString("QuotaDisplayUnit", &config.QuotaDisplayUnit)
+	config.GlobalOption.RegisterInt("QuotaDisplayDecimals", &config.QuotaDisplayDecimals)
 	config.GlobalOption.RegisterFloat("ChannelDisableThreshold", &config.ChannelDisableThreshold)
 	config.GlobalOption.RegisterBool("EmailDomainRestrictionEnabled", &config.EmailDomainRestrictionEnabled)
 
@@ -51,11 +64,31 @@ func InitOptionMap() {
 		return nil
 	}, "")
 
+	registerCORSOptions()
+
+	config.GlobalOption.RegisterBool("InviteOnlyRegister", &config.InviteOnlyRegister)
+	config.GlobalOption.RegisterInt("RegisterIPLimitNum", &config.RegisterIPLimitNum)
+	config.GlobalOption.RegisterInt("RegisterIPLimitDuration", &config.RegisterIPLimitDuration)
+	config.GlobalOption.RegisterBool("DisposableEmailBlockEnabled", &config.DisposableEmailBlockEnabled)
+	config.GlobalOption.RegisterCustom("DisposableEmailDomains", func() string {
+		return strings.Join(config.DisposableEmailDomains, ",")
+	}, func(value string) error {
+		config.DisposableEmailDomains = strings.Split(value, ",")
+		return nil
+	}, "")
+
 	config.GlobalOption.RegisterString("SMTPServer", &config.SMTPServer)
 	config.GlobalOption.RegisterString("SMTPFrom", &config.SMTPFrom)
 	config.GlobalOption.RegisterInt("SMTPPort", &config.SMTPPort)
 	config.GlobalOption.RegisterString("SMTPAccount", &config.SMTPAccount)
 	config.GlobalOption.RegisterString("SMTPToken", &config.SMTPToken)
+	config.GlobalOption.RegisterString("EmailProvider", &config.EmailProvider)
+	config.GlobalOption.RegisterString("SendGridToken", &config.SendGridToken)
+	config.GlobalOption.RegisterString("SESRegion", &config.SESRegion)
+	config.GlobalOption.RegisterString("SESAccessKeyId", &config.SESAccessKeyId)
+	config.GlobalOption.RegisterString("SESSecret", &config.SESSecret)
+	config.GlobalOption.RegisterString("EmailTemplates", &config.EmailTemplates)
+	config.GlobalOption.RegisterString("EmailDefaultLanguage", &config.EmailDefaultLanguage)
 	config.GlobalOption.RegisterValue("Notice")
 	config.GlobalOption.RegisterValue("About")
 	config.GlobalOption.RegisterValue("HomePageContent")
@@ -66,6 +99,18 @@ func InitOptionMap() {
 	config.GlobalOption.RegisterString("ServerAddress", &config.ServerAddress)
 	config.GlobalOption.RegisterString("GitHubClientId", &config.GitHubClientId)
 	config.GlobalOption.RegisterString("GitHubClientSecret", &config.GitHubClientSecret)
+	config.GlobalOption.RegisterString("GitHubAllowedOrganizations", &config.GitHubAllowedOrganizations)
+	config.GlobalOption.RegisterString("GitHubOrgCheckToken", &config.GitHubOrgCheckToken)
+
+	config.GlobalOption.RegisterString("LarkClientId", &config.LarkClientId)
+	config.GlobalOption.RegisterString("LarkClientSecret", &config.LarkClientSecret)
+	config.GlobalOption.RegisterString("WeComCorpId", &config.WeComCorpId)
+	config.GlobalOption.RegisterString("WeComAgentId", &config.WeComAgentId)
+	config.GlobalOption.RegisterString("WeComSecret", &config.WeComSecret)
+	config.GlobalOption.RegisterString("DingTalkClientId", &config.DingTalkClientId)
+	config.GlobalOption.RegisterString("DingTalkClientSecret", &config.DingTalkClientSecret)
+	config.GlobalOption.RegisterString("EnterpriseOAuthGroupMapping", &config.EnterpriseOAuthGroupMapping)
+	config.GlobalOption.RegisterString("SCIMToken", &config.SCIMToken)
 
 	config.GlobalOption.RegisterString("OIDCClientId", &config.OIDCClientId)
 	config.GlobalOption.RegisterString("OIDCClientSecret", &config.OIDCClientSecret)
@@ -81,6 +126,10 @@ func InitOptionMap() {
 	config.GlobalOption.RegisterInt("QuotaForNewUser", &config.QuotaForNewUser)
 	config.GlobalOption.RegisterInt("QuotaForInviter", &config.QuotaForInviter)
 	config.GlobalOption.RegisterInt("QuotaForInvitee", &config.QuotaForInvitee)
+	config.GlobalOption.RegisterInt("QuotaForInviterPurchase", &config.QuotaForInviterPurchase)
+	config.GlobalOption.RegisterInt("QuotaForInviteePurchase", &config.QuotaForInviteePurchase)
+	config.GlobalOption.RegisterInt("ReferralDailyLimit", &config.ReferralDailyLimit)
+	config.GlobalOption.RegisterInt("ReferralIPLimit", &config.ReferralIPLimit)
 	config.GlobalOption.RegisterInt("QuotaRemindThreshold", &config.QuotaRemindThreshold)
 	config.GlobalOption.RegisterInt("PreConsumedQuota", &config.PreConsumedQuota)
 
@@ -111,6 +160,10 @@ func InitOptionMap() {
 
 	config.GlobalOption.RegisterBool("GeminiAPIEnabled", &config.GeminiAPIEnabled)
 	config.GlobalOption.RegisterBool("ClaudeAPIEnabled", &config.ClaudeAPIEnabled)
+	config.GlobalOption.RegisterFloat("ReasoningTokenRatio", &config.ReasoningTokenRatio)
+	config.GlobalOption.RegisterBool("RedactReasoningContent", &config.RedactReasoningContent)
+	config.GlobalOption.RegisterBool("MaintenanceEnabled", &config.MaintenanceEnabled)
+	config.GlobalOption.RegisterString("MaintenanceMessage", &config.MaintenanceMessage)
 
 	config.GlobalOption.RegisterCustom("DisableChannelKeywords", func() string {
 		return common.DisableChannelKeywordsInstance.GetKeywords()
@@ -120,6 +173,25 @@ func InitOptionMap() {
 	}, common.GetDefaultDisableChannelKeywords())
 
 	config.GlobalOption.RegisterInt("RetryTimeOut", &config.RetryTimeOut)
+	config.GlobalOption.RegisterBool("StreamPassthroughEnabled", &config.StreamPassthroughEnabled)
+	config.GlobalOption.RegisterBool("RelayChannelHeaderEnabled", &config.RelayChannelHeaderEnabled)
+	config.GlobalOption.RegisterString("ChannelDuplicateKeyPolicy", &config.ChannelDuplicateKeyPolicy)
+	config.GlobalOption.RegisterCustom("BaiduModelEndpoints", func() string {
+		if len(config.BaiduModelEndpoints) == 0 {
+			return ""
+		}
+		data, _ := json.Marshal(config.BaiduModelEndpoints)
+		return string(data)
+	}, func(value string) error {
+		endpoints := make(map[string]string)
+		if strings.TrimSpace(value) != "" {
+			if err := json.Unmarshal([]byte(value), &endpoints); err != nil {
+				return err
+			}
+		}
+		config.BaiduModelEndpoints = endpoints
+		return nil
+	}, "")
 
 	// Global non-retryable policy (status codes and error keywords)
 	config.GlobalOption.RegisterCustom("NonRetryableStatusCodes", func() string {
@@ -186,16 +258,70 @@ func InitOptionMap() {
 	config.GlobalOption.RegisterCustom("SafeKeyWords", func() string {
 		return strings.Join(config.SafeKeyWords, "\n")
 	}, func(value string) error {
+		if value == strings.Join(config.SafeKeyWords, "\n") {
+			return nil
+		}
 		config.SafeKeyWords = strings.Split(value, "\n")
+		safty.ReloadKeywords()
 		return nil
 	}, "")
 
+	config.GlobalOption.RegisterBool("StorageImagePersistEnabled", &config.StorageImagePersistEnabled)
+	config.GlobalOption.RegisterInt("StorageImageExpirationDays", &config.StorageImageExpirationDays)
+	config.GlobalOption.RegisterBool("StorageTranscriptEnabled", &config.StorageTranscriptEnabled)
+	config.GlobalOption.RegisterInt("StorageTranscriptExpirationDays", &config.StorageTranscriptExpirationDays)
+	config.GlobalOption.RegisterBool("StorageLogArchiveEnabled", &config.StorageLogArchiveEnabled)
+	config.GlobalOption.RegisterInt("StorageLogArchiveDays", &config.StorageLogArchiveDays)
+	config.GlobalOption.RegisterInt("StorageLogArchiveExpirationDays", &config.StorageLogArchiveExpirationDays)
+
 	loadOptionsFromDatabase()
 }
 
+// registerCORSOptions 注册跨域设置，修改后通知中间件重新生成
+func registerCORSOptions() {
+	lists := map[string]*[]string{
+		"CORSAllowOrigins":  &config.CORSAllowOrigins,
+		"CORSAllowHeaders":  &config.CORSAllowHeaders,
+		"CORSExposeHeaders": &config.CORSExposeHeaders,
+	}
+	for key, list := range lists {
+		list := list
+		config.GlobalOption.RegisterCustom(key, func() string {
+			return strings.Join(*list, ",")
+		}, func(value string) error {
+			*list = config.SplitList(value)
+			config.CORSChanged()
+			return nil
+		}, "")
+	}
+
+	config.GlobalOption.RegisterCustom("CORSAllowCredentials", func() string {
+		return strconv.FormatBool(config.CORSAllowCredentials)
+	}, func(value string) error {
+		config.CORSAllowCredentials = value == "true"
+		config.CORSChanged()
+		return nil
+	}, "")
+	config.GlobalOption.RegisterCustom("CORSMaxAge", func() string {
+		return strconv.Itoa(config.CORSMaxAge)
+	}, func(value string) error {
+		maxAge, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		config.CORSMaxAge = maxAge
+		config.CORSChanged()
+		return nil
+	}, "")
+}
+
 func loadOptionsFromDatabase() {
 	options, _ := AllOption()
 	for _, option := range options {
+		// 配置中心中的值优先
+		if remoteconfig.Managed(option.Key) {
+			continue
+		}
 		err := config.GlobalOption.Set(option.Key, option.Value)
 		if err != nil {
 			logger.SysError("failed to update option map: " + err.Error())
@@ -212,28 +338,71 @@ func SyncOptions(frequency int) {
 }
 
 func UpdateOption(key string, value string) error {
+	_, err := UpdateOptionWithVersion(key, value, 0)
+	return err
+}
+
+// UpdateOptionWithVersion version 不为 0 时检查版本，不一致时返回 ErrVersionConflict，返回更新后的版本
+func UpdateOptionWithVersion(key string, value string, version int) (int, error) {
 	// Save to database first
 	option := Option{
 		Key: key,
 	}
-	// https://gorm.io/docs/update.html#Save-All-Fields
 	DB.FirstOrCreate(&option, Option{Key: key})
-	option.Value = value
-	// Save is a combination function.
-	// If save value does not contain primary key, it will execute Create,
-	// otherwise it will execute Update (with all fields).
-	DB.Save(&option)
+	if version != 0 && option.Version != version {
+		return option.Version, ErrVersionConflict
+	}
+	tx := DB.Model(&Option{}).Where(&Option{Key: key})
+	if version != 0 {
+		tx = tx.Where("version = ?", version)
+	}
+	result := tx.Updates(map[string]any{
+		"value":   value,
+		"version": gorm.Expr("version + 1"),
+	})
+	if result.Error != nil {
+		return option.Version, result.Error
+	}
+	if version != 0 && result.RowsAffected == 0 {
+		return option.Version, ErrVersionConflict
+	}
 	// Update OptionMap
 	err := config.GlobalOption.Set(key, value)
 
 	// Realtime notify other instances (if Redis enabled)
 	if config.RedisEnabled {
-		_ = redis.RedisPublish(redis.RedisTopicOptionsSync, "reload")
+		_ = redis.RedisPublish(redis.RedisTopicOptionsSync, "set:"+key)
 	}
-	return err
+	return option.Version + 1, err
+}
+
+// GetOptionVersions 所有设置项在数据库中的版本
+func GetOptionVersions() map[string]int {
+	versions := make(map[string]int)
+	options, err := AllOption()
+	if err != nil {
+		return versions
+	}
+	for _, option := range options {
+		versions[option.Key] = option.Version
+	}
+	return versions
 }
 
 // ReloadOptions triggers an immediate options reload from database (used by realtime sync).
 func ReloadOptions() {
 	loadOptionsFromDatabase()
 }
+
+// ReloadOption reloads a single option from database (used by realtime sync).
+func ReloadOption(key string) error {
+	if remoteconfig.Managed(key) {
+		return nil
+	}
+	option, err := GetOption(key)
+	if err != nil {
+		return err
+	}
+
+	return config.GlobalOption.Set(option.Key, option.Value)
+}
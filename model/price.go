@@ -128,6 +128,10 @@ func (price *Price) GetExtraRatio(key string) float64 {
 		}
 	}
 
+	if key == config.UsageExtraReasoning {
+		return config.ReasoningTokenRatio
+	}
+
 	ratio, ok := defaultExtraPrice[key]
 	if !ok {
 		return 1
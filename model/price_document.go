@@ -0,0 +1,217 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"gorm.io/datatypes"
+)
+
+// PriceEntry 价格文档中单个模型的价格
+type PriceEntry struct {
+	Type        string             `json:"type"`
+	ChannelType int                `json:"channel_type"`
+	Input       float64            `json:"input"`
+	Output      float64            `json:"output"`
+	Locked      bool               `json:"locked"`
+	ExtraRatios map[string]float64 `json:"extra_ratios,omitempty"`
+}
+
+// PriceDocument 以模型名为键的完整价格配置
+type PriceDocument map[string]*PriceEntry
+
+type PriceChange struct {
+	Model  string      `json:"model"`
+	Before *PriceEntry `json:"before,omitempty"`
+	After  *PriceEntry `json:"after,omitempty"`
+}
+
+// PriceDiff 价格文档的变化
+type PriceDiff struct {
+	Added   []*PriceChange `json:"added"`
+	Updated []*PriceChange `json:"updated"`
+	Removed []*PriceChange `json:"removed"`
+}
+
+func (d *PriceDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Removed) == 0
+}
+
+func newPriceEntry(price *Price) *PriceEntry {
+	entry := &PriceEntry{
+		Type:        price.Type,
+		ChannelType: price.ChannelType,
+		Input:       price.Input,
+		Output:      price.Output,
+		Locked:      price.Locked,
+	}
+	if price.ExtraRatios != nil && len(price.ExtraRatios.Data()) > 0 {
+		entry.ExtraRatios = maps.Clone(price.ExtraRatios.Data())
+	}
+	return entry
+}
+
+func (e *PriceEntry) toPrice(modelName string) *Price {
+	price := &Price{
+		Model:       modelName,
+		Type:        e.Type,
+		ChannelType: e.ChannelType,
+		Input:       e.Input,
+		Output:      e.Output,
+		Locked:      e.Locked,
+	}
+	if len(e.ExtraRatios) > 0 {
+		extraRatios := datatypes.NewJSONType(e.ExtraRatios)
+		price.ExtraRatios = &extraRatios
+	}
+	return price
+}
+
+func (e *PriceEntry) equal(other *PriceEntry) bool {
+	return e.Type == other.Type &&
+		e.ChannelType == other.ChannelType &&
+		e.Input == other.Input &&
+		e.Output == other.Output &&
+		e.Locked == other.Locked &&
+		maps.Equal(e.ExtraRatios, other.ExtraRatios)
+}
+
+// ExportPriceDocument 导出当前所有价格
+func (p *Pricing) ExportPriceDocument() PriceDocument {
+	p.RLock()
+	defer p.RUnlock()
+
+	document := make(PriceDocument, len(p.Prices))
+	for modelName, price := range p.Prices {
+		document[modelName] = newPriceEntry(price)
+	}
+	return document
+}
+
+// ValidatePriceDocument 检查价格文档，返回第一个错误
+func ValidatePriceDocument(document PriceDocument) error {
+	for _, modelName := range slices.Sorted(maps.Keys(document)) {
+		entry := document[modelName]
+		if modelName == "" || len(modelName) > 100 {
+			return fmt.Errorf("模型名称长度必须在 1 到 100 之间: %q", modelName)
+		}
+		if entry == nil {
+			return fmt.Errorf("%s: 价格不能为空", modelName)
+		}
+		if entry.Type != TokensPriceType && entry.Type != TimesPriceType {
+			return fmt.Errorf("%s: type 只能为 %s 或 %s", modelName, TokensPriceType, TimesPriceType)
+		}
+		if entry.ChannelType < 0 {
+			return fmt.Errorf("%s: channel_type 不能为负数", modelName)
+		}
+		if entry.Input < 0 || entry.Output < 0 {
+			return fmt.Errorf("%s: input 和 output 不能为负数", modelName)
+		}
+		for key, ratio := range entry.ExtraRatios {
+			if _, ok := ExtraKeyIsPrompt[key]; !ok {
+				return fmt.Errorf("%s: 未知的 extra_ratios 键 %s", modelName, key)
+			}
+			if ratio < 0 {
+				return fmt.Errorf("%s: extra_ratios.%s 不能为负数", modelName, key)
+			}
+		}
+	}
+	return nil
+}
+
+// DiffPriceDocument 比较两个价格文档，结果按模型名排序
+func DiffPriceDocument(before, after PriceDocument) *PriceDiff {
+	diff := &PriceDiff{
+		Added:   make([]*PriceChange, 0),
+		Updated: make([]*PriceChange, 0),
+		Removed: make([]*PriceChange, 0),
+	}
+	for _, modelName := range slices.Sorted(maps.Keys(after)) {
+		entry := after[modelName]
+		old, ok := before[modelName]
+		if !ok {
+			diff.Added = append(diff.Added, &PriceChange{Model: modelName, After: entry})
+		} else if !old.equal(entry) {
+			diff.Updated = append(diff.Updated, &PriceChange{Model: modelName, Before: old, After: entry})
+		}
+	}
+	for _, modelName := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := after[modelName]; !ok {
+			diff.Removed = append(diff.Removed, &PriceChange{Model: modelName, Before: before[modelName]})
+		}
+	}
+	return diff
+}
+
+// ApplyPriceDocument 用价格文档替换全部价格，文档中没有的模型会被删除，dryRun 时只返回变化
+func (p *Pricing) ApplyPriceDocument(document PriceDocument, dryRun bool) (*PriceDiff, error) {
+	if len(document) == 0 {
+		return nil, errors.New("价格文档不能为空")
+	}
+	if err := ValidatePriceDocument(document); err != nil {
+		return nil, err
+	}
+
+	diff := DiffPriceDocument(p.ExportPriceDocument(), document)
+	if dryRun || diff.Empty() {
+		return diff, nil
+	}
+
+	prices := make([]*Price, 0, len(document))
+	for _, modelName := range slices.Sorted(maps.Keys(document)) {
+		prices = append(prices, document[modelName].toPrice(modelName))
+	}
+
+	tx := DB.Begin()
+	if err := DeleteAllPrices(tx); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := InsertPrices(tx, prices); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, err
+	}
+
+	return diff, p.Init()
+}
+
+// MergePricePreset 按更新模式把预设价格合并到当前价格，和 SyncPricing 一样不修改 locked 的价格
+func (p *Pricing) MergePricePreset(prices []*Price, mode string) PriceDocument {
+	document := p.ExportPriceDocument()
+	if mode == string(PriceUpdateModeOverwrite) {
+		for modelName, entry := range document {
+			if !entry.Locked {
+				delete(document, modelName)
+			}
+		}
+	}
+
+	for _, price := range prices {
+		if price == nil {
+			continue
+		}
+		old, exists := document[price.Model]
+		if exists && old.Locked {
+			continue
+		}
+
+		switch mode {
+		case string(PriceUpdateModeUpdate):
+			if exists {
+				document[price.Model] = newPriceEntry(price)
+			}
+		case string(PriceUpdateModeOverwrite):
+			document[price.Model] = newPriceEntry(price)
+		default:
+			if !exists {
+				document[price.Model] = newPriceEntry(price)
+			}
+		}
+	}
+	return document
+}
@@ -0,0 +1,208 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common/logger"
+	"one-api/common/utils"
+
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+)
+
+const (
+	PriceProposalStatusPending  = "pending"
+	PriceProposalStatusApproved = "approved"
+	PriceProposalStatusRejected = "rejected"
+)
+
+// PriceProposal 价格同步发现的渠道已提供但没有价格的模型，审核通过后写入价格
+type PriceProposal struct {
+	Id          int                            `json:"id"`
+	Model       string                         `json:"model" gorm:"type:varchar(100);index"`
+	Price       datatypes.JSONType[PriceEntry] `json:"price" gorm:"type:json"`
+	Source      string                         `json:"source" gorm:"type:varchar(255);default:''"`
+	Status      string                         `json:"status" gorm:"type:varchar(16);index"`
+	CreatedTime int64                          `json:"created_time" gorm:"bigint"`
+	UpdatedTime int64                          `json:"updated_time" gorm:"bigint"`
+}
+
+type PriceProposalsListParams struct {
+	Status string `form:"status"`
+	PaginationParams
+}
+
+var allowedPriceProposalsOrderFields = map[string]bool{
+	"id":           true,
+	"model":        true,
+	"created_time": true,
+	"updated_time": true,
+}
+
+func GetPriceProposalsList(params *PriceProposalsListParams) (*DataResult[PriceProposal], error) {
+	var proposals []*PriceProposal
+	tx := DB.Model(&PriceProposal{})
+	if params.Status != "" {
+		tx = tx.Where("status = ?", params.Status)
+	}
+	return PaginateAndOrder(tx, &params.PaginationParams, &proposals, allowedPriceProposalsOrderFields)
+}
+
+// HasPrice 模型是否配置了价格（含通配符），没有配置时按默认价格计费
+func (p *Pricing) HasPrice(modelName string) bool {
+	p.RLock()
+	defer p.RUnlock()
+
+	if _, ok := p.Prices[modelName]; ok {
+		return true
+	}
+	return utils.GetModelsWithMatch(&p.Match, modelName) != ""
+}
+
+// unpricedChannelModels 渠道已提供但没有配置价格的模型
+func unpricedChannelModels() map[string]bool {
+	ChannelGroup.RLock()
+	models := make(map[string]bool)
+	for _, modelMap := range ChannelGroup.Rule {
+		for modelName := range modelMap {
+			models[modelName] = true
+		}
+	}
+	ChannelGroup.RUnlock()
+
+	for modelName := range models {
+		if PricingInstance.HasPrice(modelName) {
+			delete(models, modelName)
+		}
+	}
+	return models
+}
+
+// SyncPriceProposals 从价格清单中找出渠道已提供但没有价格的模型，
+// autoApply 时直接写入价格，否则加入待审核队列，已拒绝的模型不再提出
+func SyncPriceProposals(prices []*Price, source string, autoApply bool) (int, error) {
+	unpriced := unpricedChannelModels()
+	if len(unpriced) == 0 {
+		return 0, nil
+	}
+
+	var rejected []string
+	if err := DB.Model(&PriceProposal{}).Where("status = ?", PriceProposalStatusRejected).Pluck("model", &rejected).Error; err != nil {
+		return 0, err
+	}
+	for _, modelName := range rejected {
+		delete(unpriced, modelName)
+	}
+
+	now := utils.GetTimestamp()
+	document := PricingInstance.ExportPriceDocument()
+	count := 0
+	for _, price := range prices {
+		if price == nil || !unpriced[price.Model] {
+			continue
+		}
+		delete(unpriced, price.Model)
+		entry := newPriceEntry(price)
+		entry.Locked = false
+		if err := ValidatePriceDocument(PriceDocument{price.Model: entry}); err != nil {
+			logger.SysError("skip price proposal: " + err.Error())
+			continue
+		}
+
+		status := PriceProposalStatusPending
+		if autoApply {
+			document[price.Model] = entry
+			status = PriceProposalStatusApproved
+		}
+
+		var proposal PriceProposal
+		err := DB.Where("model = ? AND status = ?", price.Model, PriceProposalStatusPending).First(&proposal).Error
+		if err != nil {
+			proposal = PriceProposal{Model: price.Model, CreatedTime: now}
+		}
+		proposal.Price = datatypes.NewJSONType(*entry)
+		proposal.Source = source
+		proposal.Status = status
+		proposal.UpdatedTime = now
+		if err = DB.Save(&proposal).Error; err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if autoApply && count > 0 {
+		if _, err := PricingInstance.ApplyPriceDocument(document, false); err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// SyncPriceProposalsByService 按 price_sync 配置拉取价格清单并同步
+func SyncPriceProposalsByService() (int, error) {
+	api := viper.GetString("price_sync.url")
+	if api == "" {
+		api = viper.GetString("update_price_service")
+	}
+	if api == "" {
+		return 0, errors.New("price_sync.url is not configured")
+	}
+
+	prices, err := FetchPrices(api)
+	if err != nil {
+		return 0, err
+	}
+	return SyncPriceProposals(prices, api, viper.GetBool("price_sync.auto_apply"))
+}
+
+// ApprovePriceProposals 通过待审核的价格，写入价格后返回通过的数量
+func ApprovePriceProposals(ids []int) (int, error) {
+	var proposals []*PriceProposal
+	if err := DB.Where("id IN (?) AND status = ?", ids, PriceProposalStatusPending).Find(&proposals).Error; err != nil {
+		return 0, err
+	}
+	if len(proposals) == 0 {
+		return 0, errors.New("没有待审核的价格")
+	}
+
+	document := PricingInstance.ExportPriceDocument()
+	for _, proposal := range proposals {
+		entry := proposal.Price.Data()
+		document[proposal.Model] = &entry
+	}
+	if _, err := PricingInstance.ApplyPriceDocument(document, false); err != nil {
+		return 0, err
+	}
+
+	if err := updatePriceProposalsStatus(proposals, PriceProposalStatusApproved); err != nil {
+		return 0, err
+	}
+	logger.SysLog(fmt.Sprintf("approved %d price proposals", len(proposals)))
+	return len(proposals), nil
+}
+
+// RejectPriceProposals 拒绝待审核的价格，之后的同步不再提出这些模型
+func RejectPriceProposals(ids []int) (int, error) {
+	var proposals []*PriceProposal
+	if err := DB.Where("id IN (?) AND status = ?", ids, PriceProposalStatusPending).Find(&proposals).Error; err != nil {
+		return 0, err
+	}
+	if err := updatePriceProposalsStatus(proposals, PriceProposalStatusRejected); err != nil {
+		return 0, err
+	}
+	return len(proposals), nil
+}
+
+func updatePriceProposalsStatus(proposals []*PriceProposal, status string) error {
+	if len(proposals) == 0 {
+		return nil
+	}
+	ids := make([]int, 0, len(proposals))
+	for _, proposal := range proposals {
+		ids = append(ids, proposal.Id)
+	}
+	return DB.Model(&PriceProposal{}).Where("id IN (?)", ids).Updates(map[string]any{
+		"status":       status,
+		"updated_time": utils.GetTimestamp(),
+	}).Error
+}
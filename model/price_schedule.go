@@ -0,0 +1,387 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"one-api/common/utils"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	PriceScheduleStatusPending  = "pending"
+	PriceScheduleStatusApplied  = "applied"
+	PriceScheduleStatusCanceled = "canceled"
+	PriceScheduleStatusFailed   = "failed"
+)
+
+// PriceSchedule 定时生效的模型价格和分组倍率调整，由主节点在生效时间后在同一个事务中写入
+type PriceSchedule struct {
+	Id   int    `json:"id"`
+	Name string `json:"name" gorm:"type:varchar(100);default:''"`
+	// 生效时写入的模型价格，覆盖同名模型，其它模型的价格不变
+	Prices datatypes.JSONType[PriceDocument] `json:"prices" gorm:"type:json"`
+	// 分组 symbol 到新的倍率
+	GroupRatios   datatypes.JSONType[map[string]float64] `json:"group_ratios" gorm:"type:json"`
+	EffectiveTime int64                                  `json:"effective_time" gorm:"bigint;index"`
+	Status        string                                 `json:"status" gorm:"type:varchar(16);index"`
+	Error         string                                 `json:"error" gorm:"type:text"`
+	CreatedTime   int64                                  `json:"created_time" gorm:"bigint"`
+	AppliedTime   int64                                  `json:"applied_time" gorm:"bigint;default:0"`
+}
+
+type PriceSchedulesListParams struct {
+	Status string `form:"status"`
+	PaginationParams
+}
+
+var allowedPriceSchedulesOrderFields = map[string]bool{
+	"id":             true,
+	"effective_time": true,
+	"created_time":   true,
+}
+
+func GetPriceSchedulesList(params *PriceSchedulesListParams) (*DataResult[PriceSchedule], error) {
+	var schedules []*PriceSchedule
+	tx := DB.Model(&PriceSchedule{})
+	if params.Status != "" {
+		tx = tx.Where("status = ?", params.Status)
+	}
+	return PaginateAndOrder(tx, &params.PaginationParams, &schedules, allowedPriceSchedulesOrderFields)
+}
+
+// Validate 检查生效时间、价格和分组
+func (s *PriceSchedule) Validate() error {
+	if s.EffectiveTime <= utils.GetTimestamp() {
+		return errors.New("生效时间必须晚于当前时间")
+	}
+
+	prices := s.Prices.Data()
+	groupRatios := s.GroupRatios.Data()
+	if len(prices) == 0 && len(groupRatios) == 0 {
+		return errors.New("价格和分组倍率不能都为空")
+	}
+	if err := ValidatePriceDocument(prices); err != nil {
+		return err
+	}
+
+	for _, symbol := range slices.Sorted(maps.Keys(groupRatios)) {
+		if groupRatios[symbol] < 0 {
+			return fmt.Errorf("分组 %s 的倍率不能为负数", symbol)
+		}
+		var count int64
+		if err := DB.Model(&UserGroup{}).Where("symbol = ?", symbol).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("分组 %s 不存在", symbol)
+		}
+	}
+	return nil
+}
+
+func (s *PriceSchedule) Insert() error {
+	s.Id = 0
+	s.Status = PriceScheduleStatusPending
+	s.Error = ""
+	s.CreatedTime = utils.GetTimestamp()
+	s.AppliedTime = 0
+	return DB.Create(s).Error
+}
+
+// CancelPriceSchedule 取消尚未生效的调整
+func CancelPriceSchedule(id int) error {
+	result := DB.Model(&PriceSchedule{}).Where("id = ? AND status = ?", id, PriceScheduleStatusPending).Update("status", PriceScheduleStatusCanceled)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("调整不存在或已经生效")
+	}
+	return nil
+}
+
+// ApplyDuePriceSchedules 按生效时间依次写入到期的调整，返回成功的数量，失败的调整标记为 failed
+func ApplyDuePriceSchedules(now int64) (int, error) {
+	var schedules []*PriceSchedule
+	if err := DB.Where("status = ? AND effective_time <= ?", PriceScheduleStatusPending, now).Order("effective_time, id").Find(&schedules).Error; err != nil {
+		return 0, err
+	}
+
+	applied := 0
+	var errs []error
+	for _, schedule := range schedules {
+		if err := schedule.apply(now); err != nil {
+			errs = append(errs, fmt.Errorf("price schedule %d: %w", schedule.Id, err))
+			DB.Model(&PriceSchedule{}).Where("id = ? AND status = ?", schedule.Id, PriceScheduleStatusPending).Updates(map[string]any{
+				"status": PriceScheduleStatusFailed,
+				"error":  err.Error(),
+			})
+			continue
+		}
+		applied++
+		logger.SysLog(fmt.Sprintf("applied price schedule %d", schedule.Id))
+	}
+
+	if applied > 0 {
+		PricingInstance.Init()
+		GlobalUserGroupRatio.Load()
+		if config.RedisEnabled {
+			_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+		}
+	}
+	return applied, errors.Join(errs...)
+}
+
+// apply 在同一个事务中写入价格、分组倍率和状态，调整在此期间被取消时回滚
+func (s *PriceSchedule) apply(now int64) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if prices := s.Prices.Data(); len(prices) > 0 {
+			// 从数据库读取当前价格，其它节点修改的价格可能还没有同步到内存
+			var current []*Price
+			if err := tx.Find(&current).Error; err != nil {
+				return err
+			}
+			document := make(PriceDocument, len(current)+len(prices))
+			for _, price := range current {
+				document[price.Model] = newPriceEntry(price)
+			}
+			maps.Copy(document, prices)
+			if err := ValidatePriceDocument(document); err != nil {
+				return err
+			}
+
+			rows := make([]*Price, 0, len(document))
+			for _, modelName := range slices.Sorted(maps.Keys(document)) {
+				rows = append(rows, document[modelName].toPrice(modelName))
+			}
+			if err := DeleteAllPrices(tx); err != nil {
+				return err
+			}
+			if err := InsertPrices(tx, rows); err != nil {
+				return err
+			}
+		}
+
+		groupRatios := s.GroupRatios.Data()
+		for _, symbol := range slices.Sorted(maps.Keys(groupRatios)) {
+			result := tx.Model(&UserGroup{}).Where("symbol = ?", symbol).Update("ratio", groupRatios[symbol])
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("分组 %s 不存在", symbol)
+			}
+		}
+
+		result := tx.Model(&PriceSchedule{}).Where("id = ? AND status = ?", s.Id, PriceScheduleStatusPending).Updates(map[string]any{
+			"status":       PriceScheduleStatusApplied,
+			"applied_time": now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("调整已被取消")
+		}
+		return nil
+	})
+}
+
+// PriceImpact 按最近用量估算的额度变化
+type PriceImpact struct {
+	Name           string `json:"name"`
+	RequestCount   int64  `json:"request_count"`
+	CurrentQuota   int64  `json:"current_quota"`
+	EstimatedQuota int64  `json:"estimated_quota"`
+}
+
+// PriceSchedulePreview 按最近几天的统计数据估算调整后的额度消耗，用户按当前所在分组计算，不含额外计费项的变化
+type PriceSchedulePreview struct {
+	Days           int            `json:"days"`
+	CurrentQuota   int64          `json:"current_quota"`
+	EstimatedQuota int64          `json:"estimated_quota"`
+	Models         []*PriceImpact `json:"models"`
+	Groups         []*PriceImpact `json:"groups"`
+}
+
+type priceUsageRow struct {
+	ModelName        string
+	GroupName        string
+	RequestCount     int64
+	Quota            int64
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// PreviewPriceSchedule 估算调整对最近 days 天用量的影响，只统计受影响的模型和分组
+func PreviewPriceSchedule(s *PriceSchedule, days int) (*PriceSchedulePreview, error) {
+	groupCol := "users.`group`"
+	if common.UsingPostgreSQL {
+		groupCol = `users."group"`
+	}
+
+	var rows []*priceUsageRow
+	err := ReadDB().Table("statistics").
+		Select("statistics.model_name, "+groupCol+" as group_name, SUM(statistics.request_count) as request_count, SUM(statistics.quota) as quota, SUM(statistics.prompt_tokens) as prompt_tokens, SUM(statistics.completion_tokens) as completion_tokens").
+		Joins("JOIN users ON users.id = statistics.user_id").
+		Where("statistics.date >= ?", time.Now().AddDate(0, 0, -days).Format("2006-01-02")).
+		Group("statistics.model_name, " + groupCol).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	groupFactors, err := scheduledGroupFactors(s.GroupRatios.Data())
+	if err != nil {
+		return nil, err
+	}
+	prices := s.Prices.Data()
+	var wildcards []string
+	for modelName := range prices {
+		if strings.HasSuffix(modelName, "*") {
+			wildcards = append(wildcards, modelName)
+		}
+	}
+
+	preview := &PriceSchedulePreview{Days: days}
+	models := make(map[string]*PriceImpact)
+	groups := make(map[string]*PriceImpact)
+	for _, row := range rows {
+		after := scheduledPrice(prices, wildcards, row.ModelName)
+		groupFactor, groupChanged := groupFactors[row.GroupName]
+		if after == nil && !groupChanged {
+			continue
+		}
+		if !groupChanged {
+			groupFactor = 1
+		}
+
+		estimated := float64(row.Quota) * groupFactor
+		if after != nil {
+			before := PricingInstance.GetPrice(row.ModelName)
+			if beforeCost := row.cost(before); beforeCost > 0 {
+				estimated *= row.cost(after) / beforeCost
+			} else {
+				// 原来不计费时按新价格和分组倍率直接计算
+				ratio := 1.0
+				if group := GlobalUserGroupRatio.GetBySymbol(row.GroupName); group != nil {
+					ratio = group.Ratio
+				}
+				estimated = row.cost(after) * ratio * groupFactor
+			}
+		}
+
+		impact := &PriceImpact{RequestCount: row.RequestCount, CurrentQuota: row.Quota, EstimatedQuota: int64(estimated)}
+		preview.CurrentQuota += impact.CurrentQuota
+		preview.EstimatedQuota += impact.EstimatedQuota
+		addPriceImpact(models, row.ModelName, impact)
+		addPriceImpact(groups, row.GroupName, impact)
+	}
+
+	preview.Models = sortedPriceImpacts(models)
+	preview.Groups = sortedPriceImpacts(groups)
+	return preview, nil
+}
+
+// cost 按价格计算的额度，未乘分组倍率
+func (r *priceUsageRow) cost(price *Price) float64 {
+	if price.Type == TimesPriceType {
+		return float64(r.RequestCount) * 1000 * price.GetInput()
+	}
+	return float64(r.PromptTokens)*price.GetInput() + float64(r.CompletionTokens)*price.GetOutput()
+}
+
+// scheduledPrice 调整后模型的价格，调整中没有该模型时返回 nil；当前有精确价格的模型不受通配符价格影响
+func scheduledPrice(prices PriceDocument, wildcards []string, modelName string) *Price {
+	if entry, ok := prices[modelName]; ok {
+		return entry.toPrice(modelName)
+	}
+	if len(wildcards) == 0 {
+		return nil
+	}
+	if _, ok := PricingInstance.GetAllPrices()[modelName]; ok {
+		return nil
+	}
+	if match := utils.GetModelsWithMatch(&wildcards, modelName); match != "" {
+		return prices[match].toPrice(modelName)
+	}
+	return nil
+}
+
+// scheduledGroupFactors 调整前后分组实际倍率的比值，子分组随父分组变化
+func scheduledGroupFactors(groupRatios map[string]float64) (map[string]float64, error) {
+	factors := make(map[string]float64)
+	if len(groupRatios) == 0 {
+		return factors, nil
+	}
+
+	var groups []*UserGroup
+	if err := DB.Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	groupMap := make(map[string]*UserGroup, len(groups))
+	for _, group := range groups {
+		groupMap[group.Symbol] = group
+	}
+
+	effectiveRatio := func(symbol string, overrides map[string]float64) (float64, bool) {
+		ratio, changed := 1.0, false
+		for depth := 0; symbol != "" && depth <= maxUserGroupDepth; depth++ {
+			group, ok := groupMap[symbol]
+			if !ok {
+				break
+			}
+			if override, ok := overrides[symbol]; ok {
+				ratio *= override
+				changed = true
+			} else {
+				ratio *= group.Ratio
+			}
+			symbol = group.Parent
+		}
+		return ratio, changed
+	}
+
+	for symbol := range groupMap {
+		after, changed := effectiveRatio(symbol, groupRatios)
+		if !changed {
+			continue
+		}
+		before, _ := effectiveRatio(symbol, nil)
+		if before > 0 {
+			factors[symbol] = after / before
+		} else {
+			factors[symbol] = 1
+		}
+	}
+	return factors, nil
+}
+
+func addPriceImpact(impacts map[string]*PriceImpact, name string, impact *PriceImpact) {
+	total, ok := impacts[name]
+	if !ok {
+		total = &PriceImpact{Name: name}
+		impacts[name] = total
+	}
+	total.RequestCount += impact.RequestCount
+	total.CurrentQuota += impact.CurrentQuota
+	total.EstimatedQuota += impact.EstimatedQuota
+}
+
+func sortedPriceImpacts(impacts map[string]*PriceImpact) []*PriceImpact {
+	result := make([]*PriceImpact, 0, len(impacts))
+	for _, name := range slices.Sorted(maps.Keys(impacts)) {
+		result = append(result, impacts[name])
+	}
+	return result
+}
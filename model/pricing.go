@@ -314,6 +314,11 @@ func GetPriceByPriceService() ([]*Price, error) {
 	if api == "" {
 		return nil, errors.New("update_price_service is not configured")
 	}
+	return FetchPrices(api)
+}
+
+// FetchPrices 从价格服务获取价格，支持带 data 字段的格式和数组格式
+func FetchPrices(api string) ([]*Price, error) {
 	logger.SysLog("Start Update Price,Prices Service URL：" + api)
 	client := &http.Client{
 		Timeout: 10 * time.Second,
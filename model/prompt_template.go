@@ -0,0 +1,190 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// PromptTemplate 由管理员维护的提示词模板，请求通过模型名 template:<name> 或 X-Prompt-Template 请求头引用
+type PromptTemplate struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name" gorm:"type:varchar(64);uniqueIndex"`
+	Description string `json:"description" gorm:"type:varchar(255);default:''"`
+	Model       string `json:"model" gorm:"type:varchar(100);default:''"` // 通过模型名引用模板时实际调用的模型
+	Messages    string `json:"messages" gorm:"type:text"`                 // JSON 格式 [{"role": "system", "content": "..."}]，内容中可使用 {{变量名}}
+	Enable      *bool  `json:"enable" form:"enable" gorm:"default:true"`
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+	UpdatedTime int64  `json:"updated_time" gorm:"bigint"`
+
+	messages []PromptTemplateMessage
+}
+
+type PromptTemplateMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type SearchPromptTemplateParams struct {
+	PromptTemplate
+	PaginationParams
+}
+
+var allowedPromptTemplateOrderFields = map[string]bool{
+	"id":           true,
+	"name":         true,
+	"created_time": true,
+}
+
+var promptVariableRegexp = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+
+func GetPromptTemplatesList(params *SearchPromptTemplateParams) (*DataResult[PromptTemplate], error) {
+	var templates []*PromptTemplate
+	db := DB
+
+	if params.Name != "" {
+		db = db.Where("name LIKE ?", params.Name+"%")
+	}
+
+	if params.Enable != nil {
+		db = db.Where("enable = ?", *params.Enable)
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &templates, allowedPromptTemplateOrderFields)
+}
+
+func GetPromptTemplateById(id int) (*PromptTemplate, error) {
+	var template PromptTemplate
+	err := DB.Where("id = ?", id).First(&template).Error
+	return &template, err
+}
+
+func (t *PromptTemplate) Create() error {
+	t.CreatedTime = utils.GetTimestamp()
+	t.UpdatedTime = t.CreatedTime
+	err := DB.Create(t).Error
+	if err == nil {
+		PromptTemplatesInstance.Load()
+	}
+	return err
+}
+
+func (t *PromptTemplate) Update() error {
+	t.UpdatedTime = utils.GetTimestamp()
+	err := DB.Select("name", "description", "model", "messages", "enable", "updated_time").Updates(t).Error
+	if err == nil {
+		PromptTemplatesInstance.Load()
+	}
+	return err
+}
+
+func (t *PromptTemplate) Delete() error {
+	err := DB.Delete(t).Error
+	if err == nil {
+		PromptTemplatesInstance.Load()
+	}
+	return err
+}
+
+// Validate 校验模板名称和消息格式
+func (t *PromptTemplate) Validate() error {
+	if t.Name == "" {
+		return errors.New("模板名称不能为空")
+	}
+	if strings.ContainsAny(t.Name, ":#") {
+		return errors.New("模板名称不能包含 : 或 #")
+	}
+	return t.parseMessages()
+}
+
+func (t *PromptTemplate) parseMessages() error {
+	var messages []PromptTemplateMessage
+	if err := json.Unmarshal([]byte(t.Messages), &messages); err != nil {
+		return fmt.Errorf("模板消息格式错误: %w", err)
+	}
+	if len(messages) == 0 {
+		return errors.New("模板消息不能为空")
+	}
+	for _, message := range messages {
+		if message.Role == "" {
+			return errors.New("模板消息的 role 不能为空")
+		}
+	}
+	t.messages = messages
+	return nil
+}
+
+// Render 使用变量替换模板中的 {{变量名}}，缺少变量时返回错误
+func (t *PromptTemplate) Render(variables map[string]string) ([]PromptTemplateMessage, error) {
+	var missing []string
+	rendered := make([]PromptTemplateMessage, 0, len(t.messages))
+	for _, message := range t.messages {
+		content := promptVariableRegexp.ReplaceAllStringFunc(message.Content, func(match string) string {
+			name := promptVariableRegexp.FindStringSubmatch(match)[1]
+			value, ok := variables[name]
+			if !ok {
+				if !utils.Contains(name, missing) {
+					missing = append(missing, name)
+				}
+				return match
+			}
+			return value
+		})
+		rendered = append(rendered, PromptTemplateMessage{Role: message.Role, Content: content})
+	}
+
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("prompt template %s missing variables: %s", t.Name, strings.Join(missing, ", "))
+	}
+
+	return rendered, nil
+}
+
+// PromptTemplates 已启用模板的内存缓存
+type PromptTemplates struct {
+	sync.RWMutex
+	Templates map[string]*PromptTemplate
+}
+
+var PromptTemplatesInstance = &PromptTemplates{}
+
+func NewPromptTemplates() {
+	if err := PromptTemplatesInstance.Load(); err != nil {
+		logger.SysError("Failed to initialize PromptTemplates:" + err.Error())
+	}
+}
+
+func (p *PromptTemplates) Load() error {
+	var templates []*PromptTemplate
+	if err := DB.Where("enable = ?", true).Find(&templates).Error; err != nil {
+		return err
+	}
+
+	newTemplates := make(map[string]*PromptTemplate, len(templates))
+	for _, template := range templates {
+		if err := template.parseMessages(); err != nil {
+			logger.SysError(fmt.Sprintf("failed to parse prompt template %s: %s", template.Name, err.Error()))
+			continue
+		}
+		newTemplates[template.Name] = template
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	p.Templates = newTemplates
+
+	return nil
+}
+
+func (p *PromptTemplates) Get(name string) *PromptTemplate {
+	p.RLock()
+	defer p.RUnlock()
+
+	return p.Templates[name]
+}
@@ -0,0 +1,209 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	QuotaGrantPeriodDaily   = "daily"
+	QuotaGrantPeriodMonthly = "monthly"
+
+	QuotaGrantModeAdd   = "add"    // 每个周期增加固定额度
+	QuotaGrantModeTopUp = "top_up" // 每个周期补足到固定额度，已超过时不发放
+
+	quotaGrantBatchSize = 500
+)
+
+// QuotaGrant 定时发放额度的规则，由主节点的定时任务执行
+type QuotaGrant struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name" gorm:"type:varchar(64);default:''"`
+	Period      string `json:"period" gorm:"type:varchar(16)"`
+	Mode        string `json:"mode" gorm:"type:varchar(16)"`
+	Quota       int    `json:"quota"`
+	Group       string `json:"group" gorm:"type:varchar(32);default:''"` // 发放给该分组的用户，为空时发放给所有用户
+	Enabled     bool   `json:"enabled" gorm:"default:true"`
+	LastPeriod  string `json:"last_period" gorm:"type:varchar(16);default:''"` // 最近一次全部发放完成的周期
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+}
+
+// QuotaGrantRecord 用户在某个周期已领取的额度，保证同一周期只发放一次
+type QuotaGrantRecord struct {
+	Id        int    `json:"id"`
+	GrantId   int    `json:"grant_id" gorm:"uniqueIndex:idx_quota_grant_user_period"`
+	UserId    int    `json:"user_id" gorm:"uniqueIndex:idx_quota_grant_user_period"`
+	Period    string `json:"period" gorm:"type:varchar(16);uniqueIndex:idx_quota_grant_user_period"`
+	Quota     int    `json:"quota"`
+	CreatedAt int64  `json:"created_at" gorm:"bigint"`
+}
+
+var allowedQuotaGrantOrderFields = map[string]bool{
+	"id":           true,
+	"name":         true,
+	"created_time": true,
+}
+
+func GetQuotaGrantsList(params *PaginationParams) (*DataResult[QuotaGrant], error) {
+	var grants []*QuotaGrant
+	return PaginateAndOrder(DB.Model(&QuotaGrant{}), params, &grants, allowedQuotaGrantOrderFields)
+}
+
+func GetQuotaGrantById(id int) (*QuotaGrant, error) {
+	var grant QuotaGrant
+	err := DB.Where("id = ?", id).First(&grant).Error
+	return &grant, err
+}
+
+func (grant *QuotaGrant) Create() error {
+	grant.CreatedTime = utils.GetTimestamp()
+	return DB.Create(grant).Error
+}
+
+func (grant *QuotaGrant) Update() error {
+	return DB.Select("name", "period", "mode", "quota", "group", "enabled").Updates(grant).Error
+}
+
+func (grant *QuotaGrant) Delete() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("grant_id = ?", grant.Id).Delete(&QuotaGrantRecord{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(grant).Error
+	})
+}
+
+// Validate 校验周期、模式和分组
+func (grant *QuotaGrant) Validate() error {
+	if grant.Period != QuotaGrantPeriodDaily && grant.Period != QuotaGrantPeriodMonthly {
+		return fmt.Errorf("不支持的发放周期：%s", grant.Period)
+	}
+	if grant.Mode != QuotaGrantModeAdd && grant.Mode != QuotaGrantModeTopUp {
+		return fmt.Errorf("不支持的发放模式：%s", grant.Mode)
+	}
+	if grant.Quota <= 0 {
+		return errors.New("额度必须大于 0")
+	}
+
+	grant.Group = strings.TrimSpace(grant.Group)
+	if grant.Group != "" && GlobalUserGroupRatio.GetBySymbol(grant.Group) == nil {
+		return fmt.Errorf("分组不存在：%s", grant.Group)
+	}
+	return nil
+}
+
+// periodKey 当前时间所在的周期，作为幂等键的一部分
+func (grant *QuotaGrant) periodKey(now time.Time) string {
+	if grant.Period == QuotaGrantPeriodMonthly {
+		return now.Format("2006-01")
+	}
+	return now.Format("2006-01-02")
+}
+
+// RunQuotaGrants 执行所有启用的规则，每个用户每个周期只发放一次，中断后再次执行会继续发放剩余的用户
+func RunQuotaGrants(now time.Time) (granted int, err error) {
+	var grants []*QuotaGrant
+	if err = DB.Where("enabled = ?", true).Find(&grants).Error; err != nil {
+		return 0, err
+	}
+
+	for _, grant := range grants {
+		period := grant.periodKey(now)
+		if grant.LastPeriod == period {
+			continue
+		}
+
+		count, err := grant.run(period)
+		granted += count
+		if err != nil {
+			return granted, fmt.Errorf("quota grant #%d: %w", grant.Id, err)
+		}
+	}
+	return granted, nil
+}
+
+func (grant *QuotaGrant) run(period string) (granted int, err error) {
+	lastId := 0
+	for {
+		var userIds []int
+		tx := DB.Model(&User{}).Where("id > ? AND status = ?", lastId, config.UserStatusEnabled)
+		if grant.Group != "" {
+			tx = tx.Where(&User{Group: grant.Group})
+		}
+		if err = tx.Order("id").Limit(quotaGrantBatchSize).Pluck("id", &userIds).Error; err != nil {
+			return granted, err
+		}
+		if len(userIds) == 0 {
+			break
+		}
+
+		for _, userId := range userIds {
+			quota, err := grant.grantUser(userId, period)
+			if err != nil {
+				return granted, err
+			}
+			if quota > 0 {
+				granted++
+			}
+		}
+		lastId = userIds[len(userIds)-1]
+	}
+
+	// 全部发放完成后只保留当前周期的记录
+	grant.LastPeriod = period
+	if err = DB.Model(grant).Update("last_period", period).Error; err != nil {
+		return granted, err
+	}
+	if err = DB.Where("grant_id = ? AND period <> ?", grant.Id, period).Delete(&QuotaGrantRecord{}).Error; err != nil {
+		logger.SysError("failed to prune quota grant records: " + err.Error())
+	}
+	return granted, nil
+}
+
+// grantUser 在同一个事务中写入领取记录和增加额度，已领取时返回 0
+func (grant *QuotaGrant) grantUser(userId int, period string) (quota int, err error) {
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&QuotaGrantRecord{}).Where("grant_id = ? AND user_id = ? AND period = ?", grant.Id, userId, period).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+
+		quota = grant.Quota
+		if grant.Mode == QuotaGrantModeTopUp {
+			var current int
+			if err := tx.Model(&User{}).Where("id = ?", userId).Select("quota").Find(&current).Error; err != nil {
+				return err
+			}
+			quota = max(grant.Quota-current, 0)
+		}
+
+		record := &QuotaGrantRecord{GrantId: grant.Id, UserId: userId, Period: period, Quota: quota, CreatedAt: utils.GetTimestamp()}
+		if err := tx.Create(record).Error; err != nil {
+			return err
+		}
+		if quota == 0 {
+			return nil
+		}
+		return tx.Model(&User{}).Where("id = ?", userId).Update("quota", gorm.Expr("quota + ?", quota)).Error
+	})
+	if err != nil || quota == 0 {
+		return 0, err
+	}
+
+	if err := CacheIncreaseUserQuota(userId, quota); err != nil {
+		logger.SysError("failed to increase user quota cache: " + err.Error())
+	}
+	RecordLog(userId, LogTypeTopup, fmt.Sprintf("定时发放额度「%s」%s", grant.Name, common.LogQuota(quota)))
+	return quota, nil
+}
@@ -0,0 +1,157 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common/logger"
+	"one-api/common/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrQuotaRefunded = errors.New("该请求已经退款")
+
+// QuotaRefund 额度退款记录，同一次请求（没有请求 ID 时为同一条日志）只退款一次
+type QuotaRefund struct {
+	Id         int    `json:"id"`
+	RefundKey  string `json:"refund_key" gorm:"type:varchar(100);uniqueIndex"`
+	LogId      int    `json:"log_id" gorm:"index"`
+	RequestId  string `json:"request_id" gorm:"type:varchar(64);index"`
+	UserId     int    `json:"user_id" gorm:"index"`
+	TokenId    int    `json:"token_id"`
+	ChannelId  int    `json:"channel_id"`
+	ModelName  string `json:"model_name" gorm:"type:varchar(100)"`
+	Quota      int    `json:"quota"`
+	Reason     string `json:"reason" gorm:"type:varchar(255)"`
+	OperatorId int    `json:"operator_id"` // 操作的管理员，0 为自动退款
+	CreatedAt  int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+// NewLogRefund 按消费日志生成退款，quota 为 0 时全额退款
+func NewLogRefund(log *Log, quota int, reason string, operatorId int) (*QuotaRefund, error) {
+	if log.Type != LogTypeConsume {
+		return nil, errors.New("只能退款消费日志")
+	}
+	if quota == 0 {
+		quota = log.Quota
+	}
+	if quota <= 0 || quota > log.Quota {
+		return nil, fmt.Errorf("退款额度必须大于 0 且不超过消费额度 %d", log.Quota)
+	}
+
+	return &QuotaRefund{
+		LogId:      log.Id,
+		RequestId:  log.RequestId,
+		UserId:     log.UserId,
+		TokenId:    log.TokenId,
+		ChannelId:  log.ChannelId,
+		ModelName:  log.ModelName,
+		Quota:      quota,
+		Reason:     reason,
+		OperatorId: operatorId,
+	}, nil
+}
+
+// Refund 退还额度：增加用户和令牌的剩余额度，扣减用户和渠道的已用额度，并记录退款日志，在一个事务中完成
+func (r *QuotaRefund) Refund() error {
+	if r.Quota <= 0 {
+		return errors.New("退款额度必须大于 0")
+	}
+	if r.RequestId != "" {
+		r.RefundKey = "request:" + r.RequestId
+	} else if r.LogId > 0 {
+		r.RefundKey = fmt.Sprintf("log:%d", r.LogId)
+	} else {
+		return errors.New("缺少请求 ID 或日志 ID")
+	}
+	r.Reason = truncateString(r.Reason, 255)
+	r.CreatedAt = utils.GetTimestamp()
+
+	var token *Token
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(r)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrQuotaRefunded
+		}
+
+		if err := increaseUserQuota(tx, r.UserId, r.Quota); err != nil {
+			return err
+		}
+		if err := updateUserUsedQuota(tx, r.UserId, -r.Quota); err != nil {
+			return err
+		}
+		if r.TokenId > 0 {
+			token = &Token{}
+			if err := tx.First(token, r.TokenId).Error; err != nil {
+				// 令牌已删除时只退还用户额度
+				token = nil
+			} else if !token.UnlimitedQuota {
+				if err := increaseTokenQuota(tx, r.TokenId, r.Quota); err != nil {
+					return err
+				}
+			}
+		}
+		if r.ChannelId > 0 {
+			if err := updateChannelUsedQuota(tx, r.ChannelId, -r.Quota); err != nil {
+				return err
+			}
+		}
+
+		username, _ := CacheGetUsername(r.UserId)
+		log := &Log{
+			UserId:    r.UserId,
+			Username:  username,
+			CreatedAt: r.CreatedAt,
+			Type:      LogTypeRefund,
+			Content:   "退款：" + r.Reason,
+			TokenId:   r.TokenId,
+			ModelName: r.ModelName,
+			Quota:     r.Quota,
+			ChannelId: r.ChannelId,
+			RequestId: r.RequestId,
+		}
+		if token != nil {
+			log.TokenName = token.Name
+		}
+		return tx.Create(log).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := CacheIncreaseUserQuota(r.UserId, r.Quota); err != nil {
+		logger.SysError("failed to increase user quota cache: " + err.Error())
+	}
+	InvalidateTokenCache(token)
+	return nil
+}
+
+type QuotaRefundsListParams struct {
+	PaginationParams
+	UserId    int    `form:"user_id"`
+	RequestId string `form:"request_id"`
+}
+
+var allowedQuotaRefundsOrderFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"quota":      true,
+}
+
+func GetQuotaRefundsList(params *QuotaRefundsListParams) (*DataResult[QuotaRefund], error) {
+	var refunds []*QuotaRefund
+
+	tx := ReadDB().Model(&QuotaRefund{})
+	if params.UserId != 0 {
+		tx = tx.Where("user_id = ?", params.UserId)
+	}
+	if params.RequestId != "" {
+		tx = tx.Where("request_id = ?", params.RequestId)
+	}
+
+	return PaginateAndOrder[QuotaRefund](tx, &params.PaginationParams, &refunds, allowedQuotaRefundsOrderFields)
+}
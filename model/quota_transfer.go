@@ -0,0 +1,177 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/utils"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QuotaTransfer 用户之间的额度转账记录，转出方扣除 Quota + Fee，接收方增加 Quota
+type QuotaTransfer struct {
+	Id           int    `json:"id"`
+	FromUserId   int    `json:"from_user_id" gorm:"index"`
+	FromUsername string `json:"from_username" gorm:"type:varchar(64);default:''"`
+	ToUserId     int    `json:"to_user_id" gorm:"index"`
+	ToUsername   string `json:"to_username" gorm:"type:varchar(64);default:''"`
+	Quota        int    `json:"quota"`
+	Fee          int    `json:"fee"`
+	Remark       string `json:"remark" gorm:"type:varchar(255);default:''"`
+	CreatedAt    int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+// QuotaTransferEnabled 是否允许用户之间转账
+// 批量更新模式下数据库中的额度还未扣除缓冲中的消费，无法可靠地检查余额，不允许转账
+func QuotaTransferEnabled() bool {
+	return viper.GetBool("transfer.enable") && !config.BatchUpdateEnabled
+}
+
+// QuotaTransferFee 按手续费比例计算转账手续费，向上取整
+func QuotaTransferFee(quota int) int {
+	rate := viper.GetFloat64("transfer.fee_rate")
+	if rate <= 0 {
+		return 0
+	}
+	return int(math.Ceil(float64(quota) * rate))
+}
+
+// TransferQuota 从 fromUserId 向 toUserId 转账，检查单笔和 24 小时内的转出限额，扣款、入账和记录在一个事务中完成
+func TransferQuota(fromUserId, toUserId, quota int, remark string) (*QuotaTransfer, error) {
+	if config.BatchUpdateEnabled {
+		return nil, errors.New("批量更新模式下不支持转账")
+	}
+	if !QuotaTransferEnabled() {
+		return nil, errors.New("未开启转账")
+	}
+	if fromUserId == toUserId {
+		return nil, errors.New("不能向自己转账")
+	}
+	if quota <= 0 {
+		return nil, errors.New("转账额度必须大于 0")
+	}
+	if minQuota := viper.GetInt("transfer.min_quota"); quota < minQuota {
+		return nil, fmt.Errorf("单笔转账额度不能低于 %s", common.LogQuota(minQuota))
+	}
+	if maxQuota := viper.GetInt("transfer.max_quota"); maxQuota > 0 && quota > maxQuota {
+		return nil, fmt.Errorf("单笔转账额度不能超过 %s", common.LogQuota(maxQuota))
+	}
+
+	from, err := GetUserById(fromUserId, false)
+	if err != nil {
+		return nil, err
+	}
+	to, err := GetUserById(toUserId, false)
+	if err != nil {
+		return nil, errors.New("接收用户不存在")
+	}
+	if to.Status != config.UserStatusEnabled {
+		return nil, errors.New("接收用户已被禁用")
+	}
+	// 租户用户只能在租户内转账
+	if from.TenantId != to.TenantId {
+		return nil, errors.New("不能向其他租户的用户转账")
+	}
+
+	transfer := &QuotaTransfer{
+		FromUserId:   from.Id,
+		FromUsername: from.Username,
+		ToUserId:     to.Id,
+		ToUsername:   to.Username,
+		Quota:        quota,
+		Fee:          QuotaTransferFee(quota),
+		Remark:       truncateString(remark, 255),
+		CreatedAt:    utils.GetTimestamp(),
+	}
+	total := transfer.Quota + transfer.Fee
+
+	err = DB.Transaction(func(tx *gorm.DB) error {
+		// 先锁定转出用户，同一用户的并发转账排队执行，24 小时限额按已提交的转账计算
+		locking := tx
+		if !common.UsingSQLite {
+			locking = tx.Clauses(clause.Locking{Strength: "UPDATE"})
+		}
+		if err := locking.Model(&User{}).Select("id").Where("id = ?", from.Id).Take(&User{}).Error; err != nil {
+			return err
+		}
+
+		if dailyLimit := viper.GetInt("transfer.daily_limit"); dailyLimit > 0 {
+			var sent int64
+			err := tx.Model(&QuotaTransfer{}).Where("from_user_id = ? AND created_at >= ?", from.Id, transfer.CreatedAt-86400).
+				Select("COALESCE(SUM(quota), 0)").Scan(&sent).Error
+			if err != nil {
+				return err
+			}
+			if sent+int64(quota) > int64(dailyLimit) {
+				return fmt.Errorf("24 小时内转账额度不能超过 %s，已转出 %s", common.LogQuota(dailyLimit), common.LogQuota(int(sent)))
+			}
+		}
+
+		result := tx.Model(&User{}).Where("id = ? AND quota >= ?", from.Id, total).Update("quota", gorm.Expr("quota - ?", total))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("额度不足，本次转账需要 %s（含手续费 %s）", common.LogQuota(total), common.LogQuota(transfer.Fee))
+		}
+		if err := increaseUserQuota(tx, to.Id, quota); err != nil {
+			return err
+		}
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+
+		content := fmt.Sprintf("转账给用户 %s %s", to.Username, common.LogQuota(quota))
+		if transfer.Fee > 0 {
+			content += fmt.Sprintf("，手续费 %s", common.LogQuota(transfer.Fee))
+		}
+		logs := []*Log{
+			{UserId: from.Id, Username: from.Username, CreatedAt: transfer.CreatedAt, Type: LogTypeTransfer, Quota: total, Content: content},
+			{UserId: to.Id, Username: to.Username, CreatedAt: transfer.CreatedAt, Type: LogTypeTransfer, Quota: quota, Content: fmt.Sprintf("收到用户 %s 转账 %s", from.Username, common.LogQuota(quota))},
+		}
+		return tx.Create(&logs).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CacheDecreaseUserQuota(from.Id, total); err != nil {
+		logger.SysError("failed to decrease user quota cache: " + err.Error())
+	}
+	if err := CacheIncreaseUserQuota(to.Id, quota); err != nil {
+		logger.SysError("failed to increase user quota cache: " + err.Error())
+	}
+	return transfer, nil
+}
+
+type QuotaTransfersListParams struct {
+	PaginationParams
+	UserId int `form:"user_id"` // 转出或接收的用户
+}
+
+var allowedQuotaTransfersOrderFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"quota":      true,
+}
+
+// GetQuotaTransfersList 转账记录，tenantId 不为 0 时只返回该租户用户的记录
+func GetQuotaTransfersList(params *QuotaTransfersListParams, tenantId int) (*DataResult[QuotaTransfer], error) {
+	var transfers []*QuotaTransfer
+
+	tx := ReadDB().Model(&QuotaTransfer{})
+	if tenantId != 0 {
+		tx = tx.Where("from_user_id IN (?)", ReadDB().Model(&User{}).Select("id").Where("tenant_id = ?", tenantId))
+	}
+	if params.UserId != 0 {
+		tx = tx.Where("(from_user_id = ? OR to_user_id = ?)", params.UserId, params.UserId)
+	}
+
+	return PaginateAndOrder[QuotaTransfer](tx, &params.PaginationParams, &transfers, allowedQuotaTransfersOrderFields)
+}
@@ -21,6 +21,10 @@ type Redemption struct {
 	CreatedTime  int64  `json:"created_time" gorm:"bigint"`
 	RedeemedTime int64  `json:"redeemed_time" gorm:"bigint"`
 	Count        int    `json:"count" gorm:"-:all"` // only for api request
+
+	BatchId        int              `json:"batch_id" gorm:"index;default:0"`
+	RedeemedUserId int              `json:"redeemed_user_id" gorm:"index;default:0"` // 兑换的用户，user_id 为创建者
+	Batch          *RedemptionBatch `json:"batch,omitempty" gorm:"-:all"`            // only for api request
 }
 
 var allowedRedemptionslOrderFields = map[string]bool{
@@ -74,11 +78,17 @@ func Redeem(key string, userId int, ip string) (quota int, err error) {
 		if redemption.Status != config.RedemptionCodeStatusEnabled {
 			return errors.New("该兑换码已被使用")
 		}
+		if redemption.BatchId != 0 {
+			if err := checkRedemptionBatch(tx, redemption.BatchId, userId); err != nil {
+				return err
+			}
+		}
 		err = tx.Model(&User{}).Where("id = ?", userId).Update("quota", gorm.Expr("quota + ?", redemption.Quota)).Error
 		if err != nil {
 			return err
 		}
 		redemption.RedeemedTime = utils.GetTimestamp()
+		redemption.RedeemedUserId = userId
 		redemption.Status = config.RedemptionCodeStatusUsed
 		err = tx.Save(redemption).Error
 		return err
@@ -87,6 +97,10 @@ func Redeem(key string, userId int, ip string) (quota int, err error) {
 		return 0, errors.New("兑换失败，" + err.Error())
 	}
 
+	if err = CacheIncreaseUserQuota(userId, redemption.Quota); err != nil {
+		logger.SysError("failed to increase user quota cache: " + err.Error())
+	}
+
 	// Try to upgrade user group based on cumulative recharge amount
 	err = CheckAndUpgradeUserGroup(userId, redemption.Quota)
 	if err != nil {
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/utils"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// RedemptionBatch 一次生成的兑换码，同一个活动可以有多个批次
+type RedemptionBatch struct {
+	Id           int    `json:"id"`
+	Name         string `json:"name" gorm:"type:varchar(64);default:''"`
+	Campaign     string `json:"campaign" gorm:"type:varchar(64);index;default:''"`
+	StartTime    int64  `json:"start_time" gorm:"bigint;default:0"` // 开始兑换时间，0 为不限制
+	EndTime      int64  `json:"end_time" gorm:"bigint;default:0"`   // 截止兑换时间，0 为不限制
+	PerUserLimit int    `json:"per_user_limit" gorm:"default:0"`    // 每个用户最多兑换本批次的个数，0 为不限制
+	CreatedBy    int    `json:"created_by" gorm:"default:0"`
+	CreatedTime  int64  `json:"created_time" gorm:"bigint"`
+}
+
+type RedemptionBatchesListParams struct {
+	Campaign string `form:"campaign"`
+	PaginationParams
+}
+
+var allowedRedemptionBatchesOrderFields = map[string]bool{
+	"id":           true,
+	"campaign":     true,
+	"created_time": true,
+}
+
+func GetRedemptionBatchesList(params *RedemptionBatchesListParams) (*DataResult[RedemptionBatch], error) {
+	var batches []*RedemptionBatch
+	tx := DB.Model(&RedemptionBatch{})
+	if params.Campaign != "" {
+		tx = tx.Where("campaign = ?", params.Campaign)
+	}
+	return PaginateAndOrder(tx, &params.PaginationParams, &batches, allowedRedemptionBatchesOrderFields)
+}
+
+func GetRedemptionBatchById(id int) (*RedemptionBatch, error) {
+	var batch RedemptionBatch
+	err := DB.Where("id = ?", id).First(&batch).Error
+	return &batch, err
+}
+
+// Validate 校验兑换时间范围和每用户上限
+func (batch *RedemptionBatch) Validate() error {
+	batch.Campaign = strings.TrimSpace(batch.Campaign)
+	if len(batch.Campaign) > 64 {
+		return errors.New("活动名称过长")
+	}
+	if batch.StartTime < 0 || batch.EndTime < 0 || batch.PerUserLimit < 0 {
+		return errors.New("兑换时间和每用户上限不能为负数")
+	}
+	if batch.StartTime > 0 && batch.EndTime > 0 && batch.EndTime <= batch.StartTime {
+		return errors.New("截止时间必须晚于开始时间")
+	}
+	return nil
+}
+
+func (batch *RedemptionBatch) Insert() error {
+	batch.CreatedTime = utils.GetTimestamp()
+	return DB.Create(batch).Error
+}
+
+func (batch *RedemptionBatch) Update() error {
+	return DB.Model(batch).Select("campaign", "start_time", "end_time", "per_user_limit").Updates(batch).Error
+}
+
+// checkRedemptionBatch 在兑换的事务中检查批次的兑换时间和用户已兑换的个数
+func checkRedemptionBatch(tx *gorm.DB, batchId int, userId int) error {
+	var batch RedemptionBatch
+	if err := tx.Where("id = ?", batchId).First(&batch).Error; err != nil {
+		// 批次被删除时不再限制
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	now := utils.GetTimestamp()
+	if batch.StartTime > 0 && now < batch.StartTime {
+		return errors.New("该兑换码尚未到兑换时间")
+	}
+	if batch.EndTime > 0 && now > batch.EndTime {
+		return errors.New("该兑换码已过兑换期限")
+	}
+
+	if batch.PerUserLimit > 0 {
+		var count int64
+		err := tx.Model(&Redemption{}).Where("batch_id = ? AND redeemed_user_id = ? AND status = ?", batchId, userId, config.RedemptionCodeStatusUsed).Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count >= int64(batch.PerUserLimit) {
+			return fmt.Errorf("每个用户最多兑换 %d 个该批次的兑换码", batch.PerUserLimit)
+		}
+	}
+	return nil
+}
+
+// RedemptionCampaignStatistics 活动的兑换情况，只统计属于批次的兑换码
+type RedemptionCampaignStatistics struct {
+	Campaign       string  `json:"campaign"`
+	Batches        int64   `json:"batches"`
+	Total          int64   `json:"total"`
+	Redeemed       int64   `json:"redeemed"`
+	RedeemedQuota  int64   `json:"redeemed_quota"`
+	UserCount      int64   `json:"user_count"`
+	RedemptionRate float64 `json:"redemption_rate"`
+}
+
+func GetRedemptionCampaignStatistics(campaign string) ([]*RedemptionCampaignStatistics, error) {
+	var statistics []*RedemptionCampaignStatistics
+	tx := DB.Table("redemptions").
+		Select(
+			"redemption_batches.campaign AS campaign",
+			"count(distinct redemption_batches.id) AS batches",
+			"count(*) AS total",
+			fmt.Sprintf("sum(case when redemptions.status = %d then 1 else 0 end) AS redeemed", config.RedemptionCodeStatusUsed),
+			fmt.Sprintf("sum(case when redemptions.status = %d then redemptions.quota else 0 end) AS redeemed_quota", config.RedemptionCodeStatusUsed),
+			fmt.Sprintf("count(distinct case when redemptions.status = %d then redemptions.redeemed_user_id end) AS user_count", config.RedemptionCodeStatusUsed),
+		).
+		Joins("JOIN redemption_batches ON redemptions.batch_id = redemption_batches.id")
+	if campaign != "" {
+		tx = tx.Where("redemption_batches.campaign = ?", campaign)
+	}
+	if err := tx.Group("redemption_batches.campaign").Order("campaign").Scan(&statistics).Error; err != nil {
+		return nil, err
+	}
+
+	for _, item := range statistics {
+		if item.Total > 0 {
+			item.RedemptionRate = float64(item.Redeemed) / float64(item.Total)
+		}
+	}
+	return statistics, nil
+}
@@ -0,0 +1,233 @@
+package model
+
+import (
+	"fmt"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/utils"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReferralStatusValid    = "valid"    // 已发放注册奖励
+	ReferralStatusRejected = "rejected" // 未通过防刷检查，不发放奖励
+)
+
+// Referral 邀请记录，每个被邀请用户只有一条
+type Referral struct {
+	Id               int    `json:"id"`
+	InviterId        int    `json:"inviter_id" gorm:"index"`
+	InviteeId        int    `json:"invitee_id" gorm:"uniqueIndex"`
+	SignupIp         string `json:"signup_ip,omitempty" gorm:"type:varchar(64);index;default:''"`
+	Status           string `json:"status" gorm:"type:varchar(16)"`
+	Reason           string `json:"reason" gorm:"type:varchar(255);default:''"`
+	InviterQuota     int    `json:"inviter_quota" gorm:"default:0"` // 邀请人累计获得的奖励
+	InviteeQuota     int    `json:"invitee_quota" gorm:"default:0"` // 被邀请人累计获得的奖励
+	PurchaseRewarded bool   `json:"purchase_rewarded" gorm:"default:false"`
+	CreatedTime      int64  `json:"created_time" gorm:"bigint;index"`
+}
+
+type ReferralsListParams struct {
+	InviterId int    `form:"inviter_id"`
+	Status    string `form:"status"`
+	PaginationParams
+}
+
+var allowedReferralsOrderFields = map[string]bool{
+	"id":            true,
+	"inviter_id":    true,
+	"inviter_quota": true,
+	"created_time":  true,
+}
+
+func GetReferralsList(params *ReferralsListParams) (*DataResult[Referral], error) {
+	var referrals []*Referral
+	tx := DB.Model(&Referral{})
+	if params.InviterId != 0 {
+		tx = tx.Where("inviter_id = ?", params.InviterId)
+	}
+	if params.Status != "" {
+		tx = tx.Where("status = ?", params.Status)
+	}
+	return PaginateAndOrder(tx, &params.PaginationParams, &referrals, allowedReferralsOrderFields)
+}
+
+// ApplyReferral 新用户注册后记录邀请关系，通过防刷检查时发放注册奖励
+func ApplyReferral(invitee *User, ip string) {
+	if invitee.InviterId == 0 || invitee.InviterId == invitee.Id {
+		return
+	}
+
+	referral := &Referral{
+		InviterId:   invitee.InviterId,
+		InviteeId:   invitee.Id,
+		SignupIp:    ip,
+		Status:      ReferralStatusValid,
+		CreatedTime: utils.GetTimestamp(),
+	}
+	if reason := checkReferralAbuse(referral); reason != "" {
+		referral.Status = ReferralStatusRejected
+		referral.Reason = reason
+	} else {
+		referral.InviteeQuota = config.QuotaForInvitee
+		referral.InviterQuota = config.QuotaForInviter
+	}
+
+	if err := DB.Create(referral).Error; err != nil {
+		logger.SysError(fmt.Sprintf("failed to create referral for user %d: %s", invitee.Id, err.Error()))
+		return
+	}
+	if referral.Status != ReferralStatusValid {
+		logger.SysLog(fmt.Sprintf("referral of user %d by %d rejected: %s", invitee.Id, invitee.InviterId, referral.Reason))
+		return
+	}
+	if err := DB.Model(&User{}).Where("id = ?", invitee.InviterId).Update("aff_count", gorm.Expr("aff_count + ?", 1)).Error; err != nil {
+		logger.SysError(fmt.Sprintf("failed to update inviter %d aff count: %s", invitee.InviterId, err.Error()))
+	}
+
+	rewardReferral(referral, config.QuotaForInviter, config.QuotaForInvitee, "邀请用户赠送", "使用邀请码赠送")
+}
+
+// RewardReferralPurchase 被邀请用户首次充值成功后发放奖励，每个被邀请用户只发放一次
+func RewardReferralPurchase(inviteeId int) {
+	if config.QuotaForInviterPurchase <= 0 && config.QuotaForInviteePurchase <= 0 {
+		return
+	}
+
+	var referral Referral
+	if err := DB.Where("invitee_id = ? AND status = ?", inviteeId, ReferralStatusValid).First(&referral).Error; err != nil {
+		return
+	}
+	if referral.PurchaseRewarded {
+		return
+	}
+	if enabled, _ := IsUserEnabled(referral.InviterId); !enabled {
+		return
+	}
+
+	result := DB.Model(&Referral{}).Where("id = ? AND purchase_rewarded = ?", referral.Id, false).Updates(map[string]any{
+		"purchase_rewarded": true,
+		"inviter_quota":     gorm.Expr("inviter_quota + ?", config.QuotaForInviterPurchase),
+		"invitee_quota":     gorm.Expr("invitee_quota + ?", config.QuotaForInviteePurchase),
+	})
+	if result.Error != nil {
+		logger.SysError(fmt.Sprintf("failed to update referral %d: %s", referral.Id, result.Error.Error()))
+		return
+	}
+	// 并发的充值回调已经发放过
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	rewardReferral(&referral, config.QuotaForInviterPurchase, config.QuotaForInviteePurchase, "邀请用户首次充值赠送", "首次充值邀请奖励")
+}
+
+func rewardReferral(referral *Referral, inviterQuota, inviteeQuota int, inviterLog, inviteeLog string) {
+	if inviteeQuota > 0 {
+		if err := IncreaseUserQuota(referral.InviteeId, inviteeQuota); err != nil {
+			logger.SysError(fmt.Sprintf("failed to reward invitee %d: %s", referral.InviteeId, err.Error()))
+		}
+		RecordLog(referral.InviteeId, LogTypeSystem, fmt.Sprintf("%s %s", inviteeLog, common.LogQuota(inviteeQuota)))
+	}
+	if inviterQuota > 0 {
+		if err := IncreaseUserQuota(referral.InviterId, inviterQuota); err != nil {
+			logger.SysError(fmt.Sprintf("failed to reward inviter %d: %s", referral.InviterId, err.Error()))
+		}
+		err := DB.Model(&User{}).Where("id = ?", referral.InviterId).Update("aff_history", gorm.Expr("aff_history + ?", inviterQuota)).Error
+		if err != nil {
+			logger.SysError(fmt.Sprintf("failed to update inviter %d aff history: %s", referral.InviterId, err.Error()))
+		}
+		RecordLog(referral.InviterId, LogTypeSystem, fmt.Sprintf("%s %s", inviterLog, common.LogQuota(inviterQuota)))
+	}
+}
+
+// checkReferralAbuse 返回不发放奖励的原因，通过时返回空
+func checkReferralAbuse(referral *Referral) string {
+	if enabled, _ := IsUserEnabled(referral.InviterId); !enabled {
+		return "邀请人已被禁用"
+	}
+
+	if config.ReferralDailyLimit > 0 {
+		var count int64
+		DB.Model(&Referral{}).Where("inviter_id = ? AND status = ? AND created_time >= ?", referral.InviterId, ReferralStatusValid, utils.GetTimestamp()-86400).Count(&count)
+		if count >= int64(config.ReferralDailyLimit) {
+			return "邀请人 24 小时内的邀请数超过上限"
+		}
+	}
+
+	if config.ReferralIPLimit > 0 && referral.SignupIp != "" {
+		var count int64
+		DB.Model(&Referral{}).Where("signup_ip = ? AND status = ? AND created_time >= ?", referral.SignupIp, ReferralStatusValid, utils.GetTimestamp()-86400).Count(&count)
+		if count >= int64(config.ReferralIPLimit) {
+			return "同一 IP 24 小时内的邀请注册数超过上限"
+		}
+	}
+
+	return ""
+}
+
+// ReferralReport 邀请人的邀请汇总
+type ReferralReport struct {
+	InviterId    int    `json:"inviter_id"`
+	Username     string `json:"username"`
+	Total        int64  `json:"total"`
+	Valid        int64  `json:"valid"`
+	Rejected     int64  `json:"rejected"`
+	Purchased    int64  `json:"purchased"`
+	InviterQuota int64  `json:"inviter_quota"`
+	InviteeQuota int64  `json:"invitee_quota"`
+}
+
+type ReferralReportParams struct {
+	StartTimestamp int64 `form:"start_timestamp"`
+	EndTimestamp   int64 `form:"end_timestamp"`
+	Limit          int   `form:"limit"`
+}
+
+// GetReferralReport 按邀请人汇总邀请数和奖励，按有效邀请数排序
+func GetReferralReport(params *ReferralReportParams) ([]*ReferralReport, error) {
+	limit := params.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	tx := DB.Model(&Referral{}).Select(
+		"inviter_id",
+		"count(*) AS total",
+		fmt.Sprintf("sum(case when status = '%s' then 1 else 0 end) AS valid", ReferralStatusValid),
+		fmt.Sprintf("sum(case when status = '%s' then 1 else 0 end) AS rejected", ReferralStatusRejected),
+		"sum(case when purchase_rewarded then 1 else 0 end) AS purchased",
+		"sum(inviter_quota) AS inviter_quota",
+		"sum(invitee_quota) AS invitee_quota",
+	)
+	if params.StartTimestamp > 0 {
+		tx = tx.Where("created_time >= ?", params.StartTimestamp)
+	}
+	if params.EndTimestamp > 0 {
+		tx = tx.Where("created_time <= ?", params.EndTimestamp)
+	}
+
+	var reports []*ReferralReport
+	if err := tx.Group("inviter_id").Order("valid DESC").Limit(limit).Scan(&reports).Error; err != nil {
+		return nil, err
+	}
+	for _, report := range reports {
+		report.Username, _ = CacheGetUsername(report.InviterId)
+	}
+	return reports, nil
+}
+
+// GetUserReferrals 用户自己的邀请记录，不返回注册 IP
+func GetUserReferrals(inviterId int, params *PaginationParams) (*DataResult[Referral], error) {
+	var referrals []*Referral
+	result, err := PaginateAndOrder(DB.Model(&Referral{}).Where("inviter_id = ?", inviterId), params, &referrals, allowedReferralsOrderFields)
+	if err != nil {
+		return nil, err
+	}
+	for _, referral := range referrals {
+		referral.SignupIp = ""
+	}
+	return result, nil
+}
@@ -0,0 +1,67 @@
+package model
+
+import (
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// RequestCapture 客户端发送的原始请求体，用于从日志重放请求
+type RequestCapture struct {
+	Id          int    `json:"id"`
+	RequestId   string `json:"request_id" gorm:"type:varchar(64);uniqueIndex"`
+	UserId      int    `json:"user_id" gorm:"index"`
+	Method      string `json:"method" gorm:"type:varchar(16);default:''"`
+	Path        string `json:"path" gorm:"type:varchar(255);default:''"`
+	ContentType string `json:"content_type" gorm:"type:varchar(255);default:''"`
+	Body        string `json:"body" gorm:"type:text"`
+	CreatedAt   int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+func RequestCaptureEnabled() bool {
+	return viper.GetBool("request_capture.enable")
+}
+
+// RecordRequestCapture 保存请求体，超过 request_capture.max_size 的请求不保存
+func RecordRequestCapture(capture *RequestCapture) {
+	if !RequestCaptureEnabled() || capture.RequestId == "" {
+		return
+	}
+	if maxSize := viper.GetInt("request_capture.max_size"); maxSize > 0 && len(capture.Body) > maxSize*1024 {
+		return
+	}
+
+	capture.CreatedAt = utils.GetTimestamp()
+	if err := DB.Create(capture).Error; err != nil {
+		logger.SysError("failed to record request capture: " + err.Error())
+	}
+}
+
+func GetRequestCapture(requestId string) (*RequestCapture, error) {
+	var capture RequestCapture
+	err := DB.Where("request_id = ?", requestId).First(&capture).Error
+	return &capture, err
+}
+
+// DeleteExpiredRequestCaptures 删除超过 request_capture.retention_days 的请求体
+func DeleteExpiredRequestCaptures() (int64, error) {
+	days := viper.GetInt("request_capture.retention_days")
+	if days <= 0 {
+		return 0, nil
+	}
+	result := DB.Where("created_at < ?", time.Now().AddDate(0, 0, -days).Unix()).Delete(&RequestCapture{})
+	return result.RowsAffected, result.Error
+}
+
+// GetRecentRequestCaptures 最近保存的请求体，path 不为空时只返回该路径的请求
+func GetRecentRequestCaptures(path string, limit int) ([]*RequestCapture, error) {
+	var captures []*RequestCapture
+	tx := DB.Order("id desc").Limit(limit)
+	if path != "" {
+		tx = tx.Where("path = ?", path)
+	}
+	err := tx.Find(&captures).Error
+	return captures, err
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/utils"
+
+	"github.com/spf13/viper"
+)
+
+// RequestJournal 已预扣费但尚未结算的请求
+// 请求结算或退款时删除记录，实例崩溃后遗留的记录由恢复任务退还预扣费用。
+// 结算、退款与恢复任务通过删除记录竞争，只有删除成功的一方调整额度，保证预扣费用只处理一次。
+type RequestJournal struct {
+	Id               int    `json:"id"`
+	RequestId        string `json:"request_id" gorm:"type:varchar(64);default:''"`
+	InstanceId       string `json:"instance_id" gorm:"type:varchar(64);default:''"`
+	UserId           int    `json:"user_id"`
+	TokenId          int    `json:"token_id"`
+	PreConsumedQuota int    `json:"pre_consumed_quota"`
+	CreatedAt        int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+// StartRequestJournal 记录预扣费，返回记录 ID，写入失败时返回 0
+func StartRequestJournal(requestId string, userId int, tokenId int, preConsumedQuota int) int {
+	journal := &RequestJournal{
+		RequestId:        requestId,
+		InstanceId:       config.InstanceID,
+		UserId:           userId,
+		TokenId:          tokenId,
+		PreConsumedQuota: preConsumedQuota,
+		CreatedAt:        utils.GetTimestamp(),
+	}
+
+	if err := DB.Create(journal).Error; err != nil {
+		logger.SysError("failed to create request journal: " + err.Error())
+		return 0
+	}
+
+	return journal.Id
+}
+
+// FinishRequestJournal 结算前删除记录，返回 false 表示预扣费用已被恢复任务退还
+func FinishRequestJournal(id int) bool {
+	if id == 0 {
+		return true
+	}
+
+	result := DB.Delete(&RequestJournal{}, id)
+	if result.Error != nil {
+		// 无法确认状态时按未退还处理，避免少扣费
+		logger.SysError("failed to finish request journal: " + result.Error.Error())
+		return true
+	}
+
+	return result.RowsAffected > 0
+}
+
+// RecoverRequestJournals 退还超时未结算请求的预扣费用
+func RecoverRequestJournals() {
+	timeout := int64(viper.GetInt("request_journal_timeout"))
+	if timeout <= 0 {
+		return
+	}
+
+	var journals []*RequestJournal
+	err := DB.Where("created_at < ?", utils.GetTimestamp()-timeout).Limit(1000).Find(&journals).Error
+	if err != nil {
+		logger.SysError("failed to query request journals: " + err.Error())
+		return
+	}
+
+	for _, journal := range journals {
+		if !FinishRequestJournal(journal.Id) {
+			continue
+		}
+
+		if err := PostConsumeTokenQuota(journal.TokenId, -journal.PreConsumedQuota); err != nil {
+			logger.SysError(fmt.Sprintf("failed to refund request journal %d: %s", journal.Id, err.Error()))
+			continue
+		}
+
+		logger.SysLog(fmt.Sprintf("refunded %d pre-consumed quota of unfinished request %s (user %d, instance %s)", journal.PreConsumedQuota, journal.RequestId, journal.UserId, journal.InstanceId))
+	}
+}
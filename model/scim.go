@@ -0,0 +1,27 @@
+package model
+
+import "fmt"
+
+// SearchUsersByField 按字段精确查找用户，field 为空时返回全部用户，按 id 排序
+func SearchUsersByField(field string, value any, offset, limit int) ([]*User, int64, error) {
+	db := DB.Model(&User{})
+	if field != "" {
+		db = db.Where(fmt.Sprintf("%s = ?", field), value)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []*User
+	err := db.Order("id").Offset(offset).Limit(limit).Find(&users).Error
+	return users, total, err
+}
+
+// GetUsersByGroup 分组中的全部用户
+func GetUsersByGroup(group string) ([]*User, error) {
+	var users []*User
+	err := DB.Select("id", "username", "display_name").Where(&User{Group: group}).Order("id").Find(&users).Error
+	return users, err
+}
@@ -27,7 +27,7 @@ func GetUserModelStatisticsByPeriod(userId int, startTime, endTime string) (LogS
 		dateStr = "strftime('%Y-%m-%d', date) as date"
 	}
 
-	err = DB.Raw(`
+	err = ReadDB().Raw(`
 		SELECT `+dateStr+`,
 		model_name, 
 		sum(request_count) as request_count,
@@ -44,6 +44,20 @@ func GetUserModelStatisticsByPeriod(userId int, startTime, endTime string) (LogS
 	return
 }
 
+// GetUserStatisticsSummary 用户在日期范围内的用量合计，数据来自每十分钟更新的统计表
+func GetUserStatisticsSummary(userId int, startDate, endDate string) (*UsageStatistic, error) {
+	summary := &UsageStatistic{}
+	err := ReadDB().Model(&Statistics{}).
+		Select(`COALESCE(sum(request_count), 0) as request_count,
+		COALESCE(sum(quota), 0) as quota,
+		COALESCE(sum(prompt_tokens), 0) as prompt_tokens,
+		COALESCE(sum(completion_tokens), 0) as completion_tokens,
+		COALESCE(sum(request_time), 0) as request_time`).
+		Where("user_id = ? AND date BETWEEN ? AND ?", userId, startDate, endDate).
+		Scan(summary).Error
+	return summary, err
+}
+
 type MultiUserStatistic struct {
 	Username         string `gorm:"column:username" json:"username"`
 	ModelName        string `gorm:"column:model_name" json:"model_name"`
@@ -80,7 +94,7 @@ func GetMultiUserStatisticsByPeriod(usernames []string, startTime, endTime strin
 		ORDER BY users.username, statistics.model_name
 	`
 
-	err := DB.Raw(query, usernames, startTime, endTime).Scan(&statistics).Error
+	err := ReadDB().Raw(query, usernames, startTime, endTime).Scan(&statistics).Error
 	if err != nil {
 		return nil, err
 	}
@@ -130,7 +144,7 @@ func GetUserGroupedStatisticsByPeriod(usernames []string, startTime, endTime str
 		ORDER BY users.username
 	`
 
-	err := DB.Raw(query, usernames, startTime, endTime).Scan(&statistics).Error
+	err := ReadDB().Raw(query, usernames, startTime, endTime).Scan(&statistics).Error
 	if err != nil {
 		return nil, err
 	}
@@ -159,7 +173,7 @@ func GetModelUsageByUser(usernames []string, startTime, endTime string) ([]*Mode
 		ORDER BY users.username, request_count DESC
 	`
 
-	err := DB.Raw(query, usernames, startTime, endTime).Scan(&usage).Error
+	err := ReadDB().Raw(query, usernames, startTime, endTime).Scan(&usage).Error
 	if err != nil {
 		return nil, err
 	}
@@ -222,7 +236,7 @@ func GetChannelExpensesStatisticsByPeriod(startTime, endTime, groupType string,
 	}
 
 	sql = fmt.Sprintf(sql, whereClause.String())
-	err = DB.Raw(sql, args...).Scan(&LogStatistics).Error
+	err = ReadDB().Raw(sql, args...).Scan(&LogStatistics).Error
 	if err != nil {
 		return nil, err
 	}
@@ -238,14 +252,19 @@ const (
 	StatisticsUpdateTypeALL       StatisticsUpdateType = 3
 )
 
+// UpdateStatistics 更新每日统计，今天和昨天的数据从小时统计汇总，全部更新时从日志表汇总
 func UpdateStatistics(updateType StatisticsUpdateType) error {
-	sql := `
-	%s statistics (date, user_id, channel_id, model_name, request_count, quota, prompt_tokens, completion_tokens, request_time)
-	SELECT 
-		%s as date,
-		user_id,
-		channel_id,
-		model_name, 
+	now := time.Now()
+	todayTimestamp := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Unix()
+
+	switch updateType {
+	case StatisticsUpdateTypeToDay:
+		return updateDailyStatistics(todayTimestamp, todayTimestamp+86400)
+	case StatisticsUpdateTypeYesterday:
+		return updateDailyStatistics(todayTimestamp-86400, todayTimestamp)
+	}
+
+	return upsertDailyStatistics("created_at", `
 		count(1) as request_count,
 		sum(quota) as quota,
 		sum(prompt_tokens) as prompt_tokens,
@@ -253,7 +272,33 @@ func UpdateStatistics(updateType StatisticsUpdateType) error {
 		sum(request_time) as request_time
 	FROM logs
 	WHERE
-		type = 2
+		type = 2`)
+}
+
+// updateDailyStatistics 从小时统计重新汇总 [start, end) 所在日期的每日统计，start 向前取整到当天零点
+func updateDailyStatistics(start, end int64) error {
+	startTime := time.Unix(start, 0)
+	dayStart := time.Date(startTime.Year(), startTime.Month(), startTime.Day(), 0, 0, 0, 0, startTime.Location()).Unix()
+	return upsertDailyStatistics("hour_start", fmt.Sprintf(`
+		sum(request_count) as request_count,
+		sum(quota) as quota,
+		sum(prompt_tokens) as prompt_tokens,
+		sum(completion_tokens) as completion_tokens,
+		sum(request_time) as request_time
+	FROM statistics_hourly
+	WHERE
+		hour_start >= %d AND hour_start < %d`, dayStart, end))
+}
+
+// upsertDailyStatistics 按 timeColumn 所在日期汇总 source 并写入每日统计，已有的记录会被覆盖
+func upsertDailyStatistics(timeColumn string, source string) error {
+	sql := `
+	%s statistics (date, user_id, channel_id, model_name, request_count, quota, prompt_tokens, completion_tokens, request_time)
+	SELECT 
+		%s as date,
+		user_id,
+		channel_id,
+		model_name, 
 		%s
 	GROUP BY date, channel_id, user_id, model_name
 	ORDER BY date, model_name
@@ -261,16 +306,15 @@ func UpdateStatistics(updateType StatisticsUpdateType) error {
 	`
 
 	sqlPrefix := ""
-	sqlWhere := ""
 	sqlDate := ""
 	sqlSuffix := ""
 	if common.UsingSQLite {
 		sqlPrefix = "INSERT OR REPLACE INTO"
-		sqlDate = "strftime('%Y-%m-%d', datetime(created_at, 'unixepoch', '+8 hours'))"
+		sqlDate = "strftime('%Y-%m-%d', datetime(" + timeColumn + ", 'unixepoch', '+8 hours'))"
 		sqlSuffix = ""
 	} else if common.UsingPostgreSQL {
 		sqlPrefix = "INSERT INTO"
-		sqlDate = "DATE_TRUNC('day', TO_TIMESTAMP(created_at))::DATE"
+		sqlDate = "DATE_TRUNC('day', TO_TIMESTAMP(" + timeColumn + "))::DATE"
 		sqlSuffix = `ON CONFLICT (date, user_id, channel_id, model_name) DO UPDATE SET
 		request_count = EXCLUDED.request_count,
 		quota = EXCLUDED.quota,
@@ -279,7 +323,7 @@ func UpdateStatistics(updateType StatisticsUpdateType) error {
 		request_time = EXCLUDED.request_time`
 	} else {
 		sqlPrefix = "INSERT INTO"
-		sqlDate = "DATE_FORMAT(FROM_UNIXTIME(created_at), '%Y-%m-%d')"
+		sqlDate = "DATE_FORMAT(FROM_UNIXTIME(" + timeColumn + "), '%Y-%m-%d')"
 		sqlSuffix = `ON DUPLICATE KEY UPDATE
 		request_count = VALUES(request_count),
 		quota = VALUES(quota),
@@ -287,17 +331,6 @@ func UpdateStatistics(updateType StatisticsUpdateType) error {
 		completion_tokens = VALUES(completion_tokens),
 		request_time = VALUES(request_time)`
 	}
-	now := time.Now()
-	todayTimestamp := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).Unix()
-
-	switch updateType {
-	case StatisticsUpdateTypeToDay:
-		sqlWhere = fmt.Sprintf("AND created_at >= %d", todayTimestamp)
-	case StatisticsUpdateTypeYesterday:
-		yesterdayTimestamp := todayTimestamp - 86400
-		sqlWhere = fmt.Sprintf("AND created_at >= %d AND created_at < %d", yesterdayTimestamp, todayTimestamp)
-	}
 
-	err := DB.Exec(fmt.Sprintf(sql, sqlPrefix, sqlDate, sqlWhere, sqlSuffix)).Error
-	return err
+	return DB.Exec(fmt.Sprintf(sql, sqlPrefix, sqlDate, source, sqlSuffix)).Error
 }
@@ -0,0 +1,142 @@
+package model
+
+import (
+	"errors"
+	"one-api/common/logger"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// StatisticsHourly 按小时汇总的消费日志，由主节点定时更新，每日统计和用量合计从这里汇总，避免扫描日志表
+type StatisticsHourly struct {
+	HourStart        int64  `json:"hour_start" gorm:"primaryKey;autoIncrement:false"` // 小时开始的时间戳
+	UserId           int    `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	ChannelId        int    `json:"channel_id" gorm:"primaryKey;autoIncrement:false"`
+	ModelName        string `json:"model_name" gorm:"primaryKey;type:varchar(255)"`
+	RequestCount     int    `json:"request_count"`
+	Quota            int    `json:"quota"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	RequestTime      int    `json:"request_time"`
+}
+
+func (StatisticsHourly) TableName() string {
+	return "statistics_hourly"
+}
+
+// hourlyRetentionStart 小时统计保留的最早时间，更早的数据已删除
+func hourlyRetentionStart() int64 {
+	days := viper.GetInt("statistics.hourly_retention_days")
+	if days <= 0 {
+		days = 90
+	}
+	now := time.Now().Unix()
+	return now - now%3600 - int64(days)*86400
+}
+
+// latestHourlyStatistics 小时统计表中最新的小时，更早的小时已不会再变化，表为空时返回 0
+func latestHourlyStatistics(db *gorm.DB) int64 {
+	var hour int64
+	db.Model(&StatisticsHourly{}).Select("COALESCE(MAX(hour_start), 0)").Scan(&hour)
+	return hour
+}
+
+// updateStatisticsHourly 重新汇总 [start, start+1h) 的消费日志
+func updateStatisticsHourly(start int64) error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("hour_start = ?", start).Delete(&StatisticsHourly{}).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+		INSERT INTO statistics_hourly (hour_start, user_id, channel_id, model_name, request_count, quota, prompt_tokens, completion_tokens, request_time)
+		SELECT
+			?,
+			user_id,
+			channel_id,
+			model_name,
+			count(1),
+			sum(quota),
+			sum(prompt_tokens),
+			sum(completion_tokens),
+			sum(request_time)
+		FROM logs
+		WHERE type = ? AND created_at >= ? AND created_at < ?
+		GROUP BY user_id, channel_id, model_name
+		`, start, LogTypeConsume, start, start+3600).Error
+	})
+}
+
+// RollupStatistics 重新汇总当前和上一个小时的日志，并更新这些小时所在日期的每日统计
+// 表为空时补齐保留期内的数据，主节点停机期间缺少的小时在下次运行时补齐
+func RollupStatistics() error {
+	now := time.Now().Unix()
+	current := now - now%3600
+	start := current - 3600
+
+	latest := latestHourlyStatistics(DB)
+	if latest == 0 {
+		// 从保留期内最早的消费日志开始补齐
+		var first int64
+		DB.Model(&Log{}).Select("COALESCE(MIN(created_at), 0)").
+			Where("type = ? AND created_at >= ?", LogTypeConsume, hourlyRetentionStart()).Scan(&first)
+		if first > 0 && first < start {
+			start = first - first%3600
+			logger.SysLog("backfilling hourly statistics")
+		}
+	} else if latest < start {
+		start = latest
+	}
+
+	for hour := start; hour <= current; hour += 3600 {
+		if err := updateStatisticsHourly(hour); err != nil {
+			return err
+		}
+	}
+
+	return updateDailyStatistics(start, current+3600)
+}
+
+// PruneStatisticsHourly 删除超过保留期的小时统计
+func PruneStatisticsHourly() (int64, error) {
+	result := DB.Where("hour_start < ?", hourlyRetentionStart()).Delete(&StatisticsHourly{})
+	return result.RowsAffected, result.Error
+}
+
+// sumHourlyQuota 汇总 [start, end) 内完整小时的额度
+func sumHourlyQuota(start, end int64, modelName string, userId int, channel int) (quota int) {
+	tx := ReadDB().Model(&StatisticsHourly{}).Select(assembleSumSelectStr("quota")).
+		Where("hour_start >= ? AND hour_start < ?", start, end)
+	if userId != 0 {
+		tx = tx.Where("user_id = ?", userId)
+	}
+	if modelName != "" {
+		tx = tx.Where("model_name = ?", modelName)
+	}
+	if channel != 0 {
+		tx = tx.Where("channel_id = ?", channel)
+	}
+	tx.Scan(&quota)
+	return quota
+}
+
+// hourlyRollupRange 返回 [start, end] 中可以从小时统计读取的完整小时范围 [from, to)
+// 最新的小时可能还在变化，保留期之前的数据已删除，这些部分需要读取日志表
+func hourlyRollupRange(start, end int64) (from, to int64, err error) {
+	latest := latestHourlyStatistics(ReadDB())
+	if latest == 0 {
+		return 0, 0, errors.New("hourly statistics not ready")
+	}
+
+	from = max(start, hourlyRetentionStart())
+	from = (from + 3599) / 3600 * 3600
+	to = latest
+	if end != 0 {
+		to = min(to, (end+1)/3600*3600)
+	}
+	if from >= to {
+		return 0, 0, errors.New("no complete hours in range")
+	}
+	return from, to, nil
+}
@@ -8,8 +8,9 @@ import (
 )
 
 const (
-	TaskPlatformSuno  = "suno"
-	TaskPlatformKling = "kling"
+	TaskPlatformSuno       = "suno"
+	TaskPlatformKling      = "kling"
+	TaskPlatformFineTuning = "fine_tuning"
 )
 
 type TaskStatus string
@@ -71,6 +72,17 @@ func GetTaskByTaskId(platform string, userId int, taskId string) (task *Task, er
 	return
 }
 
+// GetUserTasksAfter 用户在某个平台的任务，按 ID 倒序，afterId 大于 0 时只返回更早的任务
+func GetUserTasksAfter(platform string, userId int, afterId int64, limit int) (tasks []*Task, err error) {
+	tx := DB.Where("platform = ? and user_id = ?", platform, userId)
+	if afterId > 0 {
+		tx = tx.Where("id < ?", afterId)
+	}
+	err = tx.Order("id desc").Limit(limit).Find(&tasks).Error
+
+	return
+}
+
 func (Task *Task) Insert() error {
 	return DB.Create(Task).Error
 }
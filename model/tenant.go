@@ -0,0 +1,96 @@
+package model
+
+import (
+	"errors"
+	"one-api/common/config"
+	"one-api/common/utils"
+)
+
+// Tenant 租户，用户和渠道通过 tenant_id 归属租户，0 表示不属于任何租户
+// 租户管理员只能管理本租户的用户和渠道，租户用户可以使用本租户的渠道和公共渠道（tenant_id 为 0）
+type Tenant struct {
+	Id          int    `json:"id"`
+	Name        string `json:"name" gorm:"type:varchar(64);uniqueIndex"`
+	CreatedTime int64  `json:"created_time" gorm:"bigint"`
+}
+
+type SearchTenantParams struct {
+	Tenant
+	PaginationParams
+}
+
+var allowedTenantOrderFields = map[string]bool{
+	"id":           true,
+	"name":         true,
+	"created_time": true,
+}
+
+func GetTenantsList(params *SearchTenantParams) (*DataResult[Tenant], error) {
+	var tenants []*Tenant
+	db := DB
+
+	if params.Name != "" {
+		db = db.Where("name LIKE ?", params.Name+"%")
+	}
+
+	return PaginateAndOrder(db, &params.PaginationParams, &tenants, allowedTenantOrderFields)
+}
+
+func GetTenantById(id int) (*Tenant, error) {
+	var tenant Tenant
+	err := DB.Where("id = ?", id).First(&tenant).Error
+	return &tenant, err
+}
+
+func (t *Tenant) Create() error {
+	t.CreatedTime = utils.GetTimestamp()
+	return DB.Create(t).Error
+}
+
+func (t *Tenant) Update() error {
+	return DB.Select("name").Updates(t).Error
+}
+
+// Delete 租户下仍有用户或渠道时不允许删除
+func (t *Tenant) Delete() error {
+	var count int64
+	if err := DB.Model(&User{}).Where("tenant_id = ?", t.Id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("租户下仍有用户，无法删除")
+	}
+
+	if err := DB.Model(&Channel{}).Where("tenant_id = ?", t.Id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("租户下仍有渠道，无法删除")
+	}
+
+	return DB.Delete(t).Error
+}
+
+// SetUserTenant 修改用户所属租户，tenantId 为 0 时移出租户
+func SetUserTenant(userId int, tenantId int) error {
+	if tenantId != 0 {
+		if _, err := GetTenantById(tenantId); err != nil {
+			return errors.New("租户不存在")
+		}
+	}
+
+	var user User
+	if err := DB.Select("id", "role").First(&user, "id = ?", userId).Error; err != nil {
+		return err
+	}
+	if user.Role == config.RoleRootUser && tenantId != 0 {
+		return errors.New("超级管理员不能属于租户")
+	}
+
+	return UpdateUser(userId, map[string]interface{}{"tenant_id": tenantId})
+}
+
+func GetUserTenantId(id int) (tenantId int, err error) {
+	err = DB.Model(&User{}).Where("id = ?", id).Select("tenant_id").Find(&tenantId).Error
+	return tenantId, err
+}
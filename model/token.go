@@ -10,7 +10,10 @@ import (
 	"one-api/common/redis"
 	"one-api/common/stmp"
 	"one-api/common/utils"
+	"strings"
 
+	"github.com/samber/lo"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -26,7 +29,8 @@ var (
 type Token struct {
 	Id             int            `json:"id"`
 	UserId         int            `json:"user_id"`
-	Key            string         `json:"key" gorm:"type:varchar(59);uniqueIndex"`
+	Key            string         `json:"-" gorm:"type:varchar(128);uniqueIndex"` // 加盐哈希，见 HashTokenKey
+	KeyPrefix      string         `json:"key_prefix" gorm:"type:varchar(16);index;default:''"`
 	Status         int            `json:"status" gorm:"default:1"`
 	Name           string         `json:"name" gorm:"index" `
 	CreatedTime    int64          `json:"created_time" gorm:"bigint"`
@@ -39,7 +43,21 @@ type Token struct {
 	BackupGroup    string         `json:"backup_group" gorm:"default:''"`
 	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
 
+	// 管理员绑定的渠道，不为空时令牌只使用这些渠道
+	ChannelIds datatypes.JSONSlice[int] `json:"channel_ids,omitempty" gorm:"type:json"`
+	// 内部服务账号，不扣除额度但照常记录日志和限流，只能由管理员设置
+	ServiceAccount bool `json:"service_account" gorm:"default:false"`
+	// 外部系统（如 Terraform）中的 ID，同一用户内唯一，按此 ID 创建或更新
+	ExternalId string `json:"external_id" gorm:"type:varchar(255);index;default:''"`
+
 	Setting database.JSONType[TokenSetting] `json:"setting" form:"setting" gorm:"type:json"`
+
+	// 明文 key，只在创建时返回一次
+	PlainKey string `json:"key,omitempty" gorm:"-:all"`
+
+	// 按额度显示设置格式化的额度
+	RemainQuotaText string `json:"remain_quota_text" gorm:"-:all"`
+	UsedQuotaText   string `json:"used_quota_text" gorm:"-:all"`
 }
 
 var allowedTokenOrderFields = map[string]bool{
@@ -52,6 +70,12 @@ var allowedTokenOrderFields = map[string]bool{
 	"used_quota":   true,
 }
 
+func (token *Token) AfterFind(tx *gorm.DB) (err error) {
+	token.RemainQuotaText = common.FormatQuota(token.RemainQuota)
+	token.UsedQuotaText = common.FormatQuota(token.UsedQuota)
+	return nil
+}
+
 // 添加 AfterCreate 钩子方法
 func (token *Token) AfterCreate(tx *gorm.DB) (err error) {
 	tokenKey, err := common.GenerateToken(token.Id, token.UserId)
@@ -59,13 +83,43 @@ func (token *Token) AfterCreate(tx *gorm.DB) (err error) {
 		return err
 	}
 
-	// 更新 key 字段
-	return tx.Model(token).Update("key", tokenKey).Error
+	// 只保存哈希和前缀
+	token.PlainKey = tokenKey
+	token.Key = HashTokenKey(tokenKey)
+	token.KeyPrefix = TokenKeyPrefix(tokenKey)
+	return tx.Model(token).Updates(map[string]any{"key": token.Key, "key_prefix": token.KeyPrefix}).Error
+}
+
+// DerivedKey 新格式的 key 可以由令牌 ID 和用户 ID 重新签名得到，供演练场、签名请求和客户端证书使用，
+// 旧格式的随机 key 只保存了哈希，返回 false
+func (token *Token) DerivedKey() (string, bool) {
+	key, err := common.GenerateToken(token.Id, token.UserId)
+	if err != nil || !VerifyTokenKey(token.Key, key) {
+		return "", false
+	}
+	return key, true
 }
 
 type TokenSetting struct {
 	Heartbeat HeartbeatSetting `json:"heartbeat,omitempty"`
 	Limits    LimitsConfig     `json:"limits,omitempty"`
+	// 上游请求超时时间（秒），0 为不限制
+	UpstreamTimeout int `json:"upstream_timeout,omitempty"`
+	// 只接受签名请求，令牌 key 泄露后也无法直接使用
+	RequireSignature bool `json:"require_signature,omitempty"`
+	// 错误消息的语言，优先于 Accept-Language
+	Language string `json:"language,omitempty"`
+	// 提示词接近模型上下文长度时压缩
+	PromptCompression PromptCompressionSetting `json:"prompt_compression,omitempty"`
+	// 沙盒令牌只返回模拟响应，不请求上游、不扣除额度
+	Sandbox bool `json:"sandbox,omitempty"`
+	// 缓存、审查、默认参数和响应头等功能开关
+	Features TokenFeatures `json:"features,omitempty"`
+}
+
+type PromptCompressionSetting struct {
+	Enabled   bool `json:"enabled"`
+	Summarize bool `json:"summarize"` // 去掉空白后仍然过长时，用低成本模型总结最早的对话
 }
 
 type HeartbeatSetting struct {
@@ -74,8 +128,35 @@ type HeartbeatSetting struct {
 }
 
 type LimitsConfig struct {
-	LimitModelSetting LimitModelSetting `json:"limit_model_setting,omitempty"`
-	LimitsIPSetting   LimitsIPSetting   `json:"limits_ip_setting,omitempty"`
+	LimitModelSetting LimitModelSetting   `json:"limit_model_setting,omitempty"`
+	LimitsIPSetting   LimitsIPSetting     `json:"limits_ip_setting,omitempty"`
+	RequestLimit      RequestLimitSetting `json:"request_limit,omitempty"`
+}
+
+// RequestLimitSetting 请求大小限制，0 为不限制
+type RequestLimitSetting struct {
+	MaxBodySize  int `json:"max_body_size,omitempty" gorm:"default:0"`  // 请求体大小，单位 KB
+	MaxMessages  int `json:"max_messages,omitempty" gorm:"default:0"`   // 消息数量
+	MaxImageSize int `json:"max_image_size,omitempty" gorm:"default:0"` // 单张 base64 图片大小，单位 KB
+}
+
+// Merge 合并两个限制，同一项都设置时取较小值
+func (s RequestLimitSetting) Merge(other RequestLimitSetting) RequestLimitSetting {
+	return RequestLimitSetting{
+		MaxBodySize:  minLimit(s.MaxBodySize, other.MaxBodySize),
+		MaxMessages:  minLimit(s.MaxMessages, other.MaxMessages),
+		MaxImageSize: minLimit(s.MaxImageSize, other.MaxImageSize),
+	}
+}
+
+func minLimit(a, b int) int {
+	if a <= 0 {
+		return b
+	}
+	if b <= 0 || a < b {
+		return a
+	}
+	return b
 }
 
 type LimitModelSetting struct {
@@ -112,7 +193,7 @@ func GetTokenModel(key string) (token *Token, err error) {
 	case 48:
 		validUser = true
 		if config.RedisEnabled {
-			exists, _ := redis.RedisSIsMember(OldUserTokensCacheKey, key)
+			exists, _ := redis.RedisSIsMember(OldUserTokensCacheKey, TokenKeyPrefix(key))
 			if !exists {
 				return nil, ErrTokenInvalid
 			}
@@ -130,6 +211,9 @@ func GetTokenModel(key string) (token *Token, err error) {
 	}
 
 	token, err = CacheGetTokenByKey(key)
+	if err == nil && !VerifyTokenKey(token.Key, key) {
+		err = ErrTokenInvalid
+	}
 	if err != nil {
 		maskedKey := key[:3] + "*********" + key[len(key)-3:]
 		logger.SysError(fmt.Sprintf("DB Not Found: userId=%d, tokenId=%d, key=%s, err=%s", userId, tokenId, maskedKey, err.Error()))
@@ -166,7 +250,8 @@ func ValidateUserToken(key string) (token *Token, err error) {
 		return nil, ErrTokenExpired
 	}
 
-	if !token.UnlimitedQuota {
+	// 沙盒令牌不扣费，鉴权中间件限制沙盒令牌只能访问模拟响应的路由
+	if !token.UnlimitedQuota && !token.ServiceAccount && !token.Setting.Data().Sandbox {
 		if !token.UnlimitedQuota && token.RemainQuota <= 0 {
 			if !config.RedisEnabled {
 				// in this case, we can make sure the token is exhausted
@@ -193,6 +278,36 @@ func GetTokenByIds(id int, userId int) (*Token, error) {
 	return &token, err
 }
 
+// GetTokenByExternalId 按外部 ID 查询用户的令牌
+func GetTokenByExternalId(userId int, externalId string) (*Token, error) {
+	var token Token
+	err := DB.Where(&Token{UserId: userId, ExternalId: externalId}).First(&token).Error
+	return &token, err
+}
+
+// GetTokensByExternalIdPrefix 外部 ID 以 prefix 开头的令牌
+func GetTokensByExternalIdPrefix(prefix string) ([]*Token, error) {
+	var tokens []*Token
+	if err := DB.Where("external_id LIKE ?", prefix+"%").Order("id").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	// LIKE 中的 % 和 _ 是通配符，再按前缀精确过滤
+	matched := tokens[:0]
+	for _, token := range tokens {
+		if strings.HasPrefix(token.ExternalId, prefix) {
+			matched = append(matched, token)
+		}
+	}
+	return matched, nil
+}
+
+// GetUserActiveTokens 用户已启用的令牌，不包含密钥
+func GetUserActiveTokens(userId int) ([]*Token, error) {
+	var tokens []*Token
+	err := DB.Omit("key").Where("user_id = ? AND status = ?", userId, config.TokenStatusEnabled).Order("id DESC").Find(&tokens).Error
+	return tokens, err
+}
+
 func GetTokenById(id int) (*Token, error) {
 	if id == 0 {
 		return nil, errors.New("id 为空！")
@@ -213,16 +328,36 @@ func GetTokenByName(name string, userId int) (*Token, error) {
 	return &token, err
 }
 
+// GetTokenByKey 新格式的 key 按签名中的令牌 ID 查找，旧格式按前缀查找，再以常量时间校验哈希
 func GetTokenByKey(key string) (*Token, error) {
-	keyCol := "`key`"
-	if common.UsingPostgreSQL {
-		keyCol = `"key"`
+	var candidates []*Token
+	var err error
+	if tokenId, _, validateErr := common.ValidateToken(key); validateErr == nil && tokenId > 0 {
+		err = DB.Where("id = ?", tokenId).Find(&candidates).Error
+	} else {
+		err = DB.Where("key_prefix = ?", TokenKeyPrefix(key)).Find(&candidates).Error
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	var token Token
+	if len(candidates) == 0 {
+		// 从库可能早于主库迁移，仍是明文 key
+		keyCol := "`key`"
+		if common.UsingPostgreSQL {
+			keyCol = `"key"`
+		}
+		if err = DB.Where(keyCol+" = ?", key).Find(&candidates).Error; err != nil {
+			return nil, err
+		}
+	}
 
-	err := DB.Where(keyCol+" = ?", key).First(&token).Error
-	return &token, err
+	for _, token := range candidates {
+		if VerifyTokenKey(token.Key, key) {
+			return token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
 }
 
 func (token *Token) Insert() error {
@@ -234,20 +369,68 @@ func (token *Token) Insert() error {
 func (token *Token) Update() error {
 	err := DB.Model(token).Select("name", "status", "expired_time", "remain_quota", "unlimited_quota", "group", "backup_group", "setting").Updates(token).Error
 	// 防止Redis缓存不生效，直接删除
-	if err == nil && config.RedisEnabled {
-		redis.RedisDel(fmt.Sprintf(UserTokensKey, token.Key))
+	if err == nil {
+		InvalidateTokenCache(token)
 	}
 
 	return err
 }
 
+// UpdateTokenChannels 绑定令牌只能使用的渠道，channelIds 为空时解除绑定
+func UpdateTokenChannels(id int, channelIds []int) (*Token, error) {
+	token, err := GetTokenById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	channelIds = lo.Uniq(channelIds)
+	if len(channelIds) > 0 {
+		var count int64
+		if err = DB.Model(&Channel{}).Where("id IN ?", channelIds).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if int(count) != len(channelIds) {
+			return nil, errors.New("渠道不存在")
+		}
+	}
+
+	token.ChannelIds = channelIds
+	if err = DB.Model(token).Select("channel_ids").Updates(token).Error; err != nil {
+		return nil, err
+	}
+	InvalidateTokenCache(token)
+	return token, nil
+}
+
+// UpdateTokenServiceAccount 设置令牌是否为内部服务账号
+func UpdateTokenServiceAccount(id int, serviceAccount bool) (*Token, error) {
+	token, err := GetTokenById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	token.ServiceAccount = serviceAccount
+	if err = DB.Model(token).Select("service_account").Updates(token).Error; err != nil {
+		return nil, err
+	}
+	InvalidateTokenCache(token)
+	return token, nil
+}
+
 func (token *Token) SelectUpdate() error {
 	// This can update zero values
-	return DB.Model(token).Select("accessed_time", "status").Updates(token).Error
+	err := DB.Model(token).Select("accessed_time", "status").Updates(token).Error
+	if err == nil {
+		InvalidateTokenCache(token)
+	}
+	return err
 }
 
 func (token *Token) Delete() error {
 	err := DB.Delete(token).Error
+	if err == nil {
+		InvalidateTokenCache(token)
+	}
 	return err
 }
 
@@ -261,13 +444,7 @@ func DeleteTokenById(id int, userId int) (err error) {
 	if err != nil {
 		return err
 	}
-	err = token.Delete()
-
-	if err == nil && config.RedisEnabled {
-		redis.RedisDel(fmt.Sprintf(UserTokensKey, token.Key))
-	}
-
-	return err
+	return token.Delete()
 
 }
 
@@ -279,11 +456,11 @@ func IncreaseTokenQuota(id int, quota int) (err error) {
 		addNewRecord(BatchUpdateTypeTokenQuota, id, quota)
 		return nil
 	}
-	return increaseTokenQuota(id, quota)
+	return increaseTokenQuota(DB, id, quota)
 }
 
-func increaseTokenQuota(id int, quota int) (err error) {
-	err = DB.Model(&Token{}).Where("id = ?", id).Updates(
+func increaseTokenQuota(db *gorm.DB, id int, quota int) (err error) {
+	err = db.Model(&Token{}).Where("id = ?", id).Updates(
 		map[string]interface{}{
 			"remain_quota":  gorm.Expr("remain_quota + ?", quota),
 			"used_quota":    gorm.Expr("used_quota - ?", quota),
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// 令牌功能开关，由令牌所有者在管理员设置的范围内修改，超出当前范围的设置在请求时忽略
+const (
+	ModerationLevelStandard = "standard" // 使用分组的审查策略
+	ModerationLevelStrict   = "strict"   // 审查提示词和响应，命中时拦截
+	ModerationLevelRelaxed  = "relaxed"  // 只记录，不拦截也不脱敏
+)
+
+type TokenFeatures struct {
+	// 为 false 时去掉请求中的 cache_control，不参与上游的提示词缓存
+	Cache *bool `json:"cache,omitempty"`
+	// 审查级别，为空时使用分组的审查策略
+	Moderation string `json:"moderation,omitempty"`
+	// 聊天请求未指定 temperature 时使用的默认值
+	DefaultTemperature *float64 `json:"default_temperature,omitempty"`
+	// 不返回渠道、模型、重试次数和额度等元数据响应头
+	HideHeaders bool `json:"hide_headers,omitempty"`
+}
+
+// Validate 检查设置是否在管理员设置的范围内
+func (f *TokenFeatures) Validate() error {
+	if f.Cache != nil && !*f.Cache && !viper.GetBool("token_features.allow_disable_cache") {
+		return errors.New("disabling cache is not allowed")
+	}
+
+	if f.Moderation != "" {
+		levels := viper.GetStringSlice("token_features.moderation_levels")
+		if !slices.Contains([]string{ModerationLevelStandard, ModerationLevelStrict, ModerationLevelRelaxed}, f.Moderation) {
+			return fmt.Errorf("unknown moderation level %s", f.Moderation)
+		}
+		if f.Moderation != ModerationLevelStandard && !slices.Contains(levels, f.Moderation) {
+			return fmt.Errorf("moderation level %s is not allowed, allowed: %s", f.Moderation, strings.Join(levels, ", "))
+		}
+	}
+
+	if f.DefaultTemperature != nil {
+		minTemperature := viper.GetFloat64("token_features.temperature_min")
+		maxTemperature := viper.GetFloat64("token_features.temperature_max")
+		if *f.DefaultTemperature < minTemperature || *f.DefaultTemperature > maxTemperature {
+			return fmt.Errorf("default temperature must be between %g and %g", minTemperature, maxTemperature)
+		}
+	}
+
+	if f.HideHeaders && !viper.GetBool("token_features.allow_hide_headers") {
+		return errors.New("hiding headers is not allowed")
+	}
+
+	return nil
+}
+
+// CacheDisabled 是否不参与提示词缓存
+func (f *TokenFeatures) CacheDisabled() bool {
+	return f.Cache != nil && !*f.Cache
+}
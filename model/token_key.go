@@ -0,0 +1,68 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+)
+
+// 令牌 key 以加盐哈希保存，格式为 h1$<盐>$<sha256(盐+key)>，另存 key 的前缀用于查找和展示，
+// 明文只在创建时返回一次；新格式的 key 由令牌 ID 和用户 ID 签名生成，校验签名后按 ID 查找
+const (
+	tokenKeyHashScheme   = "h1$"
+	tokenKeySaltSize     = 16
+	TokenKeyPrefixLength = 12
+)
+
+// TokenKeyPrefix key 的前缀，旧格式的 key 按前缀查找
+func TokenKeyPrefix(key string) string {
+	if len(key) <= TokenKeyPrefixLength {
+		return key
+	}
+	return key[:TokenKeyPrefixLength]
+}
+
+// HashTokenKey 生成加盐哈希
+func HashTokenKey(key string) string {
+	salt := make([]byte, tokenKeySaltSize)
+	rand.Read(salt)
+	return tokenKeyHashScheme + base64.RawURLEncoding.EncodeToString(salt) + "$" + base64.RawURLEncoding.EncodeToString(tokenKeyDigest(salt, key))
+}
+
+func tokenKeyDigest(salt []byte, key string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(key))
+	return h.Sum(nil)
+}
+
+// IsHashedTokenKey 保存的 key 是否已经哈希，迁移前的明文 key 返回 false
+func IsHashedTokenKey(stored string) bool {
+	return strings.HasPrefix(stored, tokenKeyHashScheme)
+}
+
+// VerifyTokenKey 以常量时间比较 key 和保存的哈希，尚未迁移的明文 key 直接比较
+func VerifyTokenKey(stored, key string) bool {
+	if stored == "" || key == "" {
+		return false
+	}
+	if !IsHashedTokenKey(stored) {
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(key)) == 1
+	}
+
+	salt, digest, ok := strings.Cut(strings.TrimPrefix(stored, tokenKeyHashScheme), "$")
+	if !ok {
+		return false
+	}
+	saltBytes, err := base64.RawURLEncoding.DecodeString(salt)
+	if err != nil {
+		return false
+	}
+	digestBytes, err := base64.RawURLEncoding.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(digestBytes, tokenKeyDigest(saltBytes, key)) == 1
+}
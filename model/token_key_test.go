@@ -0,0 +1,140 @@
+package model
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"one-api/common"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestHashTokenKeyRoundTrip(t *testing.T) {
+	key := "sk-abcdefghijklmnopqrstuvwxyz"
+	stored := HashTokenKey(key)
+
+	assert.True(t, IsHashedTokenKey(stored))
+	assert.NotContains(t, stored, key)
+	assert.True(t, VerifyTokenKey(stored, key))
+	assert.False(t, VerifyTokenKey(stored, key+"x"))
+	assert.False(t, VerifyTokenKey(stored, ""))
+}
+
+func TestHashTokenKeySaltUnique(t *testing.T) {
+	key := "sk-abcdefghijklmnopqrstuvwxyz"
+	first, second := HashTokenKey(key), HashTokenKey(key)
+
+	assert.NotEqual(t, first, second)
+	assert.NotEqual(t, strings.Split(first, "$")[1], strings.Split(second, "$")[1])
+	assert.True(t, VerifyTokenKey(first, key))
+	assert.True(t, VerifyTokenKey(second, key))
+}
+
+func TestVerifyTokenKeyLegacyPlaintext(t *testing.T) {
+	assert.False(t, IsHashedTokenKey("sk-legacy"))
+	assert.True(t, VerifyTokenKey("sk-legacy", "sk-legacy"))
+	assert.False(t, VerifyTokenKey("sk-legacy", "sk-legacy2"))
+	assert.False(t, VerifyTokenKey("sk-legacy", "sk-legac"))
+	assert.False(t, VerifyTokenKey("", ""))
+}
+
+func TestVerifyTokenKeyMalformed(t *testing.T) {
+	key := "sk-abcdefghijklmnopqrstuvwxyz"
+	valid := HashTokenKey(key)
+	salt := strings.Split(valid, "$")[1]
+
+	tests := []struct {
+		name   string
+		stored string
+	}{
+		{"scheme only", tokenKeyHashScheme},
+		{"missing digest", tokenKeyHashScheme + salt},
+		{"empty digest", tokenKeyHashScheme + salt + "$"},
+		{"bad salt", tokenKeyHashScheme + "!!!$" + strings.Split(valid, "$")[2]},
+		{"bad digest", tokenKeyHashScheme + salt + "$!!!"},
+		{"truncated digest", valid[:len(valid)-4]},
+		{"other digest", tokenKeyHashScheme + salt + "$" + base64.RawURLEncoding.EncodeToString(make([]byte, 32))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.False(t, VerifyTokenKey(tt.stored, key))
+			// 格式错误的哈希不能退化为明文比较
+			assert.False(t, VerifyTokenKey(tt.stored, tt.stored))
+		})
+	}
+}
+
+func TestTokenKeyPrefix(t *testing.T) {
+	assert.Equal(t, "sk-short", TokenKeyPrefix("sk-short"))
+	assert.Equal(t, "sk-abcdefghi", TokenKeyPrefix("sk-abcdefghijklmnopqrstuvwxyz"))
+	assert.Len(t, TokenKeyPrefix("sk-abcdefghijklmnopqrstuvwxyz"), TokenKeyPrefixLength)
+}
+
+func setupTokenKeyDB(t *testing.T) {
+	viper.Set("user_token_secret", "token-key-test-secret")
+	t.Cleanup(func() { viper.Set("user_token_secret", "") })
+	assert.Nil(t, common.InitUserToken())
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.Nil(t, err)
+	assert.Nil(t, db.AutoMigrate(&Token{}))
+
+	oldDB := DB
+	DB = db
+	t.Cleanup(func() { DB = oldDB })
+}
+
+func TestGetTokenByKey(t *testing.T) {
+	setupTokenKeyDB(t)
+
+	// 新格式的 key 签名校验后按 ID 查找
+	token := &Token{UserId: 1, Name: "signed"}
+	assert.Nil(t, token.Insert())
+	assert.NotEmpty(t, token.PlainKey)
+	assert.True(t, IsHashedTokenKey(token.Key))
+
+	found, err := GetTokenByKey(token.PlainKey)
+	assert.Nil(t, err)
+	assert.Equal(t, token.Id, found.Id)
+	assert.Empty(t, found.PlainKey)
+
+	derived, ok := found.DerivedKey()
+	assert.True(t, ok)
+	assert.Equal(t, token.PlainKey, derived)
+
+	// 签名有效但 ID 对应的令牌哈希不匹配
+	other, err := common.GenerateToken(token.Id, token.UserId+1)
+	assert.Nil(t, err)
+	_, err = GetTokenByKey(other)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 旧格式的随机 key 按前缀查找
+	legacyKey := "sk-legacyabcdefghijklmnopqrstuvwxyz"
+	legacy := &Token{UserId: 1, Name: "legacy"}
+	assert.Nil(t, legacy.Insert())
+	assert.Nil(t, DB.Model(legacy).Updates(map[string]any{"key": HashTokenKey(legacyKey), "key_prefix": TokenKeyPrefix(legacyKey)}).Error)
+
+	found, err = GetTokenByKey(legacyKey)
+	assert.Nil(t, err)
+	assert.Equal(t, legacy.Id, found.Id)
+
+	_, err = GetTokenByKey(TokenKeyPrefix(legacyKey) + "wrong")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	// 尚未迁移的明文 key
+	plainKey := "sk-plaintextabcdefghijklmnopqrstuvwxyz"
+	plain := &Token{UserId: 1, Name: "plain"}
+	assert.Nil(t, plain.Insert())
+	assert.Nil(t, DB.Model(plain).Updates(map[string]any{"key": plainKey, "key_prefix": ""}).Error)
+
+	found, err = GetTokenByKey(plainKey)
+	assert.Nil(t, err)
+	assert.Equal(t, plain.Id, found.Id)
+
+	_, err = GetTokenByKey("")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
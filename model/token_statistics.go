@@ -0,0 +1,81 @@
+package model
+
+import (
+	"one-api/common"
+
+	"gorm.io/gorm"
+)
+
+// UsageStatistic 按天或按模型汇总的用量
+type UsageStatistic struct {
+	Date             string `json:"date,omitempty" gorm:"column:date"`
+	ModelName        string `json:"model_name,omitempty" gorm:"column:model_name"`
+	RequestCount     int64  `json:"request_count" gorm:"column:request_count"`
+	Quota            int64  `json:"quota" gorm:"column:quota"`
+	PromptTokens     int64  `json:"prompt_tokens" gorm:"column:prompt_tokens"`
+	CompletionTokens int64  `json:"completion_tokens" gorm:"column:completion_tokens"`
+	RequestTime      int64  `json:"request_time" gorm:"column:request_time"`
+}
+
+// TokenStatistics 单个令牌在时间段内的用量
+type TokenStatistics struct {
+	TokenId        int               `json:"token_id"`
+	StartTimestamp int64             `json:"start_timestamp"`
+	EndTimestamp   int64             `json:"end_timestamp"`
+	Total          UsageStatistic    `json:"total"`
+	Days           []*UsageStatistic `json:"days"`
+	Models         []*UsageStatistic `json:"models"`
+}
+
+const tokenUsageColumns = `count(1) as request_count,
+	sum(quota) as quota,
+	sum(prompt_tokens) as prompt_tokens,
+	sum(completion_tokens) as completion_tokens,
+	sum(request_time) as request_time`
+
+// GetTokenStatistics 从消费日志汇总令牌的用量，
+// 没有记录令牌 ID 的旧日志按用户和令牌名称匹配
+func GetTokenStatistics(token *Token, startTimestamp, endTimestamp int64) (*TokenStatistics, error) {
+	statistics := &TokenStatistics{
+		TokenId:        token.Id,
+		StartTimestamp: startTimestamp,
+		EndTimestamp:   endTimestamp,
+		Days:           make([]*UsageStatistic, 0),
+		Models:         make([]*UsageStatistic, 0),
+	}
+
+	logs := func() *gorm.DB {
+		return ReadDB().Model(&Log{}).
+			Where("type = ? AND created_at >= ? AND created_at <= ?", LogTypeConsume, startTimestamp, endTimestamp).
+			Where("token_id = ? OR (token_id = 0 AND user_id = ? AND token_name = ?)", token.Id, token.UserId, token.Name)
+	}
+
+	date := tokenStatisticsDateExpr()
+	err := logs().Select(date + " as date, " + tokenUsageColumns).Group(date).Order("date").Scan(&statistics.Days).Error
+	if err != nil {
+		return nil, err
+	}
+	err = logs().Select("model_name, " + tokenUsageColumns).Group("model_name").Order("quota DESC").Scan(&statistics.Models).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, day := range statistics.Days {
+		statistics.Total.RequestCount += day.RequestCount
+		statistics.Total.Quota += day.Quota
+		statistics.Total.PromptTokens += day.PromptTokens
+		statistics.Total.CompletionTokens += day.CompletionTokens
+		statistics.Total.RequestTime += day.RequestTime
+	}
+	return statistics, nil
+}
+
+// tokenStatisticsDateExpr 和每日统计使用相同的日期划分
+func tokenStatisticsDateExpr() string {
+	if common.UsingSQLite {
+		return "strftime('%Y-%m-%d', datetime(created_at, 'unixepoch', '+8 hours'))"
+	} else if common.UsingPostgreSQL {
+		return "TO_CHAR(TO_TIMESTAMP(created_at), 'YYYY-MM-DD')"
+	}
+	return "DATE_FORMAT(FROM_UNIXTIME(created_at), '%Y-%m-%d')"
+}
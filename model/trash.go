@@ -0,0 +1,196 @@
+package model
+
+import (
+	"errors"
+	"one-api/common/config"
+	"one-api/common/redis"
+	"regexp"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	TrashTypeChannel = "channel"
+	TrashTypeToken   = "token"
+	TrashTypeUser    = "user"
+)
+
+// 删除用户时用户名追加的后缀，见 User.Delete
+var deletedUsernameSuffix = regexp.MustCompile(`_del_[0-9A-Za-z]{6}$`)
+
+// TrashItem 回收站中已删除的渠道、令牌或用户
+type TrashItem struct {
+	Type      string    `json:"type" gorm:"-"`
+	Id        int       `json:"id"`
+	Name      string    `json:"name"`
+	UserId    int       `json:"user_id"`   // 令牌所属用户
+	TenantId  int       `json:"tenant_id"` // 令牌为 0
+	Role      int       `json:"-"`         // 用户的角色，用于检查操作权限
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   int64     `json:"purge_at" gorm:"-"` // 超过保留期后彻底删除的时间，0 为永久保留
+}
+
+type TrashListParams struct {
+	PaginationParams
+	Type    string `form:"type" binding:"required"`
+	Keyword string `form:"keyword"`
+}
+
+var allowedTrashOrderFields = map[string]bool{
+	"id":         true,
+	"deleted_at": true,
+}
+
+func trashRetentionDays() int {
+	return viper.GetInt("trash.retention_days")
+}
+
+// trashQuery 已删除记录的查询，tenantId 不为 0 时只包含该租户的记录
+func trashQuery(trashType string, tenantId int) (*gorm.DB, error) {
+	var tx *gorm.DB
+	switch trashType {
+	case TrashTypeChannel:
+		tx = DB.Unscoped().Table("channels").Select("id, name, 0 AS user_id, tenant_id, 0 AS role, deleted_at")
+	case TrashTypeToken:
+		tx = DB.Unscoped().Table("tokens").Select("id, name, user_id, 0 AS tenant_id, 0 AS role, deleted_at")
+		if tenantId != 0 {
+			tx = tx.Where("user_id IN (?)", DB.Unscoped().Model(&User{}).Select("id").Where("tenant_id = ?", tenantId))
+		}
+		return tx.Where("deleted_at IS NOT NULL"), nil
+	case TrashTypeUser:
+		tx = DB.Unscoped().Table("users").Select("id, username AS name, id AS user_id, tenant_id, role, deleted_at")
+	default:
+		return nil, errors.New("未知的类型")
+	}
+	if tenantId != 0 {
+		tx = tx.Where("tenant_id = ?", tenantId)
+	}
+	return tx.Where("deleted_at IS NOT NULL"), nil
+}
+
+// GetTrashList 回收站列表
+func GetTrashList(params *TrashListParams, tenantId int) (*DataResult[TrashItem], error) {
+	tx, err := trashQuery(params.Type, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	if params.Keyword != "" {
+		column := "name"
+		if params.Type == TrashTypeUser {
+			column = "username"
+		}
+		tx = tx.Where(column+" LIKE ?", params.Keyword+"%")
+	}
+	if params.Order == "" {
+		params.Order = "-deleted_at"
+	}
+
+	var items []*TrashItem
+	result, err := PaginateAndOrder[TrashItem](tx, &params.PaginationParams, &items, allowedTrashOrderFields)
+	if err != nil {
+		return nil, err
+	}
+
+	days := trashRetentionDays()
+	for _, item := range items {
+		item.Type = params.Type
+		if params.Type == TrashTypeUser {
+			item.Name = deletedUsernameSuffix.ReplaceAllString(item.Name, "")
+		}
+		if days > 0 {
+			item.PurgeAt = item.DeletedAt.AddDate(0, 0, days).Unix()
+		}
+	}
+	return result, nil
+}
+
+// GetTrashItem 回收站中的记录，不存在或不在租户范围内时返回错误
+func GetTrashItem(trashType string, id int, tenantId int) (*TrashItem, error) {
+	tx, err := trashQuery(trashType, tenantId)
+	if err != nil {
+		return nil, err
+	}
+	var item TrashItem
+	err = tx.Where("id = ?", id).Take(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("回收站中不存在该记录")
+	}
+	if err != nil {
+		return nil, err
+	}
+	item.Type = trashType
+	return &item, nil
+}
+
+// RestoreTrashItem 恢复已删除的记录，渠道恢复后重新加载渠道的分组和模型
+func RestoreTrashItem(item *TrashItem) error {
+	switch item.Type {
+	case TrashTypeChannel:
+		if err := DB.Unscoped().Model(&Channel{}).Where("id = ?", item.Id).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		ChannelGroup.Load()
+		if config.RedisEnabled {
+			_ = redis.RedisPublish(redis.RedisTopicChannelsSync, "reload")
+		}
+	case TrashTypeToken:
+		var user User
+		if err := DB.Select("id").Where("id = ?", item.UserId).Take(&user).Error; err != nil {
+			return errors.New("令牌所属用户已被删除，请先恢复用户")
+		}
+		return DB.Unscoped().Model(&Token{}).Where("id = ?", item.Id).Update("deleted_at", nil).Error
+	case TrashTypeUser:
+		var user User
+		if err := DB.Unscoped().Where("id = ?", item.Id).Take(&user).Error; err != nil {
+			return err
+		}
+		// 恢复删除前的用户名，已被占用时保留带后缀的用户名
+		username := deletedUsernameSuffix.ReplaceAllString(user.Username, "")
+		if username == "" || IsFieldAlreadyTaken("username", username) {
+			username = user.Username
+		}
+		err := DB.Unscoped().Model(&User{}).Where("id = ?", item.Id).Updates(map[string]any{
+			"deleted_at": nil,
+			"username":   username,
+		}).Error
+		if err != nil {
+			return err
+		}
+		InvalidateUserCache(item.Id)
+	}
+	return nil
+}
+
+// PurgeTrashItem 彻底删除回收站中的记录
+func PurgeTrashItem(item *TrashItem) error {
+	switch item.Type {
+	case TrashTypeChannel:
+		return DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", item.Id).Delete(&Channel{}).Error
+	case TrashTypeToken:
+		return DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", item.Id).Delete(&Token{}).Error
+	case TrashTypeUser:
+		return DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL", item.Id).Delete(&User{}).Error
+	}
+	return nil
+}
+
+// PurgeExpiredTrash 彻底删除超过 trash.retention_days 的渠道、令牌和用户，返回删除的数量
+func PurgeExpiredTrash() (int64, error) {
+	days := trashRetentionDays()
+	if days <= 0 {
+		return 0, nil
+	}
+	before := time.Now().AddDate(0, 0, -days)
+
+	var count int64
+	for _, model := range []any{&Channel{}, &Token{}, &User{}} {
+		result := DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(model)
+		if result.Error != nil {
+			return count, result.Error
+		}
+		count += result.RowsAffected
+	}
+	return count, nil
+}
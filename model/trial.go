@@ -0,0 +1,105 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"one-api/common/config"
+	"one-api/common/database"
+	"one-api/common/utils"
+
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+)
+
+// TrialToken 访客领取的匿名试用令牌，同一 IP 和浏览器指纹在有效期内只领取一个
+type TrialToken struct {
+	Id          int    `json:"id"`
+	TokenId     int    `json:"token_id" gorm:"index"`
+	Ip          string `json:"ip" gorm:"type:varchar(64);index"`
+	Fingerprint string `json:"fingerprint" gorm:"type:varchar(64);index"` // 指纹的 SHA-256
+	CreatedTime int64  `json:"created_time" gorm:"bigint;index"`
+	ExpiredTime int64  `json:"expired_time" gorm:"bigint"`
+}
+
+// TrialUserId 试用令牌所属的用户，未开启试用时返回 0
+func TrialUserId() int {
+	if !viper.GetBool("trial.enable") {
+		return 0
+	}
+	return viper.GetInt("trial.user_id")
+}
+
+// IssueTrialToken 领取试用令牌，令牌只能从领取时的 IP 使用
+func IssueTrialToken(ip, fingerprint string) (*Token, error) {
+	userId := TrialUserId()
+	if userId == 0 {
+		return nil, errors.New("未开启试用")
+	}
+	if ip == "" || fingerprint == "" {
+		return nil, errors.New("无法识别访客")
+	}
+
+	sum := sha256.Sum256([]byte(fingerprint))
+	fingerprintHash := hex.EncodeToString(sum[:])
+	now := utils.GetTimestamp()
+
+	var trial TrialToken
+	err := DB.Where("ip = ? AND fingerprint = ? AND expired_time > ?", ip, fingerprintHash, now).Order("id desc").First(&trial).Error
+	if err == nil {
+		if token, err := GetTokenById(trial.TokenId); err == nil && token.Status == config.TokenStatusEnabled {
+			// 数据库只保存了 key 的哈希，重新签名得到 key
+			key, ok := token.DerivedKey()
+			if !ok {
+				return nil, errors.New("已领取过试用令牌")
+			}
+			token.PlainKey = key
+			return token, nil
+		}
+	}
+
+	if limit := viper.GetInt("trial.per_ip_daily"); limit > 0 {
+		var count int64
+		if err = DB.Model(&TrialToken{}).Where("ip = ? AND created_time >= ?", ip, now-86400).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count >= int64(limit) {
+			return nil, errors.New("今日试用次数已用完，请注册后继续使用")
+		}
+	}
+
+	expiredTime := now + int64(viper.GetInt("trial.expire_hours"))*3600
+	setting := TokenSetting{
+		Limits: LimitsConfig{
+			LimitsIPSetting: LimitsIPSetting{Enabled: true, Whitelist: []string{ip}},
+		},
+	}
+	if models := viper.GetStringSlice("trial.models"); len(models) > 0 {
+		setting.Limits.LimitModelSetting = LimitModelSetting{Enabled: true, Models: models}
+	}
+
+	token := &Token{
+		UserId:       userId,
+		Name:         "trial",
+		CreatedTime:  now,
+		AccessedTime: now,
+		ExpiredTime:  expiredTime,
+		RemainQuota:  viper.GetInt("trial.quota"),
+		Setting:      database.JSONType[TokenSetting]{JSONType: datatypes.NewJSONType(setting)},
+	}
+	if err = token.Insert(); err != nil {
+		return nil, err
+	}
+
+	trial = TrialToken{
+		TokenId:     token.Id,
+		Ip:          ip,
+		Fingerprint: fingerprintHash,
+		CreatedTime: now,
+		ExpiredTime: expiredTime,
+	}
+	if err = DB.Create(&trial).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
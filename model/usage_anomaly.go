@@ -0,0 +1,265 @@
+package model
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	UsageAnomalySpike       = "spike"
+	UsageAnomalyUnusualHour = "unusual_hour"
+	UsageAnomalyNewNetwork  = "new_network"
+
+	// 每个令牌记住的网段数量
+	usageProfileMaxNetworks = 50
+)
+
+// UsageProfile 令牌的用量基线，按小时增量更新
+// 只统计有请求的小时：AvgQuota 为活跃小时额度的指数移动平均，Hours 为各个钟点活跃的次数，Networks 为用过的来源网段
+type UsageProfile struct {
+	UserId    int                         `json:"user_id" gorm:"primaryKey;autoIncrement:false"`
+	TokenName string                      `json:"token_name" gorm:"primaryKey;type:varchar(255)"`
+	AvgQuota  float64                     `json:"avg_quota"`
+	Samples   int                         `json:"samples"`
+	Hours     datatypes.JSONSlice[int]    `json:"hours" gorm:"type:json"`
+	Networks  datatypes.JSONSlice[string] `json:"networks" gorm:"type:json"`
+	LastHour  int64                       `json:"last_hour" gorm:"bigint"` // 最后统计的小时的开始时间
+}
+
+// UsageAnomaly 检测到的异常用量
+type UsageAnomaly struct {
+	Id          int    `json:"id"`
+	UserId      int    `json:"user_id" gorm:"index"`
+	TokenName   string `json:"token_name" gorm:"type:varchar(255);default:''"`
+	Kind        string `json:"kind" gorm:"type:varchar(32);index"`
+	Detail      string `json:"detail" gorm:"type:text"`
+	Quota       int64  `json:"quota"`
+	Requests    int64  `json:"requests"`
+	WindowStart int64  `json:"window_start" gorm:"bigint"`
+	CreatedAt   int64  `json:"created_at" gorm:"bigint;index"`
+}
+
+type usageWindowRow struct {
+	UserId    int
+	TokenName string
+	SourceIp  string
+	Quota     int64
+	Requests  int64
+}
+
+// usageWindow 单个令牌在一个小时内的用量
+type usageWindow struct {
+	quota    int64
+	requests int64
+	networks map[string]bool
+}
+
+// usageNetwork 来源 IP 所在的网段，IPv4 取 /16，IPv6 取 /32，用来粗略判断地域是否变化
+func usageNetwork(ip string) string {
+	parsed := net.ParseIP(strings.TrimSpace(ip))
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		return v4.Mask(net.CIDRMask(16, 32)).String() + "/16"
+	}
+	return parsed.Mask(net.CIDRMask(32, 128)).String() + "/32"
+}
+
+// DetectUsageAnomalies 统计 [start, start+1h) 的消费日志，与每个令牌的基线比较后更新基线
+// 基线样本不足 anomaly_detection.min_samples 个小时的令牌只更新基线，不做判断；重复统计同一个小时不会重复更新
+func DetectUsageAnomalies(start time.Time) ([]*UsageAnomaly, error) {
+	start = start.Truncate(time.Hour)
+	end := start.Add(time.Hour)
+
+	var rows []*usageWindowRow
+	err := DB.Model(&Log{}).
+		Select("user_id, token_name, source_ip, SUM(quota) AS quota, COUNT(*) AS requests").
+		Where("type = ? AND created_at >= ? AND created_at < ?", LogTypeConsume, start.Unix(), end.Unix()).
+		Group("user_id, token_name, source_ip").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type tokenKey struct {
+		userId    int
+		tokenName string
+	}
+	windows := make(map[tokenKey]*usageWindow)
+	for _, row := range rows {
+		key := tokenKey{row.UserId, row.TokenName}
+		window, ok := windows[key]
+		if !ok {
+			window = &usageWindow{networks: make(map[string]bool)}
+			windows[key] = window
+		}
+		window.quota += row.Quota
+		window.requests += row.Requests
+		if network := usageNetwork(row.SourceIp); network != "" {
+			window.networks[network] = true
+		}
+	}
+
+	anomalies := make([]*UsageAnomaly, 0)
+	for key, window := range windows {
+		profile := &UsageProfile{UserId: key.userId, TokenName: key.tokenName}
+		if err := DB.Where("user_id = ? AND token_name = ?", key.userId, key.tokenName).Limit(1).Find(profile).Error; err != nil {
+			return anomalies, err
+		}
+		if profile.LastHour >= start.Unix() {
+			continue
+		}
+
+		found := profile.detect(window, start)
+		for _, anomaly := range found {
+			anomaly.UserId = key.userId
+			anomaly.TokenName = key.tokenName
+			anomaly.Quota = window.quota
+			anomaly.Requests = window.requests
+			anomaly.WindowStart = start.Unix()
+			anomaly.CreatedAt = time.Now().Unix()
+		}
+
+		profile.update(window, start)
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			if len(found) > 0 {
+				if err := tx.Create(&found).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Save(profile).Error
+		})
+		if err != nil {
+			return anomalies, err
+		}
+		anomalies = append(anomalies, found...)
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].UserId != anomalies[j].UserId {
+			return anomalies[i].UserId < anomalies[j].UserId
+		}
+		return anomalies[i].TokenName < anomalies[j].TokenName
+	})
+	return anomalies, nil
+}
+
+func (p *UsageProfile) detect(window *usageWindow, start time.Time) []*UsageAnomaly {
+	anomalies := make([]*UsageAnomaly, 0)
+	if p.Samples < viper.GetInt("anomaly_detection.min_samples") {
+		return anomalies
+	}
+
+	spikeRatio := viper.GetFloat64("anomaly_detection.spike_ratio")
+	if spikeRatio > 0 && p.AvgQuota > 0 && window.quota >= viper.GetInt64("anomaly_detection.min_quota") && float64(window.quota) >= spikeRatio*p.AvgQuota {
+		anomalies = append(anomalies, &UsageAnomaly{
+			Kind:   UsageAnomalySpike,
+			Detail: fmt.Sprintf("本小时消耗 %d，是平均值 %.0f 的 %.1f 倍", window.quota, p.AvgQuota, float64(window.quota)/p.AvgQuota),
+		})
+	}
+
+	hour := start.Hour()
+	if len(p.Hours) == 24 && p.Hours[hour] == 0 && window.requests >= viper.GetInt64("anomaly_detection.min_requests") {
+		anomalies = append(anomalies, &UsageAnomaly{
+			Kind:   UsageAnomalyUnusualHour,
+			Detail: fmt.Sprintf("%02d:00 - %02d:59 之前没有使用记录，本小时请求 %d 次", hour, hour, window.requests),
+		})
+	}
+
+	if len(p.Networks) > 0 {
+		known := make(map[string]bool, len(p.Networks))
+		for _, network := range p.Networks {
+			known[network] = true
+		}
+		newNetworks := make([]string, 0)
+		for network := range window.networks {
+			if !known[network] {
+				newNetworks = append(newNetworks, network)
+			}
+		}
+		if len(newNetworks) > 0 {
+			sort.Strings(newNetworks)
+			anomalies = append(anomalies, &UsageAnomaly{
+				Kind:   UsageAnomalyNewNetwork,
+				Detail: "新的来源网段：" + strings.Join(newNetworks, ", "),
+			})
+		}
+	}
+
+	return anomalies
+}
+
+func (p *UsageProfile) update(window *usageWindow, start time.Time) {
+	baselineHours := max(viper.GetInt("anomaly_detection.baseline_hours"), 1)
+	alpha := 2 / float64(baselineHours+1)
+	if p.Samples == 0 {
+		p.AvgQuota = float64(window.quota)
+	} else {
+		p.AvgQuota = alpha*float64(window.quota) + (1-alpha)*p.AvgQuota
+	}
+	p.AvgQuota = math.Round(p.AvgQuota*100) / 100
+	p.Samples++
+
+	if len(p.Hours) != 24 {
+		p.Hours = make(datatypes.JSONSlice[int], 24)
+	}
+	p.Hours[start.Hour()]++
+
+	known := make(map[string]bool, len(p.Networks))
+	for _, network := range p.Networks {
+		known[network] = true
+	}
+	networks := make([]string, 0, len(window.networks))
+	for network := range window.networks {
+		if !known[network] {
+			networks = append(networks, network)
+		}
+	}
+	sort.Strings(networks)
+	p.Networks = append(p.Networks, networks...)
+	if len(p.Networks) > usageProfileMaxNetworks {
+		p.Networks = p.Networks[len(p.Networks)-usageProfileMaxNetworks:]
+	}
+
+	p.LastHour = start.Unix()
+}
+
+type UsageAnomaliesListParams struct {
+	PaginationParams
+	UserId    int    `form:"user_id"`
+	TokenName string `form:"token_name"`
+	Kind      string `form:"kind"`
+}
+
+var allowedUsageAnomaliesOrderFields = map[string]bool{
+	"id":           true,
+	"created_at":   true,
+	"window_start": true,
+	"quota":        true,
+}
+
+func GetUsageAnomaliesList(params *UsageAnomaliesListParams) (*DataResult[UsageAnomaly], error) {
+	var anomalies []*UsageAnomaly
+
+	tx := DB.Model(&UsageAnomaly{})
+	if params.UserId != 0 {
+		tx = tx.Where("user_id = ?", params.UserId)
+	}
+	if params.TokenName != "" {
+		tx = tx.Where("token_name = ?", params.TokenName)
+	}
+	if params.Kind != "" {
+		tx = tx.Where("kind = ?", params.Kind)
+	}
+
+	return PaginateAndOrder[UsageAnomaly](tx, &params.PaginationParams, &anomalies, allowedUsageAnomaliesOrderFields)
+}
@@ -32,7 +32,11 @@ type User struct {
 	WeChatId         string         `json:"wechat_id" gorm:"column:wechat_id;index"`
 	TelegramId       int64          `json:"telegram_id" gorm:"bigint,column:telegram_id;default:0;"`
 	LarkId           string         `json:"lark_id" gorm:"column:lark_id;index"`
+	WeComId          string         `json:"wecom_id" gorm:"column:wecom_id;index"`
+	DingTalkId       string         `json:"dingtalk_id" gorm:"column:dingtalk_id;index"`
+	ExternalId       string         `json:"external_id" gorm:"column:external_id;index"`                       // SCIM 身份提供方中的用户 ID
 	VerificationCode string         `json:"verification_code" gorm:"-:all"`                                    // this field is only for Email verification, don't save it to database!
+	InvitationCode   string         `json:"invitation_code" gorm:"-:all"`                                      // only for invite-only registration
 	AccessToken      string         `json:"access_token" gorm:"type:char(32);column:access_token;uniqueIndex"` // this token is for system management
 	Quota            int            `json:"quota" gorm:"type:int;default:0"`
 	UsedQuota        int            `json:"used_quota" gorm:"type:int;default:0;column:used_quota"` // used quota
@@ -45,8 +49,19 @@ type User struct {
 	InviterId        int            `json:"inviter_id" gorm:"type:int;column:inviter_id;index"`
 	LastLoginTime    int64          `json:"last_login_time" gorm:"bigint;default:0"`
 	LastLoginIp      string         `json:"last_login_ip" gorm:"type:varchar(128);default:''"`
+	TenantId         int            `json:"tenant_id" gorm:"index;default:0"` // 所属租户，0 表示不属于任何租户
 	CreatedTime      int64          `json:"created_time" gorm:"bigint"`
 	DeletedAt        gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// 按额度显示设置格式化的额度
+	QuotaText     string `json:"quota_text" gorm:"-:all"`
+	UsedQuotaText string `json:"used_quota_text" gorm:"-:all"`
+}
+
+func (user *User) AfterFind(tx *gorm.DB) (err error) {
+	user.QuotaText = common.FormatQuota(user.Quota)
+	user.UsedQuotaText = common.FormatQuota(user.UsedQuota)
+	return nil
 }
 
 type UserUpdates func(*User)
@@ -67,9 +82,13 @@ var allowedUserOrderFields = map[string]bool{
 	"last_login_ip":   true,
 }
 
-func GetUsersList(params *GenericParams) (*DataResult[User], error) {
+// GetUsersList tenantId 不为 0 时只返回该租户的用户
+func GetUsersList(params *GenericParams, tenantId int) (*DataResult[User], error) {
 	var users []*User
 	db := DB.Omit("password")
+	if tenantId != 0 {
+		db = db.Where("tenant_id = ?", tenantId)
+	}
 	if params.Keyword != "" {
 		groupCol := "`group`"
 		if common.UsingPostgreSQL {
@@ -95,6 +114,13 @@ func GetUserById(id int, selectAll bool) (*User, error) {
 	return &user, err
 }
 
+// GetUserByExternalId 按外部 ID 查询用户，外部 ID 由 SCIM 或按外部 ID 更新用户的接口设置
+func GetUserByExternalId(externalId string) (*User, error) {
+	var user User
+	err := DB.Omit("password").Where(&User{ExternalId: externalId}).First(&user).Error
+	return &user, err
+}
+
 func GetUserByTelegramId(telegramId int64) (*User, error) {
 	if telegramId == 0 {
 		return nil, errors.New("telegramId 为空！")
@@ -119,11 +145,16 @@ func DeleteUserById(id int) (err error) {
 	if id == 0 {
 		return errors.New("id 为空！")
 	}
+	// 先查询用户，保留原用户名以便从回收站恢复
 	user := User{Id: id}
+	if err = DB.First(&user, "id = ?", id).Error; err != nil {
+		return err
+	}
 	return user.Delete()
 }
 
-func (user *User) Insert(inviterId int) error {
+// Insert 创建用户，邀请奖励由 ApplyReferral 发放
+func (user *User) Insert() error {
 	if RecordExists(&User{}, "username", user.Username, nil) {
 		return errors.New("用户名已存在！")
 	}
@@ -145,16 +176,6 @@ func (user *User) Insert(inviterId int) error {
 	if config.QuotaForNewUser > 0 {
 		RecordLog(user.Id, LogTypeSystem, fmt.Sprintf("新用户注册赠送 %s", common.LogQuota(config.QuotaForNewUser)))
 	}
-	if inviterId != 0 {
-		if config.QuotaForInvitee > 0 {
-			_ = IncreaseUserQuota(user.Id, config.QuotaForInvitee)
-			RecordLog(user.Id, LogTypeSystem, fmt.Sprintf("使用邀请码赠送 %s", common.LogQuota(config.QuotaForInvitee)))
-		}
-		if config.QuotaForInviter > 0 {
-			_ = IncreaseUserQuota(inviterId, config.QuotaForInviter)
-			RecordLog(inviterId, LogTypeSystem, fmt.Sprintf("邀请用户赠送 %s", common.LogQuota(config.QuotaForInviter)))
-		}
-	}
 	return nil
 }
 
@@ -178,15 +199,17 @@ func (user *User) Update(updatePassword bool) error {
 	}
 
 	// 删除缓存
-	if config.RedisEnabled {
-		redis.RedisDel(fmt.Sprintf(UserGroupCacheKey, user.Id))
-	}
+	InvalidateUserCache(user.Id)
 
 	return err
 }
 
 func UpdateUser(id int, fields map[string]interface{}) error {
-	return DB.Model(&User{}).Where("id = ?", id).Updates(fields).Error
+	err := DB.Model(&User{}).Where("id = ?", id).Updates(fields).Error
+	if err == nil {
+		InvalidateUserCache(id)
+	}
+	return err
 }
 
 func (user *User) Delete() error {
@@ -355,6 +378,15 @@ func IsLarkIdAlreadyTaken(larkId string) bool {
 	return IsFieldAlreadyTaken("lark_id", larkId)
 }
 
+// GetEnabledGitHubUsers 绑定了 GitHub 且未被禁用的普通用户
+func GetEnabledGitHubUsers() ([]*User, error) {
+	var users []*User
+	err := DB.Select("id", "username", "github_id").
+		Where("github_id <> '' AND status = ? AND role = ?", config.UserStatusEnabled, config.RoleCommonUser).
+		Find(&users).Error
+	return users, err
+}
+
 func IsTelegramIdAlreadyTaken(telegramId int64) bool {
 	return IsFieldAlreadyTaken("telegram_id", telegramId)
 }
@@ -440,13 +472,18 @@ func IncreaseUserQuota(id int, quota int) (err error) {
 	}
 	if config.BatchUpdateEnabled {
 		addNewRecord(BatchUpdateTypeUserQuota, id, quota)
-		return nil
+	} else if err = increaseUserQuota(DB, id, quota); err != nil {
+		return err
+	}
+
+	if err = CacheIncreaseUserQuota(id, quota); err != nil {
+		logger.SysError("failed to increase user quota cache: " + err.Error())
 	}
-	return increaseUserQuota(id, quota)
+	return nil
 }
 
-func increaseUserQuota(id int, quota int) (err error) {
-	err = DB.Model(&User{}).Where("id = ?", id).Update("quota", gorm.Expr("quota + ?", quota)).Error
+func increaseUserQuota(db *gorm.DB, id int, quota int) (err error) {
+	err = db.Model(&User{}).Where("id = ?", id).Update("quota", gorm.Expr("quota + ?", quota)).Error
 	return err
 }
 
@@ -492,8 +529,8 @@ func updateUserUsedQuotaAndRequestCount(id int, quota int, count int) {
 	}
 }
 
-func updateUserUsedQuota(id int, quota int) {
-	err := DB.Model(&User{}).Where("id = ?", id).Updates(
+func updateUserUsedQuota(db *gorm.DB, id int, quota int) error {
+	err := db.Model(&User{}).Where("id = ?", id).Updates(
 		map[string]interface{}{
 			"used_quota": gorm.Expr("used_quota + ?", quota),
 		},
@@ -501,13 +538,15 @@ func updateUserUsedQuota(id int, quota int) {
 	if err != nil {
 		logger.SysError("failed to update user used quota: " + err.Error())
 	}
+	return err
 }
 
-func updateUserRequestCount(id int, count int) {
-	err := DB.Model(&User{}).Where("id = ?", id).Update("request_count", gorm.Expr("request_count + ?", count)).Error
+func updateUserRequestCount(db *gorm.DB, id int, count int) error {
+	err := db.Model(&User{}).Where("id = ?", id).Update("request_count", gorm.Expr("request_count + ?", count)).Error
 	if err != nil {
 		logger.SysError("failed to update user request count: " + err.Error())
 	}
+	return err
 }
 
 func GetUsernameById(id int) (username string) {
@@ -523,7 +562,7 @@ type StatisticsUser struct {
 }
 
 func GetStatisticsUser() (statisticsUser *StatisticsUser, err error) {
-	err = DB.Model(&User{}).Select("sum(quota) as total_quota, sum(used_quota) as total_used_quota, count(*) as total_user, count(CASE WHEN inviter_id != 0 THEN 1 END) as total_inviter_user").Scan(&statisticsUser).Error
+	err = ReadDB().Model(&User{}).Select("sum(quota) as total_quota, sum(used_quota) as total_used_quota, count(*) as total_user, count(CASE WHEN inviter_id != 0 THEN 1 END) as total_inviter_user").Scan(&statisticsUser).Error
 	return statisticsUser, err
 }
 
@@ -536,7 +575,7 @@ type UserStatisticsByPeriod struct {
 func GetUserStatisticsByPeriod(startTimestamp, endTimestamp int64) (statistics []*UserStatisticsByPeriod, err error) {
 	groupSelect := getTimestampGroupsSelect("created_time", "day", "date")
 
-	err = DB.Raw(`
+	err = ReadDB().Raw(`
 		SELECT `+groupSelect+`,
 		count(*) as user_count,
 		count(CASE WHEN inviter_id != 0 THEN 1 END) as inviter_user_count
@@ -1,11 +1,15 @@
 package model
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"one-api/common/config"
 	"one-api/common/limit"
 	"one-api/common/logger"
 	"one-api/common/redis"
+	"one-api/common/utils"
+	"strings"
 	"sync"
 )
 
@@ -20,6 +24,29 @@ type UserGroup struct {
 	Min       int     `json:"min" form:"min" gorm:"default:0"`                 // 晋级条件最小值
 	Max       int     `json:"max" form:"max" gorm:"default:0"`                 // 晋级条件最大值
 	Enable    *bool   `json:"enable" form:"enable" gorm:"default:true"`        // 是否启用
+
+	// 父分组，倍率为父分组倍率乘以自身倍率，可见模型、速率限制、请求限制和敏感词策略未设置（为空或 0）时继承父分组，
+	// 模型别名、模型类别和语言路由与父分组合并，同名时以子分组为准
+	Parent string `json:"parent" gorm:"type:varchar(50);default:''"`
+
+	MaxConcurrency int `json:"max_concurrency" gorm:"default:0"` // 分组同时处理的最大请求数，所有实例共享，0 为不限制
+
+	Models       string `json:"models" gorm:"type:text"`        // 分组可见的模型，逗号分隔，为空时不限制
+	ModelAliases string `json:"model_aliases" gorm:"type:text"` // 模型别名，JSON 格式 {"别名": "模型"}
+	ModelClasses string `json:"model_classes" gorm:"type:text"` // 模型类别，JSON 格式 {"auto:fast": {"models": ["模型"], "policy": "latency"}}
+
+	LanguageRouting string `json:"language_routing" gorm:"type:text"` // 按提示词语言优先选择的渠道区域，JSON 格式 {"zh": "cn", "*": "us"}，* 匹配其它语言
+
+	SafeAction string `json:"safe_action" gorm:"type:varchar(16);default:''"` // 命中敏感词时的处理方式 block/mask/log，为空时拦截
+	SafeScope  string `json:"safe_scope" gorm:"type:varchar(16);default:''"`  // 敏感词审查范围 prompt/response/all，为空时只审查提示词
+
+	RequestLimitSetting // 请求大小限制
+
+	modelList map[string]bool
+	aliases   map[string]string
+	classes   map[string]*ModelClass
+
+	languageRegions map[string]string
 }
 
 type SearchUserGroupParams struct {
@@ -67,7 +94,12 @@ func GetUserGroupsAll(isPublic bool) ([]*UserGroup, error) {
 }
 
 func (c *UserGroup) Create() error {
+	// api_rate 有数据库默认值，子分组需要显式写入 0 才能继承父分组
+	inheritAPIRate := c.Parent != "" && c.APIRate == 0
 	err := DB.Create(c).Error
+	if err == nil && inheritAPIRate {
+		err = DB.Model(&UserGroup{}).Where("id = ?", c.Id).Update("api_rate", 0).Error
+	}
 	if err == nil {
 		GlobalUserGroupRatio.Load()
 	}
@@ -75,7 +107,7 @@ func (c *UserGroup) Create() error {
 }
 
 func (c *UserGroup) Update() error {
-	err := DB.Select("name", "ratio", "public", "api_rate", "promotion", "min", "max").Updates(c).Error
+	err := DB.Select("name", "ratio", "public", "api_rate", "promotion", "min", "max", "models", "model_aliases", "model_classes", "language_routing", "safe_action", "safe_scope", "parent", "max_body_size", "max_messages", "max_image_size", "max_concurrency").Updates(c).Error
 	if err == nil {
 		GlobalUserGroupRatio.Load()
 	}
@@ -84,6 +116,14 @@ func (c *UserGroup) Update() error {
 }
 
 func (c *UserGroup) Delete() error {
+	var children int64
+	if err := DB.Model(&UserGroup{}).Where("parent = ?", c.Symbol).Count(&children).Error; err != nil {
+		return err
+	}
+	if children > 0 {
+		return errors.New("该分组还有子分组，请先修改子分组的父分组")
+	}
+
 	err := DB.Delete(c).Error
 
 	if err == nil {
@@ -110,16 +150,31 @@ type UserGroupRatio struct {
 var GlobalUserGroupRatio = UserGroupRatio{}
 
 func (cgrm *UserGroupRatio) Load() {
-	userGroups, err := GetUserGroupsAll(false)
-	if err != nil {
+	// 停用的分组也可以作为父分组
+	var allGroups []*UserGroup
+	if err := DB.Find(&allGroups).Error; err != nil {
 		return
 	}
 
+	groupMap := make(map[string]*UserGroup, len(allGroups))
+	userGroups := make([]*UserGroup, 0, len(allGroups))
+	for _, userGroup := range allGroups {
+		if err := userGroup.parseModels(); err != nil {
+			logger.SysError(fmt.Sprintf("failed to parse models of user group %s: %s", userGroup.Symbol, err.Error()))
+		}
+		groupMap[userGroup.Symbol] = userGroup
+		if userGroup.Enable == nil || *userGroup.Enable {
+			userGroups = append(userGroups, userGroup)
+		}
+	}
+
 	newUserGroups := make(map[string]*UserGroup, len(userGroups))
 	newAPILimiter := make(map[string]limit.RateLimiter, len(userGroups))
 	publicGroup := make([]string, 0)
 
+	resolved := make(map[string]*UserGroup, len(allGroups))
 	for _, userGroup := range userGroups {
+		userGroup = resolveUserGroup(groupMap, resolved, userGroup.Symbol, 0)
 		newUserGroups[userGroup.Symbol] = userGroup
 		newAPILimiter[userGroup.Symbol] = limit.NewAPILimiter(userGroup.APIRate)
 		if userGroup.Public {
@@ -175,6 +230,16 @@ func (cgrm *UserGroupRatio) GetAPIRate(symbol string) int {
 	return userGroup.APIRate
 }
 
+// GetMaxConcurrency 获取分组的最大并发请求数，0 为不限制
+func (cgrm *UserGroupRatio) GetMaxConcurrency(symbol string) int {
+	userGroup := cgrm.GetBySymbol(symbol)
+	if userGroup == nil {
+		return 0
+	}
+
+	return userGroup.MaxConcurrency
+}
+
 func (cgrm *UserGroupRatio) GetPublicGroupList() []string {
 	cgrm.RLock()
 	defer cgrm.RUnlock()
@@ -182,6 +247,116 @@ func (cgrm *UserGroupRatio) GetPublicGroupList() []string {
 	return cgrm.PublicGroup
 }
 
+// ResolveModel 将分组内的模型别名和模型类别解析为实际模型，分组配置了可见模型时拒绝其它模型
+func (cgrm *UserGroupRatio) ResolveModel(symbol, modelName string) (string, error) {
+	userGroup := cgrm.GetBySymbol(symbol)
+	if userGroup == nil {
+		return modelName, nil
+	}
+
+	if target, ok := userGroup.aliases[modelName]; ok {
+		return target, nil
+	}
+
+	if class, ok := userGroup.classes[modelName]; ok {
+		target, err := class.resolve(symbol)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", modelName, err)
+		}
+		return target, nil
+	}
+
+	if len(userGroup.modelList) > 0 && !userGroup.modelList[modelName] {
+		return "", fmt.Errorf("当前分组 %s 不可使用模型 %s", symbol, modelName)
+	}
+
+	return modelName, nil
+}
+
+// FilterModels 返回分组可见的模型列表，别名和模型类别在其指向的模型可用时加入列表
+func (cgrm *UserGroupRatio) FilterModels(symbol string, models []string) []string {
+	userGroup := cgrm.GetBySymbol(symbol)
+	if userGroup == nil || (len(userGroup.modelList) == 0 && len(userGroup.aliases) == 0 && len(userGroup.classes) == 0) {
+		return models
+	}
+
+	available := make(map[string]bool, len(models))
+	filtered := make([]string, 0, len(models))
+	for _, modelName := range models {
+		available[modelName] = true
+		if len(userGroup.modelList) == 0 || userGroup.modelList[modelName] {
+			filtered = append(filtered, modelName)
+		}
+	}
+
+	for alias, target := range userGroup.aliases {
+		if available[target] && !utils.Contains(alias, filtered) {
+			filtered = append(filtered, alias)
+		}
+	}
+
+	for name, class := range userGroup.classes {
+		for _, target := range class.Models {
+			if available[target] {
+				filtered = append(filtered, name)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// GetModelAlias 获取分组内别名指向的模型
+func (cgrm *UserGroupRatio) GetModelAlias(symbol, alias string) (string, bool) {
+	userGroup := cgrm.GetBySymbol(symbol)
+	if userGroup == nil {
+		return "", false
+	}
+
+	target, ok := userGroup.aliases[alias]
+	return target, ok
+}
+
+// HasLanguageRouting 分组是否配置了语言路由
+func (cgrm *UserGroupRatio) HasLanguageRouting(symbol string) bool {
+	userGroup := cgrm.GetBySymbol(symbol)
+	return userGroup != nil && len(userGroup.languageRegions) > 0
+}
+
+// GetLanguageRegion 获取语言优先选择的区域，没有配置该语言时使用 *
+func (cgrm *UserGroupRatio) GetLanguageRegion(symbol, language string) string {
+	userGroup := cgrm.GetBySymbol(symbol)
+	if userGroup == nil {
+		return ""
+	}
+
+	if region, ok := userGroup.languageRegions[language]; ok {
+		return region
+	}
+	return userGroup.languageRegions["*"]
+}
+
+// GetSafePolicy 获取分组的敏感词处理策略
+func (cgrm *UserGroupRatio) GetSafePolicy(symbol string) SafePolicy {
+	userGroup := cgrm.GetBySymbol(symbol)
+	if userGroup == nil {
+		return SafePolicy{Action: SafeActionBlock, Prompt: true}
+	}
+
+	return userGroup.SafePolicy()
+}
+
+// GetRequestLimit 获取分组的请求大小限制
+func (cgrm *UserGroupRatio) GetRequestLimit(symbol string) RequestLimitSetting {
+	userGroup := cgrm.GetBySymbol(symbol)
+	if userGroup == nil {
+		return RequestLimitSetting{}
+	}
+
+	return userGroup.RequestLimitSetting
+}
+
 func (cgrm *UserGroupRatio) GetAPILimiter(symbol string) limit.RateLimiter {
 	cgrm.RLock()
 	defer cgrm.RUnlock()
@@ -244,3 +419,194 @@ func CheckAndUpgradeUserGroup(userId int, rechargeAmount int) error {
 
 	return nil
 }
+
+// ValidateModels 校验模型别名、模型类别和语言路由配置
+func (c *UserGroup) ValidateModels() error {
+	return c.parseModels()
+}
+
+// ValidateSafePolicy 校验敏感词处理策略
+func (c *UserGroup) ValidateSafePolicy() error {
+	switch c.SafeAction {
+	case "", SafeActionBlock, SafeActionMask, SafeActionLog:
+	default:
+		return fmt.Errorf("不支持的敏感词处理方式: %s", c.SafeAction)
+	}
+
+	switch c.SafeScope {
+	case "", SafeScopePrompt, SafeScopeResponse, SafeScopeAll:
+	default:
+		return fmt.Errorf("不支持的敏感词审查范围: %s", c.SafeScope)
+	}
+
+	return nil
+}
+
+// SafePolicy 返回分组的敏感词处理策略，未配置时只拦截提示词
+func (c *UserGroup) SafePolicy() SafePolicy {
+	policy := SafePolicy{Action: c.SafeAction}
+	if policy.Action == "" {
+		policy.Action = SafeActionBlock
+	}
+
+	switch c.SafeScope {
+	case SafeScopeResponse:
+		policy.Response = true
+	case SafeScopeAll:
+		policy.Prompt = true
+		policy.Response = true
+	default:
+		policy.Prompt = true
+	}
+
+	return policy
+}
+
+func (c *UserGroup) parseModels() error {
+	c.modelList = nil
+	c.aliases = nil
+	c.classes = nil
+	c.languageRegions = nil
+
+	if c.Models != "" {
+		c.modelList = make(map[string]bool)
+		for _, modelName := range strings.Split(c.Models, ",") {
+			modelName = strings.TrimSpace(modelName)
+			if modelName != "" {
+				c.modelList[modelName] = true
+			}
+		}
+	}
+
+	if c.LanguageRouting != "" {
+		regions := make(map[string]string)
+		if err := json.Unmarshal([]byte(c.LanguageRouting), &regions); err != nil {
+			return fmt.Errorf("语言路由格式错误: %w", err)
+		}
+		c.languageRegions = regions
+	}
+
+	if c.ModelClasses != "" {
+		classes, err := parseModelClasses(c.ModelClasses)
+		if err != nil {
+			return err
+		}
+		c.classes = classes
+	}
+
+	if c.ModelAliases == "" {
+		return nil
+	}
+
+	aliases := make(map[string]string)
+	if err := json.Unmarshal([]byte(c.ModelAliases), &aliases); err != nil {
+		return fmt.Errorf("模型别名格式错误: %w", err)
+	}
+	for alias, target := range aliases {
+		if alias == "" || target == "" {
+			return errors.New("模型别名和模型名称不能为空")
+		}
+	}
+	c.aliases = aliases
+
+	return nil
+}
+
+// 分组继承的最大层数
+const maxUserGroupDepth = 8
+
+// resolveUserGroup 按父分组补全分组的配置，父分组不存在或层数过多时不再继承
+func resolveUserGroup(groups, resolved map[string]*UserGroup, symbol string, depth int) *UserGroup {
+	if userGroup, ok := resolved[symbol]; ok {
+		return userGroup
+	}
+
+	userGroup := *groups[symbol]
+	if userGroup.Parent != "" {
+		if _, ok := groups[userGroup.Parent]; !ok {
+			logger.SysError(fmt.Sprintf("parent group %s of user group %s not found", userGroup.Parent, symbol))
+		} else if depth >= maxUserGroupDepth {
+			logger.SysError(fmt.Sprintf("user group %s exceeds the max inheritance depth", symbol))
+		} else {
+			userGroup.inherit(resolveUserGroup(groups, resolved, userGroup.Parent, depth+1))
+		}
+	}
+
+	resolved[symbol] = &userGroup
+	return &userGroup
+}
+
+// inherit 继承父分组的配置
+func (c *UserGroup) inherit(parent *UserGroup) {
+	c.Ratio = parent.Ratio * c.Ratio
+	if c.APIRate <= 0 {
+		c.APIRate = parent.APIRate
+	}
+	if c.MaxConcurrency <= 0 {
+		c.MaxConcurrency = parent.MaxConcurrency
+	}
+	if c.MaxBodySize <= 0 {
+		c.MaxBodySize = parent.MaxBodySize
+	}
+	if c.MaxMessages <= 0 {
+		c.MaxMessages = parent.MaxMessages
+	}
+	if c.MaxImageSize <= 0 {
+		c.MaxImageSize = parent.MaxImageSize
+	}
+	if c.SafeAction == "" {
+		c.SafeAction = parent.SafeAction
+	}
+	if c.SafeScope == "" {
+		c.SafeScope = parent.SafeScope
+	}
+	if len(c.modelList) == 0 {
+		c.Models = parent.Models
+		c.modelList = parent.modelList
+	}
+	c.aliases = inheritMap(parent.aliases, c.aliases)
+	c.classes = inheritMap(parent.classes, c.classes)
+	c.languageRegions = inheritMap(parent.languageRegions, c.languageRegions)
+}
+
+func inheritMap[V any](parent, child map[string]V) map[string]V {
+	if len(parent) == 0 {
+		return child
+	}
+	merged := make(map[string]V, len(parent)+len(child))
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range child {
+		merged[key] = value
+	}
+	return merged
+}
+
+// ValidateParent 校验父分组存在且不会形成循环
+func (c *UserGroup) ValidateParent() error {
+	if c.Parent == "" {
+		return nil
+	}
+	if c.Parent == c.Symbol {
+		return errors.New("父分组不能是自身")
+	}
+
+	parent := c.Parent
+	for depth := 0; parent != ""; depth++ {
+		if depth >= maxUserGroupDepth {
+			return fmt.Errorf("分组继承不能超过 %d 层", maxUserGroupDepth)
+		}
+
+		var group UserGroup
+		if err := DB.Where("symbol = ?", parent).First(&group).Error; err != nil {
+			return fmt.Errorf("父分组 %s 不存在", parent)
+		}
+		if group.Symbol == c.Symbol || (c.Id != 0 && group.Id == c.Id) {
+			return errors.New("分组继承不能形成循环")
+		}
+		parent = group.Parent
+	}
+
+	return nil
+}
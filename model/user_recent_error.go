@@ -0,0 +1,100 @@
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/redis"
+	"sync"
+	"time"
+)
+
+const (
+	// 每个用户保留的最近错误数
+	userRecentErrorsKeep = 20
+	userRecentErrorsTTL  = 24 * time.Hour
+
+	userRecentErrorsCacheKey = "user_recent_errors:%d"
+)
+
+// UserRecentError 用户最近失败的请求，只保存返回给用户的信息，不包含渠道
+type UserRecentError struct {
+	CreatedAt  int64  `json:"created_at"`
+	RequestId  string `json:"request_id"`
+	TokenName  string `json:"token_name"`
+	Model      string `json:"model"`
+	StatusCode int    `json:"status_code"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+var (
+	userRecentErrorsLock sync.Mutex
+	userRecentErrors     = make(map[int][]*UserRecentError)
+)
+
+// RecordUserRecentError 记录用户失败的请求，启用 Redis 时多实例共享
+func RecordUserRecentError(userId int, recentError *UserRecentError) {
+	if userId == 0 {
+		return
+	}
+
+	if config.RedisEnabled {
+		data, err := json.Marshal(recentError)
+		if err != nil {
+			return
+		}
+		key := fmt.Sprintf(userRecentErrorsCacheKey, userId)
+		pipe := redis.GetRedisClient().TxPipeline()
+		pipe.LPush(context.Background(), key, string(data))
+		pipe.LTrim(context.Background(), key, 0, userRecentErrorsKeep-1)
+		pipe.Expire(context.Background(), key, userRecentErrorsTTL)
+		if _, err = pipe.Exec(context.Background()); err != nil {
+			logger.SysError("Redis record user recent error: " + err.Error())
+		}
+		return
+	}
+
+	userRecentErrorsLock.Lock()
+	defer userRecentErrorsLock.Unlock()
+	recent := append([]*UserRecentError{recentError}, userRecentErrors[userId]...)
+	if len(recent) > userRecentErrorsKeep {
+		recent = recent[:userRecentErrorsKeep]
+	}
+	userRecentErrors[userId] = recent
+}
+
+// GetUserRecentErrors 用户最近 24 小时内失败的请求，按时间倒序
+func GetUserRecentErrors(userId int) []*UserRecentError {
+	since := time.Now().Add(-userRecentErrorsTTL).Unix()
+	list := make([]*UserRecentError, 0)
+
+	if config.RedisEnabled {
+		values, err := redis.GetRedisClient().LRange(context.Background(), fmt.Sprintf(userRecentErrorsCacheKey, userId), 0, -1).Result()
+		if err != nil {
+			logger.SysError("Redis get user recent errors: " + err.Error())
+			return list
+		}
+		for _, value := range values {
+			var recentError UserRecentError
+			if json.Unmarshal([]byte(value), &recentError) == nil && recentError.CreatedAt >= since {
+				list = append(list, &recentError)
+			}
+		}
+		return list
+	}
+
+	userRecentErrorsLock.Lock()
+	defer userRecentErrorsLock.Unlock()
+	for _, recentError := range userRecentErrors[userId] {
+		if recentError.CreatedAt >= since {
+			list = append(list, recentError)
+		}
+	}
+	if len(list) == 0 {
+		delete(userRecentErrors, userId)
+	}
+	return list
+}
@@ -0,0 +1,258 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"one-api/common/cache"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+const (
+	// 与 session cookie 的有效期一致
+	UserSessionExpiration = 30 * 24 * time.Hour
+	// 最后活跃时间的更新间隔
+	userSessionTouchInterval = 5 * time.Minute
+
+	UserSessionCacheKey = "user_session:%s"
+)
+
+// UserSession 管理后台的登录会话，每次登录生成一条，撤销后对应的 cookie 失效
+type UserSession struct {
+	Id           int    `json:"id"`
+	SessionId    string `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	UserId       int    `json:"user_id" gorm:"index"`
+	Ip           string `json:"ip" gorm:"type:varchar(64);default:''"`
+	UserAgent    string `json:"user_agent" gorm:"type:varchar(512);default:''"`
+	LoginMethod  string `json:"login_method" gorm:"type:varchar(32);default:''"`
+	CreatedAt    int64  `json:"created_at" gorm:"bigint"`
+	LastActiveAt int64  `json:"last_active_at" gorm:"bigint"`
+	ExpiresAt    int64  `json:"expires_at" gorm:"bigint;index"`
+	RevokedAt    int64  `json:"revoked_at" gorm:"bigint;default:0"`
+	Current      bool   `json:"current" gorm:"-"`
+}
+
+// LoginHistory 登录记录，包含失败的登录
+type LoginHistory struct {
+	Id        int    `json:"id"`
+	CreatedAt int64  `json:"created_at" gorm:"bigint;index"`
+	UserId    int    `json:"user_id" gorm:"index"`
+	Username  string `json:"username" gorm:"type:varchar(64);default:''"`
+	Ip        string `json:"ip" gorm:"type:varchar(64);default:''"`
+	UserAgent string `json:"user_agent" gorm:"type:varchar(512);default:''"`
+	Method    string `json:"method" gorm:"type:varchar(32);default:''"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message" gorm:"type:varchar(255);default:''"`
+}
+
+// truncateString 按字节截断，不截断多字节字符
+func truncateString(value string, length int) string {
+	if len(value) <= length {
+		return value
+	}
+	for length > 0 && !utf8.RuneStart(value[length]) {
+		length--
+	}
+	return value[:length]
+}
+
+// CreateUserSession 登录成功时创建会话
+func CreateUserSession(userId int, ip, userAgent, method string) (*UserSession, error) {
+	now := utils.GetTimestamp()
+	session := &UserSession{
+		SessionId:    utils.GetUUID(),
+		UserId:       userId,
+		Ip:           ip,
+		UserAgent:    truncateString(userAgent, 512),
+		LoginMethod:  method,
+		CreatedAt:    now,
+		LastActiveAt: now,
+		ExpiresAt:    now + int64(UserSessionExpiration.Seconds()),
+	}
+	if err := DB.Create(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// IsUserSessionActive 会话是否未撤销且未过期，启用 Redis 时缓存结果
+func IsUserSessionActive(sessionId string) bool {
+	isActive := func() (bool, error) {
+		var session UserSession
+		err := DB.Where("session_id = ?", sessionId).First(&session).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return session.RevokedAt == 0 && session.ExpiresAt > utils.GetTimestamp(), nil
+	}
+
+	var active bool
+	var err error
+	if config.RedisEnabled {
+		active, err = cache.GetOrSetCache(fmt.Sprintf(UserSessionCacheKey, sessionId), time.Duration(TokenCacheSeconds)*time.Second, isActive, cache.CacheTimeout)
+	} else {
+		active, err = isActive()
+	}
+	if err != nil {
+		// 数据库异常时不踢出用户
+		logger.SysError("check user session error: " + err.Error())
+		return true
+	}
+	return active
+}
+
+var userSessionTouched sync.Map
+
+// TouchUserSession 更新会话的最后活跃时间，每个实例间隔 5 分钟更新一次
+func TouchUserSession(sessionId string) {
+	now := time.Now()
+	if last, ok := userSessionTouched.Load(sessionId); ok && now.Sub(last.(time.Time)) < userSessionTouchInterval {
+		return
+	}
+	userSessionTouched.Store(sessionId, now)
+	DB.Model(&UserSession{}).Where("session_id = ?", sessionId).Update("last_active_at", now.Unix())
+}
+
+// GetUserSessions 用户未撤销且未过期的会话，按最后活跃时间倒序
+func GetUserSessions(userId int) ([]*UserSession, error) {
+	var sessions []*UserSession
+	err := DB.Where("user_id = ? AND revoked_at = 0 AND expires_at > ?", userId, utils.GetTimestamp()).
+		Order("last_active_at desc").Find(&sessions).Error
+	return sessions, err
+}
+
+// RevokeUserSession 撤销用户的指定会话
+func RevokeUserSession(userId, id int) error {
+	var session UserSession
+	if err := DB.Where("id = ? AND user_id = ?", id, userId).First(&session).Error; err != nil {
+		return errors.New("会话不存在")
+	}
+	return revokeUserSessions(DB.Where("id = ?", session.Id), []string{session.SessionId})
+}
+
+// RevokeUserSessions 撤销用户的所有会话，exceptSessionId 不为空时保留该会话，返回撤销的数量
+func RevokeUserSessions(userId int, exceptSessionId string) (int, error) {
+	tx := DB.Where("user_id = ? AND revoked_at = 0", userId)
+	if exceptSessionId != "" {
+		tx = tx.Where("session_id <> ?", exceptSessionId)
+	}
+	var sessionIds []string
+	if err := tx.Session(&gorm.Session{}).Model(&UserSession{}).Pluck("session_id", &sessionIds).Error; err != nil {
+		return 0, err
+	}
+	if len(sessionIds) == 0 {
+		return 0, nil
+	}
+	return len(sessionIds), revokeUserSessions(DB.Where("session_id IN ?", sessionIds), sessionIds)
+}
+
+// RevokeUserSessionBySessionId 注销时撤销当前会话
+func RevokeUserSessionBySessionId(sessionId string) error {
+	return revokeUserSessions(DB.Where("session_id = ?", sessionId), []string{sessionId})
+}
+
+func revokeUserSessions(tx *gorm.DB, sessionIds []string) error {
+	err := tx.Model(&UserSession{}).Where("revoked_at = 0").Update("revoked_at", utils.GetTimestamp()).Error
+	if err != nil {
+		return err
+	}
+	if config.RedisEnabled {
+		for _, sessionId := range sessionIds {
+			cache.DeleteCache(fmt.Sprintf(UserSessionCacheKey, sessionId))
+		}
+	}
+	return nil
+}
+
+// RecordLoginHistory 记录登录结果，用户不存在时 userId 为 0
+func RecordLoginHistory(history *LoginHistory) {
+	history.CreatedAt = utils.GetTimestamp()
+	history.UserAgent = truncateString(history.UserAgent, 512)
+	history.Message = truncateString(history.Message, 255)
+	history.Username = truncateString(history.Username, 64)
+	if err := DB.Create(history).Error; err != nil {
+		logger.SysError("failed to record login history: " + err.Error())
+	}
+}
+
+type LoginHistoryListParams struct {
+	PaginationParams
+	UserId         int    `form:"user_id"`
+	Username       string `form:"username"`
+	Ip             string `form:"ip"`
+	Success        *bool  `form:"success"`
+	StartTimestamp int64  `form:"start_timestamp"`
+	EndTimestamp   int64  `form:"end_timestamp"`
+}
+
+var allowedLoginHistoryOrderFields = map[string]bool{
+	"id":         true,
+	"created_at": true,
+	"user_id":    true,
+}
+
+// GetLoginHistoryList tenantId 不为 0 时只返回该租户用户的记录
+func GetLoginHistoryList(params *LoginHistoryListParams, tenantId int) (*DataResult[LoginHistory], error) {
+	var histories []*LoginHistory
+
+	tx := DB.Model(&LoginHistory{})
+	if tenantId != 0 {
+		tx = tx.Where("user_id IN (?)", DB.Model(&User{}).Select("id").Where("tenant_id = ?", tenantId))
+	}
+	if params.UserId != 0 {
+		tx = tx.Where("user_id = ?", params.UserId)
+	}
+	if params.Username != "" {
+		tx = tx.Where("username = ?", params.Username)
+	}
+	if params.Ip != "" {
+		tx = tx.Where("ip = ?", params.Ip)
+	}
+	if params.Success != nil {
+		tx = tx.Where("success = ?", *params.Success)
+	}
+	if params.StartTimestamp != 0 {
+		tx = tx.Where("created_at >= ?", params.StartTimestamp)
+	}
+	if params.EndTimestamp != 0 {
+		tx = tx.Where("created_at <= ?", params.EndTimestamp)
+	}
+
+	return PaginateAndOrder[LoginHistory](tx, &params.PaginationParams, &histories, allowedLoginHistoryOrderFields)
+}
+
+// DeleteExpiredUserSessions 删除已过期或已撤销超过一天的会话，以及超过 login_history.retention_days 的登录记录
+func DeleteExpiredUserSessions() (int64, error) {
+	now := time.Now()
+	result := DB.Where("expires_at < ? OR (revoked_at > 0 AND revoked_at < ?)", now.Unix(), now.Add(-24*time.Hour).Unix()).Delete(&UserSession{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	count := result.RowsAffected
+
+	userSessionTouched.Range(func(key, value any) bool {
+		if now.Sub(value.(time.Time)) > userSessionTouchInterval {
+			userSessionTouched.Delete(key)
+		}
+		return true
+	})
+
+	if days := viper.GetInt("login_history.retention_days"); days > 0 {
+		result = DB.Where("created_at < ?", now.AddDate(0, 0, -days).Unix()).Delete(&LoginHistory{})
+		if result.Error != nil {
+			return count, result.Error
+		}
+		count += result.RowsAffected
+	}
+	return count, nil
+}
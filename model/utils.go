@@ -29,15 +29,40 @@ func init() {
 }
 
 func InitBatchUpdater() {
+	initBatchWAL()
 	go func() {
 		for {
 			time.Sleep(time.Duration(config.BatchUpdateInterval) * time.Second)
+			if batchWALEnabled {
+				refreshBatchWALAlive()
+				recoverBatchWAL()
+			}
 			batchUpdate()
 		}
 	}()
 }
 
+// FlushBatchUpdates 立即写入尚未提交的批量更新，用于停机前保存扣费记录
+func FlushBatchUpdates() {
+	if !config.BatchUpdateEnabled {
+		return
+	}
+
+	batchUpdate()
+	closeBatchWAL()
+}
+
+// addNewRecord 启用预写日志时记录只写入预写日志，写入失败的记录保存在内存中，写库时和预写日志合并
+// 预写日志在锁外写入，避免每次扣费都等待 Redis
 func addNewRecord(type_ int, id int, value int) {
+	if batchWALEnabled {
+		err := appendBatchWAL(type_, id, value)
+		if err == nil {
+			return
+		}
+		logger.SysError("failed to append batch update wal: " + err.Error())
+	}
+
 	batchUpdateLocks[type_].Lock()
 	defer batchUpdateLocks[type_].Unlock()
 	if _, ok := batchUpdateStores[type_][id]; !ok {
@@ -53,32 +78,64 @@ func batchUpdate() {
 		batchUpdateLocks[i].Lock()
 		store := batchUpdateStores[i]
 		batchUpdateStores[i] = make(map[int]int)
+		var walStore map[int]batchWALRecord
+		if batchWALEnabled {
+			// 预写日志中包含上次写库失败以及从其它实例接管的记录，内存中只有未写入预写日志的记录
+			var err error
+			walStore, err = rotateBatchWAL(i)
+			if err != nil {
+				// 放回内存，下次重试，避免预写日志中的记录被重复写入
+				logger.SysError("failed to rotate batch update wal: " + err.Error())
+				for key, value := range store {
+					batchUpdateStores[i][key] += value
+				}
+				batchUpdateLocks[i].Unlock()
+				continue
+			}
+		}
 		batchUpdateLocks[i].Unlock()
 		// TODO: maybe we can combine updates with same key?
+		for key, record := range walStore {
+			if err := applyBatchRecordOnce(i, key, record); err != nil {
+				continue
+			}
+			ackBatchWAL(i, key, record.nonce)
+		}
 		for key, value := range store {
-			switch i {
-			case BatchUpdateTypeUserQuota:
-				err := increaseUserQuota(key, value)
-				if err != nil {
-					logger.SysError("failed to batch update user quota: " + err.Error())
-				}
-			case BatchUpdateTypeTokenQuota:
-				err := increaseTokenQuota(key, value)
-				if err != nil {
-					logger.SysError("failed to batch update token quota: " + err.Error())
-				}
-			case BatchUpdateTypeUsedQuota:
-				updateUserUsedQuota(key, value)
-			case BatchUpdateTypeRequestCount:
-				updateUserRequestCount(key, value)
-			case BatchUpdateTypeChannelUsedQuota:
-				updateChannelUsedQuota(key, value)
+			if err := applyBatchRecord(DB, i, key, value); err != nil && batchWALEnabled {
+				// 未写入预写日志的记录写库失败时放回内存，下次重试
+				batchUpdateLocks[i].Lock()
+				batchUpdateStores[i][key] += value
+				batchUpdateLocks[i].Unlock()
 			}
 		}
 	}
 	logger.SysLog("batch update finished")
 }
 
+func applyBatchRecord(db *gorm.DB, type_ int, key int, value int) error {
+	var err error
+	switch type_ {
+	case BatchUpdateTypeUserQuota:
+		err = increaseUserQuota(db, key, value)
+		if err != nil {
+			logger.SysError("failed to batch update user quota: " + err.Error())
+		}
+	case BatchUpdateTypeTokenQuota:
+		err = increaseTokenQuota(db, key, value)
+		if err != nil {
+			logger.SysError("failed to batch update token quota: " + err.Error())
+		}
+	case BatchUpdateTypeUsedQuota:
+		err = updateUserUsedQuota(db, key, value)
+	case BatchUpdateTypeRequestCount:
+		err = updateUserRequestCount(db, key, value)
+	case BatchUpdateTypeChannelUsedQuota:
+		err = updateChannelUsedQuota(db, key, value)
+	}
+	return err
+}
+
 func BatchInsert[T any](db *gorm.DB, data []T) error {
 	batchSize := 200
 	for i := 0; i < len(data); i += batchSize {
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"errors"
+	"one-api/common/utils"
+)
+
+// VectorStoreBinding 向量库所在的渠道，向量库只存在于创建时的渠道，之后的请求都要转发到该渠道
+type VectorStoreBinding struct {
+	Id            int    `json:"id"`
+	VectorStoreId string `json:"vector_store_id" gorm:"type:varchar(64);uniqueIndex"`
+	UserId        int    `json:"user_id" gorm:"index"`
+	ChannelId     int    `json:"channel_id" gorm:"index"`
+	CreatedTime   int64  `json:"created_time" gorm:"bigint"`
+}
+
+func BindVectorStore(vectorStoreId string, userId, channelId int) error {
+	return DB.Create(&VectorStoreBinding{
+		VectorStoreId: vectorStoreId,
+		UserId:        userId,
+		ChannelId:     channelId,
+		CreatedTime:   utils.GetTimestamp(),
+	}).Error
+}
+
+func UnbindVectorStore(vectorStoreId string, userId int) error {
+	return DB.Where("vector_store_id = ? AND user_id = ?", vectorStoreId, userId).Delete(&VectorStoreBinding{}).Error
+}
+
+// GetVectorStoreChannelId 返回用户的向量库所在的渠道，都没有记录时返回 0，分属不同渠道时返回错误
+func GetVectorStoreChannelId(userId int, vectorStoreIds []string) (int, error) {
+	if len(vectorStoreIds) == 0 {
+		return 0, nil
+	}
+
+	var channelIds []int
+	err := DB.Model(&VectorStoreBinding{}).Where("user_id = ? AND vector_store_id IN ?", userId, vectorStoreIds).Distinct("channel_id").Pluck("channel_id", &channelIds).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(channelIds) > 1 {
+		return 0, errors.New("向量库属于不同的渠道，不能在同一个请求中使用")
+	}
+	if len(channelIds) == 0 {
+		return 0, nil
+	}
+	return channelIds[0], nil
+}
@@ -108,12 +108,8 @@ func errorHandle(aliError *AliError) *types.OpenAIError {
 func (p *AliProvider) GetFullRequestURL(requestURL string, modelName string) string {
 	baseURL := strings.TrimSuffix(p.GetBaseURL(), "/")
 
-	modelKeywords := strings.Split(VisionModelKeywords, ",")
-	for _, keyword := range modelKeywords {
-		if strings.Contains(modelName, keyword) {
-			requestURL = "/api/v1/services/aigc/multimodal-generation/generation"
-			break
-		}
+	if isVisionModel(modelName) {
+		requestURL = "/api/v1/services/aigc/multimodal-generation/generation"
 	}
 
 	return fmt.Sprintf("%s%s", baseURL, requestURL)
@@ -130,3 +126,16 @@ func (p *AliProvider) GetRequestHeaders() (headers map[string]string) {
 
 	return headers
 }
+
+func (p *AliProvider) GetCapabilities(modelName string) base.Capabilities {
+	if p.UseOpenaiAPI {
+		return p.OpenAIProvider.GetCapabilities(modelName)
+	}
+	return base.Capabilities{
+		Tools:       base.CapabilityNative,
+		Vision:      base.CapabilityNative,
+		JSONMode:    base.CapabilityUnsupported,
+		Logprobs:    base.CapabilityUnsupported,
+		StreamUsage: base.CapabilityNative,
+	}
+}
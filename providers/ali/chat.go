@@ -12,9 +12,8 @@ import (
 )
 
 type aliStreamHandler struct {
-	Usage              *types.Usage
-	Request            *types.ChatCompletionRequest
-	lastStreamResponse string
+	Usage   *types.Usage
+	Request *types.ChatCompletionRequest
 }
 
 func (p *AliProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
@@ -112,6 +111,13 @@ func (p *AliProvider) convertToChatOpenai(response *AliChatResponse, request *ty
 		},
 	}
 
+	// 使用旧版 functions 请求时转换为 function_call
+	if request.Functions != nil {
+		for i := range openaiResponse.Choices {
+			openaiResponse.Choices[i].CheckChoice(request)
+		}
+	}
+
 	*p.Usage = *openaiResponse.Usage
 
 	return
@@ -119,42 +125,45 @@ func (p *AliProvider) convertToChatOpenai(response *AliChatResponse, request *ty
 
 // 阿里云聊天请求体
 func (p *AliProvider) convertFromChatOpenai(request *types.ChatCompletionRequest) *AliChatRequest {
+	isVision := isVisionModel(request.Model)
 	messages := make([]AliMessage, 0, len(request.Messages))
-	for i := 0; i < len(request.Messages); i++ {
+	for i := range request.Messages {
 		message := request.Messages[i]
-		modelKeywords := strings.Split(VisionModelKeywords, ",")
-		isVisionModel := false
-		for _, keyword := range modelKeywords {
-			if strings.Contains(request.Model, keyword) {
-				isVisionModel = true
-				break
-			}
+		if message.FunctionCall != nil {
+			message.FuncToToolCalls()
 		}
-		if !isVisionModel {
-			messages = append(messages, AliMessage{
-				Content: message.StringContent(),
-				Role:    strings.ToLower(message.Role),
-			})
+
+		aliMessage := AliMessage{
+			Role:       strings.ToLower(message.Role),
+			Name:       message.Name,
+			ToolCalls:  message.ToolCalls,
+			ToolCallId: message.ToolCallID,
+		}
+		// 旧版 function 的返回结果转为 tool
+		if aliMessage.Role == types.ChatMessageRoleFunction {
+			aliMessage.Role = types.ChatMessageRoleTool
+		}
+
+		if !isVision {
+			aliMessage.Content = message.StringContent()
 		} else {
 			openaiContent := message.ParseContent()
-			var parts []AliMessagePart
+			parts := make([]AliMessagePart, 0, len(openaiContent))
 			for _, part := range openaiContent {
 				if part.Type == types.ContentTypeText {
 					parts = append(parts, AliMessagePart{
 						Text: part.Text,
 					})
-				} else if part.Type == types.ContentTypeImageURL {
+				} else if part.Type == types.ContentTypeImageURL && part.ImageURL != nil {
+					// 支持 http 地址和 data:image/xxx;base64, 格式
 					parts = append(parts, AliMessagePart{
 						Image: part.ImageURL.URL,
 					})
 				}
 			}
-			messages = append(messages, AliMessage{
-				Content: parts,
-				Role:    strings.ToLower(message.Role),
-			})
+			aliMessage.Content = parts
 		}
-
+		messages = append(messages, aliMessage)
 	}
 
 	aliChatRequest := &AliChatRequest{
@@ -166,9 +175,23 @@ func (p *AliProvider) convertFromChatOpenai(request *types.ChatCompletionRequest
 			ResultFormat:      "message",
 			IncrementalOutput: request.Stream,
 			EnableThinking:    request.EnableThinking,
+			ToolChoice:        request.ToolChoice,
+			ParallelToolCalls: request.ParallelToolCalls,
 		},
 	}
 
+	if request.Functions != nil {
+		aliChatRequest.Parameters.Tools = make([]*types.ChatCompletionTool, 0, len(request.Functions))
+		for _, function := range request.Functions {
+			aliChatRequest.Parameters.Tools = append(aliChatRequest.Parameters.Tools, &types.ChatCompletionTool{
+				Type:     "function",
+				Function: *function,
+			})
+		}
+	} else if request.Tools != nil {
+		aliChatRequest.Parameters.Tools = request.Tools
+	}
+
 	p.pluginHandle(aliChatRequest)
 
 	return aliChatRequest
@@ -224,34 +247,13 @@ func (h *aliStreamHandler) handlerStream(rawLine *[]byte, dataChan chan string,
 
 }
 
+// convertToOpenaiStream 流式请求开启了 incremental_output，每次返回的都是增量内容
 func (h *aliStreamHandler) convertToOpenaiStream(aliResponse *AliChatResponse, dataChan chan string) {
-	content := aliResponse.Output.Choices[0].Message.StringContent()
-	reasoningContent := aliResponse.Output.Choices[0].Message.ReasoningContent
-	var choice types.ChatCompletionStreamChoice
-	choice.Index = aliResponse.Output.Choices[0].Index
-	choice.Delta.Content = strings.TrimPrefix(content, h.lastStreamResponse)
-	choice.Delta.ReasoningContent = reasoningContent
-	if aliResponse.Output.Choices[0].FinishReason != "" {
-		if aliResponse.Output.Choices[0].FinishReason != "null" {
-			finishReason := aliResponse.Output.Choices[0].FinishReason
-			choice.FinishReason = &finishReason
-		}
-	}
-
-	if aliResponse.Output.FinishReason != "" {
-		if aliResponse.Output.FinishReason != "null" {
-			finishReason := aliResponse.Output.FinishReason
-			choice.FinishReason = &finishReason
-		}
-	}
-
-	h.lastStreamResponse = content
 	streamResponse := types.ChatCompletionStreamResponse{
 		ID:      aliResponse.RequestId,
 		Object:  "chat.completion.chunk",
 		Created: utils.GetTimestamp(),
 		Model:   h.Request.Model,
-		Choices: []types.ChatCompletionStreamChoice{choice},
 	}
 
 	if aliResponse.Usage.OutputTokens != 0 {
@@ -260,6 +262,31 @@ func (h *aliStreamHandler) convertToOpenaiStream(aliResponse *AliChatResponse, d
 		h.Usage.TotalTokens = aliResponse.Usage.InputTokens + aliResponse.Usage.OutputTokens
 	}
 
+	if len(aliResponse.Output.Choices) == 0 {
+		return
+	}
+
+	aliChoice := aliResponse.Output.Choices[0]
+	var choice types.ChatCompletionStreamChoice
+	choice.Index = aliChoice.Index
+	choice.Delta.Role = types.ChatMessageRoleAssistant
+	choice.Delta.Content = aliChoice.Message.StringContent()
+	choice.Delta.ReasoningContent = aliChoice.Message.ReasoningContent
+	choice.Delta.ToolCalls = aliChoice.Message.ToolCalls
+
+	finishReason := aliChoice.FinishReason
+	if aliResponse.Output.FinishReason != "" {
+		finishReason = aliResponse.Output.FinishReason
+	}
+	if finishReason != "" && finishReason != "null" {
+		choice.FinishReason = &finishReason
+	}
+
+	if choice.Delta.ToolCalls != nil {
+		choice.CheckChoice(h.Request)
+	}
+
+	streamResponse.Choices = []types.ChatCompletionStreamChoice{choice}
 	responseBody, _ := json.Marshal(streamResponse)
 	dataChan <- string(responseBody)
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"one-api/providers/openai"
+	"strings"
 )
 
 const (
@@ -14,6 +15,16 @@ const (
 	WebSearchSupportedModels = "qwen-plus,qwen-turbo,qwen-max,qwq-plus"
 )
 
+// isVisionModel 视觉模型使用多模态接口，content 为数组
+func isVisionModel(modelName string) bool {
+	for _, keyword := range strings.Split(VisionModelKeywords, ",") {
+		if strings.Contains(modelName, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *AliProvider) GetModelList() ([]string, error) {
 	fullRequestURL := fmt.Sprintf("%s%s", OpenaiBaseURL, p.Config.ModelList)
 	headers := p.GetRequestHeaders()
@@ -17,8 +17,11 @@ type AliUsage struct {
 }
 
 type AliMessage struct {
-	Content any    `json:"content"`
-	Role    string `json:"role"`
+	Content    any                              `json:"content"`
+	Role       string                           `json:"role"`
+	Name       *string                          `json:"name,omitempty"`
+	ToolCalls  []*types.ChatCompletionToolCalls `json:"tool_calls,omitempty"`
+	ToolCallId string                           `json:"tool_call_id,omitempty"`
 }
 
 type AliMessagePart struct {
@@ -39,6 +42,10 @@ type AliParameters struct {
 	IncrementalOutput bool    `json:"incremental_output,omitempty"`
 	ResultFormat      string  `json:"result_format,omitempty"`
 	EnableThinking    *bool   `json:"enable_thinking,omitempty"` // qwen3 thinking switch
+	// 与 OpenAI 的格式相同
+	Tools             []*types.ChatCompletionTool `json:"tools,omitempty"`
+	ToolChoice        any                         `json:"tool_choice,omitempty"`
+	ParallelToolCalls bool                        `json:"parallel_tool_calls,omitempty"`
 }
 
 type AliChatRequest struct {
@@ -57,14 +64,18 @@ type AliOutput struct {
 	FinishReason string                       `json:"finish_reason,omitempty"`
 }
 
+// ToChatCompletionChoices 多模态模型返回的 content 为 [{"text": ""}]，合并为字符串
 func (o *AliOutput) ToChatCompletionChoices() []types.ChatCompletionChoice {
 	for i := range o.Choices {
+		if o.Choices[i].Message.Content == nil {
+			continue
+		}
 		_, ok := o.Choices[i].Message.Content.(string)
 		if ok {
 			continue
 		}
 
-		o.Choices[i].Message.Content = o.Choices[i].Message.ParseContent()
+		o.Choices[i].Message.Content = o.Choices[i].Message.StringContent()
 	}
 	return o.Choices
 }
@@ -1,11 +1,14 @@
 package baidu
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"one-api/common/cache"
+	"one-api/common/config"
 	"one-api/common/logger"
 	"one-api/common/requester"
 	"one-api/model"
@@ -13,6 +16,7 @@ import (
 	"one-api/providers/openai"
 	"one-api/types"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -21,6 +25,9 @@ type BaiduProviderFactory struct{}
 
 var baiduCacheKey = "api_token:baidu"
 
+// access token 有效期为 30 天，剩余不足 1 天时提前刷新
+const baiduTokenRefreshWindow = 24 * time.Hour
+
 const (
 	OpenaiBaseURL = "https://qianfan.baidubce.com"
 	BaiduBaseURL  = "https://aip.baidubce.com"
@@ -103,36 +110,21 @@ func errorHandle(baiduError *BaiduError) *types.OpenAIError {
 	}
 }
 
+// 无法按名称生成接口地址的模型，名称与接口不一致的旧模型
 var modelNameMap = map[string]string{
-	"ERNIE-4.0-Turbo-8K":           "ernie-4.0-turbo-8k",
-	"ERNIE-4.0-8K-Latest":          "ernie-4.0-8k-latest",
-	"ERNIE-4.0-8K-0613":            "ernie-4.0-8k-0613",
-	"ERNIE-3.5-8K-0613":            "ernie-3.5-8k-0613",
 	"ERNIE-Bot-turbo":              "eb-instant",
 	"ERNIE-Lite-8K-0922":           "eb-instant",
-	"ERNIE-Lite-8K":                "ernie-lite-8k",
 	"ERNIE-Lite-8K-0308":           "ernie-lite-8k",
 	"ERNIE-3.5-8K":                 "completions",
 	"ERNIE-Bot":                    "completions",
 	"ERNIE-4.0-8K":                 "completions_pro",
-	"ERNIE-4.0-8K-Preview":         "ernie-4.0-8k-preview",
 	"ERNIE-4.0-8K-Preview-0518":    "completions_adv_pro",
-	"ERNIE-4.0-8K-0329":            "ernie-4.0-8k-0329",
-	"ERNIE-4.0-8K-0104":            "ernie-4.0-8k-0104",
 	"ERNIE-Bot-4":                  "completions_pro",
 	"ERNIE-Bot-8k":                 "ernie_bot_8k",
-	"ERNIE-3.5-128K":               "ernie-3.5-128k",
-	"ERNIE-3.5-8K-preview":         "ernie-3.5-8k-preview",
-	"ERNIE-3.5-8K-0329":            "ernie-3.5-8k-0329",
-	"ERNIE-3.5-4K-0205":            "ernie-3.5-4k-0205",
-	"ERNIE-3.5-8K-0205":            "ernie-3.5-8k-0205",
-	"ERNIE-3.5-8K-1222":            "ernie-3.5-8k-1222",
 	"ERNIE Speed":                  "ernie_speed",
 	"ERNIE-Speed":                  "ernie_speed",
 	"ERNIE-Speed-8K":               "ernie_speed",
-	"ERNIE-Speed-128K":             "ernie-speed-128k",
 	"ERNIE Speed-AppBuilder":       "ai_apaas",
-	"ERNIE-Tiny-8K":                "ernie-tiny-8k",
 	"ERNIE-Function-8K":            "ernie-func-8k",
 	"ERNIE-Character-8K":           "ernie-char-8k",
 	"ERNIE-Character-Fiction-8K":   "ernie-char-fiction-8k",
@@ -151,29 +143,37 @@ var modelNameMap = map[string]string{
 	"ChatGLM2-6B-32K":              "chatglm2_6b_32k",
 	"AquilaChat-7B":                "aquilachat_7b",
 	"XuanYuan-70B-Chat-4bit":       "xuanyuan_70b_chat",
-	"ChatLaw":                      "chatlaw",
 	"Yi-34B-Chat":                  "yi_34b_chat",
 	"Mixtral-8x7B-Instruct":        "mixtral_8x7b_instruct",
 	"Gemma-7B-it":                  "gemma_7b_it",
 }
 
+// getModelEndpoint 模型对应的接口名称，依次使用系统设置中的覆盖、内置的对应关系，都没有时按模型名称生成
+func getModelEndpoint(modelName string) string {
+	if endpoint, ok := config.BaiduModelEndpoints[modelName]; ok && endpoint != "" {
+		return endpoint
+	}
+	if endpoint, ok := modelNameMap[modelName]; ok {
+		return endpoint
+	}
+	// 新模型的接口名称为小写的模型名称，空格替换为 -
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(modelName)), " ", "-")
+}
+
 // 获取完整请求 URL
 func (p *BaiduProvider) GetFullRequestURL(requestURL string, modelName string) string {
 	if p.UseOpenaiAPI {
 		return fmt.Sprintf("%s%s", p.GetBaseURL(), requestURL)
 	}
 
-	if modelNameConvert, ok := modelNameMap[modelName]; ok {
-		modelName = modelNameConvert
-	}
-
 	baseURL := strings.TrimSuffix(p.GetBaseURL(), "/")
 	apiKey, err := p.getBaiduAccessToken()
 	if err != nil {
+		logger.SysError("get baidu access token error: " + err.Error())
 		return ""
 	}
 
-	return fmt.Sprintf("%s%s/%s?access_token=%s", baseURL, requestURL, modelName, apiKey)
+	return fmt.Sprintf("%s%s/%s?access_token=%s", baseURL, requestURL, getModelEndpoint(modelName), apiKey)
 }
 
 // 获取请求头
@@ -188,18 +188,47 @@ func (p *BaiduProvider) GetRequestHeaders() (headers map[string]string) {
 	return headers
 }
 
+// baiduCachedToken 缓存的 access token，按密钥摘要缓存，同一密钥的多个渠道共用
+type baiduCachedToken struct {
+	AccessToken string
+	ExpiresAt   int64
+}
+
+// 正在后台刷新的密钥
+var baiduRefreshing sync.Map
+
+func baiduTokenCacheKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return baiduCacheKey + ":" + hex.EncodeToString(sum[:16])
+}
+
+// getBaiduAccessToken 剩余有效期不足 baiduTokenRefreshWindow 时在后台提前刷新，刷新完成前继续使用旧的 token
 func (p *BaiduProvider) getBaiduAccessToken() (string, error) {
 	apiKey := p.Channel.Key
-	cacheKey := fmt.Sprintf("%s:%d", baiduCacheKey, p.Channel.Id)
-	tokenStr, err := cache.GetCache[string](cacheKey)
-	if err != nil {
+	cacheKey := baiduTokenCacheKey(apiKey)
+	cached, err := cache.GetCache[baiduCachedToken](cacheKey)
+	if err != nil && !errors.Is(err, cache.CacheNotFound) {
 		logger.SysError("get baidu token error: " + err.Error())
 	}
 
-	if tokenStr != "" {
-		return tokenStr, nil
+	if cached.AccessToken != "" {
+		if time.Until(time.Unix(cached.ExpiresAt, 0)) < baiduTokenRefreshWindow {
+			if _, loaded := baiduRefreshing.LoadOrStore(cacheKey, true); !loaded {
+				go func() {
+					defer baiduRefreshing.Delete(cacheKey)
+					if _, err := p.refreshBaiduAccessToken(apiKey, cacheKey); err != nil {
+						logger.SysError("refresh baidu token error: " + err.Error())
+					}
+				}()
+			}
+		}
+		return cached.AccessToken, nil
 	}
 
+	return p.refreshBaiduAccessToken(apiKey, cacheKey)
+}
+
+func (p *BaiduProvider) refreshBaiduAccessToken(apiKey, cacheKey string) (string, error) {
 	accessToken, err := p.getBaiduAccessTokenHelper(apiKey)
 	if err != nil {
 		return "", err
@@ -208,7 +237,11 @@ func (p *BaiduProvider) getBaiduAccessToken() (string, error) {
 		return "", errors.New("getBaiduAccessToken return a nil token")
 	}
 
-	cache.SetCache(cacheKey, accessToken.AccessToken, time.Duration(accessToken.ExpiresIn)*time.Second)
+	expiresIn := time.Duration(accessToken.ExpiresIn) * time.Second
+	cache.SetCache(cacheKey, baiduCachedToken{
+		AccessToken: accessToken.AccessToken,
+		ExpiresAt:   time.Now().Add(expiresIn).Unix(),
+	}, expiresIn)
 
 	return accessToken.AccessToken, nil
 }
@@ -243,3 +276,16 @@ func (p *BaiduProvider) getBaiduAccessTokenHelper(apiKey string) (*BaiduAccessTo
 	}
 	return &accessToken, nil
 }
+
+func (p *BaiduProvider) GetCapabilities(modelName string) base.Capabilities {
+	if p.UseOpenaiAPI {
+		return p.OpenAIProvider.GetCapabilities(modelName)
+	}
+	return base.Capabilities{
+		Tools:       base.CapabilityNative,
+		Vision:      base.CapabilityUnsupported,
+		JSONMode:    base.CapabilityNative,
+		Logprobs:    base.CapabilityUnsupported,
+		StreamUsage: base.CapabilityNative,
+	}
+}
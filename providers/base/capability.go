@@ -0,0 +1,44 @@
+package base
+
+// 聊天功能的支持方式
+const (
+	CapabilityNative      = "native"      // 请求参数转换后由上游原生处理
+	CapabilityEmulated    = "emulated"    // 上游不支持，由中转模拟，例如本地计算流式响应的 usage
+	CapabilityUnsupported = "unsupported" // 参数会被忽略或由上游报错
+)
+
+// Capabilities 供应商对聊天请求各功能的支持方式
+type Capabilities struct {
+	Tools       string `json:"tools"`
+	Vision      string `json:"vision"`
+	JSONMode    string `json:"json_mode"`
+	Logprobs    string `json:"logprobs"`
+	StreamUsage string `json:"stream_usage"`
+}
+
+// CapabilityInterface 供应商声明模型的功能支持，未实现的聊天供应商按 DefaultCapabilities 处理
+type CapabilityInterface interface {
+	GetCapabilities(modelName string) Capabilities
+}
+
+// DefaultCapabilities 只转换文本消息的供应商，流式响应的 usage 由中转按文本计算
+func DefaultCapabilities() Capabilities {
+	return Capabilities{
+		Tools:       CapabilityUnsupported,
+		Vision:      CapabilityUnsupported,
+		JSONMode:    CapabilityUnsupported,
+		Logprobs:    CapabilityUnsupported,
+		StreamUsage: CapabilityEmulated,
+	}
+}
+
+// UnsupportedCapabilities 不支持聊天的供应商或模型
+func UnsupportedCapabilities() Capabilities {
+	return Capabilities{
+		Tools:       CapabilityUnsupported,
+		Vision:      CapabilityUnsupported,
+		JSONMode:    CapabilityUnsupported,
+		Logprobs:    CapabilityUnsupported,
+		StreamUsage: CapabilityUnsupported,
+	}
+}
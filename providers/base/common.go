@@ -106,15 +106,9 @@ func (p *BaseProvider) CommonRequestHeaders(headers map[string]string) {
 	if headers["Content-Type"] == "" {
 		headers["Content-Type"] = "application/json"
 	}
-	// 自定义header
-	if p.Channel.ModelHeaders != nil {
-		var customHeaders map[string]string
-		err := json.Unmarshal([]byte(*p.Channel.ModelHeaders), &customHeaders)
-		if err == nil {
-			for key, value := range customHeaders {
-				headers[key] = value
-			}
-		}
+	// 自定义header，通过 HTTP 请求发送时还会覆盖供应商设置的同名请求头
+	for key, value := range p.Channel.GetModelHeaders() {
+		headers[key] = value
 	}
 }
 
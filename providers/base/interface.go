@@ -119,6 +119,11 @@ type BalanceInterface interface {
 	Balance() (float64, error)
 }
 
+// 凭证校验接口，只在本地解析密钥并生成签名，不请求上游
+type CredentialInterface interface {
+	ValidateCredentials() error
+}
+
 // type ProviderResponseHandler interface {
 // 	// 响应处理函数
 // 	ResponseHandler(resp *http.Response) (OpenAIResponse any, errWithCode *types.OpenAIErrorWithStatusCode)
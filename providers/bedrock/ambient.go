@@ -0,0 +1,35 @@
+package bedrock
+
+import (
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// AmbientKey key 为 Region|ambient 时使用运行环境的凭据（环境变量、IRSA、ECS 任务角色、EC2 实例角色），
+// 渠道中不保存长期密钥
+const AmbientKey = "ambient"
+
+var (
+	ambientLock        sync.Mutex
+	ambientCredentials *credentials.Credentials
+)
+
+// getAmbientCredentials 按默认凭据链获取，临时凭据由 SDK 缓存并在过期前刷新
+func getAmbientCredentials() (credentials.Value, error) {
+	ambientLock.Lock()
+	if ambientCredentials == nil {
+		sess, err := session.NewSession(&aws.Config{})
+		if err != nil {
+			ambientLock.Unlock()
+			return credentials.Value{}, err
+		}
+		ambientCredentials = sess.Config.Credentials
+	}
+	creds := ambientCredentials
+	ambientLock.Unlock()
+
+	return creds.Get()
+}
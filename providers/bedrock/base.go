@@ -11,6 +11,7 @@ import (
 	"one-api/common/requester"
 	"one-api/model"
 	"one-api/providers/base"
+	"one-api/providers/claude"
 	"one-api/types"
 	"strings"
 	"time"
@@ -44,6 +45,7 @@ type BedrockProvider struct {
 	SecretAccessKey string
 	SessionToken    string
 	APIToken        string
+	Ambient         bool // 使用运行环境的凭据签名
 	Category        *category.Category
 }
 
@@ -99,6 +101,10 @@ func getKeyConfig(bedrock *BedrockProvider) {
 		return
 	}
 	bedrock.Region = keys[0]
+	if len(keys) == 2 && keys[1] == AmbientKey {
+		bedrock.Ambient = true
+		return
+	}
 	if len(keys) == 2 {
 		bedrock.APIToken = keys[1]
 		return
@@ -125,7 +131,15 @@ func (p *BedrockProvider) Sign(req *http.Request) error {
 	if p.APIToken != "" {
 		return nil
 	}
-	sig, err := sigv4.New(sigv4.WithCredential(p.AccessKeyID, p.SecretAccessKey, p.SessionToken), sigv4.WithRegionService(p.Region, awsService))
+	accessKeyID, secretAccessKey, sessionToken := p.AccessKeyID, p.SecretAccessKey, p.SessionToken
+	if p.Ambient {
+		creds, err := getAmbientCredentials()
+		if err != nil {
+			return errors.New("error getting ambient credentials: " + err.Error())
+		}
+		accessKeyID, secretAccessKey, sessionToken = creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken
+	}
+	sig, err := sigv4.New(sigv4.WithCredential(accessKeyID, secretAccessKey, sessionToken), sigv4.WithRegionService(p.Region, awsService))
 	if err != nil {
 		return err
 	}
@@ -135,3 +149,11 @@ func (p *BedrockProvider) Sign(req *http.Request) error {
 
 	return nil
 }
+
+// GetCapabilities 目前只转换 Anthropic 的模型
+func (p *BedrockProvider) GetCapabilities(modelName string) base.Capabilities {
+	if _, err := category.GetCategory(modelName); err != nil {
+		return base.UnsupportedCapabilities()
+	}
+	return claude.ChatCapabilities
+}
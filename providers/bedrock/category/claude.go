@@ -3,7 +3,6 @@ package category
 import (
 	"encoding/json"
 	"net/http"
-	"one-api/common"
 	"one-api/common/requester"
 	"one-api/providers/base"
 	"one-api/providers/claude"
@@ -46,7 +45,7 @@ func ConvertClaudeToChatOpenai(provider base.ProviderInterface, response *http.R
 	claudeResponse := &claude.ClaudeResponse{}
 	err := json.NewDecoder(response.Body).Decode(claudeResponse)
 	if err != nil {
-		return nil, common.ErrorWrapper(err, "decode_response_failed", http.StatusInternalServerError)
+		return nil, requester.InvalidResponseError(err)
 	}
 
 	return claude.ConvertToChatOpenai(provider, claudeResponse, request)
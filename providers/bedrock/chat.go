@@ -78,7 +78,9 @@ func (p *BedrockProvider) getChatRequest(request *types.ChatCompletionRequest) (
 	if err != nil {
 		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
 	}
-	p.Sign(req)
+	if err := p.Sign(req); err != nil {
+		return nil, common.ErrorWrapper(err, "sign_request_failed", http.StatusInternalServerError)
+	}
 
 	return req, nil
 }
@@ -100,7 +100,9 @@ func (p *BedrockProvider) getClaudeRequest(request *claude.ClaudeRequest) (*http
 		return nil, common.StringErrorWrapperLocal(err.Error(), "new_request_failed", http.StatusInternalServerError)
 	}
 
-	p.Sign(req)
+	if err := p.Sign(req); err != nil {
+		return nil, common.StringErrorWrapperLocal(err.Error(), "sign_request_failed", http.StatusInternalServerError)
+	}
 
 	return req, nil
 }
@@ -0,0 +1,35 @@
+package providers
+
+import (
+	"one-api/model"
+	"one-api/providers/base"
+	"one-api/providers/openai"
+	"strings"
+)
+
+// GetCapabilities 按适配器声明的功能支持描述渠道上的模型，模型先经过渠道的模型映射
+// 只创建供应商实例，不解析外部密钥，也不发送请求；不支持聊天的供应商全部为不支持
+func GetCapabilities(channel *model.Channel, modelName string) base.Capabilities {
+	var provider base.ProviderInterface
+	if factory, ok := providerFactories[channel.Type]; ok {
+		provider = factory.Create(channel)
+	} else if baseURL := channel.GetBaseURL(); baseURL != "" {
+		provider = openai.CreateOpenAIProvider(channel, baseURL)
+	}
+	if provider == nil {
+		return base.UnsupportedCapabilities()
+	}
+	if _, ok := provider.(base.ChatInterface); !ok {
+		return base.UnsupportedCapabilities()
+	}
+
+	if upstreamModel, err := provider.ModelMappingHandler(modelName); err == nil {
+		modelName = strings.TrimPrefix(upstreamModel, "+")
+	}
+
+	capable, ok := provider.(base.CapabilityInterface)
+	if !ok {
+		return base.DefaultCapabilities()
+	}
+	return capable.GetCapabilities(modelName)
+}
@@ -110,3 +110,16 @@ func convertRole(role string) string {
 		return types.ChatMessageRoleAssistant
 	}
 }
+
+// ChatCapabilities 转换为 Messages 接口后的功能支持，Bedrock 和 Vertex AI 上的 Claude 模型相同
+var ChatCapabilities = base.Capabilities{
+	Tools:       base.CapabilityNative,
+	Vision:      base.CapabilityNative,
+	JSONMode:    base.CapabilityUnsupported,
+	Logprobs:    base.CapabilityUnsupported,
+	StreamUsage: base.CapabilityNative,
+}
+
+func (p *ClaudeProvider) GetCapabilities(modelName string) base.Capabilities {
+	return ChatCapabilities
+}
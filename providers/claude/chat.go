@@ -28,6 +28,8 @@ type ClaudeStreamHandler struct {
 	Request     *types.ChatCompletionRequest
 	StreamTolls int
 	Prefix      string
+
+	reasoning strings.Builder // 思考内容，用于估算推理 token 数
 }
 
 func (p *ClaudeProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
@@ -436,6 +438,7 @@ func ConvertToChatOpenai(provider base.ProviderInterface, response *ClaudeRespon
 		usage.CompletionTokens = ClaudeOutputUsage(response)
 		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
 	}
+	usage.CompletionTokensDetails.ReasoningTokens = ClaudeReasoningUsage(response, usage.CompletionTokens)
 
 	openaiResponse.Usage = usage
 
@@ -483,10 +486,14 @@ func (h *ClaudeStreamHandler) HandlerStream(rawLine *[]byte, dataChan chan strin
 		h.convertToOpenaiStream(&claudeResponse, dataChan)
 		h.Usage.CompletionTokens = claudeResponse.Usage.OutputTokens
 		h.Usage.TotalTokens = h.Usage.PromptTokens + h.Usage.CompletionTokens
+		if h.reasoning.Len() > 0 {
+			h.Usage.CompletionTokensDetails.ReasoningTokens = min(common.CountTokenText(h.reasoning.String(), h.Request.Model), h.Usage.CompletionTokens)
+		}
 
 	case "content_block_delta":
 		h.convertToOpenaiStream(&claudeResponse, dataChan)
-		h.Usage.TextBuilder.WriteString(claudeResponse.Delta.Text)
+		h.Usage.AppendText(claudeResponse.Delta.Text)
+		h.reasoning.WriteString(claudeResponse.Delta.Thinking)
 	case "content_block_start":
 		h.convertToOpenaiStream(&claudeResponse, dataChan)
 
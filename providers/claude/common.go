@@ -104,3 +104,18 @@ func ClaudeOutputUsage(response *ClaudeResponse) int {
 
 	return common.CountTokenText(textMsg.String(), response.Model)
 }
+
+// ClaudeReasoningUsage Claude 不单独返回思考的 token 数，按思考内容估算，不超过输出 token 数
+func ClaudeReasoningUsage(response *ClaudeResponse, completionTokens int) int {
+	var thinking strings.Builder
+	for _, c := range response.Content {
+		if c.Type == ContentTypeThinking {
+			thinking.WriteString(c.Thinking)
+		}
+	}
+	if thinking.Len() == 0 {
+		return 0
+	}
+
+	return min(common.CountTokenText(thinking.String(), response.Model), completionTokens)
+}
@@ -118,7 +118,7 @@ func (h *ClaudeRelayStreamHandler) HandlerStream(rawLine *[]byte, dataChan chan
 		ClaudeUsageMerge(&claudeResponse.Usage, h.StartUsage)
 		ClaudeUsageToOpenaiUsage(&claudeResponse.Usage, h.Usage)
 	case "content_block_delta":
-		h.Usage.TextBuilder.WriteString(claudeResponse.Delta.Text)
+		h.Usage.AppendText(claudeResponse.Delta.Text)
 	}
 
 	dataChan <- rawStr
@@ -173,7 +173,7 @@ func (h *CloudflareAIStreamHandler) convertToOpenaiStream(chatResponse *ChatResu
 		choice.FinishReason = types.FinishReasonStop
 	} else {
 		choice.Delta.Content = chatResponse.Response
-		h.Usage.TextBuilder.WriteString(chatResponse.Response)
+		h.Usage.AppendText(chatResponse.Response)
 	}
 
 	streamResponse.Choices = []types.ChatCompletionStreamChoice{choice}
@@ -88,3 +88,13 @@ func convertFinishReason(finishReason string) string {
 		return types.FinishReasonNull
 	}
 }
+
+func (p *CohereProvider) GetCapabilities(modelName string) base.Capabilities {
+	return base.Capabilities{
+		Tools:       base.CapabilityNative,
+		Vision:      base.CapabilityUnsupported,
+		JSONMode:    base.CapabilityNative,
+		Logprobs:    base.CapabilityNative,
+		StreamUsage: base.CapabilityNative,
+	}
+}
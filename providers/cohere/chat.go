@@ -214,7 +214,7 @@ func (h *CohereStreamHandler) convertToOpenaiStream(cohereResponse *ChatStreamRe
 			choice.Delta.ToolCalls = []*types.ChatCompletionToolCalls{delta.Message.ToolCalls}
 		}
 
-		h.Usage.TextBuilder.WriteString(choice.Delta.Content)
+		h.Usage.AppendText(choice.Delta.Content)
 	}
 
 	chatCompletion := types.ChatCompletionStreamResponse{
@@ -188,7 +188,7 @@ func (h *CozeStreamHandler) convertToOpenaiStream(chatResponse *CozeStreamRespon
 		choice.FinishReason = types.FinishReasonStop
 	} else {
 		choice.Delta.Content = chatResponse.Message.Content
-		h.Usage.TextBuilder.WriteString(chatResponse.Message.Content)
+		h.Usage.AppendText(chatResponse.Message.Content)
 	}
 
 	streamResponse.Choices = []types.ChatCompletionStreamChoice{choice}
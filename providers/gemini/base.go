@@ -142,3 +142,19 @@ func (p *GeminiProvider) GetRequestHeaders() (headers map[string]string) {
 
 	return headers
 }
+
+// ChatCapabilities 转换为 generateContent 接口后的功能支持，Vertex AI 上的 Gemini 模型相同
+var ChatCapabilities = base.Capabilities{
+	Tools:       base.CapabilityNative,
+	Vision:      base.CapabilityNative,
+	JSONMode:    base.CapabilityNative,
+	Logprobs:    base.CapabilityUnsupported,
+	StreamUsage: base.CapabilityNative,
+}
+
+func (p *GeminiProvider) GetCapabilities(modelName string) base.Capabilities {
+	if p.UseOpenaiAPI {
+		return p.OpenAIProvider.GetCapabilities(modelName)
+	}
+	return ChatCapabilities
+}
@@ -407,7 +407,7 @@ func (h *GeminiStreamHandler) convertToOpenaiStream(geminiResponse *GeminiChatRe
 		dataChan <- string(responseBody)
 	}
 
-	h.Usage.TextBuilder.WriteString(streamResponse.GetResponseText())
+	h.Usage.AppendText(streamResponse.GetResponseText())
 
 	// 和ExecutableCode的tokens共用，所以跳过
 	if geminiResponse.UsageMetadata == nil {
@@ -417,6 +417,7 @@ func (h *GeminiStreamHandler) convertToOpenaiStream(geminiResponse *GeminiChatRe
 	usage := ConvertOpenAIUsage(geminiResponse.UsageMetadata)
 
 	usage.TextBuilder = h.Usage.TextBuilder
+	usage.TextCounter = h.Usage.TextCounter
 	*h.Usage = usage
 }
 
@@ -96,7 +96,7 @@ func (h *GeminiRelayStreamHandler) HandlerStream(rawLine *[]byte, dataChan chan
 		errChan <- geminiResponse.ErrorInfo
 		return
 	}
-	h.Usage.TextBuilder.WriteString(geminiResponse.GetResponseText())
+	h.Usage.AppendText(geminiResponse.GetResponseText())
 
 	if geminiResponse.UsageMetadata == nil {
 		dataChan <- rawStr
@@ -106,6 +106,7 @@ func (h *GeminiRelayStreamHandler) HandlerStream(rawLine *[]byte, dataChan chan
 	usage := ConvertOpenAIUsage(geminiResponse.UsageMetadata)
 
 	usage.TextBuilder = h.Usage.TextBuilder
+	usage.TextCounter = h.Usage.TextCounter
 	*h.Usage = usage
 
 	dataChan <- rawStr
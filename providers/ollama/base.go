@@ -79,3 +79,10 @@ func (p *OllamaProvider) GetRequestHeaders() (headers map[string]string) {
 
 	return headers
 }
+
+func (p *OllamaProvider) GetCapabilities(modelName string) base.Capabilities {
+	capabilities := base.DefaultCapabilities()
+	capabilities.Vision = base.CapabilityNative
+	capabilities.StreamUsage = base.CapabilityNative
+	return capabilities
+}
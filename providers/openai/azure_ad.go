@@ -0,0 +1,201 @@
+package openai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"one-api/common/cache"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/common/utils"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Azure OpenAI 渠道除 api-key 外，key 也可以是 Entra ID（AAD）凭据：
+// {"tenant_id":"","client_id":"","client_secret":""} 使用客户端凭据，{"managed_identity":true,"client_id":""} 使用托管标识，
+// client_id 为空时使用系统分配的标识，签发的 bearer token 按渠道缓存到过期前
+const (
+	azureADTokenCacheKey  = "api_token:azure_ad"
+	azureADScope          = "https://cognitiveservices.azure.com/.default"
+	azureADResource       = "https://cognitiveservices.azure.com/"
+	azureADAuthorityHost  = "https://login.microsoftonline.com"
+	azureIMDSEndpoint     = "http://169.254.169.254/metadata/identity/oauth2/token"
+	azureADTokenTimeout   = 30 * time.Second
+	azureADExpiryLeadTime = 5 * time.Minute // 提前刷新，避免请求途中过期
+)
+
+type AzureADCredentials struct {
+	TenantId        string `json:"tenant_id"`
+	ClientId        string `json:"client_id"`
+	ClientSecret    string `json:"client_secret"`
+	ManagedIdentity bool   `json:"managed_identity"`
+	// 私有云等使用的登录地址，为空时为 https://login.microsoftonline.com
+	AuthorityHost string `json:"authority_host,omitempty"`
+}
+
+// ParseAzureADCredentials key 为 JSON 对象时解析为 Entra ID 凭据，否则返回 nil 表示使用 api-key
+func ParseAzureADCredentials(key string) (*AzureADCredentials, error) {
+	key = strings.TrimSpace(key)
+	if !strings.HasPrefix(key, "{") {
+		return nil, nil
+	}
+
+	creds := &AzureADCredentials{}
+	if err := json.Unmarshal([]byte(key), creds); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal azure ad credentials: %w", err)
+	}
+	if !creds.ManagedIdentity && (creds.TenantId == "" || creds.ClientId == "" || creds.ClientSecret == "") {
+		return nil, errors.New("azure ad credentials require tenant_id, client_id and client_secret, or managed_identity")
+	}
+	return creds, nil
+}
+
+type azureADTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	// 客户端凭据返回数字，托管标识返回字符串
+	ExpiresIn json.Number `json:"expires_in"`
+	ExpiresOn json.Number `json:"expires_on"`
+	Error     string      `json:"error"`
+	ErrorDesc string      `json:"error_description"`
+}
+
+// setAzureAuthHeaders 按 key 的类型设置 api-key 或 Entra ID 的 bearer token
+func (p *OpenAIProvider) setAzureAuthHeaders(headers map[string]string) {
+	creds, err := ParseAzureADCredentials(p.Channel.Key)
+	if err != nil {
+		logger.SysError(fmt.Sprintf("channel %d: %s", p.Channel.Id, err.Error()))
+		return
+	}
+	if creds == nil {
+		headers["api-key"] = p.Channel.Key
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", p.Channel.Key)
+		return
+	}
+
+	token, err := p.GetAzureADToken(creds)
+	if err != nil {
+		logger.SysError(fmt.Sprintf("failed to get azure ad token of channel %d: %s", p.Channel.Id, err.Error()))
+		return
+	}
+	headers["Authorization"] = "Bearer " + token
+}
+
+// GetAzureADToken 获取缓存的 token，没有时重新签发
+func (p *OpenAIProvider) GetAzureADToken(creds *AzureADCredentials) (string, error) {
+	// 凭据修改后使用新的缓存
+	digest := sha256.Sum256([]byte(p.Channel.Key))
+	cacheKey := fmt.Sprintf("%s:%d:%s", azureADTokenCacheKey, p.Channel.Id, hex.EncodeToString(digest[:8]))
+	token, err := cache.GetCache[string](cacheKey)
+	if err != nil && !errors.Is(err, cache.CacheNotFound) {
+		logger.SysError("Failed to get token from cache: " + err.Error())
+	}
+	if token != "" {
+		return token, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), azureADTokenTimeout)
+	defer cancel()
+
+	var req *http.Request
+	if creds.ManagedIdentity {
+		// 标识端点在本机网络内，不经过渠道代理
+		req, err = newManagedIdentityRequest(ctx, creds.ClientId)
+	} else {
+		req, err = newClientCredentialsRequest(utils.SetProxy(*p.Channel.Proxy, ctx), creds)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := requester.GetHTTPClient(p.Channel.GetHTTPConfig()).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", err
+	}
+	var tokenResp azureADTokenResponse
+	if err := json.Unmarshal(body, &tokenResp); err != nil {
+		return "", fmt.Errorf("unexpected token response with status code %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || tokenResp.AccessToken == "" {
+		return "", fmt.Errorf("failed to get token with status code %d: %s %s", resp.StatusCode, tokenResp.Error, tokenResp.ErrorDesc)
+	}
+
+	if ttl := tokenResp.ttl() - azureADExpiryLeadTime; ttl > 0 {
+		cache.SetCache(cacheKey, tokenResp.AccessToken, ttl)
+	}
+
+	return tokenResp.AccessToken, nil
+}
+
+// ttl token 的有效期，expires_on 为 Unix 时间戳
+func (r *azureADTokenResponse) ttl() time.Duration {
+	if expiresIn, err := strconv.ParseInt(r.ExpiresIn.String(), 10, 64); err == nil && expiresIn > 0 {
+		return time.Duration(expiresIn) * time.Second
+	}
+	if expiresOn, err := strconv.ParseInt(r.ExpiresOn.String(), 10, 64); err == nil && expiresOn > 0 {
+		return time.Until(time.Unix(expiresOn, 0))
+	}
+	return 0
+}
+
+func newClientCredentialsRequest(ctx context.Context, creds *AzureADCredentials) (*http.Request, error) {
+	authorityHost := strings.TrimSuffix(creds.AuthorityHost, "/")
+	if authorityHost == "" {
+		authorityHost = azureADAuthorityHost
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {creds.ClientId},
+		"client_secret": {creds.ClientSecret},
+		"scope":         {azureADScope},
+	}
+
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", authorityHost, url.PathEscape(creds.TenantId))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// newManagedIdentityRequest App Service 和 Container Apps 通过环境变量提供标识端点，其它环境使用 IMDS
+func newManagedIdentityRequest(ctx context.Context, clientId string) (*http.Request, error) {
+	query := url.Values{"resource": {azureADResource}}
+	if clientId != "" {
+		query.Set("client_id", clientId)
+	}
+
+	endpoint, identityHeader := os.Getenv("IDENTITY_ENDPOINT"), os.Getenv("IDENTITY_HEADER")
+	if endpoint != "" && identityHeader != "" {
+		query.Set("api-version", "2019-08-01")
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-IDENTITY-HEADER", identityHeader)
+		return req, nil
+	}
+
+	query.Set("api-version", "2018-02-01")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, azureIMDSEndpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+	return req, nil
+}
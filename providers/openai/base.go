@@ -7,8 +7,10 @@ import (
 	"one-api/common"
 	"one-api/common/config"
 	"one-api/common/requester"
+	"one-api/common/utils"
 	"one-api/model"
 	"one-api/types"
+	"slices"
 	"strings"
 
 	"one-api/providers/base"
@@ -178,8 +180,7 @@ func (p *OpenAIProvider) GetRequestHeaders() (headers map[string]string) {
 	headers = make(map[string]string)
 	p.CommonRequestHeaders(headers)
 	if p.IsAzure {
-		headers["api-key"] = p.Channel.Key
-		headers["Authorization"] = fmt.Sprintf("Bearer %s", p.Channel.Key)
+		p.setAzureAuthHeaders(headers)
 	} else {
 		headers["Authorization"] = fmt.Sprintf("Bearer %s", p.Channel.Key)
 	}
@@ -228,7 +229,7 @@ func (p *OpenAIProvider) mergeCustomParams(requestMap map[string]interface{}, cu
 	// 添加额外参数
 	for key, value := range customParamsModel {
 		// 忽略 keys "stream", "overwrite", and "per_model"
-		if key == "stream" || key == "overwrite" || key == "per_model" || key == "pre_add" {
+		if key == "stream" || key == "overwrite" || key == "per_model" || key == "pre_add" || key == "forward_fields" {
 			continue
 		}
 		// 根据覆盖设置决定如何添加参数
@@ -246,6 +247,43 @@ func (p *OpenAIProvider) mergeCustomParams(requestMap map[string]interface{}, cu
 	return requestMap
 }
 
+// forwardedExtraFields 按渠道策略返回需要透传的未定义字段
+// OpenAI 和 Azure 渠道默认透传全部字段，其它渠道默认不透传，
+// 额外参数中的 forward_fields 可以覆盖默认策略，["*"] 表示全部，[] 表示不透传
+func (p *OpenAIProvider) forwardedExtraFields(customParams map[string]interface{}) map[string]json.RawMessage {
+	if p.Context == nil {
+		return nil
+	}
+	fields, ok := utils.GetGinValue[map[string]json.RawMessage](p.Context, config.GinExtraFieldsKey)
+	if !ok || len(fields) == 0 {
+		return nil
+	}
+
+	allowed := make([]string, 0)
+	if p.Channel.Type == config.ChannelTypeOpenAI || p.Channel.Type == config.ChannelTypeAzure {
+		allowed = append(allowed, "*")
+	}
+	if list, exists := customParams["forward_fields"].([]interface{}); exists {
+		allowed = make([]string, 0, len(list))
+		for _, item := range list {
+			if name, ok := item.(string); ok {
+				allowed = append(allowed, name)
+			}
+		}
+	}
+
+	if slices.Contains(allowed, "*") {
+		return fields
+	}
+	forwarded := make(map[string]json.RawMessage)
+	for _, name := range allowed {
+		if value, ok := fields[name]; ok {
+			forwarded[name] = value
+		}
+	}
+	return forwarded
+}
+
 // 修改GetRequestTextBody函数中的对应部分
 func (p *OpenAIProvider) GetRequestTextBody(relayMode int, ModelName string, request any) (*http.Request, *types.OpenAIErrorWithStatusCode) {
 	url, errWithCode := p.GetSupportedAPIUri(relayMode)
@@ -263,8 +301,9 @@ func (p *OpenAIProvider) GetRequestTextBody(relayMode int, ModelName string, req
 	if err != nil {
 		return nil, common.ErrorWrapper(err, "custom_parameter_error", http.StatusInternalServerError)
 	}
+	extraFields := p.forwardedExtraFields(customParams)
 	// 如果有额外参数，将其添加到请求体中
-	if customParams != nil {
+	if customParams != nil || len(extraFields) > 0 {
 		// 将请求体转换为map，以便添加额外参数
 		var requestMap map[string]interface{}
 		requestBytes, err := json.Marshal(request)
@@ -279,6 +318,11 @@ func (p *OpenAIProvider) GetRequestTextBody(relayMode int, ModelName string, req
 
 		// 处理自定义额外参数
 		requestMap = p.mergeCustomParams(requestMap, customParams)
+		for key, value := range extraFields {
+			if _, exists := requestMap[key]; !exists {
+				requestMap[key] = value
+			}
+		}
 
 		// 使用修改后的请求体创建请求
 		req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(requestMap), p.Requester.WithHeader(headers))
@@ -297,3 +341,19 @@ func (p *OpenAIProvider) GetRequestTextBody(relayMode int, ModelName string, req
 
 	return req, nil
 }
+
+// GetCapabilities 请求参数原样转发，上游不支持 stream_options 时流式响应的 usage 由中转计算
+func (p *OpenAIProvider) GetCapabilities(modelName string) base.Capabilities {
+	streamUsage := base.CapabilityEmulated
+	if p.SupportStreamOptions {
+		streamUsage = base.CapabilityNative
+	}
+
+	return base.Capabilities{
+		Tools:       base.CapabilityNative,
+		Vision:      base.CapabilityNative,
+		JSONMode:    base.CapabilityNative,
+		Logprobs:    base.CapabilityNative,
+		StreamUsage: streamUsage,
+	}
+}
@@ -119,9 +119,72 @@ func (p *OpenAIProvider) CreateChatCompletionStream(request *types.ChatCompletio
 		UsageHandler: p.UsageHandler,
 	}
 
+	// 上游会返回 usage 且无需转换时，直接透传 SSE 数据
+	if p.SupportStreamOptions && !chatHandler.EscapeJSON && !p.ReasoningHandler && p.UsageHandler == nil && config.StreamPassthroughEnabled {
+		return requester.RequestPassthroughStream(p.Requester, resp, chatHandler.HandlerChatStream, chatHandler.InspectChatStream)
+	}
+
 	return requester.RequestStream(p.Requester, resp, chatHandler.HandlerChatStream)
 }
 
+var (
+	streamDataPrefix  = []byte("data:")
+	streamDonePayload = []byte("[DONE]")
+	streamErrorMarker = []byte(`"error"`)
+	// 每个数据块都可能带有 "usage":null，只有 usage 为对象时才需要解析
+	streamUsageMarkers = [][]byte{[]byte(`"usage":{`), []byte(`"usage": {`)}
+)
+
+// InspectChatStream 透传模式下检查每一行数据
+// 只有包含 usage 或 error 的行才会解析，其余数据原样透传
+func (h *OpenAIStreamHandler) InspectChatStream(line []byte) (bool, error) {
+	if !bytes.HasPrefix(line, streamDataPrefix) {
+		return true, nil
+	}
+
+	payload := bytes.TrimSpace(line[len(streamDataPrefix):])
+	if bytes.Equal(payload, streamDonePayload) {
+		return false, io.EOF
+	}
+
+	if !bytes.Contains(payload, streamErrorMarker) && !containsAny(payload, streamUsageMarkers) {
+		return true, nil
+	}
+
+	var openaiResponse OpenAIProviderChatStreamResponse
+	if err := json.Unmarshal(payload, &openaiResponse); err != nil {
+		return false, common.ErrorToOpenAIError(err)
+	}
+
+	if aiError := ErrorHandle(&openaiResponse.OpenAIErrorResponse); aiError != nil {
+		return false, aiError
+	}
+
+	if openaiResponse.Usage != nil && openaiResponse.Usage.CompletionTokens > 0 {
+		*h.Usage = *openaiResponse.Usage
+		if h.ExtraBilling != nil {
+			h.Usage.ExtraBilling = h.ExtraBilling
+		}
+	}
+
+	// 单独返回 usage 的数据块由中转统一处理，与非透传模式保持一致
+	if openaiResponse.Usage != nil && len(openaiResponse.Choices) == 0 {
+		return false, nil
+	}
+
+	return true, nil
+}
+
+func containsAny(data []byte, markers [][]byte) bool {
+	for _, marker := range markers {
+		if bytes.Contains(data, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (h *OpenAIStreamHandler) HandlerChatStream(rawLine *[]byte, dataChan chan string, errChan chan error) {
 	// 如果rawLine 前缀不为data:，则直接返回
 	if !strings.HasPrefix(string(*rawLine), "data:") {
@@ -184,7 +247,7 @@ func (h *OpenAIStreamHandler) HandlerChatStream(rawLine *[]byte, dataChan chan s
 			if h.Usage.TotalTokens == 0 {
 				h.Usage.TotalTokens = h.Usage.PromptTokens
 			}
-			h.Usage.TextBuilder.WriteString(openaiResponse.GetResponseText())
+			h.Usage.AppendText(openaiResponse.GetResponseText())
 		}
 	}
 
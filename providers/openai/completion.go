@@ -118,6 +118,6 @@ func (h *OpenAIStreamHandler) handlerCompletionStream(rawLine *[]byte, dataChan
 		h.Usage.TotalTokens = h.Usage.PromptTokens
 	}
 
-	h.Usage.TextBuilder.WriteString(openaiResponse.getResponseText())
+	h.Usage.AppendText(openaiResponse.getResponseText())
 
 }
@@ -24,7 +24,11 @@ func (p *OpenAIProvider) CreateChatRealtime(modelName string) (*websocket.Conn,
 	// 获取请求头
 	httpHeaders := make(http.Header)
 	if p.IsAzure {
-		httpHeaders.Set("api-key", p.Channel.Key)
+		headers := make(map[string]string)
+		p.setAzureAuthHeaders(headers)
+		for key, value := range headers {
+			httpHeaders.Set(key, value)
+		}
 	} else {
 		httpHeaders.Set("Authorization", fmt.Sprintf("Bearer %s", p.Channel.Key))
 	}
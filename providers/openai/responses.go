@@ -116,7 +116,7 @@ func (h *OpenAIResponsesStreamHandler) HandlerResponsesStream(rawLine *[]byte, d
 	case "response.output_text.delta":
 		delta, ok := openaiResponse.Delta.(string)
 		if ok {
-			h.Usage.TextBuilder.WriteString(delta)
+			h.Usage.AppendText(delta)
 		}
 	case "response.output_item.added":
 		if openaiResponse.Item != nil {
@@ -196,7 +196,7 @@ func (h *OpenAIResponsesStreamHandler) HandlerChatStream(rawLine *[]byte, dataCh
 	case "response.output_text.delta": // 处理文本输出的增量
 		delta, ok := openaiResponse.Delta.(string)
 		if ok {
-			h.Usage.TextBuilder.WriteString(delta)
+			h.Usage.AppendText(delta)
 		}
 		chatRes.Choices = append(chatRes.Choices, types.ChatCompletionStreamChoice{
 			Index: 0,
@@ -208,7 +208,7 @@ func (h *OpenAIResponsesStreamHandler) HandlerChatStream(rawLine *[]byte, dataCh
 	case "response.reasoning_summary_text.delta": // 处理文本输出的增量
 		delta, ok := openaiResponse.Delta.(string)
 		if ok {
-			h.Usage.TextBuilder.WriteString(delta)
+			h.Usage.AppendText(delta)
 		}
 		chatRes.Choices = append(chatRes.Choices, types.ChatCompletionStreamChoice{
 			Index: 0,
@@ -220,7 +220,7 @@ func (h *OpenAIResponsesStreamHandler) HandlerChatStream(rawLine *[]byte, dataCh
 	case "response.function_call_arguments.delta": // 处理函数调用参数的增量
 		delta, ok := openaiResponse.Delta.(string)
 		if ok {
-			h.Usage.TextBuilder.WriteString(delta)
+			h.Usage.AppendText(delta)
 		}
 		chatRes.Choices = append(chatRes.Choices, types.ChatCompletionStreamChoice{
 			Index: 0,
@@ -189,6 +189,6 @@ func (h *palmStreamHandler) convertToOpenaiStream(palmChatResponse *PaLMChatResp
 	responseBody, _ := json.Marshal(streamResponse)
 	dataChan <- string(responseBody)
 
-	h.Usage.TextBuilder.WriteString(palmChatResponse.Candidates[0].Content)
+	h.Usage.AppendText(palmChatResponse.Candidates[0].Content)
 
 }
@@ -1,7 +1,12 @@
 package providers
 
 import (
+	"context"
+	"fmt"
 	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/common/secrets"
 	"one-api/model"
 	"one-api/providers/ali"
 	"one-api/providers/azure"
@@ -98,6 +103,18 @@ func init() {
 
 // 获取供应商
 func GetProvider(channel *model.Channel, c *gin.Context) base.ProviderInterface {
+	// key 引用外部密钥时，使用解析后的副本创建供应商，缓存中的渠道保持引用不变，无法解密的 key 不使用
+	if secrets.IsReference(channel.Key) || channel.KeyDecryptFailed() {
+		key, err := channel.ResolveKey()
+		if err != nil {
+			logger.SysError(fmt.Sprintf("failed to resolve key of channel %d: %s", channel.Id, err.Error()))
+			return nil
+		}
+		resolved := *channel
+		resolved.Key = key
+		channel = &resolved
+	}
+
 	factory, ok := providerFactories[channel.Type]
 	var provider base.ProviderInterface
 	if !ok {
@@ -113,5 +130,17 @@ func GetProvider(channel *model.Channel, c *gin.Context) base.ProviderInterface
 	}
 	provider.SetContext(c)
 
+	if r := provider.GetRequester(); r != nil {
+		r.HTTPClient = requester.GetHTTPClient(channel.GetHTTPConfig())
+		r.ExtraHeaders = channel.GetModelHeaders()
+		r.ExtraBody = channel.GetExtraBody()
+		// 使用客户端请求的上下文，客户端断开后上游请求随之取消
+		if c != nil {
+			if ctx, ok := c.Value(config.GinUpstreamContextKey).(context.Context); ok {
+				r.Context = ctx
+			}
+		}
+	}
+
 	return provider
 }
@@ -1,40 +1,77 @@
 package tencent
 
 import (
-	"crypto/hmac"
-	"crypto/sha1"
-	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
 	"one-api/common/requester"
+	"one-api/common/signing"
 	"one-api/model"
 	"one-api/providers/base"
+	"one-api/providers/openai"
 	"one-api/types"
-	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type TencentProviderFactory struct{}
 
+// 渠道的 other 字段选择接口，为空时使用旧版 hyllm 接口
+const (
+	TencentAPIOpenAI = "openai" // OpenAI 兼容接口，key 为 API Key
+	TencentAPIV3     = "v3"     // 腾讯云 API 3.0，使用签名方法 v3，key 为 SecretId|SecretKey
+)
+
 // 创建 TencentProvider
 func (f TencentProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
-	return &TencentProvider{
-		BaseProvider: base.BaseProvider{
-			Config:    getConfig(),
-			Channel:   channel,
-			Requester: requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle),
+	provider := &TencentProvider{
+		OpenAIProvider: openai.OpenAIProvider{
+			BaseProvider: base.BaseProvider{
+				Config:  getConfig(channel.Other),
+				Channel: channel,
+			},
+			SupportStreamOptions: true,
 		},
+		APIMode: channel.Other,
+	}
+
+	switch provider.APIMode {
+	case TencentAPIOpenAI:
+		provider.Requester = requester.NewHTTPRequester(*channel.Proxy, openai.RequestErrorHandle)
+	case TencentAPIV3:
+		provider.Requester = requester.NewHTTPRequester(*channel.Proxy, requestErrorHandleV3)
+	default:
+		provider.Requester = requester.NewHTTPRequester(*channel.Proxy, requestErrorHandle)
 	}
+
+	return provider
 }
 
 type TencentProvider struct {
-	base.BaseProvider
+	openai.OpenAIProvider
+
+	APIMode string
 }
 
-func getConfig() base.ProviderConfig {
+func getConfig(apiMode string) base.ProviderConfig {
+	switch apiMode {
+	case TencentAPIOpenAI:
+		// https://cloud.tencent.com/document/product/1729/111007
+		return base.ProviderConfig{
+			BaseURL:         "https://api.hunyuan.cloud.tencent.com",
+			ChatCompletions: "/v1/chat/completions",
+			Embeddings:      "/v1/embeddings",
+		}
+	case TencentAPIV3:
+		// https://cloud.tencent.com/document/api/1729/105701
+		return base.ProviderConfig{
+			BaseURL:         "https://hunyuan.tencentcloudapi.com",
+			ChatCompletions: "/",
+		}
+	}
+
 	return base.ProviderConfig{
 		BaseURL:         "https://hunyuan.cloud.tencent.com",
 		ChatCompletions: "/hyllm/v1/chat/completions",
@@ -68,53 +105,71 @@ func errorHandle(tencentError *TencentResponseError) *types.OpenAIError {
 func (p *TencentProvider) GetRequestHeaders() (headers map[string]string) {
 	headers = make(map[string]string)
 	p.CommonRequestHeaders(headers)
+	if p.APIMode == TencentAPIOpenAI {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", p.Channel.Key)
+	}
 
 	return headers
 }
 
-func (p *TencentProvider) parseTencentConfig(config string) (appId int64, secretId string, secretKey string, err error) {
-	parts := strings.Split(config, "|")
-	if len(parts) != 3 {
-		err = errors.New("invalid tencent config")
-		return
-	}
-	appId, err = strconv.ParseInt(parts[0], 10, 64)
-	secretId = parts[1]
-	secretKey = parts[2]
-	return
-}
-
+// 按请求参数生成签名，同时填充 AppId 和 SecretId
 func (p *TencentProvider) getTencentSign(req *TencentChatRequest) string {
-	apiKey := p.Channel.Key
-	appId, secretId, secretKey, err := p.parseTencentConfig(apiKey)
+	cred, err := signing.ParseTencentKey(p.Channel.Key)
 	if err != nil {
 		return ""
 	}
-	req.AppId = appId
-	req.SecretId = secretId
-
-	params := make([]string, 0)
-	params = append(params, "app_id="+strconv.FormatInt(req.AppId, 10))
-	params = append(params, "secret_id="+req.SecretId)
-	params = append(params, "timestamp="+strconv.FormatInt(req.Timestamp, 10))
-	params = append(params, "query_id="+req.QueryID)
-	params = append(params, "temperature="+strconv.FormatFloat(req.Temperature, 'f', -1, 64))
-	params = append(params, "top_p="+strconv.FormatFloat(req.TopP, 'f', -1, 64))
-	params = append(params, "stream="+strconv.Itoa(req.Stream))
-	params = append(params, "expired="+strconv.FormatInt(req.Expired, 10))
+	req.AppId = cred.AppId
+	req.SecretId = cred.SecretId
 
 	var messageStr string
 	for _, msg := range req.Messages {
 		messageStr += fmt.Sprintf(`{"role":"%s","content":"%s"},`, msg.Role, msg.Content)
 	}
 	messageStr = strings.TrimSuffix(messageStr, ",")
-	params = append(params, "messages=["+messageStr+"]")
-
-	sort.Strings(params)
-	url := "hunyuan.cloud.tencent.com/hyllm/v1/chat/completions?" + strings.Join(params, "&")
-	mac := hmac.New(sha1.New, []byte(secretKey))
-	signURL := url
-	mac.Write([]byte(signURL))
-	sign := mac.Sum([]byte(nil))
-	return base64.StdEncoding.EncodeToString(sign)
+
+	return cred.Sign(map[string]string{
+		"app_id":      strconv.FormatInt(req.AppId, 10),
+		"secret_id":   req.SecretId,
+		"timestamp":   strconv.FormatInt(req.Timestamp, 10),
+		"query_id":    req.QueryID,
+		"temperature": strconv.FormatFloat(req.Temperature, 'f', -1, 64),
+		"top_p":       strconv.FormatFloat(req.TopP, 'f', -1, 64),
+		"stream":      strconv.Itoa(req.Stream),
+		"expired":     strconv.FormatInt(req.Expired, 10),
+		"messages":    "[" + messageStr + "]",
+	})
+}
+
+// ValidateCredentials 解析密钥并生成一次签名，不请求上游
+func (p *TencentProvider) ValidateCredentials() error {
+	switch p.APIMode {
+	case TencentAPIOpenAI:
+		if p.Channel.Key == "" || strings.Contains(p.Channel.Key, "|") {
+			return errors.New("invalid tencent api key, the OpenAI compatible API uses the API Key instead of SecretId|SecretKey")
+		}
+		return nil
+	case TencentAPIV3:
+		cred, err := signing.ParseTencentCloudKey(p.Channel.Key)
+		if err != nil {
+			return err
+		}
+		cred.Authorization(tencentV3Service, tencentV3Host, tencentV3ContentType, tencentV3Action, []byte("{}"), time.Now().Unix())
+		return nil
+	}
+
+	cred, err := signing.ParseTencentKey(p.Channel.Key)
+	if err != nil {
+		return err
+	}
+	if cred.Sign(map[string]string{"app_id": strconv.FormatInt(cred.AppId, 10)}) == "" {
+		return errors.New("get tencent sign failed")
+	}
+	return nil
+}
+
+func (p *TencentProvider) GetCapabilities(modelName string) base.Capabilities {
+	if p.APIMode == TencentAPIOpenAI {
+		return p.OpenAIProvider.GetCapabilities(modelName)
+	}
+	return base.DefaultCapabilities()
 }
@@ -18,6 +18,13 @@ type tencentStreamHandler struct {
 }
 
 func (p *TencentProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	switch p.APIMode {
+	case TencentAPIOpenAI:
+		return p.OpenAIProvider.CreateChatCompletion(request)
+	case TencentAPIV3:
+		return p.createV3ChatCompletion(request)
+	}
+
 	req, errWithCode := p.getChatRequest(request)
 	if errWithCode != nil {
 		return nil, errWithCode
@@ -35,6 +42,13 @@ func (p *TencentProvider) CreateChatCompletion(request *types.ChatCompletionRequ
 }
 
 func (p *TencentProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	switch p.APIMode {
+	case TencentAPIOpenAI:
+		return p.OpenAIProvider.CreateChatCompletionStream(request)
+	case TencentAPIV3:
+		return p.createV3ChatCompletionStream(request)
+	}
+
 	req, errWithCode := p.getChatRequest(request)
 	if errWithCode != nil {
 		return nil, errWithCode
@@ -200,6 +214,6 @@ func (h *tencentStreamHandler) convertToOpenaiStream(tencentChatResponse *Tencen
 	responseBody, _ := json.Marshal(streamResponse)
 	dataChan <- string(responseBody)
 
-	h.Usage.TextBuilder.WriteString(tencentChatResponse.Choices[0].Delta.Content)
+	h.Usage.AppendText(tencentChatResponse.Choices[0].Delta.Content)
 
 }
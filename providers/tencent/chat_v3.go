@@ -0,0 +1,239 @@
+package tencent
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/requester"
+	"one-api/common/signing"
+	"one-api/types"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tencentV3Service     = "hunyuan"
+	tencentV3Host        = "hunyuan.tencentcloudapi.com"
+	tencentV3Action      = "ChatCompletions"
+	tencentV3Version     = "2023-09-01"
+	tencentV3ContentType = "application/json"
+)
+
+type tencentV3StreamHandler struct {
+	Usage   *types.Usage
+	Request *types.ChatCompletionRequest
+}
+
+// 腾讯云 API 3.0 的错误在响应体的 Response.Error 中，HTTP 状态码为 200
+func requestErrorHandleV3(resp *http.Response) *types.OpenAIError {
+	response := &TencentV3Response{}
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return nil
+	}
+
+	return errorHandleV3(response.Response.Error)
+}
+
+func errorHandleV3(tencentError *TencentV3Error) *types.OpenAIError {
+	if tencentError == nil || tencentError.Code == "" {
+		return nil
+	}
+	return &types.OpenAIError{
+		Message: tencentError.Message,
+		Type:    "tencent_error",
+		Code:    tencentError.Code,
+	}
+}
+
+func (p *TencentProvider) createV3ChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.getV3ChatRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	tencentResponse := &TencentV3Response{}
+	_, errWithCode = p.Requester.SendRequest(req, tencentResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	response := &tencentResponse.Response
+	if aiError := errorHandleV3(response.Error); aiError != nil {
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: *aiError,
+			StatusCode:  http.StatusBadRequest,
+		}
+	}
+
+	openaiResponse := &types.ChatCompletionResponse{
+		ID:      response.Id,
+		Object:  "chat.completion",
+		Created: response.Created,
+		Model:   request.Model,
+		Choices: make([]types.ChatCompletionChoice, 0, len(response.Choices)),
+	}
+	for i, choice := range response.Choices {
+		openaiResponse.Choices = append(openaiResponse.Choices, types.ChatCompletionChoice{
+			Index: i,
+			Message: types.ChatCompletionMessage{
+				Role:    types.ChatMessageRoleAssistant,
+				Content: choice.Message.Content,
+			},
+			FinishReason: choice.FinishReason,
+		})
+	}
+
+	response.Usage.setUsage(p.Usage)
+	openaiResponse.Usage = p.Usage
+
+	return openaiResponse, nil
+}
+
+func (p *TencentProvider) createV3ChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.getV3ChatRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	resp, errWithCode := p.Requester.SendRequestRaw(req)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	chatHandler := &tencentV3StreamHandler{
+		Usage:   p.Usage,
+		Request: request,
+	}
+
+	return requester.RequestStream[string](p.Requester, resp, chatHandler.handlerStream)
+}
+
+// getV3ChatRequest 签名需要使用实际发送的请求体
+func (p *TencentProvider) getV3ChatRequest(request *types.ChatCompletionRequest) (*http.Request, *types.OpenAIErrorWithStatusCode) {
+	uri, errWithCode := p.GetSupportedAPIUri(config.RelayModeChatCompletions)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	fullRequestURL := p.GetFullRequestURL(uri, request.Model)
+	parsedURL, err := url.Parse(fullRequestURL)
+	if err != nil || parsedURL.Host == "" {
+		return nil, common.ErrorWrapper(nil, "invalid_tencent_config", http.StatusInternalServerError)
+	}
+
+	cred, err := signing.ParseTencentCloudKey(p.Channel.Key)
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "invalid_tencent_config", http.StatusInternalServerError)
+	}
+
+	body, err := json.Marshal(convertFromChatOpenaiV3(request))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "marshal_request_failed", http.StatusInternalServerError)
+	}
+
+	timestamp := time.Now().Unix()
+	headers := p.GetRequestHeaders()
+	headers["Content-Type"] = tencentV3ContentType
+	headers["Authorization"] = cred.Authorization(tencentV3Service, parsedURL.Host, tencentV3ContentType, tencentV3Action, body, timestamp)
+	headers["X-TC-Action"] = tencentV3Action
+	headers["X-TC-Version"] = tencentV3Version
+	headers["X-TC-Timestamp"] = strconv.FormatInt(timestamp, 10)
+	if request.Stream {
+		headers["Accept"] = "text/event-stream"
+	}
+
+	req, err := p.Requester.NewRequest(http.MethodPost, fullRequestURL, p.Requester.WithBody(body), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+
+	return req, nil
+}
+
+func convertFromChatOpenaiV3(request *types.ChatCompletionRequest) *TencentV3ChatRequest {
+	messages := make([]TencentV3Message, 0, len(request.Messages))
+	for _, message := range request.Messages {
+		role := message.Role
+		if message.IsSystemRole() {
+			role = types.ChatMessageRoleSystem
+		}
+		messages = append(messages, TencentV3Message{
+			Role:    role,
+			Content: message.StringContent(),
+		})
+	}
+
+	return &TencentV3ChatRequest{
+		Model:       request.Model,
+		Messages:    messages,
+		Stream:      request.Stream,
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+	}
+}
+
+// handlerStream 流式响应不包含 Response 外层，出错时直接返回 JSON
+func (h *tencentV3StreamHandler) handlerStream(rawLine *[]byte, dataChan chan string, errChan chan error) {
+	if strings.HasPrefix(string(*rawLine), "{") {
+		response := &TencentV3Response{}
+		if err := json.Unmarshal(*rawLine, response); err == nil {
+			if aiError := errorHandleV3(response.Response.Error); aiError != nil {
+				errChan <- aiError
+				return
+			}
+		}
+		*rawLine = nil
+		return
+	}
+
+	if !strings.HasPrefix(string(*rawLine), "data:") {
+		*rawLine = nil
+		return
+	}
+
+	*rawLine = (*rawLine)[5:]
+
+	var response TencentV3ChatResponse
+	if err := json.Unmarshal(*rawLine, &response); err != nil {
+		errChan <- common.ErrorToOpenAIError(err)
+		return
+	}
+
+	if aiError := errorHandleV3(response.Error); aiError != nil {
+		errChan <- aiError
+		return
+	}
+
+	streamResponse := types.ChatCompletionStreamResponse{
+		ID:      response.Id,
+		Object:  "chat.completion.chunk",
+		Created: response.Created,
+		Model:   h.Request.Model,
+	}
+	for i, choice := range response.Choices {
+		streamChoice := types.ChatCompletionStreamChoice{
+			Index: i,
+			Delta: types.ChatCompletionStreamChoiceDelta{
+				Role:    types.ChatMessageRoleAssistant,
+				Content: choice.Delta.Content,
+			},
+		}
+		if choice.FinishReason != "" {
+			streamChoice.FinishReason = choice.FinishReason
+		}
+		streamResponse.Choices = append(streamResponse.Choices, streamChoice)
+		h.Usage.AppendText(choice.Delta.Content)
+	}
+
+	// 每个分块都包含累计的用量
+	if response.Usage.TotalTokens > 0 {
+		response.Usage.setUsage(h.Usage)
+	}
+
+	responseBody, _ := json.Marshal(streamResponse)
+	dataChan <- string(responseBody)
+}
@@ -64,3 +64,53 @@ type TencentChatResponse struct {
 	Model   string                   `json:"model,omitempty"`   // 模型名称
 	TencentResponseError
 }
+
+// 腾讯云 API 3.0 的字段名为大驼峰
+type TencentV3Message struct {
+	Role    string `json:"Role"`
+	Content string `json:"Content"`
+}
+
+type TencentV3ChatRequest struct {
+	Model       string             `json:"Model"`
+	Messages    []TencentV3Message `json:"Messages"`
+	Stream      bool               `json:"Stream,omitempty"`
+	Temperature *float64           `json:"Temperature,omitempty"`
+	TopP        *float64           `json:"TopP,omitempty"`
+}
+
+type TencentV3Usage struct {
+	PromptTokens     int `json:"PromptTokens"`
+	CompletionTokens int `json:"CompletionTokens"`
+	TotalTokens      int `json:"TotalTokens"`
+}
+
+func (u *TencentV3Usage) setUsage(usage *types.Usage) {
+	usage.PromptTokens = u.PromptTokens
+	usage.CompletionTokens = u.CompletionTokens
+	usage.TotalTokens = u.TotalTokens
+}
+
+type TencentV3Choice struct {
+	FinishReason string           `json:"FinishReason"`
+	Message      TencentV3Message `json:"Message"`
+	Delta        TencentV3Message `json:"Delta"`
+}
+
+type TencentV3Error struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+}
+
+type TencentV3ChatResponse struct {
+	Id        string            `json:"Id"`
+	Created   int64             `json:"Created"`
+	Choices   []TencentV3Choice `json:"Choices"`
+	Usage     TencentV3Usage    `json:"Usage"`
+	Error     *TencentV3Error   `json:"Error,omitempty"`
+	RequestId string            `json:"RequestId"`
+}
+
+type TencentV3Response struct {
+	Response TencentV3ChatResponse `json:"Response"`
+}
@@ -0,0 +1,40 @@
+package vertexai
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// AmbientKey key 为 ambient 时使用运行环境的凭据（GOOGLE_APPLICATION_CREDENTIALS、GKE Workload Identity、GCE 元数据服务、
+// 工作负载身份联合），渠道中不保存服务账号密钥
+const AmbientKey = "ambient"
+
+var (
+	ambientLock        sync.Mutex
+	ambientTokenSource oauth2.TokenSource
+)
+
+// getAmbientToken 查找默认凭据，找不到时下次请求重新查找，token 由 TokenSource 缓存并在过期前刷新
+func getAmbientToken() (string, error) {
+	ambientLock.Lock()
+	if ambientTokenSource == nil {
+		// 凭据刷新 token 时使用该上下文，不能设置超时
+		creds, err := google.FindDefaultCredentials(context.Background(), defaultScope)
+		if err != nil {
+			ambientLock.Unlock()
+			return "", err
+		}
+		ambientTokenSource = oauth2.ReuseTokenSource(nil, creds.TokenSource)
+	}
+	tokenSource := ambientTokenSource
+	ambientLock.Unlock()
+
+	token, err := tokenSource.Token()
+	if err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
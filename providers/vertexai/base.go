@@ -14,6 +14,8 @@ import (
 	"one-api/common/requester"
 	"one-api/model"
 	"one-api/providers/base"
+	"one-api/providers/claude"
+	"one-api/providers/gemini"
 	"one-api/providers/vertexai/category"
 	"one-api/types"
 	"strings"
@@ -98,6 +100,10 @@ func (p *VertexAIProvider) GetRequestHeaders() (headers map[string]string) {
 }
 
 func (p *VertexAIProvider) GetToken() (string, error) {
+	if strings.TrimSpace(p.Channel.Key) == AmbientKey {
+		return getAmbientToken()
+	}
+
 	cacheKey := fmt.Sprintf("%s:%s", TokenCacheKey, p.ProjectID)
 	token, err := cache.GetCache[string](cacheKey)
 	if err != nil {
@@ -269,3 +275,13 @@ func connectViaSOCKS5Proxy(ctx context.Context, dialer *net.Dialer, proxyURL *ur
 
 	return dialerProxy.Dial("tcp", addr)
 }
+
+func (p *VertexAIProvider) GetCapabilities(modelName string) base.Capabilities {
+	switch {
+	case strings.HasPrefix(modelName, "gemini"):
+		return gemini.ChatCapabilities
+	case strings.HasPrefix(modelName, "claude"):
+		return claude.ChatCapabilities
+	}
+	return base.UnsupportedCapabilities()
+}
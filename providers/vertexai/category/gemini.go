@@ -3,7 +3,6 @@ package category
 import (
 	"encoding/json"
 	"net/http"
-	"one-api/common"
 	"one-api/common/requester"
 	"one-api/providers/base"
 	"one-api/providers/gemini"
@@ -35,7 +34,7 @@ func ConvertGeminiToChatOpenai(provider base.ProviderInterface, response *http.R
 	geminiResponse := &gemini.GeminiChatResponse{}
 	err := json.NewDecoder(response.Body).Decode(geminiResponse)
 	if err != nil {
-		return nil, common.ErrorWrapper(err, "decode_response_failed", http.StatusInternalServerError)
+		return nil, requester.InvalidResponseError(err)
 	}
 
 	return gemini.ConvertToChatOpenai(provider, geminiResponse, request)
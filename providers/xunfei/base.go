@@ -1,13 +1,12 @@
 package xunfei
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"net/url"
+	"net/http"
 	"one-api/common/logger"
 	"one-api/common/requester"
+	"one-api/common/signing"
 	"one-api/model"
 	"one-api/providers/base"
 	"one-api/types"
@@ -19,22 +18,23 @@ type XunfeiProviderFactory struct{}
 
 // 创建 XunfeiProvider
 func (f XunfeiProviderFactory) Create(channel *model.Channel) base.ProviderInterface {
-	return &XunfeiProvider{
+	provider := &XunfeiProvider{
 		BaseProvider: base.BaseProvider{
 			Config:    getConfig(),
 			Channel:   channel,
 			Requester: requester.NewHTTPRequester(*channel.Proxy, nil),
 		},
-		wsRequester: requester.NewWSRequester(*channel.Proxy),
 	}
+	provider.wsBridge = requester.NewWSBridge(*channel.Proxy, provider.signURL, extractUsage)
+	return provider
 }
 
 // https://www.xfyun.cn/doc/spark/Web.html
 type XunfeiProvider struct {
 	base.BaseProvider
-	domain      string
-	apiId       string
-	wsRequester *requester.WSRequester
+	domain   string
+	apiId    string
+	wsBridge *requester.WSBridge
 }
 
 func getConfig() base.ProviderConfig {
@@ -62,18 +62,54 @@ func (p *XunfeiProvider) GetRequestHeaders() (headers map[string]string) {
 	return headers
 }
 
-// 获取完整请求 URL
+// 获取完整请求 URL，连接时由 signURL 签名
 func (p *XunfeiProvider) GetFullRequestURL(modelName string) string {
 	splits := strings.Split(p.Channel.Key, "|")
 	if len(splits) != 3 {
 		return ""
 	}
-	domain, authUrl := p.getXunfeiAuthUrl(splits[2], splits[1], modelName)
+	apiVersion := p.getAPIVersion(modelName)
 
-	p.domain = domain
+	p.domain = apiVersion2domain(apiVersion)
 	p.apiId = splits[0]
 
-	return authUrl
+	return fmt.Sprintf("%s/%s/chat", p.Config.BaseURL, apiVersion)
+}
+
+// signURL key 格式为 APPID|APISecret|APIKey
+func (p *XunfeiProvider) signURL(rawURL string) (string, http.Header, error) {
+	cred, err := signing.ParseXunfeiKey(p.Channel.Key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	authUrl, err := cred.AuthURL(rawURL, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+	return authUrl, nil, nil
+}
+
+// ValidateCredentials 解析密钥并生成鉴权地址，不建立连接
+func (p *XunfeiProvider) ValidateCredentials() error {
+	_, _, err := p.signURL(p.Config.BaseURL + "/v1.1/chat")
+	return err
+}
+
+// extractUsage 最后一条消息包含用量
+func extractUsage(message []byte, usage *types.Usage) {
+	var response XunfeiChatResponse
+	if err := json.Unmarshal(message, &response); err != nil {
+		return
+	}
+
+	textUsage := response.Payload.Usage.Text
+	if textUsage.TotalTokens == 0 {
+		return
+	}
+	usage.PromptTokens = textUsage.PromptTokens
+	usage.CompletionTokens = textUsage.CompletionTokens
+	usage.TotalTokens = textUsage.TotalTokens
 }
 
 func (p *XunfeiProvider) getAPIVersion(modelName string) string {
@@ -115,37 +151,9 @@ func apiVersion2domain(apiVersion string) string {
 	return "general" + apiVersion
 }
 
-func (p *XunfeiProvider) getXunfeiAuthUrl(apiKey string, apiSecret string, modelName string) (string, string) {
-	apiVersion := p.getAPIVersion(modelName)
-	domain := apiVersion2domain(apiVersion)
-
-	authUrl := p.buildXunfeiAuthUrl(fmt.Sprintf("%s/%s/chat", p.Config.BaseURL, apiVersion), apiKey, apiSecret)
-	return domain, authUrl
-}
-
-func (p *XunfeiProvider) buildXunfeiAuthUrl(hostUrl string, apiKey, apiSecret string) string {
-	HmacWithShaToBase64 := func(algorithm, data, key string) string {
-		mac := hmac.New(sha256.New, []byte(key))
-		mac.Write([]byte(data))
-		encodeData := mac.Sum(nil)
-		return base64.StdEncoding.EncodeToString(encodeData)
-	}
-	ul, err := url.Parse(hostUrl)
-	if err != nil {
-		logger.SysError("url parse error: " + err.Error())
-		return ""
-	}
-	date := time.Now().UTC().Format(time.RFC1123)
-	signString := []string{"host: " + ul.Host, "date: " + date, "GET " + ul.Path + " HTTP/1.1"}
-	sign := strings.Join(signString, "\n")
-	sha := HmacWithShaToBase64("hmac-sha256", sign, apiSecret)
-	authUrl := fmt.Sprintf("hmac username=\"%s\", algorithm=\"%s\", headers=\"%s\", signature=\"%s\"", apiKey,
-		"hmac-sha256", "host date request-line", sha)
-	authorization := base64.StdEncoding.EncodeToString([]byte(authUrl))
-	v := url.Values{}
-	v.Add("host", ul.Host)
-	v.Add("date", date)
-	v.Add("authorization", authorization)
-	callUrl := hostUrl + "?" + v.Encode()
-	return callUrl
+func (p *XunfeiProvider) GetCapabilities(modelName string) base.Capabilities {
+	capabilities := base.DefaultCapabilities()
+	capabilities.Tools = base.CapabilityNative
+	capabilities.StreamUsage = base.CapabilityNative
+	return capabilities
 }
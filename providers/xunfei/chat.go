@@ -12,67 +12,67 @@ import (
 	"one-api/common/utils"
 	"one-api/types"
 	"strings"
-
-	"github.com/gorilla/websocket"
 )
 
 type xunfeiHandler struct {
-	Usage   *types.Usage
 	Request *types.ChatCompletionRequest
 }
 
 func (p *XunfeiProvider) CreateChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
-	wsConn, errWithCode := p.getChatRequest(request)
+	url, errWithCode := p.getChatRequestURL(request)
 	if errWithCode != nil {
 		return nil, errWithCode
 	}
 
-	xunfeiRequest := p.convertFromChatOpenai(request)
-
 	chatHandler := &xunfeiHandler{
-		Usage:   p.Usage,
 		Request: request,
 	}
 
-	stream, errWithCode := requester.SendWSJsonRequest[XunfeiChatResponse](wsConn, xunfeiRequest, chatHandler.handlerNotStream)
+	stream, errWithCode := requester.SendWSBridgeRequest(p.wsBridge, &requester.WSBridgeRequest[XunfeiChatResponse]{
+		URL:     url,
+		Payload: p.convertFromChatOpenai(request),
+		Handler: chatHandler.handlerNotStream,
+		Usage:   p.Usage,
+	})
 	if errWithCode != nil {
 		return nil, errWithCode
 	}
+	defer stream.Close()
 
 	return chatHandler.convertToChatOpenai(stream)
 
 }
 
 func (p *XunfeiProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
-	wsConn, errWithCode := p.getChatRequest(request)
+	url, errWithCode := p.getChatRequestURL(request)
 	if errWithCode != nil {
 		return nil, errWithCode
 	}
 
-	xunfeiRequest := p.convertFromChatOpenai(request)
-
 	chatHandler := &xunfeiHandler{
-		Usage:   p.Usage,
 		Request: request,
 	}
 
-	return requester.SendWSJsonRequest[string](wsConn, xunfeiRequest, chatHandler.handlerStream)
+	return requester.SendWSBridgeRequest(p.wsBridge, &requester.WSBridgeRequest[string]{
+		URL:     url,
+		Payload: p.convertFromChatOpenai(request),
+		Handler: chatHandler.handlerStream,
+		Usage:   p.Usage,
+	})
 }
 
-func (p *XunfeiProvider) getChatRequest(request *types.ChatCompletionRequest) (*websocket.Conn, *types.OpenAIErrorWithStatusCode) {
+func (p *XunfeiProvider) getChatRequestURL(request *types.ChatCompletionRequest) (string, *types.OpenAIErrorWithStatusCode) {
 	_, errWithCode := p.GetSupportedAPIUri(config.RelayModeChatCompletions)
 	if errWithCode != nil {
-		return nil, errWithCode
+		return "", errWithCode
 	}
 
-	authUrl := p.GetFullRequestURL(request.Model)
-
-	wsConn, err := p.wsRequester.NewRequest(authUrl, nil)
-	if err != nil {
-		return nil, common.ErrorWrapper(err, "ws_request_failed", http.StatusInternalServerError)
+	url := p.GetFullRequestURL(request.Model)
+	if url == "" {
+		return "", common.StringErrorWrapperLocal("invalid xunfei key, the format should be APPID|APISecret|APIKey", "invalid_key", http.StatusInternalServerError)
 	}
 
-	return wsConn, nil
+	return url, nil
 }
 
 func (p *XunfeiProvider) convertFromChatOpenai(request *types.ChatCompletionRequest) *XunfeiChatRequest {
@@ -225,10 +225,6 @@ func (h *xunfeiHandler) handlerData(rawLine *[]byte, isFinished *bool) (*XunfeiC
 		*isFinished = true
 	}
 
-	h.Usage.PromptTokens = xunfeiChatResponse.Payload.Usage.Text.PromptTokens
-	h.Usage.CompletionTokens = xunfeiChatResponse.Payload.Usage.Text.CompletionTokens
-	h.Usage.TotalTokens = xunfeiChatResponse.Payload.Usage.Text.TotalTokens
-
 	return &xunfeiChatResponse, nil
 }
 
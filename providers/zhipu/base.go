@@ -4,22 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"one-api/common/cache"
 	"one-api/common/logger"
 	"one-api/common/requester"
+	"one-api/common/signing"
 	"one-api/model"
 	"one-api/providers/base"
 	"one-api/types"
 	"strings"
 	"time"
-
-	"github.com/golang-jwt/jwt/v5"
 )
 
-var expSeconds int64 = 24 * 3600
-
-var zhiPuCacheKey = "api_token:zhipu"
-
 type ZhipuProviderFactory struct{}
 
 // 创建 ZhipuProvider
@@ -85,49 +79,22 @@ func (p *ZhipuProvider) GetFullRequestURL(requestURL string) string {
 }
 
 func (p *ZhipuProvider) getZhipuToken() string {
-	cacheKey := fmt.Sprintf("%s:%d", zhiPuCacheKey, p.Channel.Id)
-	tokenStr, err := cache.GetCache[string](cacheKey)
+	token, err := signing.ZhipuToken(p.Channel.Key)
 	if err != nil {
 		logger.SysError("get zhipu token error: " + err.Error())
-	}
-
-	if tokenStr != "" {
-		return tokenStr
-	}
-
-	apikey := p.Channel.Key
-	split := strings.Split(apikey, ".")
-	if len(split) != 2 {
-		logger.SysError("invalid zhipu key: " + apikey)
 		return ""
 	}
+	return token
+}
 
-	id := split[0]
-	secret := split[1]
-
-	expMillis := time.Now().Add(time.Duration(expSeconds)*time.Second).UnixNano() / 1e6
-
-	timestamp := time.Now().UnixNano() / 1e6
-
-	payload := jwt.MapClaims{
-		"api_key":   id,
-		"exp":       expMillis,
-		"timestamp": timestamp,
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-
-	token.Header["alg"] = "HS256"
-	token.Header["sign_type"] = "SIGN"
-
-	tokenString, err := token.SignedString([]byte(secret))
+// ValidateCredentials 解析密钥并生成 JWT，不请求上游
+func (p *ZhipuProvider) ValidateCredentials() error {
+	cred, err := signing.ParseZhipuKey(p.Channel.Key)
 	if err != nil {
-		return ""
+		return err
 	}
-
-	cache.SetCache(cacheKey, tokenString, time.Duration(expSeconds)*time.Second)
-
-	return tokenString
+	_, err = cred.Token(time.Now(), signing.ZhipuTokenTTL)
+	return err
 }
 
 func convertRole(roleName string) string {
@@ -140,3 +107,13 @@ func convertRole(roleName string) string {
 		return types.ChatMessageRoleUser
 	}
 }
+
+func (p *ZhipuProvider) GetCapabilities(modelName string) base.Capabilities {
+	return base.Capabilities{
+		Tools:       base.CapabilityNative,
+		Vision:      base.CapabilityNative,
+		JSONMode:    base.CapabilityUnsupported,
+		Logprobs:    base.CapabilityUnsupported,
+		StreamUsage: base.CapabilityNative,
+	}
+}
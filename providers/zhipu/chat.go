@@ -23,6 +23,9 @@ func (p *ZhipuProvider) CreateChatCompletion(request *types.ChatCompletionReques
 	if request.Model == "glm-4-alltools" {
 		return nil, common.ErrorWrapper(nil, "glm-4-alltools 只能stream模式下请求", http.StatusBadRequest)
 	}
+	if p.isV3() {
+		return p.createV3ChatCompletion(request)
+	}
 
 	req, errWithCode := p.getChatRequest(request)
 	if errWithCode != nil {
@@ -41,6 +44,10 @@ func (p *ZhipuProvider) CreateChatCompletion(request *types.ChatCompletionReques
 }
 
 func (p *ZhipuProvider) CreateChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	if p.isV3() {
+		return p.createV3ChatCompletionStream(request)
+	}
+
 	req, errWithCode := p.getChatRequest(request)
 	if errWithCode != nil {
 		return nil, errWithCode
@@ -112,11 +119,23 @@ func (p *ZhipuProvider) convertToChatOpenai(response *ZhipuResponse, request *ty
 		}
 	}
 
-	*p.Usage = *response.Usage
+	p.setUsage(response.Usage, openaiResponse)
+	openaiResponse.Usage = p.Usage
 
 	return
 }
 
+// setUsage 使用上游返回的用量，没有返回时按输出内容计算
+func (p *ZhipuProvider) setUsage(usage *types.Usage, response *types.ChatCompletionResponse) {
+	if usage != nil && usage.TotalTokens > 0 {
+		*p.Usage = *usage
+		return
+	}
+
+	p.Usage.CompletionTokens = common.CountTokenText(response.GetContent(), response.Model)
+	p.Usage.TotalTokens = p.Usage.PromptTokens + p.Usage.CompletionTokens
+}
+
 func (p *ZhipuProvider) convertFromChatOpenai(request *types.ChatCompletionRequest) *ZhipuRequest {
 	for i := range request.Messages {
 		request.Messages[i].Role = convertRole(request.Messages[i].Role)
@@ -354,6 +373,6 @@ func (h *zhipuStreamHandler) convertToOpenaiStream(zhipuResponse *ZhipuStreamRes
 	if zhipuResponse.Usage != nil {
 		*h.Usage = *zhipuResponse.Usage
 	} else {
-		h.Usage.TextBuilder.WriteString(zhipuResponse.GetResponseText())
+		h.Usage.AppendText(zhipuResponse.GetResponseText())
 	}
 }
@@ -0,0 +1,243 @@
+package zhipu
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"one-api/common"
+	"one-api/common/requester"
+	"one-api/common/utils"
+	"one-api/types"
+	"strings"
+)
+
+// 旧版 v3 接口，渠道的版本号（other）填写 v3 时使用，只支持聊天，不支持工具调用
+// https://open.bigmodel.cn/dev/api#chatglm_turbo
+const (
+	ZhipuAPIVersionV3 = "v3"
+	zhipuV3BaseURL    = "https://open.bigmodel.cn/api/paas/v3"
+)
+
+type ZhipuV3Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ZhipuV3Request struct {
+	Prompt      []ZhipuV3Message `json:"prompt"`
+	Temperature float64          `json:"temperature,omitempty"`
+	TopP        float64          `json:"top_p,omitempty"`
+	RequestId   string           `json:"request_id,omitempty"`
+	Incremental bool             `json:"incremental,omitempty"`
+}
+
+type ZhipuV3ResponseData struct {
+	TaskId     string           `json:"task_id"`
+	RequestId  string           `json:"request_id"`
+	TaskStatus string           `json:"task_status"`
+	Choices    []ZhipuV3Message `json:"choices"`
+	Usage      *types.Usage     `json:"usage,omitempty"`
+}
+
+type ZhipuV3Response struct {
+	Code    int                 `json:"code"`
+	Msg     string              `json:"msg"`
+	Success bool                `json:"success"`
+	Data    ZhipuV3ResponseData `json:"data"`
+}
+
+func (p *ZhipuProvider) isV3() bool {
+	return p.Channel.Other == ZhipuAPIVersionV3
+}
+
+// getV3RequestURL v3 的模型名称在地址中，自定义了代理地址时需要填写到 /api/paas/v3
+func (p *ZhipuProvider) getV3RequestURL(modelName string, stream bool) string {
+	baseURL := zhipuV3BaseURL
+	if p.Channel.GetBaseURL() != "" {
+		baseURL = strings.TrimSuffix(p.Channel.GetBaseURL(), "/")
+	}
+	method := "invoke"
+	if stream {
+		method = "sse-invoke"
+	}
+	return fmt.Sprintf("%s/model-api/%s/%s", baseURL, modelName, method)
+}
+
+func (p *ZhipuProvider) getV3ChatRequest(request *types.ChatCompletionRequest) (*http.Request, *types.OpenAIErrorWithStatusCode) {
+	headers := p.GetRequestHeaders()
+	if request.Stream {
+		headers["Accept"] = "text/event-stream"
+	}
+
+	zhipuRequest := convertFromChatOpenaiV3(request)
+	req, err := p.Requester.NewRequest(http.MethodPost, p.getV3RequestURL(request.Model, request.Stream), p.Requester.WithBody(zhipuRequest), p.Requester.WithHeader(headers))
+	if err != nil {
+		return nil, common.ErrorWrapper(err, "new_request_failed", http.StatusInternalServerError)
+	}
+
+	return req, nil
+}
+
+// convertFromChatOpenaiV3 v3 不支持 system，转换为一问一答
+func convertFromChatOpenaiV3(request *types.ChatCompletionRequest) *ZhipuV3Request {
+	messages := make([]ZhipuV3Message, 0, len(request.Messages))
+	for _, message := range request.Messages {
+		if message.IsSystemRole() {
+			messages = append(messages, ZhipuV3Message{Role: types.ChatMessageRoleUser, Content: message.StringContent()})
+			messages = append(messages, ZhipuV3Message{Role: types.ChatMessageRoleAssistant, Content: "Okay"})
+			continue
+		}
+		messages = append(messages, ZhipuV3Message{Role: message.Role, Content: message.StringContent()})
+	}
+
+	zhipuRequest := &ZhipuV3Request{
+		Prompt:      messages,
+		Incremental: request.Stream,
+	}
+	if request.Temperature != nil {
+		zhipuRequest.Temperature = utils.NumClamp(*request.Temperature, 0.01, 0.99)
+	}
+	if request.TopP != nil {
+		zhipuRequest.TopP = utils.NumClamp(*request.TopP, 0.01, 0.99)
+	}
+	return zhipuRequest
+}
+
+func (p *ZhipuProvider) createV3ChatCompletion(request *types.ChatCompletionRequest) (*types.ChatCompletionResponse, *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.getV3ChatRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	zhipuResponse := &ZhipuV3Response{}
+	_, errWithCode = p.Requester.SendRequest(req, zhipuResponse, false)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	if !zhipuResponse.Success {
+		return nil, &types.OpenAIErrorWithStatusCode{
+			OpenAIError: types.OpenAIError{
+				Message: zhipuResponse.Msg,
+				Type:    "zhipu_error",
+				Code:    zhipuResponse.Code,
+			},
+			StatusCode: http.StatusBadRequest,
+		}
+	}
+
+	openaiResponse := &types.ChatCompletionResponse{
+		ID:      zhipuResponse.Data.TaskId,
+		Object:  "chat.completion",
+		Created: utils.GetTimestamp(),
+		Model:   request.Model,
+		Choices: make([]types.ChatCompletionChoice, 0, len(zhipuResponse.Data.Choices)),
+	}
+	for i, choice := range zhipuResponse.Data.Choices {
+		openaiResponse.Choices = append(openaiResponse.Choices, types.ChatCompletionChoice{
+			Index: i,
+			Message: types.ChatCompletionMessage{
+				Role:    types.ChatMessageRoleAssistant,
+				Content: strings.Trim(choice.Content, "\" "),
+			},
+			FinishReason: types.FinishReasonStop,
+		})
+	}
+
+	p.setUsage(zhipuResponse.Data.Usage, openaiResponse)
+	openaiResponse.Usage = p.Usage
+	return openaiResponse, nil
+}
+
+func (p *ZhipuProvider) createV3ChatCompletionStream(request *types.ChatCompletionRequest) (requester.StreamReaderInterface[string], *types.OpenAIErrorWithStatusCode) {
+	req, errWithCode := p.getV3ChatRequest(request)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+	defer req.Body.Close()
+
+	resp, errWithCode := p.Requester.SendRequestRaw(req)
+	if errWithCode != nil {
+		return nil, errWithCode
+	}
+
+	chatHandler := &zhipuV3StreamHandler{
+		Usage:   p.Usage,
+		Request: request,
+		Id:      "chatcmpl-" + utils.GetUUID(),
+	}
+
+	return requester.RequestStream[string](p.Requester, resp, chatHandler.handlerStream)
+}
+
+type zhipuV3StreamHandler struct {
+	Usage   *types.Usage
+	Request *types.ChatCompletionRequest
+	Id      string
+	event   string
+	lines   int
+}
+
+// handlerStream v3 的流式响应每个事件由 event、id、data 和 meta 组成，同一事件的多行 data 以换行连接
+// event 为 finish 时 meta 中包含用量
+func (h *zhipuV3StreamHandler) handlerStream(rawLine *[]byte, dataChan chan string, errChan chan error) {
+	line := string(*rawLine)
+	*rawLine = nil
+
+	switch {
+	case strings.HasPrefix(line, "event:"):
+		h.event = strings.TrimSpace(line[6:])
+		h.lines = 0
+	case strings.HasPrefix(line, "data:"):
+		content := line[5:]
+		if h.event == "error" || h.event == "interrupted" {
+			errChan <- &types.OpenAIError{
+				Message: content,
+				Type:    "zhipu_error",
+				Code:    h.event,
+			}
+			return
+		}
+		if h.lines > 0 {
+			content = "\n" + content
+		}
+		h.lines++
+		h.sendContent(content, nil, dataChan)
+	case strings.HasPrefix(line, "meta:"):
+		var meta ZhipuV3ResponseData
+		if err := json.Unmarshal([]byte(line[5:]), &meta); err != nil {
+			errChan <- common.ErrorToOpenAIError(err)
+			return
+		}
+		if meta.Usage != nil {
+			*h.Usage = *meta.Usage
+		}
+		h.sendContent("", types.FinishReasonStop, dataChan)
+		errChan <- io.EOF
+		*rawLine = requester.StreamClosed
+	}
+}
+
+func (h *zhipuV3StreamHandler) sendContent(content string, finishReason any, dataChan chan string) {
+	streamResponse := types.ChatCompletionStreamResponse{
+		ID:      h.Id,
+		Object:  "chat.completion.chunk",
+		Created: utils.GetTimestamp(),
+		Model:   h.Request.Model,
+		Choices: []types.ChatCompletionStreamChoice{{
+			Delta: types.ChatCompletionStreamChoiceDelta{
+				Role:    types.ChatMessageRoleAssistant,
+				Content: content,
+			},
+			FinishReason: finishReason,
+		}},
+	}
+	responseBody, _ := json.Marshal(streamResponse)
+	dataChan <- string(responseBody)
+
+	if content != "" {
+		h.Usage.AppendText(content)
+	}
+}
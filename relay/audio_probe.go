@@ -0,0 +1,43 @@
+package relay
+
+import (
+	"fmt"
+	"math"
+	"mime/multipart"
+	"one-api/common/audio"
+
+	"github.com/spf13/viper"
+)
+
+// probeAudio 按配置校验上传音频的大小和时长，返回时长（秒），无法识别格式时返回 0
+func probeAudio(file *multipart.FileHeader) (float64, error) {
+	if file == nil {
+		return 0, nil
+	}
+
+	if maxSize := viper.GetInt64("audio.max_size"); maxSize > 0 && file.Size > maxSize*1024*1024 {
+		return 0, fmt.Errorf("音频文件超过 %dMB 限制", maxSize)
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return 0, nil
+	}
+	defer f.Close()
+
+	seconds, err := audio.Duration(f, file.Size)
+	if err != nil {
+		return 0, nil
+	}
+
+	if maxDuration := viper.GetFloat64("audio.max_duration"); maxDuration > 0 && seconds > maxDuration {
+		return 0, fmt.Errorf("音频时长 %.0f 秒超过 %.0f 秒限制", seconds, maxDuration)
+	}
+
+	return seconds, nil
+}
+
+// audioPromptTokens 按 audio.tokens_per_second 将音频时长折算为输入 token，未配置时为 0
+func audioPromptTokens(seconds float64) int {
+	return int(math.Ceil(seconds * viper.GetFloat64("audio.tokens_per_second")))
+}
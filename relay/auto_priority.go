@@ -0,0 +1,28 @@
+package relay
+
+import (
+	"net/http"
+	"one-api/model"
+	"one-api/types"
+	"time"
+)
+
+// recordChannelResult 记录渠道的成功率和延迟，流式请求按首个响应的时间计算延迟，
+// 本地错误、客户端取消和请求参数错误不计入
+func recordChannelResult(relay RelayBaseInterface, err *types.OpenAIErrorWithStatusCode, start time.Time) {
+	if !model.AutoPriorityEnabled() || relay.getContext().Request.Context().Err() != nil {
+		return
+	}
+	if err != nil {
+		switch {
+		case err.LocalError, err.StatusCode == http.StatusBadRequest, err.StatusCode == http.StatusRequestEntityTooLarge, err.StatusCode == http.StatusUnprocessableEntity:
+			return
+		}
+	}
+
+	latency := time.Since(start)
+	if first := relay.GetFirstResponseTime(); !first.IsZero() && first.After(start) {
+		latency = first.Sub(start)
+	}
+	model.RecordChannelResult(relay.getProvider().GetChannel().Id, relay.getOriginalModel(), err == nil, latency)
+}
@@ -27,12 +27,15 @@ type relayBase struct {
 
 type RelayBaseInterface interface {
 	send() (err *types.OpenAIErrorWithStatusCode, done bool)
+	sendRaw() (err *types.OpenAIErrorWithStatusCode, done bool)
 	getPromptTokens() (int, error)
 	setRequest() error
 	getRequest() any
 	setProvider(modelName string) error
 	getProvider() providersBase.ProviderInterface
 	getOriginalModel() string
+	setOriginalModel(modelName string)
+	resolveGroupModel() error
 	getModelName() string
 	getContext() *gin.Context
 	IsStream() bool
@@ -79,6 +82,21 @@ func (r *relayBase) setOriginalModel(modelName string) {
 	r.originalModel = parts[0]
 }
 
+// resolveGroupModel 按令牌分组解析模型别名，并检查模型是否对该分组可见
+func (r *relayBase) resolveGroupModel() error {
+	modelName, err := model.GlobalUserGroupRatio.ResolveModel(requestGroup(r.c), r.originalModel)
+	if err != nil {
+		return err
+	}
+	modelName, err = resolveSunsetModel(r.c, modelName)
+	if err != nil {
+		return err
+	}
+	r.originalModel = modelName
+
+	return nil
+}
+
 func (r *relayBase) getContext() *gin.Context {
 	return r.c
 }
@@ -109,7 +127,7 @@ func (r *relayBase) SetFirstResponseTime(firstResponseTime time.Time) {
 }
 
 func (r *relayBase) GetError(err *types.OpenAIErrorWithStatusCode) (int, any) {
-	newErr := FilterOpenAIErr(r.c, err)
+	newErr := FilterOpenAIErr(r.c, mapUpstreamError(r.c, err))
 	return newErr.StatusCode, types.OpenAIErrorResponse{
 		Error: newErr.OpenAIError,
 	}
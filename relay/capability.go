@@ -0,0 +1,111 @@
+package relay
+
+import (
+	"net/http"
+	"one-api/common"
+	"one-api/model"
+	"one-api/providers"
+	"one-api/providers/base"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+// capabilityRank 支持程度从低到高
+var capabilityRank = map[string]int{
+	base.CapabilityUnsupported: 0,
+	base.CapabilityEmulated:    1,
+	base.CapabilityNative:      2,
+}
+
+// ChannelCapabilities 模型在一个可用渠道上的功能支持
+type ChannelCapabilities struct {
+	ChannelId    int               `json:"channel_id"`
+	ChannelType  int               `json:"channel_type"`
+	Capabilities base.Capabilities `json:"capabilities"`
+}
+
+// ModelCapabilities 模型的功能支持，Capabilities 为各渠道中最低的支持程度，即无论路由到哪个渠道都能保证的支持
+type ModelCapabilities struct {
+	Model        string                 `json:"model"`
+	Capabilities base.Capabilities      `json:"capabilities"`
+	Channels     []*ChannelCapabilities `json:"channels"`
+}
+
+// ListCapabilitiesByToken 令牌可用的模型在各渠道上对工具调用、图片输入、JSON 模式、logprobs 和流式 usage 的支持，
+// 由各渠道的适配器声明生成，model 参数可只查询一个模型
+func ListCapabilitiesByToken(c *gin.Context) {
+	groupName := c.GetString("token_group")
+	if groupName == "" {
+		groupName = c.GetString("group")
+	}
+	if groupName == "" {
+		common.AbortWithMessage(c, http.StatusServiceUnavailable, "分组不存在")
+		return
+	}
+
+	models, err := model.ChannelGroup.GetGroupModels(groupName)
+	if err != nil {
+		models = []string{}
+	}
+	models = model.GlobalUserGroupRatio.FilterModels(groupName, models)
+	if modelName := c.Query("model"); modelName != "" {
+		models = filterModel(models, modelName)
+	}
+	sort.Strings(models)
+
+	data := make([]*ModelCapabilities, 0, len(models))
+	for _, modelName := range models {
+		channels := model.ChannelGroup.GroupModelChannels(groupName, modelName)
+		if len(channels) == 0 {
+			continue
+		}
+
+		item := &ModelCapabilities{Model: modelName}
+		for i, channel := range channels {
+			capabilities := providers.GetCapabilities(channel, modelName)
+			item.Channels = append(item.Channels, &ChannelCapabilities{
+				ChannelId:    channel.Id,
+				ChannelType:  channel.Type,
+				Capabilities: capabilities,
+			})
+			if i == 0 {
+				item.Capabilities = capabilities
+			} else {
+				item.Capabilities = minCapabilities(item.Capabilities, capabilities)
+			}
+		}
+		data = append(data, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"object": "list",
+		"data":   data,
+	})
+}
+
+func filterModel(models []string, modelName string) []string {
+	for _, name := range models {
+		if name == modelName {
+			return []string{name}
+		}
+	}
+	return []string{}
+}
+
+func minCapabilities(a, b base.Capabilities) base.Capabilities {
+	lower := func(x, y string) string {
+		if capabilityRank[y] < capabilityRank[x] {
+			return y
+		}
+		return x
+	}
+
+	return base.Capabilities{
+		Tools:       lower(a.Tools, b.Tools),
+		Vision:      lower(a.Vision, b.Vision),
+		JSONMode:    lower(a.JSONMode, b.JSONMode),
+		Logprobs:    lower(a.Logprobs, b.Logprobs),
+		StreamUsage: lower(a.StreamUsage, b.StreamUsage),
+	}
+}
@@ -0,0 +1,85 @@
+package relay
+
+import (
+	"one-api/common/graceful"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/common/utils"
+	"one-api/model"
+	"one-api/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// channelCapture 渠道开启了请求采样时记录本次发送到上游的原始请求和响应
+type channelCapture struct {
+	c         *gin.Context
+	channel   *model.Channel
+	model     string
+	transport *requester.CaptureTransport
+}
+
+// startChannelCapture 渠道没有开启采样时返回 nil
+func startChannelCapture(relay RelayBaseInterface) *channelCapture {
+	provider := relay.getProvider()
+	channel := provider.GetChannel()
+	r := provider.GetRequester()
+	if r == nil || !model.TakeChannelCapture(channel.Id) {
+		return nil
+	}
+
+	capture := &channelCapture{
+		c:       relay.getContext(),
+		channel: channel,
+		model:   relay.getModelName(),
+	}
+	r.HTTPClient, capture.transport = requester.CaptureClient(r.HTTPClient)
+	return capture
+}
+
+// finish 隐藏渠道密钥后异步保存
+func (cc *channelCapture) finish(apiErr *types.OpenAIErrorWithStatusCode) {
+	if cc == nil {
+		return
+	}
+
+	exchanges := cc.transport.Exchanges()
+	secrets := channelKeySecrets(cc.channel.Key)
+	for _, exchange := range exchanges {
+		exchange.Redact(secrets)
+	}
+
+	capture := &model.ChannelCapture{
+		ChannelId:  cc.channel.Id,
+		RequestId:  cc.c.GetString(logger.RequestIdKey),
+		Path:       cc.c.Request.URL.Path,
+		Model:      cc.model,
+		Exchanges:  exchanges,
+		StatusCode: cc.c.Writer.Status(),
+		CreatedAt:  utils.GetTimestamp(),
+	}
+	if apiErr != nil {
+		capture.StatusCode = apiErr.StatusCode
+		capture.Error = sanitizeErrorMessage(cc.c, apiErr.Message)
+	}
+
+	graceful.Go(func() {
+		if err := capture.Insert(); err != nil {
+			logger.SysError("failed to save channel capture: " + err.Error())
+		}
+	})
+}
+
+// channelKeySecrets 渠道 key 按行和 | 拆分后的各段，百度等渠道的 key 由多段组成
+func channelKeySecrets(key string) []string {
+	secrets := make([]string, 0)
+	for _, line := range strings.Split(key, "\n") {
+		for _, secret := range strings.Split(line, "|") {
+			if secret = strings.TrimSpace(secret); len(secret) >= 8 {
+				secrets = append(secrets, secret)
+			}
+		}
+	}
+	return secrets
+}
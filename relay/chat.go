@@ -7,11 +7,10 @@ import (
 	"math"
 	"net/http"
 	"one-api/common"
-	"one-api/common/config"
 	"one-api/common/requester"
 	"one-api/common/utils"
+	"one-api/model"
 	providersBase "one-api/providers/base"
-	"one-api/safty"
 	"one-api/types"
 	"time"
 
@@ -21,6 +20,10 @@ import (
 type relayChat struct {
 	relayBase
 	chatRequest types.ChatCompletionRequest
+
+	legacyFunctions  bool // 客户端使用旧版 functions/function_call 格式
+	stringContent    bool // 客户端只使用字符串内容
+	promptCompressed bool // 已检查过是否需要压缩提示词，重试时不再压缩
 }
 
 func NewRelayChat(c *gin.Context) *relayChat {
@@ -37,6 +40,15 @@ func (r *relayChat) setRequest() error {
 	if err := common.UnmarshalBodyReusable(r.c, &r.chatRequest); err != nil {
 		return err
 	}
+	setExtraFields(r.c, &r.chatRequest)
+	r.legacyFunctions = normalizeLegacyChatRequest(&r.chatRequest)
+	r.stringContent = isStringContent(r.chatRequest.Messages)
+
+	if err := applyPromptTemplate(r.c, &r.chatRequest); err != nil {
+		return err
+	}
+	setPromptLanguage(r.c, r.chatRequest.Messages)
+	applyChatFeatures(r.c, &r.chatRequest)
 
 	if r.chatRequest.MaxTokens < 0 || r.chatRequest.MaxTokens > math.MaxInt32/2 {
 		return errors.New("max_tokens is invalid")
@@ -72,7 +84,17 @@ func (r *relayChat) IsStream() bool {
 
 func (r *relayChat) getPromptTokens() (int, error) {
 	channel := r.provider.GetChannel()
-	return common.CountTokenMessages(r.chatRequest.Messages, r.modelName, channel.PreCost), nil
+	countTokens := func() int {
+		return common.CountTokenMessages(r.chatRequest.Messages, r.modelName, channel.PreCost)
+	}
+	promptTokens := countTokens()
+
+	// 原样转发的渠道发送原始请求体，不压缩
+	if !r.promptCompressed && !canRawPassthrough(r) {
+		r.promptCompressed = true
+		promptTokens = compressPrompt(r.c, &r.chatRequest, r.getOriginalModel(), promptTokens, countTokens)
+	}
+	return promptTokens, nil
 }
 
 var need2Response = map[string]bool{
@@ -104,17 +126,16 @@ func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 
 	r.chatRequest.Model = r.modelName
 	// 内容审查
-	if config.EnableSafe {
-		for _, message := range r.chatRequest.Messages {
-			if message.Content != nil {
-				CheckResult, _ := safty.CheckContent(message.Content)
-				if !CheckResult.IsSafe {
-					err = common.StringErrorWrapperLocal(CheckResult.Reason, CheckResult.Code, http.StatusBadRequest)
-					done = true
-					return
-				}
-			}
-		}
+	if err = moderateChatPrompt(r.c, &r.chatRequest); err != nil {
+		done = true
+		return
+	}
+
+	switch r.streamConversion() {
+	case model.ChannelStreamModeNonStream:
+		return r.sendStreamFromResponse(chatProvider)
+	case model.ChannelStreamModeStream:
+		return r.sendResponseFromStream(chatProvider)
 	}
 
 	if r.chatRequest.Stream {
@@ -123,6 +144,11 @@ func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 		if err != nil {
 			return
 		}
+		response = r.withStreamFailover(response)
+		response = redactChatStream(response)
+		response = r.legacyChatStream(response)
+		response = moderateChatStream(r.c, response)
+		response = postProcessChatStream(r.c, r.provider.GetChannel().Id, &r.chatRequest, response)
 
 		if r.heartbeat != nil {
 			r.heartbeat.Stop()
@@ -141,6 +167,13 @@ func (r *relayChat) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
 		if err != nil {
 			return
 		}
+		if err = validateChatResponse(response); err != nil {
+			return
+		}
+		redactChatResponse(response)
+		r.legacyChatResponse(response)
+		moderateChatResponse(r.c, response)
+		postProcessChatResponse(r.c, r.provider.GetChannel().Id, &r.chatRequest, response)
 
 		if r.heartbeat != nil {
 			r.heartbeat.Stop()
@@ -189,6 +222,10 @@ func (r *relayChat) compatibleSend(resProvider providersBase.ResponsesInterface)
 		if err != nil {
 			return
 		}
+		response = redactChatStream(response)
+		response = r.legacyChatStream(response)
+		response = moderateChatStream(r.c, response)
+		response = postProcessChatStream(r.c, r.provider.GetChannel().Id, &r.chatRequest, response)
 
 		if r.heartbeat != nil {
 			r.heartbeat.Stop()
@@ -211,7 +248,12 @@ func (r *relayChat) compatibleSend(resProvider providersBase.ResponsesInterface)
 		if r.heartbeat != nil {
 			r.heartbeat.Stop()
 		}
-		err = responseJsonClient(r.c, response.ToChat())
+		chatResponse := response.ToChat()
+		redactChatResponse(chatResponse)
+		r.legacyChatResponse(chatResponse)
+		moderateChatResponse(r.c, chatResponse)
+		postProcessChatResponse(r.c, r.provider.GetChannel().Id, &r.chatRequest, chatResponse)
+		err = responseJsonClient(r.c, chatResponse)
 	}
 
 	if err != nil {
@@ -7,7 +7,6 @@ import (
 	"one-api/common/config"
 	"one-api/common/requester"
 	"one-api/providers/claude"
-	"one-api/safty"
 	"one-api/types"
 	"strings"
 
@@ -65,17 +64,13 @@ func (r *relayClaudeOnly) send() (err *types.OpenAIErrorWithStatusCode, done boo
 
 	r.claudeRequest.Model = r.modelName
 	// 内容审查
-	if config.EnableSafe {
-		for _, message := range r.claudeRequest.Messages {
-			if message.Content != nil {
-				CheckResult, _ := safty.CheckContent(message.Content)
-				if !CheckResult.IsSafe {
-					err = common.StringErrorWrapperLocal(CheckResult.Reason, CheckResult.Code, http.StatusBadRequest)
-					done = true
-					return
-				}
-			}
-		}
+	contents := make([]any, 0, len(r.claudeRequest.Messages))
+	for _, message := range r.claudeRequest.Messages {
+		contents = append(contents, message.Content)
+	}
+	if err = moderatePrompt(r.c, contents...); err != nil {
+		done = true
+		return
 	}
 
 	if r.claudeRequest.Stream {
@@ -9,6 +9,7 @@ import (
   "net/http"
   "one-api/common"
   "one-api/common/config"
+  "one-api/common/i18n"
   "one-api/common/logger"
   "one-api/common/requester"
   "one-api/common/utils"
@@ -17,13 +18,18 @@ import (
   "one-api/model"
   "one-api/providers"
   providersBase "one-api/providers/base"
+  "one-api/relay/hooks"
+  "one-api/relay/relay_util"
   "one-api/types"
   "regexp"
+  "slices"
   "strconv"
   "strings"
+  "sync"
   "time"
 
   "github.com/gin-gonic/gin"
+  "github.com/spf13/viper"
 )
 
 func Path2Relay(c *gin.Context, path string) RelayBaseInterface {
@@ -54,6 +60,8 @@ func Path2Relay(c *gin.Context, path string) RelayBaseInterface {
     relay = NewRelayGeminiOnly(c)
   } else if strings.HasPrefix(path, "/v1/responses") {
     relay = NewRelayResponses(c)
+  } else if strings.HasPrefix(path, "/proxy/") {
+    relay = NewRelayProxy(c)
   }
 
   return relay
@@ -108,7 +116,7 @@ func GetProvider(c *gin.Context, modelName string) (provider providersBase.Provi
       return nil, "", err
     }
   }
-  channel, fail := fetchChannel(c, modelName)
+  channel, fail := fetchUnknownModelChannel(c, modelName)
   if fail != nil {
     return
   }
@@ -151,6 +159,34 @@ func fetchChannel(c *gin.Context, modelName string) (channel *model.Channel, fai
   return fetchChannelByModel(c, modelName)
 }
 
+// fetchUnknownModelChannel 按 unknown_model 策略处理未配置价格的模型：拒绝，或者在模型没有价格、没有可用渠道时使用兜底渠道
+func fetchUnknownModelChannel(c *gin.Context, modelName string) (*model.Channel, error) {
+  policy := relay_util.UnknownModelPolicy()
+  unknown := relay_util.IsUnknownModel(modelName)
+  if unknown && policy == relay_util.UnknownModelPolicyReject {
+    return nil, fmt.Errorf("模型 %s 未配置价格，暂不可用", modelName)
+  }
+
+  catchAllId := viper.GetInt("unknown_model.channel_id")
+  // 指定了渠道，或者令牌绑定的渠道不包含兜底渠道时不使用兜底渠道
+  skipCatchAll := c.GetInt("specific_channel_id") > 0 && !c.GetBool("specific_channel_id_ignore")
+  if channelIds, ok := utils.GetGinValue[[]int](c, "token_channel_ids"); ok && !slices.Contains(channelIds, catchAllId) {
+    skipCatchAll = true
+  }
+  if policy != relay_util.UnknownModelPolicyCatchAll || catchAllId == 0 || skipCatchAll {
+    return fetchChannel(c, modelName)
+  }
+
+  if !unknown {
+    channel, err := fetchChannel(c, modelName)
+    if err == nil {
+      return channel, nil
+    }
+    logger.LogWarn(c.Request.Context(), fmt.Sprintf("model %s has no available channel, using catch-all channel #%d", modelName, catchAllId))
+  }
+  return fetchChannelById(catchAllId)
+}
+
 func fetchChannelById(channelId int) (*model.Channel, error) {
   channel, err := model.GetChannelById(channelId)
   if err != nil {
@@ -239,11 +275,17 @@ func fetchChannelByModel(c *gin.Context, modelName string) (*model.Channel, erro
   skipOnlyChat := c.GetBool("skip_only_chat")
   isStream := c.GetBool("is_stream")
 
-  var filters []model.ChannelsFilterFunc
+  // 只能使用共享渠道和本租户的渠道，并且在渠道的启用时间段内
+  filters := []model.ChannelsFilterFunc{model.FilterTenant(c.GetInt("tenant_id")), model.FilterSchedule(time.Now())}
   if skipOnlyChat {
     filters = append(filters, model.FilterOnlyChat())
   }
 
+  // 令牌绑定了渠道时只使用绑定的渠道
+  if channelIds, ok := utils.GetGinValue[[]int](c, "token_channel_ids"); ok {
+    filters = append(filters, model.FilterBoundChannels(channelIds))
+  }
+
   skipChannelIds, ok := utils.GetGinValue[[]int](c, "skip_channel_ids")
   if ok {
     filters = append(filters, model.FilterChannelId(skipChannelIds))
@@ -259,8 +301,32 @@ func fetchChannelByModel(c *gin.Context, modelName string) (*model.Channel, erro
     filters = append(filters, model.FilterDisabledStream(modelName))
   }
 
+  if prefix := c.GetString("path_prefix"); prefix != "" {
+    filters = append(filters, model.FilterPathPrefix(prefix))
+  }
+
+  filters = append(filters, routingHintFilters(c)...)
+
+  // 管理员为模型指定了渠道时按顺序使用
+  if route := model.ModelRoutesInstance.Match(c.GetString("token_group"), modelName); route != nil {
+    if channel := model.ChannelGroup.FirstAvailable(route.ChannelIds, modelName, filters...); channel != nil {
+      return channel, nil
+    }
+    if !route.Fallback {
+      return nil, fmt.Errorf("模型 %s 指定的渠道均不可用", modelName)
+    }
+  }
+
   // 使用统一的分组管理器
   groupManager := NewGroupManager(c)
+  // 优先在主分组中选择指定区域的渠道，没有时按常规流程选择
+  if regionFilter := preferRegionFilter(c); regionFilter != nil && groupManager.primaryGroup != "" {
+    regionFilters := append(filters[:len(filters):len(filters)], regionFilter)
+    if channel, err := model.ChannelGroup.Next(groupManager.primaryGroup, modelName, regionFilters...); err == nil {
+      return channel, nil
+    }
+  }
+
   return groupManager.TryWithGroups(modelName, filters, func(group string) (*model.Channel, error) {
     return model.ChannelGroup.Next(group, modelName, filters...)
   })
@@ -268,6 +334,14 @@ func fetchChannelByModel(c *gin.Context, modelName string) (*model.Channel, erro
 }
 
 func responseJsonClient(c *gin.Context, data interface{}) *types.OpenAIErrorWithStatusCode {
+  if hooks.HasHooks(hooks.StagePostUpstream) {
+    hookCtx := newHookContext(c, hooks.StagePostUpstream, c.GetString("new_model"))
+    hookCtx.Response = data
+    if err := hooks.Run(hookCtx); err != nil {
+      return hookError(err)
+    }
+  }
+
   // 将data转换为 JSON
   responseBody, err := json.Marshal(data)
   if err != nil {
@@ -288,6 +362,13 @@ func responseJsonClient(c *gin.Context, data interface{}) *types.OpenAIErrorWith
 type StreamEndHandler func() string
 
 func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface[string], endHandler StreamEndHandler) (firstResponseTime time.Time, errWithOP *types.OpenAIErrorWithStatusCode) {
+  // 合并增量时需要解析每个 chunk，不能透传
+  aggregator := newStreamAggregator()
+  if passthrough, ok := stream.(requester.PassthroughStreamInterface); ok && aggregator == nil {
+    return responsePassthroughStreamClient(c, passthrough, endHandler)
+  }
+  defer aggregator.Stop()
+
   requester.SetEventStreamHeaders(c)
   dataChan, errChan := stream.Recv()
 
@@ -299,6 +380,27 @@ func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface
 
   var isFirstResponse bool
 
+  keepalive := newStreamKeepalive()
+  defer keepalive.Stop()
+
+  // 尝试写入数据，如果客户端断开也继续处理
+  writeData := func(chunks []string) {
+    if len(chunks) == 0 {
+      return
+    }
+    select {
+    case <-c.Request.Context().Done():
+      // 客户端已断开，不执行任何操作，直接跳过
+    default:
+      // 客户端正常，发送数据
+      for _, chunk := range chunks {
+        c.Writer.Write([]byte("data: " + chunk + "\n\n"))
+      }
+      c.Writer.Flush()
+    }
+    keepalive.Reset()
+  }
+
   // 在新的goroutine中处理stream数据
   go func() {
     defer close(done)
@@ -307,26 +409,31 @@ func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface
       select {
       case data, ok := <-dataChan:
         if !ok {
+          writeData(aggregator.Flush())
           return
         }
-        streamData := "data: " + data + "\n\n"
 
         if !isFirstResponse {
           firstResponseTime = time.Now()
           isFirstResponse = true
         }
 
-        // 尝试写入数据，如果客户端断开也继续处理
+        writeData(aggregator.Add(data))
+
+      case <-aggregator.C():
+        writeData(aggregator.Flush())
+
+      case <-keepalive.C():
         select {
         case <-c.Request.Context().Done():
-          // 客户端已断开，不执行任何操作，直接跳过
         default:
-          // 客户端正常，发送数据
-          c.Writer.Write([]byte(streamData))
+          c.Writer.Write([]byte(streamKeepaliveText))
           c.Writer.Flush()
         }
+        keepalive.Reset()
 
       case err := <-errChan:
+        writeData(aggregator.Flush())
         if !errors.Is(err, io.EOF) {
           // 处理错误情况
           errMsg := "data: " + err.Error() + "\n\n"
@@ -340,6 +447,7 @@ func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface
           }
 
           finalErr = common.StringErrorWrapper(err.Error(), "stream_error", 900)
+          c.Set(config.GinStreamErrorKey, err.Error())
           logger.LogError(c.Request.Context(), "Stream err:"+err.Error())
         } else {
           // 正常结束，处理endHandler
@@ -377,6 +485,130 @@ func responseStreamClient(c *gin.Context, stream requester.StreamReaderInterface
   return firstResponseTime, nil
 }
 
+// responsePassthroughStreamClient 将上游的 SSE 数据直接写入客户端，不做解析和重新序列化
+func responsePassthroughStreamClient(c *gin.Context, stream requester.PassthroughStreamInterface, endHandler StreamEndHandler) (firstResponseTime time.Time, errWithOP *types.OpenAIErrorWithStatusCode) {
+  requester.SetEventStreamHeaders(c)
+  defer stream.Close()
+
+  writer := &passthroughWriter{c: c}
+  stopKeepalive := writer.keepalive()
+  err := stream.Passthrough(writer, writer.Flush)
+  stopKeepalive()
+  firstResponseTime = writer.firstResponseTime
+
+  if err != nil && !errors.Is(err, io.EOF) {
+    writer.WriteString("data: " + err.Error() + "\n\n")
+    c.Set(config.GinStreamErrorKey, err.Error())
+    logger.LogError(c.Request.Context(), "Stream err:"+err.Error())
+    return firstResponseTime, nil
+  }
+
+  if endHandler != nil {
+    if streamData := endHandler(); streamData != "" {
+      writer.WriteString("data: " + streamData + "\n\n")
+    }
+  }
+
+  writer.WriteString("data: [DONE]\n\n")
+  return firstResponseTime, nil
+}
+
+// passthroughWriter 客户端断开后丢弃写入，继续读取上游以便统计用量
+type passthroughWriter struct {
+  c                 *gin.Context
+  firstResponseTime time.Time
+
+  lock sync.Mutex
+  // 已写入但还没有 flush 的数据，事件写到一半时不能插入保活注释
+  pending bool
+  lastFlush time.Time
+}
+
+func (w *passthroughWriter) Write(data []byte) (int, error) {
+  w.lock.Lock()
+  defer w.lock.Unlock()
+
+  if w.firstResponseTime.IsZero() {
+    w.firstResponseTime = time.Now()
+  }
+  w.pending = true
+
+  select {
+  case <-w.c.Request.Context().Done():
+  default:
+    w.c.Writer.Write(data)
+  }
+
+  return len(data), nil
+}
+
+func (w *passthroughWriter) WriteString(data string) {
+  w.Write([]byte(data))
+  w.Flush()
+}
+
+func (w *passthroughWriter) Flush() {
+  w.lock.Lock()
+  defer w.lock.Unlock()
+
+  w.pending = false
+  w.lastFlush = time.Now()
+
+  select {
+  case <-w.c.Request.Context().Done():
+  default:
+    w.c.Writer.Flush()
+  }
+}
+
+// keepalive 透传时上游数据在另一个协程中写入，空闲时只在事件之间插入保活注释，返回停止函数
+func (w *passthroughWriter) keepalive() func() {
+  keepalive := newStreamKeepalive()
+  if keepalive.C() == nil {
+    return func() {}
+  }
+
+  w.lastFlush = time.Now()
+  stop := make(chan struct{})
+  stopped := make(chan struct{})
+  go func() {
+    defer close(stopped)
+    defer keepalive.Stop()
+    for {
+      select {
+      case <-stop:
+        return
+      case <-keepalive.C():
+        w.lock.Lock()
+        idle := time.Since(w.lastFlush)
+        if !w.pending && idle >= keepalive.interval {
+          select {
+          case <-w.c.Request.Context().Done():
+          default:
+            w.c.Writer.Write([]byte(streamKeepaliveText))
+            w.c.Writer.Flush()
+          }
+          w.lastFlush = time.Now()
+          idle = 0
+        }
+        w.lock.Unlock()
+
+        next := keepalive.interval - idle
+        if next <= 0 {
+          next = keepalive.interval
+        }
+        keepalive.timer.Reset(next)
+      }
+    }
+  }()
+
+  // 等待协程退出，之后不会再写入客户端
+  return func() {
+    close(stop)
+    <-stopped
+  }
+}
+
 func responseGeneralStreamClient(c *gin.Context, stream requester.StreamReaderInterface[string], endHandler StreamEndHandler) (firstResponseTime time.Time) {
   requester.SetEventStreamHeaders(c)
   dataChan, errChan := stream.Recv()
@@ -388,6 +620,9 @@ func responseGeneralStreamClient(c *gin.Context, stream requester.StreamReaderIn
   defer stream.Close()
   var isFirstResponse bool
 
+  keepalive := newStreamKeepalive()
+  defer keepalive.Stop()
+
   // 在新的goroutine中处理stream数据
   go func() {
     defer close(done)
@@ -411,6 +646,16 @@ func responseGeneralStreamClient(c *gin.Context, stream requester.StreamReaderIn
           fmt.Fprint(c.Writer, data)
           c.Writer.Flush()
         }
+        keepalive.Reset()
+
+      case <-keepalive.C():
+        select {
+        case <-c.Request.Context().Done():
+        default:
+          fmt.Fprint(c.Writer, streamKeepaliveText)
+          c.Writer.Flush()
+        }
+        keepalive.Reset()
 
       case err := <-errChan:
         if !errors.Is(err, io.EOF) {
@@ -424,6 +669,7 @@ func responseGeneralStreamClient(c *gin.Context, stream requester.StreamReaderIn
             c.Writer.Flush()
           }
 
+          c.Set(config.GinStreamErrorKey, err.Error())
           logger.LogError(c.Request.Context(), "Stream err:"+err.Error())
         } else {
           // 正常结束，处理endHandler
@@ -696,6 +942,8 @@ func FilterOpenAIErr(c *gin.Context, err *types.OpenAIErrorWithStatusCode) (errW
     newErr.OpenAIError.Message = "当前分组上游负载已饱和，请稍后再试"
   }
 
+  newErr.OpenAIError.Message = i18n.T(c, sanitizeErrorMessage(c, newErr.OpenAIError.Message))
+
   // 如果message中已经包含 request id: 则不再添加
   if strings.Contains(newErr.Message, "(request id:") {
     newErr.Message = requestIdRegex.ReplaceAllString(newErr.Message, "")
@@ -707,13 +955,13 @@ func FilterOpenAIErr(c *gin.Context, err *types.OpenAIErrorWithStatusCode) (errW
   if !newErr.LocalError && newErr.OpenAIError.Type == "one_hub_error" || strings.HasSuffix(newErr.OpenAIError.Type, "_api_error") {
     newErr.OpenAIError.Type = "system_error"
     if utils.ContainsString(newErr.Message, quotaKeywords) {
-      newErr.Message = "上游负载已饱和，请稍后再试"
+      newErr.Message = i18n.T(c, "上游负载已饱和，请稍后再试")
       newErr.StatusCode = http.StatusTooManyRequests
     }
   }
 
   if code, ok := newErr.OpenAIError.Code.(string); ok && code == "bad_response_status_code" && !strings.Contains(newErr.OpenAIError.Message, "bad response status code") {
-    newErr.OpenAIError.Message = fmt.Sprintf("Provider API error: bad response status code %s", newErr.OpenAIError.Param)
+    newErr.OpenAIError.Message = i18n.T(c, fmt.Sprintf("Provider API error: bad response status code %s", newErr.OpenAIError.Param))
   }
 
   return newErr
@@ -776,7 +1024,7 @@ func mergeCustomParamsForPreMapping(requestMap map[string]interface{}, customPar
 
   // 添加额外参数
   for key, value := range customParamsModel {
-    if key == "stream" || key == "overwrite" || key == "per_model" || key == "pre_add" {
+    if key == "stream" || key == "overwrite" || key == "per_model" || key == "pre_add" || key == "forward_fields" {
       continue
     }
 
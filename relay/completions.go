@@ -7,11 +7,9 @@ import (
 	"math"
 	"net/http"
 	"one-api/common"
-	"one-api/common/config"
 	"one-api/common/requester"
 	"one-api/common/utils"
 	providersBase "one-api/providers/base"
-	"one-api/safty"
 	"one-api/types"
 	"time"
 
@@ -33,6 +31,7 @@ func (r *relayCompletions) setRequest() error {
 	if err := common.UnmarshalBodyReusable(r.c, &r.request); err != nil {
 		return err
 	}
+	setExtraFields(r.c, &r.request)
 
 	if r.request.MaxTokens < 0 || r.request.MaxTokens > math.MaxInt32/2 {
 		return errors.New("max_tokens is invalid")
@@ -70,15 +69,9 @@ func (r *relayCompletions) send() (err *types.OpenAIErrorWithStatusCode, done bo
 	r.request.Model = r.modelName
 
 	// 内容审查
-	if config.EnableSafe {
-		if r.request.Prompt != nil {
-			CheckResult, _ := safty.CheckContent(r.request.Prompt)
-			if !CheckResult.IsSafe {
-				err = common.StringErrorWrapperLocal(CheckResult.Reason, CheckResult.Code, http.StatusBadRequest)
-				done = true
-				return
-			}
-		}
+	if err = moderatePrompt(r.c, r.request.Prompt); err != nil {
+		done = true
+		return
 	}
 
 	if r.request.Stream {
@@ -101,6 +94,9 @@ func (r *relayCompletions) send() (err *types.OpenAIErrorWithStatusCode, done bo
 		if err != nil {
 			return
 		}
+		if err = validateCompletionResponse(response); err != nil {
+			return
+		}
 		err = responseJsonClient(r.c, response)
 	}
 
@@ -0,0 +1,126 @@
+package relay
+
+import (
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/utils"
+	"one-api/model"
+	"one-api/relay/relay_util"
+	"one-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 单次估算的最大候选模型数
+const costEstimateMaxModels = 20
+
+// CostEstimateRequest 聊天或补全请求，Models 为候选模型，为空时使用 Model
+type CostEstimateRequest struct {
+	Model               string                        `json:"model"`
+	Models              []string                      `json:"models"`
+	Messages            []types.ChatCompletionMessage `json:"messages"`
+	Prompt              any                           `json:"prompt"`
+	MaxTokens           int                           `json:"max_tokens"`
+	MaxCompletionTokens int                           `json:"max_completion_tokens"`
+}
+
+// CostEstimate 单个模型的预估结果，CompletionTokens 取请求中的最大输出 token 数
+type CostEstimate struct {
+	Model            string  `json:"model"`
+	Available        bool    `json:"available"`
+	Message          string  `json:"message,omitempty"`
+	PriceType        string  `json:"price_type,omitempty"`
+	GroupRatio       float64 `json:"group_ratio"`
+	InputRatio       float64 `json:"input_ratio"`
+	OutputRatio      float64 `json:"output_ratio"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	PromptQuota      int     `json:"prompt_quota"`
+	TotalQuota       int     `json:"total_quota"`
+	PromptCost       float64 `json:"prompt_cost"`
+	TotalCost        float64 `json:"total_cost"`
+}
+
+// EstimateCost 按当前令牌分组的价格估算请求在各候选模型上消耗的 token 和额度，不选择渠道、不请求上游
+func EstimateCost(c *gin.Context) {
+	var request CostEstimateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		common.AbortWithMessage(c, http.StatusBadRequest, "无效的请求")
+		return
+	}
+
+	models := request.Models
+	if len(models) == 0 && request.Model != "" {
+		models = []string{request.Model}
+	}
+	if len(models) == 0 {
+		common.AbortWithMessage(c, http.StatusBadRequest, "model 和 models 不能同时为空")
+		return
+	}
+	if len(models) > costEstimateMaxModels {
+		common.AbortWithMessage(c, http.StatusBadRequest, "候选模型过多")
+		return
+	}
+	if len(request.Messages) == 0 && request.Prompt == nil {
+		common.AbortWithMessage(c, http.StatusBadRequest, "messages 和 prompt 不能同时为空")
+		return
+	}
+
+	completionTokens := request.MaxCompletionTokens
+	if completionTokens == 0 {
+		completionTokens = request.MaxTokens
+	}
+
+	group := requestGroup(c)
+	groupModels, _ := model.ChannelGroup.GetGroupModels(group)
+
+	estimates := make([]*CostEstimate, 0, len(models))
+	for _, modelName := range models {
+		estimates = append(estimates, estimateModelCost(c, &request, group, groupModels, modelName, completionTokens))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"object": "list",
+		"data":   estimates,
+	})
+}
+
+func estimateModelCost(c *gin.Context, request *CostEstimateRequest, group string, groupModels []string, modelName string, completionTokens int) *CostEstimate {
+	estimate := &CostEstimate{Model: modelName}
+
+	resolved, err := model.GlobalUserGroupRatio.ResolveModel(group, modelName)
+	if err == nil {
+		resolved, _, err = model.ModelInfosInstance.ResolveSunsetModel(resolved)
+	}
+	if err != nil {
+		estimate.Message = err.Error()
+		return estimate
+	}
+	if !utils.Contains(resolved, groupModels) {
+		estimate.Message = "当前分组没有可用的渠道"
+		return estimate
+	}
+	estimate.Available = true
+
+	if len(request.Messages) > 0 {
+		estimate.PromptTokens = common.CountTokenMessages(request.Messages, resolved, config.PreCostDefault)
+	} else {
+		estimate.PromptTokens = common.CountTokenInput(request.Prompt, resolved)
+	}
+	estimate.CompletionTokens = completionTokens
+
+	price := model.PricingInstance.GetPrice(resolved)
+	estimate.PriceType = price.Type
+	estimate.GroupRatio = c.GetFloat64("group_ratio")
+	estimate.InputRatio = price.GetInput() * estimate.GroupRatio
+	estimate.OutputRatio = price.GetOutput() * estimate.GroupRatio
+
+	quota := relay_util.NewQuota(c, resolved, estimate.PromptTokens)
+	estimate.PromptQuota = quota.GetTotalQuota(estimate.PromptTokens, 0, nil)
+	estimate.TotalQuota = quota.GetTotalQuota(estimate.PromptTokens, estimate.CompletionTokens, nil)
+	estimate.PromptCost = float64(estimate.PromptQuota) / config.QuotaPerUnit
+	estimate.TotalCost = float64(estimate.TotalQuota) / config.QuotaPerUnit
+
+	return estimate
+}
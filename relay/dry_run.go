@@ -0,0 +1,108 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+	"one-api/common"
+	"one-api/common/requester"
+	"one-api/relay/relay_util"
+	"one-api/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const dryRunHeader = "X-Oneapi-Dry-Run"
+
+// DryRunUpstream 将要发送到上游的请求，密钥相关的请求头和参数已隐藏
+type DryRunUpstream struct {
+	Method  string              `json:"method"`
+	URL     string              `json:"url"`
+	Headers map[string][]string `json:"headers"`
+	Body    any                 `json:"body"`
+}
+
+type DryRunChannel struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Type int    `json:"type"`
+}
+
+type DryRunResponse struct {
+	DryRun        bool                      `json:"dry_run"`
+	Model         string                    `json:"model"`
+	UpstreamModel string                    `json:"upstream_model"`
+	Channel       DryRunChannel             `json:"channel"`
+	Upstream      []*DryRunUpstream         `json:"upstream"`
+	Quota         *relay_util.QuotaEstimate `json:"quota"`
+}
+
+func isDryRun(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader(dryRunHeader), "true")
+}
+
+// dryRun 完成鉴权、选择渠道、模型映射和请求转换后，记录将要发送的请求并估算额度，不请求上游、不扣费
+func dryRun(relay RelayBaseInterface) {
+	c := relay.getContext()
+
+	promptTokens, err := relay.getPromptTokens()
+	if err != nil {
+		relay.HandleJsonError(common.ErrorWrapperLocal(err, "token_error", http.StatusBadRequest))
+		return
+	}
+	if apiErr := checkModelInfo(c, relay.getOriginalModel(), promptTokens); apiErr != nil {
+		relay.HandleJsonError(apiErr)
+		return
+	}
+
+	provider := relay.getProvider()
+	provider.SetUsage(&types.Usage{PromptTokens: promptTokens})
+
+	estimate, err := relay_util.NewQuota(c, relay.getModelName(), promptTokens).Estimate()
+	if err != nil {
+		relay.HandleJsonError(common.ErrorWrapper(err, "get_user_quota_failed", http.StatusInternalServerError))
+		return
+	}
+
+	var capture *requester.CaptureTransport
+	if r := provider.GetRequester(); r != nil {
+		r.HTTPClient, capture = requester.CaptureClient(r.HTTPClient)
+		capture.DryRun = true
+	}
+
+	// 发送时在 CaptureTransport 处中断，转换出错时返回错误
+	if apiErr, _ := relay.send(); apiErr != nil && (capture == nil || len(capture.Exchanges()) == 0) {
+		relay.HandleJsonError(apiErr)
+		return
+	}
+
+	channel := provider.GetChannel()
+	response := &DryRunResponse{
+		DryRun:        true,
+		Model:         relay.getOriginalModel(),
+		UpstreamModel: relay.getModelName(),
+		Channel:       DryRunChannel{Id: channel.Id, Name: channel.Name, Type: channel.Type},
+		Upstream:      make([]*DryRunUpstream, 0),
+		Quota:         estimate,
+	}
+	if capture != nil {
+		for _, exchange := range capture.Exchanges() {
+			response.Upstream = append(response.Upstream, &DryRunUpstream{
+				Method:  exchange.Method,
+				URL:     exchange.URL,
+				Headers: exchange.RequestHeaders,
+				Body:    dryRunBody(exchange.RequestBody),
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// dryRunBody JSON 请求体原样嵌入，其它格式（如 multipart）返回字符串
+func dryRunBody(body string) any {
+	if body != "" && json.Valid([]byte(body)) {
+		return json.RawMessage(body)
+	}
+	return body
+}
@@ -0,0 +1,181 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/requester"
+	"one-api/common/utils"
+	"one-api/types"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Echo 不请求上游，按 OpenAI 的格式返回请求中最后一条消息，用于验证客户端 SDK 的兼容性，不计费
+// 带工具定义时返回对工具的调用，response_format 为 JSON 时返回 JSON 内容，
+// 请求头 X-Echo-Error 为 400-599 的状态码时返回对应的错误
+func Echo(c *gin.Context) {
+	if status := c.GetHeader("X-Echo-Error"); status != "" {
+		code, err := strconv.Atoi(status)
+		if err != nil || code < 400 || code > 599 {
+			relayResponseWithOpenAIErr(c, common.StringErrorWrapperLocal("X-Echo-Error must be a status code between 400 and 599", "invalid_echo_error", http.StatusBadRequest))
+			return
+		}
+		relayResponseWithOpenAIErr(c, echoError(code))
+		return
+	}
+
+	var request types.ChatCompletionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		relayResponseWithOpenAIErr(c, common.StringErrorWrapperLocal("invalid request: "+err.Error(), "invalid_request", http.StatusBadRequest))
+		return
+	}
+
+	text := ""
+	if len(request.Messages) > 0 {
+		text = request.Messages[len(request.Messages)-1].StringContent()
+	}
+
+	message := types.ChatCompletionMessage{Role: types.ChatMessageRoleAssistant}
+	finishReason := types.FinishReasonStop
+	if toolCall := echoToolCall(&request, text); toolCall != nil {
+		message.ToolCalls = []*types.ChatCompletionToolCalls{toolCall}
+		finishReason = types.FinishReasonToolCalls
+	} else if request.ResponseFormat != nil && strings.HasPrefix(request.ResponseFormat.Type, "json") {
+		content, _ := json.Marshal(map[string]string{"echo": text})
+		message.Content = string(content)
+	} else {
+		message.Content = text
+	}
+
+	promptTokens := common.CountTokenMessages(request.Messages, request.Model, config.PreCostDefault)
+	completionText := message.StringContent()
+	for _, toolCall := range message.ToolCalls {
+		completionText += toolCall.Function.Name + toolCall.Function.Arguments
+	}
+	completionTokens := common.CountTokenText(completionText, request.Model)
+	usage := &types.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+
+	id := fmt.Sprintf("chatcmpl-%s", utils.GetUUID())
+	created := utils.GetTimestamp()
+	if !request.Stream {
+		c.JSON(http.StatusOK, types.ChatCompletionResponse{
+			ID:      id,
+			Object:  "chat.completion",
+			Created: created,
+			Model:   request.Model,
+			Choices: []types.ChatCompletionChoice{{Index: 0, Message: message, FinishReason: finishReason}},
+			Usage:   usage,
+		})
+		return
+	}
+
+	chunk := func(delta types.ChatCompletionStreamChoiceDelta, finishReason any) types.ChatCompletionStreamResponse {
+		return types.ChatCompletionStreamResponse{
+			ID:      id,
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   request.Model,
+			Choices: []types.ChatCompletionStreamChoice{{Index: 0, Delta: delta, FinishReason: finishReason}},
+		}
+	}
+
+	chunks := []types.ChatCompletionStreamResponse{chunk(types.ChatCompletionStreamChoiceDelta{Role: types.ChatMessageRoleAssistant}, nil)}
+	if message.ToolCalls != nil {
+		chunks = append(chunks, chunk(types.ChatCompletionStreamChoiceDelta{ToolCalls: message.ToolCalls}, nil))
+	} else {
+		// 按空格切分，模拟逐段输出
+		for _, part := range strings.SplitAfter(message.StringContent(), " ") {
+			if part != "" {
+				chunks = append(chunks, chunk(types.ChatCompletionStreamChoiceDelta{Content: part}, nil))
+			}
+		}
+	}
+	chunks = append(chunks, chunk(types.ChatCompletionStreamChoiceDelta{}, finishReason))
+	if request.StreamOptions != nil && request.StreamOptions.IncludeUsage {
+		usageChunk := chunk(types.ChatCompletionStreamChoiceDelta{}, nil)
+		usageChunk.Choices = []types.ChatCompletionStreamChoice{}
+		usageChunk.Usage = usage
+		chunks = append(chunks, usageChunk)
+	}
+
+	requester.SetEventStreamHeaders(c)
+	c.Status(http.StatusOK)
+	for _, item := range chunks {
+		data, _ := json.Marshal(item)
+		fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+		c.Writer.Flush()
+	}
+	fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+	c.Writer.Flush()
+}
+
+// echoToolCall 请求带工具定义且 tool_choice 不为 none 时，返回对指定工具或第一个工具的调用，参数为 {"echo": 最后一条消息}
+func echoToolCall(request *types.ChatCompletionRequest, text string) *types.ChatCompletionToolCalls {
+	toolType, toolFunc := request.ParseToolChoice()
+	if len(request.Tools) == 0 || toolType == types.ToolChoiceTypeNone {
+		return nil
+	}
+
+	name := toolFunc
+	if name == "" {
+		for _, tool := range request.Tools {
+			if tool.Type == types.ToolChoiceTypeFunction && tool.Function.Name != "" {
+				name = tool.Function.Name
+				break
+			}
+		}
+	}
+	if name == "" {
+		return nil
+	}
+
+	arguments, _ := json.Marshal(map[string]string{"echo": text})
+	return &types.ChatCompletionToolCalls{
+		Id:   "call_" + utils.GetRandomString(24),
+		Type: types.ToolChoiceTypeFunction,
+		Function: &types.ChatCompletionToolCallsFunction{
+			Name:      name,
+			Arguments: string(arguments),
+		},
+	}
+}
+
+// echoError 按 OpenAI 的错误类型构造指定状态码的错误
+func echoError(status int) *types.OpenAIErrorWithStatusCode {
+	errType, code := "server_error", "server_error"
+	switch status {
+	case http.StatusBadRequest:
+		errType, code = "invalid_request_error", "invalid_request"
+	case http.StatusUnauthorized:
+		errType, code = "invalid_request_error", "invalid_api_key"
+	case http.StatusForbidden:
+		errType, code = "invalid_request_error", "permission_denied"
+	case http.StatusNotFound:
+		errType, code = "invalid_request_error", "model_not_found"
+	case http.StatusTooManyRequests:
+		errType, code = "requests", "rate_limit_exceeded"
+	default:
+		if status < http.StatusInternalServerError {
+			errType, code = "invalid_request_error", "invalid_request"
+		}
+	}
+
+	return &types.OpenAIErrorWithStatusCode{
+		OpenAIError: types.OpenAIError{
+			Message: fmt.Sprintf("echo error with status code %d", status),
+			Type:    errType,
+			Code:    code,
+		},
+		StatusCode: status,
+		LocalError: true,
+	}
+}
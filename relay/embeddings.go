@@ -3,9 +3,7 @@ package relay
 import (
 	"net/http"
 	"one-api/common"
-	"one-api/common/config"
 	providersBase "one-api/providers/base"
-	"one-api/safty"
 	"one-api/types"
 	"strings"
 
@@ -31,6 +29,7 @@ func (r *relayEmbeddings) setRequest() error {
 	if err := common.UnmarshalBodyReusable(r.c, &r.request); err != nil {
 		return err
 	}
+	setExtraFields(r.c, &r.request)
 
 	r.setOriginalModel(r.request.Model)
 
@@ -50,14 +49,10 @@ func (r *relayEmbeddings) send() (err *types.OpenAIErrorWithStatusCode, done boo
 	}
 
 	// 内容审查
-	if config.EnableSafe {
-		if r.request.Input != nil {
-			CheckResult, _ := safty.CheckContent(r.request)
-			if !CheckResult.IsSafe {
-				err = common.StringErrorWrapperLocal(CheckResult.Reason, CheckResult.Code, http.StatusBadRequest)
-				done = true
-				return
-			}
+	if r.request.Input != nil {
+		if err = moderatePrompt(r.c, r.request); err != nil {
+			done = true
+			return
 		}
 	}
 
@@ -67,6 +62,9 @@ func (r *relayEmbeddings) send() (err *types.OpenAIErrorWithStatusCode, done boo
 	if err != nil {
 		return
 	}
+	if err = validateEmbeddingResponse(response); err != nil {
+		return
+	}
 	err = responseJsonClient(r.c, response)
 
 	if err != nil {
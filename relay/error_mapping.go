@@ -0,0 +1,196 @@
+package relay
+
+import (
+	"fmt"
+	"net/http"
+	"one-api/model"
+	"one-api/types"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 统一的错误类型，与 OpenAI 保持一致
+const (
+	errorTypeInvalidRequest = "invalid_request_error"
+	errorTypeNotFound       = "not_found_error"
+	errorTypeRateLimit      = "rate_limit_error"
+	errorTypeServer         = "server_error"
+)
+
+// errorMapping 上游错误映射后的类型、错误码和状态码，状态码为 0 时保持上游状态码
+type errorMapping struct {
+	Type       string
+	Code       string
+	StatusCode int
+}
+
+var (
+	mappingInvalidRequest = errorMapping{errorTypeInvalidRequest, "invalid_request", http.StatusBadRequest}
+	mappingContextLength  = errorMapping{errorTypeInvalidRequest, "context_length_exceeded", http.StatusBadRequest}
+	mappingContentPolicy  = errorMapping{errorTypeInvalidRequest, "content_policy_violation", http.StatusBadRequest}
+	mappingModelNotFound  = errorMapping{errorTypeNotFound, "model_not_found", http.StatusNotFound}
+	mappingRateLimit      = errorMapping{errorTypeRateLimit, "rate_limit_exceeded", http.StatusTooManyRequests}
+	mappingServer         = errorMapping{errorTypeServer, "upstream_error", http.StatusBadGateway}
+	mappingOverloaded     = errorMapping{errorTypeServer, "upstream_overloaded", http.StatusServiceUnavailable}
+	// 上游鉴权、权限和余额问题是渠道的问题，不能让调用方误以为是自己的令牌无效
+	mappingUpstreamAuth = errorMapping{errorTypeServer, "upstream_unavailable", http.StatusBadGateway}
+)
+
+// 百度千帆 error_code
+var baiduErrorCodes = map[string]errorMapping{
+	"1":      mappingServer,
+	"2":      mappingOverloaded,
+	"3":      mappingInvalidRequest,
+	"4":      mappingRateLimit,
+	"6":      mappingUpstreamAuth,
+	"13":     mappingUpstreamAuth,
+	"14":     mappingUpstreamAuth,
+	"15":     mappingUpstreamAuth,
+	"17":     mappingRateLimit,
+	"18":     mappingRateLimit,
+	"19":     mappingRateLimit,
+	"100":    mappingUpstreamAuth,
+	"110":    mappingUpstreamAuth,
+	"111":    mappingUpstreamAuth,
+	"336000": mappingServer,
+	"336001": mappingInvalidRequest,
+	"336002": mappingInvalidRequest,
+	"336003": mappingInvalidRequest,
+	"336006": mappingInvalidRequest,
+	"336007": mappingContextLength,
+	"336100": mappingOverloaded,
+	"336103": mappingContextLength,
+	"336104": mappingInvalidRequest,
+	"336501": mappingRateLimit,
+	"336502": mappingRateLimit,
+}
+
+// 智谱 code
+var zhipuErrorCodes = map[string]errorMapping{
+	"500":  mappingServer,
+	"1000": mappingUpstreamAuth,
+	"1001": mappingUpstreamAuth,
+	"1002": mappingUpstreamAuth,
+	"1003": mappingUpstreamAuth,
+	"1004": mappingUpstreamAuth,
+	"1110": mappingUpstreamAuth,
+	"1111": mappingUpstreamAuth,
+	"1112": mappingUpstreamAuth,
+	"1113": mappingUpstreamAuth,
+	"1120": mappingUpstreamAuth,
+	"1210": mappingInvalidRequest,
+	"1211": mappingModelNotFound,
+	"1212": mappingInvalidRequest,
+	"1213": mappingInvalidRequest,
+	"1214": mappingInvalidRequest,
+	"1261": mappingContextLength,
+	"1301": mappingContentPolicy,
+	"1302": mappingRateLimit,
+	"1303": mappingRateLimit,
+	"1304": mappingRateLimit,
+	"1305": mappingOverloaded,
+}
+
+// Anthropic error.type
+var anthropicErrorTypes = map[string]errorMapping{
+	"invalid_request_error": mappingInvalidRequest,
+	"authentication_error":  mappingUpstreamAuth,
+	"permission_error":      mappingUpstreamAuth,
+	"billing_error":         mappingUpstreamAuth,
+	"not_found_error":       mappingModelNotFound,
+	"request_too_large":     {errorTypeInvalidRequest, "request_too_large", http.StatusRequestEntityTooLarge},
+	"rate_limit_error":      mappingRateLimit,
+	"api_error":             mappingServer,
+	"overloaded_error":      mappingOverloaded,
+}
+
+// mapUpstreamError 把已知上游的错误码映射为统一的错误类型，原始错误保存在 upstream 字段
+// 本地错误和无法识别来源的错误原样返回
+func mapUpstreamError(c *gin.Context, err *types.OpenAIErrorWithStatusCode) *types.OpenAIErrorWithStatusCode {
+	if err == nil || err.LocalError || err.Upstream != nil {
+		return err
+	}
+
+	var provider string
+	var mapping errorMapping
+	var ok bool
+	code := fmt.Sprint(err.Code)
+	switch {
+	case err.Type == "baidu_error":
+		provider = "baidu"
+		mapping, ok = baiduErrorCodes[code]
+	case err.Type == "zhipu_error":
+		provider = "zhipu"
+		mapping, ok = zhipuErrorCodes[code]
+	case err.Code == "error" && anthropicErrorTypes[err.Type].Type != "":
+		provider = "anthropic"
+		mapping, ok = anthropicErrorTypes[err.Type]
+	case err.Type == "upstream_error":
+		provider = "upstream"
+		mapping, ok = statusErrorMapping(err.StatusCode)
+	default:
+		return err
+	}
+	if !ok {
+		mapping, _ = statusErrorMapping(err.StatusCode)
+	}
+
+	mapped := *err
+	mapped.Upstream = &types.UpstreamError{
+		Provider:   provider,
+		Type:       err.Type,
+		Code:       err.Code,
+		Message:    sanitizeErrorMessage(c, err.Message),
+		StatusCode: err.StatusCode,
+	}
+	mapped.Type = mapping.Type
+	mapped.Code = mapping.Code
+	if mapping.StatusCode != 0 {
+		mapped.StatusCode = mapping.StatusCode
+	}
+	return &mapped
+}
+
+// statusErrorMapping 只有状态码时按状态码映射
+func statusErrorMapping(statusCode int) (errorMapping, bool) {
+	switch {
+	case statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity:
+		return mappingInvalidRequest, true
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden || statusCode == http.StatusPaymentRequired:
+		return mappingUpstreamAuth, true
+	case statusCode == http.StatusNotFound:
+		return mappingModelNotFound, true
+	case statusCode == http.StatusRequestEntityTooLarge:
+		return errorMapping{errorTypeInvalidRequest, "request_too_large", http.StatusRequestEntityTooLarge}, true
+	case statusCode == http.StatusTooManyRequests:
+		return mappingRateLimit, true
+	case statusCode == http.StatusServiceUnavailable:
+		return mappingOverloaded, true
+	}
+	return mappingServer, false
+}
+
+var (
+	errorURLRegex    = regexp.MustCompile(`(?i)\b(?:https?|wss?)://[^\s"'<>]+`)
+	errorHostRegex   = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}(?::\d+)?\b`)
+	errorSecretRegex = regexp.MustCompile(`(?i)\bsk-[a-z0-9_\-]{8,}|\bAIza[0-9a-z_\-]{20,}|\bBearer\s+[a-z0-9._\-]+|\b(?:api[_-]?key|access_token|key)=[^\s&"']+`)
+)
+
+// sanitizeErrorMessage 去掉错误消息中的渠道密钥、常见格式的密钥和上游地址
+func sanitizeErrorMessage(c *gin.Context, message string) string {
+	if message == "" {
+		return message
+	}
+
+	if channel := model.ChannelGroup.GetChannel(c.GetInt("channel_id")); channel != nil {
+		for _, key := range channelKeySecrets(channel.Key) {
+			message = strings.ReplaceAll(message, key, "[redacted]")
+		}
+	}
+
+	message = errorSecretRegex.ReplaceAllString(message, "[redacted]")
+	message = errorURLRegex.ReplaceAllString(message, "[redacted url]")
+	return errorHostRegex.ReplaceAllString(message, "[redacted host]")
+}
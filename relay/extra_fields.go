@@ -0,0 +1,73 @@
+package relay
+
+import (
+	"encoding/json"
+	"one-api/common/config"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var requestFieldNames sync.Map
+
+// setExtraFields 记录请求体中请求结构体未定义的顶层字段，由 OpenAI 类渠道按策略透传，
+// 上游新增的参数不需要修改代码即可使用
+func setExtraFields(c *gin.Context, request any) {
+	raw, ok := c.Get(config.GinRequestBodyKey)
+	if !ok {
+		return
+	}
+	body, ok := raw.([]byte)
+	if !ok {
+		return
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return
+	}
+
+	known := jsonFieldNames(reflect.TypeOf(request))
+	for key := range fields {
+		if known[key] {
+			delete(fields, key)
+		}
+	}
+	if len(fields) > 0 {
+		c.Set(config.GinExtraFieldsKey, fields)
+	}
+}
+
+// jsonFieldNames 结构体所有字段的 JSON 名称，包括嵌入的结构体
+func jsonFieldNames(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if names, ok := requestFieldNames.Load(t); ok {
+		return names.(map[string]bool)
+	}
+
+	names := make(map[string]bool)
+	if t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			name, _, _ := strings.Cut(tag, ",")
+			if field.Anonymous && name == "" {
+				for embedded := range jsonFieldNames(field.Type) {
+					names[embedded] = true
+				}
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			names[name] = true
+		}
+	}
+
+	requestFieldNames.Store(t, names)
+	return names
+}
@@ -3,12 +3,10 @@ package relay
 import (
 	"encoding/json"
 	"errors"
-	"net/http"
 	"one-api/common"
 	"one-api/common/config"
 	"one-api/common/requester"
 	"one-api/providers/gemini"
-	"one-api/safty"
 	"one-api/types"
 	"strings"
 
@@ -82,18 +80,16 @@ func (r *relayGeminiOnly) send() (err *types.OpenAIErrorWithStatusCode, done boo
 	}
 
 	// 内容审查
-	if config.EnableSafe {
-		for _, message := range r.geminiRequest.Contents {
-			if message.Parts != nil {
-				CheckResult, _ := safty.CheckContent(message.Parts)
-				if !CheckResult.IsSafe {
-					err = common.StringErrorWrapperLocal(CheckResult.Reason, CheckResult.Code, http.StatusBadRequest)
-					done = true
-					return
-				}
-			}
+	contents := make([]any, 0, len(r.geminiRequest.Contents))
+	for _, message := range r.geminiRequest.Contents {
+		if message.Parts != nil {
+			contents = append(contents, message.Parts)
 		}
 	}
+	if err = moderatePrompt(r.c, contents...); err != nil {
+		done = true
+		return
+	}
 
 	r.geminiRequest.Model = r.modelName
 
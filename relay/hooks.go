@@ -0,0 +1,34 @@
+package relay
+
+import (
+	"errors"
+	"net/http"
+	"one-api/common"
+	"one-api/common/logger"
+	"one-api/relay/hooks"
+	"one-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newHookContext(c *gin.Context, stage hooks.Stage, modelName string) *hooks.Context {
+	return &hooks.Context{
+		Stage:     stage,
+		Gin:       c,
+		RequestId: c.GetString(logger.RequestIdKey),
+		UserId:    c.GetInt("id"),
+		TokenId:   c.GetInt("token_id"),
+		Group:     c.GetString("token_group"),
+		Model:     modelName,
+		ChannelId: c.GetInt("channel_id"),
+	}
+}
+
+// hookError 将钩子返回的错误转换为返回给客户端的错误
+func hookError(err error) *types.OpenAIErrorWithStatusCode {
+	var reject *hooks.RejectError
+	if errors.As(err, &reject) {
+		return common.StringErrorWrapperLocal(reject.Message, reject.Code, reject.StatusCode)
+	}
+	return common.ErrorWrapperLocal(err, "relay_hook_error", http.StatusInternalServerError)
+}
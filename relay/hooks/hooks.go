@@ -0,0 +1,97 @@
+package hooks
+
+import (
+	"fmt"
+	"one-api/common/logger"
+	"one-api/types"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stage 钩子执行阶段
+type Stage string
+
+const (
+	StagePreRequest   Stage = "pre_request"   // 请求解析完成，选择渠道之前
+	StagePreUpstream  Stage = "pre_upstream"  // 已选择渠道，发送到上游之前
+	StagePostUpstream Stage = "post_upstream" // 收到上游的非流式响应，返回给客户端之前
+	StagePostResponse Stage = "post_response" // 响应已返回给客户端，异步执行，不能修改请求和响应
+)
+
+// Context 钩子上下文，Request 和 Response 为指针，钩子可以直接修改
+// pre_request 阶段修改 Model 会切换本次请求使用的模型
+type Context struct {
+	Stage     Stage
+	Gin       *gin.Context
+	RequestId string
+	UserId    int
+	TokenId   int
+	Group     string
+	Model     string
+	ChannelId int
+
+	Request  any
+	Response any
+	Usage    *types.Usage
+}
+
+// Hook 请求处理钩子，返回错误时终止请求，post_response 阶段的错误只记录日志
+type Hook interface {
+	Name() string
+	Stages() []Stage
+	Handle(ctx *Context) error
+}
+
+// RejectError 钩子拒绝请求时返回，StatusCode 和 Code 会返回给客户端
+type RejectError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *RejectError) Error() string {
+	return e.Message
+}
+
+var (
+	mu    sync.RWMutex
+	hooks = make(map[Stage][]Hook)
+)
+
+// Register 注册钩子，同一阶段按注册顺序执行
+func Register(hook Hook) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for _, stage := range hook.Stages() {
+		hooks[stage] = append(hooks[stage], hook)
+	}
+	logger.SysLog(fmt.Sprintf("relay hook %s registered", hook.Name()))
+}
+
+func HasHooks(stage Stage) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return len(hooks[stage]) > 0
+}
+
+// Run 依次执行指定阶段的钩子，遇到错误立即返回
+func Run(ctx *Context) error {
+	mu.RLock()
+	stageHooks := hooks[ctx.Stage]
+	mu.RUnlock()
+
+	for _, hook := range stageHooks {
+		if err := hook.Handle(ctx); err != nil {
+			if ctx.Stage == StagePostResponse {
+				logger.SysError(fmt.Sprintf("relay hook %s failed: %s", hook.Name(), err.Error()))
+				continue
+			}
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,177 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// HTTPHookConfig 外部 HTTP 钩子配置
+type HTTPHookConfig struct {
+	Name     string   `mapstructure:"name"`
+	URL      string   `mapstructure:"url"`
+	Stages   []string `mapstructure:"stages"`
+	Timeout  int      `mapstructure:"timeout"`   // 单位为秒
+	FailOpen bool     `mapstructure:"fail_open"` // 钩子请求失败时是否放行
+	Secret   string   `mapstructure:"secret"`    // 通过 Authorization 请求头发送
+}
+
+type httpHookRequest struct {
+	Stage     Stage  `json:"stage"`
+	RequestId string `json:"request_id"`
+	UserId    int    `json:"user_id"`
+	TokenId   int    `json:"token_id"`
+	Group     string `json:"group"`
+	Model     string `json:"model"`
+	ChannelId int    `json:"channel_id,omitempty"`
+	Request   any    `json:"request,omitempty"`
+	Response  any    `json:"response,omitempty"`
+	Usage     any    `json:"usage,omitempty"`
+}
+
+// httpHookResponse action 为 reject 时拒绝请求，request/response 不为空时替换对应内容
+type httpHookResponse struct {
+	Action     string          `json:"action"`
+	StatusCode int             `json:"status_code"`
+	Message    string          `json:"message"`
+	Request    json.RawMessage `json:"request"`
+	Response   json.RawMessage `json:"response"`
+}
+
+type httpHook struct {
+	config HTTPHookConfig
+	stages []Stage
+}
+
+// InitHTTPHooks 从配置文件 relay_hooks 加载外部 HTTP 钩子
+func InitHTTPHooks() {
+	var configs []HTTPHookConfig
+	if err := viper.UnmarshalKey("relay_hooks", &configs); err != nil {
+		logger.SysError("failed to load relay_hooks: " + err.Error())
+		return
+	}
+
+	for _, config := range configs {
+		if config.URL == "" || len(config.Stages) == 0 {
+			continue
+		}
+		if config.Name == "" {
+			config.Name = config.URL
+		}
+		if config.Timeout <= 0 {
+			config.Timeout = 5
+		}
+
+		hook := &httpHook{config: config}
+		for _, stage := range config.Stages {
+			hook.stages = append(hook.stages, Stage(stage))
+		}
+		Register(hook)
+	}
+}
+
+func (h *httpHook) Name() string {
+	return h.config.Name
+}
+
+func (h *httpHook) Stages() []Stage {
+	return h.stages
+}
+
+func (h *httpHook) Handle(ctx *Context) error {
+	result, err := h.call(ctx)
+	if err != nil {
+		if h.config.FailOpen {
+			logger.SysError(fmt.Sprintf("relay hook %s failed, skipped: %s", h.config.Name, err.Error()))
+			return nil
+		}
+		return &RejectError{
+			StatusCode: http.StatusServiceUnavailable,
+			Code:       "relay_hook_error",
+			Message:    "relay hook unavailable",
+		}
+	}
+
+	if result.Action == "reject" {
+		statusCode := result.StatusCode
+		if statusCode == 0 {
+			statusCode = http.StatusBadRequest
+		}
+		return &RejectError{
+			StatusCode: statusCode,
+			Code:       "relay_hook_rejected",
+			Message:    result.Message,
+		}
+	}
+
+	if len(result.Request) > 0 && ctx.Request != nil && ctx.Stage != StagePostResponse {
+		if err := json.Unmarshal(result.Request, ctx.Request); err != nil {
+			return fmt.Errorf("relay hook %s returned invalid request: %w", h.config.Name, err)
+		}
+	}
+	if len(result.Response) > 0 && ctx.Response != nil && ctx.Stage == StagePostUpstream {
+		// 响应已由上游生成，替换失败时保留原响应
+		if err := json.Unmarshal(result.Response, ctx.Response); err != nil {
+			logger.SysError(fmt.Sprintf("relay hook %s returned invalid response: %s", h.config.Name, err.Error()))
+		}
+	}
+
+	return nil
+}
+
+func (h *httpHook) call(ctx *Context) (*httpHookResponse, error) {
+	body, err := json.Marshal(httpHookRequest{
+		Stage:     ctx.Stage,
+		RequestId: ctx.RequestId,
+		UserId:    ctx.UserId,
+		TokenId:   ctx.TokenId,
+		Group:     ctx.Group,
+		Model:     ctx.Model,
+		ChannelId: ctx.ChannelId,
+		Request:   ctx.Request,
+		Response:  ctx.Response,
+		Usage:     ctx.Usage,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(context.Background(), time.Duration(h.config.Timeout)*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(timeoutCtx, http.MethodPost, h.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if h.config.Secret != "" {
+		req.Header.Set("Authorization", "Bearer "+h.config.Secret)
+	}
+
+	resp, err := requester.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	result := &httpHookResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return nil, errors.New("invalid hook response: " + err.Error())
+	}
+
+	return result, nil
+}
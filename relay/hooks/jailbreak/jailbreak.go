@@ -0,0 +1,209 @@
+package jailbreak
+
+import (
+	"fmt"
+	"net/http"
+	"one-api/common/graceful"
+	"one-api/common/ipguard"
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"one-api/model"
+	"one-api/relay/hooks"
+	"one-api/types"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	ActionBlock     = "block"
+	ActionFlag      = "flag"
+	ActionDowngrade = "downgrade"
+
+	// ScoreKey 检测得分，保存在请求上下文中
+	ScoreKey = "jailbreak_score"
+)
+
+type rule struct {
+	name   string
+	regex  *regexp.Regexp
+	weight int
+}
+
+var builtinRules = []rule{
+	{name: "ignore_instructions", weight: 40, regex: regexp.MustCompile(`(?i)\b(ignore|disregard|forget|override)\s+(all\s+|any\s+)?(of\s+)?(the\s+|your\s+)?(previous|prior|above|earlier|original)\s+(instructions|prompts?|rules|directions|guidelines)`)},
+	{name: "ignore_instructions_zh", weight: 40, regex: regexp.MustCompile(`(忽略|无视|忘记|忘掉)(你)?(之前|以上|上面|前面|先前|原有)的?(所有|全部)?的?(指令|提示|规则|设定|要求)`)},
+	{name: "dan", weight: 30, regex: regexp.MustCompile(`(?i)\bdo anything now\b|\bDAN\s+mode\b|\bas\s+DAN\b`)},
+	{name: "special_mode", weight: 30, regex: regexp.MustCompile(`(?i)\b(developer|god|jailbreak|unrestricted|evil)\s+mode\b|(开发者|上帝|越狱|无限制)模式`)},
+	{name: "prompt_leak", weight: 30, regex: regexp.MustCompile(`(?i)\b(reveal|show|print|repeat|output|leak)\s+(me\s+)?(your|the)\s+(system\s+prompt|initial\s+instructions|hidden\s+instructions|original\s+prompt)|(输出|显示|告诉我|重复|泄露)(一下)?(你的)?(系统提示词?|系统指令|初始指令|原始提示词?)`)},
+	{name: "no_restrictions", weight: 20, regex: regexp.MustCompile(`(?i)\b(without|no|free\s+of)\s+(any\s+)?(restrictions|filters|limitations|censorship|guidelines|ethical\s+constraints)|(没有|不受|摆脱)(任何)?的?(限制|约束|审查|道德)`)},
+	{name: "persona_override", weight: 25, regex: regexp.MustCompile(`(?i)\b(pretend|act\s+as\s+if|imagine)\s+(that\s+)?you\s+(are|have)\s+(no|an?\s+unrestricted|an?\s+unfiltered)|\byou\s+are\s+no\s+longer\s+(an?\s+)?(ai|assistant|chatgpt|bound)`)},
+}
+
+type customRule struct {
+	Name   string `mapstructure:"name"`
+	Regex  string `mapstructure:"regex"`
+	Weight int    `mapstructure:"weight"`
+}
+
+type screener struct {
+	rules          []rule
+	groups         []string
+	threshold      int
+	action         string
+	downgradeModel string
+	classifier     *classifier
+}
+
+// Init 根据配置文件 jailbreak_filter 注册提示词注入检测钩子
+func Init() {
+	if !viper.GetBool("jailbreak_filter.enable") {
+		return
+	}
+
+	s := &screener{
+		rules:          builtinRules,
+		groups:         viper.GetStringSlice("jailbreak_filter.groups"),
+		threshold:      viper.GetInt("jailbreak_filter.threshold"),
+		action:         viper.GetString("jailbreak_filter.action"),
+		downgradeModel: viper.GetString("jailbreak_filter.downgrade_model"),
+		classifier:     newClassifier(),
+	}
+
+	switch s.action {
+	case ActionBlock, ActionFlag:
+	case ActionDowngrade:
+		if s.downgradeModel == "" {
+			logger.SysError("jailbreak_filter.downgrade_model is empty, fallback to flag")
+			s.action = ActionFlag
+		}
+	default:
+		logger.SysError(fmt.Sprintf("unknown jailbreak_filter.action %s, fallback to block", s.action))
+		s.action = ActionBlock
+	}
+
+	var customRules []customRule
+	if err := viper.UnmarshalKey("jailbreak_filter.patterns", &customRules); err != nil {
+		logger.SysError("failed to load jailbreak_filter.patterns: " + err.Error())
+	}
+	for _, custom := range customRules {
+		regex, err := regexp.Compile(custom.Regex)
+		if err != nil || custom.Name == "" || custom.Weight <= 0 {
+			logger.SysError(fmt.Sprintf("invalid jailbreak pattern %s: %v", custom.Name, err))
+			continue
+		}
+		s.rules = append(s.rules, rule{name: custom.Name, regex: regex, weight: custom.Weight})
+	}
+
+	hooks.Register(s)
+}
+
+func (s *screener) Name() string {
+	return "jailbreak_filter"
+}
+
+func (s *screener) Stages() []hooks.Stage {
+	return []hooks.Stage{hooks.StagePreRequest}
+}
+
+func (s *screener) Handle(ctx *hooks.Context) error {
+	if ctx.Gin == nil || (len(s.groups) > 0 && !utils.Contains(ctx.Group, s.groups)) {
+		return nil
+	}
+
+	request, ok := ctx.Request.(*types.ChatCompletionRequest)
+	if !ok {
+		return nil
+	}
+
+	text := userText(request)
+	if text == "" {
+		return nil
+	}
+
+	score, matched := s.score(text)
+	if s.classifier != nil {
+		classifierScore, err := s.classifier.score(ctx.Gin.Request.Context(), text)
+		if err != nil {
+			logger.LogError(ctx.Gin.Request.Context(), "jailbreak classifier failed: "+err.Error())
+		} else if classifierScore > score {
+			score = classifierScore
+			matched = append(matched, "classifier")
+		}
+	}
+
+	if score == 0 {
+		return nil
+	}
+	ctx.Gin.Set(ScoreKey, score)
+
+	action := ""
+	if score >= s.threshold {
+		action = s.action
+	}
+	s.record(ctx, score, action, matched)
+
+	switch action {
+	case ActionBlock:
+		ipguard.RecordFailure(ctx.Gin.ClientIP(), "prompt injection block")
+		return &hooks.RejectError{
+			StatusCode: http.StatusBadRequest,
+			Code:       "prompt_injection_detected",
+			Message:    "request blocked by prompt screening",
+		}
+	case ActionDowngrade:
+		ctx.Model = s.downgradeModel
+	}
+
+	return nil
+}
+
+// score 按命中规则的权重累加得分，最高 100
+func (s *screener) score(text string) (int, []string) {
+	score := 0
+	var matched []string
+	for _, r := range s.rules {
+		if r.regex.MatchString(text) {
+			score += r.weight
+			matched = append(matched, r.name)
+		}
+	}
+
+	if score > 100 {
+		score = 100
+	}
+	return score, matched
+}
+
+// record 异步记录检测结果，未达到阈值时 action 为空
+func (s *screener) record(ctx *hooks.Context, score int, action string, matched []string) {
+	log := &model.ModerationLog{
+		UserId:    ctx.UserId,
+		TokenId:   ctx.TokenId,
+		GroupName: ctx.Group,
+		ModelName: ctx.Model,
+		RequestId: ctx.RequestId,
+		Stage:     model.ModerationStageJailbreak,
+		Action:    action,
+		Score:     score,
+	}
+	graceful.Go(func() {
+		model.RecordModerationLog(log, matched)
+	})
+}
+
+// userText 拼接用户消息中的文本，系统提示由调用方提供，不参与检测
+func userText(request *types.ChatCompletionRequest) string {
+	var builder strings.Builder
+	for _, message := range request.Messages {
+		if message.Role != types.ChatMessageRoleUser {
+			continue
+		}
+		if builder.Len() > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString(message.StringContent())
+	}
+	return builder.String()
+}
@@ -0,0 +1,175 @@
+package pii
+
+import (
+	"fmt"
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"one-api/relay/hooks"
+	"one-api/types"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const placeholdersKey = "pii_placeholders"
+
+type rule struct {
+	name    string
+	regex   *regexp.Regexp
+	isValid func(string) bool
+}
+
+// 身份证号需要在手机号和银行卡号之前匹配，避免被拆分替换
+var builtinRules = []rule{
+	{name: "email", regex: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{name: "id_card", regex: regexp.MustCompile(`\b[1-9]\d{5}(?:19|20)\d{2}(?:0[1-9]|1[0-2])(?:0[1-9]|[12]\d|3[01])\d{3}[\dXx]\b`)},
+	{name: "credit_card", regex: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), isValid: luhnValid},
+	{name: "phone", regex: regexp.MustCompile(`(?:\+?86[- ]?)?\b1[3-9]\d{9}\b|\+\d{1,3}[- ]?\d{2,4}[- ]?\d{3,4}[- ]?\d{3,4}`)},
+}
+
+type customRule struct {
+	Name  string `mapstructure:"name"`
+	Regex string `mapstructure:"regex"`
+}
+
+type filter struct {
+	rules   []rule
+	groups  []string
+	restore bool
+}
+
+// Init 根据配置文件 pii_filter 注册敏感信息脱敏钩子
+func Init() {
+	if !viper.GetBool("pii_filter.enable") {
+		return
+	}
+
+	f := &filter{
+		groups:  viper.GetStringSlice("pii_filter.groups"),
+		restore: viper.GetBool("pii_filter.restore"),
+	}
+
+	enabledTypes := viper.GetStringSlice("pii_filter.types")
+	for _, r := range builtinRules {
+		if len(enabledTypes) == 0 || utils.Contains(r.name, enabledTypes) {
+			f.rules = append(f.rules, r)
+		}
+	}
+
+	var customRules []customRule
+	if err := viper.UnmarshalKey("pii_filter.patterns", &customRules); err != nil {
+		logger.SysError("failed to load pii_filter.patterns: " + err.Error())
+	}
+	for _, custom := range customRules {
+		regex, err := regexp.Compile(custom.Regex)
+		if err != nil || custom.Name == "" {
+			logger.SysError(fmt.Sprintf("invalid pii pattern %s: %v", custom.Name, err))
+			continue
+		}
+		f.rules = append(f.rules, rule{name: custom.Name, regex: regex})
+	}
+
+	hooks.Register(f)
+}
+
+func (f *filter) Name() string {
+	return "pii_filter"
+}
+
+func (f *filter) Stages() []hooks.Stage {
+	if f.restore {
+		return []hooks.Stage{hooks.StagePreRequest, hooks.StagePostUpstream}
+	}
+	return []hooks.Stage{hooks.StagePreRequest}
+}
+
+func (f *filter) Handle(ctx *hooks.Context) error {
+	if ctx.Gin == nil || (len(f.groups) > 0 && !utils.Contains(ctx.Group, f.groups)) {
+		return nil
+	}
+
+	switch ctx.Stage {
+	case hooks.StagePreRequest:
+		request, ok := ctx.Request.(*types.ChatCompletionRequest)
+		if !ok {
+			return nil
+		}
+		placeholders := make(map[string]string)
+		hooks.RewriteChatText(request, func(text string) string {
+			return f.mask(text, placeholders)
+		})
+		if len(placeholders) > 0 {
+			ctx.Gin.Set(placeholdersKey, placeholders)
+		}
+	case hooks.StagePostUpstream:
+		response, ok := ctx.Response.(*types.ChatCompletionResponse)
+		if !ok {
+			return nil
+		}
+		placeholders, ok := utils.GetGinValue[map[string]string](ctx.Gin, placeholdersKey)
+		if !ok {
+			return nil
+		}
+		hooks.RewriteResponseText(response, func(text string) string {
+			return restore(text, placeholders)
+		})
+	}
+
+	return nil
+}
+
+// mask 将匹配到的敏感信息替换为 [类型_序号] 占位符，相同内容使用同一个占位符
+func (f *filter) mask(text string, placeholders map[string]string) string {
+	originals := make(map[string]string, len(placeholders))
+	for placeholder, original := range placeholders {
+		originals[original] = placeholder
+	}
+
+	for _, r := range f.rules {
+		text = r.regex.ReplaceAllStringFunc(text, func(match string) string {
+			if r.isValid != nil && !r.isValid(match) {
+				return match
+			}
+			if placeholder, ok := originals[match]; ok {
+				return placeholder
+			}
+			placeholder := fmt.Sprintf("[%s_%d]", strings.ToUpper(r.name), len(placeholders)+1)
+			placeholders[placeholder] = match
+			originals[match] = placeholder
+			return placeholder
+		})
+	}
+
+	return text
+}
+
+func restore(text string, placeholders map[string]string) string {
+	for placeholder, original := range placeholders {
+		text = strings.ReplaceAll(text, placeholder, original)
+	}
+	return text
+}
+
+func luhnValid(number string) bool {
+	sum := 0
+	digits := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,32 @@
+package hooks
+
+import "one-api/types"
+
+// RewriteChatText 依次处理聊天请求中的文本内容，包括字符串内容和多模态内容中的 text 部分
+func RewriteChatText(request *types.ChatCompletionRequest, fn func(text string) string) {
+	for i := range request.Messages {
+		switch content := request.Messages[i].Content.(type) {
+		case string:
+			request.Messages[i].Content = fn(content)
+		case []any:
+			for _, part := range content {
+				partMap, ok := part.(map[string]any)
+				if !ok || partMap["type"] != "text" {
+					continue
+				}
+				if text, ok := partMap["text"].(string); ok {
+					partMap["text"] = fn(text)
+				}
+			}
+		}
+	}
+}
+
+// RewriteResponseText 依次处理非流式聊天响应中的文本内容
+func RewriteResponseText(response *types.ChatCompletionResponse, fn func(text string) string) {
+	for i := range response.Choices {
+		if content, ok := response.Choices[i].Message.Content.(string); ok {
+			response.Choices[i].Message.Content = fn(content)
+		}
+	}
+}
@@ -61,6 +61,7 @@ func (r *relayImageEdits) send() (err *types.OpenAIErrorWithStatusCode, done boo
 	if err != nil {
 		return
 	}
+	persistImages(r.c, response, r.request.ResponseFormat)
 	err = responseJsonClient(r.c, response)
 
 	if err != nil {
@@ -66,6 +66,7 @@ func (r *relayImageGenerations) send() (err *types.OpenAIErrorWithStatusCode, do
 	if err != nil {
 		return
 	}
+	persistImages(r.c, response, r.request.ResponseFormat)
 	err = responseJsonClient(r.c, response)
 
 	if err != nil {
@@ -56,6 +56,7 @@ func (r *relayImageVariations) send() (err *types.OpenAIErrorWithStatusCode, don
 	if err != nil {
 		return
 	}
+	persistImages(r.c, response, r.request.ResponseFormat)
 	err = responseJsonClient(r.c, response)
 
 	if err != nil {
@@ -0,0 +1,88 @@
+package relay
+
+import (
+	"one-api/model"
+	"one-api/types"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 按提示词语言路由：分组配置了语言对应的区域时，检测第一条用户消息的语言，优先选择该区域的渠道
+
+// 检测语言时最多读取的字符数
+const languageDetectMaxRunes = 500
+
+var languageScripts = []struct {
+	language string
+	table    *unicode.RangeTable
+}{
+	{"zh", unicode.Han},
+	{"ko", unicode.Hangul},
+	{"ru", unicode.Cyrillic},
+	{"ar", unicode.Arabic},
+	{"th", unicode.Thai},
+	{"hi", unicode.Devanagari},
+	{"en", unicode.Latin},
+}
+
+// detectLanguage 按文字的书写系统粗略判断语言，拉丁字母统一为 en，有假名时为 ja，没有文字时返回空
+func detectLanguage(text string) string {
+	counts := make(map[string]int)
+	kana := 0
+	read := 0
+	for _, r := range text {
+		if read >= languageDetectMaxRunes {
+			break
+		}
+		read++
+		if unicode.In(r, unicode.Hiragana, unicode.Katakana) {
+			kana++
+			continue
+		}
+		for _, script := range languageScripts {
+			if unicode.Is(script.table, r) {
+				counts[script.language]++
+				break
+			}
+		}
+	}
+
+	language, best := "", 0
+	for _, script := range languageScripts {
+		if counts[script.language] > best {
+			language, best = script.language, counts[script.language]
+		}
+	}
+	// 日文混用汉字和假名
+	if kana > 0 && kana+counts["zh"] >= best {
+		return "ja"
+	}
+	return language
+}
+
+// setPromptLanguage 分组配置了语言路由时检测第一条用户消息的语言
+func setPromptLanguage(c *gin.Context, messages []types.ChatCompletionMessage) {
+	if !model.GlobalUserGroupRatio.HasLanguageRouting(requestGroup(c)) {
+		return
+	}
+
+	for _, message := range messages {
+		if message.Role != types.ChatMessageRoleUser {
+			continue
+		}
+		if language := detectLanguage(message.StringContent()); language != "" {
+			c.Set("prompt_language", language)
+		}
+		return
+	}
+}
+
+// languageRegion 提示词语言对应的区域
+func languageRegion(c *gin.Context) string {
+	language := c.GetString("prompt_language")
+	if language == "" {
+		return ""
+	}
+	return model.GlobalUserGroupRatio.GetLanguageRegion(requestGroup(c), language)
+}
@@ -0,0 +1,161 @@
+package relay
+
+import (
+	"encoding/json"
+	"one-api/common/graceful"
+	"one-api/common/requester"
+	"one-api/types"
+)
+
+// normalizeLegacyChatRequest 将旧版 functions/function_call 请求转换为 tools/tool_choice，渠道只需要处理新版格式
+// 返回客户端是否使用旧版格式，旧版客户端的响应需要转换回 function_call
+func normalizeLegacyChatRequest(request *types.ChatCompletionRequest) bool {
+	// 历史消息中的旧版函数调用和函数结果
+	for i := range request.Messages {
+		message := &request.Messages[i]
+		switch message.Role {
+		case types.ChatMessageRoleAssistant:
+			message.FuncToToolCalls()
+		case types.ChatMessageRoleFunction:
+			// 旧版没有调用 ID，与 FuncToToolCalls 一样使用函数名
+			message.Role = types.ChatMessageRoleTool
+			if message.Name != nil && message.ToolCallID == "" {
+				message.ToolCallID = *message.Name
+			}
+		}
+	}
+
+	if len(request.Functions) == 0 || len(request.Tools) > 0 {
+		return false
+	}
+
+	request.Tools = make([]*types.ChatCompletionTool, 0, len(request.Functions))
+	for _, function := range request.Functions {
+		request.Tools = append(request.Tools, &types.ChatCompletionTool{
+			Type:     types.ToolChoiceTypeFunction,
+			Function: *function,
+		})
+	}
+
+	switch functionCall := request.FunctionCall.(type) {
+	case string:
+		request.ToolChoice = functionCall
+	case map[string]any:
+		if name, ok := functionCall["name"].(string); ok {
+			request.ToolChoice = map[string]any{
+				"type":     types.ToolChoiceTypeFunction,
+				"function": map[string]any{"name": name},
+			}
+		}
+	}
+
+	request.Functions = nil
+	request.FunctionCall = nil
+	return true
+}
+
+// isStringContent 所有消息都使用字符串内容，客户端可能不支持数组格式的内容
+func isStringContent(messages []types.ChatCompletionMessage) bool {
+	for _, message := range messages {
+		if _, ok := message.Content.([]any); ok {
+			return false
+		}
+	}
+	return true
+}
+
+// legacyChatResponse 按客户端使用的格式返回响应，旧版客户端只认识 function_call，只保留第一个工具调用
+func (r *relayChat) legacyChatResponse(response *types.ChatCompletionResponse) {
+	for i := range response.Choices {
+		choice := &response.Choices[i]
+		if r.stringContent {
+			if _, ok := choice.Message.Content.([]any); ok {
+				choice.Message.Content = choice.Message.StringContent()
+			}
+		}
+
+		if !r.legacyFunctions || len(choice.Message.ToolCalls) == 0 || choice.Message.ToolCalls[0].Function == nil {
+			continue
+		}
+		choice.Message.ToolToFuncCalls()
+		if choice.FinishReason == types.FinishReasonToolCalls {
+			choice.FinishReason = types.FinishReasonFunctionCall
+		}
+	}
+}
+
+// legacyStream 将流式响应中的 tool_calls 转换为 function_call
+type legacyStream struct {
+	requester.StreamReaderInterface[string]
+}
+
+// legacyChatStream 旧版客户端包装流，包装后不再走透传
+func (r *relayChat) legacyChatStream(stream requester.StreamReaderInterface[string]) requester.StreamReaderInterface[string] {
+	if !r.legacyFunctions {
+		return stream
+	}
+
+	return &legacyStream{StreamReaderInterface: stream}
+}
+
+func (s *legacyStream) Recv() (<-chan string, <-chan error) {
+	dataChan, errChan := s.StreamReaderInterface.Recv()
+	outData := make(chan string)
+	outErr := make(chan error)
+
+	graceful.Go(func() {
+		for {
+			select {
+			case data, ok := <-dataChan:
+				if !ok {
+					close(outData)
+					return
+				}
+				outData <- legacyChunk(data)
+			case err := <-errChan:
+				outErr <- err
+				return
+			}
+		}
+	})
+
+	return outData, outErr
+}
+
+func legacyChunk(data string) string {
+	var chunk types.ChatCompletionStreamResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return data
+	}
+
+	converted := false
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		if len(choice.Delta.ToolCalls) > 0 {
+			// 后续分片中的参数片段不带函数名
+			for _, toolCall := range choice.Delta.ToolCalls {
+				if toolCall.Index == 0 && toolCall.Function != nil {
+					choice.Delta.FunctionCall = &types.ChatCompletionToolCallsFunction{
+						Name:      toolCall.Function.Name,
+						Arguments: toolCall.Function.Arguments,
+					}
+				}
+			}
+			choice.Delta.ToolCalls = nil
+			converted = true
+		}
+		if choice.FinishReason == types.FinishReasonToolCalls {
+			choice.FinishReason = types.FinishReasonFunctionCall
+			converted = true
+		}
+	}
+	if !converted {
+		return data
+	}
+
+	body, err := json.Marshal(chunk)
+	if err != nil {
+		return data
+	}
+	return string(body)
+}
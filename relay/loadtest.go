@@ -0,0 +1,504 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"one-api/common"
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"one-api/model"
+	"one-api/types"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	loadTestTokenName      = "loadtest"
+	loadTestMaxRequests    = 100000
+	loadTestMaxConcurrency = 200
+	// 保留最近的压测报告数
+	loadTestKeep = 20
+	// 每个报告最多记录的错误信息种类
+	loadTestMaxErrors = 20
+
+	LoadTestSourceSynthetic = "synthetic" // 生成的聊天请求
+	LoadTestSourceRecorded  = "recorded"  // request_capture 保存的请求体
+
+	LoadTestStatusRunning   = "running"
+	LoadTestStatusCompleted = "completed"
+	LoadTestStatusStopped   = "stopped"
+)
+
+// LoadTestRequest 压测参数，默认按沙盒令牌处理，请求经过中继的解析和模型分配后返回模拟响应，不请求上游
+type LoadTestRequest struct {
+	ChannelId   int     `json:"channel_id"`
+	Model       string  `json:"model"`       // 为空时使用渠道的测速模型，回放时为空则使用原请求的模型
+	Source      string  `json:"source"`      // synthetic 或 recorded，默认为 synthetic
+	Path        string  `json:"path"`        // 回放时只使用该路径的请求，为空时不限制
+	Captures    int     `json:"captures"`    // 回放时使用最近保存的请求数，默认为 100
+	Requests    int     `json:"requests"`    // 总请求数
+	Concurrency int     `json:"concurrency"` // 并发数，默认为 10
+	QPS         float64 `json:"qps"`         // 目标 QPS，0 为不限制
+	Stream      bool    `json:"stream"`      // 生成的请求是否使用流式
+	MaxTokens   int     `json:"max_tokens"`  // 生成的请求的 max_tokens，默认为 16
+	// 发送到渠道的真实上游，会产生上游费用且不计入用户额度，回放时还会把用户的请求内容发送给该渠道
+	Upstream bool `json:"upstream"`
+}
+
+// LoadTestLatency 请求耗时的分布，单位为毫秒
+type LoadTestLatency struct {
+	Min int64   `json:"min"`
+	Avg float64 `json:"avg"`
+	P50 int64   `json:"p50"`
+	P90 int64   `json:"p90"`
+	P95 int64   `json:"p95"`
+	P99 int64   `json:"p99"`
+	Max int64   `json:"max"`
+}
+
+// LoadTestReport 压测报告，运行中时为当前的统计
+type LoadTestReport struct {
+	Id          string          `json:"id"`
+	Status      string          `json:"status"`
+	Request     LoadTestRequest `json:"request"`
+	Total       int             `json:"total"`
+	Completed   int             `json:"completed"`
+	Success     int             `json:"success"`
+	Failed      int             `json:"failed"`
+	ErrorRate   float64         `json:"error_rate"`
+	QPS         float64         `json:"qps"` // 实际达到的 QPS
+	Latency     LoadTestLatency `json:"latency"`
+	StatusCodes map[int]int     `json:"status_codes"`
+	Errors      map[string]int  `json:"errors"`
+	StartedAt   int64           `json:"started_at"`
+	FinishedAt  int64           `json:"finished_at"`
+}
+
+type loadTestBody struct {
+	method      string
+	path        string
+	contentType string
+	body        []byte
+}
+
+type loadTestRun struct {
+	sync.Mutex
+	report    *LoadTestReport
+	latencies []int64
+	started   time.Time
+	finished  time.Time
+	cancel    context.CancelFunc
+}
+
+var loadTests = struct {
+	sync.Mutex
+	runs []*loadTestRun
+}{}
+
+// StartLoadTest 在本实例上异步运行压测，同一时间只能运行一个，压测请求按内部服务账号处理不扣费
+// 请求在进程内经过中继、计费和日志，不经过鉴权和限流中间件
+func StartLoadTest(c *gin.Context) {
+	if c.GetInt("tenant_id") != 0 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("租户管理员不能运行压测"))
+		return
+	}
+
+	var req LoadTestRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+	bodies, err := prepareLoadTest(&req)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	loadTests.Lock()
+	for _, run := range loadTests.runs {
+		if run.status() == LoadTestStatusRunning {
+			loadTests.Unlock()
+			common.APIRespondWithError(c, http.StatusOK, errors.New("已有压测正在运行"))
+			return
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	run := &loadTestRun{
+		report: &LoadTestReport{
+			Id:          utils.GetTimeString() + utils.GetRandomString(4),
+			Status:      LoadTestStatusRunning,
+			Request:     req,
+			Total:       req.Requests,
+			StatusCodes: map[int]int{},
+			Errors:      map[string]int{},
+			StartedAt:   utils.GetTimestamp(),
+		},
+		latencies: make([]int64, 0, req.Requests),
+		started:   time.Now(),
+		cancel:    cancel,
+	}
+	loadTests.runs = append(loadTests.runs, run)
+	if len(loadTests.runs) > loadTestKeep {
+		loadTests.runs = loadTests.runs[len(loadTests.runs)-loadTestKeep:]
+	}
+	loadTests.Unlock()
+
+	userId := c.GetInt("id")
+	go run.run(ctx, userId, bodies)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    run.snapshot(),
+	})
+}
+
+// GetLoadTests 本实例最近的压测报告，最新的在前
+func GetLoadTests(c *gin.Context) {
+	loadTests.Lock()
+	reports := make([]*LoadTestReport, 0, len(loadTests.runs))
+	for i := len(loadTests.runs) - 1; i >= 0; i-- {
+		reports = append(reports, loadTests.runs[i].snapshot())
+	}
+	loadTests.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    reports,
+	})
+}
+
+func GetLoadTest(c *gin.Context) {
+	run := findLoadTest(c.Param("id"))
+	if run == nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("压测不存在"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    run.snapshot(),
+	})
+}
+
+// StopLoadTest 停止正在运行的压测，已发出的请求完成后结束
+func StopLoadTest(c *gin.Context) {
+	run := findLoadTest(c.Param("id"))
+	if run == nil {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("压测不存在"))
+		return
+	}
+	run.cancel()
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+	})
+}
+
+func findLoadTest(id string) *loadTestRun {
+	loadTests.Lock()
+	defer loadTests.Unlock()
+	for _, run := range loadTests.runs {
+		if run.report.Id == id {
+			return run
+		}
+	}
+	return nil
+}
+
+// prepareLoadTest 检查参数并生成请求体，回放时请求体按顺序循环使用
+func prepareLoadTest(req *LoadTestRequest) ([]*loadTestBody, error) {
+	if req.ChannelId == 0 {
+		return nil, errors.New("请指定压测的渠道")
+	}
+	channel, err := model.GetChannelById(req.ChannelId)
+	if err != nil {
+		return nil, errors.New("渠道不存在")
+	}
+	if req.Requests <= 0 || req.Requests > loadTestMaxRequests {
+		return nil, fmt.Errorf("请求数必须在 1 到 %d 之间", loadTestMaxRequests)
+	}
+	if req.Concurrency == 0 {
+		req.Concurrency = 10
+	}
+	if req.Concurrency < 0 || req.Concurrency > loadTestMaxConcurrency {
+		return nil, fmt.Errorf("并发数必须在 1 到 %d 之间", loadTestMaxConcurrency)
+	}
+	if req.QPS < 0 {
+		return nil, errors.New("QPS 不能为负数")
+	}
+	if req.Source == "" {
+		req.Source = LoadTestSourceSynthetic
+	}
+
+	switch req.Source {
+	case LoadTestSourceSynthetic:
+		if req.Model == "" {
+			req.Model = channel.TestModel
+		}
+		if req.Model == "" {
+			return nil, errors.New("请指定模型或填写渠道的测速模型")
+		}
+		if req.MaxTokens <= 0 {
+			req.MaxTokens = 16
+		}
+		return syntheticLoadTestBodies(req)
+	case LoadTestSourceRecorded:
+		if req.Captures <= 0 {
+			req.Captures = 100
+		}
+		return recordedLoadTestBodies(req)
+	default:
+		return nil, errors.New("source 只能为 synthetic 或 recorded")
+	}
+}
+
+// syntheticLoadTestBodies 生成若干条内容不同的聊天请求，避免命中缓存
+func syntheticLoadTestBodies(req *LoadTestRequest) ([]*loadTestBody, error) {
+	bodies := make([]*loadTestBody, 0, 10)
+	for i := 0; i < 10; i++ {
+		request := &types.ChatCompletionRequest{
+			Model: req.Model,
+			Messages: []types.ChatCompletionMessage{{
+				Role:    types.ChatMessageRoleUser,
+				Content: fmt.Sprintf("Load test %s. Reply with the word ok.", utils.GetRandomString(8)),
+			}},
+			MaxTokens: req.MaxTokens,
+			Stream:    req.Stream,
+		}
+		if req.Stream {
+			request.StreamOptions = &types.StreamOptions{IncludeUsage: true}
+		}
+		body, err := json.Marshal(request)
+		if err != nil {
+			return nil, err
+		}
+		bodies = append(bodies, &loadTestBody{
+			method:      http.MethodPost,
+			path:        "/v1/chat/completions",
+			contentType: "application/json",
+			body:        body,
+		})
+	}
+	return bodies, nil
+}
+
+func recordedLoadTestBodies(req *LoadTestRequest) ([]*loadTestBody, error) {
+	captures, err := model.GetRecentRequestCaptures(req.Path, req.Captures)
+	if err != nil {
+		return nil, err
+	}
+
+	bodies := make([]*loadTestBody, 0, len(captures))
+	for _, capture := range captures {
+		if strings.HasPrefix(capture.Path, "/gemini") {
+			continue
+		}
+		body := []byte(capture.Body)
+		if req.Model != "" {
+			if body, err = replaceRequestModel(body, req.Model); err != nil {
+				continue
+			}
+		}
+		bodies = append(bodies, &loadTestBody{
+			method:      capture.Method,
+			path:        capture.Path,
+			contentType: capture.ContentType,
+			body:        body,
+		})
+	}
+	if len(bodies) == 0 {
+		return nil, errors.New("没有可以回放的请求，请先开启 request_capture")
+	}
+	return bodies, nil
+}
+
+func (run *loadTestRun) run(ctx context.Context, userId int, bodies []*loadTestBody) {
+	req := run.report.Request
+	jobs := make(chan *loadTestBody)
+
+	var wg sync.WaitGroup
+	for i := 0; i < req.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for body := range jobs {
+				run.send(ctx, userId, body)
+			}
+		}()
+	}
+
+	var ticker *time.Ticker
+	if req.QPS > 0 {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / req.QPS))
+		defer ticker.Stop()
+	}
+
+	stopped := false
+	for i := 0; i < req.Requests && !stopped; i++ {
+		if ticker != nil {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				stopped = true
+				continue
+			}
+		}
+		select {
+		case jobs <- bodies[i%len(bodies)]:
+		case <-ctx.Done():
+			stopped = true
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	run.Lock()
+	run.report.Status = LoadTestStatusCompleted
+	if stopped {
+		run.report.Status = LoadTestStatusStopped
+	}
+	run.report.FinishedAt = utils.GetTimestamp()
+	run.finished = time.Now()
+	run.Unlock()
+	run.cancel()
+
+	report := run.snapshot()
+	logger.SysLog(fmt.Sprintf("load test %s %s: %d requests, %d failed, %.1f qps", report.Id, report.Status, report.Completed, report.Failed, report.QPS))
+}
+
+// send 在进程内发送一个请求并记录结果
+func (run *loadTestRun) send(ctx context.Context, userId int, body *loadTestBody) {
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	httpReq, err := http.NewRequestWithContext(ctx, body.method, body.path, bytes.NewReader(body.body))
+	if err != nil {
+		run.record(0, 0, err.Error())
+		return
+	}
+	httpReq.Header.Set("Content-Type", body.contentType)
+	c.Request = httpReq
+
+	if err := setReplayContext(c, userId, run.report.Request.ChannelId, nil); err != nil {
+		run.record(0, 0, err.Error())
+		return
+	}
+	c.Set("token_name", loadTestTokenName)
+	if !run.report.Request.Upstream {
+		c.Set("token_setting", &model.TokenSetting{Sandbox: true})
+	}
+
+	start := time.Now()
+	Relay(c)
+	latency := time.Since(start).Milliseconds()
+
+	message := ""
+	if w.Code < http.StatusOK || w.Code >= http.StatusMultipleChoices {
+		message = loadTestErrorMessage(w.Body.Bytes())
+	}
+	run.record(w.Code, latency, message)
+}
+
+func (run *loadTestRun) record(statusCode int, latency int64, message string) {
+	run.Lock()
+	defer run.Unlock()
+
+	report := run.report
+	report.Completed++
+	report.StatusCodes[statusCode]++
+	if statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices {
+		report.Success++
+		run.latencies = append(run.latencies, latency)
+		return
+	}
+
+	report.Failed++
+	if message == "" {
+		message = "status " + strconv.Itoa(statusCode)
+	}
+	if _, ok := report.Errors[message]; ok || len(report.Errors) < loadTestMaxErrors {
+		report.Errors[message]++
+	}
+}
+
+func (run *loadTestRun) status() string {
+	run.Lock()
+	defer run.Unlock()
+	return run.report.Status
+}
+
+// snapshot 复制当前的报告并计算 QPS、错误率和成功请求的耗时分布
+func (run *loadTestRun) snapshot() *LoadTestReport {
+	run.Lock()
+	defer run.Unlock()
+
+	report := *run.report
+	report.StatusCodes = make(map[int]int, len(run.report.StatusCodes))
+	for code, count := range run.report.StatusCodes {
+		report.StatusCodes[code] = count
+	}
+	report.Errors = make(map[string]int, len(run.report.Errors))
+	for message, count := range run.report.Errors {
+		report.Errors[message] = count
+	}
+
+	elapsed := time.Since(run.started).Seconds()
+	if !run.finished.IsZero() {
+		elapsed = run.finished.Sub(run.started).Seconds()
+	}
+	if elapsed > 0 {
+		report.QPS = float64(report.Completed) / elapsed
+	}
+	if report.Completed > 0 {
+		report.ErrorRate = float64(report.Failed) / float64(report.Completed)
+	}
+
+	latencies := slices.Clone(run.latencies)
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		var sum int64
+		for _, latency := range latencies {
+			sum += latency
+		}
+		percentile := func(p float64) int64 {
+			return latencies[int(float64(len(latencies)-1)*p)]
+		}
+		report.Latency = LoadTestLatency{
+			Min: latencies[0],
+			Avg: float64(sum) / float64(len(latencies)),
+			P50: percentile(0.5),
+			P90: percentile(0.9),
+			P95: percentile(0.95),
+			P99: percentile(0.99),
+			Max: latencies[len(latencies)-1],
+		}
+	}
+	return &report
+}
+
+// loadTestErrorMessage 取 OpenAI 格式错误中的信息，过长时截断
+func loadTestErrorMessage(body []byte) string {
+	var response types.OpenAIErrorResponse
+	message := ""
+	if json.Unmarshal(body, &response) == nil {
+		message = response.Error.Message
+	}
+	if message == "" {
+		message = string(body)
+	}
+	if runes := []rune(message); len(runes) > 200 {
+		message = string(runes[:200])
+	}
+	return message
+}
@@ -5,15 +5,21 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/eventstream"
+	"one-api/common/graceful"
 	"one-api/common/logger"
 	"one-api/common/utils"
 	"one-api/metrics"
 	"one-api/model"
+	"one-api/relay/hooks"
 	"one-api/relay/relay_util"
 	"one-api/types"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,22 +33,73 @@ func Relay(c *gin.Context) {
 		return
 	}
 
+	// 请求大小限制需要在读取请求体之前设置，并在预扣费之前检查
+	requestLimit := getRequestLimit(c)
+	if openaiErr := limitRequestBody(c, requestLimit); openaiErr != nil {
+		relay.HandleJsonError(openaiErr)
+		return
+	}
+
 	// Apply pre-mapping before setRequest to ensure request body modifications take effect
 	applyPreMappingBeforeRequest(c)
 
 	if err := relay.setRequest(); err != nil {
-		openaiErr := common.StringErrorWrapperLocal(err.Error(), "one_hub_error", http.StatusBadRequest)
+		relay.HandleJsonError(requestBodyError(err, requestLimit))
+		return
+	}
+	captureRequest(c)
+
+	if openaiErr := checkRequestLimit(requestLimit, relay.getRequest()); openaiErr != nil {
 		relay.HandleJsonError(openaiErr)
 		return
 	}
 
+	if err := relay.resolveGroupModel(); err != nil {
+		openaiErr := common.StringErrorWrapperLocal(err.Error(), "model_not_found", http.StatusNotFound)
+		relay.HandleJsonError(openaiErr)
+		return
+	}
+
+	if isSandbox(c) {
+		sandboxRelay(relay)
+		return
+	}
+
+	if hooks.HasHooks(hooks.StagePreRequest) {
+		hookCtx := newHookContext(c, hooks.StagePreRequest, relay.getOriginalModel())
+		hookCtx.Request = relay.getRequest()
+		if err := hooks.Run(hookCtx); err != nil {
+			relay.HandleJsonError(hookError(err))
+			return
+		}
+
+		// 钩子切换了模型时重新检查分组可见性
+		if hookCtx.Model != relay.getOriginalModel() {
+			relay.setOriginalModel(hookCtx.Model)
+			if err := relay.resolveGroupModel(); err != nil {
+				openaiErr := common.StringErrorWrapperLocal(err.Error(), "model_not_found", http.StatusNotFound)
+				relay.HandleJsonError(openaiErr)
+				return
+			}
+		}
+	}
+
 	c.Set("is_stream", relay.IsStream())
+
+	cancel := setUpstreamContext(c)
+	defer cancel()
+
 	if err := relay.setProvider(relay.getOriginalModel()); err != nil {
 		openaiErr := common.StringErrorWrapperLocal(err.Error(), "one_hub_error", http.StatusServiceUnavailable)
 		relay.HandleJsonError(openaiErr)
 		return
 	}
 
+	if isDryRun(c) {
+		dryRun(relay)
+		return
+	}
+
 	heartbeat := relay.SetHeartbeat(relay.IsStream())
 	if heartbeat != nil {
 		defer heartbeat.Close()
@@ -54,10 +111,20 @@ func Relay(c *gin.Context) {
 		return
 	}
 
+	if canceled, cancelErr := checkUpstreamCanceled(c); canceled {
+		if cancelErr != nil {
+			relay.HandleJsonError(cancelErr)
+		}
+		return
+	}
+
 	channel := relay.getProvider().GetChannel()
 	go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
 
 	retryTimes := config.RetryTimes
+	if retryTimes == 0 && isInvalidResponse(apiErr) {
+		retryTimes = 1
+	}
 	if done || !shouldRetry(c, apiErr, channel.Type) {
 		logger.LogError(c.Request.Context(), fmt.Sprintf("relay error happen, status code is %d, won't retry in this case", apiErr.StatusCode))
 		retryTimes = 0
@@ -81,11 +148,18 @@ func Relay(c *gin.Context) {
 
 		channel = relay.getProvider().GetChannel()
 		logger.LogError(c.Request.Context(), fmt.Sprintf("using channel #%d(%s) to retry (remain times %d)", channel.Id, channel.Name, i))
+		c.Set("retry_count", retryTimes-i+1)
 		apiErr, done = RelayHandler(relay)
 		if apiErr == nil {
 			metrics.RecordProvider(c, 200)
 			return
 		}
+		if canceled, cancelErr := checkUpstreamCanceled(c); canceled {
+			if cancelErr != nil {
+				relay.HandleJsonError(cancelErr)
+			}
+			return
+		}
 		go processChannelRelayError(c.Request.Context(), channel.Id, channel.Name, apiErr, channel.Type)
 		if done || !shouldRetry(c, apiErr, channel.Type) {
 			break
@@ -93,6 +167,19 @@ func Relay(c *gin.Context) {
 	}
 
 	if apiErr != nil {
+		publishErrorEvent(c, relay.getOriginalModel(), channel.Id, apiErr)
+		recordUserRecentError(c, relay.getOriginalModel(), apiErr)
+
+		// 没有其它渠道可用时把上游的等待时间告诉客户端，重试时 shouldCooldowns 已冻结的渠道不再重复冻结
+		if apiErr.StatusCode == http.StatusTooManyRequests {
+			if skipChannelIds, _ := utils.GetGinValue[[]int](c, "skip_channel_ids"); !slices.Contains(skipChannelIds, channel.Id) {
+				model.ChannelGroup.SetCooldownsWithRetryAfter(channel.Id, c.GetString("new_model"), apiErr.RetryAfter)
+			}
+			if apiErr.RetryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(math.Ceil(apiErr.RetryAfter.Seconds()))))
+			}
+		}
+
 		if heartbeat != nil && heartbeat.IsSafeWriteStream() {
 			relay.HandleStreamError(apiErr)
 			return
@@ -102,7 +189,65 @@ func Relay(c *gin.Context) {
 	}
 }
 
+// publishErrorEvent 推送最终失败的请求，成功的请求在结算额度后推送
+func publishErrorEvent(c *gin.Context, modelName string, channelId int, apiErr *types.OpenAIErrorWithStatusCode) {
+	if !eventstream.Enabled() {
+		return
+	}
+
+	errorCode := ""
+	if apiErr.Code != nil {
+		errorCode = fmt.Sprint(apiErr.Code)
+	}
+
+	eventstream.Publish(&eventstream.Event{
+		RequestId:    c.GetString(logger.RequestIdKey),
+		Status:       eventstream.StatusError,
+		StatusCode:   apiErr.StatusCode,
+		ErrorCode:    errorCode,
+		ErrorMessage: apiErr.Message,
+		UserId:       c.GetInt("id"),
+		TokenId:      c.GetInt("token_id"),
+		TokenName:    c.GetString("token_name"),
+		ChannelId:    channelId,
+		Group:        c.GetString("token_group"),
+		Model:        modelName,
+		Latency:      int(time.Since(c.GetTime("requestStartTime")).Milliseconds()),
+		IsStream:     c.GetBool("is_stream"),
+		SourceIp:     c.ClientIP(),
+	})
+}
+
+// recordUserRecentError 按返回给用户的内容记录失败的请求，用户可以在控制台查看
+func recordUserRecentError(c *gin.Context, modelName string, apiErr *types.OpenAIErrorWithStatusCode) {
+	userErr := FilterOpenAIErr(c, mapUpstreamError(c, apiErr))
+	code := ""
+	if userErr.Code != nil {
+		code = fmt.Sprint(userErr.Code)
+	}
+
+	model.RecordUserRecentError(c.GetInt("id"), &model.UserRecentError{
+		CreatedAt:  utils.GetTimestamp(),
+		RequestId:  c.GetString(logger.RequestIdKey),
+		TokenName:  c.GetString("token_name"),
+		Model:      modelName,
+		StatusCode: userErr.StatusCode,
+		Code:       code,
+		Message:    userErr.Message,
+	})
+}
+
 func RelayHandler(relay RelayBaseInterface) (err *types.OpenAIErrorWithStatusCode, done bool) {
+	if hooks.HasHooks(hooks.StagePreUpstream) {
+		hookCtx := newHookContext(relay.getContext(), hooks.StagePreUpstream, relay.getModelName())
+		hookCtx.Request = relay.getRequest()
+		if hookErr := hooks.Run(hookCtx); hookErr != nil {
+			err = hookError(hookErr)
+			done = true
+			return
+		}
+	}
+
 	promptTokens, tonkeErr := relay.getPromptTokens()
 	if tonkeErr != nil {
 		err = common.ErrorWrapperLocal(tonkeErr, "token_error", http.StatusBadRequest)
@@ -110,10 +255,19 @@ func RelayHandler(relay RelayBaseInterface) (err *types.OpenAIErrorWithStatusCod
 		return
 	}
 
+	if err = checkModelInfo(relay.getContext(), relay.getOriginalModel(), promptTokens); err != nil {
+		done = true
+		return
+	}
+
 	usage := &types.Usage{
 		PromptTokens: promptTokens,
 	}
 
+	if relay.IsStream() {
+		usage.TextCounter = common.NewStreamTokenCounter(relay.getModelName())
+	}
+
 	relay.getProvider().SetUsage(usage)
 
 	quota := relay_util.NewQuota(relay.getContext(), relay.getModelName(), promptTokens)
@@ -122,12 +276,27 @@ func RelayHandler(relay RelayBaseInterface) (err *types.OpenAIErrorWithStatusCod
 		return
 	}
 
-	err, done = relay.send()
+	metadata := attachResponseMetadata(relay, quota, usage)
+	capture := startChannelCapture(relay)
+	sendStart := time.Now()
+	if canRawPassthrough(relay) {
+		err, done = relay.sendRaw()
+	} else {
+		err, done = relay.send()
+	}
+	capture.finish(err)
+	recordChannelResult(relay, err, sendStart)
 	// 最后处理流式中断时计算tokens
-	if usage.CompletionTokens == 0 && usage.TextBuilder.Len() > 0 {
-		usage.CompletionTokens = common.CountTokenText(usage.TextBuilder.String(), relay.getModelName())
-		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	if usage.CompletionTokens == 0 {
+		if usage.TextCounter != nil && usage.TextCounter.HasText() {
+			usage.CompletionTokens = usage.TextCounter.Tokens()
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		} else if usage.TextBuilder.Len() > 0 {
+			usage.CompletionTokens = common.CountTokenText(usage.TextBuilder.String(), relay.getModelName())
+			usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+		}
 	}
+	metadata.detach(err == nil)
 	if err != nil {
 		quota.Undo(relay.getContext())
 		return
@@ -137,16 +306,45 @@ func RelayHandler(relay RelayBaseInterface) (err *types.OpenAIErrorWithStatusCod
 
 	quota.Consume(relay.getContext(), usage, relay.IsStream())
 
+	if hooks.HasHooks(hooks.StagePostResponse) {
+		// 异步执行，请求结束后 gin.Context 会被复用，不传给钩子
+		hookCtx := newHookContext(relay.getContext(), hooks.StagePostResponse, relay.getModelName())
+		hookCtx.Gin = nil
+		hookCtx.Request = relay.getRequest()
+		hookCtx.Usage = usage
+		graceful.Go(func() {
+			hooks.Run(hookCtx)
+		})
+	}
+
 	return
 }
 
+// checkModelInfo 按模型目录校验上下文长度，已设置弃用时间的模型通过 Deprecation 响应头提示客户端
+func checkModelInfo(c *gin.Context, modelName string, promptTokens int) *types.OpenAIErrorWithStatusCode {
+	info := model.ModelInfosInstance.Get(modelName)
+	if info == nil {
+		return nil
+	}
+
+	if info.ContextLength > 0 && promptTokens > info.ContextLength {
+		return common.StringErrorWrapperLocal(
+			fmt.Sprintf("This model's maximum context length is %d tokens, however you requested %d tokens", info.ContextLength, promptTokens),
+			"context_length_exceeded",
+			http.StatusBadRequest,
+		)
+	}
+
+	return nil
+}
+
 func shouldCooldowns(c *gin.Context, channel *model.Channel, apiErr *types.OpenAIErrorWithStatusCode) {
 	modelName := c.GetString("new_model")
 	channelId := channel.Id
 
 	// 如果是频率限制，冻结通道
 	if apiErr.StatusCode == http.StatusTooManyRequests {
-		model.ChannelGroup.SetCooldowns(channelId, modelName)
+		model.ChannelGroup.SetCooldownsWithRetryAfter(channelId, modelName, apiErr.RetryAfter)
 	}
 
 	skipChannelIds, ok := utils.GetGinValue[[]int](c, "skip_channel_ids")
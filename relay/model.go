@@ -25,6 +25,8 @@ type OpenAIModels struct {
 	Object  string  `json:"object"`
 	Created int     `json:"created"`
 	OwnedBy *string `json:"owned_by"`
+
+	Metadata *model.ModelInfoResponse `json:"metadata,omitempty"`
 }
 
 func ListModelsByToken(c *gin.Context) {
@@ -46,10 +48,17 @@ func ListModelsByToken(c *gin.Context) {
 		})
 		return
 	}
+	models = model.GlobalUserGroupRatio.FilterModels(groupName, models)
 	sort.Strings(models)
 
 	var groupOpenAIModels []*OpenAIModels
 	for _, modelName := range models {
+		if target, ok := model.GlobalUserGroupRatio.GetModelAlias(groupName, modelName); ok {
+			openAIModel := getOpenAIModelWithName(target)
+			openAIModel.Id = modelName
+			groupOpenAIModels = append(groupOpenAIModels, openAIModel)
+			continue
+		}
 		groupOpenAIModels = append(groupOpenAIModels, getOpenAIModelWithName(modelName))
 	}
 
@@ -89,6 +98,7 @@ func ListGeminiModelsByToken(c *gin.Context) {
 		})
 		return
 	}
+	models = model.GlobalUserGroupRatio.FilterModels(groupName, models)
 	sort.Strings(models)
 
 	var geminiModels []gemini.ModelDetails
@@ -129,6 +139,7 @@ func ListClaudeModelsByToken(c *gin.Context) {
 		})
 		return
 	}
+	models = model.GlobalUserGroupRatio.FilterModels(groupName, models)
 	sort.Strings(models)
 
 	var claudeModelsData []claude.Model
@@ -176,6 +187,53 @@ func ListModelsForAdmin(c *gin.Context) {
 	})
 }
 
+type ModelCatalogItem struct {
+	Model    string                   `json:"model"`
+	OwnedBy  string                   `json:"owned_by"`
+	Groups   []string                 `json:"groups"`
+	Price    *model.Price             `json:"price"`
+	Metadata *model.ModelInfoResponse `json:"metadata"`
+}
+
+// GetModelCatalog 管理员查看所有模型的分组、价格和元数据，未配置元数据的模型 metadata 为 null
+func GetModelCatalog(c *gin.Context) {
+	modelsGroups := model.ChannelGroup.GetModelsGroups()
+	prices := model.PricingInstance.GetAllPrices()
+
+	catalog := make([]*ModelCatalogItem, 0, len(prices))
+	for modelName, price := range prices {
+		item := &ModelCatalogItem{
+			Model:   modelName,
+			OwnedBy: *getModelOwnedBy(price.ChannelType),
+			Groups:  []string{},
+			Price:   price,
+		}
+
+		for group, enabled := range modelsGroups[modelName] {
+			if enabled {
+				item.Groups = append(item.Groups, group)
+			}
+		}
+		sort.Strings(item.Groups)
+
+		if info := model.ModelInfosInstance.Get(modelName); info != nil {
+			item.Metadata = info.ToResponse()
+		}
+
+		catalog = append(catalog, item)
+	}
+
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Model < catalog[j].Model
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    catalog,
+	})
+}
+
 func RetrieveModel(c *gin.Context) {
 	modelName := c.Param("model")
 	openaiModel := getOpenAIModelWithName(modelName)
@@ -206,12 +264,18 @@ func getModelOwnedBy(channelType int) (ownedBy *string) {
 func getOpenAIModelWithName(modelName string) *OpenAIModels {
 	price := model.PricingInstance.GetPrice(modelName)
 
-	return &OpenAIModels{
+	openAIModel := &OpenAIModels{
 		Id:      modelName,
 		Object:  "model",
 		Created: 1677649963,
 		OwnedBy: getModelOwnedBy(price.ChannelType),
 	}
+
+	if info := model.ModelInfosInstance.Get(modelName); info != nil {
+		openAIModel.Metadata = info.ToResponse()
+	}
+
+	return openAIModel
 }
 
 func GetModelOwnedBy(c *gin.Context) {
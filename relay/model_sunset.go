@@ -0,0 +1,38 @@
+package relay
+
+import (
+	"fmt"
+	"net/http"
+	"one-api/model"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	modelSuccessorHeader = "X-Oneapi-Model-Successor"
+	modelRewrittenHeader = "X-Oneapi-Model-Rewritten"
+)
+
+// resolveSunsetModel 已下线的模型改写为后继模型，并通过响应头提示弃用和下线时间
+func resolveSunsetModel(c *gin.Context, modelName string) (string, error) {
+	resolved, info, err := model.ModelInfosInstance.ResolveSunsetModel(modelName)
+	if info == nil {
+		return resolved, err
+	}
+
+	if info.DeprecatedAt > 0 {
+		c.Header("Deprecation", fmt.Sprintf("@%d", info.DeprecatedAt))
+	}
+	if info.SunsetAt > 0 {
+		c.Header("Sunset", time.Unix(info.SunsetAt, 0).UTC().Format(http.TimeFormat))
+	}
+	if info.Successor != "" {
+		c.Header(modelSuccessorHeader, info.Successor)
+	}
+	if err == nil && resolved != modelName {
+		c.Header(modelRewrittenHeader, fmt.Sprintf("%s -> %s", modelName, resolved))
+	}
+
+	return resolved, err
+}
@@ -0,0 +1,235 @@
+package relay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/model"
+	"one-api/types"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+const summarizerPrompt = "Summarize the following earlier conversation between a user and an AI assistant. Keep all facts, decisions, names, numbers and open questions needed to continue the conversation. Reply with the summary only."
+
+var (
+	// 行内连续的空白，不包括行首缩进
+	inlineSpaceRegex = regexp.MustCompile(`(\S)[ \t]{2,}`)
+	blankLinesRegex  = regexp.MustCompile(`\n{3,}`)
+	trailingRegex    = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// PromptCompression 提示词压缩的结果，记录在消费日志的 metadata 中
+type PromptCompression struct {
+	ContextLength      int    `json:"context_length"`
+	OriginalTokens     int    `json:"original_tokens"`
+	CompressedTokens   int    `json:"compressed_tokens"`
+	Whitespace         bool   `json:"whitespace"`
+	SummarizedMessages int    `json:"summarized_messages,omitempty"`
+	Error              string `json:"error,omitempty"`
+}
+
+// getPromptCompressionSetting 令牌开启了提示词压缩时返回设置
+func getPromptCompressionSetting(c *gin.Context) *model.PromptCompressionSetting {
+	setting, exists := c.Get("token_setting")
+	if !exists {
+		return nil
+	}
+	tokenSetting, ok := setting.(*model.TokenSetting)
+	if !ok || !tokenSetting.PromptCompression.Enabled {
+		return nil
+	}
+	return &tokenSetting.PromptCompression
+}
+
+// compressPrompt 提示词接近模型上下文长度时先去掉多余的空白，仍然过长且令牌允许时用低成本模型总结最早的对话，
+// 返回压缩后的 token 数，countTokens 用于重新计算
+func compressPrompt(c *gin.Context, request *types.ChatCompletionRequest, modelName string, promptTokens int, countTokens func() int) int {
+	setting := getPromptCompressionSetting(c)
+	if setting == nil {
+		return promptTokens
+	}
+	info := model.ModelInfosInstance.Get(modelName)
+	if info == nil || info.ContextLength <= 0 {
+		return promptTokens
+	}
+	limit := int(float64(info.ContextLength) * viper.GetFloat64("prompt_compression.threshold"))
+	if promptTokens <= limit {
+		return promptTokens
+	}
+
+	result := &PromptCompression{ContextLength: info.ContextLength, OriginalTokens: promptTokens}
+	defer func() {
+		result.CompressedTokens = promptTokens
+		c.Set("prompt_compression", result)
+		logger.LogInfo(c.Request.Context(), fmt.Sprintf("prompt compressed from %d to %d tokens", result.OriginalTokens, result.CompressedTokens))
+	}()
+
+	if compressWhitespace(request.Messages) {
+		result.Whitespace = true
+		promptTokens = countTokens()
+		if promptTokens <= limit {
+			return promptTokens
+		}
+	}
+
+	if !setting.Summarize {
+		return promptTokens
+	}
+	summarized, err := summarizeOldestMessages(c.Request.Context(), request)
+	if err != nil {
+		result.Error = err.Error()
+		logger.LogError(c.Request.Context(), "failed to summarize prompt: "+err.Error())
+		return promptTokens
+	}
+	if summarized > 0 {
+		result.SummarizedMessages = summarized
+		promptTokens = countTokens()
+	}
+
+	return promptTokens
+}
+
+// compressWhitespace 去掉行尾空白、多余的空行和行内连续的空白，保留行首缩进，返回是否有修改
+func compressWhitespace(messages []types.ChatCompletionMessage) bool {
+	changed := false
+	compress := func(text string) string {
+		compressed := trailingRegex.ReplaceAllString(text, "\n")
+		compressed = blankLinesRegex.ReplaceAllString(compressed, "\n\n")
+		compressed = inlineSpaceRegex.ReplaceAllString(compressed, "$1 ")
+		compressed = strings.TrimSpace(compressed)
+		if compressed != text {
+			changed = true
+		}
+		return compressed
+	}
+
+	for i := range messages {
+		switch content := messages[i].Content.(type) {
+		case string:
+			messages[i].Content = compress(content)
+		case []any:
+			for _, item := range content {
+				part, ok := item.(map[string]any)
+				if !ok || part["type"] != types.ContentTypeText {
+					continue
+				}
+				if text, ok := part["text"].(string); ok {
+					part["text"] = compress(text)
+				}
+			}
+		}
+	}
+
+	return changed
+}
+
+// summarizeOldestMessages 将开头的系统消息之后、最近 keep_recent 条消息之前的对话总结为一条系统消息，返回被总结的消息数
+func summarizeOldestMessages(ctx context.Context, request *types.ChatCompletionRequest) (int, error) {
+	messages := request.Messages
+	start := 0
+	for start < len(messages) && messages[start].IsSystemRole() {
+		start++
+	}
+	end := len(messages) - max(viper.GetInt("prompt_compression.keep_recent"), 1)
+	// 工具调用的结果需要和调用它的消息保留在一起
+	for end > start && messages[end].Role == types.ChatMessageRoleTool {
+		end--
+	}
+	if end-start < 2 {
+		return 0, nil
+	}
+
+	var conversation strings.Builder
+	for _, message := range messages[start:end] {
+		conversation.WriteString(message.Role)
+		conversation.WriteString(": ")
+		conversation.WriteString(message.StringContent())
+		for _, toolCall := range message.ToolCalls {
+			if toolCall.Function != nil {
+				conversation.WriteString(fmt.Sprintf("\n[tool call %s(%s)]", toolCall.Function.Name, toolCall.Function.Arguments))
+			}
+		}
+		conversation.WriteString("\n\n")
+	}
+
+	summary, err := summarizeConversation(ctx, conversation.String())
+	if err != nil {
+		return 0, err
+	}
+
+	compressed := make([]types.ChatCompletionMessage, 0, len(messages)-(end-start)+1)
+	compressed = append(compressed, messages[:start]...)
+	compressed = append(compressed, types.ChatCompletionMessage{
+		Role:    types.ChatMessageRoleSystem,
+		Content: "Summary of the earlier conversation:\n" + summary,
+	})
+	compressed = append(compressed, messages[end:]...)
+	request.Messages = compressed
+
+	return end - start, nil
+}
+
+// summarizeConversation 调用 OpenAI 兼容接口的低成本模型总结对话
+func summarizeConversation(ctx context.Context, conversation string) (string, error) {
+	url := viper.GetString("prompt_compression.summarizer.url")
+	modelName := viper.GetString("prompt_compression.summarizer.model")
+	if url == "" || modelName == "" {
+		return "", errors.New("summarizer is not configured")
+	}
+
+	body, err := json.Marshal(types.ChatCompletionRequest{
+		Model: modelName,
+		Messages: []types.ChatCompletionMessage{
+			{Role: types.ChatMessageRoleSystem, Content: summarizerPrompt},
+			{Role: types.ChatMessageRoleUser, Content: conversation},
+		},
+		MaxTokens: viper.GetInt("prompt_compression.summarizer.max_tokens"),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(viper.GetInt("prompt_compression.summarizer.timeout"))*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(timeoutCtx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if key := viper.GetString("prompt_compression.summarizer.key"); key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := requester.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var response types.ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", err
+	}
+	if len(response.Choices) == 0 || strings.TrimSpace(response.Choices[0].Message.StringContent()) == "" {
+		return "", errors.New("empty summarizer response")
+	}
+
+	return strings.TrimSpace(response.Choices[0].Message.StringContent()), nil
+}
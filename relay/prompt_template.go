@@ -0,0 +1,59 @@
+package relay
+
+import (
+	"fmt"
+	"one-api/model"
+	"one-api/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	promptTemplateModelPrefix = "template:"
+	promptTemplateHeader      = "X-Prompt-Template"
+)
+
+// applyPromptTemplate 渲染请求引用的提示词模板，并将模板消息插入到请求消息之前
+// 通过模型名 template:<name> 引用时使用模板配置的模型，通过请求头引用时保留请求的模型
+func applyPromptTemplate(c *gin.Context, request *types.ChatCompletionRequest) error {
+	variables := request.PromptVariables
+	request.PromptVariables = nil
+
+	name := c.GetHeader(promptTemplateHeader)
+	byModel := strings.HasPrefix(request.Model, promptTemplateModelPrefix)
+	if byModel {
+		name = strings.TrimPrefix(request.Model, promptTemplateModelPrefix)
+	}
+	if name == "" {
+		return nil
+	}
+
+	template := model.PromptTemplatesInstance.Get(name)
+	if template == nil {
+		return fmt.Errorf("prompt template %s not found", name)
+	}
+
+	if byModel {
+		if template.Model == "" {
+			return fmt.Errorf("prompt template %s has no model configured", name)
+		}
+		request.Model = template.Model
+	}
+
+	rendered, err := template.Render(variables)
+	if err != nil {
+		return err
+	}
+
+	messages := make([]types.ChatCompletionMessage, 0, len(rendered)+len(request.Messages))
+	for _, message := range rendered {
+		messages = append(messages, types.ChatCompletionMessage{
+			Role:    message.Role,
+			Content: message.Content,
+		})
+	}
+	request.Messages = append(messages, request.Messages...)
+
+	return nil
+}
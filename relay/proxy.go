@@ -0,0 +1,59 @@
+package relay
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"one-api/common/config"
+	"one-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// relayProxy 将 /proxy/<前缀>/<路径> 原样转发到开放了该前缀的渠道，不需要为少见的接口新增中继模式
+// 请求体中有 model 时按该模型选择渠道和计费，否则使用前缀作为模型名，渠道需要包含该模型
+type relayProxy struct {
+	relayBase
+	upstreamPath string
+}
+
+func NewRelayProxy(c *gin.Context) *relayProxy {
+	relay := &relayProxy{}
+	relay.c = c
+	return relay
+}
+
+func (r *relayProxy) setRequest() error {
+	body, err := io.ReadAll(r.c.Request.Body)
+	if err != nil {
+		return err
+	}
+	r.c.Request.Body.Close()
+	r.c.Request.Body = io.NopCloser(bytes.NewBuffer(body))
+	r.c.Set(config.GinRequestBodyKey, body)
+
+	prefix := r.c.Param("prefix")
+	r.upstreamPath = r.c.Param("path")
+	r.c.Set("path_prefix", prefix)
+
+	modelName := prefix
+	if r.c.ContentType() == "application/json" {
+		var request struct {
+			Model string `json:"model"`
+		}
+		if json.Unmarshal(body, &request) == nil && request.Model != "" {
+			modelName = request.Model
+		}
+	}
+	r.setOriginalModel(modelName)
+
+	return nil
+}
+
+func (r *relayProxy) getPromptTokens() (int, error) {
+	return 0, nil
+}
+
+func (r *relayProxy) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
+	return r.sendRawPath(r.upstreamPath)
+}
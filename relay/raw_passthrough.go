@@ -0,0 +1,233 @@
+package relay
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/common/utils"
+	"one-api/relay/hooks"
+	"one-api/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// canRawPassthrough 渠道开启了原样转发，且请求是 OpenAI 格式的接口
+// 原样转发发送的是客户端的原始请求体，网关需要改写请求或审查内容时不能原样转发
+func canRawPassthrough(relay RelayBaseInterface) bool {
+	c := relay.getContext()
+	if !relay.getProvider().GetChannel().RawPassthrough || !strings.HasPrefix(c.Request.URL.Path, "/v1/") {
+		return false
+	}
+	if _, ok := relay.getProvider().(interface{ GetBaseURL() string }); !ok {
+		return false
+	}
+	return !requestProcessed(c)
+}
+
+// requestProcessed 请求是否经过了网关的处理：模型被别名、映射或模板替换，请求体被模板、令牌设置或钩子改写，
+// 或分组需要审查、脱敏提示词和响应
+func requestProcessed(c *gin.Context) bool {
+	if rawRequestModel(c) != c.GetString("new_model") {
+		return true
+	}
+	if c.GetHeader(promptTemplateHeader) != "" {
+		return true
+	}
+	if features := tokenFeatures(c); features != nil && (features.DefaultTemperature != nil || features.CacheDisabled()) {
+		return true
+	}
+	for _, stage := range []hooks.Stage{hooks.StagePreRequest, hooks.StagePreUpstream, hooks.StagePostUpstream} {
+		if hooks.HasHooks(stage) {
+			return true
+		}
+	}
+	if config.EnableSafe {
+		policy := safePolicy(c)
+		if policy.Prompt || policy.Response {
+			return true
+		}
+	}
+	return false
+}
+
+// rawRequestModel 读取原始请求中的模型名称
+func rawRequestModel(c *gin.Context) string {
+	contentType := c.ContentType()
+	if contentType == "multipart/form-data" || contentType == "application/x-www-form-urlencoded" {
+		return c.PostForm("model")
+	}
+
+	body, _ := utils.GetGinValue[[]byte](c, config.GinRequestBodyKey)
+	var request struct {
+		Model string `json:"model"`
+	}
+	if json.Unmarshal(body, &request) != nil {
+		return ""
+	}
+	return request.Model
+}
+
+// sendRaw 请求体和路径原样发送到上游，上游响应原样返回，只从响应中读取用量用于计费
+func (r *relayBase) sendRaw() (err *types.OpenAIErrorWithStatusCode, done bool) {
+	return r.sendRawPath(r.c.Request.URL.Path)
+}
+
+// sendRawPath 请求体原样发送到上游的指定路径
+func (r *relayBase) sendRawPath(path string) (err *types.OpenAIErrorWithStatusCode, done bool) {
+	body, _ := utils.GetGinValue[[]byte](r.c, config.GinRequestBodyKey)
+	baseURL, ok := r.provider.(interface{ GetBaseURL() string })
+	if !ok {
+		err = common.StringErrorWrapperLocal("channel not implemented", "channel_error", http.StatusServiceUnavailable)
+		done = true
+		return
+	}
+	fullRequestURL := strings.TrimSuffix(baseURL.GetBaseURL(), "/") + path
+	if r.c.Request.URL.RawQuery != "" {
+		fullRequestURL += "?" + r.c.Request.URL.RawQuery
+	}
+
+	headers := r.provider.GetRequestHeaders()
+	if contentType := r.c.Request.Header.Get("Content-Type"); contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+
+	httpRequester := r.provider.GetRequester()
+	req, reqErr := httpRequester.NewRequest(r.c.Request.Method, fullRequestURL, httpRequester.WithBody(body), httpRequester.WithHeader(headers))
+	if reqErr != nil {
+		err = common.ErrorWrapper(reqErr, "new_request_failed", http.StatusInternalServerError)
+		return
+	}
+
+	resp, err := httpRequester.SendRequestRaw(req)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	if r.heartbeat != nil {
+		r.heartbeat.Stop()
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		r.sendRawStream(resp)
+		return nil, false
+	}
+
+	responseBody, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		err = common.ErrorWrapper(readErr, "read_response_body_failed", http.StatusInternalServerError)
+		return
+	}
+	applyRawUsage(r.provider.GetUsage(), responseBody)
+
+	r.c.Data(resp.StatusCode, resp.Header.Get("Content-Type"), responseBody)
+	return nil, false
+}
+
+// sendRawStream 逐行转发上游的 SSE 数据，同时读取用量和输出文本
+func (r *relayBase) sendRawStream(resp *http.Response) {
+	requester.SetEventStreamHeaders(r.c)
+
+	usage := r.provider.GetUsage()
+	writer := &passthroughWriter{c: r.c}
+	stopKeepalive := writer.keepalive()
+	defer stopKeepalive()
+
+	reader := bufio.NewReaderSize(resp.Body, 32*1024)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			writer.Write(line)
+			trimmed := bytes.TrimSpace(line)
+			if len(trimmed) == 0 {
+				writer.Flush()
+			} else if data, ok := bytes.CutPrefix(trimmed, []byte("data:")); ok {
+				inspectRawChunk(usage, bytes.TrimSpace(data))
+			}
+		}
+
+		if readErr != nil {
+			if !errors.Is(readErr, io.EOF) {
+				logger.LogError(r.c.Request.Context(), "raw passthrough stream err: "+readErr.Error())
+			}
+			break
+		}
+	}
+	writer.Flush()
+	r.SetFirstResponseTime(writer.firstResponseTime)
+}
+
+// inspectRawChunk 记录流式分片中的输出文本，上游返回用量时以上游为准
+func inspectRawChunk(usage *types.Usage, data []byte) {
+	if len(data) == 0 || data[0] != '{' {
+		return
+	}
+
+	var chunk struct {
+		Choices []struct {
+			Text  string `json:"text"`
+			Delta struct {
+				Content string `json:"content"`
+			} `json:"delta"`
+		} `json:"choices"`
+		Type  string `json:"type"`
+		Delta string `json:"delta"`
+	}
+	if json.Unmarshal(data, &chunk) != nil {
+		return
+	}
+	for _, choice := range chunk.Choices {
+		usage.AppendText(choice.Text + choice.Delta.Content)
+	}
+	if chunk.Type == "response.output_text.delta" {
+		usage.AppendText(chunk.Delta)
+	}
+
+	applyRawUsage(usage, data)
+}
+
+// applyRawUsage 从响应 JSON 中读取用量，兼容 Chat 和 Responses 接口的格式
+func applyRawUsage(usage *types.Usage, data []byte) {
+	var body struct {
+		Usage    json.RawMessage `json:"usage"`
+		Response *struct {
+			Usage json.RawMessage `json:"usage"`
+		} `json:"response"`
+	}
+	if json.Unmarshal(data, &body) != nil {
+		return
+	}
+	raw := body.Usage
+	if len(raw) == 0 && body.Response != nil {
+		raw = body.Response.Usage
+	}
+	if len(raw) == 0 || string(raw) == "null" {
+		return
+	}
+
+	upstream := &types.Usage{}
+	if json.Unmarshal(raw, upstream) != nil || (upstream.PromptTokens == 0 && upstream.CompletionTokens == 0) {
+		var responsesUsage types.ResponsesUsage
+		if json.Unmarshal(raw, &responsesUsage) != nil || (responsesUsage.InputTokens == 0 && responsesUsage.OutputTokens == 0) {
+			return
+		}
+		upstream = responsesUsage.ToOpenAIUsage()
+	}
+
+	usage.PromptTokens = upstream.PromptTokens
+	usage.CompletionTokens = upstream.CompletionTokens
+	usage.TotalTokens = upstream.TotalTokens
+	if usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	usage.PromptTokensDetails = upstream.PromptTokensDetails
+	usage.CompletionTokensDetails = upstream.CompletionTokensDetails
+}
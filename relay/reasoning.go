@@ -0,0 +1,95 @@
+package relay
+
+import (
+	"encoding/json"
+	"one-api/common/config"
+	"one-api/common/graceful"
+	"one-api/common/requester"
+	"one-api/types"
+)
+
+// redactChatResponse 开启 RedactReasoningContent 时移除推理内容
+func redactChatResponse(response *types.ChatCompletionResponse) {
+	if !config.RedactReasoningContent || response == nil {
+		return
+	}
+
+	for i := range response.Choices {
+		response.Choices[i].Message.ReasoningContent = ""
+		response.Choices[i].Message.Reasoning = ""
+	}
+}
+
+// redactedStream 移除流式响应中的推理内容，只有推理内容的分片不再发送
+type redactedStream struct {
+	requester.StreamReaderInterface[string]
+}
+
+// redactChatStream 开启 RedactReasoningContent 时包装流，包装后不再走透传
+func redactChatStream(stream requester.StreamReaderInterface[string]) requester.StreamReaderInterface[string] {
+	if !config.RedactReasoningContent {
+		return stream
+	}
+
+	return &redactedStream{StreamReaderInterface: stream}
+}
+
+func (s *redactedStream) Recv() (<-chan string, <-chan error) {
+	dataChan, errChan := s.StreamReaderInterface.Recv()
+	outData := make(chan string)
+	outErr := make(chan error)
+
+	graceful.Go(func() {
+		for {
+			select {
+			case data, ok := <-dataChan:
+				if !ok {
+					close(outData)
+					return
+				}
+				if data, ok = redactChunk(data); ok {
+					outData <- data
+				}
+			case err := <-errChan:
+				outErr <- err
+				return
+			}
+		}
+	})
+
+	return outData, outErr
+}
+
+// redactChunk 返回移除推理内容后的分片，分片没有其它内容时返回 false
+func redactChunk(data string) (string, bool) {
+	var chunk types.ChatCompletionStreamResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return data, true
+	}
+
+	redacted := false
+	empty := chunk.Usage == nil
+	for i := range chunk.Choices {
+		delta := &chunk.Choices[i].Delta
+		if delta.ReasoningContent != "" || delta.Reasoning != "" {
+			delta.ReasoningContent = ""
+			delta.Reasoning = ""
+			redacted = true
+		}
+		if delta.Content != "" || delta.Role != "" || len(delta.ToolCalls) > 0 || delta.FunctionCall != nil || len(delta.Image) > 0 || len(delta.Images) > 0 || chunk.Choices[i].FinishReason != nil {
+			empty = false
+		}
+	}
+	if !redacted {
+		return data, true
+	}
+	if empty {
+		return "", false
+	}
+
+	body, err := json.Marshal(chunk)
+	if err != nil {
+		return data, true
+	}
+	return string(body), true
+}
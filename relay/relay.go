@@ -67,6 +67,8 @@ func RelayOnly(c *gin.Context) {
 		return
 	}
 
+	recordVectorStore(c, path, response, channel.Id)
+
 	errWithCode = responseMultipart(c, response)
 
 	if errWithCode != nil {
@@ -83,6 +85,6 @@ func RelayOnly(c *gin.Context) {
 			requestTime = int(time.Since(requestStartTime).Milliseconds())
 		}
 	}
-	model.RecordConsumeLog(c.Request.Context(), c.GetInt("id"), c.GetInt("channel_id"), 0, 0, "", c.GetString("token_name"), 0, "中继:"+path, requestTime, false, nil, c.ClientIP())
+	model.RecordConsumeLog(c.Request.Context(), "", c.GetInt("id"), c.GetInt("channel_id"), 0, 0, "", c.GetInt("token_id"), c.GetString("token_name"), 0, "中继:"+path, requestTime, false, nil, c.ClientIP())
 
 }
@@ -7,30 +7,39 @@ import (
 	"net/http"
 	"one-api/common"
 	"one-api/common/config"
+	"one-api/common/eventstream"
+	"one-api/common/graceful"
 	"one-api/common/logger"
 	"one-api/model"
 	"one-api/types"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
 )
 
 type Quota struct {
-	modelName        string
-	promptTokens     int
-	price            model.Price
-	groupName        string
-	isBackupGroup    bool // 新增字段记录是否使用备用分组
-	backupGroupName  string
-	groupRatio       float64
-	inputRatio       float64
-	outputRatio      float64
-	preConsumedQuota int
-	cacheQuota       int
-	userId           int
-	channelId        int
-	tokenId          int
-	HandelStatus     bool
+	modelName         string
+	promptTokens      int
+	price             model.Price
+	groupName         string
+	isBackupGroup     bool // 新增字段记录是否使用备用分组
+	backupGroupName   string
+	groupRatio        float64
+	inputRatio        float64
+	outputRatio       float64
+	preConsumedQuota  int
+	cacheQuota        int
+	cacheDecreased    int // 已从缓存中扣除的预扣费额度
+	userId            int
+	channelId         int
+	tokenId           int
+	requestId         string
+	journalId         int
+	HandelStatus      bool
+	serviceAccount    bool   // 内部服务账号不计费
+	promptCompression any    // 提示词压缩的结果
+	streamError       string // 流式响应中途上游返回的错误
 
 	startTime         time.Time
 	firstResponseTime time.Time
@@ -41,19 +50,29 @@ func NewQuota(c *gin.Context, modelName string, promptTokens int) *Quota {
 	isBackupGroup := c.GetBool("is_backupGroup")
 
 	quota := &Quota{
-		modelName:     modelName,
-		promptTokens:  promptTokens,
-		userId:        c.GetInt("id"),
-		channelId:     c.GetInt("channel_id"),
-		tokenId:       c.GetInt("token_id"),
-		HandelStatus:  false,
-		isBackupGroup: isBackupGroup, // 记录是否使用备用分组
+		modelName:      modelName,
+		promptTokens:   promptTokens,
+		userId:         c.GetInt("id"),
+		channelId:      c.GetInt("channel_id"),
+		tokenId:        c.GetInt("token_id"),
+		requestId:      c.GetString(logger.RequestIdKey),
+		HandelStatus:   false,
+		isBackupGroup:  isBackupGroup, // 记录是否使用备用分组
+		serviceAccount: c.GetBool("token_service_account"),
 	}
+	quota.promptCompression, _ = c.Get("prompt_compression")
 
 	quota.price = *model.PricingInstance.GetPrice(quota.modelName)
+	if price := unknownModelPrice(quota.modelName); price != nil {
+		quota.price = *price
+	}
 	quota.groupName = c.GetString("token_group")
 	quota.backupGroupName = c.GetString("token_backup_group")
 	quota.groupRatio = c.GetFloat64("group_ratio") // 这里的倍率已经在 common.go 中正确设置了
+	if quota.serviceAccount {
+		// 倍率为 0 时所有计费都为 0，也不会预扣费
+		quota.groupRatio = 0
+	}
 	quota.inputRatio = quota.price.GetInput() * quota.groupRatio
 	quota.outputRatio = quota.price.GetOutput() * quota.groupRatio
 
@@ -85,6 +104,7 @@ func (q *Quota) PreQuotaConsumption() *types.OpenAIErrorWithStatusCode {
 	if err != nil {
 		return common.ErrorWrapper(err, "decrease_user_quota_failed", http.StatusInternalServerError)
 	}
+	q.cacheDecreased = q.preConsumedQuota
 
 	if userQuota > 100*q.preConsumedQuota {
 		// in this case, we do not pre-consume quota
@@ -99,17 +119,56 @@ func (q *Quota) PreQuotaConsumption() *types.OpenAIErrorWithStatusCode {
 			return common.ErrorWrapper(err, "pre_consume_token_quota_failed", http.StatusForbidden)
 		}
 		q.HandelStatus = true
+		q.journalId = model.StartRequestJournal(q.requestId, q.userId, q.tokenId, q.preConsumedQuota)
 	}
 
 	return nil
 }
 
+// QuotaEstimate 试运行时预估的额度
+type QuotaEstimate struct {
+	PriceType        string  `json:"price_type"`
+	GroupRatio       float64 `json:"group_ratio"`
+	InputRatio       float64 `json:"input_ratio"`
+	OutputRatio      float64 `json:"output_ratio"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	PromptQuota      int     `json:"prompt_quota"`       // 按提示词计算的额度，按次计费时为单次额度
+	PreConsumedQuota int     `json:"pre_consumed_quota"` // 实际请求时需要预扣的额度
+	UserQuota        int     `json:"user_quota"`
+	Sufficient       bool    `json:"sufficient"`
+}
+
+// Estimate 按预扣费规则估算额度，不扣费
+func (q *Quota) Estimate() (*QuotaEstimate, error) {
+	estimate := &QuotaEstimate{
+		PriceType:    q.price.Type,
+		GroupRatio:   q.groupRatio,
+		InputRatio:   q.inputRatio,
+		OutputRatio:  q.outputRatio,
+		PromptTokens: q.promptTokens,
+		PromptQuota:  q.GetTotalQuota(q.promptTokens, 0, nil),
+	}
+	if q.price.Type == model.TimesPriceType {
+		estimate.PreConsumedQuota = int(1000 * q.inputRatio)
+	} else if q.price.Input != 0 || q.price.Output != 0 {
+		estimate.PreConsumedQuota = int(float64(q.promptTokens)*q.inputRatio) + config.PreConsumedQuota
+	}
+
+	userQuota, err := model.CacheGetUserQuota(q.userId)
+	if err != nil {
+		return nil, err
+	}
+	estimate.UserQuota = userQuota
+	estimate.Sufficient = userQuota >= estimate.PreConsumedQuota
+	return estimate, nil
+}
+
 // 更新用户实时配额
 func (q *Quota) UpdateUserRealtimeQuota(usage *types.UsageEvent, nowUsage *types.UsageEvent) error {
 	usage.Merge(nowUsage)
 
 	// 不开启Redis，则不更新实时配额
-	if !config.RedisEnabled {
+	if !config.RedisEnabled || q.serviceAccount {
 		return nil
 	}
 
@@ -143,8 +202,25 @@ func (q *Quota) completedQuotaConsumption(usage *types.Usage, tokenName string,
 
 	quota := q.GetTotalQuotaByUsage(usage)
 
+	// 以请求 ID 为幂等键，结算协程重复执行时不会重复扣费
+	billingKey := q.requestId
+	claimed, err := model.ClaimBilling(billingKey, q.userId, quota)
+	if err != nil {
+		logger.LogError(ctx, "failed to claim billing: "+err.Error())
+		billingKey = ""
+	} else if !claimed {
+		logger.LogWarn(ctx, "request "+billingKey+" already billed, skipped")
+		return nil
+	}
+
+	preConsumedQuota := q.preConsumedQuota
+	if q.HandelStatus && !model.FinishRequestJournal(q.journalId) {
+		// 预扣费用已被恢复任务退还，需要按全额结算
+		preConsumedQuota = 0
+	}
+
 	if quota > 0 {
-		quotaDelta := quota - q.preConsumedQuota
+		quotaDelta := quota - preConsumedQuota
 		err := model.PostConsumeTokenQuota(q.tokenId, quotaDelta)
 		if err != nil {
 			return errors.New("error consuming token remain quota: " + err.Error())
@@ -158,11 +234,13 @@ func (q *Quota) completedQuotaConsumption(usage *types.Usage, tokenName string,
 
 	model.RecordConsumeLog(
 		ctx,
+		billingKey,
 		q.userId,
 		q.channelId,
 		usage.PromptTokens,
 		usage.CompletionTokens,
 		q.modelName,
+		q.tokenId,
 		tokenName,
 		quota,
 		"",
@@ -173,32 +251,86 @@ func (q *Quota) completedQuotaConsumption(usage *types.Usage, tokenName string,
 	)
 	model.UpdateUserUsedQuotaAndRequestCount(q.userId, quota)
 
+	if q.streamError != "" && quota > 0 && billingKey != "" && viper.GetBool("refund_on_stream_error") {
+		// 已按实际用量结算，上游中途出错视为失败，全额退还
+		refund := &model.QuotaRefund{
+			RequestId: billingKey,
+			UserId:    q.userId,
+			TokenId:   q.tokenId,
+			ChannelId: q.channelId,
+			ModelName: q.modelName,
+			Quota:     quota,
+			Reason:    "上游流式响应中断：" + q.streamError,
+		}
+		if err := refund.Refund(); err != nil {
+			logger.LogError(ctx, "failed to refund stream error: "+err.Error())
+		}
+	}
+
+	if eventstream.Enabled() {
+		eventstream.Publish(&eventstream.Event{
+			RequestId:        q.requestId,
+			Status:           eventstream.StatusSuccess,
+			StatusCode:       http.StatusOK,
+			UserId:           q.userId,
+			TokenId:          q.tokenId,
+			TokenName:        tokenName,
+			ChannelId:        q.channelId,
+			Group:            q.groupName,
+			Model:            q.modelName,
+			PromptTokens:     usage.PromptTokens,
+			CompletionTokens: usage.CompletionTokens,
+			Quota:            quota,
+			Latency:          q.getRequestTime(),
+			FirstResponse:    q.GetFirstResponseTime(),
+			IsStream:         isStream,
+			SourceIp:         sourceIp,
+		})
+	}
+
 	return nil
 }
 
 func (q *Quota) Undo(c *gin.Context) {
 	tokenId := c.GetInt("token_id")
 	if q.HandelStatus {
-		go func(ctx context.Context) {
+		ctx := c.Request.Context()
+		graceful.Go(func() {
+			if !model.FinishRequestJournal(q.journalId) {
+				// 已被恢复任务退还
+				return
+			}
 			// return pre-consumed quota
 			err := model.PostConsumeTokenQuota(tokenId, -q.preConsumedQuota)
 			if err != nil {
 				logger.LogError(ctx, "error return pre-consumed quota: "+err.Error())
 			}
-		}(c.Request.Context())
+		})
 	}
 }
 
 func (q *Quota) Consume(c *gin.Context, usage *types.Usage, isStream bool) {
 	tokenName := c.GetString("token_name")
 	q.startTime = c.GetTime("requestStartTime")
+	q.streamError = c.GetString(config.GinStreamErrorKey)
 	// 如果没有报错，则消费配额
-	go func(ctx context.Context) {
-		err := q.completedQuotaConsumption(usage, tokenName, isStream, c.ClientIP(), ctx)
+	ctx := c.Request.Context()
+	clientIP := c.ClientIP()
+	graceful.Go(func() {
+		err := q.completedQuotaConsumption(usage, tokenName, isStream, clientIP, ctx)
 		if err != nil {
 			logger.LogError(ctx, err.Error())
 		}
-	}(c.Request.Context())
+	})
+}
+
+// RemainingQuota 按本次消耗推算结算后用户的剩余额度，结算在请求结束后异步进行
+func (q *Quota) RemainingQuota(consumed int) (int, error) {
+	userQuota, err := model.CacheGetUserQuota(q.userId)
+	if err != nil {
+		return 0, err
+	}
+	return userQuota - (consumed - q.cacheDecreased), nil
 }
 
 func (q *Quota) GetInputRatio() float64 {
@@ -235,6 +367,14 @@ func (q *Quota) GetLogMeta(usage *types.Usage) map[string]any {
 		meta["extra_billing"] = q.extraBillingData
 	}
 
+	if q.serviceAccount {
+		meta["service_account"] = true
+	}
+
+	if q.promptCompression != nil {
+		meta["prompt_compression"] = q.promptCompression
+	}
+
 	return meta
 }
 
@@ -0,0 +1,65 @@
+package relay_util
+
+import (
+	"fmt"
+	"one-api/common/notify"
+	"one-api/model"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	UnknownModelPolicyDefault  = "default"   // 按内置默认价格计费
+	UnknownModelPolicyReject   = "reject"    // 拒绝请求
+	UnknownModelPolicyCatchAll = "catch_all" // 转发到兜底渠道，按 unknown_model.ratio 计费
+	UnknownModelPolicyBill     = "bill"      // 按 unknown_model.ratio 计费
+)
+
+// 同一个模型每天只通知一次
+const unknownModelAlertInterval = 24 * time.Hour
+
+var unknownModelAlerts sync.Map
+
+// UnknownModelPolicy 请求未配置价格的模型时的处理方式
+func UnknownModelPolicy() string {
+	switch policy := viper.GetString("unknown_model.policy"); policy {
+	case UnknownModelPolicyReject, UnknownModelPolicyCatchAll, UnknownModelPolicyBill:
+		return policy
+	}
+	return UnknownModelPolicyDefault
+}
+
+// IsUnknownModel 模型没有配置价格
+func IsUnknownModel(modelName string) bool {
+	return modelName != "" && !model.PricingInstance.HasPrice(modelName)
+}
+
+// unknownModelPrice 未配置价格的模型的计费价格，未启用计费策略时返回 nil
+func unknownModelPrice(modelName string) *model.Price {
+	policy := UnknownModelPolicy()
+	if (policy != UnknownModelPolicyBill && policy != UnknownModelPolicyCatchAll) || !IsUnknownModel(modelName) {
+		return nil
+	}
+
+	alertUnknownModel(modelName, policy)
+	ratio := viper.GetFloat64("unknown_model.ratio")
+	return &model.Price{
+		Model:  modelName,
+		Type:   model.TokensPriceType,
+		Input:  ratio,
+		Output: ratio,
+	}
+}
+
+// alertUnknownModel 通知管理员有请求使用了未配置价格的模型
+func alertUnknownModel(modelName, policy string) {
+	now := time.Now()
+	if last, ok := unknownModelAlerts.Load(modelName); ok && now.Sub(last.(time.Time)) < unknownModelAlertInterval {
+		return
+	}
+	unknownModelAlerts.Store(modelName, now)
+
+	notify.Send("未配置价格的模型", fmt.Sprintf("模型 %s 没有配置价格，已按 unknown_model 策略 %s 以倍率 %v 计费，请及时配置价格。", modelName, policy, viper.GetFloat64("unknown_model.ratio")))
+}
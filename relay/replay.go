@@ -0,0 +1,240 @@
+package relay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/graceful"
+	"one-api/common/logger"
+	"one-api/common/utils"
+	"one-api/model"
+	"one-api/relay/relay_util"
+	"one-api/types"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const replayTokenName = "replay"
+
+// ReplayRequest 重放参数，为空时使用日志中的渠道和原请求的模型
+type ReplayRequest struct {
+	ChannelId int    `json:"channel_id"`
+	Model     string `json:"model"`
+}
+
+// ReplayResult 重放结果，重放按内部服务账号处理，不扣费，Quota 为按当前价格计算的额度
+type ReplayResult struct {
+	RequestId  string       `json:"request_id"`
+	ChannelId  int          `json:"channel_id"`
+	Model      string       `json:"model"`
+	StatusCode int          `json:"status_code"`
+	Response   any          `json:"response"`
+	Usage      *types.Usage `json:"usage"`
+	Quota      int          `json:"quota"`
+}
+
+// captureRequest 开启 request_capture 时异步保存客户端的 JSON 请求体
+func captureRequest(c *gin.Context) {
+	if !model.RequestCaptureEnabled() || c.ContentType() != "application/json" {
+		return
+	}
+	body, ok := utils.GetGinValue[[]byte](c, config.GinRequestBodyKey)
+	if !ok || len(body) == 0 {
+		return
+	}
+
+	capture := &model.RequestCapture{
+		RequestId:   c.GetString(logger.RequestIdKey),
+		UserId:      c.GetInt("id"),
+		Method:      c.Request.Method,
+		Path:        c.Request.URL.Path,
+		ContentType: c.GetHeader("Content-Type"),
+		Body:        string(body),
+	}
+	graceful.Go(func() {
+		model.RecordRequestCapture(capture)
+	})
+}
+
+// ReplayLog 用保存的请求体重放日志对应的请求，用于复现用户反馈的问题
+func ReplayLog(c *gin.Context) {
+	if c.GetInt("tenant_id") != 0 {
+		common.APIRespondWithError(c, http.StatusOK, errors.New("租户管理员不能重放请求"))
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	var req ReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	result, err := replayLog(c, id, &req)
+	if err != nil {
+		common.APIRespondWithError(c, http.StatusOK, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "",
+		"data":    result,
+	})
+}
+
+func replayLog(c *gin.Context, logId int, req *ReplayRequest) (*ReplayResult, error) {
+	log, err := model.GetLogById(logId)
+	if err != nil {
+		return nil, errors.New("日志不存在")
+	}
+	if log.RequestId == "" {
+		return nil, errors.New("日志没有记录请求 ID")
+	}
+	capture, err := model.GetRequestCapture(log.RequestId)
+	if err != nil {
+		return nil, errors.New("请求体未保存或已过期")
+	}
+	if strings.HasPrefix(capture.Path, "/gemini") {
+		// Gemini 原生接口的模型在路径中
+		return nil, errors.New("暂不支持重放 Gemini 原生接口的请求")
+	}
+
+	body := []byte(capture.Body)
+	if req.Model != "" {
+		if body, err = replaceRequestModel(body, req.Model); err != nil {
+			return nil, err
+		}
+	}
+
+	channelId := req.ChannelId
+	if channelId == 0 {
+		channelId = log.ChannelId
+	}
+
+	w := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(w)
+	httpReq, err := http.NewRequestWithContext(c.Request.Context(), capture.Method, capture.Path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", capture.ContentType)
+	ctx.Request = httpReq
+
+	if err := setReplayContext(ctx, c.GetInt("id"), channelId, log.Metadata.Data()); err != nil {
+		return nil, err
+	}
+
+	Relay(ctx)
+
+	result := &ReplayResult{
+		RequestId:  ctx.GetString(logger.RequestIdKey),
+		ChannelId:  ctx.GetInt("channel_id"),
+		Model:      ctx.GetString("original_model"),
+		StatusCode: w.Code,
+	}
+	if result.Model == "" {
+		result.Model = log.ModelName
+	}
+	result.Response, result.Usage = parseReplayResponse(w.Body.Bytes())
+	if result.Usage != nil {
+		// 按非服务账号计算本次请求应扣的额度
+		ctx.Set("token_service_account", false)
+		result.Quota = relay_util.NewQuota(ctx, result.Model, result.Usage.PromptTokens).GetTotalQuotaByUsage(result.Usage)
+	}
+	return result, nil
+}
+
+// setReplayContext 设置鉴权和分组中间件写入的上下文，使用原请求的分组，按内部服务账号处理不扣费
+func setReplayContext(ctx *gin.Context, userId, channelId int, meta map[string]any) error {
+	requestId := utils.GetTimeString() + utils.GetRandomString(8)
+	ctx.Set(logger.RequestIdKey, requestId)
+	ctx.Set("requestStartTime", time.Now())
+	ctx.Request = ctx.Request.WithContext(context.WithValue(ctx.Request.Context(), logger.RequestIdKey, requestId))
+
+	userGroup, err := model.CacheGetUserGroup(userId)
+	if err != nil {
+		return err
+	}
+	group := userGroup
+	if name, _ := meta["group_name"].(string); name != "" {
+		group = name
+	}
+	groupRatio := model.GlobalUserGroupRatio.GetBySymbol(group)
+	if groupRatio == nil {
+		return errors.New("分组 " + group + " 不存在")
+	}
+
+	ctx.Set("id", userId)
+	ctx.Set("token_name", replayTokenName)
+	ctx.Set("token_service_account", true)
+	ctx.Set("group", userGroup)
+	ctx.Set("token_group", group)
+	ctx.Set("group_ratio", groupRatio.Ratio)
+	if channelId > 0 {
+		ctx.Set("specific_channel_id", channelId)
+	}
+	return nil
+}
+
+// replaceRequestModel 替换请求体中的模型，其它字段保持原样
+func replaceRequestModel(body []byte, modelName string) ([]byte, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	value, err := json.Marshal(modelName)
+	if err != nil {
+		return nil, err
+	}
+	payload["model"] = value
+	return json.Marshal(payload)
+}
+
+// parseReplayResponse 解析响应和用量，流式响应返回全部分片，用量取最后一个带 usage 的分片
+func parseReplayResponse(body []byte) (any, *types.Usage) {
+	var response struct {
+		Usage *types.Usage `json:"usage"`
+	}
+	if json.Valid(body) {
+		_ = json.Unmarshal(body, &response)
+		return json.RawMessage(body), response.Usage
+	}
+
+	var chunks []string
+	var usage *types.Usage
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 8*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		chunks = append(chunks, line)
+
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue
+		}
+		response.Usage = nil
+		if json.Unmarshal([]byte(strings.TrimSpace(data)), &response) == nil && response.Usage != nil {
+			usage = response.Usage
+		}
+	}
+	return chunks, usage
+}
@@ -0,0 +1,146 @@
+package relay
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"one-api/common"
+	"one-api/model"
+	"one-api/providers/claude"
+	"one-api/providers/gemini"
+	"one-api/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// getRequestLimit 合并分组和令牌的请求大小限制
+func getRequestLimit(c *gin.Context) model.RequestLimitSetting {
+	limit := model.GlobalUserGroupRatio.GetRequestLimit(requestGroup(c))
+	if setting, exists := c.Get("token_setting"); exists {
+		if tokenSetting, ok := setting.(*model.TokenSetting); ok && tokenSetting != nil {
+			limit = limit.Merge(tokenSetting.Limits.RequestLimit)
+		}
+	}
+	return limit
+}
+
+// limitRequestBody 限制请求体大小，Content-Length 超出时直接拒绝，否则在读取时限制
+func limitRequestBody(c *gin.Context, limit model.RequestLimitSetting) *types.OpenAIErrorWithStatusCode {
+	if limit.MaxBodySize <= 0 {
+		return nil
+	}
+
+	maxBytes := int64(limit.MaxBodySize) * 1024
+	if c.Request.ContentLength > maxBytes {
+		return bodyTooLargeError(limit.MaxBodySize)
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+	return nil
+}
+
+// requestBodyError 读取请求体超出限制时返回 413，其它错误按请求格式错误处理
+func requestBodyError(err error, limit model.RequestLimitSetting) *types.OpenAIErrorWithStatusCode {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return bodyTooLargeError(limit.MaxBodySize)
+	}
+	return common.StringErrorWrapperLocal(err.Error(), "one_hub_error", http.StatusBadRequest)
+}
+
+func bodyTooLargeError(maxBodySize int) *types.OpenAIErrorWithStatusCode {
+	message := fmt.Sprintf("request body exceeds the limit of %d KB", maxBodySize)
+	return common.StringErrorWrapperLocal(message, "request_too_large", http.StatusRequestEntityTooLarge)
+}
+
+// checkRequestLimit 检查消息数量和 base64 图片大小，远程图片地址不在检查范围内
+func checkRequestLimit(limit model.RequestLimitSetting, request any) *types.OpenAIErrorWithStatusCode {
+	if limit.MaxMessages <= 0 && limit.MaxImageSize <= 0 {
+		return nil
+	}
+
+	messages := 0
+	var images []string
+
+	switch req := request.(type) {
+	case *types.ChatCompletionRequest:
+		messages = len(req.Messages)
+		if limit.MaxImageSize > 0 {
+			for _, message := range req.Messages {
+				if _, ok := message.Content.([]any); !ok {
+					continue
+				}
+				for _, part := range message.ParseContent() {
+					if part.ImageURL != nil {
+						images = append(images, part.ImageURL.URL)
+					}
+				}
+			}
+		}
+	case *claude.ClaudeRequest:
+		messages = len(req.Messages)
+		if limit.MaxImageSize > 0 {
+			for _, message := range req.Messages {
+				contents, ok := message.Content.([]any)
+				if !ok {
+					continue
+				}
+				for _, content := range contents {
+					contentMap, ok := content.(map[string]any)
+					if !ok {
+						continue
+					}
+					if source, ok := contentMap["source"].(map[string]any); ok {
+						if data, ok := source["data"].(string); ok {
+							images = append(images, data)
+						}
+					}
+				}
+			}
+		}
+	case *gemini.GeminiChatRequest:
+		messages = len(req.Contents)
+		if limit.MaxImageSize > 0 {
+			for _, content := range req.Contents {
+				for _, part := range content.Parts {
+					if part.InlineData != nil {
+						images = append(images, part.InlineData.Data)
+					}
+				}
+			}
+		}
+	}
+
+	if limit.MaxMessages > 0 && messages > limit.MaxMessages {
+		message := fmt.Sprintf("request contains %d messages, exceeds the limit of %d", messages, limit.MaxMessages)
+		return common.StringErrorWrapperLocal(message, "too_many_messages", http.StatusBadRequest)
+	}
+
+	maxImageBytes := limit.MaxImageSize * 1024
+	for _, image := range images {
+		if limit.MaxImageSize > 0 && base64Size(image) > maxImageBytes {
+			message := fmt.Sprintf("image exceeds the limit of %d KB", limit.MaxImageSize)
+			return common.StringErrorWrapperLocal(message, "image_too_large", http.StatusRequestEntityTooLarge)
+		}
+	}
+
+	return nil
+}
+
+// base64Size 估算 base64 或 data URL 解码后的字节数，非 base64 数据返回 0
+func base64Size(data string) int {
+	if strings.HasPrefix(data, "http://") || strings.HasPrefix(data, "https://") {
+		return 0
+	}
+	if strings.HasPrefix(data, "data:") {
+		index := strings.Index(data, ",")
+		if index < 0 {
+			return 0
+		}
+		data = data[index+1:]
+	}
+
+	padding := len(data) - len(strings.TrimRight(data, "="))
+	return len(data)*3/4 - padding
+}
@@ -0,0 +1,123 @@
+package relay
+
+import (
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/relay/relay_util"
+	"one-api/types"
+	"strconv"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	channelIdHeader      = "X-Oneapi-Channel-Id"
+	upstreamModelHeader  = "X-Oneapi-Model"
+	quotaConsumedHeader  = "X-Oneapi-Quota-Consumed"
+	quotaRemainingHeader = "X-Oneapi-Quota-Remaining"
+	retryCountHeader     = "X-Oneapi-Retry-Count"
+)
+
+// metadataWriter 在第一次写入响应前补充响应头
+type metadataWriter struct {
+	gin.ResponseWriter
+	once   sync.Once
+	before func()
+}
+
+func (w *metadataWriter) WriteHeaderNow() {
+	w.once.Do(w.before)
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *metadataWriter) Write(data []byte) (int, error) {
+	w.once.Do(w.before)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *metadataWriter) WriteString(s string) (int, error) {
+	w.once.Do(w.before)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *metadataWriter) Flush() {
+	w.once.Do(w.before)
+	w.ResponseWriter.Flush()
+}
+
+// responseMetadata 中继响应的元数据：渠道 ID（需要开启 RelayChannelHeaderEnabled）、映射后的模型、重试次数、消耗额度和剩余额度
+// 流式响应或心跳提前写入响应时还没有用量，额度通过 trailer 在响应结束时返回
+type responseMetadata struct {
+	c         *gin.Context
+	writer    gin.ResponseWriter
+	quota     *relay_util.Quota
+	usage     *types.Usage
+	channelId int
+	stream    bool
+	trailer   bool
+}
+
+// attachResponseMetadata 替换 c.Writer，发送请求后需要调用 detach
+func attachResponseMetadata(relay RelayBaseInterface, quota *relay_util.Quota, usage *types.Usage) *responseMetadata {
+	c := relay.getContext()
+	m := &responseMetadata{
+		c:         c,
+		writer:    c.Writer,
+		quota:     quota,
+		usage:     usage,
+		channelId: relay.getProvider().GetChannel().Id,
+		stream:    relay.IsStream(),
+	}
+	c.Writer = &metadataWriter{ResponseWriter: m.writer, before: m.writeHeaders}
+	return m
+}
+
+func (m *responseMetadata) writeHeaders() {
+	header := m.writer.Header()
+	if headersHidden(m.c) {
+		for key, value := range responseRuleHeaders(m.c, m.channelId) {
+			header.Set(key, value)
+		}
+		return
+	}
+	if config.RelayChannelHeaderEnabled {
+		header.Set(channelIdHeader, strconv.Itoa(m.channelId))
+	}
+	if upstreamModel := m.c.GetString("new_model"); upstreamModel != "" {
+		header.Set(upstreamModelHeader, upstreamModel)
+	}
+	header.Set(retryCountHeader, strconv.Itoa(m.c.GetInt("retry_count")))
+	for key, value := range responseRuleHeaders(m.c, m.channelId) {
+		header.Set(key, value)
+	}
+
+	if m.stream || m.usage.TotalTokens == 0 {
+		header.Add("Trailer", quotaConsumedHeader)
+		header.Add("Trailer", quotaRemainingHeader)
+		m.trailer = true
+		return
+	}
+	m.setQuota()
+}
+
+func (m *responseMetadata) setQuota() {
+	consumed := m.quota.GetTotalQuotaByUsage(m.usage)
+	header := m.writer.Header()
+	header.Set(quotaConsumedHeader, strconv.Itoa(consumed))
+
+	remaining, err := m.quota.RemainingQuota(consumed)
+	if err != nil {
+		logger.LogError(m.c.Request.Context(), "failed to get remaining quota: "+err.Error())
+		return
+	}
+	header.Set(quotaRemainingHeader, strconv.Itoa(remaining))
+}
+
+// detach 恢复 c.Writer，请求成功时在 trailer 中写入额度
+func (m *responseMetadata) detach(success bool) {
+	m.c.Writer = m.writer
+	if success && m.trailer {
+		m.setQuota()
+	}
+}
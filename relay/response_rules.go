@@ -0,0 +1,317 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"one-api/common/graceful"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/types"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// 响应后处理规则：按渠道、分组和模型匹配，截断上游忽略的停止序列、删除上游特有的标记，并添加响应头
+
+type responseRuleConfig struct {
+	Name               string            `mapstructure:"name"`
+	Channels           []int             `mapstructure:"channels"`
+	Groups             []string          `mapstructure:"groups"`
+	Models             []string          `mapstructure:"models"`
+	StopSequences      []string          `mapstructure:"stop_sequences"`
+	EnforceRequestStop bool              `mapstructure:"enforce_request_stop"`
+	Strip              []string          `mapstructure:"strip"`
+	Headers            map[string]string `mapstructure:"headers"`
+}
+
+type responseRule struct {
+	responseRuleConfig
+	strip []*regexp.Regexp
+}
+
+var loadResponseRules = sync.OnceValue(func() []*responseRule {
+	var configs []responseRuleConfig
+	if err := viper.UnmarshalKey("response_rules", &configs); err != nil {
+		logger.SysError("failed to parse response_rules: " + err.Error())
+		return nil
+	}
+
+	rules := make([]*responseRule, 0, len(configs))
+	for _, config := range configs {
+		rule := &responseRule{responseRuleConfig: config}
+		valid := true
+		for _, pattern := range config.Strip {
+			regex, err := regexp.Compile(pattern)
+			if err != nil {
+				logger.SysError(fmt.Sprintf("invalid strip pattern of response rule %s: %s", config.Name, err.Error()))
+				valid = false
+				break
+			}
+			rule.strip = append(rule.strip, regex)
+		}
+		if valid {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+})
+
+func (rule *responseRule) match(channelId int, group, modelName string) bool {
+	return (len(rule.Channels) == 0 || slices.Contains(rule.Channels, channelId)) &&
+		(len(rule.Groups) == 0 || slices.Contains(rule.Groups, group)) &&
+		(len(rule.Models) == 0 || slices.Contains(rule.Models, modelName))
+}
+
+func matchResponseRules(c *gin.Context, channelId int) []*responseRule {
+	var matched []*responseRule
+	for _, rule := range loadResponseRules() {
+		if rule.match(channelId, requestGroup(c), c.GetString("original_model")) {
+			matched = append(matched, rule)
+		}
+	}
+	return matched
+}
+
+// responseRuleHeaders 本次请求需要添加的响应头，值中的 {model} 和 {request_id} 替换为请求的模型和请求 ID
+func responseRuleHeaders(c *gin.Context, channelId int) map[string]string {
+	headers := make(map[string]string)
+	replacer := strings.NewReplacer("{model}", c.GetString("original_model"), "{request_id}", c.GetString(logger.RequestIdKey))
+	for _, rule := range matchResponseRules(c, channelId) {
+		for key, value := range rule.Headers {
+			headers[key] = replacer.Replace(value)
+		}
+	}
+	return headers
+}
+
+// responsePostProcessor 合并匹配的规则对聊天响应的处理
+type responsePostProcessor struct {
+	stops   []string
+	strip   []*regexp.Regexp
+	maxStop int // 停止序列的最大字符数，流式响应需要保留这么多字符检测跨分片的停止序列
+}
+
+// newResponsePostProcessor 没有需要处理的内容时返回 nil
+func newResponsePostProcessor(c *gin.Context, channelId int, request *types.ChatCompletionRequest) *responsePostProcessor {
+	p := &responsePostProcessor{}
+	for _, rule := range matchResponseRules(c, channelId) {
+		p.stops = append(p.stops, rule.StopSequences...)
+		if rule.EnforceRequestStop {
+			p.stops = append(p.stops, requestStopSequences(request.Stop)...)
+		}
+		p.strip = append(p.strip, rule.strip...)
+	}
+	p.stops = slices.DeleteFunc(p.stops, func(stop string) bool { return stop == "" })
+	if len(p.stops) == 0 && len(p.strip) == 0 {
+		return nil
+	}
+	for _, stop := range p.stops {
+		p.maxStop = max(p.maxStop, len([]rune(stop)))
+	}
+	return p
+}
+
+// requestStopSequences 请求的 stop 可以是字符串或字符串数组
+func requestStopSequences(stop any) []string {
+	switch stop := stop.(type) {
+	case string:
+		return []string{stop}
+	case []any:
+		stops := make([]string, 0, len(stop))
+		for _, item := range stop {
+			if s, ok := item.(string); ok {
+				stops = append(stops, s)
+			}
+		}
+		return stops
+	case []string:
+		return stop
+	}
+	return nil
+}
+
+// cut 返回第一个停止序列之前的内容
+func (p *responsePostProcessor) cut(text string) (string, bool) {
+	index := -1
+	for _, stop := range p.stops {
+		if i := strings.Index(text, stop); i >= 0 && (index < 0 || i < index) {
+			index = i
+		}
+	}
+	if index < 0 {
+		return text, false
+	}
+	return text[:index], true
+}
+
+func (p *responsePostProcessor) clean(text string) string {
+	for _, regex := range p.strip {
+		text = regex.ReplaceAllString(text, "")
+	}
+	return text
+}
+
+// postProcessChatResponse 处理非流式聊天响应
+func postProcessChatResponse(c *gin.Context, channelId int, request *types.ChatCompletionRequest, response *types.ChatCompletionResponse) {
+	p := newResponsePostProcessor(c, channelId, request)
+	if p == nil || response == nil {
+		return
+	}
+
+	for i := range response.Choices {
+		content, ok := response.Choices[i].Message.Content.(string)
+		if !ok {
+			continue
+		}
+		if cut, stopped := p.cut(content); stopped {
+			content = cut
+			response.Choices[i].FinishReason = types.FinishReasonStop
+		}
+		response.Choices[i].Message.Content = p.clean(content)
+	}
+}
+
+// postProcessedStream 处理流式聊天响应，每个选项保留末尾可能是停止序列开头的内容，
+// 遇到停止序列后以 stop 结束该选项，之后的内容不再发送；删除标记只处理单个分片内的内容
+type postProcessedStream struct {
+	requester.StreamReaderInterface[string]
+	processor *responsePostProcessor
+
+	pending  map[int]string
+	finished map[int]bool
+	last     *types.ChatCompletionStreamResponse
+}
+
+// postProcessChatStream 需要处理时包装流，包装后不再走透传
+func postProcessChatStream(c *gin.Context, channelId int, request *types.ChatCompletionRequest, stream requester.StreamReaderInterface[string]) requester.StreamReaderInterface[string] {
+	processor := newResponsePostProcessor(c, channelId, request)
+	if processor == nil {
+		return stream
+	}
+
+	return &postProcessedStream{
+		StreamReaderInterface: stream,
+		processor:             processor,
+		pending:               make(map[int]string),
+		finished:              make(map[int]bool),
+	}
+}
+
+func (s *postProcessedStream) Recv() (<-chan string, <-chan error) {
+	dataChan, errChan := s.StreamReaderInterface.Recv()
+	outData := make(chan string)
+	outErr := make(chan error)
+
+	graceful.Go(func() {
+		for {
+			select {
+			case data, ok := <-dataChan:
+				if !ok {
+					if flushed, ok := s.flush(); ok {
+						outData <- flushed
+					}
+					close(outData)
+					return
+				}
+				if data, ok = s.process(data); ok {
+					outData <- data
+				}
+			case err := <-errChan:
+				if flushed, ok := s.flush(); ok {
+					outData <- flushed
+				}
+				outErr <- err
+				return
+			}
+		}
+	})
+
+	return outData, outErr
+}
+
+// process 处理单个分片，分片没有需要发送的内容时返回 false
+func (s *postProcessedStream) process(data string) (string, bool) {
+	var chunk types.ChatCompletionStreamResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return data, true
+	}
+	s.last = &chunk
+
+	choices := chunk.Choices[:0]
+	for _, choice := range chunk.Choices {
+		if s.finished[choice.Index] {
+			continue
+		}
+
+		text, stopped := s.processor.cut(s.pending[choice.Index] + choice.Delta.Content)
+		switch {
+		case stopped:
+			choice.FinishReason = types.FinishReasonStop
+			s.finished[choice.Index] = true
+			delete(s.pending, choice.Index)
+		case choice.FinishReason != nil && choice.FinishReason != "":
+			delete(s.pending, choice.Index)
+		default:
+			tail := lastRunes(text, s.processor.maxStop-1)
+			s.pending[choice.Index] = tail
+			text = text[:len(text)-len(tail)]
+		}
+
+		choice.Delta.Content = s.processor.clean(text)
+		delta := choice.Delta
+		if delta.Content == "" && delta.Role == "" && len(delta.ToolCalls) == 0 && delta.FunctionCall == nil && len(delta.Image) == 0 && len(delta.Images) == 0 &&
+			delta.ReasoningContent == "" && delta.Reasoning == "" && (choice.FinishReason == nil || choice.FinishReason == "") {
+			continue
+		}
+		choices = append(choices, choice)
+	}
+	chunk.Choices = choices
+	if len(chunk.Choices) == 0 && chunk.Usage == nil {
+		return "", false
+	}
+
+	body, err := json.Marshal(chunk)
+	if err != nil {
+		return data, true
+	}
+	return string(body), true
+}
+
+// flush 上游没有返回结束原因时发送保留的内容
+func (s *postProcessedStream) flush() (string, bool) {
+	if s.last == nil || len(s.pending) == 0 {
+		return "", false
+	}
+
+	chunk := *s.last
+	chunk.Usage = nil
+	chunk.Choices = nil
+	indexes := make([]int, 0, len(s.pending))
+	for index := range s.pending {
+		indexes = append(indexes, index)
+	}
+	slices.Sort(indexes)
+	for _, index := range indexes {
+		if text := s.processor.clean(s.pending[index]); text != "" {
+			chunk.Choices = append(chunk.Choices, types.ChatCompletionStreamChoice{
+				Index: index,
+				Delta: types.ChatCompletionStreamChoiceDelta{Content: text},
+			})
+		}
+	}
+	s.pending = make(map[int]string)
+	if len(chunk.Choices) == 0 {
+		return "", false
+	}
+
+	body, err := json.Marshal(chunk)
+	if err != nil {
+		return "", false
+	}
+	return string(body), true
+}
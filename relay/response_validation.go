@@ -0,0 +1,45 @@
+package relay
+
+import (
+	"errors"
+	"one-api/common/requester"
+	"one-api/types"
+)
+
+// 上游返回的 JSON 可以解析但不是预期的结构时视为无效响应，与无法解析的响应一样可以重试
+
+func validateChatResponse(response *types.ChatCompletionResponse) *types.OpenAIErrorWithStatusCode {
+	if response == nil {
+		return requester.InvalidResponseError(errors.New("empty response"))
+	}
+	// 内容审核拦截时可能没有选项
+	if len(response.Choices) == 0 && response.PromptFilterResults == nil {
+		return requester.InvalidResponseError(errors.New("missing choices"))
+	}
+	return nil
+}
+
+func validateCompletionResponse(response *types.CompletionResponse) *types.OpenAIErrorWithStatusCode {
+	if response == nil {
+		return requester.InvalidResponseError(errors.New("empty response"))
+	}
+	if len(response.Choices) == 0 {
+		return requester.InvalidResponseError(errors.New("missing choices"))
+	}
+	return nil
+}
+
+func validateEmbeddingResponse(response *types.EmbeddingResponse) *types.OpenAIErrorWithStatusCode {
+	if response == nil {
+		return requester.InvalidResponseError(errors.New("empty response"))
+	}
+	if len(response.Data) == 0 {
+		return requester.InvalidResponseError(errors.New("missing data"))
+	}
+	return nil
+}
+
+// isInvalidResponse 上游的响应无效，即使没有配置重试次数也重试一次
+func isInvalidResponse(apiErr *types.OpenAIErrorWithStatusCode) bool {
+	return apiErr != nil && apiErr.Code == requester.InvalidResponseCode
+}
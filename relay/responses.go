@@ -32,6 +32,10 @@ func (r *relayResponses) setRequest() error {
 		return err
 	}
 
+	if err := bindVectorStoreChannel(r.c, r.responsesRequest.Tools); err != nil {
+		return err
+	}
+
 	r.setOriginalModel(r.responsesRequest.Model)
 
 	return nil
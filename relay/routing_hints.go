@@ -0,0 +1,51 @@
+package relay
+
+import (
+	"one-api/model"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 客户端路由提示，只能在用户本就可用的渠道中进一步筛选
+const (
+	channelTagHeader      = "X-Oneapi-Channel-Tag"
+	preferRegionHeader    = "X-Oneapi-Prefer-Region"
+	excludeChannelsHeader = "X-Oneapi-Exclude-Channels"
+)
+
+// routingHintFilters 根据请求头限定渠道标签和排除指定渠道
+func routingHintFilters(c *gin.Context) []model.ChannelsFilterFunc {
+	filters := make([]model.ChannelsFilterFunc, 0, 2)
+
+	if tag := strings.TrimSpace(c.GetHeader(channelTagHeader)); tag != "" {
+		filters = append(filters, model.FilterChannelTag(tag))
+	}
+
+	if exclude := c.GetHeader(excludeChannelsHeader); exclude != "" {
+		channelIds := make([]int, 0)
+		for _, value := range strings.Split(exclude, ",") {
+			if channelId, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+				channelIds = append(channelIds, channelId)
+			}
+		}
+		if len(channelIds) > 0 {
+			filters = append(filters, model.FilterChannelId(channelIds))
+		}
+	}
+
+	return filters
+}
+
+// preferRegionFilter 优先选择的区域，请求头未指定时按提示词语言选择，没有该区域的渠道时不限制
+func preferRegionFilter(c *gin.Context) model.ChannelsFilterFunc {
+	region := strings.TrimSpace(c.GetHeader(preferRegionHeader))
+	if region == "" {
+		region = languageRegion(c)
+	}
+	if region == "" {
+		return nil
+	}
+	return model.FilterChannelRegion(region)
+}
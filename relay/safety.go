@@ -0,0 +1,289 @@
+package relay
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/graceful"
+	"one-api/common/ipguard"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/model"
+	"one-api/relay/hooks"
+	"one-api/safty"
+	"one-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestGroup 获取本次请求使用的分组，令牌未指定分组时使用用户分组
+func requestGroup(c *gin.Context) string {
+	groupName := c.GetString("token_group")
+	if groupName == "" {
+		groupName = c.GetString("group")
+	}
+	return groupName
+}
+
+// safePolicy 分组的敏感词处理策略，按令牌的审查级别调整
+func safePolicy(c *gin.Context) model.SafePolicy {
+	return applyModerationLevel(c, model.GlobalUserGroupRatio.GetSafePolicy(requestGroup(c)))
+}
+
+// recordModeration 异步记录敏感词命中
+func recordModeration(c *gin.Context, stage, action string, words []string) {
+	if len(words) == 0 {
+		return
+	}
+
+	log := &model.ModerationLog{
+		UserId:    c.GetInt("id"),
+		TokenId:   c.GetInt("token_id"),
+		GroupName: requestGroup(c),
+		ModelName: c.GetString("original_model"),
+		RequestId: c.GetString(logger.RequestIdKey),
+		Stage:     stage,
+		Action:    action,
+	}
+	graceful.Go(func() {
+		model.RecordModerationLog(log, words)
+	})
+}
+
+// moderatePrompt 按分组策略审查提示词，mask 策略在不支持脱敏的请求中按 block 处理
+func moderatePrompt(c *gin.Context, contents ...any) *types.OpenAIErrorWithStatusCode {
+	if !config.EnableSafe {
+		return nil
+	}
+
+	policy := safePolicy(c)
+	if !policy.Prompt {
+		return nil
+	}
+
+	var words []string
+	for _, content := range contents {
+		if content == nil {
+			continue
+		}
+
+		result, _ := safty.CheckContent(content)
+		if result.IsSafe {
+			continue
+		}
+
+		if policy.Action != model.SafeActionLog {
+			ipguard.RecordFailure(c.ClientIP(), "moderation block")
+			recordModeration(c, model.ModerationStagePrompt, model.SafeActionBlock, result.Details)
+			return common.StringErrorWrapperLocal(result.Reason, result.Code, http.StatusBadRequest)
+		}
+		words = append(words, result.Details...)
+	}
+
+	recordModeration(c, model.ModerationStagePrompt, model.SafeActionLog, words)
+	return nil
+}
+
+// moderateChatPrompt 审查聊天请求，mask 策略下将敏感词替换为 *
+func moderateChatPrompt(c *gin.Context, request *types.ChatCompletionRequest) *types.OpenAIErrorWithStatusCode {
+	if !config.EnableSafe {
+		return nil
+	}
+
+	policy := safePolicy(c)
+	if policy.Prompt && policy.Action == model.SafeActionMask {
+		var words []string
+		hooks.RewriteChatText(request, func(text string) string {
+			masked, matched := safty.MaskWords(text)
+			words = append(words, matched...)
+			return masked
+		})
+		recordModeration(c, model.ModerationStagePrompt, model.SafeActionMask, words)
+		return nil
+	}
+
+	contents := make([]any, 0, len(request.Messages))
+	for _, message := range request.Messages {
+		contents = append(contents, message.Content)
+	}
+	return moderatePrompt(c, contents...)
+}
+
+// getResponsePolicy 获取需要审查响应时的策略
+func getResponsePolicy(c *gin.Context) (model.SafePolicy, bool) {
+	if !config.EnableSafe || safty.MaxWordLength() == 0 {
+		return model.SafePolicy{}, false
+	}
+
+	policy := safePolicy(c)
+	return policy, policy.Response
+}
+
+// moderateChatResponse 审查非流式聊天响应，block 策略下清空内容并以 content_filter 结束
+func moderateChatResponse(c *gin.Context, response *types.ChatCompletionResponse) {
+	policy, ok := getResponsePolicy(c)
+	if !ok || response == nil {
+		return
+	}
+
+	var words []string
+	for i := range response.Choices {
+		content, ok := response.Choices[i].Message.Content.(string)
+		if !ok {
+			continue
+		}
+
+		switch policy.Action {
+		case model.SafeActionMask:
+			masked, matched := safty.MaskWords(content)
+			response.Choices[i].Message.Content = masked
+			words = append(words, matched...)
+		case model.SafeActionBlock:
+			if matched := safty.MatchWords(content); len(matched) > 0 {
+				response.Choices[i].Message.Content = ""
+				response.Choices[i].FinishReason = types.FinishReasonContentFilter
+				words = append(words, matched...)
+			}
+		default:
+			words = append(words, safty.MatchWords(content)...)
+		}
+	}
+
+	recordModeration(c, model.ModerationStageResponse, policy.Action, words)
+}
+
+// moderatedStream 审查流式聊天响应
+// mask 只处理单个分片内的敏感词；block 会保留已输出内容的末尾，检测跨分片的敏感词，命中后以 content_filter 结束
+type moderatedStream struct {
+	requester.StreamReaderInterface[string]
+	c      *gin.Context
+	policy model.SafePolicy
+
+	tails   map[int]string
+	words   []string
+	blocked bool
+}
+
+// moderateChatStream 分组需要审查响应时包装流，包装后不再走透传
+func moderateChatStream(c *gin.Context, stream requester.StreamReaderInterface[string]) requester.StreamReaderInterface[string] {
+	policy, ok := getResponsePolicy(c)
+	if !ok {
+		return stream
+	}
+
+	return &moderatedStream{
+		StreamReaderInterface: stream,
+		c:                     c,
+		policy:                policy,
+		tails:                 make(map[int]string),
+	}
+}
+
+func (s *moderatedStream) Recv() (<-chan string, <-chan error) {
+	dataChan, errChan := s.StreamReaderInterface.Recv()
+	outData := make(chan string)
+	outErr := make(chan error)
+
+	graceful.Go(func() {
+		defer func() {
+			recordModeration(s.c, model.ModerationStageResponse, s.policy.Action, s.words)
+		}()
+
+		for {
+			select {
+			case data, ok := <-dataChan:
+				if !ok {
+					close(outData)
+					return
+				}
+				if s.blocked {
+					// 拦截后继续读取，避免上游读取协程阻塞
+					continue
+				}
+
+				data, blocked := s.process(data)
+				outData <- data
+				if blocked {
+					s.blocked = true
+					outErr <- io.EOF
+				}
+			case err := <-errChan:
+				if !s.blocked {
+					outErr <- err
+				}
+				return
+			}
+		}
+	})
+
+	return outData, outErr
+}
+
+// process 审查单个分片，返回需要输出的数据以及是否已拦截
+func (s *moderatedStream) process(data string) (string, bool) {
+	var chunk types.ChatCompletionStreamResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return data, false
+	}
+
+	changed := false
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		if choice.Delta.Content == "" {
+			continue
+		}
+
+		switch s.policy.Action {
+		case model.SafeActionMask:
+			masked, matched := safty.MaskWords(choice.Delta.Content)
+			if len(matched) > 0 {
+				choice.Delta.Content = masked
+				s.words = append(s.words, matched...)
+				changed = true
+			}
+		case model.SafeActionBlock:
+			tail := s.tails[choice.Index]
+			if matched := safty.MatchWords(tail + choice.Delta.Content); len(matched) > 0 {
+				s.words = append(s.words, matched...)
+				return s.blockedChunk(&chunk, choice.Index), true
+			}
+			s.tails[choice.Index] = lastRunes(tail+choice.Delta.Content, safty.MaxWordLength()-1)
+		default:
+			s.words = append(s.words, safty.MatchWords(choice.Delta.Content)...)
+		}
+	}
+
+	if !changed {
+		return data, false
+	}
+
+	responseBody, err := json.Marshal(chunk)
+	if err != nil {
+		return data, false
+	}
+	return string(responseBody), false
+}
+
+func (s *moderatedStream) blockedChunk(chunk *types.ChatCompletionStreamResponse, index int) string {
+	chunk.Choices = []types.ChatCompletionStreamChoice{{
+		Index:        index,
+		FinishReason: types.FinishReasonContentFilter,
+	}}
+	chunk.Usage = nil
+
+	responseBody, _ := json.Marshal(chunk)
+	return string(responseBody)
+}
+
+func lastRunes(text string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[len(runes)-n:])
+}
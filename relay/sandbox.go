@@ -0,0 +1,205 @@
+package relay
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/requester"
+	"one-api/common/utils"
+	"one-api/model"
+	"one-api/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 沙盒令牌：返回固定格式的模拟响应，不请求上游、不扣费，供客户接入和 CI 测试使用
+// 相同的请求返回相同的内容，只支持聊天、补全和向量接口
+
+const (
+	sandboxHeader              = "X-Oneapi-Sandbox"
+	sandboxEmbeddingDimensions = 1536
+)
+
+func isSandbox(c *gin.Context) bool {
+	setting, exists := c.Get("token_setting")
+	if !exists {
+		return false
+	}
+	tokenSetting, ok := setting.(*model.TokenSetting)
+	return ok && tokenSetting.Sandbox
+}
+
+// sandboxDigest 请求内容的摘要，模拟响应由摘要决定
+func sandboxDigest(request any) []byte {
+	body, _ := json.Marshal(request)
+	digest := sha256.Sum256(body)
+	return digest[:]
+}
+
+func sandboxText(modelName string, digest []byte) string {
+	return fmt.Sprintf("This is a sandbox response from %s. No upstream was called and no quota was consumed. Request digest: %s.", modelName, hex.EncodeToString(digest[:8]))
+}
+
+func sandboxUsage(promptTokens, completionTokens int) *types.Usage {
+	return &types.Usage{
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		TotalTokens:      promptTokens + completionTokens,
+	}
+}
+
+// sandboxRelay 返回模拟响应
+func sandboxRelay(relay RelayBaseInterface) {
+	c := relay.getContext()
+	c.Header(sandboxHeader, "true")
+	modelName := relay.getOriginalModel()
+	id := fmt.Sprintf("sandbox-%s", utils.GetUUID())
+
+	switch r := relay.(type) {
+	case *relayChat:
+		request := &r.chatRequest
+		text := sandboxText(modelName, sandboxDigest(request.Messages))
+		usage := sandboxUsage(common.CountTokenMessages(request.Messages, modelName, config.PreCostDefault), common.CountTokenText(text, modelName))
+		if !request.Stream {
+			c.JSON(http.StatusOK, &types.ChatCompletionResponse{
+				ID:      id,
+				Object:  "chat.completion",
+				Created: utils.GetTimestamp(),
+				Model:   modelName,
+				Choices: []types.ChatCompletionChoice{{
+					Message:      types.ChatCompletionMessage{Role: types.ChatMessageRoleAssistant, Content: text},
+					FinishReason: types.FinishReasonStop,
+				}},
+				Usage: usage,
+			})
+			return
+		}
+
+		chunks := make([]any, 0)
+		chunk := func(delta types.ChatCompletionStreamChoiceDelta, finishReason any) *types.ChatCompletionStreamResponse {
+			return &types.ChatCompletionStreamResponse{
+				ID:      id,
+				Object:  "chat.completion.chunk",
+				Created: utils.GetTimestamp(),
+				Model:   modelName,
+				Choices: []types.ChatCompletionStreamChoice{{Delta: delta, FinishReason: finishReason}},
+			}
+		}
+		chunks = append(chunks, chunk(types.ChatCompletionStreamChoiceDelta{Role: types.ChatMessageRoleAssistant}, nil))
+		for _, word := range sandboxWords(text) {
+			chunks = append(chunks, chunk(types.ChatCompletionStreamChoiceDelta{Content: word}, nil))
+		}
+		chunks = append(chunks, chunk(types.ChatCompletionStreamChoiceDelta{}, types.FinishReasonStop))
+		if request.StreamOptions != nil && request.StreamOptions.IncludeUsage {
+			usageChunk := chunk(types.ChatCompletionStreamChoiceDelta{}, nil)
+			usageChunk.Choices = []types.ChatCompletionStreamChoice{}
+			usageChunk.Usage = usage
+			chunks = append(chunks, usageChunk)
+		}
+		writeSandboxStream(c, chunks)
+
+	case *relayCompletions:
+		request := &r.request
+		text := sandboxText(modelName, sandboxDigest(request.Prompt))
+		usage := sandboxUsage(common.CountTokenInput(request.Prompt, modelName), common.CountTokenText(text, modelName))
+		response := func(text, finishReason string) *types.CompletionResponse {
+			return &types.CompletionResponse{
+				ID:      id,
+				Object:  "text_completion",
+				Created: utils.GetTimestamp(),
+				Model:   modelName,
+				Choices: []types.CompletionChoice{{Text: text, FinishReason: finishReason}},
+			}
+		}
+		if !request.Stream {
+			completion := response(text, types.FinishReasonStop)
+			completion.Usage = usage
+			c.JSON(http.StatusOK, completion)
+			return
+		}
+
+		chunks := make([]any, 0)
+		words := sandboxWords(text)
+		for i, word := range words {
+			finishReason := ""
+			if i == len(words)-1 {
+				finishReason = types.FinishReasonStop
+			}
+			chunks = append(chunks, response(word, finishReason))
+		}
+		writeSandboxStream(c, chunks)
+
+	case *relayEmbeddings:
+		request := &r.request
+		dimensions := sandboxEmbeddingDimensions
+		if request.Dimensions > 0 {
+			dimensions = request.Dimensions
+		}
+		inputs := request.ParseInput()
+		if len(inputs) == 0 {
+			inputs = []string{""}
+		}
+		data := make([]types.Embedding, 0, len(inputs))
+		for i, input := range inputs {
+			data = append(data, types.Embedding{
+				Object:    "embedding",
+				Embedding: sandboxEmbedding(sandboxDigest(input), dimensions),
+				Index:     i,
+			})
+		}
+		c.JSON(http.StatusOK, &types.EmbeddingResponse{
+			Object: "list",
+			Data:   data,
+			Model:  modelName,
+			Usage:  sandboxUsage(common.CountTokenInput(request.Input, modelName), 0),
+		})
+
+	default:
+		relay.HandleJsonError(common.StringErrorWrapperLocal("sandbox tokens only support chat, completions and embeddings", "sandbox_not_supported", http.StatusBadRequest))
+	}
+}
+
+// sandboxWords 按空格切分，流式响应每个分片一个单词
+func sandboxWords(text string) []string {
+	return strings.SplitAfter(text, " ")
+}
+
+// sandboxEmbedding 由摘要生成的单位向量
+func sandboxEmbedding(digest []byte, dimensions int) []float64 {
+	vector := make([]float64, dimensions)
+	seed := binary.BigEndian.Uint64(digest[:8]) | 1
+	norm := 0.0
+	for i := range vector {
+		// xorshift 伪随机数，保证相同输入得到相同向量
+		seed ^= seed << 13
+		seed ^= seed >> 7
+		seed ^= seed << 17
+		vector[i] = float64(seed%2000)/1000 - 1
+		norm += vector[i] * vector[i]
+	}
+	norm = math.Sqrt(norm)
+	if norm > 0 {
+		for i := range vector {
+			vector[i] /= norm
+		}
+	}
+	return vector
+}
+
+func writeSandboxStream(c *gin.Context, chunks []any) {
+	requester.SetEventStreamHeaders(c)
+	c.Status(http.StatusOK)
+	for _, chunk := range chunks {
+		data, _ := json.Marshal(chunk)
+		c.Writer.Write([]byte("data: " + string(data) + "\n\n"))
+	}
+	c.Writer.Write([]byte("data: [DONE]\n\n"))
+	c.Writer.Flush()
+}
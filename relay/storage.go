@@ -0,0 +1,141 @@
+package relay
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"one-api/common/config"
+	"one-api/common/graceful"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/common/storage"
+	"one-api/common/utils"
+	"one-api/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPersistImageSize 转存上游图片时允许下载的最大字节数
+const maxPersistImageSize = 32 << 20
+
+var imageExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+	"image/gif":  ".gif",
+}
+
+// persistImages 将生成的图片保存到对象存储，上游的临时地址和 base64 替换为稳定地址
+// 只有明确请求 url 格式时才去掉 base64 数据，否则同时返回两者；转存失败时保持上游结果不变
+func persistImages(c *gin.Context, response *types.ImageResponse, responseFormat string) {
+	if !config.StorageImagePersistEnabled || !storage.ObjectStorageEnabled() || response == nil {
+		return
+	}
+
+	ctx := c.Request.Context()
+	for i := range response.Data {
+		image := &response.Data[i]
+
+		var data []byte
+		var err error
+		switch {
+		case image.B64JSON != "":
+			data, err = decodeImageBase64(image.B64JSON)
+		case image.URL != "" && !storage.IsStoredURL(image.URL):
+			data, err = downloadImage(ctx, image.URL)
+		default:
+			continue
+		}
+		if err != nil {
+			logger.LogError(ctx, "persist image error: "+err.Error())
+			continue
+		}
+
+		contentType := http.DetectContentType(data)
+		ext, ok := imageExtensions[contentType]
+		if !ok {
+			ext = ".png"
+		}
+
+		url, err := storage.PutObject(storage.ObjectKey(storage.ImagePrefix, utils.GetUUID()+ext), data, contentType)
+		if err != nil {
+			logger.LogError(ctx, "persist image error: "+err.Error())
+			continue
+		}
+
+		image.URL = url
+		if responseFormat == "url" {
+			image.B64JSON = ""
+		}
+	}
+}
+
+func decodeImageBase64(data string) ([]byte, error) {
+	if strings.HasPrefix(data, "data:") {
+		if _, after, ok := strings.Cut(data, ","); ok {
+			data = after
+		}
+	}
+	return base64.StdEncoding.DecodeString(data)
+}
+
+func downloadImage(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := requester.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download image status code %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPersistImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxPersistImageSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxPersistImageSize)
+	}
+	return data, nil
+}
+
+var transcriptExtensions = map[string]string{
+	"json": ".json",
+	"text": ".txt",
+	"srt":  ".srt",
+	"vtt":  ".vtt",
+}
+
+// persistTranscript 异步保存语音转文字结果，文件名为请求 ID，便于与日志对应
+func persistTranscript(c *gin.Context, response *types.AudioResponseWrapper, responseFormat string) {
+	if !config.StorageTranscriptEnabled || !storage.ObjectStorageEnabled() || response == nil || len(response.Body) == 0 {
+		return
+	}
+
+	ext, ok := transcriptExtensions[responseFormat]
+	if !ok {
+		ext = ".json"
+	}
+	name := c.GetString(logger.RequestIdKey)
+	if name == "" {
+		name = utils.GetUUID()
+	}
+	contentType := response.Headers["Content-Type"]
+	key := storage.ObjectKey(storage.TranscriptPrefix, name+ext)
+	body := response.Body
+	ctx := c.Request.Context()
+
+	graceful.Go(func() {
+		if _, err := storage.PutObject(key, body, contentType); err != nil {
+			logger.LogError(ctx, "persist transcript error: "+err.Error())
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package relay
+
+import (
+	"encoding/json"
+	"one-api/types"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// streamAggregator 把连续的纯文本增量合并为一个 chunk，每隔 stream_aggregation_interval 毫秒发送一次
+// 只合并 chat.completion.chunk，角色、工具调用、结束原因和用量等 chunk 原样发送，发送前先发送已合并的内容
+type streamAggregator struct {
+	interval   time.Duration
+	timer      *time.Timer
+	pending    *types.ChatCompletionStreamResponse
+	pendingRaw string
+	merged     bool
+}
+
+// newStreamAggregator 未开启时返回 nil，nil 的方法不做合并
+func newStreamAggregator() *streamAggregator {
+	interval := time.Duration(viper.GetInt("stream_aggregation_interval")) * time.Millisecond
+	if interval <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(interval)
+	timer.Stop()
+	return &streamAggregator{interval: interval, timer: timer}
+}
+
+// Add 返回需要立即发送的数据
+func (a *streamAggregator) Add(data string) []string {
+	if a == nil {
+		return []string{data}
+	}
+
+	var chunk types.ChatCompletionStreamResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil || !aggregatable(&chunk) {
+		return append(a.Flush(), data)
+	}
+
+	if a.pending == nil {
+		a.pending = &chunk
+		a.pendingRaw = data
+		a.merged = false
+		a.timer.Reset(a.interval)
+		return nil
+	}
+
+	if chunk.ID != a.pending.ID || chunk.Model != a.pending.Model || chunk.Choices[0].Index != a.pending.Choices[0].Index {
+		flushed := a.Flush()
+		a.Add(data)
+		return flushed
+	}
+
+	delta := &a.pending.Choices[0].Delta
+	delta.Content += chunk.Choices[0].Delta.Content
+	delta.ReasoningContent += chunk.Choices[0].Delta.ReasoningContent
+	delta.Reasoning += chunk.Choices[0].Delta.Reasoning
+	a.merged = true
+	return nil
+}
+
+// C 到达发送间隔，未开启时返回 nil
+func (a *streamAggregator) C() <-chan time.Time {
+	if a == nil {
+		return nil
+	}
+	return a.timer.C
+}
+
+// Flush 返回已合并的内容
+func (a *streamAggregator) Flush() []string {
+	if a == nil || a.pending == nil {
+		return nil
+	}
+	a.timer.Stop()
+
+	// 没有合并时原样发送，保留上游的其它字段
+	data := a.pendingRaw
+	if a.merged {
+		if body, err := json.Marshal(a.pending); err == nil {
+			data = string(body)
+		}
+	}
+	a.pending = nil
+	a.pendingRaw = ""
+	return []string{data}
+}
+
+func (a *streamAggregator) Stop() {
+	if a != nil {
+		a.timer.Stop()
+	}
+}
+
+// aggregatable 只有单个选项、只包含文本增量的 chunk 可以合并
+func aggregatable(chunk *types.ChatCompletionStreamResponse) bool {
+	if chunk.Object != "chat.completion.chunk" || len(chunk.Choices) != 1 || chunk.Usage != nil || chunk.PromptAnnotations != nil {
+		return false
+	}
+
+	choice := chunk.Choices[0]
+	if choice.FinishReason != nil || choice.Usage != nil || choice.ContentFilterResults != nil {
+		return false
+	}
+
+	delta := choice.Delta
+	return delta.Role == "" && delta.FunctionCall == nil && len(delta.ToolCalls) == 0 && len(delta.Image) == 0 && len(delta.Images) == 0
+}
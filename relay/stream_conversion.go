@@ -0,0 +1,297 @@
+package relay
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"one-api/common"
+	"one-api/common/requester"
+	"one-api/model"
+	providersBase "one-api/providers/base"
+	"one-api/types"
+	"sort"
+	"time"
+)
+
+// 非流式响应切分为 chunk 时每个 chunk 的最大字符数
+const convertedChunkRunes = 20
+
+// streamConversion 渠道的流式模式与客户端请求不一致时返回渠道的模式，否则返回空
+func (r *relayChat) streamConversion() string {
+	mode := r.provider.GetChannel().StreamMode
+	if (mode == model.ChannelStreamModeNonStream && r.chatRequest.Stream) || (mode == model.ChannelStreamModeStream && !r.chatRequest.Stream) {
+		return mode
+	}
+	return ""
+}
+
+// sendStreamFromResponse 上游只支持非流式，将完整的响应切分为 chunk 返回给流式客户端
+func (r *relayChat) sendStreamFromResponse(chatProvider providersBase.ChatInterface) (err *types.OpenAIErrorWithStatusCode, done bool) {
+	streamOptions := r.chatRequest.StreamOptions
+	r.chatRequest.Stream = false
+	r.chatRequest.StreamOptions = nil
+	response, err := chatProvider.CreateChatCompletion(&r.chatRequest)
+	r.chatRequest.Stream = true
+	r.chatRequest.StreamOptions = streamOptions
+	if err != nil {
+		return
+	}
+	if err = validateChatResponse(response); err != nil {
+		return
+	}
+	redactChatResponse(response)
+	moderateChatResponse(r.c, response)
+	postProcessChatResponse(r.c, r.provider.GetChannel().Id, &r.chatRequest, response)
+
+	if r.heartbeat != nil {
+		r.heartbeat.Stop()
+	}
+
+	doneStr := func() string {
+		return r.getUsageResponse()
+	}
+
+	var firstResponseTime time.Time
+	firstResponseTime, err = responseStreamClient(r.c, r.legacyChatStream(&chunkStream{chunks: responseToChunks(response)}), doneStr)
+	r.SetFirstResponseTime(firstResponseTime)
+	if err != nil {
+		done = true
+	}
+	return
+}
+
+// sendResponseFromStream 上游只支持流式，合并所有 chunk 后返回给非流式客户端
+func (r *relayChat) sendResponseFromStream(chatProvider providersBase.ChatInterface) (err *types.OpenAIErrorWithStatusCode, done bool) {
+	r.chatRequest.Stream = true
+	r.chatRequest.StreamOptions = &types.StreamOptions{IncludeUsage: true}
+	stream, err := chatProvider.CreateChatCompletionStream(&r.chatRequest)
+	r.chatRequest.Stream = false
+	r.chatRequest.StreamOptions = nil
+	if err != nil {
+		return
+	}
+
+	// 还没有写入客户端，出错时可以重试其它渠道
+	response, streamErr := chunksToResponse(stream)
+	if streamErr != nil {
+		err = common.ErrorWrapper(streamErr, "stream_error", http.StatusBadGateway)
+		return
+	}
+
+	usage := r.provider.GetUsage()
+	if usage.CompletionTokens == 0 && usage.TextBuilder.Len() > 0 {
+		usage.CompletionTokens = common.CountTokenText(usage.TextBuilder.String(), r.modelName)
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	response.Usage = usage
+	redactChatResponse(response)
+	r.legacyChatResponse(response)
+	moderateChatResponse(r.c, response)
+	postProcessChatResponse(r.c, r.provider.GetChannel().Id, &r.chatRequest, response)
+
+	if r.heartbeat != nil {
+		r.heartbeat.Stop()
+	}
+
+	if err = responseJsonClient(r.c, response); err != nil {
+		done = true
+	}
+	return
+}
+
+// chunkStream 把已有的 chunk 作为流式响应返回，chunk 全部被读取后才发送结束信号
+type chunkStream struct {
+	chunks []string
+}
+
+func (s *chunkStream) Recv() (<-chan string, <-chan error) {
+	dataChan := make(chan string)
+	errChan := make(chan error, 1)
+	go func() {
+		for _, chunk := range s.chunks {
+			dataChan <- chunk
+		}
+		errChan <- io.EOF
+	}()
+	return dataChan, errChan
+}
+
+func (s *chunkStream) Close() {}
+
+// responseToChunks 每个选项依次发送角色、推理内容、文本、工具调用和结束原因
+func responseToChunks(response *types.ChatCompletionResponse) []string {
+	chunks := make([]string, 0)
+	appendChunk := func(index int, delta types.ChatCompletionStreamChoiceDelta, finishReason any) {
+		chunk := types.ChatCompletionStreamResponse{
+			ID:      response.ID,
+			Object:  "chat.completion.chunk",
+			Created: response.Created,
+			Model:   response.Model,
+			Choices: []types.ChatCompletionStreamChoice{{Index: index, Delta: delta, FinishReason: finishReason}},
+		}
+		if body, err := json.Marshal(chunk); err == nil {
+			chunks = append(chunks, string(body))
+		}
+	}
+
+	for _, choice := range response.Choices {
+		message := choice.Message
+		role := message.Role
+		if role == "" {
+			role = types.ChatMessageRoleAssistant
+		}
+		appendChunk(choice.Index, types.ChatCompletionStreamChoiceDelta{Role: role}, nil)
+
+		for _, text := range splitRunes(message.ReasoningContent, convertedChunkRunes) {
+			appendChunk(choice.Index, types.ChatCompletionStreamChoiceDelta{ReasoningContent: text}, nil)
+		}
+		for _, text := range splitRunes(message.StringContent(), convertedChunkRunes) {
+			appendChunk(choice.Index, types.ChatCompletionStreamChoiceDelta{Content: text}, nil)
+		}
+		if len(message.ToolCalls) > 0 || message.FunctionCall != nil {
+			toolCalls := make([]*types.ChatCompletionToolCalls, 0, len(message.ToolCalls))
+			for i, toolCall := range message.ToolCalls {
+				call := *toolCall
+				call.Index = i
+				toolCalls = append(toolCalls, &call)
+			}
+			appendChunk(choice.Index, types.ChatCompletionStreamChoiceDelta{ToolCalls: toolCalls, FunctionCall: message.FunctionCall}, nil)
+		}
+
+		finishReason := choice.FinishReason
+		if finishReason == "" {
+			finishReason = types.FinishReasonStop
+		}
+		appendChunk(choice.Index, types.ChatCompletionStreamChoiceDelta{}, finishReason)
+	}
+
+	return chunks
+}
+
+func splitRunes(text string, size int) []string {
+	runes := []rune(text)
+	parts := make([]string, 0, len(runes)/size+1)
+	for start := 0; start < len(runes); start += size {
+		parts = append(parts, string(runes[start:min(start+size, len(runes))]))
+	}
+	return parts
+}
+
+// chunksToResponse 读取所有 chunk，按选项合并文本和工具调用
+func chunksToResponse(stream requester.StreamReaderInterface[string]) (*types.ChatCompletionResponse, error) {
+	defer stream.Close()
+
+	response := &types.ChatCompletionResponse{Object: "chat.completion"}
+	choices := make(map[int]*types.ChatCompletionChoice)
+	contents := make(map[int]string)
+	toolCalls := make(map[int]map[int]*types.ChatCompletionToolCalls)
+
+	merge := func(data string) error {
+		var chunk types.ChatCompletionStreamResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return err
+		}
+		if response.ID == "" {
+			response.ID = chunk.ID
+			response.Created = chunk.Created
+			response.Model = chunk.Model
+		}
+
+		for _, streamChoice := range chunk.Choices {
+			choice, ok := choices[streamChoice.Index]
+			if !ok {
+				choice = &types.ChatCompletionChoice{Index: streamChoice.Index, Message: types.ChatCompletionMessage{Role: types.ChatMessageRoleAssistant}}
+				choices[streamChoice.Index] = choice
+				toolCalls[streamChoice.Index] = make(map[int]*types.ChatCompletionToolCalls)
+			}
+
+			delta := streamChoice.Delta
+			if delta.Role != "" {
+				choice.Message.Role = delta.Role
+			}
+			contents[streamChoice.Index] += delta.Content
+			choice.Message.ReasoningContent += delta.ReasoningContent + delta.Reasoning
+			if delta.FunctionCall != nil {
+				if choice.Message.FunctionCall == nil {
+					choice.Message.FunctionCall = &types.ChatCompletionToolCallsFunction{}
+				}
+				choice.Message.FunctionCall.Name += delta.FunctionCall.Name
+				choice.Message.FunctionCall.Arguments += delta.FunctionCall.Arguments
+			}
+			for _, toolCall := range delta.ToolCalls {
+				call, ok := toolCalls[streamChoice.Index][toolCall.Index]
+				if !ok {
+					call = &types.ChatCompletionToolCalls{Index: toolCall.Index, Function: &types.ChatCompletionToolCallsFunction{}}
+					toolCalls[streamChoice.Index][toolCall.Index] = call
+				}
+				if toolCall.Id != "" {
+					call.Id = toolCall.Id
+				}
+				if toolCall.Type != "" {
+					call.Type = toolCall.Type
+				}
+				if toolCall.Function != nil {
+					call.Function.Name += toolCall.Function.Name
+					call.Function.Arguments += toolCall.Function.Arguments
+				}
+			}
+			if reason, ok := streamChoice.FinishReason.(string); ok && reason != "" {
+				choice.FinishReason = reason
+			}
+		}
+		return nil
+	}
+
+	dataChan, errChan := stream.Recv()
+	var streamErr error
+	for streamErr == nil {
+		select {
+		case data, ok := <-dataChan:
+			if !ok {
+				streamErr = io.EOF
+			} else if err := merge(data); err != nil {
+				return nil, err
+			}
+		case streamErr = <-errChan:
+			// 结束信号可能先于缓冲中的数据到达
+			for drained := false; !drained; {
+				select {
+				case data, ok := <-dataChan:
+					if !ok {
+						drained = true
+					} else if err := merge(data); err != nil {
+						return nil, err
+					}
+				default:
+					drained = true
+				}
+			}
+		}
+	}
+	if !errors.Is(streamErr, io.EOF) {
+		return nil, streamErr
+	}
+
+	for index, choice := range choices {
+		if contents[index] != "" {
+			choice.Message.Content = contents[index]
+		}
+		calls := make([]*types.ChatCompletionToolCalls, 0, len(toolCalls[index]))
+		for _, call := range toolCalls[index] {
+			calls = append(calls, call)
+		}
+		sort.Slice(calls, func(i, j int) bool {
+			return calls[i].Index < calls[j].Index
+		})
+		if len(calls) > 0 {
+			choice.Message.ToolCalls = calls
+		}
+		response.Choices = append(response.Choices, *choice)
+	}
+	sort.Slice(response.Choices, func(i, j int) bool {
+		return response.Choices[i].Index < response.Choices[j].Index
+	})
+
+	return response, nil
+}
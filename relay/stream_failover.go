@@ -0,0 +1,199 @@
+package relay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"one-api/common/config"
+	"one-api/common/graceful"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/common/utils"
+	"one-api/model"
+	providersBase "one-api/providers/base"
+	"one-api/types"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// supportsContinuation 渠道会把末尾的 assistant 消息作为回复的开头继续生成
+func supportsContinuation(channel *model.Channel, modelName string) bool {
+	switch channel.Type {
+	case config.ChannelTypeAnthropic:
+		return true
+	case config.ChannelTypeBedrock, config.ChannelTypeVertexAI:
+		return strings.Contains(modelName, "claude")
+	}
+	return false
+}
+
+// failoverStream 上游流式响应中途断开时，把已生成的文本作为 assistant 前缀提交给其它渠道续写，
+// 客户端看到的仍是同一个流
+type failoverStream struct {
+	requester.StreamReaderInterface[string]
+	c      *gin.Context
+	resume func(prefix string) (requester.StreamReaderInterface[string], error)
+	times  int
+
+	id          string
+	model       string
+	text        strings.Builder
+	resumed     bool
+	unresumable bool // 已返回工具调用、结束原因或多个选项时无法续写
+}
+
+// withStreamFailover 开启 stream_failover_times 时包装流式响应
+func (r *relayChat) withStreamFailover(stream requester.StreamReaderInterface[string]) requester.StreamReaderInterface[string] {
+	times := viper.GetInt("stream_failover_times")
+	if times <= 0 {
+		return stream
+	}
+
+	return &failoverStream{
+		StreamReaderInterface: stream,
+		c:                     r.c,
+		resume:                r.resumeStream,
+		times:                 times,
+	}
+}
+
+func (s *failoverStream) Recv() (<-chan string, <-chan error) {
+	outData := make(chan string)
+	outErr := make(chan error)
+
+	graceful.Go(func() {
+		for {
+			dataChan, errChan := s.StreamReaderInterface.Recv()
+			err := s.forward(dataChan, errChan, outData)
+			if err == nil {
+				close(outData)
+				return
+			}
+			if errors.Is(err, io.EOF) || !s.canResume() {
+				outErr <- err
+				return
+			}
+
+			stream, resumeErr := s.resume(s.text.String())
+			if resumeErr != nil {
+				logger.LogError(s.c.Request.Context(), "failed to resume stream: "+resumeErr.Error())
+				outErr <- err
+				return
+			}
+			s.StreamReaderInterface.Close()
+			s.StreamReaderInterface = stream
+			s.resumed = true
+		}
+	})
+
+	return outData, outErr
+}
+
+// forward 转发分片直到上游结束，数据通道关闭时返回 nil
+func (s *failoverStream) forward(dataChan <-chan string, errChan <-chan error, outData chan<- string) error {
+	for {
+		select {
+		case data, ok := <-dataChan:
+			if !ok {
+				return nil
+			}
+			outData <- s.process(data)
+		case err := <-errChan:
+			return err
+		}
+	}
+}
+
+// process 记录已生成的文本，续写后的分片沿用第一个流的 ID 和模型
+func (s *failoverStream) process(data string) string {
+	var chunk types.ChatCompletionStreamResponse
+	if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+		return data
+	}
+
+	if s.id == "" {
+		s.id = chunk.ID
+		s.model = chunk.Model
+	}
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		if choice.Index != 0 || len(choice.Delta.ToolCalls) > 0 || choice.Delta.FunctionCall != nil || choice.FinishReason != nil {
+			s.unresumable = true
+		}
+		s.text.WriteString(choice.Delta.Content)
+		if s.resumed {
+			choice.Delta.Role = ""
+		}
+	}
+
+	if !s.resumed {
+		return data
+	}
+	chunk.ID = s.id
+	chunk.Model = s.model
+	body, err := json.Marshal(chunk)
+	if err != nil {
+		return data
+	}
+	return string(body)
+}
+
+func (s *failoverStream) canResume() bool {
+	if s.times <= 0 || s.unresumable || s.c.Request.Context().Err() != nil {
+		return false
+	}
+	s.times--
+	return true
+}
+
+// resumeStream 换一个渠道续写，已有文本时只选择支持续写的渠道，计费沿用同一个用量
+func (r *relayChat) resumeStream(prefix string) (requester.StreamReaderInterface[string], error) {
+	usage := r.provider.GetUsage()
+	interrupted := r.provider.GetChannel().Id
+	failed := interrupted
+
+	for {
+		skipChannelIds, ok := utils.GetGinValue[[]int](r.c, "skip_channel_ids")
+		if !ok {
+			skipChannelIds = make([]int, 0)
+		}
+		r.c.Set("skip_channel_ids", append(skipChannelIds, failed))
+
+		if err := r.setProvider(r.getOriginalModel()); err != nil {
+			return nil, err
+		}
+		channel := r.provider.GetChannel()
+		if prefix == "" || supportsContinuation(channel, r.modelName) {
+			break
+		}
+		failed = channel.Id
+	}
+
+	chatProvider, ok := r.provider.(providersBase.ChatInterface)
+	if !ok {
+		return nil, errors.New("channel not implemented")
+	}
+	r.provider.SetUsage(usage)
+
+	request := r.chatRequest
+	request.Model = r.modelName
+	if prefix != "" {
+		// 部分渠道不接受以空白结尾的 assistant 前缀
+		request.Messages = append(slices.Clone(r.chatRequest.Messages), types.ChatCompletionMessage{
+			Role:    types.ChatMessageRoleAssistant,
+			Content: strings.TrimRight(prefix, " \t\r\n"),
+		})
+	}
+
+	stream, apiErr := chatProvider.CreateChatCompletionStream(&request)
+	if apiErr != nil {
+		return nil, errors.New(apiErr.Message)
+	}
+	logger.LogWarn(r.c.Request.Context(), fmt.Sprintf("stream from channel #%d interrupted, resumed on channel #%d", interrupted, r.provider.GetChannel().Id))
+
+	return stream, nil
+}
@@ -0,0 +1,45 @@
+package relay
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// 流式响应的保活注释，SSE 客户端会忽略以冒号开头的行
+const streamKeepaliveText = ": keepalive\n\n"
+
+// streamKeepalive 上游超过 stream_keepalive_interval 秒没有数据时向客户端发送保活注释，避免代理和负载均衡断开空闲连接
+type streamKeepalive struct {
+	interval time.Duration
+	timer    *time.Timer
+}
+
+func newStreamKeepalive() *streamKeepalive {
+	k := &streamKeepalive{interval: time.Duration(viper.GetInt("stream_keepalive_interval")) * time.Second}
+	if k.interval > 0 {
+		k.timer = time.NewTimer(k.interval)
+	}
+	return k
+}
+
+// C 未开启时返回 nil，在 select 中永远不会就绪
+func (k *streamKeepalive) C() <-chan time.Time {
+	if k.timer == nil {
+		return nil
+	}
+	return k.timer.C
+}
+
+// Reset 写入数据后重新计时
+func (k *streamKeepalive) Reset() {
+	if k.timer != nil {
+		k.timer.Reset(k.interval)
+	}
+}
+
+func (k *streamKeepalive) Stop() {
+	if k.timer != nil {
+		k.timer.Stop()
+	}
+}
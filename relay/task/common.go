@@ -5,6 +5,7 @@ import (
 	"one-api/common/config"
 	"one-api/model"
 	"one-api/relay/task/base"
+	"one-api/relay/task/finetune"
 	"one-api/relay/task/kling"
 	"one-api/relay/task/suno"
 
@@ -21,6 +22,10 @@ func GetTaskAdaptor(relayType int, c *gin.Context) (base.TaskInterface, error) {
 		return &kling.KlingTask{
 			TaskBase: getTaskBase(c, model.TaskPlatformKling),
 		}, nil
+	case config.RelayModeFineTuning:
+		return &finetune.FineTuningTask{
+			TaskBase: getTaskBase(c, model.TaskPlatformFineTuning),
+		}, nil
 	default:
 		return nil, errors.New("adaptor not found")
 	}
@@ -34,6 +39,8 @@ func GetTaskAdaptorByPlatform(platform string) (base.TaskInterface, error) {
 		relayType = config.RelayModeSuno
 	case model.TaskPlatformKling:
 		relayType = config.RelayModeKling
+	case model.TaskPlatformFineTuning:
+		relayType = config.RelayModeFineTuning
 	}
 
 	return GetTaskAdaptor(relayType, nil)
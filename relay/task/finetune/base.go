@@ -0,0 +1,93 @@
+package finetune
+
+import (
+	"encoding/json"
+	"one-api/model"
+	"one-api/providers/azure"
+	providersBase "one-api/providers/base"
+	"one-api/providers/openai"
+	"one-api/types"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FineTuningJob 微调任务中计费和状态同步用到的字段，返回给用户的是上游的原始响应
+type FineTuningJob struct {
+	ID             string `json:"id"`
+	Model          string `json:"model"`
+	Status         string `json:"status"`
+	FineTunedModel string `json:"fine_tuned_model"`
+	TrainedTokens  int    `json:"trained_tokens"`
+	CreatedAt      int64  `json:"created_at"`
+	FinishedAt     int64  `json:"finished_at"`
+	Error          *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// taskProperties 提交任务时记录的计费信息，任务完成后按训练 token 数扣费
+type taskProperties struct {
+	Model      string  `json:"model"`
+	GroupRatio float64 `json:"group_ratio"`
+	TokenName  string  `json:"token_name"`
+}
+
+func StringError(c *gin.Context, httpCode int, code, message string) {
+	c.JSON(httpCode, types.OpenAIErrorResponse{
+		Error: types.OpenAIError{
+			Code:    code,
+			Message: message,
+			Type:    "one_hub_error",
+		},
+	})
+}
+
+func OpenAIError(c *gin.Context, err *types.OpenAIErrorWithStatusCode) {
+	c.JSON(err.StatusCode, types.OpenAIErrorResponse{Error: err.OpenAIError})
+}
+
+// getOpenAIProvider 只有 OpenAI 和 Azure 渠道支持微调接口
+func getOpenAIProvider(provider providersBase.ProviderInterface) *openai.OpenAIProvider {
+	switch p := provider.(type) {
+	case *openai.OpenAIProvider:
+		return p
+	case *azure.AzureProvider:
+		return &p.OpenAIProvider
+	}
+	return nil
+}
+
+// mapJobStatus 将上游任务状态转换为任务表的状态
+func mapJobStatus(status string) model.TaskStatus {
+	switch status {
+	case "validating_files", "queued":
+		return model.TaskStatusQueued
+	case "running":
+		return model.TaskStatusInProgress
+	case "succeeded":
+		return model.TaskStatusSuccess
+	case "failed", "cancelled":
+		return model.TaskStatusFailure
+	}
+	return model.TaskStatusUnknown
+}
+
+func isJobFinished(status string) bool {
+	return status == "succeeded" || status == "failed" || status == "cancelled"
+}
+
+// applyJob 用上游返回的任务更新任务表记录，不处理计费
+func applyJob(task *model.Task, job *FineTuningJob, raw json.RawMessage) {
+	task.Status = mapJobStatus(job.Status)
+	if job.Status == "running" && task.StartTime == 0 {
+		task.StartTime = time.Now().Unix()
+	}
+	if job.FinishedAt > 0 {
+		task.FinishTime = job.FinishedAt
+	}
+	if job.Error != nil && job.Error.Message != "" {
+		task.FailReason = job.Error.Message
+	}
+	task.Data = []byte(raw)
+}
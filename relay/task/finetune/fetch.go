@@ -0,0 +1,134 @@
+package finetune
+
+import (
+	"encoding/json"
+	"net/http"
+	"one-api/model"
+	"one-api/providers/openai"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListJobs 列出用户的微调任务，数据来自最近一次同步的结果
+func ListJobs(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	userId := c.GetInt("id")
+	var afterId int64
+	if after := c.Query("after"); after != "" {
+		task, err := model.GetTaskByTaskId(model.TaskPlatformFineTuning, userId, after)
+		if err != nil {
+			StringError(c, http.StatusInternalServerError, "get_task_failed", err.Error())
+			return
+		}
+		if task == nil {
+			StringError(c, http.StatusNotFound, "job_not_found", "fine-tuning job not found")
+			return
+		}
+		afterId = task.ID
+	}
+
+	tasks, err := model.GetUserTasksAfter(model.TaskPlatformFineTuning, userId, afterId, limit+1)
+	if err != nil {
+		StringError(c, http.StatusInternalServerError, "get_task_failed", err.Error())
+		return
+	}
+
+	hasMore := len(tasks) > limit
+	if hasMore {
+		tasks = tasks[:limit]
+	}
+	jobs := make([]json.RawMessage, 0, len(tasks))
+	for _, task := range tasks {
+		jobs = append(jobs, json.RawMessage(task.Data))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"object":   "list",
+		"data":     jobs,
+		"has_more": hasMore,
+	})
+}
+
+// RetrieveJob 从任务所在渠道查询最新状态
+func RetrieveJob(c *gin.Context) {
+	relayJobAction(c, http.MethodGet, "")
+}
+
+// CancelJob 取消任务，已训练的部分在任务同步时计费
+func CancelJob(c *gin.Context) {
+	relayJobAction(c, http.MethodPost, "/cancel")
+}
+
+// JobEvents 转发任务事件列表
+func JobEvents(c *gin.Context) {
+	relayJobList(c, "/events")
+}
+
+// JobCheckpoints 转发任务检查点列表
+func JobCheckpoints(c *gin.Context) {
+	relayJobList(c, "/checkpoints")
+}
+
+// getUserTask 查询用户自己的微调任务及其所在渠道
+func getUserTask(c *gin.Context) (*model.Task, *openai.OpenAIProvider, bool) {
+	task, err := model.GetTaskByTaskId(model.TaskPlatformFineTuning, c.GetInt("id"), c.Param("id"))
+	if err != nil {
+		StringError(c, http.StatusInternalServerError, "get_task_failed", err.Error())
+		return nil, nil, false
+	}
+	if task == nil {
+		StringError(c, http.StatusNotFound, "job_not_found", "fine-tuning job not found")
+		return nil, nil, false
+	}
+
+	provider, err := getChannelProvider(task.ChannelId)
+	if err != nil {
+		StringError(c, http.StatusServiceUnavailable, "provider_not_found", err.Error())
+		return nil, nil, false
+	}
+	return task, provider, true
+}
+
+func relayJobAction(c *gin.Context, method, action string) {
+	task, provider, ok := getUserTask(c)
+	if !ok {
+		return
+	}
+
+	job, raw, errWithCode := sendJobRequest(provider, method, jobsPath+"/"+task.TaskID+action, nil)
+	if errWithCode != nil {
+		OpenAIError(c, errWithCode)
+		return
+	}
+
+	// 结束状态和计费由任务同步处理
+	if task.Progress != 100 {
+		applyJob(task, job, raw)
+		if err := task.Update(); err != nil {
+			StringError(c, http.StatusInternalServerError, "save_task_failed", err.Error())
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, "application/json", raw)
+}
+
+func relayJobList(c *gin.Context, action string) {
+	task, provider, ok := getUserTask(c)
+	if !ok {
+		return
+	}
+
+	raw, errWithCode := sendRequest(provider, http.MethodGet, jobsPath+"/"+task.TaskID+action, c.Request.URL.RawQuery, nil)
+	if errWithCode != nil {
+		OpenAIError(c, errWithCode)
+		return
+	}
+
+	c.Data(http.StatusOK, "application/json", raw)
+}
@@ -0,0 +1,259 @@
+package finetune
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/common/requester"
+	"one-api/common/utils"
+	"one-api/model"
+	"one-api/providers"
+	"one-api/providers/openai"
+	"one-api/relay/task/base"
+	"one-api/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const jobsPath = "/v1/fine_tuning/jobs"
+
+type FineTuningTask struct {
+	base.TaskBase
+	Body     []byte
+	Provider *openai.OpenAIProvider
+}
+
+func (t *FineTuningTask) HandleError(err *base.TaskError) {
+	StringError(t.C, err.StatusCode, err.Code, err.Message)
+}
+
+func (t *FineTuningTask) Init() *base.TaskError {
+	var request struct {
+		Model string `json:"model"`
+	}
+	if err := common.UnmarshalBodyReusable(t.C, &request); err != nil {
+		return base.StringTaskError(http.StatusBadRequest, "invalid_request", err.Error(), true)
+	}
+	if request.Model == "" {
+		return base.StringTaskError(http.StatusBadRequest, "invalid_request", "model is required", true)
+	}
+	t.OriginalModel = request.Model
+
+	t.Body, _ = utils.GetGinValue[[]byte](t.C, config.GinRequestBodyKey)
+
+	// 训练用量在任务完成后才知道，提交时只要求余额为正
+	userQuota, err := model.CacheGetUserQuota(t.C.GetInt("id"))
+	if err != nil {
+		return base.StringTaskError(http.StatusInternalServerError, "get_user_quota_failed", err.Error(), true)
+	}
+	if userQuota <= 0 {
+		return base.StringTaskError(http.StatusPaymentRequired, "insufficient_user_quota", "user quota is not enough", true)
+	}
+
+	return nil
+}
+
+func (t *FineTuningTask) SetProvider() *base.TaskError {
+	provider, err := t.GetProviderByModel()
+	if err != nil {
+		return base.StringTaskError(http.StatusServiceUnavailable, "provider_not_found", err.Error(), true)
+	}
+
+	openAIProvider := getOpenAIProvider(provider)
+	if openAIProvider == nil {
+		return base.StringTaskError(http.StatusServiceUnavailable, "provider_not_found", "provider must be of type azureopenai or openai", true)
+	}
+
+	t.Provider = openAIProvider
+	t.BaseProvider = provider
+
+	return nil
+}
+
+func (t *FineTuningTask) Relay() *base.TaskError {
+	// 请求体原样转发，只替换为映射后的模型
+	var body map[string]any
+	if err := json.Unmarshal(t.Body, &body); err != nil {
+		return base.StringTaskError(http.StatusBadRequest, "invalid_request", err.Error(), true)
+	}
+	body["model"] = t.ModelName
+
+	job, raw, errWithCode := sendJobRequest(t.Provider, http.MethodPost, jobsPath, body)
+	if errWithCode != nil {
+		return base.OpenAIErrToTaskErr(errWithCode)
+	}
+	t.Response = raw
+
+	properties, _ := json.Marshal(taskProperties{
+		Model:      t.GetModelName(),
+		GroupRatio: t.C.GetFloat64("group_ratio"),
+		TokenName:  t.C.GetString("token_name"),
+	})
+
+	t.InitTask()
+	t.Task.TaskID = job.ID
+	t.Task.ChannelId = t.Provider.Channel.Id
+	t.Task.Action = "JOB"
+	t.Task.Properties = properties
+	applyJob(t.Task, job, raw)
+
+	return nil
+}
+
+func (t *FineTuningTask) ShouldRetry(c *gin.Context, err *base.TaskError) bool {
+	// 训练文件只存在于上传时的渠道，不能换渠道重试
+	return false
+}
+
+func (t *FineTuningTask) UpdateTaskStatus(ctx context.Context, taskChannelM map[int][]string, taskM map[string]*model.Task) error {
+	for channelId, taskIds := range taskChannelM {
+		err := updateFineTuningTaskAll(ctx, channelId, taskIds, taskM)
+		if err != nil {
+			logger.LogError(ctx, fmt.Sprintf("渠道 #%d 更新微调任务失败: %s", channelId, err.Error()))
+		}
+	}
+	return nil
+}
+
+func updateFineTuningTaskAll(ctx context.Context, channelId int, taskIds []string, taskM map[string]*model.Task) error {
+	if len(taskIds) == 0 {
+		return nil
+	}
+
+	provider, err := getChannelProvider(channelId)
+	if err != nil {
+		// 渠道被删除后任务无法再查询，不会产生训练费用
+		updateErr := model.TaskBulkUpdate(taskIds, map[string]any{
+			"fail_reason": fmt.Sprintf("获取渠道信息失败，请联系管理员，渠道ID：%d", channelId),
+			"status":      model.TaskStatusFailure,
+			"progress":    100,
+		})
+		if updateErr != nil {
+			logger.SysError(fmt.Sprintf("UpdateTask error: %v", updateErr))
+		}
+		return err
+	}
+
+	for _, taskId := range taskIds {
+		task := taskM[taskId]
+		job, raw, errWithCode := sendJobRequest(provider, http.MethodGet, jobsPath+"/"+taskId, nil)
+		if errWithCode != nil {
+			logger.LogError(ctx, fmt.Sprintf("Get fine-tuning job %s error: %s", taskId, errWithCode.Message))
+			continue
+		}
+
+		applyJob(task, job, raw)
+		if isJobFinished(job.Status) {
+			task.Progress = 100
+			billTask(ctx, task, job)
+		}
+
+		if err := task.Update(); err != nil {
+			logger.SysError("UpdateTask task error: " + err.Error())
+		}
+	}
+
+	return nil
+}
+
+// billTask 任务结束后按训练 token 数扣费，取消和失败的任务已训练的部分同样计费
+func billTask(ctx context.Context, task *model.Task, job *FineTuningJob) {
+	if job.TrainedTokens <= 0 || task.Quota > 0 {
+		return
+	}
+
+	var properties taskProperties
+	if err := json.Unmarshal(task.Properties, &properties); err != nil {
+		logger.LogError(ctx, fmt.Sprintf("fine-tuning job %s has invalid properties: %s", task.TaskID, err.Error()))
+		return
+	}
+
+	price := model.PricingInstance.GetPrice(properties.Model)
+	quota := int(math.Ceil(float64(job.TrainedTokens) * price.GetInput() * properties.GroupRatio))
+	if quota <= 0 {
+		return
+	}
+
+	// 任务状态保存前崩溃时，下次轮询不会重复扣费
+	billingKey := "finetune:" + task.TaskID
+	claimed, err := model.ClaimBilling(billingKey, task.UserId, quota)
+	if err != nil {
+		logger.LogError(ctx, "fail to claim billing: "+err.Error())
+		billingKey = ""
+	} else if !claimed {
+		task.Quota = quota
+		return
+	}
+
+	if err := model.PostConsumeTokenQuota(task.TokenID, quota); err != nil {
+		// 令牌已删除时直接从用户余额扣除
+		logger.LogError(ctx, "fail to consume token quota: "+err.Error())
+		if err = model.DecreaseUserQuota(task.UserId, quota); err != nil {
+			logger.LogError(ctx, "fail to decrease user quota: "+err.Error())
+		}
+	}
+	if err := model.CacheUpdateUserQuota(task.UserId); err != nil {
+		logger.LogError(ctx, "fail to update user quota cache: "+err.Error())
+	}
+	model.UpdateChannelUsedQuota(task.ChannelId, quota)
+	model.UpdateUserUsedQuotaAndRequestCount(task.UserId, quota)
+	model.RecordConsumeLog(ctx, billingKey, task.UserId, task.ChannelId, job.TrainedTokens, 0, properties.Model, task.TokenID, properties.TokenName, quota, "微调任务 "+task.TaskID, 0, false, nil, "")
+
+	task.Quota = quota
+}
+
+func getChannelProvider(channelId int) (*openai.OpenAIProvider, error) {
+	channel := model.ChannelGroup.GetChannel(channelId)
+	if channel == nil {
+		return nil, fmt.Errorf("channel not found")
+	}
+
+	provider := getOpenAIProvider(providers.GetProvider(channel, nil))
+	if provider == nil {
+		return nil, fmt.Errorf("provider not found")
+	}
+	return provider, nil
+}
+
+// sendJobRequest 请求上游微调接口并解析返回的任务
+func sendJobRequest(provider *openai.OpenAIProvider, method, path string, body any) (*FineTuningJob, json.RawMessage, *types.OpenAIErrorWithStatusCode) {
+	raw, errWithCode := sendRequest(provider, method, path, "", body)
+	if errWithCode != nil {
+		return nil, nil, errWithCode
+	}
+
+	job := &FineTuningJob{}
+	if err := json.Unmarshal(raw, job); err != nil || job.ID == "" {
+		return nil, nil, requester.InvalidResponseError(fmt.Errorf("missing job id"))
+	}
+	return job, raw, nil
+}
+
+// sendRequest 请求上游微调接口，返回原始响应
+func sendRequest(provider *openai.OpenAIProvider, method, path, rawQuery string, body any) (json.RawMessage, *types.OpenAIErrorWithStatusCode) {
+	fullRequestURL := provider.GetFullRequestURL(path, "")
+	if rawQuery != "" {
+		if strings.Contains(fullRequestURL, "?") {
+			fullRequestURL += "&" + rawQuery
+		} else {
+			fullRequestURL += "?" + rawQuery
+		}
+	}
+
+	req, err := provider.Requester.NewRequest(method, fullRequestURL, provider.Requester.WithBody(body), provider.Requester.WithHeader(provider.GetRequestHeaders()))
+	if err != nil {
+		return nil, common.ErrorWrapperLocal(err, "new_request_failed", http.StatusInternalServerError)
+	}
+
+	var raw json.RawMessage
+	if _, errWithCode := provider.Requester.SendRequest(req, &raw, false); errWithCode != nil {
+		return nil, errWithCode
+	}
+	return raw, nil
+}
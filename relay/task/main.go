@@ -90,6 +90,36 @@ func RelayTaskSubmit(c *gin.Context) {
 
 }
 
+// RelayFineTuningSubmit 创建微调任务，训练用量在任务结束后才知道，提交时不预扣费，由任务同步按训练 token 数扣费
+func RelayFineTuningSubmit(c *gin.Context) {
+	taskAdaptor, err := GetTaskAdaptor(config.RelayModeFineTuning, c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, base.StringTaskError(http.StatusBadRequest, "adaptor_not_found", "adaptor not found", true))
+		return
+	}
+
+	if taskErr := taskAdaptor.Init(); taskErr != nil {
+		taskAdaptor.HandleError(taskErr)
+		return
+	}
+	if taskErr := taskAdaptor.SetProvider(); taskErr != nil {
+		taskAdaptor.HandleError(taskErr)
+		return
+	}
+	if taskErr := taskAdaptor.Relay(); taskErr != nil {
+		taskAdaptor.HandleError(taskErr)
+		return
+	}
+
+	if err := taskAdaptor.GetTask().Insert(); err != nil {
+		logger.SysError(fmt.Sprintf("task error: %s", err.Error()))
+	}
+	ActivateUpdateTaskBulk()
+
+	taskAdaptor.GinResponse()
+	metrics.RecordProvider(c, 200)
+}
+
 func CompletedTask(quotaInstance *relay_util.Quota, taskAdaptor base.TaskInterface, c *gin.Context) {
 	quotaInstance.Consume(c, &types.Usage{CompletionTokens: 0, PromptTokens: 1, TotalTokens: 1}, false)
 
@@ -112,6 +142,8 @@ func GetRelayMode(c *gin.Context) int {
 		relayMode = config.RelayModeSuno
 	} else if strings.HasPrefix(path, "/kling") {
 		relayMode = config.RelayModeKling
+	} else if strings.HasPrefix(path, "/v1/fine_tuning") {
+		relayMode = config.RelayModeFineTuning
 	}
 
 	return relayMode
@@ -0,0 +1,92 @@
+package relay
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/model"
+	"one-api/types"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/viper"
+)
+
+// 客户端可以通过请求头指定本次请求的超时时间（秒，可以是小数），X-Upstream-Timeout 为旧的请求头
+const (
+	requestTimeoutHeader  = "X-Oneapi-Timeout"
+	upstreamTimeoutHeader = "X-Upstream-Timeout"
+)
+
+// getHeaderTimeout 解析请求头中的超时时间，并限制在 client_timeout_min 和 client_timeout_max 之间
+func getHeaderTimeout(c *gin.Context) time.Duration {
+	value := c.GetHeader(requestTimeoutHeader)
+	if value == "" {
+		value = c.GetHeader(upstreamTimeoutHeader)
+	}
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds <= 0 || math.IsInf(seconds, 0) {
+		return 0
+	}
+
+	// 过短的超时会让上游已经产生费用的请求被取消，由服务端设置下限
+	if minSeconds := viper.GetFloat64("client_timeout_min"); minSeconds > 0 && seconds < minSeconds {
+		seconds = minSeconds
+	}
+	if maxSeconds := viper.GetFloat64("client_timeout_max"); maxSeconds > 0 && seconds > maxSeconds {
+		seconds = maxSeconds
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// getUpstreamTimeout 获取本次请求的上游超时时间，请求头和令牌设置同时存在时取较小值
+func getUpstreamTimeout(c *gin.Context) time.Duration {
+	var timeout time.Duration
+	if setting, exists := c.Get("token_setting"); exists {
+		if tokenSetting, ok := setting.(*model.TokenSetting); ok && tokenSetting.UpstreamTimeout > 0 {
+			timeout = time.Duration(tokenSetting.UpstreamTimeout) * time.Second
+		}
+	}
+
+	if headerTimeout := getHeaderTimeout(c); headerTimeout > 0 {
+		if timeout == 0 || headerTimeout < timeout {
+			timeout = headerTimeout
+		}
+	}
+
+	return timeout
+}
+
+// setUpstreamContext 将客户端请求的上下文传递给上游请求，客户端断开或超时后上游请求随之取消
+func setUpstreamContext(c *gin.Context) context.CancelFunc {
+	ctx := c.Request.Context()
+	cancel := context.CancelFunc(func() {})
+	if timeout := getUpstreamTimeout(c); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+
+	c.Set(config.GinUpstreamContextKey, ctx)
+	return cancel
+}
+
+// checkUpstreamCanceled 检查上游请求是否因客户端断开或超时而中止
+// 这类错误不应重试，也不应计入渠道的错误统计，预扣的额度在 RelayHandler 中已退还
+func checkUpstreamCanceled(c *gin.Context) (canceled bool, apiErr *types.OpenAIErrorWithStatusCode) {
+	if errors.Is(c.Request.Context().Err(), context.Canceled) {
+		logger.LogWarn(c.Request.Context(), "client canceled the request, upstream request aborted")
+		return true, nil
+	}
+
+	ctx, ok := c.Value(config.GinUpstreamContextKey).(context.Context)
+	if ok && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		logger.LogWarn(c.Request.Context(), "upstream request timed out")
+		return true, common.StringErrorWrapperLocal("upstream request timed out", "upstream_timeout", http.StatusGatewayTimeout)
+	}
+
+	return false, nil
+}
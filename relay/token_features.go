@@ -0,0 +1,74 @@
+package relay
+
+import (
+	"one-api/model"
+	"one-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+// tokenFeatures 令牌的功能开关，管理员调整范围后不再符合的设置整体忽略
+func tokenFeatures(c *gin.Context) *model.TokenFeatures {
+	setting, exists := c.Get("token_setting")
+	if !exists {
+		return nil
+	}
+	tokenSetting, ok := setting.(*model.TokenSetting)
+	if !ok || tokenSetting == nil {
+		return nil
+	}
+	if tokenSetting.Features.Validate() != nil {
+		return nil
+	}
+	return &tokenSetting.Features
+}
+
+// applyChatFeatures 按令牌设置补充默认 temperature，关闭缓存时去掉 cache_control
+func applyChatFeatures(c *gin.Context, request *types.ChatCompletionRequest) {
+	features := tokenFeatures(c)
+	if features == nil {
+		return
+	}
+
+	if request.Temperature == nil && features.DefaultTemperature != nil {
+		temperature := *features.DefaultTemperature
+		request.Temperature = &temperature
+	}
+
+	if features.CacheDisabled() {
+		for i := range request.Messages {
+			request.Messages[i].CacheControl = nil
+			parts, ok := request.Messages[i].Content.([]any)
+			if !ok {
+				continue
+			}
+			for _, part := range parts {
+				if part, ok := part.(map[string]any); ok {
+					delete(part, "cache_control")
+				}
+			}
+		}
+	}
+}
+
+// applyModerationLevel 按令牌的审查级别调整分组策略
+func applyModerationLevel(c *gin.Context, policy model.SafePolicy) model.SafePolicy {
+	features := tokenFeatures(c)
+	if features == nil {
+		return policy
+	}
+
+	switch features.Moderation {
+	case model.ModerationLevelStrict:
+		return model.SafePolicy{Action: model.SafeActionBlock, Prompt: true, Response: true}
+	case model.ModerationLevelRelaxed:
+		policy.Action = model.SafeActionLog
+	}
+	return policy
+}
+
+// headersHidden 令牌是否隐藏渠道、模型、重试次数和额度等元数据响应头
+func headersHidden(c *gin.Context) bool {
+	features := tokenFeatures(c)
+	return features != nil && features.HideHeaders
+}
@@ -12,6 +12,7 @@ import (
 type relayTranscriptions struct {
 	relayBase
 	request types.AudioRequest
+	seconds float64 // 音频时长，无法识别格式时为 0
 }
 
 func NewRelayTranscriptions(c *gin.Context) *relayTranscriptions {
@@ -25,13 +26,19 @@ func (r *relayTranscriptions) setRequest() error {
 		return err
 	}
 
+	seconds, err := probeAudio(r.request.File)
+	if err != nil {
+		return err
+	}
+	r.seconds = seconds
+
 	r.setOriginalModel(r.request.Model)
 
 	return nil
 }
 
 func (r *relayTranscriptions) getPromptTokens() (int, error) {
-	return 0, nil
+	return audioPromptTokens(r.seconds), nil
 }
 
 func (r *relayTranscriptions) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
@@ -43,11 +50,15 @@ func (r *relayTranscriptions) send() (err *types.OpenAIErrorWithStatusCode, done
 	}
 
 	r.request.Model = r.modelName
+	if tokens := audioPromptTokens(r.seconds); tokens > 0 {
+		r.provider.GetUsage().PromptTokensDetails.AudioTokens = tokens
+	}
 
 	response, err := provider.CreateTranscriptions(&r.request)
 	if err != nil {
 		return
 	}
+	persistTranscript(r.c, response, r.request.ResponseFormat)
 	err = responseCustom(r.c, response)
 
 	if err != nil {
@@ -12,6 +12,7 @@ import (
 type relayTranslations struct {
 	relayBase
 	request types.AudioRequest
+	seconds float64 // 音频时长，无法识别格式时为 0
 }
 
 func NewRelayTranslations(c *gin.Context) *relayTranslations {
@@ -25,13 +26,19 @@ func (r *relayTranslations) setRequest() error {
 		return err
 	}
 
+	seconds, err := probeAudio(r.request.File)
+	if err != nil {
+		return err
+	}
+	r.seconds = seconds
+
 	r.setOriginalModel(r.request.Model)
 
 	return nil
 }
 
 func (r *relayTranslations) getPromptTokens() (int, error) {
-	return 0, nil
+	return audioPromptTokens(r.seconds), nil
 }
 
 func (r *relayTranslations) send() (err *types.OpenAIErrorWithStatusCode, done bool) {
@@ -43,6 +50,9 @@ func (r *relayTranslations) send() (err *types.OpenAIErrorWithStatusCode, done b
 	}
 
 	r.request.Model = r.modelName
+	if tokens := audioPromptTokens(r.seconds); tokens > 0 {
+		r.provider.GetUsage().PromptTokensDetails.AudioTokens = tokens
+	}
 
 	response, err := provider.CreateTranslation(&r.request)
 	if err != nil {
@@ -0,0 +1,77 @@
+package relay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"one-api/common/logger"
+	"one-api/model"
+	"one-api/types"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const vectorStoresPath = "/v1/vector_stores"
+
+// bindVectorStoreChannel file_search 工具用到已记录的向量库时转发到向量库所在的渠道
+func bindVectorStoreChannel(c *gin.Context, tools []types.ResponsesTools) error {
+	if c.GetInt("specific_channel_id") > 0 {
+		return nil
+	}
+
+	var vectorStoreIds []string
+	for _, tool := range tools {
+		if tool.Type == types.APITollTypeFileSearch {
+			vectorStoreIds = append(vectorStoreIds, tool.VectorStoreIds...)
+		}
+	}
+
+	channelId, err := model.GetVectorStoreChannelId(c.GetInt("id"), vectorStoreIds)
+	if err != nil {
+		return err
+	}
+	if channelId > 0 {
+		c.Set("specific_channel_id", channelId)
+		c.Set("specific_channel_id_ignore", false)
+	}
+	return nil
+}
+
+// recordVectorStore 创建向量库成功后记录所在渠道，删除后移除记录
+func recordVectorStore(c *gin.Context, path string, response *http.Response, channelId int) {
+	if response.StatusCode != http.StatusOK {
+		return
+	}
+
+	userId := c.GetInt("id")
+	switch {
+	case c.Request.Method == http.MethodPost && path == vectorStoresPath:
+		body, err := io.ReadAll(response.Body)
+		response.Body.Close()
+		response.Body = io.NopCloser(bytes.NewBuffer(body))
+		if err != nil {
+			return
+		}
+
+		var vectorStore struct {
+			Id string `json:"id"`
+		}
+		if json.Unmarshal(body, &vectorStore) != nil || vectorStore.Id == "" {
+			return
+		}
+		if err := model.BindVectorStore(vectorStore.Id, userId, channelId); err != nil {
+			logger.LogError(c.Request.Context(), fmt.Sprintf("failed to bind vector store %s: %s", vectorStore.Id, err.Error()))
+		}
+	case c.Request.Method == http.MethodDelete && strings.HasPrefix(path, vectorStoresPath+"/"):
+		vectorStoreId := strings.TrimPrefix(path, vectorStoresPath+"/")
+		if strings.Contains(vectorStoreId, "/") {
+			return
+		}
+		if err := model.UnbindVectorStore(vectorStoreId, userId); err != nil {
+			logger.LogError(c.Request.Context(), fmt.Sprintf("failed to unbind vector store %s: %s", vectorStoreId, err.Error()))
+		}
+	}
+}
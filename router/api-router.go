@@ -8,6 +8,7 @@ import (
 	"github.com/gin-contrib/gzip"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
 )
 
 func SetApiRouter(router *gin.Engine) {
@@ -21,11 +22,25 @@ func SetApiRouter(router *gin.Engine) {
 		systemInfo.POST("/log", controller.SystemLog)
 	}
 
+	// 排查线上问题，pprof 和 goroutine 调用栈需要开启 diagnostics.pprof
+	diagnosticsRoute := apiRouter.Group("/diagnostics")
+	diagnosticsRoute.Use(middleware.RootAuth())
+	{
+		diagnosticsRoute.GET("/runtime", controller.GetRuntimeStats)
+		diagnosticsRoute.GET("/requests", controller.GetInFlightRequests)
+		diagnosticsRoute.GET("/goroutines", controller.GetGoroutineDump)
+		diagnosticsRoute.GET("/pprof", controller.GetPprofProfiles)
+		diagnosticsRoute.GET("/pprof/:name", controller.GetPprofProfile)
+	}
+
 	apiRouter.POST("/telegram/:token", middleware.Telegram(), controller.TelegramBotWebHook)
 	apiRouter.Use(middleware.GlobalAPIRateLimit())
 	{
 		apiRouter.GET("/image/:id", controller.CheckImg)
 		apiRouter.GET("/status", controller.GetStatus)
+		apiRouter.GET("/openapi.json", controller.GetOpenAPISpec)
+		apiRouter.GET("/status/leader", middleware.AdminAuth(), controller.GetLeaderStatus)
+		apiRouter.GET("/status/config_version", middleware.AdminAuth(), controller.GetConfigVersionStatus)
 		apiRouter.GET("/notice", controller.GetNotice)
 		apiRouter.GET("/about", controller.GetAbout)
 		apiRouter.GET("/prices", middleware.PricesAuth(), middleware.CORS(), controller.GetPricesList)
@@ -36,8 +51,11 @@ func SetApiRouter(router *gin.Engine) {
 		apiRouter.GET("/verification", middleware.CriticalRateLimit(), middleware.TurnstileCheck(), controller.SendEmailVerification)
 		apiRouter.GET("/reset_password", middleware.CriticalRateLimit(), middleware.TurnstileCheck(), controller.SendPasswordResetEmail)
 		apiRouter.POST("/user/reset", middleware.CriticalRateLimit(), controller.ResetPassword)
+		apiRouter.POST("/trial/token", middleware.CriticalRateLimit(), middleware.TurnstileCheck(), controller.IssueTrialToken)
 		apiRouter.GET("/oauth/github", middleware.CriticalRateLimit(), controller.GitHubOAuth)
 		apiRouter.GET("/oauth/lark", middleware.CriticalRateLimit(), controller.LarkOAuth)
+		apiRouter.GET("/oauth/wecom", middleware.CriticalRateLimit(), controller.WeComOAuth)
+		apiRouter.GET("/oauth/dingtalk", middleware.CriticalRateLimit(), controller.DingTalkOAuth)
 		apiRouter.GET("/oauth/state", middleware.CriticalRateLimit(), controller.GenerateOAuthCode)
 		apiRouter.GET("/oauth/wechat", middleware.CriticalRateLimit(), controller.WeChatAuth)
 		apiRouter.GET("/oauth/wechat/bind", middleware.CriticalRateLimit(), middleware.UserAuth(), controller.WeChatBind)
@@ -74,6 +92,7 @@ func SetApiRouter(router *gin.Engine) {
 			{
 				selfRoute.GET("/dashboard", controller.GetUserDashboard)
 				selfRoute.GET("/dashboard/rate", controller.GetRateRealtime)
+				selfRoute.GET("/dashboard/overview", controller.GetUserDashboardOverview)
 				selfRoute.GET("/dashboard/uptimekuma/status-page", controller.UptimeKumaStatusPage)
 				selfRoute.GET("/dashboard/uptimekuma/status-page/heartbeat", controller.UptimeKumaStatusPageHeartbeat)
 				selfRoute.GET("/invoice", controller.GetUserInvoice)
@@ -84,22 +103,34 @@ func SetApiRouter(router *gin.Engine) {
 				// selfRoute.DELETE("/self", controller.DeleteSelf)
 				selfRoute.GET("/token", controller.GenerateAccessToken)
 				selfRoute.GET("/aff", controller.GetAffCode)
+				selfRoute.GET("/referral", controller.GetUserReferrals)
 				selfRoute.POST("/topup", controller.TopUp)
 				selfRoute.GET("/payment", controller.GetUserPaymentList)
 				selfRoute.POST("/order", controller.CreateOrder)
 				selfRoute.GET("/order/status", controller.CheckOrderStatus)
+				selfRoute.GET("/sessions", controller.GetUserSessions)
+				selfRoute.DELETE("/sessions", controller.RevokeOtherUserSessions)
+				selfRoute.DELETE("/sessions/:id", controller.RevokeUserSession)
+				selfRoute.GET("/login_history", controller.GetSelfLoginHistory)
+				selfRoute.POST("/transfer", middleware.CriticalRateLimit(), controller.TransferQuota)
+				selfRoute.GET("/transfer", controller.GetUserQuotaTransfers)
 			}
 
 			adminRoute := userRoute.Group("/")
-			adminRoute.Use(middleware.AdminAuth())
+			adminRoute.Use(middleware.TenantAdminAuth())
 			{
 				adminRoute.GET("/", controller.GetUsersList)
 				adminRoute.GET("/:id", controller.GetUser)
-				adminRoute.POST("/", controller.CreateUser)
+				adminRoute.POST("/", middleware.Idempotency(), controller.CreateUser)
+				adminRoute.PUT("/external/:external_id", controller.PutUserByExternalId)
 				adminRoute.POST("/manage", controller.ManageUser)
 				adminRoute.POST("/quota/:id", controller.ChangeUserQuota)
 				adminRoute.PUT("/", controller.UpdateUser)
 				adminRoute.DELETE("/:id", controller.DeleteUser)
+				adminRoute.GET("/:id/sessions", controller.GetUserSessionsByAdmin)
+				adminRoute.DELETE("/:id/sessions", controller.RevokeUserSessionsByAdmin)
+				adminRoute.GET("/login_history/all", controller.GetLoginHistoryList)
+				adminRoute.GET("/transfer/all", controller.GetQuotaTransfersList)
 			}
 		}
 		optionRoute := apiRouter.Group("/option")
@@ -107,6 +138,7 @@ func SetApiRouter(router *gin.Engine) {
 		{
 			optionRoute.GET("/", controller.GetOptions)
 			optionRoute.PUT("/", controller.UpdateOption)
+			optionRoute.POST("/reload", controller.ForceReload)
 			optionRoute.GET("/telegram", controller.GetTelegramMenuList)
 			optionRoute.POST("/telegram", controller.AddOrUpdateTelegramMenu)
 			optionRoute.GET("/telegram/status", controller.GetTelegramBotStatus)
@@ -114,11 +146,20 @@ func SetApiRouter(router *gin.Engine) {
 			optionRoute.GET("/telegram/:id", controller.GetTelegramMenu)
 			optionRoute.DELETE("/telegram/:id", controller.DeleteTelegramMenu)
 			optionRoute.GET("/safe_tools", controller.GetSafeTools)
+			optionRoute.GET("/email_templates", controller.GetEmailTemplates)
+			optionRoute.POST("/email_templates/preview", controller.PreviewEmailTemplate)
 			optionRoute.POST("/invoice/gen/:time", controller.GenInvoice)
 			optionRoute.POST("/invoice/update/:time", controller.UpdateInvoice)
 			optionRoute.POST("/system_info/log", controller.SystemLog)
 		}
 
+		backupRoute := apiRouter.Group("/backup")
+		backupRoute.Use(middleware.RootAuth(), middleware.LeaderOnly())
+		{
+			backupRoute.POST("/", controller.CreateBackup)
+			backupRoute.POST("/restore", controller.RestoreBackup)
+		}
+
 		modelOwnedByRoute := apiRouter.Group("/model_ownedby")
 		modelOwnedByRoute.GET("/", controller.GetAllModelOwnedBy)
 		modelOwnedByRoute.Use(middleware.AdminAuth())
@@ -133,6 +174,8 @@ func SetApiRouter(router *gin.Engine) {
 		modelInfoRoute.GET("/", controller.GetAllModelInfo)
 		modelInfoRoute.Use(middleware.AdminAuth())
 		{
+			modelInfoRoute.GET("/catalog", relay.GetModelCatalog)
+			modelInfoRoute.GET("/deprecated", controller.GetDeprecatedModelUsages)
 			modelInfoRoute.GET("/:id", controller.GetModelInfo)
 			modelInfoRoute.POST("/", controller.CreateModelInfo)
 			modelInfoRoute.PUT("/", controller.UpdateModelInfo)
@@ -151,24 +194,109 @@ func SetApiRouter(router *gin.Engine) {
 
 		}
 		channelRoute := apiRouter.Group("/channel")
-		channelRoute.Use(middleware.AdminAuth())
 		{
-			channelRoute.GET("/", controller.GetChannelsList)
-			channelRoute.GET("/models", relay.ListModelsForAdmin)
-			channelRoute.POST("/provider_models_list", controller.GetModelList)
-			channelRoute.GET("/:id", controller.GetChannel)
-			channelRoute.GET("/test", controller.TestAllChannels)
-			channelRoute.GET("/test/:id", controller.TestChannel)
-			channelRoute.GET("/update_balance", controller.UpdateAllChannelsBalance)
-			channelRoute.GET("/update_balance/:id", controller.UpdateChannelBalance)
-			channelRoute.POST("/", controller.AddChannel)
-			channelRoute.PUT("/", controller.UpdateChannel)
-			channelRoute.PUT("/batch/azure_api", controller.BatchUpdateChannelsAzureApi)
-			channelRoute.PUT("/batch/del_model", controller.BatchDelModelChannels)
-			channelRoute.DELETE("/disabled", controller.DeleteDisabledChannel)
-			channelRoute.DELETE("/:id/tag", controller.DeleteChannelTag)
-			channelRoute.DELETE("/:id", controller.DeleteChannel)
-			channelRoute.DELETE("/batch", controller.BatchDeleteChannel)
+			// 租户管理员只能管理本租户的渠道
+			tenantChannelRoute := channelRoute.Group("/")
+			tenantChannelRoute.Use(middleware.TenantAdminAuth())
+			{
+				tenantChannelRoute.GET("/", controller.GetChannelsList)
+				tenantChannelRoute.GET("/models", relay.ListModelsForAdmin)
+				tenantChannelRoute.POST("/provider_models_list", controller.GetModelList)
+				tenantChannelRoute.GET("/:id", controller.GetChannel)
+				tenantChannelRoute.GET("/test/:id", controller.TestChannel)
+				tenantChannelRoute.POST("/:id/sync_models", controller.SyncChannelModels)
+				tenantChannelRoute.POST("/", middleware.Idempotency(), controller.AddChannel)
+				tenantChannelRoute.PUT("/", controller.UpdateChannel)
+				tenantChannelRoute.PUT("/external/:external_id", controller.PutChannelByExternalId)
+				tenantChannelRoute.PATCH("/batch", controller.BatchPatchChannels)
+				tenantChannelRoute.POST("/:id/clone", controller.CloneChannel)
+				tenantChannelRoute.POST("/:id/credentials/validate", controller.ValidateChannelCredentials)
+				tenantChannelRoute.GET("/template", controller.GetChannelTemplatesList)
+				tenantChannelRoute.GET("/template/:id", controller.GetChannelTemplate)
+				tenantChannelRoute.POST("/template/:id/apply", controller.ApplyChannelTemplate)
+				tenantChannelRoute.DELETE("/:id", controller.DeleteChannel)
+			}
+
+			adminChannelRoute := channelRoute.Group("/")
+			adminChannelRoute.Use(middleware.AdminAuth())
+			{
+				adminChannelRoute.GET("/test", controller.TestAllChannels)
+				adminChannelRoute.GET("/auto_priority", controller.GetChannelAdjustments)
+				adminChannelRoute.GET("/test/reports", controller.GetChannelTestReports)
+				adminChannelRoute.GET("/test/reports/:id", controller.GetChannelTestReport)
+				adminChannelRoute.POST("/playground", controller.ChannelPlayground)
+				adminChannelRoute.GET("/:id/capture", controller.GetChannelCaptures)
+				adminChannelRoute.POST("/:id/capture", controller.StartChannelCapture)
+				adminChannelRoute.DELETE("/:id/capture", controller.DeleteChannelCaptures)
+				adminChannelRoute.GET("/update_balance", controller.UpdateAllChannelsBalance)
+				adminChannelRoute.GET("/update_balance/:id", controller.UpdateChannelBalance)
+				adminChannelRoute.PUT("/batch/azure_api", controller.BatchUpdateChannelsAzureApi)
+				adminChannelRoute.PUT("/batch/del_model", controller.BatchDelModelChannels)
+				adminChannelRoute.DELETE("/disabled", controller.DeleteDisabledChannel)
+				adminChannelRoute.DELETE("/:id/tag", controller.DeleteChannelTag)
+				adminChannelRoute.DELETE("/batch", controller.BatchDeleteChannel)
+				adminChannelRoute.POST("/template", controller.AddChannelTemplate)
+				adminChannelRoute.PUT("/template", controller.UpdateChannelTemplate)
+				adminChannelRoute.DELETE("/template/:id", controller.DeleteChannelTemplate)
+			}
+		}
+		promptTemplateRoute := apiRouter.Group("/prompt_template")
+		promptTemplateRoute.Use(middleware.AdminAuth())
+		{
+			promptTemplateRoute.GET("/", controller.GetPromptTemplates)
+			promptTemplateRoute.GET("/:id", controller.GetPromptTemplateById)
+			promptTemplateRoute.POST("/", controller.AddPromptTemplate)
+			promptTemplateRoute.PUT("/", controller.UpdatePromptTemplate)
+			promptTemplateRoute.DELETE("/:id", controller.DeletePromptTemplate)
+		}
+
+		// 回收站，租户管理员只能管理本租户的渠道、令牌和用户
+		trashRoute := apiRouter.Group("/trash")
+		trashRoute.Use(middleware.TenantAdminAuth())
+		{
+			trashRoute.GET("/", controller.GetTrashList)
+			trashRoute.POST("/:type/:id/restore", controller.RestoreTrashItem)
+			trashRoute.DELETE("/:type/:id", controller.PurgeTrashItem)
+		}
+
+		ipRuleRoute := apiRouter.Group("/ip_rule")
+		ipRuleRoute.Use(middleware.AdminAuth())
+		{
+			ipRuleRoute.GET("/", controller.GetIPRules)
+			ipRuleRoute.POST("/", controller.AddIPRule)
+			ipRuleRoute.PUT("/", controller.UpdateIPRule)
+			ipRuleRoute.DELETE("/:id", controller.DeleteIPRule)
+			ipRuleRoute.GET("/ban", controller.GetIPBans)
+			ipRuleRoute.DELETE("/ban/:ip", controller.DeleteIPBan)
+		}
+
+		modelRouteRoute := apiRouter.Group("/model_route")
+		modelRouteRoute.Use(middleware.AdminAuth())
+		{
+			modelRouteRoute.GET("/", controller.GetModelRoutes)
+			modelRouteRoute.POST("/", controller.AddModelRoute)
+			modelRouteRoute.PUT("/", controller.UpdateModelRoute)
+			modelRouteRoute.DELETE("/:id", controller.DeleteModelRoute)
+		}
+
+		clientCertRoute := apiRouter.Group("/client_cert")
+		clientCertRoute.Use(middleware.AdminAuth())
+		{
+			clientCertRoute.GET("/", controller.GetClientCerts)
+			clientCertRoute.POST("/", controller.AddClientCert)
+			clientCertRoute.PUT("/", controller.UpdateClientCert)
+			clientCertRoute.DELETE("/:id", controller.DeleteClientCert)
+		}
+
+		tenantRoute := apiRouter.Group("/tenant")
+		tenantRoute.Use(middleware.RootAuth())
+		{
+			tenantRoute.GET("/", controller.GetTenants)
+			tenantRoute.GET("/:id", controller.GetTenantById)
+			tenantRoute.POST("/", controller.AddTenant)
+			tenantRoute.PUT("/", controller.UpdateTenant)
+			tenantRoute.DELETE("/:id", controller.DeleteTenant)
+			tenantRoute.POST("/user", controller.SetUserTenant)
 		}
 		channelTagRoute := apiRouter.Group("/channel_tag")
 		channelTagRoute.Use(middleware.AdminAuth())
@@ -190,26 +318,62 @@ func SetApiRouter(router *gin.Engine) {
 			tokenRoute.GET("/playground", controller.GetPlaygroundToken)
 			tokenRoute.GET("/", controller.GetUserTokensList)
 			tokenRoute.GET("/:id", controller.GetToken)
-			tokenRoute.POST("/", controller.AddToken)
+			tokenRoute.GET("/:id/stats", controller.GetTokenStatistics)
+			tokenRoute.POST("/", middleware.Idempotency(), controller.AddToken)
 			tokenRoute.PUT("/", controller.UpdateToken)
+			tokenRoute.PUT("/external/:external_id", controller.PutTokenByExternalId)
 			tokenRoute.DELETE("/:id", controller.DeleteToken)
+			tokenRoute.PUT("/:id/channels", middleware.AdminAuth(), controller.UpdateTokenChannels)
+			tokenRoute.PUT("/:id/service_account", middleware.AdminAuth(), controller.UpdateTokenServiceAccount)
 		}
 		redemptionRoute := apiRouter.Group("/redemption")
 		redemptionRoute.Use(middleware.AdminAuth())
 		{
 			redemptionRoute.GET("/", controller.GetRedemptionsList)
+			redemptionRoute.GET("/batch", controller.GetRedemptionBatchesList)
+			redemptionRoute.PUT("/batch", controller.UpdateRedemptionBatch)
+			redemptionRoute.GET("/campaign", controller.GetRedemptionCampaignStatistics)
 			redemptionRoute.GET("/:id", controller.GetRedemption)
 			redemptionRoute.POST("/", controller.AddRedemption)
 			redemptionRoute.PUT("/", controller.UpdateRedemption)
 			redemptionRoute.DELETE("/:id", controller.DeleteRedemption)
 		}
+		referralRoute := apiRouter.Group("/referral")
+		referralRoute.Use(middleware.AdminAuth())
+		{
+			referralRoute.GET("/", controller.GetReferralsList)
+			referralRoute.GET("/report", controller.GetReferralReport)
+		}
+		quotaGrantRoute := apiRouter.Group("/quota_grant")
+		quotaGrantRoute.Use(middleware.RootAuth())
+		{
+			quotaGrantRoute.GET("/", controller.GetQuotaGrantsList)
+			quotaGrantRoute.POST("/", controller.AddQuotaGrant)
+			quotaGrantRoute.PUT("/", controller.UpdateQuotaGrant)
+			quotaGrantRoute.DELETE("/:id", controller.DeleteQuotaGrant)
+		}
+		invitationCodeRoute := apiRouter.Group("/invitation_code")
+		invitationCodeRoute.Use(middleware.AdminAuth())
+		{
+			invitationCodeRoute.GET("/", controller.GetInvitationCodesList)
+			invitationCodeRoute.GET("/:id", controller.GetInvitationCode)
+			invitationCodeRoute.POST("/", controller.AddInvitationCode)
+			invitationCodeRoute.PUT("/", controller.UpdateInvitationCode)
+			invitationCodeRoute.DELETE("/:id", controller.DeleteInvitationCode)
+		}
 		logRoute := apiRouter.Group("/log")
 		logRoute.GET("/", middleware.AdminAuth(), controller.GetLogsList)
 		logRoute.DELETE("/", middleware.AdminAuth(), controller.DeleteHistoryLogs)
+		logRoute.GET("/search", middleware.AdminAuth(), controller.SearchLogs)
 		logRoute.GET("/stat", middleware.AdminAuth(), controller.GetLogsStat)
 		logRoute.GET("/self/stat", middleware.UserAuth(), controller.GetLogsSelfStat)
 		// logRoute.GET("/search", middleware.AdminAuth(), controller.SearchAllLogs)
 		logRoute.GET("/self", middleware.UserAuth(), controller.GetUserLogsList)
+		logRoute.GET("/moderation", middleware.AdminAuth(), controller.GetModerationLogsList)
+		logRoute.GET("/anomaly", middleware.AdminAuth(), controller.GetUsageAnomaliesList)
+		logRoute.POST("/:id/replay", middleware.AdminAuth(), relay.ReplayLog)
+		logRoute.POST("/refund", middleware.AdminAuth(), controller.RefundLogs)
+		logRoute.GET("/refunds", middleware.AdminAuth(), controller.GetQuotaRefundsList)
 		// logRoute.GET("/self/search", middleware.UserAuth(), controller.SearchUserLogs)
 		groupRoute := apiRouter.Group("/group")
 		groupRoute.Use(middleware.AdminAuth())
@@ -225,6 +389,25 @@ func SetApiRouter(router *gin.Engine) {
 			analyticsRoute.GET("/multi_user_stats", controller.GetMultiUserStatistics)
 			analyticsRoute.GET("/multi_user_stats/export", controller.ExportMultiUserStatisticsCSV)
 		}
+
+		loadTestRoute := apiRouter.Group("/loadtest")
+		loadTestRoute.Use(middleware.RootAuth())
+		{
+			loadTestRoute.GET("/", relay.GetLoadTests)
+			loadTestRoute.POST("/", relay.StartLoadTest)
+			loadTestRoute.GET("/:id", relay.GetLoadTest)
+			loadTestRoute.POST("/:id/stop", relay.StopLoadTest)
+		}
+		// 外部控制器（如 Kubernetes operator）声明式同步渠道和令牌
+		syncRoute := apiRouter.Group("/sync")
+		syncRoute.Use(middleware.AdminAuth())
+		{
+			syncRoute.POST("/", controller.SyncResources)
+			syncRoute.GET("/status", controller.GetSyncStatus)
+		}
+		if viper.GetBool("graphql.enable") {
+			apiRouter.POST("/graphql", middleware.AdminAuth(), controller.GraphQL)
+		}
 		pricesRoute := apiRouter.Group("/prices")
 		pricesRoute.Use(middleware.AdminAuth())
 		{
@@ -236,6 +419,18 @@ func SetApiRouter(router *gin.Engine) {
 			pricesRoute.PUT("/multiple/delete", controller.BatchDeletePrices)
 			pricesRoute.POST("/sync", controller.SyncPricing)
 			pricesRoute.GET("/updateService", controller.GetUpdatePriceService)
+			pricesRoute.GET("/document", controller.GetPriceDocument)
+			pricesRoute.PUT("/document", controller.UpdatePriceDocument)
+			pricesRoute.GET("/presets", controller.GetPricePresets)
+			pricesRoute.POST("/import", controller.ImportPricePreset)
+			pricesRoute.GET("/proposals", controller.GetPriceProposals)
+			pricesRoute.POST("/proposals/sync", controller.SyncPriceProposals)
+			pricesRoute.POST("/proposals/approve", controller.ApprovePriceProposals)
+			pricesRoute.POST("/proposals/reject", controller.RejectPriceProposals)
+			pricesRoute.GET("/schedules", controller.GetPriceSchedules)
+			pricesRoute.POST("/schedules", controller.AddPriceSchedule)
+			pricesRoute.POST("/schedules/preview", controller.PreviewPriceSchedule)
+			pricesRoute.POST("/schedules/:id/cancel", controller.CancelPriceSchedule)
 
 		}
 
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"one-api/common/config"
 	"one-api/common/logger"
+	"one-api/controller"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -13,9 +14,13 @@ import (
 )
 
 func SetRouter(router *gin.Engine, buildFS embed.FS, indexPage []byte) {
+	// Kubernetes 和负载均衡的存活、就绪检查
+	router.GET("/healthz", controller.Healthz)
+	router.GET("/readyz", controller.Readyz)
 	SetApiRouter(router)
 	SetDashboardRouter(router)
 	SetRelayRouter(router)
+	SetSCIMRouter(router)
 	// 初始化MCP服务器与Gin集成
 	if config.MCP_ENABLE {
 		logger.SysLog("Enable MCP Server")
@@ -34,4 +39,5 @@ func SetRouter(router *gin.Engine, buildFS embed.FS, indexPage []byte) {
 			c.Redirect(http.StatusMovedPermanently, fmt.Sprintf("%s%s", frontendBaseUrl, c.Request.RequestURI))
 		})
 	}
+	setOpenAPI(router)
 }
@@ -0,0 +1,216 @@
+package router
+
+import (
+	"net/http"
+	"one-api/common/graphql"
+	"one-api/common/openapi"
+	"one-api/common/redis"
+	"one-api/controller"
+	"one-api/middleware"
+	"one-api/model"
+	"one-api/providers/claude"
+	"one-api/relay"
+	"one-api/types"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	managementSecurity = []map[string][]string{{"accessToken": {}}, {"session": {}}}
+	relaySecurity      = []map[string][]string{{"apiKey": {}}}
+)
+
+// publicRoutes 无需登录即可访问的管理接口
+var publicRoutes = []string{
+	"GET /api/status",
+	"GET /api/notice",
+	"GET /api/about",
+	"GET /api/ownedby",
+	"GET /api/available_model",
+	"GET /api/user_group_map",
+	"GET /api/home_page_content",
+	"GET /api/image/:id",
+	"GET /api/openapi.json",
+	"GET /api/prices",
+	"GET /api/verification",
+	"GET /api/reset_password",
+	"POST /api/user/reset",
+	"POST /api/trial/token",
+	"POST /api/user/register",
+	"POST /api/user/login",
+	"GET /api/user/logout",
+	"GET /api/oauth/github",
+	"GET /api/oauth/lark",
+	"GET /api/oauth/wecom",
+	"GET /api/oauth/dingtalk",
+	"GET /api/oauth/state",
+	"GET /api/oauth/wechat",
+	"GET /api/oauth/endpoint",
+	"GET /api/oauth/oidc",
+	"POST /api/webauthn/login/begin",
+	"POST /api/webauthn/login/finish",
+	"GET /api/model_ownedby/",
+	"GET /api/model_info/",
+}
+
+// setOpenAPI 在所有路由注册完成后生成 /api/openapi.json 文档
+func setOpenAPI(router *gin.Engine) {
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/api/", Security: managementSecurity})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/dashboard/", Tag: "billing", Security: relaySecurity, Relay: true})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/v1/dashboard/", Tag: "billing", Security: relaySecurity, Relay: true})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/v1/", Tag: "openai", Security: relaySecurity, Relay: true})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/claude/", Tag: "claude", Security: []map[string][]string{{"claudeKey": {}}, {"apiKey": {}}}, Relay: true})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/gemini/", Tag: "gemini", Security: []map[string][]string{{"geminiKey": {}}, {"apiKey": {}}}, Relay: true})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/mj/", Tag: "midjourney", Security: []map[string][]string{{"mjKey": {}}}, Relay: true})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/:mode/mj/", Tag: "midjourney", Security: []map[string][]string{{"mjKey": {}}}, Relay: true})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/suno/", Tag: "suno", Security: relaySecurity, Relay: true})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/recraftAI/", Tag: "recraft", Security: relaySecurity, Relay: true})
+	openapi.AddSecurityRule(openapi.SecurityRule{Prefix: "/kling/", Tag: "kling", Security: relaySecurity, Relay: true})
+
+	for _, route := range publicRoutes {
+		openapi.MarkPublic(route)
+	}
+
+	// 中继接口
+	openapi.Describe(http.MethodPost, "/v1/chat/completions", openapi.Route{Summary: "聊天补全", Body: types.ChatCompletionRequest{}, Response: types.ChatCompletionResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/completions", openapi.Route{Summary: "文本补全", Body: types.CompletionRequest{}, Response: types.CompletionResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/responses", openapi.Route{Summary: "Responses 接口", Body: types.OpenAIResponsesRequest{}, Response: types.OpenAIResponsesResponses{}})
+	openapi.Describe(http.MethodPost, "/v1/embeddings", openapi.Route{Summary: "文本向量", Body: types.EmbeddingRequest{}, Response: types.EmbeddingResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/images/generations", openapi.Route{Summary: "生成图片", Body: types.ImageRequest{}, Response: types.ImageResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/images/edits", openapi.Route{Summary: "编辑图片", Body: types.ImageEditRequest{}, ContentType: "multipart/form-data", Response: types.ImageResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/images/variations", openapi.Route{Summary: "图片变体", Body: types.ImageEditRequest{}, ContentType: "multipart/form-data", Response: types.ImageResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/audio/transcriptions", openapi.Route{Summary: "语音转文字", Body: types.AudioRequest{}, ContentType: "multipart/form-data", Response: types.AudioResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/audio/translations", openapi.Route{Summary: "语音翻译", Body: types.AudioRequest{}, ContentType: "multipart/form-data", Response: types.AudioResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/audio/speech", openapi.Route{Summary: "文字转语音，返回音频", Body: types.SpeechAudioRequest{}})
+	openapi.Describe(http.MethodPost, "/v1/moderations", openapi.Route{Summary: "内容审查", Body: types.ModerationRequest{}, Response: types.ModerationResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/rerank", openapi.Route{Summary: "重排序", Body: types.RerankRequest{}, Response: types.RerankResponse{}})
+	openapi.Describe(http.MethodGet, "/v1/realtime", openapi.Route{Summary: "Realtime WebSocket"})
+	openapi.Describe(http.MethodGet, "/v1/models", openapi.Route{Summary: "令牌可用的模型列表"})
+	openapi.Describe(http.MethodGet, "/v1/capabilities", openapi.Route{Summary: "令牌可用的模型在各渠道上对工具调用、图片输入、JSON 模式、logprobs 和流式 usage 的支持（native/emulated/unsupported），model 参数可只查询一个模型", Response: []relay.ModelCapabilities{}})
+	openapi.Describe(http.MethodPost, "/v1/echo", openapi.Route{Summary: "不请求上游，按 OpenAI 的格式返回最后一条消息，支持流式、工具调用和 JSON 模式，请求头 X-Echo-Error 指定错误状态码", Body: types.ChatCompletionRequest{}, Response: types.ChatCompletionResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/echo/chat/completions", openapi.Route{Summary: "同 /v1/echo，供客户端 SDK 将 base_url 设置为 /v1/echo 使用", Body: types.ChatCompletionRequest{}, Response: types.ChatCompletionResponse{}})
+	openapi.Describe(http.MethodPost, "/v1/cost/estimate", openapi.Route{Summary: "估算请求在各候选模型上消耗的 token 和额度", Body: relay.CostEstimateRequest{}, Response: relay.CostEstimate{}})
+	openapi.Describe(http.MethodPost, "/claude/v1/messages", openapi.Route{Summary: "Claude Messages 接口", Body: claude.ClaudeRequest{}, Response: claude.ClaudeResponse{}})
+	openapi.Describe(http.MethodPost, "/gemini/:version/models/:model", openapi.Route{Summary: "Gemini 接口，model 为 模型:generateContent 或 模型:streamGenerateContent"})
+
+	// 管理接口
+	openapi.Describe(http.MethodGet, "/api/channel/", openapi.Route{Summary: "渠道列表", Query: model.SearchChannelsParams{}, Response: model.DataResult[model.Channel]{}})
+	openapi.Describe(http.MethodGet, "/api/channel/:id", openapi.Route{Summary: "获取渠道", Response: model.Channel{}})
+	openapi.Describe(http.MethodPost, "/api/channel/", openapi.Route{Summary: "添加渠道，key 按行拆分为多个渠道，duplicate_policy 指定重复 key 的处理方式：warn、skip、merge、allow，请求头 Idempotency-Key 可避免重复创建", Body: model.Channel{}, Response: model.ChannelImportResult{}})
+	openapi.Describe(http.MethodPut, "/api/channel/", openapi.Route{Summary: "更新渠道", Body: model.Channel{}, Response: model.Channel{}})
+	openapi.Describe(http.MethodPut, "/api/channel/external/:external_id", openapi.Route{Summary: "按外部 ID 创建或更新渠道，更新时只修改提供的字段", Body: model.Channel{}, Response: model.Channel{}})
+	openapi.Describe(http.MethodDelete, "/api/channel/:id", openapi.Route{Summary: "删除渠道"})
+	openapi.Describe(http.MethodPost, "/api/channel/:id/clone", openapi.Route{Summary: "复制渠道的所有设置，可以替换名称、密钥和地址", Body: controller.CloneChannelRequest{}})
+	openapi.Describe(http.MethodPost, "/api/channel/:id/credentials/validate", openapi.Route{Summary: "校验渠道密钥格式并在本地生成签名，不请求上游", Response: controller.ChannelCredentialResult{}})
+	openapi.Describe(http.MethodGet, "/api/channel/template", openapi.Route{Summary: "渠道模板列表", Query: model.GenericParams{}, Response: model.DataResult[model.ChannelTemplate]{}})
+	openapi.Describe(http.MethodGet, "/api/channel/template/:id", openapi.Route{Summary: "获取渠道模板", Response: model.ChannelTemplate{}})
+	openapi.Describe(http.MethodPost, "/api/channel/template", openapi.Route{Summary: "添加渠道模板，from_channel_id 不为 0 时使用该渠道的设置", Body: controller.ChannelTemplateRequest{}, Response: model.ChannelTemplate{}})
+	openapi.Describe(http.MethodPut, "/api/channel/template", openapi.Route{Summary: "更新渠道模板", Body: controller.ChannelTemplateRequest{}, Response: model.ChannelTemplate{}})
+	openapi.Describe(http.MethodDelete, "/api/channel/template/:id", openapi.Route{Summary: "删除渠道模板"})
+	openapi.Describe(http.MethodPost, "/api/channel/template/:id/apply", openapi.Route{Summary: "按模板添加渠道，key 按行拆分为多个渠道，模板中的价格只补充系统中没有的模型", Body: controller.ApplyChannelTemplateRequest{}})
+	openapi.Describe(http.MethodPatch, "/api/channel/batch", openapi.Route{Summary: "批量修改渠道，只修改提供的字段，在一个事务中完成", Body: model.BatchPatchChannelsParams{}})
+	openapi.Describe(http.MethodPost, "/api/channel/playground", openapi.Route{Summary: "通过指定渠道发送调试聊天请求，返回上游原始请求和响应", Body: controller.PlaygroundRequest{}, Response: controller.PlaygroundResult{}})
+	openapi.Describe(http.MethodPost, "/api/channel/:id/capture", openapi.Route{Summary: "记录渠道接下来的 count 次上游原始请求和响应，密钥已隐藏，count 为 0 时关闭", Body: controller.ChannelCaptureRequest{}, Response: controller.ChannelCaptureResult{}})
+	openapi.Describe(http.MethodGet, "/api/channel/:id/capture", openapi.Route{Summary: "渠道剩余的采样次数和已记录的采样", Query: model.PaginationParams{}, Response: controller.ChannelCaptureResult{}})
+	openapi.Describe(http.MethodDelete, "/api/channel/:id/capture", openapi.Route{Summary: "关闭采样并删除渠道的所有采样"})
+	openapi.Describe(http.MethodPost, "/api/channel/:id/sync_models", openapi.Route{Summary: "按渠道的 model_sync 设置从上游同步模型列表，dry_run=true 时只返回变化", Response: model.ModelSyncResult{}})
+	openapi.Describe(http.MethodGet, "/api/model_info/deprecated", openapi.Route{Summary: "仍有渠道提供的弃用或下线模型", Response: []model.DeprecatedModelUsage{}})
+	openapi.Describe(http.MethodGet, "/api/channel/auto_priority", openapi.Route{Summary: "本实例按成功率和 p95 延迟对渠道的自动调整", Response: []model.ChannelAdjustment{}})
+	openapi.Describe(http.MethodGet, "/api/channel/test", openapi.Route{Summary: "后台测试所有渠道，all_models=true 时测试渠道的所有模型，返回测试报告", Response: model.ChannelTestReport{}})
+	openapi.Describe(http.MethodGet, "/api/channel/test/:id", openapi.Route{Summary: "测试渠道，mode=conformance 时检查普通响应、流式、工具调用、JSON 模式和错误格式是否与 OpenAI 一致，返回兼容性报告", Response: controller.ConformanceReport{}})
+	openapi.Describe(http.MethodGet, "/api/channel/test/reports", openapi.Route{Summary: "渠道测试报告列表", Query: model.PaginationParams{}, Response: model.DataResult[model.ChannelTestReport]{}})
+	openapi.Describe(http.MethodGet, "/api/channel/test/reports/:id", openapi.Route{Summary: "渠道测试报告详情，failed=true 时只返回失败的结果", Response: model.ChannelTestReport{}})
+	openapi.Describe(http.MethodGet, "/api/user/", openapi.Route{Summary: "用户列表", Query: model.GenericParams{}, Response: model.DataResult[model.User]{}})
+	openapi.Describe(http.MethodGet, "/api/user/:id", openapi.Route{Summary: "获取用户", Response: model.User{}})
+	openapi.Describe(http.MethodPost, "/api/user/", openapi.Route{Summary: "创建用户，请求头 Idempotency-Key 可避免重复创建", Body: model.User{}})
+	openapi.Describe(http.MethodPut, "/api/user/", openapi.Route{Summary: "更新用户", Body: model.User{}})
+	openapi.Describe(http.MethodPut, "/api/user/external/:external_id", openapi.Route{Summary: "按外部 ID 创建或更新用户，更新时只修改提供的字段", Body: controller.ExternalUserRequest{}, Response: model.User{}})
+	openapi.Describe(http.MethodPost, "/api/user/manage", openapi.Route{Summary: "启用、禁用、删除、提升或降级用户", Body: controller.ManageRequest{}})
+	openapi.Describe(http.MethodPost, "/api/user/quota/:id", openapi.Route{Summary: "增减用户额度", Body: controller.ChangeUserQuotaRequest{}})
+	openapi.Describe(http.MethodGet, "/api/user/dashboard/overview", openapi.Route{Summary: "当前用户的用量合计、已启用的令牌、限流状态和最近 24 小时失败的请求", Response: controller.UserDashboardOverview{}})
+	openapi.Describe(http.MethodGet, "/api/user/referral", openapi.Route{Summary: "当前用户的邀请记录", Query: model.PaginationParams{}, Response: model.DataResult[model.Referral]{}})
+	openapi.Describe(http.MethodPost, "/api/trial/token", openapi.Route{Summary: "访客领取匿名试用令牌，令牌绑定领取时的 IP，同一 IP 和指纹在有效期内返回同一个令牌", Body: controller.TrialTokenRequest{}, Response: controller.TrialTokenResult{}})
+	openapi.Describe(http.MethodPost, "/api/user/login", openapi.Route{Summary: "登录", Body: controller.LoginRequest{}})
+	openapi.Describe(http.MethodGet, "/api/trash/", openapi.Route{Summary: "回收站中已删除的渠道、令牌或用户，type 为 channel、token 或 user", Query: model.TrashListParams{}, Response: model.DataResult[model.TrashItem]{}})
+	openapi.Describe(http.MethodPost, "/api/trash/:type/:id/restore", openapi.Route{Summary: "从回收站恢复，渠道恢复后重新加载可用模型，令牌需要先恢复所属用户"})
+	openapi.Describe(http.MethodDelete, "/api/trash/:type/:id", openapi.Route{Summary: "彻底删除回收站中的记录，无法恢复"})
+	openapi.Describe(http.MethodGet, "/api/user/sessions", openapi.Route{Summary: "当前用户的登录会话，current 为 true 的是当前会话", Response: []model.UserSession{}})
+	openapi.Describe(http.MethodDelete, "/api/user/sessions", openapi.Route{Summary: "撤销除当前会话外的所有会话，返回撤销的数量"})
+	openapi.Describe(http.MethodDelete, "/api/user/sessions/:id", openapi.Route{Summary: "撤销当前用户的指定会话"})
+	openapi.Describe(http.MethodGet, "/api/user/login_history", openapi.Route{Summary: "当前用户的登录记录", Query: model.LoginHistoryListParams{}, Response: model.DataResult[model.LoginHistory]{}})
+	openapi.Describe(http.MethodGet, "/api/user/login_history/all", openapi.Route{Summary: "所有用户的登录记录，租户管理员只能查看本租户用户", Query: model.LoginHistoryListParams{}, Response: model.DataResult[model.LoginHistory]{}})
+	openapi.Describe(http.MethodPost, "/api/user/transfer", openapi.Route{Summary: "向其他用户转账，需要开启 transfer.enable，接收用户可用 username 或 user_id 指定，手续费从转出方额外扣除", Body: controller.TransferQuotaRequest{}, Response: model.QuotaTransfer{}})
+	openapi.Describe(http.MethodGet, "/api/user/transfer", openapi.Route{Summary: "当前用户转出和收到的转账记录", Query: model.PaginationParams{}, Response: model.DataResult[model.QuotaTransfer]{}})
+	openapi.Describe(http.MethodGet, "/api/user/transfer/all", openapi.Route{Summary: "所有用户的转账记录，租户管理员只能查看本租户用户", Query: model.QuotaTransfersListParams{}, Response: model.DataResult[model.QuotaTransfer]{}})
+	openapi.Describe(http.MethodGet, "/api/user/:id/sessions", openapi.Route{Summary: "管理员查看用户的登录会话", Response: []model.UserSession{}})
+	openapi.Describe(http.MethodDelete, "/api/user/:id/sessions", openapi.Route{Summary: "管理员撤销用户的所有会话，返回撤销的数量"})
+	openapi.Describe(http.MethodGet, "/api/token/", openapi.Route{Summary: "当前用户的令牌列表", Query: model.GenericParams{}, Response: model.DataResult[model.Token]{}})
+	openapi.Describe(http.MethodGet, "/api/token/:id", openapi.Route{Summary: "获取令牌", Response: model.Token{}})
+	openapi.Describe(http.MethodGet, "/api/token/:id/stats", openapi.Route{Summary: "当前用户令牌按天和按模型的用量，默认最近 30 天", Query: controller.TokenStatisticsParams{}, Response: model.TokenStatistics{}})
+	openapi.Describe(http.MethodPost, "/api/token/", openapi.Route{Summary: "添加令牌，请求头 Idempotency-Key 可避免重复创建", Body: model.Token{}, Response: model.Token{}})
+	openapi.Describe(http.MethodPut, "/api/token/", openapi.Route{Summary: "更新令牌", Body: model.Token{}, Response: model.Token{}})
+	openapi.Describe(http.MethodPut, "/api/token/external/:external_id", openapi.Route{Summary: "按外部 ID 创建或更新当前用户的令牌，更新时只修改提供的字段", Body: model.Token{}, Response: model.Token{}})
+	openapi.Describe(http.MethodDelete, "/api/token/:id", openapi.Route{Summary: "删除令牌"})
+	openapi.Describe(http.MethodPut, "/api/token/:id/channels", openapi.Route{Summary: "管理员绑定令牌只能使用的渠道，channel_ids 为空时解除绑定", Body: controller.TokenChannelsRequest{}, Response: model.Token{}})
+	openapi.Describe(http.MethodPut, "/api/token/:id/service_account", openapi.Route{Summary: "设置令牌为内部服务账号，不扣除额度但照常记录日志和限流", Body: controller.TokenServiceAccountRequest{}, Response: model.Token{}})
+	openapi.Describe(http.MethodGet, "/api/redemption/", openapi.Route{Summary: "兑换码列表", Query: model.GenericParams{}, Response: model.DataResult[model.Redemption]{}})
+	openapi.Describe(http.MethodPost, "/api/redemption/", openapi.Route{Summary: "添加兑换码，batch 可设置活动、兑换时间和每用户上限", Body: model.Redemption{}})
+	openapi.Describe(http.MethodGet, "/api/redemption/batch", openapi.Route{Summary: "兑换码批次列表", Query: model.RedemptionBatchesListParams{}, Response: model.DataResult[model.RedemptionBatch]{}})
+	openapi.Describe(http.MethodPut, "/api/redemption/batch", openapi.Route{Summary: "更新兑换码批次的活动、兑换时间和每用户上限", Body: model.RedemptionBatch{}, Response: model.RedemptionBatch{}})
+	openapi.Describe(http.MethodGet, "/api/redemption/campaign", openapi.Route{Summary: "按活动统计兑换码的兑换率，campaign 参数筛选活动", Response: []model.RedemptionCampaignStatistics{}})
+	openapi.Describe(http.MethodGet, "/api/referral/", openapi.Route{Summary: "邀请记录，rejected 为未通过防刷检查未发放奖励", Query: model.ReferralsListParams{}, Response: model.DataResult[model.Referral]{}})
+	openapi.Describe(http.MethodGet, "/api/referral/report", openapi.Route{Summary: "按邀请人汇总邀请数和发放的奖励", Query: model.ReferralReportParams{}, Response: []model.ReferralReport{}})
+	openapi.Describe(http.MethodGet, "/api/quota_grant/", openapi.Route{Summary: "定时发放额度规则列表", Query: model.PaginationParams{}, Response: model.DataResult[model.QuotaGrant]{}})
+	openapi.Describe(http.MethodPost, "/api/quota_grant/", openapi.Route{Summary: "添加定时发放额度规则，period 为 daily 或 monthly，mode 为 add（增加）或 top_up（补足）", Body: model.QuotaGrant{}, Response: model.QuotaGrant{}})
+	openapi.Describe(http.MethodPut, "/api/quota_grant/", openapi.Route{Summary: "更新定时发放额度规则", Body: model.QuotaGrant{}, Response: model.QuotaGrant{}})
+	openapi.Describe(http.MethodDelete, "/api/quota_grant/:id", openapi.Route{Summary: "删除定时发放额度规则"})
+	openapi.Describe(http.MethodGet, "/api/invitation_code/", openapi.Route{Summary: "邀请码列表", Query: model.GenericParams{}, Response: model.DataResult[model.InvitationCode]{}})
+	openapi.Describe(http.MethodPost, "/api/invitation_code/", openapi.Route{Summary: "添加邀请码", Body: model.InvitationCode{}})
+	openapi.Describe(http.MethodGet, "/api/log/", openapi.Route{Summary: "所有日志", Query: model.LogsListParams{}, Response: model.DataResult[model.Log]{}})
+	openapi.Describe(http.MethodGet, "/api/log/search", openapi.Route{Summary: "按 id 倒序游标分页查询日志，支持组合过滤，keyword 同时搜索日志内容和保存的请求体", Query: model.LogsCursorParams{}, Response: model.LogsCursorResult{}})
+	openapi.Describe(http.MethodGet, "/api/log/self", openapi.Route{Summary: "当前用户的日志", Query: model.LogsListParams{}, Response: model.DataResult[model.Log]{}})
+	openapi.Describe(http.MethodPost, "/api/log/:id/replay", openapi.Route{Summary: "用 request_capture 保存的请求体重放日志对应的请求，可指定渠道和模型，不扣费，返回响应和按当前价格计算的额度", Body: relay.ReplayRequest{}, Response: relay.ReplayResult{}})
+	openapi.Describe(http.MethodGet, "/api/diagnostics/runtime", openapi.Route{Summary: "本实例的 goroutine 数、内存、GC 和正在处理的中继请求数", Response: controller.RuntimeStats{}})
+	openapi.Describe(http.MethodGet, "/api/diagnostics/requests", openapi.Route{Summary: "本实例正在处理的中继请求，包括渠道、模型和已处理的时间，min_age 只返回处理超过指定秒数的请求", Response: []middleware.InFlightRequest{}})
+	openapi.Describe(http.MethodGet, "/api/diagnostics/goroutines", openapi.Route{Summary: "以文本返回所有 goroutine 的调用栈，需要开启 diagnostics.pprof", Raw: true})
+	openapi.Describe(http.MethodGet, "/api/diagnostics/pprof", openapi.Route{Summary: "可用的 pprof 分析项，需要开启 diagnostics.pprof", Response: []string{}})
+	openapi.Describe(http.MethodGet, "/api/diagnostics/pprof/:name", openapi.Route{Summary: "与 net/http/pprof 相同，profile 和 trace 按 seconds 参数采样，需要开启 diagnostics.pprof", Raw: true})
+	openapi.Describe(http.MethodGet, "/api/loadtest/", openapi.Route{Summary: "本实例最近的压测报告，最新的在前", Response: []relay.LoadTestReport{}})
+	openapi.Describe(http.MethodPost, "/api/loadtest/", openapi.Route{Summary: "在本实例上异步运行压测，发送生成的请求或回放 request_capture 保存的请求，默认返回沙盒模拟响应，upstream 为 true 时才请求指定渠道的上游，不扣费，同一时间只能运行一个", Body: relay.LoadTestRequest{}, Response: relay.LoadTestReport{}})
+	openapi.Describe(http.MethodGet, "/api/loadtest/:id", openapi.Route{Summary: "压测报告，包括实际 QPS、耗时分布、错误率和状态码分布，运行中时为当前的统计", Response: relay.LoadTestReport{}})
+	openapi.Describe(http.MethodPost, "/api/loadtest/:id/stop", openapi.Route{Summary: "停止正在运行的压测"})
+	openapi.Describe(http.MethodPost, "/api/log/refund", openapi.Route{Summary: "按消费日志退还额度并记录原因，quota 为 0 时全额退款，同一个请求只能退款一次", Body: controller.RefundLogsRequest{}, Response: controller.RefundLogsResult{}})
+	openapi.Describe(http.MethodGet, "/api/log/refunds", openapi.Route{Summary: "额度退款记录，包括流式响应中断时的自动退款", Query: model.QuotaRefundsListParams{}, Response: model.DataResult[model.QuotaRefund]{}})
+	openapi.Describe(http.MethodGet, "/api/log/anomaly", openapi.Route{Summary: "用量异常记录", Query: model.UsageAnomaliesListParams{}, Response: model.DataResult[model.UsageAnomaly]{}})
+	openapi.Describe(http.MethodGet, "/api/ip_rule/", openapi.Route{Summary: "IP 规则列表", Query: model.SearchIPRuleParams{}, Response: model.DataResult[model.IPRule]{}})
+	openapi.Describe(http.MethodGet, "/api/model_route/", openapi.Route{Summary: "模型路由列表，模型固定使用的渠道优先于权重和优先级选择", Query: model.SearchModelRouteParams{}, Response: model.DataResult[model.ModelRoute]{}})
+	openapi.Describe(http.MethodPost, "/api/model_route/", openapi.Route{Summary: "添加模型路由，group 为空时对所有分组生效，channel_ids 按顺序选择第一个可用的渠道", Body: model.ModelRoute{}, Response: model.ModelRoute{}})
+	openapi.Describe(http.MethodPut, "/api/model_route/", openapi.Route{Summary: "更新模型路由", Body: model.ModelRoute{}, Response: model.ModelRoute{}})
+	openapi.Describe(http.MethodDelete, "/api/model_route/:id", openapi.Route{Summary: "删除模型路由"})
+	openapi.Describe(http.MethodGet, "/api/client_cert/", openapi.Route{Summary: "客户端证书绑定列表", Query: model.SearchClientCertParams{}, Response: model.DataResult[model.ClientCert]{}})
+	openapi.Describe(http.MethodGet, "/api/prices/document", openapi.Route{Summary: "以模型名为键导出全部价格", Response: model.PriceDocument{}})
+	openapi.Describe(http.MethodPut, "/api/prices/document", openapi.Route{Summary: "用整份价格文档替换全部价格，文档中没有的模型会被删除，dry_run=true 时只返回变化", Body: model.PriceDocument{}, Response: model.PriceDiff{}})
+	openapi.Describe(http.MethodGet, "/api/prices/presets", openapi.Route{Summary: "可导入的价格预设名称", Response: []string{}})
+	openapi.Describe(http.MethodPost, "/api/prices/import", openapi.Route{Summary: "按更新模式导入价格预设，不修改 locked 的价格，返回价格的变化", Body: controller.PricePresetImportRequest{}, Response: model.PriceDiff{}})
+	openapi.Describe(http.MethodGet, "/api/prices/proposals", openapi.Route{Summary: "价格同步提出的新模型价格", Query: model.PriceProposalsListParams{}, Response: model.DataResult[model.PriceProposal]{}})
+	openapi.Describe(http.MethodPost, "/api/prices/proposals/sync", openapi.Route{Summary: "立即拉取价格清单，为渠道已提供但没有价格的模型提出价格"})
+	openapi.Describe(http.MethodPost, "/api/prices/proposals/approve", openapi.Route{Summary: "通过待审核的价格并写入", Body: controller.PriceProposalsRequest{}})
+	openapi.Describe(http.MethodPost, "/api/prices/proposals/reject", openapi.Route{Summary: "拒绝待审核的价格，之后的同步不再提出这些模型", Body: controller.PriceProposalsRequest{}})
+	openapi.Describe(http.MethodGet, "/api/prices/schedules", openapi.Route{Summary: "定时生效的价格和分组倍率调整", Query: model.PriceSchedulesListParams{}, Response: model.DataResult[model.PriceSchedule]{}})
+	openapi.Describe(http.MethodPost, "/api/prices/schedules", openapi.Route{Summary: "添加定时调整，生效时由主节点在同一个事务中写入价格和分组倍率", Body: model.PriceSchedule{}, Response: model.PriceSchedule{}})
+	openapi.Describe(http.MethodPost, "/api/prices/schedules/preview", openapi.Route{Summary: "按最近 days 天（默认 7）的用量估算调整后的额度消耗", Body: model.PriceSchedule{}, Response: model.PriceSchedulePreview{}})
+	openapi.Describe(http.MethodPost, "/api/prices/schedules/:id/cancel", openapi.Route{Summary: "取消尚未生效的调整"})
+	openapi.Describe(http.MethodGet, "/api/option/", openapi.Route{Summary: "系统设置", Response: []model.Option{}})
+	openapi.Describe(http.MethodPut, "/api/option/", openapi.Route{Summary: "更新系统设置", Body: model.Option{}})
+	openapi.Describe(http.MethodGet, "/api/option/email_templates", openapi.Route{Summary: "邮件模板，包括内置模板、自定义模板和可用变量"})
+	openapi.Describe(http.MethodPost, "/api/option/email_templates/preview", openapi.Route{Summary: "用示例数据预览邮件模板", Body: controller.EmailTemplatePreviewRequest{}})
+	openapi.Describe(http.MethodPost, "/api/backup/", openapi.Route{Summary: "下载备份，未提供口令时不导出密钥，只能在主节点执行", Body: controller.BackupRequest{}, Raw: true})
+	openapi.Describe(http.MethodPost, "/api/backup/restore", openapi.Route{Summary: "从备份恢复到新部署，表单字段 file 为备份文件，passphrase 为导出口令", ContentType: "multipart/form-data"})
+	openapi.Describe(http.MethodGet, "/api/status/config_version", openapi.Route{Summary: "最新的配置版本和各实例已应用的版本，stale 为 true 的实例还有渠道或设置的修改没有应用", Response: redis.ConfigVersionStatus{}})
+	openapi.Describe(http.MethodPost, "/api/sync/", openapi.Route{Summary: "按外部 ID 声明式同步渠道和令牌，prune 时删除前缀下不再声明的资源，返回每个资源的操作和状态", Body: controller.SyncRequest{}, Response: []controller.SyncResult{}})
+	openapi.Describe(http.MethodGet, "/api/sync/status", openapi.Route{Summary: "外部 ID 前缀下渠道和令牌的状态，包括渠道状态、最后测试时间和响应时间", Response: []controller.SyncResult{}})
+	openapi.Describe(http.MethodPost, "/api/graphql", openapi.Route{Summary: "只读 GraphQL 查询，根字段为 users、user、tokens、token、channels、channel、logs、statistics 和 usage", Body: graphql.Request{}, Response: graphql.Response{}})
+	openapi.Describe(http.MethodGet, "/api/openapi.json", openapi.Route{Summary: "OpenAPI 文档", Raw: true})
+
+	openapi.Build(router.Routes())
+}
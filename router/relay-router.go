@@ -5,6 +5,7 @@ import (
 	"one-api/relay"
 	"one-api/relay/midjourney"
 	"one-api/relay/task"
+	"one-api/relay/task/finetune"
 	"one-api/relay/task/kling"
 	"one-api/relay/task/suno"
 
@@ -12,7 +13,7 @@ import (
 )
 
 func SetRelayRouter(router *gin.Engine) {
-	router.Use(middleware.CORS())
+	router.Use(middleware.CORS(), middleware.Draining())
 	// https://platform.openai.com/docs/api-reference/introduction
 	setOpenAIRouter(router)
 	setMJRouter(router)
@@ -21,17 +22,34 @@ func SetRelayRouter(router *gin.Engine) {
 	setGeminiRouter(router)
 	setRecraftRouter(router)
 	setKlingRouter(router)
+	setProxyRouter(router)
 }
 
 func setOpenAIRouter(router *gin.Engine) {
 	modelsRouter := router.Group("/v1/models")
-	modelsRouter.Use(middleware.OpenaiAuth(), middleware.Distribute())
+	modelsRouter.Use(middleware.Maintenance(), middleware.OpenaiAuth(), middleware.Distribute())
 	{
 		modelsRouter.GET("", relay.ListModelsByToken)
 		modelsRouter.GET("/:model", relay.RetrieveModel)
 	}
+	capabilitiesRouter := router.Group("/v1/capabilities")
+	capabilitiesRouter.Use(middleware.Maintenance(), middleware.OpenaiAuth(), middleware.Distribute())
+	{
+		capabilitiesRouter.GET("", relay.ListCapabilitiesByToken)
+	}
+	costRouter := router.Group("/v1/cost")
+	costRouter.Use(middleware.Maintenance(), middleware.OpenaiAuth(), middleware.Distribute())
+	{
+		costRouter.POST("/estimate", relay.EstimateCost)
+	}
+	echoRouter := router.Group("/v1/echo")
+	echoRouter.Use(middleware.Maintenance(), middleware.RelayPanicRecover(), middleware.OpenaiAuth())
+	{
+		echoRouter.POST("", relay.Echo)
+		echoRouter.POST("/chat/completions", relay.Echo)
+	}
 	relayV1Router := router.Group("/v1")
-	relayV1Router.Use(middleware.RelayPanicRecover(), middleware.OpenaiAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
+	relayV1Router.Use(middleware.TrackInFlight(), middleware.Maintenance(), middleware.RelayPanicRecover(), middleware.OpenaiAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
 	{
 		relayV1Router.POST("/completions", relay.Relay)
 		relayV1Router.POST("/chat/completions", relay.Relay)
@@ -49,16 +67,23 @@ func setOpenAIRouter(router *gin.Engine) {
 		relayV1Router.POST("/rerank", relay.RelayRerank)
 		relayV1Router.GET("/realtime", relay.ChatRealtime)
 
-		relayV1Router.Use(middleware.SpecifiedChannel())
+		relayV1Router.Use(middleware.VectorStoreChannel(), middleware.SpecifiedChannel())
 		{
 			relayV1Router.Any("/files", relay.RelayOnly)
 			relayV1Router.Any("/files/*any", relay.RelayOnly)
-			relayV1Router.Any("/fine_tuning/*any", relay.RelayOnly)
+			relayV1Router.POST("/fine_tuning/jobs", task.RelayFineTuningSubmit)
+			relayV1Router.GET("/fine_tuning/jobs", finetune.ListJobs)
+			relayV1Router.GET("/fine_tuning/jobs/:id", finetune.RetrieveJob)
+			relayV1Router.POST("/fine_tuning/jobs/:id/cancel", finetune.CancelJob)
+			relayV1Router.GET("/fine_tuning/jobs/:id/events", finetune.JobEvents)
+			relayV1Router.GET("/fine_tuning/jobs/:id/checkpoints", finetune.JobCheckpoints)
+			relayV1Router.Any("/fine_tuning/checkpoints/*any", relay.RelayOnly)
 			relayV1Router.Any("/assistants", relay.RelayOnly)
 			relayV1Router.Any("/assistants/*any", relay.RelayOnly)
 			relayV1Router.Any("/threads", relay.RelayOnly)
 			relayV1Router.Any("/threads/*any", relay.RelayOnly)
 			relayV1Router.Any("/batches/*any", relay.RelayOnly)
+			relayV1Router.Any("/vector_stores", relay.RelayOnly)
 			relayV1Router.Any("/vector_stores/*any", relay.RelayOnly)
 			relayV1Router.DELETE("/models/:model", relay.RelayOnly)
 		}
@@ -78,7 +103,7 @@ func setMJRouter(router *gin.Engine) {
 // Path: router/relay-router.go
 func registerMjRouterGroup(relayMjRouter *gin.RouterGroup) {
 	relayMjRouter.GET("/image/:id", midjourney.RelayMidjourneyImage)
-	relayMjRouter.Use(middleware.RelayMJPanicRecover(), middleware.MjAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
+	relayMjRouter.Use(middleware.TrackInFlight(), middleware.Maintenance(), middleware.RelayMJPanicRecover(), middleware.MjAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
 	{
 		relayMjRouter.POST("/submit/action", midjourney.RelayMidjourney)
 		relayMjRouter.POST("/submit/shorten", midjourney.RelayMidjourney)
@@ -99,7 +124,7 @@ func registerMjRouterGroup(relayMjRouter *gin.RouterGroup) {
 
 func setSunoRouter(router *gin.Engine) {
 	relaySunoRouter := router.Group("/suno")
-	relaySunoRouter.Use(middleware.RelaySunoPanicRecover(), middleware.OpenaiAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
+	relaySunoRouter.Use(middleware.TrackInFlight(), middleware.Maintenance(), middleware.RelaySunoPanicRecover(), middleware.OpenaiAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
 	{
 		relaySunoRouter.POST("/submit/:action", task.RelayTaskSubmit)
 		relaySunoRouter.POST("/fetch", suno.GetFetch)
@@ -110,7 +135,7 @@ func setSunoRouter(router *gin.Engine) {
 func setClaudeRouter(router *gin.Engine) {
 	relayClaudeRouter := router.Group("/claude")
 	relayV1Router := relayClaudeRouter.Group("/v1")
-	relayV1Router.Use(middleware.APIEnabled("claude"), middleware.RelayCluadePanicRecover(), middleware.ClaudeAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
+	relayV1Router.Use(middleware.TrackInFlight(), middleware.Maintenance(), middleware.APIEnabled("claude"), middleware.RelayCluadePanicRecover(), middleware.ClaudeAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
 	{
 		relayV1Router.POST("/messages", relay.Relay)
 		relayV1Router.GET("/models", relay.ListClaudeModelsByToken)
@@ -119,7 +144,7 @@ func setClaudeRouter(router *gin.Engine) {
 
 func setGeminiRouter(router *gin.Engine) {
 	relayGeminiRouter := router.Group("/gemini")
-	relayGeminiRouter.Use(middleware.APIEnabled("gemini"), middleware.RelayGeminiPanicRecover(), middleware.GeminiAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
+	relayGeminiRouter.Use(middleware.TrackInFlight(), middleware.Maintenance(), middleware.APIEnabled("gemini"), middleware.RelayGeminiPanicRecover(), middleware.GeminiAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
 	{
 		relayGeminiRouter.POST("/:version/models/:model", relay.Relay)
 		relayGeminiRouter.GET("/:version/models", relay.ListGeminiModelsByToken)
@@ -128,7 +153,7 @@ func setGeminiRouter(router *gin.Engine) {
 
 func setRecraftRouter(router *gin.Engine) {
 	relayRecraftRouter := router.Group("/recraftAI/v1")
-	relayRecraftRouter.Use(middleware.RelayPanicRecover(), middleware.OpenaiAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
+	relayRecraftRouter.Use(middleware.TrackInFlight(), middleware.Maintenance(), middleware.RelayPanicRecover(), middleware.OpenaiAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
 	{
 		relayRecraftRouter.POST("/images/generations", relay.Relay)
 		relayRecraftRouter.POST("/images/vectorize", relay.RelayRecraftAI)
@@ -141,7 +166,7 @@ func setRecraftRouter(router *gin.Engine) {
 
 func setKlingRouter(router *gin.Engine) {
 	relayKlingRouter := router.Group("/kling")
-	relayKlingRouter.Use(middleware.RelayKlingPanicRecover(), middleware.OpenaiAuth(), middleware.Distribute())
+	relayKlingRouter.Use(middleware.TrackInFlight(), middleware.Maintenance(), middleware.RelayKlingPanicRecover(), middleware.OpenaiAuth(), middleware.Distribute())
 	relayKlingRouter.GET("/v1/videos/text2video/:id", kling.GetFetchByID)
 	relayKlingRouter.GET("/v1/videos/image2video/:id", kling.GetFetchByID)
 
@@ -150,3 +175,12 @@ func setKlingRouter(router *gin.Engine) {
 		relayKlingRouter.POST("/v1/:class/:action", task.RelayTaskSubmit)
 	}
 }
+
+// setProxyRouter 按渠道配置的路径前缀转发任意上游接口
+func setProxyRouter(router *gin.Engine) {
+	relayProxyRouter := router.Group("/proxy")
+	relayProxyRouter.Use(middleware.TrackInFlight(), middleware.Maintenance(), middleware.RelayPanicRecover(), middleware.OpenaiAuth(), middleware.Distribute(), middleware.DynamicRedisRateLimiter())
+	{
+		relayProxyRouter.Any("/:prefix/*path", relay.Relay)
+	}
+}
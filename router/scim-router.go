@@ -0,0 +1,31 @@
+package router
+
+import (
+	"one-api/controller"
+	"one-api/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetSCIMRouter SCIM 2.0 用户同步接口，供企业身份提供方创建、更新、停用用户和管理分组成员
+func SetSCIMRouter(router *gin.Engine) {
+	scimRouter := router.Group("/scim/v2")
+	scimRouter.Use(middleware.GlobalAPIRateLimit(), middleware.SCIMAuth())
+	{
+		scimRouter.GET("/ServiceProviderConfig", controller.SCIMServiceProviderConfig)
+		scimRouter.GET("/ResourceTypes", controller.SCIMResourceTypes)
+
+		scimRouter.GET("/Users", controller.SCIMListUsers)
+		scimRouter.POST("/Users", controller.SCIMCreateUser)
+		scimRouter.GET("/Users/:id", controller.SCIMGetUser)
+		scimRouter.PUT("/Users/:id", controller.SCIMReplaceUser)
+		scimRouter.PATCH("/Users/:id", controller.SCIMPatchUser)
+		scimRouter.DELETE("/Users/:id", controller.SCIMDeleteUser)
+
+		scimRouter.GET("/Groups", controller.SCIMListGroups)
+		scimRouter.POST("/Groups", controller.SCIMCreateGroup)
+		scimRouter.GET("/Groups/:id", controller.SCIMGetGroup)
+		scimRouter.PUT("/Groups/:id", controller.SCIMReplaceGroup)
+		scimRouter.PATCH("/Groups/:id", controller.SCIMPatchGroup)
+	}
+}
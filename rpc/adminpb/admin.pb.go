@@ -0,0 +1,2420 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: oneapi/admin/v1/admin.proto
+
+package adminpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ChannelEventType int32
+
+const (
+	ChannelEventType_CHANNEL_EVENT_TYPE_UNSPECIFIED ChannelEventType = 0
+	ChannelEventType_CHANNEL_EVENT_TYPE_ADDED       ChannelEventType = 1
+	ChannelEventType_CHANNEL_EVENT_TYPE_MODIFIED    ChannelEventType = 2
+	ChannelEventType_CHANNEL_EVENT_TYPE_DELETED     ChannelEventType = 3
+)
+
+// Enum value maps for ChannelEventType.
+var (
+	ChannelEventType_name = map[int32]string{
+		0: "CHANNEL_EVENT_TYPE_UNSPECIFIED",
+		1: "CHANNEL_EVENT_TYPE_ADDED",
+		2: "CHANNEL_EVENT_TYPE_MODIFIED",
+		3: "CHANNEL_EVENT_TYPE_DELETED",
+	}
+	ChannelEventType_value = map[string]int32{
+		"CHANNEL_EVENT_TYPE_UNSPECIFIED": 0,
+		"CHANNEL_EVENT_TYPE_ADDED":       1,
+		"CHANNEL_EVENT_TYPE_MODIFIED":    2,
+		"CHANNEL_EVENT_TYPE_DELETED":     3,
+	}
+)
+
+func (x ChannelEventType) Enum() *ChannelEventType {
+	p := new(ChannelEventType)
+	*p = x
+	return p
+}
+
+func (x ChannelEventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ChannelEventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_oneapi_admin_v1_admin_proto_enumTypes[0].Descriptor()
+}
+
+func (ChannelEventType) Type() protoreflect.EnumType {
+	return &file_oneapi_admin_v1_admin_proto_enumTypes[0]
+}
+
+func (x ChannelEventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ChannelEventType.Descriptor instead.
+func (ChannelEventType) EnumDescriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type Pagination struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Size          int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	Order         string                 `protobuf:"bytes,3,opt,name=order,proto3" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Pagination) Reset() {
+	*x = Pagination{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Pagination) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Pagination) ProtoMessage() {}
+
+func (x *Pagination) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Pagination.ProtoReflect.Descriptor instead.
+func (*Pagination) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Pagination) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *Pagination) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *Pagination) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
+type PageInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Page          int32                  `protobuf:"varint,1,opt,name=page,proto3" json:"page,omitempty"`
+	Size          int32                  `protobuf:"varint,2,opt,name=size,proto3" json:"size,omitempty"`
+	TotalCount    int64                  `protobuf:"varint,3,opt,name=total_count,json=totalCount,proto3" json:"total_count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PageInfo) Reset() {
+	*x = PageInfo{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PageInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PageInfo) ProtoMessage() {}
+
+func (x *PageInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PageInfo.ProtoReflect.Descriptor instead.
+func (*PageInfo) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PageInfo) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *PageInfo) GetSize() int32 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *PageInfo) GetTotalCount() int64 {
+	if x != nil {
+		return x.TotalCount
+	}
+	return 0
+}
+
+// Channel 渠道，key 只能写入不会返回
+type Channel struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type          int32                  `protobuf:"varint,2,opt,name=type,proto3" json:"type,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Status        int32                  `protobuf:"varint,4,opt,name=status,proto3" json:"status,omitempty"`
+	Group         string                 `protobuf:"bytes,5,opt,name=group,proto3" json:"group,omitempty"`
+	Models        string                 `protobuf:"bytes,6,opt,name=models,proto3" json:"models,omitempty"`
+	Tag           string                 `protobuf:"bytes,7,opt,name=tag,proto3" json:"tag,omitempty"`
+	BaseUrl       string                 `protobuf:"bytes,8,opt,name=base_url,json=baseUrl,proto3" json:"base_url,omitempty"`
+	Weight        uint32                 `protobuf:"varint,9,opt,name=weight,proto3" json:"weight,omitempty"`
+	Priority      int64                  `protobuf:"varint,10,opt,name=priority,proto3" json:"priority,omitempty"`
+	ModelMapping  string                 `protobuf:"bytes,11,opt,name=model_mapping,json=modelMapping,proto3" json:"model_mapping,omitempty"`
+	TestModel     string                 `protobuf:"bytes,12,opt,name=test_model,json=testModel,proto3" json:"test_model,omitempty"`
+	ResponseTime  int32                  `protobuf:"varint,13,opt,name=response_time,json=responseTime,proto3" json:"response_time,omitempty"`
+	Balance       float64                `protobuf:"fixed64,14,opt,name=balance,proto3" json:"balance,omitempty"`
+	UsedQuota     int64                  `protobuf:"varint,15,opt,name=used_quota,json=usedQuota,proto3" json:"used_quota,omitempty"`
+	CreatedTime   int64                  `protobuf:"varint,16,opt,name=created_time,json=createdTime,proto3" json:"created_time,omitempty"`
+	TestTime      int64                  `protobuf:"varint,17,opt,name=test_time,json=testTime,proto3" json:"test_time,omitempty"`
+	TenantId      int64                  `protobuf:"varint,18,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Channel) Reset() {
+	*x = Channel{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Channel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Channel) ProtoMessage() {}
+
+func (x *Channel) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Channel.ProtoReflect.Descriptor instead.
+func (*Channel) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Channel) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Channel) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Channel) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Channel) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *Channel) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Channel) GetModels() string {
+	if x != nil {
+		return x.Models
+	}
+	return ""
+}
+
+func (x *Channel) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+func (x *Channel) GetBaseUrl() string {
+	if x != nil {
+		return x.BaseUrl
+	}
+	return ""
+}
+
+func (x *Channel) GetWeight() uint32 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+func (x *Channel) GetPriority() int64 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Channel) GetModelMapping() string {
+	if x != nil {
+		return x.ModelMapping
+	}
+	return ""
+}
+
+func (x *Channel) GetTestModel() string {
+	if x != nil {
+		return x.TestModel
+	}
+	return ""
+}
+
+func (x *Channel) GetResponseTime() int32 {
+	if x != nil {
+		return x.ResponseTime
+	}
+	return 0
+}
+
+func (x *Channel) GetBalance() float64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *Channel) GetUsedQuota() int64 {
+	if x != nil {
+		return x.UsedQuota
+	}
+	return 0
+}
+
+func (x *Channel) GetCreatedTime() int64 {
+	if x != nil {
+		return x.CreatedTime
+	}
+	return 0
+}
+
+func (x *Channel) GetTestTime() int64 {
+	if x != nil {
+		return x.TestTime
+	}
+	return 0
+}
+
+func (x *Channel) GetTenantId() int64 {
+	if x != nil {
+		return x.TenantId
+	}
+	return 0
+}
+
+type ListChannelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pagination    *Pagination            `protobuf:"bytes,1,opt,name=pagination,proto3" json:"pagination,omitempty"`
+	Type          int32                  `protobuf:"varint,2,opt,name=type,proto3" json:"type,omitempty"`
+	Status        int32                  `protobuf:"varint,3,opt,name=status,proto3" json:"status,omitempty"`
+	Name          string                 `protobuf:"bytes,4,opt,name=name,proto3" json:"name,omitempty"`
+	Group         string                 `protobuf:"bytes,5,opt,name=group,proto3" json:"group,omitempty"`
+	Models        string                 `protobuf:"bytes,6,opt,name=models,proto3" json:"models,omitempty"`
+	Tag           string                 `protobuf:"bytes,7,opt,name=tag,proto3" json:"tag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChannelsRequest) Reset() {
+	*x = ListChannelsRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChannelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChannelsRequest) ProtoMessage() {}
+
+func (x *ListChannelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChannelsRequest.ProtoReflect.Descriptor instead.
+func (*ListChannelsRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListChannelsRequest) GetPagination() *Pagination {
+	if x != nil {
+		return x.Pagination
+	}
+	return nil
+}
+
+func (x *ListChannelsRequest) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *ListChannelsRequest) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *ListChannelsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ListChannelsRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *ListChannelsRequest) GetModels() string {
+	if x != nil {
+		return x.Models
+	}
+	return ""
+}
+
+func (x *ListChannelsRequest) GetTag() string {
+	if x != nil {
+		return x.Tag
+	}
+	return ""
+}
+
+type ListChannelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channels      []*Channel             `protobuf:"bytes,1,rep,name=channels,proto3" json:"channels,omitempty"`
+	Page          *PageInfo              `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChannelsResponse) Reset() {
+	*x = ListChannelsResponse{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChannelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChannelsResponse) ProtoMessage() {}
+
+func (x *ListChannelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChannelsResponse.ProtoReflect.Descriptor instead.
+func (*ListChannelsResponse) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListChannelsResponse) GetChannels() []*Channel {
+	if x != nil {
+		return x.Channels
+	}
+	return nil
+}
+
+func (x *ListChannelsResponse) GetPage() *PageInfo {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type GetChannelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetChannelRequest) Reset() {
+	*x = GetChannelRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetChannelRequest) ProtoMessage() {}
+
+func (x *GetChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetChannelRequest.ProtoReflect.Descriptor instead.
+func (*GetChannelRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetChannelRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateChannelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       *Channel               `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateChannelRequest) Reset() {
+	*x = CreateChannelRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateChannelRequest) ProtoMessage() {}
+
+func (x *CreateChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateChannelRequest.ProtoReflect.Descriptor instead.
+func (*CreateChannelRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreateChannelRequest) GetChannel() *Channel {
+	if x != nil {
+		return x.Channel
+	}
+	return nil
+}
+
+func (x *CreateChannelRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+// UpdateChannelRequest 覆盖更新渠道，key 为空时保留原 key
+type UpdateChannelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Channel       *Channel               `protobuf:"bytes,1,opt,name=channel,proto3" json:"channel,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateChannelRequest) Reset() {
+	*x = UpdateChannelRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateChannelRequest) ProtoMessage() {}
+
+func (x *UpdateChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateChannelRequest.ProtoReflect.Descriptor instead.
+func (*UpdateChannelRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateChannelRequest) GetChannel() *Channel {
+	if x != nil {
+		return x.Channel
+	}
+	return nil
+}
+
+func (x *UpdateChannelRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type SetChannelStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        int32                  `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetChannelStatusRequest) Reset() {
+	*x = SetChannelStatusRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetChannelStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetChannelStatusRequest) ProtoMessage() {}
+
+func (x *SetChannelStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetChannelStatusRequest.ProtoReflect.Descriptor instead.
+func (*SetChannelStatusRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SetChannelStatusRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SetChannelStatusRequest) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+type DeleteChannelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteChannelRequest) Reset() {
+	*x = DeleteChannelRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteChannelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteChannelRequest) ProtoMessage() {}
+
+func (x *DeleteChannelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteChannelRequest.ProtoReflect.Descriptor instead.
+func (*DeleteChannelRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteChannelRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteChannelResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteChannelResponse) Reset() {
+	*x = DeleteChannelResponse{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteChannelResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteChannelResponse) ProtoMessage() {}
+
+func (x *DeleteChannelResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteChannelResponse.ProtoReflect.Descriptor instead.
+func (*DeleteChannelResponse) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{10}
+}
+
+type WatchChannelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchChannelsRequest) Reset() {
+	*x = WatchChannelsRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchChannelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchChannelsRequest) ProtoMessage() {}
+
+func (x *WatchChannelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchChannelsRequest.ProtoReflect.Descriptor instead.
+func (*WatchChannelsRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{11}
+}
+
+type ChannelEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          ChannelEventType       `protobuf:"varint,1,opt,name=type,proto3,enum=oneapi.admin.v1.ChannelEventType" json:"type,omitempty"`
+	Channel       *Channel               `protobuf:"bytes,2,opt,name=channel,proto3" json:"channel,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChannelEvent) Reset() {
+	*x = ChannelEvent{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChannelEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChannelEvent) ProtoMessage() {}
+
+func (x *ChannelEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChannelEvent.ProtoReflect.Descriptor instead.
+func (*ChannelEvent) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ChannelEvent) GetType() ChannelEventType {
+	if x != nil {
+		return x.Type
+	}
+	return ChannelEventType_CHANNEL_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *ChannelEvent) GetChannel() *Channel {
+	if x != nil {
+		return x.Channel
+	}
+	return nil
+}
+
+// Token 令牌，不返回 key
+type Token struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId         int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Name           string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Status         int32                  `protobuf:"varint,4,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedTime    int64                  `protobuf:"varint,5,opt,name=created_time,json=createdTime,proto3" json:"created_time,omitempty"`
+	AccessedTime   int64                  `protobuf:"varint,6,opt,name=accessed_time,json=accessedTime,proto3" json:"accessed_time,omitempty"`
+	ExpiredTime    int64                  `protobuf:"varint,7,opt,name=expired_time,json=expiredTime,proto3" json:"expired_time,omitempty"`
+	RemainQuota    int64                  `protobuf:"varint,8,opt,name=remain_quota,json=remainQuota,proto3" json:"remain_quota,omitempty"`
+	UnlimitedQuota bool                   `protobuf:"varint,9,opt,name=unlimited_quota,json=unlimitedQuota,proto3" json:"unlimited_quota,omitempty"`
+	UsedQuota      int64                  `protobuf:"varint,10,opt,name=used_quota,json=usedQuota,proto3" json:"used_quota,omitempty"`
+	Group          string                 `protobuf:"bytes,11,opt,name=group,proto3" json:"group,omitempty"`
+	BackupGroup    string                 `protobuf:"bytes,12,opt,name=backup_group,json=backupGroup,proto3" json:"backup_group,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Token) Reset() {
+	*x = Token{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Token) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Token) ProtoMessage() {}
+
+func (x *Token) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Token.ProtoReflect.Descriptor instead.
+func (*Token) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Token) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Token) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Token) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Token) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *Token) GetCreatedTime() int64 {
+	if x != nil {
+		return x.CreatedTime
+	}
+	return 0
+}
+
+func (x *Token) GetAccessedTime() int64 {
+	if x != nil {
+		return x.AccessedTime
+	}
+	return 0
+}
+
+func (x *Token) GetExpiredTime() int64 {
+	if x != nil {
+		return x.ExpiredTime
+	}
+	return 0
+}
+
+func (x *Token) GetRemainQuota() int64 {
+	if x != nil {
+		return x.RemainQuota
+	}
+	return 0
+}
+
+func (x *Token) GetUnlimitedQuota() bool {
+	if x != nil {
+		return x.UnlimitedQuota
+	}
+	return false
+}
+
+func (x *Token) GetUsedQuota() int64 {
+	if x != nil {
+		return x.UsedQuota
+	}
+	return 0
+}
+
+func (x *Token) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Token) GetBackupGroup() string {
+	if x != nil {
+		return x.BackupGroup
+	}
+	return ""
+}
+
+type ListTokensRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Pagination    *Pagination            `protobuf:"bytes,2,opt,name=pagination,proto3" json:"pagination,omitempty"`
+	Keyword       string                 `protobuf:"bytes,3,opt,name=keyword,proto3" json:"keyword,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTokensRequest) Reset() {
+	*x = ListTokensRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTokensRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTokensRequest) ProtoMessage() {}
+
+func (x *ListTokensRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTokensRequest.ProtoReflect.Descriptor instead.
+func (*ListTokensRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListTokensRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ListTokensRequest) GetPagination() *Pagination {
+	if x != nil {
+		return x.Pagination
+	}
+	return nil
+}
+
+func (x *ListTokensRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+type ListTokensResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tokens        []*Token               `protobuf:"bytes,1,rep,name=tokens,proto3" json:"tokens,omitempty"`
+	Page          *PageInfo              `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTokensResponse) Reset() {
+	*x = ListTokensResponse{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTokensResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTokensResponse) ProtoMessage() {}
+
+func (x *ListTokensResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTokensResponse.ProtoReflect.Descriptor instead.
+func (*ListTokensResponse) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ListTokensResponse) GetTokens() []*Token {
+	if x != nil {
+		return x.Tokens
+	}
+	return nil
+}
+
+func (x *ListTokensResponse) GetPage() *PageInfo {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type GetTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTokenRequest) Reset() {
+	*x = GetTokenRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTokenRequest) ProtoMessage() {}
+
+func (x *GetTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTokenRequest.ProtoReflect.Descriptor instead.
+func (*GetTokenRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *GetTokenRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type SetTokenStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        int32                  `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetTokenStatusRequest) Reset() {
+	*x = SetTokenStatusRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetTokenStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetTokenStatusRequest) ProtoMessage() {}
+
+func (x *SetTokenStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetTokenStatusRequest.ProtoReflect.Descriptor instead.
+func (*SetTokenStatusRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SetTokenStatusRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SetTokenStatusRequest) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+type DeleteTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTokenRequest) Reset() {
+	*x = DeleteTokenRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTokenRequest) ProtoMessage() {}
+
+func (x *DeleteTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTokenRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTokenRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *DeleteTokenRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteTokenResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTokenResponse) Reset() {
+	*x = DeleteTokenResponse{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTokenResponse) ProtoMessage() {}
+
+func (x *DeleteTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTokenResponse.ProtoReflect.Descriptor instead.
+func (*DeleteTokenResponse) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{19}
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	DisplayName   string                 `protobuf:"bytes,3,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	Role          int32                  `protobuf:"varint,4,opt,name=role,proto3" json:"role,omitempty"`
+	Status        int32                  `protobuf:"varint,5,opt,name=status,proto3" json:"status,omitempty"`
+	Email         string                 `protobuf:"bytes,6,opt,name=email,proto3" json:"email,omitempty"`
+	Quota         int64                  `protobuf:"varint,7,opt,name=quota,proto3" json:"quota,omitempty"`
+	UsedQuota     int64                  `protobuf:"varint,8,opt,name=used_quota,json=usedQuota,proto3" json:"used_quota,omitempty"`
+	RequestCount  int64                  `protobuf:"varint,9,opt,name=request_count,json=requestCount,proto3" json:"request_count,omitempty"`
+	Group         string                 `protobuf:"bytes,10,opt,name=group,proto3" json:"group,omitempty"`
+	TenantId      int64                  `protobuf:"varint,11,opt,name=tenant_id,json=tenantId,proto3" json:"tenant_id,omitempty"`
+	CreatedTime   int64                  `protobuf:"varint,12,opt,name=created_time,json=createdTime,proto3" json:"created_time,omitempty"`
+	LastLoginTime int64                  `protobuf:"varint,13,opt,name=last_login_time,json=lastLoginTime,proto3" json:"last_login_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *User) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *User) GetRole() int32 {
+	if x != nil {
+		return x.Role
+	}
+	return 0
+}
+
+func (x *User) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetQuota() int64 {
+	if x != nil {
+		return x.Quota
+	}
+	return 0
+}
+
+func (x *User) GetUsedQuota() int64 {
+	if x != nil {
+		return x.UsedQuota
+	}
+	return 0
+}
+
+func (x *User) GetRequestCount() int64 {
+	if x != nil {
+		return x.RequestCount
+	}
+	return 0
+}
+
+func (x *User) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *User) GetTenantId() int64 {
+	if x != nil {
+		return x.TenantId
+	}
+	return 0
+}
+
+func (x *User) GetCreatedTime() int64 {
+	if x != nil {
+		return x.CreatedTime
+	}
+	return 0
+}
+
+func (x *User) GetLastLoginTime() int64 {
+	if x != nil {
+		return x.LastLoginTime
+	}
+	return 0
+}
+
+type ListUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pagination    *Pagination            `protobuf:"bytes,1,opt,name=pagination,proto3" json:"pagination,omitempty"`
+	Keyword       string                 `protobuf:"bytes,2,opt,name=keyword,proto3" json:"keyword,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersRequest) Reset() {
+	*x = ListUsersRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersRequest) ProtoMessage() {}
+
+func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersRequest.ProtoReflect.Descriptor instead.
+func (*ListUsersRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListUsersRequest) GetPagination() *Pagination {
+	if x != nil {
+		return x.Pagination
+	}
+	return nil
+}
+
+func (x *ListUsersRequest) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+type ListUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	Page          *PageInfo              `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersResponse) Reset() {
+	*x = ListUsersResponse{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersResponse) ProtoMessage() {}
+
+func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersResponse.ProtoReflect.Descriptor instead.
+func (*ListUsersResponse) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *ListUsersResponse) GetPage() *PageInfo {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type GetUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *GetUserRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type SetUserStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Status        int32                  `protobuf:"varint,2,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetUserStatusRequest) Reset() {
+	*x = SetUserStatusRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetUserStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetUserStatusRequest) ProtoMessage() {}
+
+func (x *SetUserStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetUserStatusRequest.ProtoReflect.Descriptor instead.
+func (*SetUserStatusRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SetUserStatusRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *SetUserStatusRequest) GetStatus() int32 {
+	if x != nil {
+		return x.Status
+	}
+	return 0
+}
+
+// AdjustUserQuotaRequest delta 为正数时增加额度，为负数时扣减额度
+type AdjustUserQuotaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Delta         int64                  `protobuf:"varint,2,opt,name=delta,proto3" json:"delta,omitempty"`
+	Remark        string                 `protobuf:"bytes,3,opt,name=remark,proto3" json:"remark,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdjustUserQuotaRequest) Reset() {
+	*x = AdjustUserQuotaRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdjustUserQuotaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdjustUserQuotaRequest) ProtoMessage() {}
+
+func (x *AdjustUserQuotaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdjustUserQuotaRequest.ProtoReflect.Descriptor instead.
+func (*AdjustUserQuotaRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AdjustUserQuotaRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *AdjustUserQuotaRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *AdjustUserQuotaRequest) GetRemark() string {
+	if x != nil {
+		return x.Remark
+	}
+	return ""
+}
+
+type Log struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId           int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	CreatedAt        int64                  `protobuf:"varint,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	Type             int32                  `protobuf:"varint,4,opt,name=type,proto3" json:"type,omitempty"`
+	Content          string                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	Username         string                 `protobuf:"bytes,6,opt,name=username,proto3" json:"username,omitempty"`
+	TokenName        string                 `protobuf:"bytes,7,opt,name=token_name,json=tokenName,proto3" json:"token_name,omitempty"`
+	ModelName        string                 `protobuf:"bytes,8,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	Quota            int64                  `protobuf:"varint,9,opt,name=quota,proto3" json:"quota,omitempty"`
+	PromptTokens     int32                  `protobuf:"varint,10,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int32                  `protobuf:"varint,11,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	ChannelId        int64                  `protobuf:"varint,12,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	RequestTime      int32                  `protobuf:"varint,13,opt,name=request_time,json=requestTime,proto3" json:"request_time,omitempty"`
+	IsStream         bool                   `protobuf:"varint,14,opt,name=is_stream,json=isStream,proto3" json:"is_stream,omitempty"`
+	SourceIp         string                 `protobuf:"bytes,15,opt,name=source_ip,json=sourceIp,proto3" json:"source_ip,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *Log) Reset() {
+	*x = Log{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Log) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Log) ProtoMessage() {}
+
+func (x *Log) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Log.ProtoReflect.Descriptor instead.
+func (*Log) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *Log) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Log) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Log) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *Log) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *Log) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Log) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Log) GetTokenName() string {
+	if x != nil {
+		return x.TokenName
+	}
+	return ""
+}
+
+func (x *Log) GetModelName() string {
+	if x != nil {
+		return x.ModelName
+	}
+	return ""
+}
+
+func (x *Log) GetQuota() int64 {
+	if x != nil {
+		return x.Quota
+	}
+	return 0
+}
+
+func (x *Log) GetPromptTokens() int32 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *Log) GetCompletionTokens() int32 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *Log) GetChannelId() int64 {
+	if x != nil {
+		return x.ChannelId
+	}
+	return 0
+}
+
+func (x *Log) GetRequestTime() int32 {
+	if x != nil {
+		return x.RequestTime
+	}
+	return 0
+}
+
+func (x *Log) GetIsStream() bool {
+	if x != nil {
+		return x.IsStream
+	}
+	return false
+}
+
+func (x *Log) GetSourceIp() string {
+	if x != nil {
+		return x.SourceIp
+	}
+	return ""
+}
+
+type ListLogsRequest struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Pagination     *Pagination            `protobuf:"bytes,1,opt,name=pagination,proto3" json:"pagination,omitempty"`
+	Type           int32                  `protobuf:"varint,2,opt,name=type,proto3" json:"type,omitempty"`
+	StartTimestamp int64                  `protobuf:"varint,3,opt,name=start_timestamp,json=startTimestamp,proto3" json:"start_timestamp,omitempty"`
+	EndTimestamp   int64                  `protobuf:"varint,4,opt,name=end_timestamp,json=endTimestamp,proto3" json:"end_timestamp,omitempty"`
+	ModelName      string                 `protobuf:"bytes,5,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	Username       string                 `protobuf:"bytes,6,opt,name=username,proto3" json:"username,omitempty"`
+	TokenName      string                 `protobuf:"bytes,7,opt,name=token_name,json=tokenName,proto3" json:"token_name,omitempty"`
+	ChannelId      int64                  `protobuf:"varint,8,opt,name=channel_id,json=channelId,proto3" json:"channel_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ListLogsRequest) Reset() {
+	*x = ListLogsRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLogsRequest) ProtoMessage() {}
+
+func (x *ListLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLogsRequest.ProtoReflect.Descriptor instead.
+func (*ListLogsRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListLogsRequest) GetPagination() *Pagination {
+	if x != nil {
+		return x.Pagination
+	}
+	return nil
+}
+
+func (x *ListLogsRequest) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *ListLogsRequest) GetStartTimestamp() int64 {
+	if x != nil {
+		return x.StartTimestamp
+	}
+	return 0
+}
+
+func (x *ListLogsRequest) GetEndTimestamp() int64 {
+	if x != nil {
+		return x.EndTimestamp
+	}
+	return 0
+}
+
+func (x *ListLogsRequest) GetModelName() string {
+	if x != nil {
+		return x.ModelName
+	}
+	return ""
+}
+
+func (x *ListLogsRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *ListLogsRequest) GetTokenName() string {
+	if x != nil {
+		return x.TokenName
+	}
+	return ""
+}
+
+func (x *ListLogsRequest) GetChannelId() int64 {
+	if x != nil {
+		return x.ChannelId
+	}
+	return 0
+}
+
+type ListLogsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Logs          []*Log                 `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	Page          *PageInfo              `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLogsResponse) Reset() {
+	*x = ListLogsResponse{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLogsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLogsResponse) ProtoMessage() {}
+
+func (x *ListLogsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLogsResponse.ProtoReflect.Descriptor instead.
+func (*ListLogsResponse) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ListLogsResponse) GetLogs() []*Log {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+func (x *ListLogsResponse) GetPage() *PageInfo {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+// WatchLogsRequest after_id 为 0 时只发送订阅之后的日志
+type WatchLogsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          int32                  `protobuf:"varint,1,opt,name=type,proto3" json:"type,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	ModelName     string                 `protobuf:"bytes,3,opt,name=model_name,json=modelName,proto3" json:"model_name,omitempty"`
+	AfterId       int64                  `protobuf:"varint,4,opt,name=after_id,json=afterId,proto3" json:"after_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchLogsRequest) Reset() {
+	*x = WatchLogsRequest{}
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLogsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLogsRequest) ProtoMessage() {}
+
+func (x *WatchLogsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_oneapi_admin_v1_admin_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLogsRequest.ProtoReflect.Descriptor instead.
+func (*WatchLogsRequest) Descriptor() ([]byte, []int) {
+	return file_oneapi_admin_v1_admin_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *WatchLogsRequest) GetType() int32 {
+	if x != nil {
+		return x.Type
+	}
+	return 0
+}
+
+func (x *WatchLogsRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *WatchLogsRequest) GetModelName() string {
+	if x != nil {
+		return x.ModelName
+	}
+	return ""
+}
+
+func (x *WatchLogsRequest) GetAfterId() int64 {
+	if x != nil {
+		return x.AfterId
+	}
+	return 0
+}
+
+var File_oneapi_admin_v1_admin_proto protoreflect.FileDescriptor
+
+const file_oneapi_admin_v1_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x1boneapi/admin/v1/admin.proto\x12\x0foneapi.admin.v1\"J\n" +
+	"\n" +
+	"Pagination\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x05R\x04size\x12\x14\n" +
+	"\x05order\x18\x03 \x01(\tR\x05order\"S\n" +
+	"\bPageInfo\x12\x12\n" +
+	"\x04page\x18\x01 \x01(\x05R\x04page\x12\x12\n" +
+	"\x04size\x18\x02 \x01(\x05R\x04size\x12\x1f\n" +
+	"\vtotal_count\x18\x03 \x01(\x03R\n" +
+	"totalCount\"\xe7\x03\n" +
+	"\aChannel\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\x05R\x04type\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\x05R\x06status\x12\x14\n" +
+	"\x05group\x18\x05 \x01(\tR\x05group\x12\x16\n" +
+	"\x06models\x18\x06 \x01(\tR\x06models\x12\x10\n" +
+	"\x03tag\x18\a \x01(\tR\x03tag\x12\x19\n" +
+	"\bbase_url\x18\b \x01(\tR\abaseUrl\x12\x16\n" +
+	"\x06weight\x18\t \x01(\rR\x06weight\x12\x1a\n" +
+	"\bpriority\x18\n" +
+	" \x01(\x03R\bpriority\x12#\n" +
+	"\rmodel_mapping\x18\v \x01(\tR\fmodelMapping\x12\x1d\n" +
+	"\n" +
+	"test_model\x18\f \x01(\tR\ttestModel\x12#\n" +
+	"\rresponse_time\x18\r \x01(\x05R\fresponseTime\x12\x18\n" +
+	"\abalance\x18\x0e \x01(\x01R\abalance\x12\x1d\n" +
+	"\n" +
+	"used_quota\x18\x0f \x01(\x03R\tusedQuota\x12!\n" +
+	"\fcreated_time\x18\x10 \x01(\x03R\vcreatedTime\x12\x1b\n" +
+	"\ttest_time\x18\x11 \x01(\x03R\btestTime\x12\x1b\n" +
+	"\ttenant_id\x18\x12 \x01(\x03R\btenantId\"\xd2\x01\n" +
+	"\x13ListChannelsRequest\x12;\n" +
+	"\n" +
+	"pagination\x18\x01 \x01(\v2\x1b.oneapi.admin.v1.PaginationR\n" +
+	"pagination\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\x05R\x04type\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\x05R\x06status\x12\x12\n" +
+	"\x04name\x18\x04 \x01(\tR\x04name\x12\x14\n" +
+	"\x05group\x18\x05 \x01(\tR\x05group\x12\x16\n" +
+	"\x06models\x18\x06 \x01(\tR\x06models\x12\x10\n" +
+	"\x03tag\x18\a \x01(\tR\x03tag\"{\n" +
+	"\x14ListChannelsResponse\x124\n" +
+	"\bchannels\x18\x01 \x03(\v2\x18.oneapi.admin.v1.ChannelR\bchannels\x12-\n" +
+	"\x04page\x18\x02 \x01(\v2\x19.oneapi.admin.v1.PageInfoR\x04page\"#\n" +
+	"\x11GetChannelRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\\\n" +
+	"\x14CreateChannelRequest\x122\n" +
+	"\achannel\x18\x01 \x01(\v2\x18.oneapi.admin.v1.ChannelR\achannel\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\"\\\n" +
+	"\x14UpdateChannelRequest\x122\n" +
+	"\achannel\x18\x01 \x01(\v2\x18.oneapi.admin.v1.ChannelR\achannel\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\"A\n" +
+	"\x17SetChannelStatusRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\x05R\x06status\"&\n" +
+	"\x14DeleteChannelRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x17\n" +
+	"\x15DeleteChannelResponse\"\x16\n" +
+	"\x14WatchChannelsRequest\"y\n" +
+	"\fChannelEvent\x125\n" +
+	"\x04type\x18\x01 \x01(\x0e2!.oneapi.admin.v1.ChannelEventTypeR\x04type\x122\n" +
+	"\achannel\x18\x02 \x01(\v2\x18.oneapi.admin.v1.ChannelR\achannel\"\xeb\x02\n" +
+	"\x05Token\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\x05R\x06status\x12!\n" +
+	"\fcreated_time\x18\x05 \x01(\x03R\vcreatedTime\x12#\n" +
+	"\raccessed_time\x18\x06 \x01(\x03R\faccessedTime\x12!\n" +
+	"\fexpired_time\x18\a \x01(\x03R\vexpiredTime\x12!\n" +
+	"\fremain_quota\x18\b \x01(\x03R\vremainQuota\x12'\n" +
+	"\x0funlimited_quota\x18\t \x01(\bR\x0eunlimitedQuota\x12\x1d\n" +
+	"\n" +
+	"used_quota\x18\n" +
+	" \x01(\x03R\tusedQuota\x12\x14\n" +
+	"\x05group\x18\v \x01(\tR\x05group\x12!\n" +
+	"\fbackup_group\x18\f \x01(\tR\vbackupGroup\"\x83\x01\n" +
+	"\x11ListTokensRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12;\n" +
+	"\n" +
+	"pagination\x18\x02 \x01(\v2\x1b.oneapi.admin.v1.PaginationR\n" +
+	"pagination\x12\x18\n" +
+	"\akeyword\x18\x03 \x01(\tR\akeyword\"s\n" +
+	"\x12ListTokensResponse\x12.\n" +
+	"\x06tokens\x18\x01 \x03(\v2\x16.oneapi.admin.v1.TokenR\x06tokens\x12-\n" +
+	"\x04page\x18\x02 \x01(\v2\x19.oneapi.admin.v1.PageInfoR\x04page\"!\n" +
+	"\x0fGetTokenRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"?\n" +
+	"\x15SetTokenStatusRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\x05R\x06status\"$\n" +
+	"\x12DeleteTokenRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x15\n" +
+	"\x13DeleteTokenResponse\"\xef\x02\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12!\n" +
+	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12\x12\n" +
+	"\x04role\x18\x04 \x01(\x05R\x04role\x12\x16\n" +
+	"\x06status\x18\x05 \x01(\x05R\x06status\x12\x14\n" +
+	"\x05email\x18\x06 \x01(\tR\x05email\x12\x14\n" +
+	"\x05quota\x18\a \x01(\x03R\x05quota\x12\x1d\n" +
+	"\n" +
+	"used_quota\x18\b \x01(\x03R\tusedQuota\x12#\n" +
+	"\rrequest_count\x18\t \x01(\x03R\frequestCount\x12\x14\n" +
+	"\x05group\x18\n" +
+	" \x01(\tR\x05group\x12\x1b\n" +
+	"\ttenant_id\x18\v \x01(\x03R\btenantId\x12!\n" +
+	"\fcreated_time\x18\f \x01(\x03R\vcreatedTime\x12&\n" +
+	"\x0flast_login_time\x18\r \x01(\x03R\rlastLoginTime\"i\n" +
+	"\x10ListUsersRequest\x12;\n" +
+	"\n" +
+	"pagination\x18\x01 \x01(\v2\x1b.oneapi.admin.v1.PaginationR\n" +
+	"pagination\x12\x18\n" +
+	"\akeyword\x18\x02 \x01(\tR\akeyword\"o\n" +
+	"\x11ListUsersResponse\x12+\n" +
+	"\x05users\x18\x01 \x03(\v2\x15.oneapi.admin.v1.UserR\x05users\x12-\n" +
+	"\x04page\x18\x02 \x01(\v2\x19.oneapi.admin.v1.PageInfoR\x04page\" \n" +
+	"\x0eGetUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\">\n" +
+	"\x14SetUserStatusRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\x05R\x06status\"V\n" +
+	"\x16AdjustUserQuotaRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05delta\x18\x02 \x01(\x03R\x05delta\x12\x16\n" +
+	"\x06remark\x18\x03 \x01(\tR\x06remark\"\xb9\x03\n" +
+	"\x03Log\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\x03R\tcreatedAt\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\x05R\x04type\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\tR\acontent\x12\x1a\n" +
+	"\busername\x18\x06 \x01(\tR\busername\x12\x1d\n" +
+	"\n" +
+	"token_name\x18\a \x01(\tR\ttokenName\x12\x1d\n" +
+	"\n" +
+	"model_name\x18\b \x01(\tR\tmodelName\x12\x14\n" +
+	"\x05quota\x18\t \x01(\x03R\x05quota\x12#\n" +
+	"\rprompt_tokens\x18\n" +
+	" \x01(\x05R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\v \x01(\x05R\x10completionTokens\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\f \x01(\x03R\tchannelId\x12!\n" +
+	"\frequest_time\x18\r \x01(\x05R\vrequestTime\x12\x1b\n" +
+	"\tis_stream\x18\x0e \x01(\bR\bisStream\x12\x1b\n" +
+	"\tsource_ip\x18\x0f \x01(\tR\bsourceIp\"\xa9\x02\n" +
+	"\x0fListLogsRequest\x12;\n" +
+	"\n" +
+	"pagination\x18\x01 \x01(\v2\x1b.oneapi.admin.v1.PaginationR\n" +
+	"pagination\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\x05R\x04type\x12'\n" +
+	"\x0fstart_timestamp\x18\x03 \x01(\x03R\x0estartTimestamp\x12#\n" +
+	"\rend_timestamp\x18\x04 \x01(\x03R\fendTimestamp\x12\x1d\n" +
+	"\n" +
+	"model_name\x18\x05 \x01(\tR\tmodelName\x12\x1a\n" +
+	"\busername\x18\x06 \x01(\tR\busername\x12\x1d\n" +
+	"\n" +
+	"token_name\x18\a \x01(\tR\ttokenName\x12\x1d\n" +
+	"\n" +
+	"channel_id\x18\b \x01(\x03R\tchannelId\"k\n" +
+	"\x10ListLogsResponse\x12(\n" +
+	"\x04logs\x18\x01 \x03(\v2\x14.oneapi.admin.v1.LogR\x04logs\x12-\n" +
+	"\x04page\x18\x02 \x01(\v2\x19.oneapi.admin.v1.PageInfoR\x04page\"|\n" +
+	"\x10WatchLogsRequest\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\x05R\x04type\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x1d\n" +
+	"\n" +
+	"model_name\x18\x03 \x01(\tR\tmodelName\x12\x19\n" +
+	"\bafter_id\x18\x04 \x01(\x03R\aafterId*\x95\x01\n" +
+	"\x10ChannelEventType\x12\"\n" +
+	"\x1eCHANNEL_EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x1c\n" +
+	"\x18CHANNEL_EVENT_TYPE_ADDED\x10\x01\x12\x1f\n" +
+	"\x1bCHANNEL_EVENT_TYPE_MODIFIED\x10\x02\x12\x1e\n" +
+	"\x1aCHANNEL_EVENT_TYPE_DELETED\x10\x032\x87\v\n" +
+	"\fAdminService\x12[\n" +
+	"\fListChannels\x12$.oneapi.admin.v1.ListChannelsRequest\x1a%.oneapi.admin.v1.ListChannelsResponse\x12J\n" +
+	"\n" +
+	"GetChannel\x12\".oneapi.admin.v1.GetChannelRequest\x1a\x18.oneapi.admin.v1.Channel\x12P\n" +
+	"\rCreateChannel\x12%.oneapi.admin.v1.CreateChannelRequest\x1a\x18.oneapi.admin.v1.Channel\x12P\n" +
+	"\rUpdateChannel\x12%.oneapi.admin.v1.UpdateChannelRequest\x1a\x18.oneapi.admin.v1.Channel\x12V\n" +
+	"\x10SetChannelStatus\x12(.oneapi.admin.v1.SetChannelStatusRequest\x1a\x18.oneapi.admin.v1.Channel\x12^\n" +
+	"\rDeleteChannel\x12%.oneapi.admin.v1.DeleteChannelRequest\x1a&.oneapi.admin.v1.DeleteChannelResponse\x12W\n" +
+	"\rWatchChannels\x12%.oneapi.admin.v1.WatchChannelsRequest\x1a\x1d.oneapi.admin.v1.ChannelEvent0\x01\x12U\n" +
+	"\n" +
+	"ListTokens\x12\".oneapi.admin.v1.ListTokensRequest\x1a#.oneapi.admin.v1.ListTokensResponse\x12D\n" +
+	"\bGetToken\x12 .oneapi.admin.v1.GetTokenRequest\x1a\x16.oneapi.admin.v1.Token\x12P\n" +
+	"\x0eSetTokenStatus\x12&.oneapi.admin.v1.SetTokenStatusRequest\x1a\x16.oneapi.admin.v1.Token\x12X\n" +
+	"\vDeleteToken\x12#.oneapi.admin.v1.DeleteTokenRequest\x1a$.oneapi.admin.v1.DeleteTokenResponse\x12R\n" +
+	"\tListUsers\x12!.oneapi.admin.v1.ListUsersRequest\x1a\".oneapi.admin.v1.ListUsersResponse\x12A\n" +
+	"\aGetUser\x12\x1f.oneapi.admin.v1.GetUserRequest\x1a\x15.oneapi.admin.v1.User\x12M\n" +
+	"\rSetUserStatus\x12%.oneapi.admin.v1.SetUserStatusRequest\x1a\x15.oneapi.admin.v1.User\x12Q\n" +
+	"\x0fAdjustUserQuota\x12'.oneapi.admin.v1.AdjustUserQuotaRequest\x1a\x15.oneapi.admin.v1.User\x12O\n" +
+	"\bListLogs\x12 .oneapi.admin.v1.ListLogsRequest\x1a!.oneapi.admin.v1.ListLogsResponse\x12F\n" +
+	"\tWatchLogs\x12!.oneapi.admin.v1.WatchLogsRequest\x1a\x14.oneapi.admin.v1.Log0\x01B\x15Z\x13one-api/rpc/adminpbb\x06proto3"
+
+var (
+	file_oneapi_admin_v1_admin_proto_rawDescOnce sync.Once
+	file_oneapi_admin_v1_admin_proto_rawDescData []byte
+)
+
+func file_oneapi_admin_v1_admin_proto_rawDescGZIP() []byte {
+	file_oneapi_admin_v1_admin_proto_rawDescOnce.Do(func() {
+		file_oneapi_admin_v1_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_oneapi_admin_v1_admin_proto_rawDesc), len(file_oneapi_admin_v1_admin_proto_rawDesc)))
+	})
+	return file_oneapi_admin_v1_admin_proto_rawDescData
+}
+
+var file_oneapi_admin_v1_admin_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_oneapi_admin_v1_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 30)
+var file_oneapi_admin_v1_admin_proto_goTypes = []any{
+	(ChannelEventType)(0),           // 0: oneapi.admin.v1.ChannelEventType
+	(*Pagination)(nil),              // 1: oneapi.admin.v1.Pagination
+	(*PageInfo)(nil),                // 2: oneapi.admin.v1.PageInfo
+	(*Channel)(nil),                 // 3: oneapi.admin.v1.Channel
+	(*ListChannelsRequest)(nil),     // 4: oneapi.admin.v1.ListChannelsRequest
+	(*ListChannelsResponse)(nil),    // 5: oneapi.admin.v1.ListChannelsResponse
+	(*GetChannelRequest)(nil),       // 6: oneapi.admin.v1.GetChannelRequest
+	(*CreateChannelRequest)(nil),    // 7: oneapi.admin.v1.CreateChannelRequest
+	(*UpdateChannelRequest)(nil),    // 8: oneapi.admin.v1.UpdateChannelRequest
+	(*SetChannelStatusRequest)(nil), // 9: oneapi.admin.v1.SetChannelStatusRequest
+	(*DeleteChannelRequest)(nil),    // 10: oneapi.admin.v1.DeleteChannelRequest
+	(*DeleteChannelResponse)(nil),   // 11: oneapi.admin.v1.DeleteChannelResponse
+	(*WatchChannelsRequest)(nil),    // 12: oneapi.admin.v1.WatchChannelsRequest
+	(*ChannelEvent)(nil),            // 13: oneapi.admin.v1.ChannelEvent
+	(*Token)(nil),                   // 14: oneapi.admin.v1.Token
+	(*ListTokensRequest)(nil),       // 15: oneapi.admin.v1.ListTokensRequest
+	(*ListTokensResponse)(nil),      // 16: oneapi.admin.v1.ListTokensResponse
+	(*GetTokenRequest)(nil),         // 17: oneapi.admin.v1.GetTokenRequest
+	(*SetTokenStatusRequest)(nil),   // 18: oneapi.admin.v1.SetTokenStatusRequest
+	(*DeleteTokenRequest)(nil),      // 19: oneapi.admin.v1.DeleteTokenRequest
+	(*DeleteTokenResponse)(nil),     // 20: oneapi.admin.v1.DeleteTokenResponse
+	(*User)(nil),                    // 21: oneapi.admin.v1.User
+	(*ListUsersRequest)(nil),        // 22: oneapi.admin.v1.ListUsersRequest
+	(*ListUsersResponse)(nil),       // 23: oneapi.admin.v1.ListUsersResponse
+	(*GetUserRequest)(nil),          // 24: oneapi.admin.v1.GetUserRequest
+	(*SetUserStatusRequest)(nil),    // 25: oneapi.admin.v1.SetUserStatusRequest
+	(*AdjustUserQuotaRequest)(nil),  // 26: oneapi.admin.v1.AdjustUserQuotaRequest
+	(*Log)(nil),                     // 27: oneapi.admin.v1.Log
+	(*ListLogsRequest)(nil),         // 28: oneapi.admin.v1.ListLogsRequest
+	(*ListLogsResponse)(nil),        // 29: oneapi.admin.v1.ListLogsResponse
+	(*WatchLogsRequest)(nil),        // 30: oneapi.admin.v1.WatchLogsRequest
+}
+var file_oneapi_admin_v1_admin_proto_depIdxs = []int32{
+	1,  // 0: oneapi.admin.v1.ListChannelsRequest.pagination:type_name -> oneapi.admin.v1.Pagination
+	3,  // 1: oneapi.admin.v1.ListChannelsResponse.channels:type_name -> oneapi.admin.v1.Channel
+	2,  // 2: oneapi.admin.v1.ListChannelsResponse.page:type_name -> oneapi.admin.v1.PageInfo
+	3,  // 3: oneapi.admin.v1.CreateChannelRequest.channel:type_name -> oneapi.admin.v1.Channel
+	3,  // 4: oneapi.admin.v1.UpdateChannelRequest.channel:type_name -> oneapi.admin.v1.Channel
+	0,  // 5: oneapi.admin.v1.ChannelEvent.type:type_name -> oneapi.admin.v1.ChannelEventType
+	3,  // 6: oneapi.admin.v1.ChannelEvent.channel:type_name -> oneapi.admin.v1.Channel
+	1,  // 7: oneapi.admin.v1.ListTokensRequest.pagination:type_name -> oneapi.admin.v1.Pagination
+	14, // 8: oneapi.admin.v1.ListTokensResponse.tokens:type_name -> oneapi.admin.v1.Token
+	2,  // 9: oneapi.admin.v1.ListTokensResponse.page:type_name -> oneapi.admin.v1.PageInfo
+	1,  // 10: oneapi.admin.v1.ListUsersRequest.pagination:type_name -> oneapi.admin.v1.Pagination
+	21, // 11: oneapi.admin.v1.ListUsersResponse.users:type_name -> oneapi.admin.v1.User
+	2,  // 12: oneapi.admin.v1.ListUsersResponse.page:type_name -> oneapi.admin.v1.PageInfo
+	1,  // 13: oneapi.admin.v1.ListLogsRequest.pagination:type_name -> oneapi.admin.v1.Pagination
+	27, // 14: oneapi.admin.v1.ListLogsResponse.logs:type_name -> oneapi.admin.v1.Log
+	2,  // 15: oneapi.admin.v1.ListLogsResponse.page:type_name -> oneapi.admin.v1.PageInfo
+	4,  // 16: oneapi.admin.v1.AdminService.ListChannels:input_type -> oneapi.admin.v1.ListChannelsRequest
+	6,  // 17: oneapi.admin.v1.AdminService.GetChannel:input_type -> oneapi.admin.v1.GetChannelRequest
+	7,  // 18: oneapi.admin.v1.AdminService.CreateChannel:input_type -> oneapi.admin.v1.CreateChannelRequest
+	8,  // 19: oneapi.admin.v1.AdminService.UpdateChannel:input_type -> oneapi.admin.v1.UpdateChannelRequest
+	9,  // 20: oneapi.admin.v1.AdminService.SetChannelStatus:input_type -> oneapi.admin.v1.SetChannelStatusRequest
+	10, // 21: oneapi.admin.v1.AdminService.DeleteChannel:input_type -> oneapi.admin.v1.DeleteChannelRequest
+	12, // 22: oneapi.admin.v1.AdminService.WatchChannels:input_type -> oneapi.admin.v1.WatchChannelsRequest
+	15, // 23: oneapi.admin.v1.AdminService.ListTokens:input_type -> oneapi.admin.v1.ListTokensRequest
+	17, // 24: oneapi.admin.v1.AdminService.GetToken:input_type -> oneapi.admin.v1.GetTokenRequest
+	18, // 25: oneapi.admin.v1.AdminService.SetTokenStatus:input_type -> oneapi.admin.v1.SetTokenStatusRequest
+	19, // 26: oneapi.admin.v1.AdminService.DeleteToken:input_type -> oneapi.admin.v1.DeleteTokenRequest
+	22, // 27: oneapi.admin.v1.AdminService.ListUsers:input_type -> oneapi.admin.v1.ListUsersRequest
+	24, // 28: oneapi.admin.v1.AdminService.GetUser:input_type -> oneapi.admin.v1.GetUserRequest
+	25, // 29: oneapi.admin.v1.AdminService.SetUserStatus:input_type -> oneapi.admin.v1.SetUserStatusRequest
+	26, // 30: oneapi.admin.v1.AdminService.AdjustUserQuota:input_type -> oneapi.admin.v1.AdjustUserQuotaRequest
+	28, // 31: oneapi.admin.v1.AdminService.ListLogs:input_type -> oneapi.admin.v1.ListLogsRequest
+	30, // 32: oneapi.admin.v1.AdminService.WatchLogs:input_type -> oneapi.admin.v1.WatchLogsRequest
+	5,  // 33: oneapi.admin.v1.AdminService.ListChannels:output_type -> oneapi.admin.v1.ListChannelsResponse
+	3,  // 34: oneapi.admin.v1.AdminService.GetChannel:output_type -> oneapi.admin.v1.Channel
+	3,  // 35: oneapi.admin.v1.AdminService.CreateChannel:output_type -> oneapi.admin.v1.Channel
+	3,  // 36: oneapi.admin.v1.AdminService.UpdateChannel:output_type -> oneapi.admin.v1.Channel
+	3,  // 37: oneapi.admin.v1.AdminService.SetChannelStatus:output_type -> oneapi.admin.v1.Channel
+	11, // 38: oneapi.admin.v1.AdminService.DeleteChannel:output_type -> oneapi.admin.v1.DeleteChannelResponse
+	13, // 39: oneapi.admin.v1.AdminService.WatchChannels:output_type -> oneapi.admin.v1.ChannelEvent
+	16, // 40: oneapi.admin.v1.AdminService.ListTokens:output_type -> oneapi.admin.v1.ListTokensResponse
+	14, // 41: oneapi.admin.v1.AdminService.GetToken:output_type -> oneapi.admin.v1.Token
+	14, // 42: oneapi.admin.v1.AdminService.SetTokenStatus:output_type -> oneapi.admin.v1.Token
+	20, // 43: oneapi.admin.v1.AdminService.DeleteToken:output_type -> oneapi.admin.v1.DeleteTokenResponse
+	23, // 44: oneapi.admin.v1.AdminService.ListUsers:output_type -> oneapi.admin.v1.ListUsersResponse
+	21, // 45: oneapi.admin.v1.AdminService.GetUser:output_type -> oneapi.admin.v1.User
+	21, // 46: oneapi.admin.v1.AdminService.SetUserStatus:output_type -> oneapi.admin.v1.User
+	21, // 47: oneapi.admin.v1.AdminService.AdjustUserQuota:output_type -> oneapi.admin.v1.User
+	29, // 48: oneapi.admin.v1.AdminService.ListLogs:output_type -> oneapi.admin.v1.ListLogsResponse
+	27, // 49: oneapi.admin.v1.AdminService.WatchLogs:output_type -> oneapi.admin.v1.Log
+	33, // [33:50] is the sub-list for method output_type
+	16, // [16:33] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_oneapi_admin_v1_admin_proto_init() }
+func file_oneapi_admin_v1_admin_proto_init() {
+	if File_oneapi_admin_v1_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_oneapi_admin_v1_admin_proto_rawDesc), len(file_oneapi_admin_v1_admin_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   30,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_oneapi_admin_v1_admin_proto_goTypes,
+		DependencyIndexes: file_oneapi_admin_v1_admin_proto_depIdxs,
+		EnumInfos:         file_oneapi_admin_v1_admin_proto_enumTypes,
+		MessageInfos:      file_oneapi_admin_v1_admin_proto_msgTypes,
+	}.Build()
+	File_oneapi_admin_v1_admin_proto = out.File
+	file_oneapi_admin_v1_admin_proto_goTypes = nil
+	file_oneapi_admin_v1_admin_proto_depIdxs = nil
+}
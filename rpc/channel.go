@@ -0,0 +1,207 @@
+package rpc
+
+import (
+	"context"
+	"one-api/common/config"
+	"one-api/common/utils"
+	"one-api/model"
+	"one-api/rpc/adminpb"
+	"time"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func channelToProto(channel *model.Channel) *adminpb.Channel {
+	pb := &adminpb.Channel{
+		Id:           int64(channel.Id),
+		Type:         int32(channel.Type),
+		Name:         channel.Name,
+		Status:       int32(channel.Status),
+		Group:        channel.Group,
+		Models:       channel.Models,
+		Tag:          channel.Tag,
+		BaseUrl:      channel.GetBaseURL(),
+		Priority:     channel.GetPriority(),
+		ModelMapping: channel.GetModelMapping(),
+		TestModel:    channel.TestModel,
+		ResponseTime: int32(channel.ResponseTime),
+		Balance:      channel.Balance,
+		UsedQuota:    channel.UsedQuota,
+		CreatedTime:  channel.CreatedTime,
+		TestTime:     channel.TestTime,
+		TenantId:     int64(channel.TenantId),
+	}
+	if channel.Weight != nil {
+		pb.Weight = uint32(*channel.Weight)
+	}
+	return pb
+}
+
+// applyChannel 将请求中可写的字段覆盖到渠道上
+func applyChannel(channel *model.Channel, pb *adminpb.Channel) {
+	weight := uint(pb.GetWeight())
+	priority := pb.GetPriority()
+	baseURL := pb.GetBaseUrl()
+	modelMapping := pb.GetModelMapping()
+
+	channel.Type = int(pb.GetType())
+	channel.Name = pb.GetName()
+	channel.Group = pb.GetGroup()
+	channel.Models = pb.GetModels()
+	channel.Tag = pb.GetTag()
+	channel.BaseURL = &baseURL
+	channel.Weight = &weight
+	channel.Priority = &priority
+	channel.ModelMapping = &modelMapping
+	channel.TestModel = pb.GetTestModel()
+	channel.TenantId = int(pb.GetTenantId())
+	if pb.GetStatus() != 0 {
+		channel.Status = int(pb.GetStatus())
+	}
+}
+
+func (s *adminServer) ListChannels(_ context.Context, req *adminpb.ListChannelsRequest) (*adminpb.ListChannelsResponse, error) {
+	params := &model.SearchChannelsParams{PaginationParams: pagination(req.GetPagination())}
+	params.Type = int(req.GetType())
+	params.Status = int(req.GetStatus())
+	params.Name = req.GetName()
+	params.Group = req.GetGroup()
+	params.Models = req.GetModels()
+	params.Tag = req.GetTag()
+
+	result, err := model.GetChannelsList(params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	response := &adminpb.ListChannelsResponse{Page: pageInfo(result)}
+	for _, channel := range *result.Data {
+		response.Channels = append(response.Channels, channelToProto(channel))
+	}
+	return response, nil
+}
+
+func (s *adminServer) GetChannel(_ context.Context, req *adminpb.GetChannelRequest) (*adminpb.Channel, error) {
+	channel, err := model.GetChannelById(int(req.GetId()))
+	if err != nil {
+		return nil, modelError(err)
+	}
+	return channelToProto(channel), nil
+}
+
+func (s *adminServer) CreateChannel(_ context.Context, req *adminpb.CreateChannelRequest) (*adminpb.Channel, error) {
+	if req.GetChannel() == nil || req.GetKey() == "" {
+		return nil, status.Error(codes.InvalidArgument, "channel 和 key 不能为空")
+	}
+
+	channel := &model.Channel{
+		Key:         req.GetKey(),
+		Status:      config.ChannelStatusEnabled,
+		CreatedTime: utils.GetTimestamp(),
+	}
+	applyChannel(channel, req.GetChannel())
+	if err := channel.Insert(); err != nil {
+		return nil, modelError(err)
+	}
+	return channelToProto(channel), nil
+}
+
+func (s *adminServer) UpdateChannel(_ context.Context, req *adminpb.UpdateChannelRequest) (*adminpb.Channel, error) {
+	if req.GetChannel() == nil {
+		return nil, status.Error(codes.InvalidArgument, "channel 不能为空")
+	}
+
+	channel, err := model.GetChannelById(int(req.GetChannel().GetId()))
+	if err != nil {
+		return nil, modelError(err)
+	}
+	applyChannel(channel, req.GetChannel())
+	if req.GetKey() != "" {
+		channel.Key = req.GetKey()
+	}
+	if err := channel.Update(true); err != nil {
+		return nil, modelError(err)
+	}
+	return channelToProto(channel), nil
+}
+
+func (s *adminServer) SetChannelStatus(_ context.Context, req *adminpb.SetChannelStatusRequest) (*adminpb.Channel, error) {
+	switch int(req.GetStatus()) {
+	case config.ChannelStatusEnabled, config.ChannelStatusManuallyDisabled, config.ChannelStatusDraining:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "status 只能为启用、手动禁用或排空")
+	}
+
+	channel, err := model.GetChannelById(int(req.GetId()))
+	if err != nil {
+		return nil, modelError(err)
+	}
+	model.UpdateChannelStatusById(channel.Id, int(req.GetStatus()))
+	channel.Status = int(req.GetStatus())
+	return channelToProto(channel), nil
+}
+
+func (s *adminServer) DeleteChannel(_ context.Context, req *adminpb.DeleteChannelRequest) (*adminpb.DeleteChannelResponse, error) {
+	channel := model.Channel{Id: int(req.GetId())}
+	if err := channel.Delete(); err != nil {
+		return nil, modelError(err)
+	}
+	return &adminpb.DeleteChannelResponse{}, nil
+}
+
+// WatchChannels 定时对比渠道快照，发送变化的渠道，多实例部署时任意实例的修改都能收到
+func (s *adminServer) WatchChannels(_ *adminpb.WatchChannelsRequest, stream grpc.ServerStreamingServer[adminpb.ChannelEvent]) error {
+	ticker := time.NewTicker(watchInterval())
+	defer ticker.Stop()
+
+	snapshot := make(map[int64]*adminpb.Channel)
+	for {
+		channels, err := model.GetAllChannels()
+		if err != nil {
+			return modelError(err)
+		}
+
+		current := make(map[int64]*adminpb.Channel, len(channels))
+		for _, channel := range channels {
+			pb := channelToProto(channel)
+			current[pb.Id] = pb
+
+			eventType := adminpb.ChannelEventType_CHANNEL_EVENT_TYPE_MODIFIED
+			if old, ok := snapshot[pb.Id]; !ok {
+				eventType = adminpb.ChannelEventType_CHANNEL_EVENT_TYPE_ADDED
+			} else if proto.Equal(old, pb) {
+				continue
+			}
+			if err := stream.Send(&adminpb.ChannelEvent{Type: eventType, Channel: pb}); err != nil {
+				return err
+			}
+		}
+		for id, old := range snapshot {
+			if _, ok := current[id]; ok {
+				continue
+			}
+			if err := stream.Send(&adminpb.ChannelEvent{Type: adminpb.ChannelEventType_CHANNEL_EVENT_TYPE_DELETED, Channel: old}); err != nil {
+				return err
+			}
+		}
+		snapshot = current
+
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func watchInterval() time.Duration {
+	interval := viper.GetInt("grpc.watch_interval")
+	if interval <= 0 {
+		interval = 2
+	}
+	return time.Duration(interval) * time.Second
+}
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"one-api/common/config"
+	"one-api/model"
+	"one-api/rpc/adminpb"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func logToProto(log *model.Log) *adminpb.Log {
+	return &adminpb.Log{
+		Id:               int64(log.Id),
+		UserId:           int64(log.UserId),
+		CreatedAt:        log.CreatedAt,
+		Type:             int32(log.Type),
+		Content:          log.Content,
+		Username:         log.Username,
+		TokenName:        log.TokenName,
+		ModelName:        log.ModelName,
+		Quota:            int64(log.Quota),
+		PromptTokens:     int32(log.PromptTokens),
+		CompletionTokens: int32(log.CompletionTokens),
+		ChannelId:        int64(log.ChannelId),
+		RequestTime:      int32(log.RequestTime),
+		IsStream:         log.IsStream,
+		SourceIp:         log.SourceIp,
+	}
+}
+
+func (s *adminServer) ListLogs(_ context.Context, req *adminpb.ListLogsRequest) (*adminpb.ListLogsResponse, error) {
+	params := &model.LogsListParams{
+		PaginationParams: pagination(req.GetPagination()),
+		LogType:          int(req.GetType()),
+		StartTimestamp:   req.GetStartTimestamp(),
+		EndTimestamp:     req.GetEndTimestamp(),
+		ModelName:        req.GetModelName(),
+		Username:         req.GetUsername(),
+		TokenName:        req.GetTokenName(),
+		ChannelId:        int(req.GetChannelId()),
+	}
+	result, err := model.GetLogsList(params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	response := &adminpb.ListLogsResponse{Page: pageInfo(result)}
+	for _, log := range *result.Data {
+		response.Logs = append(response.Logs, logToProto(log))
+	}
+	return response, nil
+}
+
+// WatchLogs 定时查询 id 大于上次发送的日志
+func (s *adminServer) WatchLogs(req *adminpb.WatchLogsRequest, stream grpc.ServerStreamingServer[adminpb.Log]) error {
+	lastId := int(req.GetAfterId())
+	if lastId == 0 {
+		var err error
+		if lastId, err = model.GetMaxLogId(); err != nil {
+			return modelError(err)
+		}
+	}
+
+	params := &model.LogsListParams{
+		LogType:   int(req.GetType()),
+		Username:  req.GetUsername(),
+		ModelName: req.GetModelName(),
+	}
+
+	ticker := time.NewTicker(watchInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		for {
+			logs, err := model.GetLogsAfterId(lastId, params, config.MaxRecentItems)
+			if err != nil {
+				return modelError(err)
+			}
+			for _, log := range logs {
+				if err := stream.Send(logToProto(log)); err != nil {
+					return err
+				}
+				lastId = log.Id
+			}
+			if len(logs) < config.MaxRecentItems {
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"one-api/common/config"
+	"one-api/common/logger"
+	"one-api/model"
+	"one-api/rpc/adminpb"
+
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+type adminServer struct {
+	adminpb.UnimplementedAdminServiceServer
+}
+
+type callerKey struct{}
+
+// Enabled 是否启用 gRPC 管理接口
+func Enabled() bool {
+	return viper.GetBool("grpc.enable")
+}
+
+// Start 启动 gRPC 管理接口，返回的服务在退出时调用 GracefulStop
+// 默认只监听本机，配置证书后使用 TLS，避免 access token 明文传输
+func Start() (*grpc.Server, error) {
+	options := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryAuth),
+		grpc.ChainStreamInterceptor(streamAuth),
+	}
+	certFile, keyFile := viper.GetString("grpc.tls_cert"), viper.GetString("grpc.tls_key")
+	if certFile != "" || keyFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, grpc.Creds(creds))
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(viper.GetString("grpc.listen"), viper.GetString("grpc.port")))
+	if err != nil {
+		return nil, err
+	}
+
+	server := grpc.NewServer(options...)
+	adminpb.RegisterAdminServiceServer(server, &adminServer{})
+
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			logger.SysError("gRPC server stopped: " + err.Error())
+		}
+	}()
+	if certFile == "" && keyFile == "" {
+		logger.SysLog("gRPC admin server listening on " + listener.Addr().String() + " without TLS")
+	} else {
+		logger.SysLog("gRPC admin server listening on " + listener.Addr().String() + " with TLS")
+	}
+
+	return server, nil
+}
+
+// authenticate 与 AdminAuth 一致，只允许未禁用的管理员使用系统访问令牌调用，租户管理员无权访问
+func authenticate(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 || values[0] == "" {
+		return nil, status.Error(codes.Unauthenticated, "未提供 access token")
+	}
+
+	user := model.ValidateAccessToken(values[0])
+	if user == nil || user.Username == "" {
+		return nil, status.Error(codes.Unauthenticated, "access token 无效")
+	}
+	if user.Status == config.UserStatusDisabled {
+		return nil, status.Error(codes.PermissionDenied, "用户已被封禁")
+	}
+	if user.Role < config.RoleAdminUser {
+		return nil, status.Error(codes.PermissionDenied, "权限不足")
+	}
+	if user.Role < config.RoleRootUser && user.TenantId != 0 {
+		return nil, status.Error(codes.PermissionDenied, "租户管理员无法访问")
+	}
+
+	return context.WithValue(ctx, callerKey{}, user), nil
+}
+
+func unaryAuth(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	ctx, err := authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func streamAuth(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := authenticate(stream.Context())
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+}
+
+func pagination(page *adminpb.Pagination) model.PaginationParams {
+	return model.PaginationParams{
+		Page:  int(page.GetPage()),
+		Size:  int(page.GetSize()),
+		Order: page.GetOrder(),
+	}
+}
+
+func pageInfo[T any](result *model.DataResult[T]) *adminpb.PageInfo {
+	return &adminpb.PageInfo{
+		Page:       int32(result.Page),
+		Size:       int32(result.Size),
+		TotalCount: result.TotalCount,
+	}
+}
+
+// modelError 将模型层错误转换为 gRPC 状态
+func modelError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	if errors.Is(err, model.ErrVersionConflict) {
+		return status.Error(codes.Aborted, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// caller 获取通过认证的管理员
+func caller(ctx context.Context) *model.User {
+	user, _ := ctx.Value(callerKey{}).(*model.User)
+	return user
+}
+
+func clientIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"one-api/common/config"
+	"one-api/common/utils"
+	"one-api/model"
+	"one-api/rpc/adminpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func tokenToProto(token *model.Token) *adminpb.Token {
+	return &adminpb.Token{
+		Id:             int64(token.Id),
+		UserId:         int64(token.UserId),
+		Name:           token.Name,
+		Status:         int32(token.Status),
+		CreatedTime:    token.CreatedTime,
+		AccessedTime:   token.AccessedTime,
+		ExpiredTime:    token.ExpiredTime,
+		RemainQuota:    int64(token.RemainQuota),
+		UnlimitedQuota: token.UnlimitedQuota,
+		UsedQuota:      int64(token.UsedQuota),
+		Group:          token.Group,
+		BackupGroup:    token.BackupGroup,
+	}
+}
+
+func (s *adminServer) ListTokens(_ context.Context, req *adminpb.ListTokensRequest) (*adminpb.ListTokensResponse, error) {
+	if req.GetUserId() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "user_id 不能为空")
+	}
+
+	params := &model.GenericParams{
+		PaginationParams: pagination(req.GetPagination()),
+		Keyword:          req.GetKeyword(),
+	}
+	result, err := model.GetUserTokensList(int(req.GetUserId()), params)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	response := &adminpb.ListTokensResponse{Page: pageInfo(result)}
+	for _, token := range *result.Data {
+		response.Tokens = append(response.Tokens, tokenToProto(token))
+	}
+	return response, nil
+}
+
+func (s *adminServer) GetToken(_ context.Context, req *adminpb.GetTokenRequest) (*adminpb.Token, error) {
+	token, err := model.GetTokenById(int(req.GetId()))
+	if err != nil {
+		return nil, modelError(err)
+	}
+	return tokenToProto(token), nil
+}
+
+func (s *adminServer) SetTokenStatus(_ context.Context, req *adminpb.SetTokenStatusRequest) (*adminpb.Token, error) {
+	tokenStatus := int(req.GetStatus())
+	if tokenStatus != config.TokenStatusEnabled && tokenStatus != config.TokenStatusDisabled {
+		return nil, status.Error(codes.InvalidArgument, "status 只能为启用或禁用")
+	}
+
+	token, err := model.GetTokenById(int(req.GetId()))
+	if err != nil {
+		return nil, modelError(err)
+	}
+	if tokenStatus == config.TokenStatusEnabled {
+		if token.Status == config.TokenStatusExpired && token.ExpiredTime <= utils.GetTimestamp() && token.ExpiredTime != -1 {
+			return nil, status.Error(codes.FailedPrecondition, "令牌已过期，无法启用")
+		}
+		if token.Status == config.TokenStatusExhausted && token.RemainQuota <= 0 && !token.UnlimitedQuota {
+			return nil, status.Error(codes.FailedPrecondition, "令牌可用额度已用尽，无法启用")
+		}
+	}
+
+	token.Status = tokenStatus
+	if err := token.Update(); err != nil {
+		return nil, modelError(err)
+	}
+	return tokenToProto(token), nil
+}
+
+func (s *adminServer) DeleteToken(_ context.Context, req *adminpb.DeleteTokenRequest) (*adminpb.DeleteTokenResponse, error) {
+	token, err := model.GetTokenById(int(req.GetId()))
+	if err != nil {
+		return nil, modelError(err)
+	}
+	if err := token.Delete(); err != nil {
+		return nil, modelError(err)
+	}
+	return &adminpb.DeleteTokenResponse{}, nil
+}
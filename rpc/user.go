@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"one-api/common"
+	"one-api/common/config"
+	"one-api/model"
+	"one-api/rpc/adminpb"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func userToProto(user *model.User) *adminpb.User {
+	return &adminpb.User{
+		Id:            int64(user.Id),
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		Role:          int32(user.Role),
+		Status:        int32(user.Status),
+		Email:         user.Email,
+		Quota:         int64(user.Quota),
+		UsedQuota:     int64(user.UsedQuota),
+		RequestCount:  int64(user.RequestCount),
+		Group:         user.Group,
+		TenantId:      int64(user.TenantId),
+		CreatedTime:   user.CreatedTime,
+		LastLoginTime: user.LastLoginTime,
+	}
+}
+
+func (s *adminServer) ListUsers(_ context.Context, req *adminpb.ListUsersRequest) (*adminpb.ListUsersResponse, error) {
+	params := &model.GenericParams{
+		PaginationParams: pagination(req.GetPagination()),
+		Keyword:          req.GetKeyword(),
+	}
+	result, err := model.GetUsersList(params, 0)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	response := &adminpb.ListUsersResponse{Page: pageInfo(result)}
+	for _, user := range *result.Data {
+		response.Users = append(response.Users, userToProto(user))
+	}
+	return response, nil
+}
+
+func (s *adminServer) GetUser(_ context.Context, req *adminpb.GetUserRequest) (*adminpb.User, error) {
+	user, err := model.GetUserById(int(req.GetId()), false)
+	if err != nil {
+		return nil, modelError(err)
+	}
+	return userToProto(user), nil
+}
+
+// SetUserStatus 与 ManageUser 一致，不能修改同级或更高权限的用户，超级管理员不能被禁用
+func (s *adminServer) SetUserStatus(ctx context.Context, req *adminpb.SetUserStatusRequest) (*adminpb.User, error) {
+	userStatus := int(req.GetStatus())
+	if userStatus != config.UserStatusEnabled && userStatus != config.UserStatusDisabled {
+		return nil, status.Error(codes.InvalidArgument, "status 只能为启用或禁用")
+	}
+
+	user, err := model.GetUserById(int(req.GetId()), false)
+	if err != nil {
+		return nil, modelError(err)
+	}
+	myRole := caller(ctx).Role
+	if myRole <= user.Role && myRole != config.RoleRootUser {
+		return nil, status.Error(codes.PermissionDenied, "无权更新同权限等级或更高权限等级的用户信息")
+	}
+	if user.Role == config.RoleRootUser && userStatus == config.UserStatusDisabled {
+		return nil, status.Error(codes.PermissionDenied, "无法禁用超级管理员用户")
+	}
+
+	if err := model.UpdateUser(user.Id, map[string]interface{}{"status": userStatus}); err != nil {
+		return nil, modelError(err)
+	}
+	user.Status = userStatus
+	return userToProto(user), nil
+}
+
+func (s *adminServer) AdjustUserQuota(ctx context.Context, req *adminpb.AdjustUserQuotaRequest) (*adminpb.User, error) {
+	if req.GetDelta() == 0 {
+		return nil, status.Error(codes.InvalidArgument, "delta 不能为 0")
+	}
+
+	user, err := model.GetUserById(int(req.GetId()), false)
+	if err != nil {
+		return nil, modelError(err)
+	}
+
+	delta := int(req.GetDelta())
+	if err := model.ChangeUserQuota(user.Id, delta, false); err != nil {
+		return nil, modelError(err)
+	}
+
+	remark := fmt.Sprintf("管理员增减用户额度 %s", common.LogQuota(delta))
+	if req.GetRemark() != "" {
+		remark = fmt.Sprintf("%s, 备注: %s", remark, req.GetRemark())
+	}
+	model.RecordQuotaLog(user.Id, model.LogTypeManage, delta, clientIP(ctx), remark)
+
+	user.Quota += delta
+	return userToProto(user), nil
+}
@@ -0,0 +1,126 @@
+package keyword
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Match 关键词在文本中的位置，Start 和 End 为 rune 下标，End 不包含
+type Match struct {
+	Start int
+	End   int
+	Word  string
+}
+
+type acNode struct {
+	next   map[rune]int
+	fail   int
+	output []int
+}
+
+// automaton Aho-Corasick 多模式匹配，忽略大小写
+type automaton struct {
+	nodes []acNode
+	words []string
+	runes []int
+	// maxRunes 最长关键词的字符数
+	maxRunes int
+}
+
+func newAutomaton(words []string) *automaton {
+	a := &automaton{nodes: []acNode{{next: map[rune]int{}}}}
+
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word == "" {
+			continue
+		}
+
+		state := 0
+		length := 0
+		for _, r := range word {
+			r = unicode.ToLower(r)
+			nextState, ok := a.nodes[state].next[r]
+			if !ok {
+				a.nodes = append(a.nodes, acNode{next: map[rune]int{}})
+				nextState = len(a.nodes) - 1
+				a.nodes[state].next[r] = nextState
+			}
+			state = nextState
+			length++
+		}
+		a.nodes[state].output = append(a.nodes[state].output, len(a.words))
+		a.words = append(a.words, word)
+		a.runes = append(a.runes, length)
+		if length > a.maxRunes {
+			a.maxRunes = length
+		}
+	}
+
+	// 按层构建失败指针
+	queue := make([]int, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for r, child := range a.nodes[state].next {
+			fail := a.nodes[state].fail
+			for fail > 0 {
+				if _, ok := a.nodes[fail].next[r]; ok {
+					break
+				}
+				fail = a.nodes[fail].fail
+			}
+			if target, ok := a.nodes[fail].next[r]; ok && target != child {
+				a.nodes[child].fail = target
+			}
+			a.nodes[child].output = append(a.nodes[child].output, a.nodes[a.nodes[child].fail].output...)
+			queue = append(queue, child)
+		}
+	}
+
+	return a
+}
+
+func (a *automaton) empty() bool {
+	return len(a.words) == 0
+}
+
+// find 返回文本中所有关键词的位置，limit 大于 0 时最多返回 limit 个
+func (a *automaton) find(text string, limit int) []Match {
+	if a.empty() {
+		return nil
+	}
+
+	var matches []Match
+	state := 0
+	index := 0
+	for _, r := range text {
+		r = unicode.ToLower(r)
+		for state > 0 {
+			if _, ok := a.nodes[state].next[r]; ok {
+				break
+			}
+			state = a.nodes[state].fail
+		}
+		if next, ok := a.nodes[state].next[r]; ok {
+			state = next
+		}
+		index++
+
+		for _, word := range a.nodes[state].output {
+			matches = append(matches, Match{
+				Start: index - a.runes[word],
+				End:   index,
+				Word:  a.words[word],
+			})
+			if limit > 0 && len(matches) >= limit {
+				return matches
+			}
+		}
+	}
+
+	return matches
+}
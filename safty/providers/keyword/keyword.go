@@ -5,7 +5,7 @@ import (
 	"one-api/common/config"
 	"one-api/common/logger"
 	"one-api/safty/types"
-	"strings"
+	"sync"
 )
 
 // KeywordChecker 基于关键词的内容安全检查器
@@ -13,6 +13,9 @@ import (
 type KeywordChecker struct {
 	// keywords 预定义的关键词列表
 	keywords []string
+	// matcher 由关键词构建的 Aho-Corasick 自动机
+	matcher *automaton
+	mu      sync.RWMutex
 	// config 检查器配置
 	config *types.CheckConfig
 }
@@ -27,6 +30,7 @@ type KeywordChecker struct {
 func NewKeywordChecker() *KeywordChecker {
 	return &KeywordChecker{
 		keywords: make([]string, 0),
+		matcher:  newAutomaton(nil),
 		config: &types.CheckConfig{
 			Threshold: 0.8,
 			Options:   make(map[string]interface{}),
@@ -45,11 +49,67 @@ func (k *KeywordChecker) Name() string {
 //   - error: 初始化过程中发生的错误
 func (k *KeywordChecker) Init() error {
 	// 特殊供应商可以在这里进行相关配置初始化
+	matcher := newAutomaton(config.SafeKeyWords)
+
+	k.mu.Lock()
 	k.keywords = config.SafeKeyWords
-	logger.SysLog(fmt.Sprintf("SafeTools %s loda keyword：%d pcs", k.Name(), len(k.keywords)))
+	k.matcher = matcher
+	k.mu.Unlock()
+
+	logger.SysLog(fmt.Sprintf("SafeTools %s loda keyword：%d pcs", k.Name(), len(matcher.words)))
 	return nil
 }
 
+func (k *KeywordChecker) getMatcher() *automaton {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.matcher
+}
+
+// Match 返回内容中命中的关键词，已去重
+func (k *KeywordChecker) Match(data string) []string {
+	return uniqueWords(k.getMatcher().find(data, 0))
+}
+
+// Mask 将内容中命中的关键词替换为等长的 *，返回替换后的内容和命中的关键词
+func (k *KeywordChecker) Mask(data string) (string, []string) {
+	matches := k.getMatcher().find(data, 0)
+	if len(matches) == 0 {
+		return data, nil
+	}
+
+	runes := []rune(data)
+	for _, match := range matches {
+		for i := match.Start; i < match.End; i++ {
+			runes[i] = '*'
+		}
+	}
+
+	return string(runes), uniqueWords(matches)
+}
+
+// MaxWordLength 返回最长关键词的字符数，用于流式响应跨分片匹配
+func (k *KeywordChecker) MaxWordLength() int {
+	return k.getMatcher().maxRunes
+}
+
+func uniqueWords(matches []Match) []string {
+	if len(matches) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(matches))
+	words := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if seen[match.Word] {
+			continue
+		}
+		seen[match.Word] = true
+		words = append(words, match.Word)
+	}
+	return words
+}
+
 // Check 执行关键词检查
 // 检查内容中是否包含预定义的关键词
 // 参数:
@@ -67,15 +127,13 @@ func (k *KeywordChecker) Check(data string) (types.CheckResult, error) {
 		Details:   make([]string, 0),
 	}
 
-	for _, keyword := range config.SafeKeyWords {
-		if strings.Contains(strings.ToLower(data), strings.ToLower(keyword)) {
-			result.IsSafe = false
-			result.Details = append(result.Details, types.SafeDefaultErrorMessage)
-			result.Code = types.SafeDefaultErrorCode
-			result.Reason = types.SafeDefaultErrorMessage
-			result.RiskLevel = 10
-			return result, nil
-		}
+	if words := k.Match(data); len(words) > 0 {
+		result.IsSafe = false
+		result.Details = words
+		result.Code = types.SafeDefaultErrorCode
+		result.Reason = types.SafeDefaultErrorMessage
+		result.RiskLevel = 10
+		return result, nil
 	}
 	result.Code = types.SafeDefaultSuccessCode
 	result.Reason = types.SafeDefaultSuccessMessage
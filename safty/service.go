@@ -173,3 +173,57 @@ func CheckContent(content interface{}) (types.CheckResult, error) {
 
 	return tool.Check(contentStr)
 }
+
+// wordMatcher 可以定位敏感词的检查器，用于脱敏和响应审查
+type wordMatcher interface {
+	Match(data string) []string
+	Mask(data string) (string, []string)
+	MaxWordLength() int
+}
+
+func getWordMatcher() wordMatcher {
+	tool, ok := Tools["Keyword"]
+	if !ok {
+		return nil
+	}
+	matcher, _ := tool.(wordMatcher)
+	return matcher
+}
+
+// ReloadKeywords 敏感词列表变更后重新构建匹配器
+func ReloadKeywords() {
+	tool, ok := Tools["Keyword"]
+	if !ok {
+		return
+	}
+	if err := tool.Init(); err != nil {
+		logger.SysError(fmt.Sprintf("Failed to reload safety tool %s: %v", tool.Name(), err))
+	}
+}
+
+// MatchWords 返回内容中命中的敏感词
+func MatchWords(content string) []string {
+	matcher := getWordMatcher()
+	if matcher == nil || content == "" {
+		return nil
+	}
+	return matcher.Match(content)
+}
+
+// MaskWords 将内容中的敏感词替换为 *，返回替换后的内容和命中的敏感词
+func MaskWords(content string) (string, []string) {
+	matcher := getWordMatcher()
+	if matcher == nil || content == "" {
+		return content, nil
+	}
+	return matcher.Mask(content)
+}
+
+// MaxWordLength 返回最长敏感词的字符数
+func MaxWordLength() int {
+	matcher := getWordMatcher()
+	if matcher == nil {
+		return 0
+	}
+	return matcher.MaxWordLength()
+}
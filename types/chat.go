@@ -217,6 +217,8 @@ type ChatCompletionRequest struct {
 	ThinkingBudget *int  `json:"thinking_budget,omitempty"` // qwen3 思考长度，只有enable_thinking开启才生效
 	EnableSearch   *bool `json:"enable_search,omitempty"`   // qwen 搜索开关
 
+	PromptVariables map[string]string `json:"prompt_variables,omitempty"` // 提示词模板变量，渲染后清空，不会发送到上游
+
 	OneOtherArg string `json:"-"`
 }
 
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"one-api/common/config"
 	"strings"
+	"time"
 )
 
 type Usage struct {
@@ -17,6 +18,24 @@ type Usage struct {
 	ExtraTokens  map[string]int          `json:"-"`
 	ExtraBilling map[string]ExtraBilling `json:"-"`
 	TextBuilder  strings.Builder         `json:"-"`
+	TextCounter  TextCounter             `json:"-"`
+}
+
+// TextCounter 流式输出时增量统计补全 tokens
+type TextCounter interface {
+	Write(text string)
+	Tokens() int
+	HasText() bool
+}
+
+// AppendText 记录流式输出的文本，设置了 TextCounter 时增量计数，不再缓存全文
+func (u *Usage) AppendText(text string) {
+	if u.TextCounter != nil {
+		u.TextCounter.Write(text)
+		return
+	}
+
+	u.TextBuilder.WriteString(text)
 }
 
 type ExtraBilling struct {
@@ -137,6 +156,17 @@ type OpenAIError struct {
 	Param      string `json:"param,omitempty"`
 	Type       string `json:"type,omitempty"`
 	InnerError any    `json:"innererror,omitempty"`
+
+	Upstream *UpstreamError `json:"upstream,omitempty"`
+}
+
+// UpstreamError 映射前的上游错误，消息已脱敏
+type UpstreamError struct {
+	Provider   string `json:"provider"`
+	Type       string `json:"type,omitempty"`
+	Code       any    `json:"code,omitempty"`
+	Message    string `json:"message,omitempty"`
+	StatusCode int    `json:"status_code,omitempty"`
 }
 
 func (e *OpenAIError) Error() string {
@@ -155,6 +185,8 @@ type OpenAIErrorWithStatusCode struct {
 	OpenAIError
 	StatusCode int  `json:"status_code"`
 	LocalError bool `json:"-"`
+	// 上游通过 Retry-After 等响应头给出的等待时间
+	RetryAfter time.Duration `json:"-"`
 }
 
 type OpenAIErrorResponse struct {
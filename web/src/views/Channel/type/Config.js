@@ -170,10 +170,16 @@ const typeConfig = {
     modelGroup: 'Baidu'
   },
   16: {
+    inputLabel: {
+      other: '版本号'
+    },
     input: {
       models: ['glm-3-turbo', 'glm-4', 'glm-4v', 'embedding-2', 'cogview-3'],
       test_model: 'glm-3-turbo'
     },
+    prompt: {
+      other: '默认使用 v4 接口，旧渠道需要使用 v3 接口时填写 v3，v3 只支持聊天且不支持工具调用'
+    },
     modelGroup: 'Zhipu'
   },
   17: {
@@ -216,12 +222,16 @@ const typeConfig = {
     }
   },
   23: {
+    inputLabel: {
+      other: '接口类型'
+    },
     input: {
       models: ['ChatStd', 'ChatPro'],
       test_model: 'ChatStd'
     },
     prompt: {
-      key: '按照如下格式输入：AppId|SecretId|SecretKey'
+      key: '旧版接口按照如下格式输入：AppId|SecretId|SecretKey，v3 接口输入 SecretId|SecretKey，openai 接口输入 API Key',
+      other: '为空时使用旧版 hyllm 接口，填写 openai 使用 OpenAI 兼容接口，填写 v3 使用腾讯云 API 3.0 签名接口'
     },
     modelGroup: 'Tencent'
   },
@@ -326,7 +336,7 @@ const typeConfig = {
       test_model: 'claude-3-haiku-20240307'
     },
     prompt: {
-      key: '老版本Bedrock按照如下格式输入：Region|AccessKeyID|SecretAccessKey|SessionToken 其中SessionToken可不填空,新版本Bedrock按照如下格式输入：Region|Token(其中Token不能为空，Token前往新版本Bedrock控制台创建API密钥)'
+      key: '老版本Bedrock按照如下格式输入：Region|AccessKeyID|SecretAccessKey|SessionToken 其中SessionToken可不填空,新版本Bedrock按照如下格式输入：Region|Token(其中Token不能为空，Token前往新版本Bedrock控制台创建API密钥)，使用运行环境的凭据（IRSA、ECS/EC2 实例角色）时输入：Region|ambient'
     },
     modelGroup: 'Anthropic'
   },
@@ -467,7 +477,7 @@ const typeConfig = {
       models: ['claude-3-opus-20240229', 'claude-3-sonnet-20240229', 'claude-3-haiku-20240307']
     },
     prompt: {
-      key: '请参考wiki中的文档获取key. https://github.com/MartialBE/one-hub/wiki/VertexAI',
+      key: '请参考wiki中的文档获取key. https://github.com/MartialBE/one-hub/wiki/VertexAI ，使用运行环境的凭据（Workload Identity、GCE 元数据服务）时输入：ambient',
       other: 'Region|ProjectID',
       base_url: ''
     },
@@ -30,7 +30,7 @@ import {
 import { AdapterDayjs } from '@mui/x-date-pickers/AdapterDayjs';
 import { LocalizationProvider } from '@mui/x-date-pickers/LocalizationProvider';
 import { DateTimePicker } from '@mui/x-date-pickers/DateTimePicker';
-import { renderQuotaWithPrompt, showSuccess, showError } from 'utils/common';
+import { renderQuotaWithPrompt, showSuccess, showError, copy } from 'utils/common';
 import { API } from 'utils/api';
 import { useTranslation } from 'react-i18next';
 import 'dayjs/locale/zh-cn';
@@ -146,12 +146,14 @@ const EditModal = ({ open, tokenId, onCancel, onOk, userGroupOptions }) => {
       } else {
         res = await API.post(`/api/token/`, values);
       }
-      const { success, message } = res.data;
+      const { success, message, data } = res.data;
       if (success) {
         if (values.is_edit) {
           showSuccess('令牌更新成功！');
         } else {
-          showSuccess('令牌创建成功，请在列表页面点击复制获取令牌！');
+          // 完整的 key 只在创建时返回一次
+          copy(`sk-${data.key}`, t('token_index.token'));
+          showSuccess('令牌创建成功，已复制到剪贴板，完整的 key 只显示这一次，请妥善保存！');
         }
         setSubmitting(false);
         setStatus({ success: true });
@@ -20,7 +20,7 @@ import {
 } from '@mui/material';
 
 import TableSwitch from 'ui-component/Switch';
-import { renderQuota, timestamp2string, copy, getChatLinks, replaceChatPlaceholders } from 'utils/common';
+import { renderQuota, timestamp2string, copy, getChatLinks, replaceChatPlaceholders, showError } from 'utils/common';
 import Label from 'ui-component/Label';
 
 import { Icon } from '@iconify/react';
@@ -139,6 +139,12 @@ export default function TokensTableRow({ item, manageToken, handleOpenModal, set
 
     let url = option.url;
 
+    // 令牌只保存哈希，列表中没有完整的 key
+    if (!item.key) {
+      showError('令牌只在创建时显示完整的 key，请重新创建令牌');
+      handleCloseMenu();
+      return;
+    }
     const key = 'sk-' + item.key;
     const text = replaceChatPlaceholders(url, key, server);
     if (type === 'link') {
@@ -209,6 +215,10 @@ export default function TokensTableRow({ item, manageToken, handleOpenModal, set
               <Button
                 color="primary"
                 onClick={() => {
+                  if (!item.key) {
+                    showError('令牌只在创建时显示完整的 key，请重新创建令牌');
+                    return;
+                  }
                   copy(`sk-${item.key}`, t('token_index.token'));
                 }}
               >